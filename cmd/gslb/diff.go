@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+)
+
+// runDiff implements "gslb diff", which compares the live records of every
+// origin of the configuration at configPath with the IPs of its highest
+// priority level and prints a plan of the differences, without changing
+// anything.
+func runDiff(configPath string, args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Print the differences as JSON lines")
+	exitCode := flags.Bool("exit-code", false, "Exit with status 2 if any record differs")
+	timeout := flags.Duration("timeout", time.Minute, "Timeout of reading all records")
+	_ = flags.Parse(args)
+
+	overrides, err := config.OverridesFromEnv(os.LookupEnv)
+	if err != nil {
+		log.Fatalf("Invalid environment variable: %v", err)
+	}
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	logWarnings(cfg)
+	overrides.Apply(cfg)
+
+	service, err := gslb.NewService(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create GSLB service: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	diffs, err := service.Diff(ctx)
+	if err != nil {
+		log.Fatalf("Failed to compare records: %v", err)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, diff := range diffs {
+			if err := encoder.Encode(diff); err != nil {
+				log.Fatalf("Failed to write difference: %v", err)
+			}
+		}
+	} else if err := printDiff(os.Stdout, diffs); err != nil {
+		log.Fatalf("Failed to write differences: %v", err)
+	}
+	if *exitCode {
+		for _, diff := range diffs {
+			if diff.Changed() {
+				os.Exit(2)
+			}
+		}
+	}
+}
+
+// printDiff prints diffs as a plan: "+" for records to create, "~" for
+// records to update and " " for the rest, followed by a count of each.
+func printDiff(w io.Writer, diffs []gslb.RecordDiff) error {
+	counts := make(map[string]int)
+	for _, diff := range diffs {
+		counts[diff.Action]++
+		label := fmt.Sprintf("%s %s (%s)", diff.Name, diff.RecordType, diff.Zone)
+		switch diff.Action {
+		case gslb.DiffCreate:
+			fmt.Fprintf(w, "+ %s: %s\n", label, ipList(diff.Desired))
+		case gslb.DiffUpdate:
+			fmt.Fprintf(w, "~ %s: %s -> %s\n", label, ipList(diff.Live), ipList(diff.Desired))
+		default:
+			fmt.Fprintf(w, "  %s: %s\n", label, ipList(diff.Live))
+		}
+		if diff.Action == gslb.DiffUpdate && diff.LivePriority != 0 {
+			fmt.Fprintf(w, "    live records are priority level %d\n", diff.LivePriority)
+		}
+		if len(diff.Unmanaged) > 0 {
+			fmt.Fprintf(w, "    not in any priority level: %s\n", strings.Join(diff.Unmanaged, ", "))
+		}
+		if diff.ProxiedMismatch {
+			fmt.Fprintln(w, "    proxied differs from the configuration, which the service does not correct")
+		}
+		if diff.ObserveOnly && diff.Action != gslb.DiffUnchanged {
+			fmt.Fprintln(w, "    observe mode: the service will not change this record")
+		}
+	}
+	_, err := fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d unchanged.\n", counts[gslb.DiffCreate], counts[gslb.DiffUpdate], counts[gslb.DiffUnchanged])
+	return err
+}
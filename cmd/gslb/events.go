@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/display"
+	"github.com/bootjp/cloudflare-gslb/pkg/history"
+	"github.com/bootjp/cloudflare-gslb/pkg/remoteconfig"
+	"github.com/bootjp/cloudflare-gslb/pkg/statusapi"
+)
+
+// runEvents implements "gslb events", which prints the event history of the
+// configuration at configPath.
+func runEvents(configPath string, args []string) {
+	flags := flag.NewFlagSet("events", flag.ExitOnError)
+	origin := flags.String("origin", "", "Only show events of this record name")
+	eventType := flags.String("type", "", "Only show events of this type ("+history.TypeHealth+", "+history.TypeDNSChange+" or "+history.TypeAvailability+")")
+	since := flags.String("since", "24h", "Show events after this RFC 3339 time or duration ago; empty for all")
+	until := flags.String("until", "", "Show events before this RFC 3339 time or duration ago")
+	limit := flags.Int("limit", 0, "Show at most this many of the newest events (0 = all)")
+	asJSON := flags.Bool("json", false, "Print events as JSON lines")
+	_ = flags.Parse(args)
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if !cfg.EventHistory.Enabled() {
+		log.Fatal("event_history is not configured")
+	}
+
+	now := time.Now()
+	filter := history.Filter{Origin: *origin, Type: *eventType, Limit: *limit}
+	if filter.Since, err = statusapi.ParseTime(*since, now); err != nil {
+		log.Fatalf("Invalid -since: %v", err)
+	}
+	if filter.Until, err = statusapi.ParseTime(*until, now); err != nil {
+		log.Fatalf("Invalid -until: %v", err)
+	}
+
+	// Read without compacting, so that this works next to a running service
+	events, err := history.Read(cfg.EventHistory.File, filter)
+	if err != nil {
+		log.Fatalf("Failed to read event history: %v", err)
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, event := range events {
+			if err := encoder.Encode(event); err != nil {
+				log.Fatalf("Failed to write event: %v", err)
+			}
+		}
+		return
+	}
+	if err := display.Events(os.Stdout, events); err != nil {
+		log.Fatalf("Failed to write events: %v", err)
+	}
+}
+
+// loadConfig loads the configuration from a local path or a remote URL.
+func loadConfig(configPath string) (*config.Config, error) {
+	if !remoteconfig.IsRemote(configPath) {
+		return config.LoadConfig(configPath)
+	}
+	fetcher, err := remoteconfig.NewFetcher(context.Background(), configPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := fetcher.Load(context.Background())
+	return cfg, err
+}
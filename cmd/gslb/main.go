@@ -2,33 +2,183 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/adminapi"
+	"github.com/bootjp/cloudflare-gslb/pkg/grpcapi"
 	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/listenaddr"
+	"github.com/bootjp/cloudflare-gslb/pkg/logfile"
+	"github.com/bootjp/cloudflare-gslb/pkg/metrics"
+	"github.com/bootjp/cloudflare-gslb/pkg/remoteconfig"
+	"github.com/bootjp/cloudflare-gslb/pkg/sentry"
+	"github.com/bootjp/cloudflare-gslb/pkg/servertls"
+	"github.com/bootjp/cloudflare-gslb/pkg/slackactions"
+	"github.com/bootjp/cloudflare-gslb/pkg/statusapi"
+	"github.com/bootjp/cloudflare-gslb/pkg/syslog"
+	"github.com/bootjp/cloudflare-gslb/pkg/tracing"
 )
 
 func main() {
-	configPath := "config.json"
-	if len(os.Args) > 1 {
-		configPath = os.Args[1]
+	configFlag := flag.String("config", "", "Path or https:// or s3:// URL of the configuration file (env: "+config.EnvConfigPath+", default: config.json)")
+	var checkInterval config.Seconds
+	flag.Func("check-interval", "Health check interval such as 30s, overriding check_interval_seconds (env: "+config.EnvCheckInterval+")", func(value string) error {
+		return checkInterval.UnmarshalText([]byte(value))
+	})
+	dryRun := flag.Bool("dry-run", false, "Check health and send notifications without changing DNS records (env: "+config.EnvDryRun+")")
+	apiToken := flag.String("api-token", "", "Cloudflare API token, overriding cloudflare_api_token (env: "+config.EnvAPIToken+")")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [config]\n       %s [flags] events [-origin name] [-type type] [-since 24h] [-json]\n       %s [flags] status [-addr host:port] [-json]\n       %s [flags] notify-test [-timeout 30s]\n       %s [flags] validate [-probe] [-timeout 1m]\n       %s [flags] simulate [-json] step...\n       %s [flags] diff [-json] [-exit-code]\n       %s prober -controller host:port [-name name] [-region region]\n", os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.Arg(0) == "events" {
+		runEvents(resolveConfigPath(*configFlag, nil), flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "status" {
+		runStatus(resolveConfigPath(*configFlag, nil), flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "notify-test" {
+		runNotifyTest(resolveConfigPath(*configFlag, nil), flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "validate" {
+		runValidate(resolveConfigPath(*configFlag, nil), flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "simulate" {
+		runSimulate(resolveConfigPath(*configFlag, nil), flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "diff" {
+		runDiff(resolveConfigPath(*configFlag, nil), flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "prober" {
+		runProber(flag.Args()[1:])
+		return
 	}
 
-	cfg, err := config.LoadConfig(configPath)
+	// Flags take precedence over environment variables, which take precedence over the config file
+	overrides, err := config.OverridesFromEnv(os.LookupEnv)
+	if err != nil {
+		log.Fatalf("Invalid environment variable: %v", err)
+	}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "check-interval":
+			overrides.CheckInterval = checkInterval.Duration()
+		case "dry-run":
+			overrides.DryRun = *dryRun
+		case "api-token":
+			overrides.APIToken = *apiToken
+		}
+	})
+	configPath := resolveConfigPath(*configFlag, flag.Args())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var fetcher *remoteconfig.Fetcher
+	var cfg *config.Config
+	if remoteconfig.IsRemote(configPath) {
+		fetcher, err = remoteconfig.NewFetcher(ctx, configPath)
+		if err == nil {
+			cfg, _, err = fetcher.Load(ctx)
+		}
+	} else {
+		cfg, err = config.LoadConfig(configPath)
+	}
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	// Logging and telemetry are set up once; changes to log, tracing and metrics take effect on restart
+	defer setupLogging(cfg)()
+	logWarnings(cfg)
+	overrides.Apply(cfg)
+	defer setupTelemetry(cfg)()
+
+	// The event streams of the status and gRPC APIs follow the services across reloads
+	var eventFeed *gslb.EventFeed
+	if cfg.StatusAPI.Enabled() || cfg.GRPCAPI.Enabled() {
+		eventFeed = gslb.NewEventFeed()
+	}
+	// And so do the remote probers registered over the gRPC API
+	var probeHub *gslb.ProbeHub
+	if cfg.GRPCAPI.Enabled() {
+		probeHub = gslb.NewProbeHub()
+	}
+
+	// The status API is started once; changes to status_api take effect on restart.
+	// It is up before the service, so that /healthz answers while the token is checked
+	var statusServer *statusapi.Server
+	if cfg.StatusAPI.Enabled() {
+		statusServer = statusapi.NewServer(nil)
+		statusServer.RequireToken(cfg.StatusAPI.Token)
+		statusServer.SetEventFeed(eventFeed)
+		if cfg.StatusAPI.TLS.MutualTLS() {
+			statusServer.RequireClientCert()
+		}
+		if debug := cfg.StatusAPI.Debug; debug.Enabled() && !debug.SeparateListener() {
+			statusServer.EnableDebug(debug.Token)
+		}
+		statusTLS := loadServerTLS("status_api", cfg.StatusAPI.TLS)
+		for _, address := range cfg.StatusAPI.Addresses() {
+			listener, err := listenaddr.Listen(address, cfg.StatusAPI.EffectiveSocketMode())
+			if err != nil {
+				log.Fatalf("Failed to start status API: %v", err)
+			}
+			statusServer.Serve(listener, statusTLS)
+			log.Printf("Serving origin status on %s", describeListener(address, statusTLS, statusapi.OriginsPath))
+		}
+		defer shutdownStatusAPI(statusServer)
+		if debug := cfg.StatusAPI.Debug; debug.SeparateListener() {
+			// The profiles are served with the certificate of the status API
+			debugServer := statusapi.NewDebugServer(debug.Token)
+			if cfg.StatusAPI.TLS.MutualTLS() {
+				debugServer.RequireClientCert()
+			}
+			if err := debugServer.ListenAndServe(debug.Listen, statusTLS); err != nil {
+				log.Fatalf("Failed to start debug endpoints: %v", err)
+			}
+			log.Printf("Serving profiles on %s://%s%s", scheme(statusTLS), debug.Listen, statusapi.PprofPath)
+			defer shutdownStatusAPI(debugServer)
+		}
+	}
+
+	// Like the status API, the callback of the Slack buttons is started once
+	var actionsServer *slackactions.Server
+	if cfg.SlackActions.Enabled() {
+		actionsServer = slackactions.NewServer(cfg.SlackActions.SigningSecret, cfg.SlackActions.AllowedUsers)
+		if err := actionsServer.ListenAndServe(cfg.SlackActions.EffectiveListen()); err != nil {
+			log.Fatalf("Failed to start the Slack actions callback: %v", err)
+		}
+		log.Printf("Serving Slack actions on http://%s%s", cfg.SlackActions.EffectiveListen(), slackactions.Path)
+		defer shutdownSlackActions(actionsServer)
+	}
 
 	service, err := gslb.NewService(cfg)
 	if err != nil {
 		log.Fatalf("Failed to create GSLB service: %v", err)
 	}
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	if eventFeed != nil {
+		service.SetEventFeed(eventFeed)
+	}
+	if probeHub != nil {
+		service.SetProbeHub(probeHub)
+	}
 
 	signalCh := make(chan os.Signal, 1)
 	signal.Notify(signalCh, syscall.SIGINT, syscall.SIGTERM)
@@ -37,9 +187,445 @@ func main() {
 		log.Printf("Failed to start GSLB service: %v", err)
 		return
 	}
+	service.NotifyStarted(ctx)
+
+	daemonVars := publishVars()
+	daemonVars.setService(service)
+	if statusServer != nil {
+		statusServer.SetSource(service)
+	}
+	if actionsServer != nil {
+		actionsServer.SetController(service)
+	}
 
-	sig := <-signalCh
-	log.Printf("Received signal: %v", sig)
+	// The watchers report reload failures to the running service, which
+	// notifies them
+	var running atomic.Pointer[gslb.Service]
+	running.Store(service)
+	report := func(err error) {
+		running.Load().ReportReload(ctx, err)
+	}
 
-	service.Stop()
+	reloadCh := make(chan *gslb.Service)
+	apply := func(newCfg *config.Config) error {
+		logWarnings(newCfg)
+		overrides.Apply(newCfg)
+		// Build the new service before stopping the old one, so that a
+		// config that cannot be applied leaves the old one running
+		next, err := gslb.NewService(newCfg)
+		if err != nil {
+			return err
+		}
+		select {
+		case reloadCh <- next:
+		case <-ctx.Done():
+		}
+		return nil
+	}
+	if fetcher != nil {
+		go fetcher.Watch(ctx, cfg.ConfigPollInterval, apply, report)
+	}
+	reload := func() (*config.Config, error) {
+		if fetcher != nil {
+			return fetcher.Reload()
+		}
+		return config.LoadConfig(configPath)
+	}
+	if cfg.OriginsKV != nil {
+		go watchOriginsKV(ctx, cfg, reload, apply, report)
+	}
+	if cfg.OriginsKubernetes != nil {
+		go watchOriginsKubernetes(ctx, cfg, reload, apply, report)
+	}
+
+	// Unlike the watchers, the APIs fetch the configuration again from its source
+	reloadConfig := func(reqCtx context.Context) error {
+		var newCfg *config.Config
+		var err error
+		if fetcher != nil {
+			newCfg, _, err = fetcher.Load(reqCtx)
+		} else {
+			newCfg, err = config.LoadConfig(configPath)
+		}
+		if err == nil {
+			err = apply(newCfg)
+		}
+		report(err)
+		return err
+	}
+
+	// Like the status API, the admin API is started once
+	var adminServer *adminapi.Server
+	if cfg.AdminAPI.Enabled() {
+		adminServer = adminapi.NewServer(cfg.AdminAPI.Token, reloadConfig)
+		adminServer.SetController(service)
+		adminServer.SetRuntimeFile(cfg.RuntimeFile)
+		for _, token := range cfg.AdminAPI.Tokens {
+			adminServer.AddToken(token.Name, token.Token, token.EffectiveRole())
+		}
+		if cfg.AdminAPI.TLS.MutualTLS() {
+			adminServer.RequireClientCert()
+		}
+		adminTLS := loadServerTLS("admin_api", cfg.AdminAPI.TLS)
+		for _, address := range cfg.AdminAPI.Addresses() {
+			listener, err := listenaddr.Listen(address, cfg.AdminAPI.EffectiveSocketMode())
+			if err != nil {
+				log.Fatalf("Failed to start the admin API: %v", err)
+			}
+			adminServer.Serve(listener, adminTLS)
+			log.Printf("Serving the admin API on %s", describeListener(address, adminTLS, adminapi.OriginsPath))
+		}
+		defer shutdownAdminAPI(adminServer)
+	}
+	var grpcServer *grpcapi.Server
+	if cfg.GRPCAPI.Enabled() {
+		grpcServer = grpcapi.NewServer(cfg.GRPCAPI.Token, eventFeed, reloadConfig)
+		grpcServer.SetController(service)
+		grpcServer.SetProbeHub(probeHub)
+		for _, token := range cfg.GRPCAPI.Tokens {
+			grpcServer.AddToken(token.Name, token.Token, token.EffectiveRole())
+		}
+		if cfg.GRPCAPI.TLS.MutualTLS() {
+			grpcServer.RequireClientCert()
+		}
+		grpcTLS := loadServerTLS("grpc_api", cfg.GRPCAPI.TLS)
+		if err := grpcServer.ListenAndServe(cfg.GRPCAPI.EffectiveListen(), grpcTLS); err != nil {
+			log.Fatalf("Failed to start the gRPC API: %v", err)
+		}
+		log.Printf("Serving the gRPC API (%s) on %s://%s", grpcapi.ServiceName, scheme(grpcTLS), cfg.GRPCAPI.EffectiveListen())
+		defer shutdownGRPCAPI(grpcServer)
+	}
+
+	for {
+		select {
+		case next := <-reloadCh:
+			service.Stop()
+			previous := service
+			service = next
+			service.InheritHolds(previous)
+			if eventFeed != nil {
+				service.SetEventFeed(eventFeed)
+			}
+			if probeHub != nil {
+				service.SetProbeHub(probeHub)
+			}
+			running.Store(service)
+			if err := service.Start(ctx); err != nil {
+				log.Printf("Failed to start GSLB service: %v", err)
+				return
+			}
+			service.NotifyReloaded(ctx, previous)
+			daemonVars.setService(service)
+			if statusServer != nil {
+				statusServer.SetSource(service)
+			}
+			if actionsServer != nil {
+				actionsServer.SetController(service)
+			}
+			if adminServer != nil {
+				adminServer.SetController(service)
+			}
+			if grpcServer != nil {
+				grpcServer.SetController(service)
+			}
+		case sig := <-signalCh:
+			log.Printf("Received signal: %v", sig)
+			notifyCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			service.NotifyStopping(notifyCtx)
+			cancel()
+			service.Stop()
+			return
+		}
+	}
+}
+
+// kvRetryDelay is how long to wait before watching the KV store again after an error.
+const kvRetryDelay = 10 * time.Second
+
+// watchOriginsKV reloads the configuration whenever the origins in the KV
+// store change. The store of the initial configuration is watched, and the
+// outcome of every reload is passed to report.
+func watchOriginsKV(ctx context.Context, cfg *config.Config, reload func() (*config.Config, error), apply func(*config.Config) error, report func(error)) {
+	kv := cfg.OriginsKV
+	index := cfg.OriginsKVIndex
+	for {
+		next, err := config.WaitOriginsKV(ctx, kv, index)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to watch origins in %s: %v", kv.Backend, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(kvRetryDelay):
+			}
+			continue
+		}
+		index = next
+
+		log.Printf("Origins in %s changed, reloading", kv.Backend)
+		newCfg, err := reload()
+		if err != nil {
+			log.Printf("Keeping the current config: %v", err)
+			report(err)
+			continue
+		}
+		if newCfg.OriginsKV != nil {
+			index = newCfg.OriginsKVIndex
+		}
+		if err := apply(newCfg); err != nil {
+			log.Printf("Failed to apply the reloaded config: %v", err)
+			report(err)
+			continue
+		}
+		report(nil)
+	}
+}
+
+// watchOriginsKubernetes reloads the configuration whenever a GSLBOrigin
+// resource is added or deleted or its spec changes. After a failed reload,
+// it waits kvRetryDelay before trying again.
+func watchOriginsKubernetes(ctx context.Context, cfg *config.Config, reload func() (*config.Config, error), apply func(*config.Config) error, report func(error)) {
+	c := cfg.OriginsKubernetes
+	loaded := cfg.KubernetesOrigins
+	for {
+		err := config.WaitOriginsKubernetes(ctx, c, loaded)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			log.Println("GSLBOrigin resources changed, reloading")
+			var newCfg *config.Config
+			if newCfg, err = reload(); err != nil {
+				log.Printf("Keeping the current config: %v", err)
+				report(err)
+			} else if err = apply(newCfg); err != nil {
+				log.Printf("Failed to apply the reloaded config: %v", err)
+				report(err)
+			} else {
+				loaded = newCfg.KubernetesOrigins
+				report(nil)
+				continue
+			}
+		} else {
+			log.Printf("Failed to watch GSLBOrigin resources: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(kvRetryDelay):
+		}
+	}
+}
+
+// logWarnings logs the settings that were migrated from an older config
+// version and the GSLBOrigin resources that were not loaded.
+func logWarnings(cfg *config.Config) {
+	for _, warning := range cfg.Warnings {
+		log.Printf("Warning: %s", warning)
+	}
+}
+
+// setupLogging redirects the log to the configured file and syslog and
+// returns a function that closes them.
+func setupLogging(cfg *config.Config) func() {
+	if !cfg.Log.Enabled() {
+		return func() {}
+	}
+	var writers []io.Writer
+	var closers []func() error
+	if cfg.Log.File != "" {
+		writer, err := logfile.Open(logfile.Config{
+			Path:       cfg.Log.File,
+			MaxSize:    int64(cfg.Log.EffectiveMaxSizeMB()) << 20,
+			MaxAge:     cfg.Log.MaxAgeSeconds.Duration(),
+			MaxBackups: cfg.Log.EffectiveMaxBackups(),
+			Compress:   cfg.Log.Compress,
+		})
+		if err != nil {
+			log.Fatalf("Failed to open log file: %v", err)
+		}
+		log.Printf("Logging to %s", cfg.Log.File)
+		writers = append(writers, writer)
+		closers = append(closers, writer.Close)
+	}
+	if cfg.Log.Syslog != nil {
+		// The facility was validated with the config
+		facility, _ := syslog.ParseFacility(cfg.Log.Syslog.EffectiveFacility())
+		writer, err := syslog.Dial(syslog.Config{
+			Network:  cfg.Log.Syslog.EffectiveNetwork(),
+			Address:  cfg.Log.Syslog.Address,
+			Facility: facility,
+			AppName:  cfg.Log.Syslog.EffectiveAppName(),
+		})
+		if err != nil {
+			log.Fatalf("Failed to set up syslog: %v", err)
+		}
+		log.Printf("Logging to syslog (%s)", cfg.Log.Syslog.EffectiveFacility())
+		// The file comes first, so that it gets every line even while syslog is unreachable
+		writers = append(writers, writer)
+		closers = append(closers, writer.Close)
+	}
+	log.SetOutput(io.MultiWriter(writers...))
+	return func() {
+		log.SetOutput(os.Stderr)
+		for _, closeFn := range closers {
+			if err := closeFn(); err != nil {
+				log.Printf("Failed to close log output: %v", err)
+			}
+		}
+	}
+}
+
+// setupTelemetry starts exporting traces and metrics when the config enables
+// them and returns a function that sends what is still queued.
+func setupTelemetry(cfg *config.Config) func() {
+	var shutdowns []func(context.Context) error
+	if cfg.Tracing.Enabled() {
+		shutdown, err := tracing.Setup(tracing.Config{
+			Endpoint:    cfg.Tracing.EffectiveEndpoint(),
+			ServiceName: cfg.Tracing.EffectiveServiceName(),
+			Headers:     cfg.Tracing.Headers,
+		})
+		if err != nil {
+			log.Fatalf("Failed to set up tracing: %v", err)
+		}
+		log.Printf("Exporting traces to %s", cfg.Tracing.EffectiveEndpoint())
+		shutdowns = append(shutdowns, shutdown)
+	}
+	if cfg.Metrics.Enabled() {
+		shutdowns = append(shutdowns, setupMetrics(cfg.Metrics))
+	}
+	if cfg.ErrorReporting.Enabled() {
+		shutdown, err := sentry.Setup(sentry.Config{
+			DSN:            cfg.ErrorReporting.EffectiveDSN(),
+			Environment:    cfg.ErrorReporting.Environment,
+			Release:        cfg.ErrorReporting.Release,
+			RepeatInterval: time.Duration(cfg.ErrorReporting.EffectiveRepeatInterval()),
+		})
+		if err != nil {
+			log.Fatalf("Failed to set up error reporting: %v", err)
+		}
+		log.Println("Reporting errors to Sentry")
+		shutdowns = append(shutdowns, shutdown)
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		for _, shutdown := range shutdowns {
+			if err := shutdown(ctx); err != nil {
+				log.Printf("Failed to export telemetry: %v", err)
+			}
+		}
+	}
+}
+
+// setupMetrics starts the configured metrics exporter.
+func setupMetrics(cfg *config.MetricsConfig) func(context.Context) error {
+	var shutdown func(context.Context) error
+	var err error
+	switch cfg.EffectiveExporter() {
+	case config.MetricsExporterStatsD:
+		shutdown, err = metrics.SetupStatsD(metrics.StatsDConfig{
+			Address: cfg.EffectiveAddress(),
+			Prefix:  cfg.Prefix,
+			Tags:    cfg.Tags,
+		})
+		if err == nil {
+			log.Printf("Sending metrics to StatsD at %s", cfg.EffectiveAddress())
+		}
+	default:
+		shutdown, err = metrics.Setup(metrics.Config{
+			Endpoint:    cfg.EffectiveEndpoint(),
+			ServiceName: cfg.EffectiveServiceName(),
+			Headers:     cfg.Headers,
+			Interval:    cfg.EffectiveInterval().Duration(),
+		})
+		if err == nil {
+			log.Printf("Exporting metrics to %s every %s", cfg.EffectiveEndpoint(), cfg.EffectiveInterval().Duration())
+		}
+	}
+	if err != nil {
+		log.Fatalf("Failed to set up metrics: %v", err)
+	}
+	return shutdown
+}
+
+// loadServerTLS loads the certificate of the API configured by the block
+// name, or returns nil when the API is served over plain HTTP.
+func loadServerTLS(name string, cfg *config.ServerTLSConfig) *tls.Config {
+	if !cfg.Enabled() {
+		return nil
+	}
+	tlsConfig, err := servertls.Load(cfg.ServerConfig())
+	if err != nil {
+		log.Fatalf("Failed to load %s.tls: %v", name, err)
+	}
+	return tlsConfig
+}
+
+// scheme returns the URL scheme of a listener served with tlsConfig.
+func scheme(tlsConfig *tls.Config) string {
+	if tlsConfig == nil {
+		return "http"
+	}
+	return "https"
+}
+
+// describeListener returns the URL of path on the listener of address for
+// the log, or the socket with the path.
+func describeListener(address string, tlsConfig *tls.Config, path string) string {
+	if _, ok := listenaddr.SocketPath(address); ok {
+		return address + " (" + scheme(tlsConfig) + " " + path + ")"
+	}
+	return scheme(tlsConfig) + "://" + address + path
+}
+
+// shutdownStatusAPI stops the status API, waiting briefly for in-flight requests.
+func shutdownStatusAPI(server *statusapi.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Failed to stop status API: %v", err)
+	}
+}
+
+func shutdownSlackActions(server *slackactions.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Failed to stop the Slack actions callback: %v", err)
+	}
+}
+
+func shutdownAdminAPI(server *adminapi.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Failed to stop the admin API: %v", err)
+	}
+}
+
+func shutdownGRPCAPI(server *grpcapi.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Failed to stop the gRPC API: %v", err)
+	}
+}
+
+// resolveConfigPath returns the -config flag, the first argument (the
+// original way of passing the path), GSLB_CONFIG or config.json, in that order.
+func resolveConfigPath(flagValue string, args []string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if len(args) > 0 {
+		return args[0]
+	}
+	if path := os.Getenv(config.EnvConfigPath); path != "" {
+		return path
+	}
+	return "config.json"
 }
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+)
+
+// runNotifyTest implements "gslb notify-test", which sends a test failover
+// to every notification of the configuration at configPath and exits with
+// status 1 if any of them failed.
+func runNotifyTest(configPath string, args []string) {
+	flags := flag.NewFlagSet("notify-test", flag.ExitOnError)
+	timeout := flags.Duration("timeout", 30*time.Second, "Timeout of sending to all notifications")
+	_ = flags.Parse(args)
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if len(cfg.Notifications) == 0 {
+		log.Fatal("no notifications are configured")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	results := gslb.SendTestNotifications(ctx, cfg)
+	if err := printNotifyTest(os.Stdout, results); err != nil {
+		log.Fatalf("Failed to write results: %v", err)
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			os.Exit(1)
+		}
+	}
+}
+
+func printNotifyTest(w io.Writer, results []gslb.NotifyTestResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NOTIFICATION\tTYPE\tRESULT\tTIME")
+	for _, result := range results {
+		status := "ok"
+		if result.Err != nil {
+			status = "failed: " + result.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result.Name, result.Type, status, result.Duration.Round(time.Millisecond))
+	}
+	return tw.Flush()
+}
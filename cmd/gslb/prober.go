@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/grpcapi"
+	"github.com/bootjp/cloudflare-gslb/pkg/prober"
+	"github.com/bootjp/cloudflare-gslb/pkg/servertls"
+)
+
+// envProberToken is the environment variable of the -token flag of
+// "gslb prober", to keep the token out of the process list.
+const envProberToken = "GSLB_PROBER_TOKEN"
+
+// runProber implements "gslb prober", which runs the health checks assigned
+// by the gRPC API of a daemon and reports their results to it. It needs no
+// configuration file and keeps no state.
+func runProber(args []string) {
+	flags := flag.NewFlagSet("prober", flag.ExitOnError)
+	controller := flags.String("controller", "", "host:port of the gRPC API of the daemon")
+	token := flags.String("token", "", "Token of the prober role in grpc_api.tokens (env: "+envProberToken+")")
+	name := flags.String("name", "", "Unique name of the prober (default: the name of the token, or the host name)")
+	region := flags.String("region", "", "Where the prober runs, e.g. ap-northeast-1")
+	useTLS := flags.Bool("tls", false, "Call the gRPC API over TLS; implied by -ca-file and -cert")
+	caFile := flags.String("ca-file", "", "PEM file of the CA that signed the certificate of the gRPC API")
+	certFile := flags.String("cert", "", "PEM file of the client certificate, when grpc_api.tls.client_ca_file is set")
+	keyFile := flags.String("key", "", "PEM file of the key of the client certificate")
+	_ = flags.Parse(args)
+
+	if *controller == "" {
+		log.Fatal("-controller is required")
+	}
+	if *token == "" {
+		*token = os.Getenv(envProberToken)
+	}
+	if *name == "" && *token == "" {
+		// Without a token the daemon cannot name the prober
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.Fatalf("Failed to get the host name, pass -name: %v", err)
+		}
+		*name = hostname
+	}
+	var tlsConfig *tls.Config
+	if *useTLS || *caFile != "" || *certFile != "" {
+		var err error
+		if tlsConfig, err = servertls.ClientConfig(*caFile, *certFile, *keyFile); err != nil {
+			log.Fatalf("Failed to set up TLS: %v", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	log.Printf("Running remote checks for the controller at %s", *controller)
+	p := prober.New(*name, *region, grpcapi.NewClient(*controller, *token, tlsConfig))
+	if err := p.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		log.Fatalf("Prober stopped: %v", err)
+	}
+	log.Println("Prober stopped")
+}
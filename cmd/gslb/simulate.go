@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+)
+
+// runSimulate implements "gslb simulate", which prints the DNS changes and
+// notifications the service of the configuration at configPath would make
+// in the scenario given by the arguments, without probing or changing
+// anything.
+func runSimulate(configPath string, args []string) {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Print the steps as JSON lines")
+	_ = flags.Parse(args)
+
+	var scenario []gslb.ScenarioStep
+	for _, arg := range flags.Args() {
+		step, err := gslb.ParseScenarioStep(arg)
+		if err != nil {
+			log.Fatalf("Invalid scenario: %v", err)
+		}
+		scenario = append(scenario, step)
+	}
+	if len(scenario) == 0 {
+		log.Fatal(`no scenario given, e.g. "down 203.0.113.10" "wait 10m" "up 203.0.113.10"`)
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	steps, err := gslb.Simulate(context.Background(), cfg, scenario)
+	if err != nil {
+		log.Fatalf("Failed to simulate: %v", err)
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, step := range steps {
+			if err := encoder.Encode(step); err != nil {
+				log.Fatalf("Failed to write step: %v", err)
+			}
+		}
+		return
+	}
+	if err := printSimulation(os.Stdout, steps); err != nil {
+		log.Fatalf("Failed to write steps: %v", err)
+	}
+}
+
+func printSimulation(w io.Writer, steps []gslb.SimulatedStep) error {
+	for _, step := range steps {
+		elapsed := time.Duration(step.ElapsedSeconds * float64(time.Second))
+		if _, err := fmt.Fprintf(w, "%s (+%s)\n", step.Step, elapsed); err != nil {
+			return err
+		}
+		if len(step.Changes) == 0 && len(step.Events) == 0 {
+			fmt.Fprintln(w, "  no changes")
+		}
+		for _, change := range step.Changes {
+			if change.Before == nil {
+				fmt.Fprintf(w, "  records %s %s: %s\n", change.Name, change.RecordType, ipList(change.After))
+				continue
+			}
+			fmt.Fprintf(w, "  change  %s %s: %s -> %s\n", change.Name, change.RecordType, ipList(change.Before), ipList(change.After))
+		}
+		for _, event := range step.Events {
+			notifications := "no notifications"
+			if len(event.Notifications) > 0 {
+				notifications = strings.Join(event.Notifications, ", ")
+			}
+			fmt.Fprintf(w, "  notify  %s %s %s: %s -> %s\n", event.Event.Type, event.Event.OriginName, event.Event.RecordType, event.Event.Reason, notifications)
+		}
+	}
+	return nil
+}
+
+// ipList formats ips for printSimulation, showing an empty list as "none".
+func ipList(ips []string) string {
+	if len(ips) == 0 {
+		return "none"
+	}
+	return strings.Join(ips, ", ")
+}
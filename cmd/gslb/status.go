@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/display"
+	"github.com/bootjp/cloudflare-gslb/pkg/listenaddr"
+	"github.com/bootjp/cloudflare-gslb/pkg/servertls"
+	"github.com/bootjp/cloudflare-gslb/pkg/statusapi"
+)
+
+// runStatus implements "gslb status", which prints the state of every origin
+// as reported by the status API of the running service.
+func runStatus(configPath string, args []string) {
+	flags := flag.NewFlagSet("status", flag.ExitOnError)
+	addr := flags.String("addr", "", "host:port, unix:<socket> or URL of the status API (default: status_api.listen of the configuration)")
+	token := flags.String("token", "", "Token of the status API (default: status_api.token of the configuration)")
+	caFile := flags.String("ca-file", "", "PEM file of the CA that signed the certificate of the status API")
+	certFile := flags.String("cert", "", "PEM file of the client certificate, when status_api.tls.client_ca_file is set")
+	keyFile := flags.String("key", "", "PEM file of the key of the client certificate")
+	asJSON := flags.Bool("json", false, "Print the reports as JSON")
+	timeout := flags.Duration("timeout", 10*time.Second, "Timeout of the request")
+	_ = flags.Parse(args)
+
+	address, secure := *addr, *caFile != "" || *certFile != ""
+	if address == "" || *token == "" {
+		cfg, err := loadConfig(configPath)
+		if err != nil && address == "" {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+		if err == nil && cfg.StatusAPI.Enabled() {
+			if address == "" {
+				if address, err = statusapi.DialAddress(cfg.StatusAPI.EffectiveListen()); err != nil {
+					log.Fatalf("Invalid status_api.listen: %v", err)
+				}
+				secure = secure || cfg.StatusAPI.TLS.Enabled()
+			}
+			if *token == "" {
+				*token = cfg.StatusAPI.Token
+			}
+		} else if address == "" {
+			log.Fatal("status_api is not configured; enable it or pass -addr")
+		}
+	}
+	baseURL := listenaddr.BaseURL(address, secure)
+	tlsConfig, err := servertls.ClientConfig(*caFile, *certFile, *keyFile)
+	if err != nil {
+		log.Fatalf("Failed to set up TLS: %v", err)
+	}
+	client := statusapi.NewHTTPClient(*token, listenaddr.Transport(address, tlsConfig))
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	origins, err := statusapi.FetchOrigins(ctx, client, baseURL)
+	if err != nil {
+		log.Fatalf("Failed to query the service at %s: %v", address, err)
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(origins); err != nil {
+			log.Fatalf("Failed to write status: %v", err)
+		}
+		return
+	}
+	if err := display.Status(os.Stdout, origins, time.Now()); err != nil {
+		log.Fatalf("Failed to write status: %v", err)
+	}
+	// Services older than the notifications endpoint only report origins
+	notifications, err := statusapi.FetchNotifications(ctx, client, baseURL)
+	if err != nil {
+		return
+	}
+	if err := display.FailingNotifications(os.Stdout, notifications, time.Now()); err != nil {
+		log.Fatalf("Failed to write status: %v", err)
+	}
+}
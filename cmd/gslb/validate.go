@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+)
+
+// runValidate implements "gslb validate", which checks that the configuration
+// at configPath can be deployed without changing anything and exits with
+// status 1 if it cannot, for use as a pre-deploy gate.
+func runValidate(configPath string, args []string) {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	probe := flags.Bool("probe", false, "Also run the health check of every origin once against its IPs")
+	timeout := flags.Duration("timeout", time.Minute, "Timeout of all checks")
+	_ = flags.Parse(args)
+
+	overrides, err := config.OverridesFromEnv(os.LookupEnv)
+	if err != nil {
+		log.Fatalf("Invalid environment variable: %v", err)
+	}
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	logWarnings(cfg)
+	overrides.Apply(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	checks := gslb.ValidateDeployment(ctx, cfg, *probe)
+	if err := printValidate(os.Stdout, checks); err != nil {
+		log.Fatalf("Failed to write results: %v", err)
+	}
+	for _, check := range checks {
+		if check.Err != nil {
+			os.Exit(1)
+		}
+	}
+}
+
+func printValidate(w io.Writer, checks []gslb.ValidationCheck) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tRESULT")
+	for _, check := range checks {
+		status := "ok"
+		if check.Detail != "" {
+			status += ": " + check.Detail
+		}
+		if check.Err != nil {
+			status = "failed: " + check.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", check.Name, status)
+	}
+	return tw.Flush()
+}
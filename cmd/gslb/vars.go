@@ -0,0 +1,93 @@
+package main
+
+import (
+	"expvar"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/sentry"
+	"github.com/bootjp/cloudflare-gslb/pkg/tracing"
+)
+
+// daemonVars publishes the state of the running service at /debug/vars,
+// next to the memstats and cmdline variables of the expvar package.
+type daemonVars struct {
+	service atomic.Pointer[gslb.Service]
+	reloads atomic.Int64
+}
+
+// gslbVars is the "gslb" variable.
+type gslbVars struct {
+	gslb.Vars
+	Reloads int64 `json:"reloads"`
+	// Queues are the items waiting to be sent by the background exporters;
+	// notifications being sent are counted in PendingNotifications.
+	Queues queueVars `json:"queues"`
+}
+
+type queueVars struct {
+	ErrorReports int `json:"error_reports"`
+	TraceSpans   int `json:"trace_spans"`
+}
+
+// buildVars is the "build" variable.
+type buildVars struct {
+	GoVersion string `json:"go_version"`
+	Module    string `json:"module,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// publishVars publishes the variables of the daemon. It must be called once.
+func publishVars() *daemonVars {
+	vars := &daemonVars{}
+	expvar.Publish("gslb", expvar.Func(vars.gslb))
+	expvar.Publish("build", expvar.Func(func() any { return readBuildVars() }))
+	return vars
+}
+
+// setService records the service that is running, after startup or a reload.
+func (v *daemonVars) setService(service *gslb.Service) {
+	if v.service.Swap(service) != nil {
+		v.reloads.Add(1)
+	}
+}
+
+func (v *daemonVars) gslb() any {
+	vars := gslbVars{
+		Reloads: v.reloads.Load(),
+		Queues: queueVars{
+			ErrorReports: sentry.Queued(),
+			TraceSpans:   tracing.Queued(),
+		},
+	}
+	if service := v.service.Load(); service != nil {
+		vars.Vars = service.Vars()
+	}
+	return vars
+}
+
+func readBuildVars() buildVars {
+	vars := buildVars{GoVersion: runtime.Version()}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return vars
+	}
+	vars.Module = info.Main.Path
+	vars.Version = info.Main.Version
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			vars.Revision = setting.Value
+		case "vcs.time":
+			vars.Time = setting.Value
+		case "vcs.modified":
+			vars.Modified = setting.Value == "true"
+		}
+	}
+	return vars
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/adminapi"
+	"github.com/bootjp/cloudflare-gslb/pkg/display"
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/history"
+	"github.com/bootjp/cloudflare-gslb/pkg/listenaddr"
+	"github.com/bootjp/cloudflare-gslb/pkg/remoteconfig"
+	"github.com/bootjp/cloudflare-gslb/pkg/servertls"
+	"github.com/bootjp/cloudflare-gslb/pkg/statusapi"
+)
+
+// Environment variables of the flags of gslbctl.
+const (
+	envAddr  = "GSLBCTL_ADDR"
+	envToken = "GSLBCTL_TOKEN"
+)
+
+// actions are the commands that operate an origin, by their admin API action.
+var actions = map[string]string{
+	"pause":    adminapi.ActionPause,
+	"resume":   adminapi.ActionResume,
+	"failover": adminapi.ActionFailover,
+	"failback": adminapi.ActionFailback,
+	"check":    adminapi.ActionCheck,
+}
+
+const usage = `Usage: %[1]s [flags] <command> [args]
+
+Commands:
+  status                                 List every origin and its state
+  events [-origin name] [-type type] [-since 24h] [-until time] [-limit n]
+                                         List the recorded events
+  pause|resume <zone> <name> <type>      Pause or resume the automatic DNS changes of an origin
+  failover|failback <zone> <name> <type> Publish the next lower or the highest priority level of an origin
+  check <zone> <name> <type>             Check an origin right away
+  reload                                 Reload the configuration of the daemon
+  validate [config]                      Check a configuration without a running daemon
+
+Flags:
+`
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gslbctl: ")
+	addr := flag.String("addr", "", "host:port, unix:<socket> or URL of the admin API (env: "+envAddr+", default: admin_api.listen of -config, or "+config.DefaultAdminAPIListen+")")
+	token := flag.String("token", "", "Token of the admin API (env: "+envToken+", default: admin_api.token of -config)")
+	configPath := flag.String("config", "", "Path or https:// or s3:// URL of the configuration of the daemon, to read admin_api from (env: "+config.EnvConfigPath+")")
+	caFile := flag.String("ca-file", "", "PEM file of the CA that signed the certificate of the admin API")
+	certFile := flag.String("cert", "", "PEM file of the client certificate, when admin_api.tls.client_ca_file is set")
+	keyFile := flag.String("key", "", "PEM file of the key of the client certificate")
+	by := flag.String("by", os.Getenv("USER"), "Who changes are attributed to when the token has no name")
+	asJSON := flag.Bool("json", false, "Print JSON instead of tables")
+	timeout := flag.Duration("timeout", 30*time.Second, "Timeout of the request")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *configPath == "" {
+		*configPath = os.Getenv(config.EnvConfigPath)
+	}
+	command, args := flag.Arg(0), flag.Args()[1:]
+	out := output{json: *asJSON}
+
+	// Validating does not need a running daemon
+	if command == "validate" {
+		path := *configPath
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if path == "" {
+			path = "config.json"
+		}
+		if !out.validation(path, validate(path)) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	tlsConfig, err := servertls.ClientConfig(*caFile, *certFile, *keyFile)
+	if err != nil {
+		log.Fatalf("Failed to set up TLS: %v", err)
+	}
+	client := newClient(*addr, *token, *configPath, tlsConfig)
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	switch {
+	case command == "status":
+		origins, err := client.Origins(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list origins: %v", err)
+		}
+		out.origins(origins)
+	case command == "events":
+		runEvents(ctx, client, out, args)
+	case actions[command] != "":
+		if len(args) != 3 {
+			log.Fatalf("%s takes the zone, name and record type of the origin, e.g. %s example.com www.example.com A", command, command)
+		}
+		report, err := client.Act(ctx, args[0], args[1], args[2], actions[command], *by)
+		if err != nil {
+			log.Fatalf("Failed to %s %s: %v", command, args[1], err)
+		}
+		out.origins([]gslb.OriginReport{report})
+	case command == "reload":
+		if err := client.Reload(ctx); err != nil {
+			log.Fatalf("Failed to reload: %v", err)
+		}
+		out.message("Reloading the configuration", map[string]string{"status": "reloading"})
+	default:
+		log.Printf("Unknown command %q", command)
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// runEvents implements "gslbctl events".
+func runEvents(ctx context.Context, client *adminapi.Client, out output, args []string) {
+	flags := flag.NewFlagSet("events", flag.ExitOnError)
+	origin := flags.String("origin", "", "Only show events of this record name")
+	eventType := flags.String("type", "", "Only show events of this type ("+history.TypeHealth+", "+history.TypeDNSChange+" or "+history.TypeAvailability+")")
+	since := flags.String("since", "24h", "Show events after this RFC 3339 time or duration ago; empty for all")
+	until := flags.String("until", "", "Show events before this RFC 3339 time or duration ago")
+	limit := flags.Int("limit", statusapi.DefaultEventLimit, "Show at most this many of the newest events (0 = all)")
+	_ = flags.Parse(args)
+
+	query := url.Values{"limit": {strconv.Itoa(*limit)}}
+	for name, value := range map[string]string{"origin": *origin, "type": *eventType, "since": *since, "until": *until} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	events, err := client.Events(ctx, query)
+	if err != nil {
+		log.Fatalf("Failed to list events: %v", err)
+	}
+	out.events(events)
+}
+
+// newClient returns a client of the admin API given by the flags, their
+// environment variables or the admin_api block of the configuration. The
+// token may only be missing when a client certificate is used instead, and
+// the API is reached over TLS when it is served over TLS or tlsConfig has a
+// client certificate.
+func newClient(addr, token, configPath string, tlsConfig *tls.Config) *adminapi.Client {
+	hasCert := len(tlsConfig.Certificates) > 0
+	if addr == "" {
+		addr = os.Getenv(envAddr)
+	}
+	if token == "" {
+		token = os.Getenv(envToken)
+	}
+	secure, mutualTLS := hasCert, hasCert
+	if (addr == "" || token == "") && configPath != "" {
+		cfg, err := loadConfig(configPath)
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+		if !cfg.AdminAPI.Enabled() {
+			log.Fatalf("admin_api is not configured in %s", configPath)
+		}
+		if addr == "" {
+			if addr, err = statusapi.DialAddress(cfg.AdminAPI.EffectiveListen()); err != nil {
+				log.Fatalf("Invalid admin_api.listen: %v", err)
+			}
+			secure = secure || cfg.AdminAPI.TLS.Enabled()
+		}
+		mutualTLS = mutualTLS || cfg.AdminAPI.TLS.MutualTLS()
+		if token == "" {
+			token = cfg.AdminAPI.Token
+		}
+	}
+	if addr == "" {
+		addr = config.DefaultAdminAPIListen
+	}
+	if token == "" && !mutualTLS {
+		log.Fatalf("The token of the admin API is required; pass -token, set %s or pass -config", envToken)
+	}
+	return &adminapi.Client{
+		BaseURL:    listenaddr.BaseURL(addr, secure),
+		Token:      token,
+		HTTPClient: &http.Client{Transport: listenaddr.Transport(addr, tlsConfig)},
+	}
+}
+
+// loadConfig loads the configuration from a local path or a remote URL.
+func loadConfig(configPath string) (*config.Config, error) {
+	if !remoteconfig.IsRemote(configPath) {
+		return config.LoadConfig(configPath)
+	}
+	fetcher, err := remoteconfig.NewFetcher(context.Background(), configPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := fetcher.Load(context.Background())
+	return cfg, err
+}
+
+// validationResult is the JSON output of "gslbctl validate".
+type validationResult struct {
+	Config   string   `json:"config"`
+	Valid    bool     `json:"valid"`
+	Error    string   `json:"error,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// validate loads the configuration at path and checks its origins, like the
+// daemon does before starting.
+func validate(path string) validationResult {
+	result := validationResult{Config: path}
+	cfg, err := loadConfig(path)
+	if err == nil {
+		result.Warnings = cfg.Warnings
+		err = config.ValidateOrigins(cfg)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Valid = true
+	return result
+}
+
+// output prints the results as tables or as JSON.
+type output struct {
+	json bool
+}
+
+func (o output) origins(origins []gslb.OriginReport) {
+	if o.json {
+		o.encode(origins)
+		return
+	}
+	if err := display.Status(os.Stdout, origins, time.Now()); err != nil {
+		log.Fatalf("Failed to write origins: %v", err)
+	}
+}
+
+// events prints events as a table or as JSON lines, like "gslb events".
+func (o output) events(events []history.Event) {
+	if !o.json {
+		if err := display.Events(os.Stdout, events); err != nil {
+			log.Fatalf("Failed to write events: %v", err)
+		}
+		return
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			log.Fatalf("Failed to write event: %v", err)
+		}
+	}
+}
+
+func (o output) message(text string, body any) {
+	if o.json {
+		o.encode(body)
+		return
+	}
+	fmt.Println(text)
+}
+
+// validation prints the result of validating path and reports whether the
+// configuration is valid.
+func (o output) validation(path string, result validationResult) bool {
+	if o.json {
+		o.encode(result)
+		return result.Valid
+	}
+	for _, warning := range result.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	if !result.Valid {
+		fmt.Printf("%s is invalid: %s\n", path, result.Error)
+		return false
+	}
+	fmt.Printf("%s is valid\n", path)
+	return true
+}
+
+func (o output) encode(body any) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(body); err != nil {
+		log.Fatalf("Failed to write JSON: %v", err)
+	}
+}
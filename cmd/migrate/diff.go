@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// writeDiff writes a unified diff of the lines of a and b.
+func writeDiff(w io.Writer, aName, bName string, a, b []byte) {
+	ops := diffLines(splitLines(a), splitLines(b))
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", aName, bName)
+
+	for start := 0; start < len(ops); {
+		// Find the next change and the end of its hunk
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			return
+		}
+		end := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+			} else if i-end >= 2*diffContext {
+				break
+			}
+		}
+		from := max(first-diffContext, 0)
+		to := min(end+diffContext, len(ops))
+
+		aLine, bLine := 1, 1
+		for _, op := range ops[:from] {
+			if op.kind != '+' {
+				aLine++
+			}
+			if op.kind != '-' {
+				bLine++
+			}
+		}
+		aCount, bCount := 0, 0
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(w, "@@ -%d,%d +%d,%d @@\n", aLine, aCount, bLine, bCount)
+		for _, op := range ops[from:to] {
+			fmt.Fprintf(w, "%c%s\n", op.kind, op.line)
+		}
+		start = to
+	}
+}
+
+func splitLines(data []byte) []string {
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// diffLines returns the shortest edit script from a to b (Myers' algorithm).
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	var trace [][]int
+
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, offset)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrack(a, b []string, trace [][]int, offset int) []diffOp {
+	var ops []diffOp
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{' ', a[x]})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				ops = append(ops, diffOp{'+', b[y]})
+			} else {
+				x--
+				ops = append(ops, diffOp{'-', a[x]})
+			}
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
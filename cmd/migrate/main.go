@@ -1,72 +1,111 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
+	"io/fs"
 	"log"
 	"os"
-	"time"
 
 	"github.com/bootjp/cloudflare-gslb/config"
 )
 
-type migrateOrigin struct {
-	Name             string                 `json:"name"`
-	ZoneName         string                 `json:"zone_name,omitempty"`
-	RecordType       string                 `json:"record_type"`
-	HealthCheck      config.HealthCheck     `json:"health_check"`
-	PriorityLevels   []config.PriorityLevel `json:"priority_levels,omitempty"`
-	Proxied          bool                   `json:"proxied"`
-	ReturnToPriority bool                   `json:"return_to_priority"`
-}
-
-type migrateConfig struct {
-	CloudflareAPIToken string                      `json:"cloudflare_api_token"`
-	CloudflareZoneIDs  []config.ZoneConfig         `json:"cloudflare_zones"`
-	CheckInterval      int                         `json:"check_interval_seconds"`
-	Origins            []migrateOrigin             `json:"origins"`
-	Notifications      []config.NotificationConfig `json:"notifications,omitempty"`
-}
-
 func main() {
 	configPath := flag.String("config", "config.json", "Path to configuration file")
-	outPath := flag.String("out", "config.migrated.json", "Path to write migrated config")
+	outPath := flag.String("out", "", "Path to write migrated config (default: config.migrated.json or config.migrated.yaml)")
+	format := flag.String("format", "", "Output format, json or yaml (default: from the -out extension, or the format of -config)")
+	inPlace := flag.Bool("in-place", false, "Overwrite -config, keeping the original as <config>.bak")
 	flag.Parse()
 
-	cfg, err := config.LoadConfig(*configPath)
+	data, err := os.ReadFile(*configPath)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		log.Fatalf("Failed to read config: %v", err)
 	}
-
-	origins := make([]migrateOrigin, 0, len(cfg.Origins))
-	for _, origin := range cfg.Origins {
-		origins = append(origins, migrateOrigin{
-			Name:             origin.Name,
-			ZoneName:         origin.ZoneName,
-			RecordType:       origin.RecordType,
-			HealthCheck:      origin.HealthCheck,
-			PriorityLevels:   origin.EffectivePriorityLevels(),
-			Proxied:          origin.Proxied,
-			ReturnToPriority: origin.ReturnToPriority,
-		})
+	doc, err := config.ParseDocument(*configPath, data)
+	if err != nil {
+		log.Fatalf("Failed to parse config: %v", err)
 	}
 
-	out := migrateConfig{
-		CloudflareAPIToken: cfg.CloudflareAPIToken,
-		CloudflareZoneIDs:  cfg.CloudflareZoneIDs,
-		CheckInterval:      int(cfg.CheckInterval / time.Second),
-		Origins:            origins,
-		Notifications:      cfg.Notifications,
+	target := *outPath
+	if *inPlace {
+		if target != "" {
+			log.Fatal("-in-place and -out cannot be used together")
+		}
+		target = *configPath
+	}
+	outFormat := *format
+	if outFormat == "" {
+		outFormat = config.FormatOf(*configPath)
+		if target != "" {
+			outFormat = config.FormatOf(target)
+		}
+		if outFormat == config.FormatTOML {
+			outFormat = config.FormatJSON
+		}
+	}
+	if target == "" {
+		ext := ".json"
+		if outFormat == config.FormatYAML {
+			ext = ".yaml"
+		}
+		target = "config.migrated" + ext
+	}
+	if *inPlace && outFormat != config.FormatOf(*configPath) {
+		log.Fatalf("-in-place cannot write %s to %s; use -out instead", outFormat, *configPath)
 	}
 
-	data, err := json.MarshalIndent(out, "", "  ")
+	// Render the original in the output format too, so that the diff only shows the migration
+	before, err := config.FormatDocument(doc, outFormat)
+	if err != nil {
+		log.Fatalf("Failed to format config: %v", err)
+	}
+	changes, err := config.MigrateDocument(doc)
+	if err != nil {
+		log.Fatalf("Failed to migrate config: %v", err)
+	}
+	after, err := config.FormatDocument(doc, outFormat)
 	if err != nil {
-		log.Fatalf("Failed to marshal migrated config: %v", err)
+		log.Fatalf("Failed to format migrated config: %v", err)
+	}
+
+	if len(changes) == 0 && *inPlace {
+		log.Printf("%s is already up to date", *configPath)
+		return
+	}
+	for _, change := range changes {
+		log.Printf("Migrated: %s", change)
+	}
+	if len(changes) > 0 {
+		writeDiff(os.Stdout, *configPath, target, before, after)
 	}
 
-	if err := os.WriteFile(*outPath, data, 0o600); err != nil {
+	if *inPlace {
+		backup := *configPath + ".bak"
+		if err := writeNewFile(backup, data); err != nil {
+			log.Fatalf("Failed to write backup: %v", err)
+		}
+		log.Printf("Original config saved to %s", backup)
+	}
+	if err := os.WriteFile(target, after, 0o600); err != nil {
 		log.Fatalf("Failed to write migrated config: %v", err)
 	}
 
-	log.Printf("Migrated config written to %s", *outPath)
+	log.Printf("Migrated config written to %s", target)
+}
+
+// writeNewFile writes data to path, refusing to replace an existing file.
+func writeNewFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%s already exists; move it away to migrate again", path)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
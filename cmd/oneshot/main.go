@@ -3,32 +3,306 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
+	"io"
 	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/bootjp/cloudflare-gslb/config"
 	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/logfile"
+	"github.com/bootjp/cloudflare-gslb/pkg/metrics"
+	"github.com/bootjp/cloudflare-gslb/pkg/remoteconfig"
+	"github.com/bootjp/cloudflare-gslb/pkg/sentry"
+	"github.com/bootjp/cloudflare-gslb/pkg/syslog"
+	"github.com/bootjp/cloudflare-gslb/pkg/tracing"
 )
 
+// switchFlags collects repeated -switch origin=set arguments
+type switchFlags []string
+
+func (f *switchFlags) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *switchFlags) Set(value string) error {
+	if _, _, ok := strings.Cut(value, "="); !ok {
+		return fmt.Errorf("expected origin=set, got %q", value)
+	}
+	*f = append(*f, value)
+	return nil
+}
+
 func main() {
-	configPath := flag.String("config", "config.json", "Path to configuration file")
+	configPath := flag.String("config", "config.json", "Path or https:// or s3:// URL of the configuration file")
+	var switches switchFlags
+	flag.Var(&switches, "switch", "Switch an origin to a named IP set (origin=set), can be repeated")
+	exportPath := flag.String("export", "", "Write the expected DNS state of all origins to this JSON or YAML file")
+	importPath := flag.String("import", "", "Apply the DNS state from this JSON or YAML snapshot file")
+	printSchema := flag.Bool("schema", false, "Print the JSON Schema of the configuration file and exit")
 	flag.Parse()
 
-	cfg, err := config.LoadConfig(*configPath)
+	if *printSchema {
+		schema, err := config.Schema()
+		if err != nil {
+			log.Fatalf("Failed to generate schema: %v", err)
+		}
+		if _, err := os.Stdout.Write(schema); err != nil {
+			log.Fatalf("Failed to write schema: %v", err)
+		}
+		return
+	}
+
+	cfg, err := loadConfig(*configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	defer setupLogging(cfg)()
+	for _, warning := range cfg.Warnings {
+		log.Printf("Warning: %s", warning)
+	}
+	defer setupTelemetry(cfg)()
 
 	service, err := gslb.NewService(cfg)
 	if err != nil {
 		log.Fatalf("Failed to create GSLB service: %v", err)
 	}
 
-	log.Println("Running one-shot health check...")
 	ctx := context.Background()
 
+	if len(switches) > 0 {
+		for _, sw := range switches {
+			originName, setName, _ := strings.Cut(sw, "=")
+			if err := service.SwitchIPSet(ctx, originName, setName); err != nil {
+				log.Fatalf("Failed to switch %s to IP set %s: %v", originName, setName, err)
+			}
+			log.Printf("Switched %s to IP set %s", originName, setName)
+		}
+		return
+	}
+
+	if *importPath != "" {
+		if err := importSnapshot(ctx, service, *importPath); err != nil {
+			log.Fatalf("Failed to import snapshot: %v", err)
+		}
+		log.Printf("Applied snapshot %s", *importPath)
+		return
+	}
+
+	if *exportPath != "" {
+		if err := exportSnapshot(ctx, service, *exportPath); err != nil {
+			log.Fatalf("Failed to export snapshot: %v", err)
+		}
+		log.Printf("Wrote snapshot to %s", *exportPath)
+		return
+	}
+
+	log.Println("Running one-shot health check...")
+
 	if err := service.RunOneShot(ctx); err != nil {
 		log.Fatalf("Health check failed: %v", err)
 	}
 
+	printStatuses(service)
 	log.Println("One-shot health check completed successfully")
 }
+
+// setupLogging redirects the log to the configured file and syslog and
+// returns a function that closes them.
+func setupLogging(cfg *config.Config) func() {
+	if !cfg.Log.Enabled() {
+		return func() {}
+	}
+	var writers []io.Writer
+	var closers []func() error
+	if cfg.Log.File != "" {
+		writer, err := logfile.Open(logfile.Config{
+			Path:       cfg.Log.File,
+			MaxSize:    int64(cfg.Log.EffectiveMaxSizeMB()) << 20,
+			MaxAge:     cfg.Log.MaxAgeSeconds.Duration(),
+			MaxBackups: cfg.Log.EffectiveMaxBackups(),
+			Compress:   cfg.Log.Compress,
+		})
+		if err != nil {
+			log.Fatalf("Failed to open log file: %v", err)
+		}
+		log.Printf("Logging to %s", cfg.Log.File)
+		writers = append(writers, writer)
+		closers = append(closers, writer.Close)
+	}
+	if cfg.Log.Syslog != nil {
+		// The facility was validated with the config
+		facility, _ := syslog.ParseFacility(cfg.Log.Syslog.EffectiveFacility())
+		writer, err := syslog.Dial(syslog.Config{
+			Network:  cfg.Log.Syslog.EffectiveNetwork(),
+			Address:  cfg.Log.Syslog.Address,
+			Facility: facility,
+			AppName:  cfg.Log.Syslog.EffectiveAppName(),
+		})
+		if err != nil {
+			log.Fatalf("Failed to set up syslog: %v", err)
+		}
+		log.Printf("Logging to syslog (%s)", cfg.Log.Syslog.EffectiveFacility())
+		// The file comes first, so that it gets every line even while syslog is unreachable
+		writers = append(writers, writer)
+		closers = append(closers, writer.Close)
+	}
+	log.SetOutput(io.MultiWriter(writers...))
+	return func() {
+		log.SetOutput(os.Stderr)
+		for _, closeFn := range closers {
+			if err := closeFn(); err != nil {
+				log.Printf("Failed to close log output: %v", err)
+			}
+		}
+	}
+}
+
+// setupTelemetry starts exporting traces and metrics when the config enables
+// them and returns a function that sends what is still queued.
+func setupTelemetry(cfg *config.Config) func() {
+	var shutdowns []func(context.Context) error
+	if cfg.Tracing.Enabled() {
+		shutdown, err := tracing.Setup(tracing.Config{
+			Endpoint:    cfg.Tracing.EffectiveEndpoint(),
+			ServiceName: cfg.Tracing.EffectiveServiceName(),
+			Headers:     cfg.Tracing.Headers,
+		})
+		if err != nil {
+			log.Fatalf("Failed to set up tracing: %v", err)
+		}
+		log.Printf("Exporting traces to %s", cfg.Tracing.EffectiveEndpoint())
+		shutdowns = append(shutdowns, shutdown)
+	}
+	if cfg.Metrics.Enabled() {
+		shutdowns = append(shutdowns, setupMetrics(cfg.Metrics))
+	}
+	if cfg.ErrorReporting.Enabled() {
+		shutdown, err := sentry.Setup(sentry.Config{
+			DSN:            cfg.ErrorReporting.EffectiveDSN(),
+			Environment:    cfg.ErrorReporting.Environment,
+			Release:        cfg.ErrorReporting.Release,
+			RepeatInterval: time.Duration(cfg.ErrorReporting.EffectiveRepeatInterval()),
+		})
+		if err != nil {
+			log.Fatalf("Failed to set up error reporting: %v", err)
+		}
+		log.Println("Reporting errors to Sentry")
+		shutdowns = append(shutdowns, shutdown)
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		for _, shutdown := range shutdowns {
+			if err := shutdown(ctx); err != nil {
+				log.Printf("Failed to export telemetry: %v", err)
+			}
+		}
+	}
+}
+
+// setupMetrics starts the configured metrics exporter.
+func setupMetrics(cfg *config.MetricsConfig) func(context.Context) error {
+	var shutdown func(context.Context) error
+	var err error
+	switch cfg.EffectiveExporter() {
+	case config.MetricsExporterStatsD:
+		shutdown, err = metrics.SetupStatsD(metrics.StatsDConfig{
+			Address: cfg.EffectiveAddress(),
+			Prefix:  cfg.Prefix,
+			Tags:    cfg.Tags,
+		})
+		if err == nil {
+			log.Printf("Sending metrics to StatsD at %s", cfg.EffectiveAddress())
+		}
+	default:
+		shutdown, err = metrics.Setup(metrics.Config{
+			Endpoint:    cfg.EffectiveEndpoint(),
+			ServiceName: cfg.EffectiveServiceName(),
+			Headers:     cfg.Headers,
+			Interval:    cfg.EffectiveInterval().Duration(),
+		})
+		if err == nil {
+			log.Printf("Exporting metrics to %s every %s", cfg.EffectiveEndpoint(), cfg.EffectiveInterval().Duration())
+		}
+	}
+	if err != nil {
+		log.Fatalf("Failed to set up metrics: %v", err)
+	}
+	return shutdown
+}
+
+// loadConfig reads the config from a file or from an https:// or s3:// URL
+func loadConfig(location string) (*config.Config, error) {
+	if !remoteconfig.IsRemote(location) {
+		return config.LoadConfig(location)
+	}
+	ctx := context.Background()
+	fetcher, err := remoteconfig.NewFetcher(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := fetcher.Load(ctx)
+	return cfg, err
+}
+
+func printStatuses(service *gslb.Service) {
+	statuses := service.OriginStatuses()
+	keys := make([]string, 0, len(statuses))
+	for key := range statuses {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		status := statuses[key]
+		log.Printf("%s: priority %d, IPs %v", key, status.CurrentPriority, status.CurrentIPs)
+
+		ips := make([]string, 0, len(status.Scores))
+		for ip := range status.Scores {
+			ips = append(ips, ip)
+		}
+		sort.Strings(ips)
+		for _, ip := range ips {
+			log.Printf("  %s health score %.1f", ip, status.Scores[ip])
+		}
+	}
+}
+
+// snapshotFormat picks the snapshot format from the file extension, defaulting to JSON.
+func snapshotFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return gslb.SnapshotFormatYAML
+	default:
+		return gslb.SnapshotFormatJSON
+	}
+}
+
+func exportSnapshot(ctx context.Context, service *gslb.Service, path string) error {
+	snapshot, err := service.ExportSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := gslb.MarshalSnapshot(snapshot, snapshotFormat(path))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func importSnapshot(ctx context.Context, service *gslb.Service, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	snapshot, err := gslb.ParseSnapshot(data, snapshotFormat(path))
+	if err != nil {
+		return err
+	}
+	return service.ApplySnapshot(ctx, snapshot)
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidAccount is returned when a cloudflare_accounts entry has no account_id or is listed twice
+var ErrInvalidAccount = errors.New("invalid cloudflare account")
+
+// AccountConfig はCloudflareアカウントごとの認証情報を表す構造体
+type AccountConfig struct {
+	AccountID string `json:"account_id" yaml:"account_id"`                   // CloudflareのアカウントID
+	Name      string `json:"name,omitempty" yaml:"name,omitempty"`           // ログに表示するアカウント名
+	APIToken  string `json:"api_token,omitempty" yaml:"api_token,omitempty"` // このアカウントのゾーンで使用するAPIトークン
+	APIKey    string `json:"api_key,omitempty" yaml:"api_key,omitempty"`     // 互換用: Global API Key（api_emailと併用）
+	APIEmail  string `json:"api_email,omitempty" yaml:"api_email,omitempty"` // Global API Keyに対応するアカウントのメールアドレス
+}
+
+// DisplayName はログに表示するアカウント名を返す（省略時はアカウントID）
+func (a AccountConfig) DisplayName() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return a.AccountID
+}
+
+// credentials はアカウントに設定された認証情報を返す（未設定の場合はfalse）
+func (a AccountConfig) credentials() (APICredentials, bool) {
+	if a.APIToken != "" {
+		return APICredentials{APIToken: a.APIToken}, true
+	}
+	if a.APIKey != "" {
+		return APICredentials{APIKey: a.APIKey, APIEmail: a.APIEmail}, true
+	}
+	return APICredentials{}, false
+}
+
+// Account はアカウントIDに対応するアカウント設定を返す
+func (c *Config) Account(accountID string) (AccountConfig, bool) {
+	if accountID == "" {
+		return AccountConfig{}, false
+	}
+	for _, account := range c.Accounts {
+		if account.AccountID == accountID {
+			return account, true
+		}
+	}
+	return AccountConfig{}, false
+}
+
+func validateAccounts(accounts []AccountConfig) error {
+	seen := make(map[string]struct{}, len(accounts))
+	for _, account := range accounts {
+		if account.AccountID == "" {
+			return fmt.Errorf("%w: account_id is required", ErrInvalidAccount)
+		}
+		if _, ok := seen[account.AccountID]; ok {
+			return fmt.Errorf("%w: account %s is listed more than once", ErrInvalidAccount, account.AccountID)
+		}
+		seen[account.AccountID] = struct{}{}
+		if (account.APIKey == "") != (account.APIEmail == "") {
+			return fmt.Errorf("%w: account %s", ErrIncompleteAPIKey, account.AccountID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// ErrInvalidAdminAPI is returned when admin_api has neither a token nor a client CA, an invalid token, a listen address that is neither host:port nor a socket, an invalid socket or an incomplete tls
+var ErrInvalidAdminAPI = errors.New("invalid admin_api config")
+
+// DefaultAdminAPIListen はlistenを省略したときの待ち受けアドレス
+const DefaultAdminAPIListen = "127.0.0.1:8082"
+
+// AdminAPIConfig はオリジンの一時停止、強制切替、即時チェック、設定の再読み込み、オリジンと通知の追加と削除を行う管理用HTTP APIの設定を表す構造体
+type AdminAPIConfig struct {
+	Listen      string           `json:"listen,omitempty" yaml:"listen,omitempty"`             // 待ち受けアドレス（省略時は "127.0.0.1:8082"、"unix:/run/gslb/admin.sock" でUnixドメインソケット）
+	Socket      string           `json:"socket,omitempty" yaml:"socket,omitempty"`             // listenに加えて待ち受けるUnixドメインソケットのパス
+	SocketMode  string           `json:"socket_mode,omitempty" yaml:"socket_mode,omitempty"`   // ソケットのパーミッション（8進数、省略時は "0600"）
+	Token       string           `json:"token,omitempty" yaml:"token,omitempty"`               // Authorization: Bearerで要求するトークン（operatorの役割を持つ。tokensかtls.client_ca_fileを指定した場合は省略可、証明書と両方指定すると両方を要求する）
+	Tokens      []APITokenConfig `json:"tokens,omitempty" yaml:"tokens,omitempty"`             // 役割を持つ追加のトークン（tokenはoperatorの役割を持つ）
+	TLS         *ServerTLSConfig `json:"tls,omitempty" yaml:"tls,omitempty"`                   // HTTPSで待ち受ける場合の証明書とクライアント証明書のCA
+	RuntimeFile string           `json:"runtime_file,omitempty" yaml:"runtime_file,omitempty"` // APIで追加したオリジンと通知を保存するJSONファイル（設定ファイルのディレクトリからの相対パス、省略時は追加と削除を行わない）
+}
+
+// Enabled は管理用APIが有効かどうかを返す
+func (c *AdminAPIConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveListen は待ち受けアドレスを返す
+func (c *AdminAPIConfig) EffectiveListen() string {
+	if c == nil || c.Listen == "" {
+		return DefaultAdminAPIListen
+	}
+	return c.Listen
+}
+
+// Addresses はlistenとsocketの待ち受けアドレスを返す
+func (c *AdminAPIConfig) Addresses() []string {
+	return listenAddresses(c.EffectiveListen(), c.Socket)
+}
+
+// EffectiveSocketMode はソケットのパーミッションを返す
+func (c *AdminAPIConfig) EffectiveSocketMode() fs.FileMode {
+	return socketMode(c.SocketMode)
+}
+
+func validateAdminAPI(c *AdminAPIConfig) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.Token == "" && len(c.Tokens) == 0 && !c.TLS.MutualTLS() {
+		return fmt.Errorf("%w: token, tokens or tls.client_ca_file is required", ErrInvalidAdminAPI)
+	}
+	if err := validateAPITokens(c.Token, c.Tokens); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAdminAPI, err)
+	}
+	if err := validateServerTLS(c.TLS); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAdminAPI, err)
+	}
+	if err := validateListen(c.EffectiveListen(), c.Socket, c.SocketMode); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAdminAPI, err)
+	}
+	return nil
+}
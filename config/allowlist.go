@@ -0,0 +1,50 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// ErrInvalidCIDR is returned when an allowed_cidrs entry is neither a CIDR nor an IP address
+var ErrInvalidCIDR = errors.New("invalid CIDR in allowed_cidrs")
+
+// ParseAllowlist はallowed_cidrsを解析する。CIDRのほか、単一のIPアドレスも指定できる
+func ParseAllowlist(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidCIDR, cidr)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// ZoneAllowedCIDRs はゾーンのレコードに書き込めるアドレスの範囲を返す
+// ゾーンにallowed_cidrsがあればそれを、なければ全体の設定を使う（空の場合は制限なし）
+func (c *Config) ZoneAllowedCIDRs(zoneName string) []string {
+	for _, zone := range c.CloudflareZoneIDs {
+		if zone.Name == zoneName && len(zone.AllowedCIDRs) > 0 {
+			return zone.AllowedCIDRs
+		}
+	}
+	return c.AllowedCIDRs
+}
+
+func validateAllowlists(c *Config) error {
+	if _, err := ParseAllowlist(c.AllowedCIDRs); err != nil {
+		return err
+	}
+	for _, zone := range c.CloudflareZoneIDs {
+		if _, err := ParseAllowlist(zone.AllowedCIDRs); err != nil {
+			return fmt.Errorf("zone %s: %w", zone.Name, err)
+		}
+	}
+	return nil
+}
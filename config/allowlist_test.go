@@ -0,0 +1,60 @@
+package config
+
+import (
+	"errors"
+	"net/netip"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseAllowlist(t *testing.T) {
+	prefixes, err := ParseAllowlist([]string{"192.0.2.7/24", "198.51.100.1", "2001:db8::/32"})
+	if err != nil {
+		t.Fatalf("ParseAllowlist: %v", err)
+	}
+	want := []netip.Prefix{
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.51.100.1/32"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}
+	if !reflect.DeepEqual(prefixes, want) {
+		t.Errorf("ParseAllowlist = %v, want %v", prefixes, want)
+	}
+
+	if _, err := ParseAllowlist([]string{"192.0.2.0/33"}); !errors.Is(err, ErrInvalidCIDR) {
+		t.Errorf("Expected ErrInvalidCIDR, got %v", err)
+	}
+}
+
+func TestConfig_ZoneAllowedCIDRs(t *testing.T) {
+	c := &Config{
+		AllowedCIDRs: []string{"192.0.2.0/24"},
+		CloudflareZoneIDs: []ZoneConfig{
+			{Name: "example.com"},
+			{Name: "example.net", AllowedCIDRs: []string{"198.51.100.0/24"}},
+		},
+	}
+	if got := c.ZoneAllowedCIDRs("example.com"); !reflect.DeepEqual(got, c.AllowedCIDRs) {
+		t.Errorf("example.com: got %v, want the global list", got)
+	}
+	if got := c.ZoneAllowedCIDRs("example.net"); !reflect.DeepEqual(got, []string{"198.51.100.0/24"}) {
+		t.Errorf("example.net: got %v, want the zone's list", got)
+	}
+}
+
+func TestLoadConfig_InvalidAllowedCIDRs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, path, `
+cloudflare_api_token: token
+allowed_cidrs: ["192.0.2.0/24"]
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+    allowed_cidrs: ["198.51.100.0/24", "backup.example.net"]
+origins: []
+`)
+	if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidCIDR) {
+		t.Errorf("Expected ErrInvalidCIDR, got %v", err)
+	}
+}
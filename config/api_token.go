@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/apiauth"
+)
+
+// APITokenConfig は役割を持つ管理用APIのトークンを表す構造体
+type APITokenConfig struct {
+	Name  string `json:"name,omitempty" yaml:"name,omitempty"` // トークンの名前（byを省略した変更の操作者として記録する）
+	Token string `json:"token" yaml:"token"`                   // Authorization: Bearerで送るトークン
+	Role  string `json:"role" yaml:"role"`                     // "viewer"（オリジンとイベントの参照のみ）、"operator"（変更と再読み込みも可能）または"prober"（gRPC APIでのリモートのプローバーの登録と結果の報告のみ）
+}
+
+// EffectiveRole はトークンの役割を返す
+func (c APITokenConfig) EffectiveRole() apiauth.Role {
+	return apiauth.Role(c.Role)
+}
+
+// validateAPITokens はtokensを検証する。tokenと同じトークンは役割が曖昧になるため許可しない
+func validateAPITokens(token string, tokens []APITokenConfig) error {
+	seen := map[string]bool{token: token != ""}
+	for i, t := range tokens {
+		if t.Token == "" {
+			return fmt.Errorf("tokens[%d]: token is required", i)
+		}
+		if _, err := apiauth.ParseRole(t.Role); err != nil {
+			return fmt.Errorf("tokens[%d]: %v", i, err)
+		}
+		if seen[t.Token] {
+			return fmt.Errorf("tokens[%d]: the token is used more than once", i)
+		}
+		seen[t.Token] = true
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrInvalidAudit is returned when audit is configured without any sink
+var ErrInvalidAudit = errors.New("invalid audit config")
+
+// AuditConfig はDNSプロバイダのAPI呼び出しを監査ログとして記録する設定を表す構造体
+type AuditConfig struct {
+	File          string `json:"file,omitempty" yaml:"file,omitempty"`                     // 追記専用のJSON Linesファイルのパス
+	WebhookURL    string `json:"webhook_url,omitempty" yaml:"webhook_url,omitempty"`       // 監査イベントを送信するWebhookのURL
+	Actor         string `json:"actor,omitempty" yaml:"actor,omitempty"`                   // イベントに記録する実行者名（省略時はホスト名）
+	MutationsFile string `json:"mutations_file,omitempty" yaml:"mutations_file,omitempty"` // DNSレコードの変更のみを記録する追記専用のJSON Linesファイルのパス
+}
+
+// Enabled は監査ログが有効かどうかを返す
+func (c *AuditConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveActor はイベントに記録する実行者名を返す
+func (c *AuditConfig) EffectiveActor() string {
+	if c != nil && c.Actor != "" {
+		return c.Actor
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "cloudflare-gslb"
+	}
+	return hostname
+}
+
+func validateAuditConfig(c *AuditConfig) error {
+	if c.Enabled() && c.File == "" && c.WebhookURL == "" && c.MutationsFile == "" {
+		return fmt.Errorf("%w: file, webhook_url or mutations_file is required", ErrInvalidAudit)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidCloudflareHealthCheck is returned when cloudflare_health_check has an unknown mode
+var ErrInvalidCloudflareHealthCheck = errors.New("invalid cloudflare_health_check")
+
+// Cloudflare Health Checkの結果とローカルのヘルスチェックの組み合わせ方
+const (
+	CloudflareHealthModeAll  = "all"  // 両方が正常な場合のみ正常（デフォルト）
+	CloudflareHealthModeAny  = "any"  // どちらかが正常なら正常
+	CloudflareHealthModeOnly = "only" // Cloudflareの結果のみを使用（結果がないIPはローカルのヘルスチェックを使用）
+)
+
+// CloudflareHealthCheckConfig はCloudflareのスタンドアロンHealth Checkの結果をヘルスシグナルとして取り込む設定を表す構造体
+type CloudflareHealthCheckConfig struct {
+	Mode string            `json:"mode,omitempty" yaml:"mode,omitempty"` // "all"（デフォルト）、"any"、"only"
+	IDs  map[string]string `json:"ids,omitempty" yaml:"ids,omitempty"`   // IPごとのHealth Check ID（省略時はaddressがIPと一致するものを使用）
+}
+
+// Enabled はCloudflare Health Checkの取り込みが有効かどうかを返す
+func (c *CloudflareHealthCheckConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveMode は組み合わせ方を返す（省略時は "all"）
+func (c *CloudflareHealthCheckConfig) EffectiveMode() string {
+	if c == nil || c.Mode == "" {
+		return CloudflareHealthModeAll
+	}
+	return c.Mode
+}
+
+func validateCloudflareHealthCheck(c *CloudflareHealthCheckConfig) error {
+	switch c.EffectiveMode() {
+	case CloudflareHealthModeAll, CloudflareHealthModeAny, CloudflareHealthModeOnly:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown mode %q", ErrInvalidCloudflareHealthCheck, c.Mode)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -20,6 +21,7 @@ const (
 	extYAML fileExt = ".yaml"
 	extYML  fileExt = ".yml"
 	extJSON fileExt = ".json"
+	extTOML fileExt = ".toml"
 )
 
 // Default config file names
@@ -27,6 +29,7 @@ const (
 	configFileYAML = "config" + string(extYAML)
 	configFileYML  = "config" + string(extYML)
 	configFileJSON = "config" + string(extJSON)
+	configFileTOML = "config" + string(extTOML)
 )
 
 // Error definitions
@@ -39,34 +42,459 @@ var (
 	ErrParseYAML = errors.New("failed to parse YAML")
 	// ErrParseJSON is returned when JSON parsing fails
 	ErrParseJSON = errors.New("failed to parse JSON")
+	// ErrUnsupportedOriginMode is returned when an unknown origin mode is specified
+	ErrUnsupportedOriginMode = errors.New("unsupported origin mode")
+	// ErrUnsupportedVerifyMethod is returned when an unknown verify method is specified
+	ErrUnsupportedVerifyMethod = errors.New("unsupported verify method")
+	// ErrInvalidMinHealthy is returned when min_healthy is negative
+	ErrInvalidMinHealthy = errors.New("min_healthy must not be negative")
+	// ErrUnknownIPSet is returned when an IP set name is not defined for the origin
+	ErrUnknownIPSet = errors.New("unknown IP set")
+	// ErrIPSetsWithPriorityLevels is returned when ip_sets are combined with priority_levels
+	ErrIPSetsWithPriorityLevels = errors.New("ip_sets cannot be combined with priority_levels or legacy failover IPs")
+	// ErrInvalidScoring is returned when the health scoring configuration is out of range
+	ErrInvalidScoring = errors.New("invalid health scoring config")
+	// ErrIncompleteAPIKey is returned when only one of cloudflare_api_key and cloudflare_api_email is set
+	ErrIncompleteAPIKey = errors.New("cloudflare_api_key and cloudflare_api_email must be set together")
+	// ErrIncompleteAWSCredentials is returned when only one of aws_access_key_id and aws_secret_access_key is set
+	ErrIncompleteAWSCredentials = errors.New("aws_access_key_id and aws_secret_access_key must be set together")
 )
 
 // Config はアプリケーションの設定を表す構造体
 type Config struct {
-	CloudflareAPIToken string               `json:"cloudflare_api_token" yaml:"cloudflare_api_token"`
-	CloudflareZoneIDs  []ZoneConfig         `json:"cloudflare_zones" yaml:"cloudflare_zones"`
-	CheckInterval      time.Duration        `json:"check_interval_seconds" yaml:"check_interval_seconds"`
-	Origins            []OriginConfig       `json:"origins" yaml:"origins"`
-	Notifications      []NotificationConfig `json:"notifications" yaml:"notifications"` // 通知設定
+	CloudflareAPIToken string                    `json:"cloudflare_api_token" yaml:"cloudflare_api_token"`
+	APITokenFile       string                    `json:"cloudflare_api_token_file" yaml:"cloudflare_api_token_file"`
+	CloudflareAPIKey   string                    `json:"cloudflare_api_key" yaml:"cloudflare_api_key"`     // 互換用: Global API Key（cloudflare_api_emailと併用）
+	CloudflareAPIEmail string                    `json:"cloudflare_api_email" yaml:"cloudflare_api_email"` // Global API Keyに対応するアカウントのメールアドレス
+	CloudflareZoneIDs  []ZoneConfig              `json:"cloudflare_zones" yaml:"cloudflare_zones"`
+	Accounts           []AccountConfig           `json:"cloudflare_accounts" yaml:"cloudflare_accounts"` // アカウントごとの認証情報
+	CheckInterval      time.Duration             `json:"check_interval_seconds" yaml:"check_interval_seconds"`
+	Origins            []OriginConfig            `json:"origins" yaml:"origins"`
+	Notifications      []NotificationConfig      `json:"notifications" yaml:"notifications"`               // 通知設定
+	ChangeLimit        ChangeLimitConfig         `json:"change_limit" yaml:"change_limit"`                 // 全体のDNS変更回数の上限
+	Flapping           *FlappingConfig           `json:"flapping,omitempty" yaml:"flapping,omitempty"`     // DNSの切替を繰り返すオリジンの検出（オリジン単位の設定で上書き可能）
+	APIRetry           APIRetryConfig            `json:"api_retry" yaml:"api_retry"`                       // Cloudflare APIの一時的なエラーのリトライ設定
+	APIRateLimit       APIRateLimitConfig        `json:"api_rate_limit" yaml:"api_rate_limit"`             // 全DNSクライアントで共有するAPIリクエスト数の上限
+	APITimeout         time.Duration             `json:"api_timeout_seconds" yaml:"api_timeout_seconds"`   // Cloudflare APIリクエスト1回あたりのタイムアウト（0はデフォルト）
+	RecordTags         bool                      `json:"record_tags" yaml:"record_tags"`                   // 作成するレコードにタグを付与するかどうか（有料プランのみ）
+	RecordCacheTTL     time.Duration             `json:"record_cache_seconds" yaml:"record_cache_seconds"` // DNSレコード一覧のキャッシュ時間（0は無効）
+	ProviderPlugins    []string                  `json:"provider_plugins" yaml:"provider_plugins"`         // 起動時に読み込むDNSプロバイダのGoプラグイン
+	StateStore         *StateStoreConfig         `json:"state_store" yaml:"state_store"`                   // インスタンス間で状態を共有するストア
+	Audit              *AuditConfig              `json:"audit" yaml:"audit"`                               // API呼び出しの監査ログ
+	SkipTokenCheck     bool                      `json:"skip_token_check" yaml:"skip_token_check"`         // 起動時のAPIトークン権限の確認を省略するかどうか
+	ConfigPollInterval time.Duration             `json:"config_poll_seconds" yaml:"config_poll_seconds"`   // リモートの設定を確認する間隔（0はデフォルト）
+	OriginsKV          *OriginsKVConfig          `json:"origins_kv" yaml:"origins_kv"`                     // オリジンを読み込むConsul KVまたはetcdの設定
+	OriginsKVIndex     uint64                    `json:"-" yaml:"-"`                                       // オリジンを読み込んだ時点のKVストアのインデックス
+	OriginsKubernetes  *OriginsKubernetesConfig  `json:"origins_kubernetes" yaml:"origins_kubernetes"`     // オリジンを読み込むGSLBOriginリソースの設定
+	KubernetesOrigins  *KubernetesOrigins        `json:"-" yaml:"-"`                                       // 読み込んだGSLBOriginリソース
+	KubernetesClusters []KubernetesClusterConfig `json:"kubernetes_clusters" yaml:"kubernetes_clusters"`   // フェイルオーバー先のServiceを読み込むクラスタ
+	Consul             *ConsulConfig             `json:"consul" yaml:"consul"`                             // フェイルオーバー先のサービスを読み込むConsulエージェント
+	DNSDiscovery       *DNSDiscoveryConfig       `json:"dns_discovery" yaml:"dns_discovery"`               // ipsのホスト名を解決する間隔とリゾルバ
+	Warnings           []string                  `json:"-" yaml:"-"`                                       // 読み込み時に古い形式の設定を書き換えた内容
+	AllowedCIDRs       []string                  `json:"allowed_cidrs" yaml:"allowed_cidrs"`               // レコードに書き込めるアドレスの範囲（空の場合は制限なし）
+	Tracing            *TracingConfig            `json:"tracing" yaml:"tracing"`                           // OpenTelemetryのトレースの送信先
+	Metrics            *MetricsConfig            `json:"metrics" yaml:"metrics"`                           // OTLPで送信するメトリクスの設定
+	StatusAPI          *StatusAPIConfig          `json:"status_api" yaml:"status_api"`                     // オリジンの状態を返すHTTP APIの設定
+	EventHistory       *EventHistoryConfig       `json:"event_history" yaml:"event_history"`               // ヘルス状態の変化とDNSの変更の記録先
+	Log                *LogConfig                `json:"log" yaml:"log"`                                   // ログの出力先（ファイルとsyslog）
+	ErrorReporting     *ErrorReportingConfig     `json:"error_reporting" yaml:"error_reporting"`           // panicと繰り返し発生するエラーの送信先
+	Summary            *SummaryConfig            `json:"summary" yaml:"summary"`                           // 通知先へ定期的に送るサマリー
+	Heartbeat          *HeartbeatConfig          `json:"heartbeat" yaml:"heartbeat"`                       // 外部の死活監視サービスへ送るping
+	Escalation         *EscalationConfig         `json:"escalation" yaml:"escalation"`                     // 正常なIPがなくなったオリジンの通知の再送
+	SlackActions       *SlackActionsConfig       `json:"slack_actions" yaml:"slack_actions"`               // Slackのボタンでオリジンの自動切替を止めるコールバック
+	AdminAPI           *AdminAPIConfig           `json:"admin_api" yaml:"admin_api"`                       // オリジンの操作と設定の再読み込みを行う管理用API
+	RuntimeFile        string                    `json:"-" yaml:"-"`                                       // 管理用APIで追加したオリジンと通知を保存するファイルのパス
+	GRPCAPI            *GRPCAPIConfig            `json:"grpc_api" yaml:"grpc_api"`                         // 管理用APIの操作とイベントの配信を行うgRPC API
 }
 
-// ZoneConfig はCloudflareゾーンの設定を表す構造体
+// ZoneConfig はDNSゾーンの設定を表す構造体
 type ZoneConfig struct {
-	ZoneID string `json:"zone_id" yaml:"zone_id"`
-	Name   string `json:"name" yaml:"name"`
+	ZoneID             string `json:"zone_id" yaml:"zone_id"` // CloudflareのゾーンID、またはRoute 53のホストゾーンID
+	Name               string `json:"name" yaml:"name"`
+	APIToken           string `json:"api_token,omitempty" yaml:"api_token,omitempty"`                         // このゾーン専用のスコープ付きAPIトークン
+	AccountID          string `json:"account_id,omitempty" yaml:"account_id,omitempty"`                       // ゾーンが属するCloudflareアカウントのID
+	Provider           string `json:"provider,omitempty" yaml:"provider,omitempty"`                           // DNSプロバイダ（"cloudflare"、"route53" または登録されたプロバイダ名、省略時は "cloudflare"）
+	AWSRegion          string `json:"aws_region,omitempty" yaml:"aws_region,omitempty"`                       // Route 53用のAWSリージョン（省略時は環境設定に従う）
+	AWSAccessKeyID     string `json:"aws_access_key_id,omitempty" yaml:"aws_access_key_id,omitempty"`         // Route 53用のアクセスキー（省略時はデフォルトの認証情報チェーン）
+	AWSSecretAccessKey string `json:"aws_secret_access_key,omitempty" yaml:"aws_secret_access_key,omitempty"` // Route 53用のシークレットアクセスキー
+	// AllowedCIDRs はこのゾーンのレコードに書き込めるアドレスの範囲（全体のallowed_cidrsより優先する）
+	AllowedCIDRs []string `json:"allowed_cidrs,omitempty" yaml:"allowed_cidrs,omitempty"`
+}
+
+// DNSプロバイダ名
+const (
+	ProviderCloudflare = "cloudflare"
+	ProviderRoute53    = "route53"
+)
+
+// EffectiveProvider はゾーンのDNSプロバイダ名を返す（省略時は "cloudflare"）
+func (z ZoneConfig) EffectiveProvider() string {
+	if z.Provider == "" {
+		return ProviderCloudflare
+	}
+	return z.Provider
+}
+
+// APICredentials はCloudflare APIの認証情報を表す構造体
+type APICredentials struct {
+	APIToken     string
+	APITokenFile string // APITokenの読み込み元ファイル（変更時に再読み込みする）
+	APIKey       string
+	APIEmail     string
+}
+
+// CredentialsForZone はゾーンで使用する認証情報を返す
+// 優先順位はゾーンのapi_token、ゾーンのaccount_idに対応するアカウントの認証情報、
+// cloudflare_api_token、cloudflare_api_key + cloudflare_api_emailの順
+func (c *Config) CredentialsForZone(zoneName string) APICredentials {
+	for _, zone := range c.CloudflareZoneIDs {
+		if zone.Name != zoneName {
+			continue
+		}
+		if zone.APIToken != "" {
+			return APICredentials{APIToken: zone.APIToken}
+		}
+		return c.CredentialsForAccount(zone.AccountID)
+	}
+	return c.CredentialsForAccount("")
+}
+
+// CredentialsForAccount はアカウントで使用する認証情報を返す
+// アカウントに認証情報が設定されていない場合はグローバルな認証情報を返す
+func (c *Config) CredentialsForAccount(accountID string) APICredentials {
+	if account, ok := c.Account(accountID); ok {
+		if credentials, ok := account.credentials(); ok {
+			return credentials
+		}
+	}
+	if c.CloudflareAPIToken != "" {
+		return APICredentials{APIToken: c.CloudflareAPIToken, APITokenFile: c.APITokenFile}
+	}
+	return APICredentials{APIKey: c.CloudflareAPIKey, APIEmail: c.CloudflareAPIEmail}
 }
 
 // OriginConfig はオリジンサーバーの設定を表す構造体
 type OriginConfig struct {
-	Name                string          `json:"name" yaml:"name"`
-	ZoneName            string          `json:"zone_name" yaml:"zone_name"`     // 対象のゾーン名
-	RecordType          string          `json:"record_type" yaml:"record_type"` // "A" または "AAAA"
-	HealthCheck         HealthCheck     `json:"health_check" yaml:"health_check"`
-	PriorityLevels      []PriorityLevel `json:"priority_levels,omitempty" yaml:"priority_levels,omitempty"`             // 優先度付きIPグループ（高い値ほど優先）
-	PriorityFailoverIPs []string        `json:"priority_failover_ips,omitempty" yaml:"priority_failover_ips,omitempty"` // 互換用: 優先的に使用するフェイルオーバー用のIPアドレスリスト
-	FailoverIPs         []string        `json:"failover_ips,omitempty" yaml:"failover_ips,omitempty"`                   // 互換用: フェイルオーバー用のIPアドレスリスト
-	Proxied             bool            `json:"proxied" yaml:"proxied"`                                                 // Cloudflareのプロキシを有効にするかどうか
-	ReturnToPriority    bool            `json:"return_to_priority" yaml:"return_to_priority"`                           // 正常に戻ったときに優先IPに戻すかどうか
+	Name                string                       `json:"name" yaml:"name"`
+	ZoneName            string                       `json:"zone_name" yaml:"zone_name"`     // 対象のゾーン名
+	RecordType          string                       `json:"record_type" yaml:"record_type"` // "A" または "AAAA"
+	HealthCheck         HealthCheck                  `json:"health_check" yaml:"health_check"`
+	PriorityLevels      []PriorityLevel              `json:"priority_levels,omitempty" yaml:"priority_levels,omitempty"`                 // 優先度付きIPグループ（高い値ほど優先）
+	PriorityFailoverIPs []string                     `json:"priority_failover_ips,omitempty" yaml:"priority_failover_ips,omitempty"`     // 互換用: 優先的に使用するフェイルオーバー用のIPアドレスリスト
+	FailoverIPs         []string                     `json:"failover_ips,omitempty" yaml:"failover_ips,omitempty"`                       // 互換用: フェイルオーバー用のIPアドレスリスト
+	Proxied             bool                         `json:"proxied" yaml:"proxied"`                                                     // Cloudflareのプロキシを有効にするかどうか
+	ReturnToPriority    bool                         `json:"return_to_priority" yaml:"return_to_priority"`                               // 正常に戻ったときに優先IPに戻すかどうか
+	ChangeLimit         *ChangeLimitConfig           `json:"change_limit,omitempty" yaml:"change_limit,omitempty"`                       // オリジン単位のDNS変更回数の上限
+	Mode                string                       `json:"mode,omitempty" yaml:"mode,omitempty"`                                       // "active"（デフォルト）または "observe"
+	Quarantine          *QuarantineConfig            `json:"quarantine,omitempty" yaml:"quarantine,omitempty"`                           // 昇格直後に失敗を繰り返すIPの隔離設定
+	Verify              *VerifyConfig                `json:"verify,omitempty" yaml:"verify,omitempty"`                                   // DNS更新後の反映確認設定
+	MinHealthy          int                          `json:"min_healthy,omitempty" yaml:"min_healthy,omitempty"`                         // 公開するIP数の下限（0は無効）
+	IPSets              map[string][]PriorityLevel   `json:"ip_sets,omitempty" yaml:"ip_sets,omitempty"`                                 // 名前付きIPセット（blue/greenなど）
+	ActiveSet           string                       `json:"active_set,omitempty" yaml:"active_set,omitempty"`                           // 既定で使用するIPセット名
+	Strategy            string                       `json:"strategy,omitempty" yaml:"strategy,omitempty"`                               // フェイルオーバー戦略（デフォルトは "priority"）
+	Weights             map[string]int               `json:"weights,omitempty" yaml:"weights,omitempty"`                                 // strategy=weightedの場合のIPごとの重み
+	Scoring             *ScoringConfig               `json:"scoring,omitempty" yaml:"scoring,omitempty"`                                 // 複数の指標から計算するヘルススコアの設定
+	Schedules           []ScheduleConfig             `json:"schedules,omitempty" yaml:"schedules,omitempty"`                             // 計画切替のスケジュール
+	CloudflareHealth    *CloudflareHealthCheckConfig `json:"cloudflare_health_check,omitempty" yaml:"cloudflare_health_check,omitempty"` // CloudflareのHealth Check結果の取り込み設定
+	RecordBinding       *RecordBindingConfig         `json:"record_binding,omitempty" yaml:"record_binding,omitempty"`                   // 管理対象のDNSレコードの限定
+	Spectrum            *SpectrumConfig              `json:"spectrum,omitempty" yaml:"spectrum,omitempty"`                               // 合わせて切り替えるSpectrumアプリケーション
+	LatencySLO          *LatencySLOConfig            `json:"latency_slo,omitempty" yaml:"latency_slo,omitempty"`                         // ヘルスチェックのレイテンシのSLO
+	Labels              map[string]string            `json:"labels,omitempty" yaml:"labels,omitempty"`                                   // 通知のテンプレートで使うラベル（ランブックのURLや資産IDなど）
+	Flapping            *FlappingConfig              `json:"flapping,omitempty" yaml:"flapping,omitempty"`                               // オリジン単位のフラッピングの検出設定
+}
+
+// 組み込みのフェイルオーバー戦略名
+const (
+	StrategyPriority     = "priority"
+	StrategyRoundRobin   = "round-robin"
+	StrategyRandom       = "random"
+	StrategyLeastLatency = "least-latency"
+	StrategyWeighted     = "weighted"
+)
+
+// HasIPSets は名前付きIPセットが設定されているかどうかを返す
+func (o OriginConfig) HasIPSets() bool {
+	return len(o.IPSets) > 0
+}
+
+// IPSetNames はIPセット名をソートして返す
+func (o OriginConfig) IPSetNames() []string {
+	names := make([]string, 0, len(o.IPSets))
+	for name := range o.IPSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultIPSet は既定で使用するIPセット名を返す（未指定の場合は名前順で最初のセット）
+func (o OriginConfig) DefaultIPSet() string {
+	if o.ActiveSet != "" {
+		return o.ActiveSet
+	}
+	names := o.IPSetNames()
+	if len(names) == 0 {
+		return ""
+	}
+	return names[0]
+}
+
+const (
+	// VerifyMethodAPI はCloudflare APIでレコードを再取得して反映を確認する
+	VerifyMethodAPI = "api"
+	// VerifyMethodDNS はDNSリゾルバで名前解決して反映を確認する
+	VerifyMethodDNS = "dns"
+
+	// DefaultVerifyAttempts はverifyのattempts省略時の確認回数
+	DefaultVerifyAttempts = 3
+	// DefaultVerifyInterval はverifyのinterval_seconds省略時の確認間隔
+	DefaultVerifyInterval = 2 * time.Second
+	// DefaultVerifyResolver はverifyのresolvers省略時に使用するリゾルバ
+	DefaultVerifyResolver = "1.1.1.1:53"
+)
+
+// VerifyConfig はDNS更新後に内容が反映されたかを確認する設定を表す構造体
+type VerifyConfig struct {
+	Method          string   `json:"method" yaml:"method"`                     // "api"（デフォルト）または "dns"
+	Resolvers       []string `json:"resolvers" yaml:"resolvers"`               // method=dnsの場合に使用するリゾルバ（host:port）
+	Attempts        int      `json:"attempts" yaml:"attempts"`                 // 確認回数
+	IntervalSeconds Seconds  `json:"interval_seconds" yaml:"interval_seconds"` // 確認間隔
+}
+
+// EffectiveMethod は確認方法を返す
+func (v *VerifyConfig) EffectiveMethod() string {
+	if v.Method == "" {
+		return VerifyMethodAPI
+	}
+	return v.Method
+}
+
+// EffectiveResolvers は使用するリゾルバを返す
+func (v *VerifyConfig) EffectiveResolvers() []string {
+	if len(v.Resolvers) == 0 {
+		return []string{DefaultVerifyResolver}
+	}
+	return v.Resolvers
+}
+
+// EffectiveAttempts は確認回数を返す
+func (v *VerifyConfig) EffectiveAttempts() int {
+	if v.Attempts <= 0 {
+		return DefaultVerifyAttempts
+	}
+	return v.Attempts
+}
+
+// Interval は確認間隔を返す
+func (v *VerifyConfig) Interval() time.Duration {
+	if v.IntervalSeconds <= 0 {
+		return DefaultVerifyInterval
+	}
+	return v.IntervalSeconds.Duration()
+}
+
+const (
+	// DefaultQuarantineFailures はquarantineのfailures省略時のしきい値
+	DefaultQuarantineFailures = 2
+	// DefaultQuarantineWindow はquarantineのwindow_seconds省略時のウィンドウ長
+	DefaultQuarantineWindow = 5 * time.Minute
+)
+
+// QuarantineConfig は昇格直後に失敗を繰り返すIPを一時的に除外する設定を表す構造体
+type QuarantineConfig struct {
+	Failures        int     `json:"failures" yaml:"failures"`                 // 隔離するまでの昇格直後の失敗回数
+	WindowSeconds   Seconds `json:"window_seconds" yaml:"window_seconds"`     // 昇格後この時間以内の失敗を「昇格直後の失敗」とみなす
+	DurationSeconds Seconds `json:"duration_seconds" yaml:"duration_seconds"` // 隔離期間
+}
+
+// Enabled は隔離が有効かどうかを返す
+func (q *QuarantineConfig) Enabled() bool {
+	return q != nil && q.DurationSeconds > 0
+}
+
+// FailureThreshold は隔離するまでの失敗回数を返す
+func (q *QuarantineConfig) FailureThreshold() int {
+	if q.Failures <= 0 {
+		return DefaultQuarantineFailures
+	}
+	return q.Failures
+}
+
+// Window は昇格直後とみなす期間を返す
+func (q *QuarantineConfig) Window() time.Duration {
+	if q.WindowSeconds <= 0 {
+		return DefaultQuarantineWindow
+	}
+	return q.WindowSeconds.Duration()
+}
+
+// Duration は隔離期間を返す
+func (q *QuarantineConfig) Duration() time.Duration {
+	return q.DurationSeconds.Duration()
+}
+
+const (
+	// DefaultScoringThreshold はscoringのthreshold省略時のしきい値
+	DefaultScoringThreshold = 60
+	// DefaultScoringWindow はscoringのwindow省略時に失敗率を計算するチェック回数
+	DefaultScoringWindow = 10
+	// DefaultScoringMaxLatency はscoringのmax_latency_ms省略時にレイテンシ評価が0になる値
+	DefaultScoringMaxLatency = time.Second
+
+	// デフォルトの各指標の重み
+	DefaultScoringCheckWeight   = 0.4
+	DefaultScoringFailureWeight = 0.4
+	DefaultScoringLatencyWeight = 0.2
+)
+
+// ScoringConfig はレイテンシ・直近の失敗率・チェック結果からIPごとのヘルススコア（0〜100）を計算する設定を表す構造体
+type ScoringConfig struct {
+	Threshold     float64      `json:"threshold" yaml:"threshold"`           // このスコアを下回るIPをunhealthyとみなす
+	Window        int          `json:"window" yaml:"window"`                 // 失敗率を計算する直近のチェック回数
+	MaxLatencyMs  Milliseconds `json:"max_latency_ms" yaml:"max_latency_ms"` // レイテンシ評価が0になるレイテンシ
+	CheckWeight   float64      `json:"check_weight" yaml:"check_weight"`     // 今回のチェック結果の重み
+	FailureWeight float64      `json:"failure_weight" yaml:"failure_weight"` // 直近の失敗率の重み
+	LatencyWeight float64      `json:"latency_weight" yaml:"latency_weight"` // レイテンシの重み
+}
+
+// Enabled はスコアリングが有効かどうかを返す
+func (c *ScoringConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveThreshold はunhealthyとみなすしきい値を返す
+func (c *ScoringConfig) EffectiveThreshold() float64 {
+	if c.Threshold <= 0 {
+		return DefaultScoringThreshold
+	}
+	return c.Threshold
+}
+
+// EffectiveWindow は失敗率を計算するチェック回数を返す
+func (c *ScoringConfig) EffectiveWindow() int {
+	if c.Window <= 0 {
+		return DefaultScoringWindow
+	}
+	return c.Window
+}
+
+// MaxLatency はレイテンシ評価が0になるレイテンシを返す
+func (c *ScoringConfig) MaxLatency() time.Duration {
+	if c.MaxLatencyMs <= 0 {
+		return DefaultScoringMaxLatency
+	}
+	return c.MaxLatencyMs.Duration()
+}
+
+// Weights はチェック結果・失敗率・レイテンシの重みを返す（すべて未指定の場合はデフォルト値）
+func (c *ScoringConfig) Weights() (check, failure, latency float64) {
+	if c.CheckWeight == 0 && c.FailureWeight == 0 && c.LatencyWeight == 0 {
+		return DefaultScoringCheckWeight, DefaultScoringFailureWeight, DefaultScoringLatencyWeight
+	}
+	return c.CheckWeight, c.FailureWeight, c.LatencyWeight
+}
+
+const (
+	// OriginModeActive はヘルスチェック結果に応じてDNSを更新するモード
+	OriginModeActive = "active"
+	// OriginModeObserve はヘルスチェックと通知のみ行い、DNSを更新しないモード
+	OriginModeObserve = "observe"
+)
+
+// IsObserveOnly はDNSを更新しない観測専用モードかどうかを返す
+func (o OriginConfig) IsObserveOnly() bool {
+	return o.Mode == OriginModeObserve
+}
+
+// DefaultChangeLimitWindow はchange_limitのwindow_seconds省略時のウィンドウ長
+const DefaultChangeLimitWindow = time.Hour
+
+// ChangeLimitConfig は一定時間内に許可するDNS変更回数の上限を表す構造体
+type ChangeLimitConfig struct {
+	MaxChanges    int     `json:"max_changes" yaml:"max_changes"`       // ウィンドウ内で許可する最大変更回数（0は無制限）
+	WindowSeconds Seconds `json:"window_seconds" yaml:"window_seconds"` // ウィンドウの長さ
+}
+
+// Enabled は上限が設定されているかどうかを返す
+func (c ChangeLimitConfig) Enabled() bool {
+	return c.MaxChanges > 0
+}
+
+// Window はウィンドウの長さを返す
+func (c ChangeLimitConfig) Window() time.Duration {
+	if c.WindowSeconds <= 0 {
+		return DefaultChangeLimitWindow
+	}
+	return c.WindowSeconds.Duration()
+}
+
+const (
+	// DefaultAPIRetryMaxAttempts はapi_retryのmax_attempts省略時の試行回数
+	DefaultAPIRetryMaxAttempts = 4
+	// DefaultAPIRetryBaseDelay はapi_retryのbase_delay_ms省略時の初回待機時間
+	DefaultAPIRetryBaseDelay = 500 * time.Millisecond
+	// DefaultAPIRetryMaxDelay はapi_retryのmax_delay_ms省略時の最大待機時間
+	DefaultAPIRetryMaxDelay = 30 * time.Second
+)
+
+// APIRetryConfig はCloudflare APIの429/5xxレスポンスをリトライする設定を表す構造体
+type APIRetryConfig struct {
+	MaxAttempts int          `json:"max_attempts" yaml:"max_attempts"`   // 最初のリクエストを含む試行回数（1でリトライなし）
+	BaseDelayMs Milliseconds `json:"base_delay_ms" yaml:"base_delay_ms"` // 指数バックオフの初回待機時間
+	MaxDelayMs  Milliseconds `json:"max_delay_ms" yaml:"max_delay_ms"`   // 待機時間の上限
+}
+
+// EffectiveMaxAttempts は試行回数を返す
+func (c APIRetryConfig) EffectiveMaxAttempts() int {
+	if c.MaxAttempts <= 0 {
+		return DefaultAPIRetryMaxAttempts
+	}
+	return c.MaxAttempts
+}
+
+// BaseDelay は初回待機時間を返す
+func (c APIRetryConfig) BaseDelay() time.Duration {
+	if c.BaseDelayMs <= 0 {
+		return DefaultAPIRetryBaseDelay
+	}
+	return c.BaseDelayMs.Duration()
+}
+
+// MaxDelay は待機時間の上限を返す
+func (c APIRetryConfig) MaxDelay() time.Duration {
+	if c.MaxDelayMs <= 0 {
+		return DefaultAPIRetryMaxDelay
+	}
+	return c.MaxDelayMs.Duration()
+}
+
+const (
+	// DefaultAPIRequestsPerSecond はapi_rate_limitのrequests_per_second省略時の値（Cloudflareの1200リクエスト/5分に相当）
+	DefaultAPIRequestsPerSecond = 4.0
+	// DefaultAPIRateLimitBurst はapi_rate_limitのburst省略時の値
+	DefaultAPIRateLimitBurst = 10
+)
+
+// APIRateLimitConfig は全DNSクライアントで共有するトークンバケットの設定を表す構造体
+type APIRateLimitConfig struct {
+	RequestsPerSecond float64 `json:"requests_per_second" yaml:"requests_per_second"` // 平均リクエスト数/秒（負の値で無効）
+	Burst             int     `json:"burst" yaml:"burst"`                             // 連続して送信できる最大リクエスト数
+}
+
+// EffectiveRequestsPerSecond は平均リクエスト数/秒を返す（0以下の場合は無制限）
+func (c APIRateLimitConfig) EffectiveRequestsPerSecond() float64 {
+	if c.RequestsPerSecond == 0 {
+		return DefaultAPIRequestsPerSecond
+	}
+	return c.RequestsPerSecond
+}
+
+// EffectiveBurst は連続して送信できる最大リクエスト数を返す
+func (c APIRateLimitConfig) EffectiveBurst() int {
+	if c.Burst <= 0 {
+		return DefaultAPIRateLimitBurst
+	}
+	return c.Burst
 }
 
 // PriorityLevel は優先度付きIPグループを表す構造体
@@ -91,6 +519,20 @@ func (o OriginConfig) EffectivePriorityLevels() []PriorityLevel {
 	return NormalizePriorityLevels(legacyPriorityLevels(o.PriorityFailoverIPs, o.FailoverIPs))
 }
 
+// Targets はオリジンのすべての優先度とIPセットのフェイルオーバー先を返す
+func (o OriginConfig) Targets() []string {
+	var targets []string
+	for _, level := range o.EffectivePriorityLevels() {
+		targets = append(targets, level.IPs...)
+	}
+	for _, name := range sortedKeys(o.IPSets) {
+		for _, level := range o.IPSets[name] {
+			targets = append(targets, level.IPs...)
+		}
+	}
+	return targets
+}
+
 func legacyPriorityLevels(priorityIPs, failoverIPs []string) []PriorityLevel {
 	levels := make([]PriorityLevel, 0, 2)
 	if len(priorityIPs) > 0 {
@@ -163,15 +605,34 @@ type HealthCheck struct {
 	Type               string            `json:"type" yaml:"type"`                                 // "http", "https", "icmp"
 	Endpoint           string            `json:"endpoint" yaml:"endpoint"`                         // HTTPSの場合のパス
 	Host               string            `json:"host" yaml:"host"`                                 // HTTPSの場合のホスト名
-	Timeout            int               `json:"timeout" yaml:"timeout"`                           // タイムアウト（秒）
+	Timeout            Seconds           `json:"timeout" yaml:"timeout"`                           // タイムアウト
 	InsecureSkipVerify bool              `json:"insecure_skip_verify" yaml:"insecure_skip_verify"` // HTTPSの場合に証明書検証をスキップするかどうか
 	Headers            map[string]string `json:"headers" yaml:"headers"`                           // ヘルスチェックリクエストに追加するHTTPヘッダ
 }
 
 // NotificationConfig は通知設定を表す構造体
 type NotificationConfig struct {
-	Type       string `json:"type" yaml:"type"`               // "slack" または "discord"
-	WebhookURL string `json:"webhook_url" yaml:"webhook_url"` // WebhookのURL
+	Type           string                      `json:"type" yaml:"type"`                                           // "slack"、"discord"、"opsgenie"、"telegram"、"ntfy"、"pushover"、"exec" または "mqtt"
+	Name           string                      `json:"name,omitempty" yaml:"name,omitempty"`                       // ログ、メトリクス、ステータスAPIでの名前（省略時は "notifications[0]" のような位置）
+	TimeoutSeconds Seconds                     `json:"timeout_seconds,omitempty" yaml:"timeout_seconds,omitempty"` // 1件の送信のタイムアウト（省略時は10秒、execはコマンドのタイムアウト）
+	WebhookURL     string                      `json:"webhook_url" yaml:"webhook_url"`                             // WebhookのURL
+	APIKey         string                      `json:"api_key,omitempty" yaml:"api_key,omitempty"`                 // OpsgenieのAPIキー
+	APIURL         string                      `json:"api_url,omitempty" yaml:"api_url,omitempty"`                 // Slack、Opsgenie、Telegram、PushoverのAPIまたはntfyのサーバーのURL（省略時は公式のURL）
+	Priority       string                      `json:"priority,omitempty" yaml:"priority,omitempty"`               // Opsgenieのアラートの優先度（P1〜P5、省略時はP3）
+	Tags           []string                    `json:"tags,omitempty" yaml:"tags,omitempty"`                       // Opsgenieのアラートに付けるタグ
+	BotToken       string                      `json:"bot_token,omitempty" yaml:"bot_token,omitempty"`             // SlackまたはTelegramのボットのトークン
+	Channel        string                      `json:"channel,omitempty" yaml:"channel,omitempty"`                 // 送信先のSlackのチャンネルID（bot_tokenと合わせて指定）
+	ChatID         string                      `json:"chat_id,omitempty" yaml:"chat_id,omitempty"`                 // 送信先のTelegramのチャットIDまたは@チャンネル名
+	Topic          string                      `json:"topic,omitempty" yaml:"topic,omitempty"`                     // 送信先のntfyのトピック
+	Token          string                      `json:"token,omitempty" yaml:"token,omitempty"`                     // ntfyのアクセストークンまたはPushoverのアプリケーションのトークン
+	UserKey        string                      `json:"user_key,omitempty" yaml:"user_key,omitempty"`               // 送信先のPushoverのユーザーまたはグループのキー
+	Route          *NotificationRouteConfig    `json:"route,omitempty" yaml:"route,omitempty"`                     // 送信するイベントの条件（省略時はすべてのイベント）
+	QuietHours     *QuietHoursConfig           `json:"quiet_hours,omitempty" yaml:"quiet_hours,omitempty"`         // 通知を控えて後で送る時間帯
+	Template       *NotificationTemplateConfig `json:"template,omitempty" yaml:"template,omitempty"`               // メッセージのテンプレート（省略時は既定のメッセージ）
+	Digest         *DigestConfig               `json:"digest,omitempty" yaml:"digest,omitempty"`                   // 短い時間に起きたイベントを1件にまとめる設定（省略時は1件ずつ送信）
+	Discord        *DiscordConfig              `json:"discord,omitempty" yaml:"discord,omitempty"`                 // Discordのメンションとイベントの種類ごとの送信先
+	Exec           *ExecConfig                 `json:"exec,omitempty" yaml:"exec,omitempty"`                       // 実行するコマンドとその制限（type: exec）
+	MQTT           *MQTTConfig                 `json:"mqtt,omitempty" yaml:"mqtt,omitempty"`                       // 送信先のMQTTのブローカーとトピック（type: mqtt）
 }
 
 // LoadConfig は設定ファイルを読み込む関数
@@ -185,7 +646,7 @@ func LoadConfig(path string) (*Config, error) {
 	// If directory, look for default config files
 	if fileInfo.IsDir() {
 		originalPath := path
-		configFiles := []string{configFileYAML, configFileYML, configFileJSON}
+		configFiles := []string{configFileYAML, configFileYML, configFileJSON, configFileTOML}
 		found := false
 		for _, configFile := range configFiles {
 			configPath := filepath.Join(path, configFile)
@@ -204,16 +665,136 @@ func LoadConfig(path string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseConfig(path, data, filepath.Dir(path))
+}
+
+// LoadConfigData はファイル以外（HTTPSやS3など）から取得した設定を読み込む関数
+// 形式はnameの拡張子で判定する。includeは使用できない
+func LoadConfigData(name string, data []byte) (*Config, error) {
+	return parseConfig(name, data, "")
+}
+
+// parseConfig は設定の内容を解析して検証する
+// includeのパターンはbaseDirからの相対パスとして扱い、baseDirが空の場合はincludeを許可しない
+func parseConfig(name string, data []byte, baseDir string) (*Config, error) {
+	if trimmed, ok := templateName(name); ok {
+		rendered, err := renderTemplate(name, data, os.LookupEnv)
+		if err != nil {
+			return nil, err
+		}
+		name, data = trimmed, rendered
+	}
+	data, err := expandEnv(data, os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
 
 	// Determine file format based on file name extension
-	ext := fileExt(strings.ToLower(filepath.Ext(path)))
+	ext := fileExt(strings.ToLower(filepath.Ext(name)))
+	if err := validateSchema(configSchema(), ext, data); err != nil {
+		return nil, err
+	}
+	ext, data, warnings, err := migrateConfig(ext, data)
+	if err != nil {
+		return nil, err
+	}
 	tmpConfig, err := decodeConfig(ext, data)
 	if err != nil {
 		return nil, err
 	}
+	if err := loadIncludes(&tmpConfig, baseDir); err != nil {
+		return nil, err
+	}
+	runtimeFile, err := loadRuntimeFile(&tmpConfig, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := resolveSecretRefs(&tmpConfig); err != nil {
+		return nil, err
+	}
+	kvIndex, err := loadOriginsKV(&tmpConfig)
+	if err != nil {
+		return nil, err
+	}
+	kubernetesOrigins, kubernetesWarnings, err := loadOriginsKubernetes(&tmpConfig)
+	if err != nil {
+		return nil, err
+	}
 
 	config := buildConfig(tmpConfig)
+	config.OriginsKVIndex = kvIndex
+	config.RuntimeFile = runtimeFile
+	config.KubernetesOrigins = kubernetesOrigins
+	config.Warnings = append(warnings, kubernetesWarnings...)
+	if err := loadAPITokenFile(config); err != nil {
+		return nil, err
+	}
+	if (config.CloudflareAPIKey == "") != (config.CloudflareAPIEmail == "") {
+		return nil, ErrIncompleteAPIKey
+	}
+	if err := validateAccounts(config.Accounts); err != nil {
+		return nil, err
+	}
+	if err := validateStateStore(config.StateStore); err != nil {
+		return nil, err
+	}
+	if err := validateAuditConfig(config.Audit); err != nil {
+		return nil, err
+	}
+	if err := validateAllowlists(config); err != nil {
+		return nil, err
+	}
+	if err := validateKubernetesClusters(config); err != nil {
+		return nil, err
+	}
+	if err := validateDNSDiscovery(config.DNSDiscovery); err != nil {
+		return nil, err
+	}
+	if err := validateTracing(config.Tracing); err != nil {
+		return nil, err
+	}
+	if err := validateMetrics(config.Metrics); err != nil {
+		return nil, err
+	}
+	if err := validateStatusAPI(config.StatusAPI); err != nil {
+		return nil, err
+	}
+	if err := validateEventHistory(config.EventHistory); err != nil {
+		return nil, err
+	}
+	if err := validateLog(config.Log); err != nil {
+		return nil, err
+	}
+	if err := validateErrorReporting(config.ErrorReporting); err != nil {
+		return nil, err
+	}
+	if err := validateNotifications(config.Notifications); err != nil {
+		return nil, err
+	}
+	if err := validateSummary(config.Summary, config.Notifications); err != nil {
+		return nil, err
+	}
+	if err := validateHeartbeat(config.Heartbeat); err != nil {
+		return nil, err
+	}
+	if err := validateEscalation(config.Escalation); err != nil {
+		return nil, err
+	}
+	if err := validateSlackActions(config.SlackActions); err != nil {
+		return nil, err
+	}
+	if err := validateAdminAPI(config.AdminAPI); err != nil {
+		return nil, err
+	}
+	if err := validateGRPCAPI(config.GRPCAPI); err != nil {
+		return nil, err
+	}
 	applyLegacyZoneConfig(config, tmpConfig)
+	for _, zone := range config.CloudflareZoneIDs {
+		if (zone.AWSAccessKeyID == "") != (zone.AWSSecretAccessKey == "") {
+			return nil, fmt.Errorf("%w: zone %s", ErrIncompleteAWSCredentials, zone.Name)
+		}
+	}
 	if err := normalizeOrigins(config); err != nil {
 		return nil, err
 	}
@@ -222,44 +803,124 @@ func LoadConfig(path string) (*Config, error) {
 }
 
 type rawConfig struct {
-	CloudflareAPIToken string               `json:"cloudflare_api_token" yaml:"cloudflare_api_token"`
-	CloudflareZoneID   string               `json:"cloudflare_zone_id" yaml:"cloudflare_zone_id"`
-	CloudflareZoneIDs  []ZoneConfig         `json:"cloudflare_zones" yaml:"cloudflare_zones"`
-	CheckInterval      int                  `json:"check_interval_seconds" yaml:"check_interval_seconds"`
-	Origins            []OriginConfig       `json:"origins" yaml:"origins"`
-	Notifications      []NotificationConfig `json:"notifications" yaml:"notifications"`
+	Version            int                       `json:"version" yaml:"version"`
+	CloudflareAPIToken string                    `json:"cloudflare_api_token" yaml:"cloudflare_api_token"`
+	APITokenFile       string                    `json:"cloudflare_api_token_file" yaml:"cloudflare_api_token_file"`
+	CloudflareAPIKey   string                    `json:"cloudflare_api_key" yaml:"cloudflare_api_key"`
+	CloudflareAPIEmail string                    `json:"cloudflare_api_email" yaml:"cloudflare_api_email"`
+	CloudflareZoneID   string                    `json:"cloudflare_zone_id" yaml:"cloudflare_zone_id"`
+	CloudflareZoneIDs  []ZoneConfig              `json:"cloudflare_zones" yaml:"cloudflare_zones"`
+	Accounts           []AccountConfig           `json:"cloudflare_accounts" yaml:"cloudflare_accounts"`
+	CheckInterval      Seconds                   `json:"check_interval_seconds" yaml:"check_interval_seconds"`
+	Origins            []OriginConfig            `json:"origins" yaml:"origins"`
+	Notifications      []NotificationConfig      `json:"notifications" yaml:"notifications"`
+	ChangeLimit        ChangeLimitConfig         `json:"change_limit" yaml:"change_limit"`
+	Flapping           *FlappingConfig           `json:"flapping" yaml:"flapping"`
+	APIRetry           APIRetryConfig            `json:"api_retry" yaml:"api_retry"`
+	APIRateLimit       APIRateLimitConfig        `json:"api_rate_limit" yaml:"api_rate_limit"`
+	APITimeoutSeconds  Seconds                   `json:"api_timeout_seconds" yaml:"api_timeout_seconds"`
+	RecordTags         bool                      `json:"record_tags" yaml:"record_tags"`
+	RecordCacheSeconds Seconds                   `json:"record_cache_seconds" yaml:"record_cache_seconds"`
+	ProviderPlugins    []string                  `json:"provider_plugins" yaml:"provider_plugins"`
+	StateStore         *StateStoreConfig         `json:"state_store" yaml:"state_store"`
+	Audit              *AuditConfig              `json:"audit" yaml:"audit"`
+	SkipTokenCheck     bool                      `json:"skip_token_check" yaml:"skip_token_check"`
+	Include            []string                  `json:"include" yaml:"include"`
+	ConfigPollSeconds  Seconds                   `json:"config_poll_seconds" yaml:"config_poll_seconds"`
+	OriginsKV          *OriginsKVConfig          `json:"origins_kv" yaml:"origins_kv"`
+	OriginsKubernetes  *OriginsKubernetesConfig  `json:"origins_kubernetes" yaml:"origins_kubernetes"`
+	KubernetesClusters []KubernetesClusterConfig `json:"kubernetes_clusters" yaml:"kubernetes_clusters"`
+	Consul             *ConsulConfig             `json:"consul" yaml:"consul"`
+	DNSDiscovery       *DNSDiscoveryConfig       `json:"dns_discovery" yaml:"dns_discovery"`
+	AllowedCIDRs       []string                  `json:"allowed_cidrs" yaml:"allowed_cidrs"`
+	Tracing            *TracingConfig            `json:"tracing" yaml:"tracing"`
+	Metrics            *MetricsConfig            `json:"metrics" yaml:"metrics"`
+	StatusAPI          *StatusAPIConfig          `json:"status_api" yaml:"status_api"`
+	EventHistory       *EventHistoryConfig       `json:"event_history" yaml:"event_history"`
+	Log                *LogConfig                `json:"log" yaml:"log"`
+	ErrorReporting     *ErrorReportingConfig     `json:"error_reporting" yaml:"error_reporting"`
+	Summary            *SummaryConfig            `json:"summary" yaml:"summary"`
+	Heartbeat          *HeartbeatConfig          `json:"heartbeat" yaml:"heartbeat"`
+	Escalation         *EscalationConfig         `json:"escalation" yaml:"escalation"`
+	SlackActions       *SlackActionsConfig       `json:"slack_actions" yaml:"slack_actions"`
+	AdminAPI           *AdminAPIConfig           `json:"admin_api" yaml:"admin_api"`
+	GRPCAPI            *GRPCAPIConfig            `json:"grpc_api" yaml:"grpc_api"`
 }
 
 func decodeConfig(ext fileExt, data []byte) (rawConfig, error) {
 	var tmpConfig rawConfig
+	if err := decodeFile(ext, data, &tmpConfig); err != nil {
+		return rawConfig{}, err
+	}
+	return tmpConfig, nil
+}
 
+// decodeFile は拡張子に応じた形式でdataをvにデコードする
+func decodeFile(ext fileExt, data []byte, v any) error {
 	// Decode based on file extension
 	switch ext {
 	case extYAML, extYML:
-		if err := yaml.Unmarshal(data, &tmpConfig); err != nil {
-			return rawConfig{}, fmt.Errorf("%w: %w", ErrParseYAML, err)
+		if err := yaml.Unmarshal(data, v); err != nil {
+			return fmt.Errorf("%w: %w", ErrParseYAML, err)
 		}
 	case extJSON:
-		if err := json.Unmarshal(data, &tmpConfig); err != nil {
-			return rawConfig{}, fmt.Errorf("%w: %w", ErrParseJSON, err)
+		if err := json.Unmarshal(data, v); err != nil {
+			return fmt.Errorf("%w: %w", ErrParseJSON, err)
+		}
+	case extTOML:
+		if err := decodeTOML(data, v); err != nil {
+			return err
 		}
 	default:
 		// Default to JSON for backward compatibility
-		if err := json.Unmarshal(data, &tmpConfig); err != nil {
-			return rawConfig{}, fmt.Errorf("%w (assumed JSON): %w", ErrParseJSON, err)
+		if err := json.Unmarshal(data, v); err != nil {
+			return fmt.Errorf("%w (assumed JSON): %w", ErrParseJSON, err)
 		}
 	}
-
-	return tmpConfig, nil
+	return nil
 }
 
 func buildConfig(tmpConfig rawConfig) *Config {
 	return &Config{
 		CloudflareAPIToken: tmpConfig.CloudflareAPIToken,
+		APITokenFile:       tmpConfig.APITokenFile,
+		CloudflareAPIKey:   tmpConfig.CloudflareAPIKey,
+		CloudflareAPIEmail: tmpConfig.CloudflareAPIEmail,
 		CloudflareZoneIDs:  tmpConfig.CloudflareZoneIDs,
-		CheckInterval:      time.Duration(tmpConfig.CheckInterval) * time.Second,
+		Accounts:           tmpConfig.Accounts,
+		CheckInterval:      tmpConfig.CheckInterval.Duration(),
 		Origins:            tmpConfig.Origins,
 		Notifications:      tmpConfig.Notifications,
+		ChangeLimit:        tmpConfig.ChangeLimit,
+		Flapping:           tmpConfig.Flapping,
+		APIRetry:           tmpConfig.APIRetry,
+		APIRateLimit:       tmpConfig.APIRateLimit,
+		APITimeout:         tmpConfig.APITimeoutSeconds.Duration(),
+		RecordTags:         tmpConfig.RecordTags,
+		RecordCacheTTL:     tmpConfig.RecordCacheSeconds.Duration(),
+		ProviderPlugins:    tmpConfig.ProviderPlugins,
+		StateStore:         tmpConfig.StateStore,
+		Audit:              tmpConfig.Audit,
+		SkipTokenCheck:     tmpConfig.SkipTokenCheck,
+		ConfigPollInterval: tmpConfig.ConfigPollSeconds.Duration(),
+		OriginsKV:          tmpConfig.OriginsKV,
+		OriginsKubernetes:  tmpConfig.OriginsKubernetes,
+		KubernetesClusters: tmpConfig.KubernetesClusters,
+		Consul:             tmpConfig.Consul,
+		DNSDiscovery:       tmpConfig.DNSDiscovery,
+		AllowedCIDRs:       tmpConfig.AllowedCIDRs,
+		Tracing:            tmpConfig.Tracing,
+		Metrics:            tmpConfig.Metrics,
+		StatusAPI:          tmpConfig.StatusAPI,
+		EventHistory:       tmpConfig.EventHistory,
+		Log:                tmpConfig.Log,
+		ErrorReporting:     tmpConfig.ErrorReporting,
+		Summary:            tmpConfig.Summary,
+		Heartbeat:          tmpConfig.Heartbeat,
+		Escalation:         tmpConfig.Escalation,
+		SlackActions:       tmpConfig.SlackActions,
+		AdminAPI:           tmpConfig.AdminAPI,
+		GRPCAPI:            tmpConfig.GRPCAPI,
 	}
 }
 
@@ -292,20 +953,56 @@ func normalizeOrigins(config *Config) error {
 		defaultZoneName = config.CloudflareZoneIDs[0].Name
 	}
 
+	var errs []error
 	for i := range config.Origins {
 		origin := &config.Origins[i]
 		normalizeOriginPriorityLevels(origin)
 		if err := validateRecordType(origin.RecordType); err != nil {
-			return fmt.Errorf("invalid record type for origin %s: %w", origin.Name, err)
+			errs = append(errs, fmt.Errorf("invalid record type for origin %s: %w", origin.Name, err))
+		}
+		if err := validateOriginMode(origin.Mode); err != nil {
+			errs = append(errs, fmt.Errorf("invalid mode for origin %s: %w", origin.Name, err))
+		}
+		if err := validateVerifyConfig(origin.Verify); err != nil {
+			errs = append(errs, fmt.Errorf("invalid verify config for origin %s: %w", origin.Name, err))
+		}
+		if origin.MinHealthy < 0 {
+			errs = append(errs, fmt.Errorf("invalid origin %s: %w", origin.Name, ErrInvalidMinHealthy))
+		}
+		if err := validateIPSets(*origin); err != nil {
+			errs = append(errs, fmt.Errorf("invalid ip_sets for origin %s: %w", origin.Name, err))
+		}
+		if err := validateScoringConfig(origin.Scoring); err != nil {
+			errs = append(errs, fmt.Errorf("invalid scoring config for origin %s: %w", origin.Name, err))
+		}
+		if err := validateSchedules(origin.Schedules); err != nil {
+			errs = append(errs, fmt.Errorf("invalid schedules for origin %s: %w", origin.Name, err))
+		}
+		if err := validateCloudflareHealthCheck(origin.CloudflareHealth); err != nil {
+			errs = append(errs, fmt.Errorf("invalid origin %s: %w", origin.Name, err))
+		}
+		if err := validateRecordBinding(origin.RecordBinding); err != nil {
+			errs = append(errs, fmt.Errorf("invalid origin %s: %w", origin.Name, err))
+		}
+		if err := validateSpectrum(origin.Spectrum); err != nil {
+			errs = append(errs, fmt.Errorf("invalid origin %s: %w", origin.Name, err))
+		}
+		if err := validateLatencySLO(origin.LatencySLO); err != nil {
+			errs = append(errs, fmt.Errorf("invalid origin %s: %w", origin.Name, err))
 		}
 		if origin.ZoneName == "" && defaultZoneName != "" {
 			origin.ZoneName = defaultZoneName
 		}
 	}
-	return nil
+	// Report every problem of every origin at once
+	errs = append(errs, ValidateOrigins(config))
+	return errors.Join(errs...)
 }
 
 func normalizeOriginPriorityLevels(origin *OriginConfig) {
+	for name, levels := range origin.IPSets {
+		origin.IPSets[name] = NormalizePriorityLevels(levels)
+	}
 	origin.PriorityLevels = NormalizePriorityLevels(origin.PriorityLevels)
 	if len(origin.PriorityLevels) > 0 {
 		return
@@ -321,3 +1018,55 @@ func validateRecordType(recordType string) error {
 	}
 	return fmt.Errorf("%w: %s", ErrUnsupportedRecordType, recordType)
 }
+
+func validateOriginMode(mode string) error {
+	switch mode {
+	case "", OriginModeActive, OriginModeObserve:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedOriginMode, mode)
+	}
+}
+
+func validateVerifyConfig(verify *VerifyConfig) error {
+	if verify == nil {
+		return nil
+	}
+	switch verify.Method {
+	case "", VerifyMethodAPI, VerifyMethodDNS:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedVerifyMethod, verify.Method)
+	}
+}
+
+func validateScoringConfig(scoring *ScoringConfig) error {
+	if scoring == nil {
+		return nil
+	}
+	if scoring.Threshold < 0 || scoring.Threshold > 100 {
+		return fmt.Errorf("%w: threshold must be between 0 and 100", ErrInvalidScoring)
+	}
+	if scoring.CheckWeight < 0 || scoring.FailureWeight < 0 || scoring.LatencyWeight < 0 {
+		return fmt.Errorf("%w: weights must not be negative", ErrInvalidScoring)
+	}
+	return nil
+}
+
+func validateIPSets(origin OriginConfig) error {
+	if !origin.HasIPSets() {
+		if origin.ActiveSet != "" {
+			return fmt.Errorf("%w: %s", ErrUnknownIPSet, origin.ActiveSet)
+		}
+		return nil
+	}
+	if len(origin.PriorityLevels) > 0 {
+		return ErrIPSetsWithPriorityLevels
+	}
+	if origin.ActiveSet != "" {
+		if _, ok := origin.IPSets[origin.ActiveSet]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownIPSet, origin.ActiveSet)
+		}
+	}
+	return nil
+}
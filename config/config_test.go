@@ -1,9 +1,11 @@
 package config
 
 import (
+	"context"
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -93,8 +95,8 @@ func TestLoadConfig(t *testing.T) {
 	if config.Origins[0].HealthCheck.Host != "example.com" {
 		t.Errorf("Expected first origin health check host = 'example.com', got '%s'", config.Origins[0].HealthCheck.Host)
 	}
-	if config.Origins[0].HealthCheck.Timeout != 5 {
-		t.Errorf("Expected first origin health check timeout = 5, got %d", config.Origins[0].HealthCheck.Timeout)
+	if config.Origins[0].HealthCheck.Timeout != Seconds(5*time.Second) {
+		t.Errorf("Expected first origin health check timeout = 5s, got %v", config.Origins[0].HealthCheck.Timeout.Duration())
 	}
 	if config.Origins[0].HealthCheck.Headers == nil {
 		t.Errorf("Expected first origin health check headers to be initialized")
@@ -386,16 +388,9 @@ func TestNonExistentZoneInConfig(t *testing.T) {
 		t.Fatalf("Failed to close temp file: %v", err)
 	}
 
-	config, err := LoadConfig(tmpfile.Name())
-	if err != nil {
-		t.Fatalf("LoadConfig() error = %v", err)
-	}
-
-	if len(config.CloudflareZoneIDs) != 1 {
-		t.Errorf("Expected 1 zone ID, got %d", len(config.CloudflareZoneIDs))
-	}
-	if len(config.Origins) != 2 {
-		t.Errorf("Expected 2 origins, got %d", len(config.Origins))
+	_, err = LoadConfig(tmpfile.Name())
+	if !errors.Is(err, ErrUnknownZone) || !strings.Contains(err.Error(), "origin api") {
+		t.Fatalf("Expected ErrUnknownZone for origin api, got %v", err)
 	}
 }
 
@@ -408,6 +403,7 @@ func TestLoadConfig_LegacyPriorityLevels(t *testing.T) {
 			{
 				"name": "example.com",
 				"record_type": "A",
+				"health_check": {"type": "icmp"},
 				"priority_failover_ips": ["192.168.1.1", "192.168.1.2"],
 				"failover_ips": ["192.168.1.3"]
 			}
@@ -700,6 +696,133 @@ origins:
 	}
 }
 
+func TestLoadYAMLConfig_AnchorsAndBlockScalars(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones: [{zone_id: zone-1, name: example.com}]
+check_interval_seconds: 60
+x-health: &health
+  type: https
+  endpoint: /health
+  headers:
+    X-Check: >-
+      folded
+      value
+origins:
+  - name: www.example.com
+    zone_name: example.com
+    record_type: A
+    health_check: *health
+    priority_levels: [{priority: 0, ips: ["192.0.2.1"]}]
+  - name: api.example.com
+    zone_name: example.com
+    record_type: A
+    health_check:
+      <<: *health
+      endpoint: /status
+    priority_levels:
+      - priority: 0
+        ips: ["192.0.2.2"]
+notifications:
+  - type: slack
+    webhook_url: |-
+      https://hooks.example.com/x
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.CloudflareZoneIDs) != 1 || cfg.CloudflareZoneIDs[0].ZoneID != "zone-1" {
+		t.Errorf("Expected inline zone map to be parsed, got %+v", cfg.CloudflareZoneIDs)
+	}
+	www, api := cfg.Origins[0].HealthCheck, cfg.Origins[1].HealthCheck
+	if www.Type != "https" || www.Endpoint != "/health" || www.Headers["X-Check"] != "folded value" {
+		t.Errorf("Expected aliased health check, got %+v", www)
+	}
+	if api.Type != "https" || api.Endpoint != "/status" || api.Headers["X-Check"] != "folded value" {
+		t.Errorf("Expected merged health check with overridden endpoint, got %+v", api)
+	}
+	if got := cfg.Notifications[0].WebhookURL; got != "https://hooks.example.com/x" {
+		t.Errorf("Expected literal block scalar, got %q", got)
+	}
+}
+
+func TestLoadTOMLConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := `
+cloudflare_api_token = "test-token"
+check_interval_seconds = 30
+
+[[cloudflare_zones]]
+zone_id = "zone-1"
+name = "example.com"
+
+[[origins]]
+name = "www.example.com"
+zone_name = "example.com"
+record_type = "A"
+return_to_priority = true
+health_check = { type = "https", endpoint = "/health", timeout = 5, headers = { X-Check = "1" } }
+
+[[origins.priority_levels]]
+priority = 1
+ips = ["192.0.2.1", "192.0.2.2"]
+
+[[origins.priority_levels]]
+priority = 0
+ips = ["198.51.100.1"]
+
+[[notifications]]
+type = "slack"
+webhook_url = "https://hooks.example.com/x"
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.CloudflareAPIToken != "test-token" || cfg.CheckInterval != 30*time.Second {
+		t.Errorf("Unexpected top-level values: %+v", cfg)
+	}
+	if len(cfg.CloudflareZoneIDs) != 1 || cfg.CloudflareZoneIDs[0].Name != "example.com" {
+		t.Errorf("Unexpected zones: %+v", cfg.CloudflareZoneIDs)
+	}
+	origin := cfg.Origins[0]
+	if !origin.ReturnToPriority || origin.HealthCheck.Timeout != Seconds(5*time.Second) || origin.HealthCheck.Headers["X-Check"] != "1" {
+		t.Errorf("Unexpected origin: %+v", origin)
+	}
+	if len(origin.PriorityLevels) != 2 || len(origin.PriorityLevels[0].IPs) != 2 {
+		t.Errorf("Unexpected priority levels: %+v", origin.PriorityLevels)
+	}
+	if cfg.Notifications[0].WebhookURL != "https://hooks.example.com/x" {
+		t.Errorf("Unexpected notifications: %+v", cfg.Notifications)
+	}
+
+	if err := os.WriteFile(path, []byte("cloudflare_api_token = \"unclosed\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrParseTOML) {
+		t.Fatalf("Expected ErrParseTOML, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("skip_token_check = \"soon\"\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrSchemaViolation) {
+		t.Fatalf("Expected ErrSchemaViolation for a type mismatch, got %v", err)
+	}
+}
+
 func TestLoadConfig_DirectoryWithConfigFile(t *testing.T) {
 	// Create a temporary directory
 	tmpDir, err := os.MkdirTemp("", "config_dir_test_*")
@@ -807,3 +930,2210 @@ func TestLoadConfig_DirectoryWithoutConfigFile(t *testing.T) {
 		t.Errorf("Expected ErrNoConfigFound, got: %v", err)
 	}
 }
+
+func TestLoadConfig_InvalidOriginMode(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	content := `{
+		"cloudflare_api_token": "test-token",
+		"cloudflare_zone_id": "test-zone",
+		"check_interval_seconds": 60,
+		"origins": [
+			{
+				"name": "example.com",
+				"record_type": "A",
+				"mode": "passive",
+				"priority_levels": [{"priority": 100, "ips": ["192.168.1.1"]}]
+			}
+		]
+	}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	_, err := LoadConfig(path)
+	if !errors.Is(err, ErrUnsupportedOriginMode) {
+		t.Fatalf("Expected ErrUnsupportedOriginMode, got %v", err)
+	}
+}
+
+func TestLoadConfig_IPSets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zone_id: test-zone
+check_interval_seconds: 60
+origins:
+  - name: www.example.com
+    record_type: A
+    health_check: {type: icmp}
+    active_set: green
+    ip_sets:
+      blue:
+        - priority: 100
+          ips: [192.168.1.1, 192.168.1.1]
+      green:
+        - priority: 100
+          ips: [192.168.2.1]
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	origin := cfg.Origins[0]
+	if origin.DefaultIPSet() != "green" {
+		t.Errorf("Expected default IP set green, got %s", origin.DefaultIPSet())
+	}
+	if len(origin.IPSets["blue"][0].IPs) != 1 {
+		t.Errorf("Expected IP set levels to be normalized, got %v", origin.IPSets["blue"])
+	}
+
+	content = strings.Replace(content, "active_set: green", "active_set: purple", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrUnknownIPSet) {
+		t.Fatalf("Expected ErrUnknownIPSet, got %v", err)
+	}
+}
+
+func TestLoadConfig_InvalidScoring(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zone_id: test-zone
+check_interval_seconds: 60
+origins:
+  - name: www.example.com
+    record_type: A
+    scoring:
+      threshold: 150
+    priority_levels:
+      - priority: 100
+        ips: [192.168.1.1]
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidScoring) {
+		t.Fatalf("Expected ErrInvalidScoring, got %v", err)
+	}
+}
+
+func TestLoadConfig_APITokenFile(t *testing.T) {
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenPath, []byte("file-token\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write token: %v", err)
+	}
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token_file: ` + tokenPath + `
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	want := APICredentials{APIToken: "file-token", APITokenFile: tokenPath}
+	if got := cfg.CredentialsForZone("example.com"); got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	if err := os.WriteFile(path, []byte("cloudflare_api_token: inline\n"+content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrConflictingAPIToken) {
+		t.Fatalf("Expected ErrConflictingAPIToken, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := os.WriteFile(tokenPath, []byte(" \n"), 0o600); err != nil {
+		t.Fatalf("Failed to write token: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrEmptyAPITokenFile) {
+		t.Fatalf("Expected ErrEmptyAPITokenFile, got %v", err)
+	}
+
+	if err := os.Remove(tokenPath); err != nil {
+		t.Fatalf("Failed to remove token: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Expected os.ErrNotExist, got %v", err)
+	}
+}
+
+type fakeSecretResolver map[string]string
+
+func (f fakeSecretResolver) Resolve(_ context.Context, ref string) (string, error) {
+	value, ok := f[ref]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return value, nil
+}
+
+func TestLoadConfig_SecretRefs(t *testing.T) {
+	created := 0
+	original := newSecretResolver
+	newSecretResolver = func(context.Context) (secretResolver, error) {
+		created++
+		return fakeSecretResolver{
+			"aws-sm://gslb#token": "secret-token",
+			"ssm:///gslb/webhook": "https://hooks.example.com/x",
+			"ssm:///gslb/auth":    "Bearer abc",
+		}, nil
+	}
+	t.Cleanup(func() { newSecretResolver = original })
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: aws-sm://gslb#token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins:
+  - name: www
+    zone_name: example.com
+    record_type: A
+    health_check:
+      type: https
+      endpoint: /health
+      headers:
+        Authorization: ssm:///gslb/auth
+    priority_levels:
+      - priority: 0
+        ips: ["192.0.2.1"]
+notifications:
+  - type: slack
+    webhook_url: ssm:///gslb/webhook
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.CloudflareAPIToken != "secret-token" {
+		t.Errorf("Expected resolved token, got %q", cfg.CloudflareAPIToken)
+	}
+	if got := cfg.Notifications[0].WebhookURL; got != "https://hooks.example.com/x" {
+		t.Errorf("Expected resolved webhook URL, got %q", got)
+	}
+	if got := cfg.Origins[0].HealthCheck.Headers["Authorization"]; got != "Bearer abc" {
+		t.Errorf("Expected resolved header, got %q", got)
+	}
+	if created != 1 {
+		t.Errorf("Expected one resolver, got %d", created)
+	}
+
+	content = strings.Replace(content, "aws-sm://gslb#token", "aws-sm://missing", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "aws-sm://missing") {
+		t.Fatalf("Expected an error naming the reference, got %v", err)
+	}
+}
+
+func TestLoadConfig_APICredentials(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_key: global-key
+cloudflare_api_email: ops@example.com
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+  - zone_id: zone-2
+    name: example.net
+    api_token: scoped-token
+check_interval_seconds: 60
+origins: []
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if got := cfg.CredentialsForZone("example.com"); got != (APICredentials{APIKey: "global-key", APIEmail: "ops@example.com"}) {
+		t.Errorf("Expected Global API Key credentials, got %+v", got)
+	}
+	if got := cfg.CredentialsForZone("example.net"); got != (APICredentials{APIToken: "scoped-token"}) {
+		t.Errorf("Expected scoped token credentials, got %+v", got)
+	}
+
+	content = strings.Replace(content, "cloudflare_api_email: ops@example.com\n", "", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrIncompleteAPIKey) {
+		t.Fatalf("Expected ErrIncompleteAPIKey, got %v", err)
+	}
+}
+
+func TestLoadConfig_Route53Zone(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_zones:
+  - zone_id: Z0123456789
+    name: example.org
+    provider: route53
+    aws_region: us-east-1
+    aws_access_key_id: AKIAEXAMPLE
+    aws_secret_access_key: secret
+check_interval_seconds: 60
+origins: []
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	zone := cfg.CloudflareZoneIDs[0]
+	if zone.EffectiveProvider() != ProviderRoute53 || zone.AWSRegion != "us-east-1" {
+		t.Errorf("Unexpected zone config %+v", zone)
+	}
+	if (ZoneConfig{}).EffectiveProvider() != ProviderCloudflare {
+		t.Error("Expected zones to default to the cloudflare provider")
+	}
+
+	content = strings.Replace(content, "    aws_secret_access_key: secret\n", "", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrIncompleteAWSCredentials) {
+		t.Fatalf("Expected ErrIncompleteAWSCredentials, got %v", err)
+	}
+}
+
+func TestLoadConfig_InvalidCloudflareHealthCheck(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins:
+  - name: www
+    zone_name: example.com
+    record_type: A
+    health_check:
+      type: http
+    priority_levels:
+      - priority: 100
+        ips: [192.168.1.1]
+    cloudflare_health_check:
+      mode: majority
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidCloudflareHealthCheck) {
+		t.Fatalf("Expected ErrInvalidCloudflareHealthCheck, got %v", err)
+	}
+
+	content = strings.Replace(content, "mode: majority", "mode: any", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if got := cfg.Origins[0].CloudflareHealth.EffectiveMode(); got != CloudflareHealthModeAny {
+		t.Errorf("Expected mode any, got %s", got)
+	}
+}
+
+func TestLoadConfig_StateStore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+state_store:
+  type: workers_kv
+  account_id: account-1
+  namespace_id: namespace-1
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.StateStore.Enabled() || cfg.StateStore.NamespaceID != "namespace-1" {
+		t.Errorf("Unexpected state store %+v", cfg.StateStore)
+	}
+	if cfg.StateStore.EffectiveKeyPrefix() != DefaultStateKeyPrefix {
+		t.Errorf("Expected default key prefix, got %s", cfg.StateStore.EffectiveKeyPrefix())
+	}
+
+	content = strings.Replace(content, "  namespace_id: namespace-1\n", "", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidStateStore) {
+		t.Fatalf("Expected ErrInvalidStateStore, got %v", err)
+	}
+}
+
+func TestLoadConfig_Audit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+audit:
+  file: /var/log/gslb-audit.jsonl
+  actor: gslb-1
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.Audit.Enabled() || cfg.Audit.File != "/var/log/gslb-audit.jsonl" || cfg.Audit.EffectiveActor() != "gslb-1" {
+		t.Errorf("Unexpected audit config %+v", cfg.Audit)
+	}
+
+	// The mutation log alone is enough
+	content = strings.Replace(content, "  file: /var/log/gslb-audit.jsonl\n", "  mutations_file: /var/log/gslb-mutations.jsonl\n", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err = LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Audit.MutationsFile != "/var/log/gslb-mutations.jsonl" {
+		t.Errorf("Unexpected audit config %+v", cfg.Audit)
+	}
+
+	content = strings.Replace(content, "  mutations_file: /var/log/gslb-mutations.jsonl\n", "", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidAudit) {
+		t.Fatalf("Expected ErrInvalidAudit, got %v", err)
+	}
+}
+
+func TestLoadConfig_Tracing(t *testing.T) {
+	t.Setenv(EnvOTLPEndpoint, "")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+tracing:
+  headers:
+    x-honeycomb-team: key
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.Tracing.Enabled() || cfg.Tracing.Headers["x-honeycomb-team"] != "key" {
+		t.Errorf("Unexpected tracing config %+v", cfg.Tracing)
+	}
+	if cfg.Tracing.EffectiveEndpoint() != DefaultOTLPEndpoint || cfg.Tracing.EffectiveServiceName() != DefaultOTLPServiceName {
+		t.Errorf("Expected default endpoint and service name, got %s and %s", cfg.Tracing.EffectiveEndpoint(), cfg.Tracing.EffectiveServiceName())
+	}
+	t.Setenv(EnvOTLPEndpoint, "http://collector:4318")
+	if cfg.Tracing.EffectiveEndpoint() != "http://collector:4318" {
+		t.Errorf("Expected the endpoint from %s, got %s", EnvOTLPEndpoint, cfg.Tracing.EffectiveEndpoint())
+	}
+
+	content += "  endpoint: collector:4318\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidTracing) {
+		t.Fatalf("Expected ErrInvalidTracing, got %v", err)
+	}
+}
+
+func TestLoadConfig_Metrics(t *testing.T) {
+	t.Setenv(EnvOTLPEndpoint, "")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+metrics:
+  endpoint: https://otlp.example.com
+  service_name: gslb-edge
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.Metrics.Enabled() || cfg.Metrics.EffectiveEndpoint() != "https://otlp.example.com" || cfg.Metrics.EffectiveServiceName() != "gslb-edge" {
+		t.Errorf("Unexpected metrics config %+v", cfg.Metrics)
+	}
+	if cfg.Metrics.EffectiveInterval().Duration() != time.Minute {
+		t.Errorf("Expected the default one minute interval, got %v", cfg.Metrics.EffectiveInterval().Duration())
+	}
+
+	content += "  interval_seconds: 15s\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if cfg, err = LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Metrics.EffectiveInterval().Duration() != 15*time.Second {
+		t.Errorf("Expected a 15s interval, got %v", cfg.Metrics.EffectiveInterval().Duration())
+	}
+
+	content = strings.Replace(content, "https://otlp.example.com", "otlp.example.com", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidMetrics) {
+		t.Fatalf("Expected ErrInvalidMetrics, got %v", err)
+	}
+}
+
+func TestLoadConfig_StatusAPI(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+status_api: {}
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.StatusAPI.Enabled() || cfg.StatusAPI.EffectiveListen() != DefaultStatusAPIListen {
+		t.Errorf("Unexpected status_api config %+v", cfg.StatusAPI)
+	}
+
+	content = strings.Replace(content, "status_api: {}", "status_api:\n  listen: 8080", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidStatusAPI) {
+		t.Fatalf("Expected ErrInvalidStatusAPI, got %v", err)
+	}
+
+	content = strings.Replace(content, "listen: 8080", "listen: \":9090\"", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if cfg, err = LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.StatusAPI.EffectiveListen() != ":9090" {
+		t.Errorf("Expected listen :9090, got %q", cfg.StatusAPI.EffectiveListen())
+	}
+	if cfg.StatusAPI.Debug.Enabled() {
+		t.Errorf("Expected the debug endpoints to be disabled by default")
+	}
+
+	content += "  debug:\n    listen: \"127.0.0.1:6060\"\n    token: secret\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if cfg, err = LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.StatusAPI.Debug.SeparateListener() || cfg.StatusAPI.Debug.Token != "secret" {
+		t.Errorf("Unexpected debug config %+v", cfg.StatusAPI.Debug)
+	}
+
+	invalid := map[string]string{
+		"listen without port": strings.Replace(content, "127.0.0.1:6060", "127.0.0.1", 1),
+		"same listener":       strings.Replace(content, "127.0.0.1:6060", ":9090", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidStatusAPI) {
+			t.Errorf("%s: expected ErrInvalidStatusAPI, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_EventHistory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+event_history:
+  file: /var/lib/gslb/events.log
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.EventHistory.Enabled() || cfg.EventHistory.File != "/var/lib/gslb/events.log" {
+		t.Errorf("Unexpected event_history config %+v", cfg.EventHistory)
+	}
+	if cfg.EventHistory.EffectiveRetention().Duration() != 30*24*time.Hour {
+		t.Errorf("Expected a 30 day retention, got %v", cfg.EventHistory.EffectiveRetention().Duration())
+	}
+
+	content += "  retention_seconds: 168h\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if cfg, err = LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.EventHistory.EffectiveRetention().Duration() != 7*24*time.Hour {
+		t.Errorf("Expected a 7 day retention, got %v", cfg.EventHistory.EffectiveRetention().Duration())
+	}
+
+	content = strings.Replace(content, "  file: /var/lib/gslb/events.log\n", "", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidEventHistory) {
+		t.Fatalf("Expected ErrInvalidEventHistory, got %v", err)
+	}
+}
+
+func TestLoadConfig_MetricsStatsD(t *testing.T) {
+	t.Setenv(EnvDDAgentHost, "")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+metrics:
+  exporter: statsd
+  tags: ["env:prod"]
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Metrics.EffectiveExporter() != MetricsExporterStatsD || cfg.Metrics.EffectiveAddress() != DefaultStatsDAddress {
+		t.Errorf("Unexpected metrics config %+v", cfg.Metrics)
+	}
+	if len(cfg.Metrics.Tags) != 1 || cfg.Metrics.Tags[0] != "env:prod" {
+		t.Errorf("Expected the env:prod tag, got %v", cfg.Metrics.Tags)
+	}
+
+	t.Setenv(EnvDDAgentHost, "10.0.0.5")
+	if got := cfg.Metrics.EffectiveAddress(); got != "10.0.0.5:8125" {
+		t.Errorf("Expected the agent host from %s, got %q", EnvDDAgentHost, got)
+	}
+
+	for name, broken := range map[string]string{
+		"address without port": content + "  address: dd-agent\n",
+		"unknown exporter":     strings.Replace(content, "exporter: statsd", "exporter: prometheus", 1),
+	} {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidMetrics) {
+			t.Errorf("%s: expected ErrInvalidMetrics, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_LatencySLO(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins:
+  - name: www.example.com
+    zone_name: example.com
+    record_type: A
+    health_check:
+      type: http
+      endpoint: /health
+    priority_levels:
+      - priority: 100
+        ips: ["192.0.2.1"]
+    latency_slo:
+      threshold_ms: 250
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	slo := cfg.Origins[0].LatencySLO
+	if !slo.Enabled() || slo.ThresholdMs.Duration() != 250*time.Millisecond {
+		t.Fatalf("Unexpected latency_slo config %+v", slo)
+	}
+	if slo.EffectiveObjective() != DefaultLatencySLOObjective || slo.EffectiveWindow().Duration() != time.Hour {
+		t.Errorf("Expected the default objective and window, got %v and %v", slo.EffectiveObjective(), slo.EffectiveWindow().Duration())
+	}
+
+	for name, broken := range map[string]string{
+		"no threshold":      strings.Replace(content, "threshold_ms: 250", "objective: 0.9", 1),
+		"objective above 1": content + "      objective: 99.9\n",
+	} {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidLatencySLO) {
+			t.Errorf("%s: expected ErrInvalidLatencySLO, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_Log(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+log:
+  file: /var/log/gslb/gslb.log
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.Log.Enabled() || cfg.Log.File != "/var/log/gslb/gslb.log" {
+		t.Errorf("Unexpected log config %+v", cfg.Log)
+	}
+	if cfg.Log.EffectiveMaxSizeMB() != DefaultLogMaxSizeMB || cfg.Log.EffectiveMaxBackups() != DefaultLogMaxBackups || cfg.Log.MaxAgeSeconds != 0 {
+		t.Errorf("Expected default rotation, got %+v", cfg.Log)
+	}
+
+	content += "  max_size_mb: 10\n  max_age_seconds: 24h\n  max_backups: 3\n  compress: true\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if cfg, err = LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Log.EffectiveMaxSizeMB() != 10 || cfg.Log.MaxAgeSeconds.Duration() != 24*time.Hour || cfg.Log.EffectiveMaxBackups() != 3 || !cfg.Log.Compress {
+		t.Errorf("Unexpected log config %+v", cfg.Log)
+	}
+
+	invalid := map[string]string{
+		"missing file":     strings.Replace(content, "  file: /var/log/gslb/gslb.log\n", "", 1),
+		"negative size":    strings.Replace(content, "max_size_mb: 10", "max_size_mb: -1", 1),
+		"negative age":     strings.Replace(content, "max_age_seconds: 24h", "max_age_seconds: -1", 1),
+		"negative backups": strings.Replace(content, "max_backups: 3", "max_backups: -1", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidLog) {
+			t.Errorf("%s: expected ErrInvalidLog, got %v", name, err)
+		}
+	}
+
+	// Syslog alone is enough
+	content = strings.Replace(content, "log:\n  file: /var/log/gslb/gslb.log\n", "log:\n  syslog:\n    address: syslog.example.com:514\n    facility: local3\n", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if cfg, err = LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Log.File != "" || cfg.Log.Syslog.EffectiveNetwork() != SyslogNetworkUDP || cfg.Log.Syslog.EffectiveFacility() != "local3" || cfg.Log.Syslog.EffectiveAppName() != DefaultSyslogAppName {
+		t.Errorf("Unexpected syslog config %+v", cfg.Log.Syslog)
+	}
+
+	invalid = map[string]string{
+		"unknown facility":     strings.Replace(content, "facility: local3", "facility: local9", 1),
+		"unknown network":      strings.Replace(content, "    facility: local3\n", "    facility: local3\n    network: sctp\n", 1),
+		"address without port": strings.Replace(content, "syslog.example.com:514", "syslog.example.com", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidLog) {
+			t.Errorf("%s: expected ErrInvalidLog, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_ErrorReporting(t *testing.T) {
+	t.Setenv(EnvSentryDSN, "")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+error_reporting:
+  environment: production
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidErrorReporting) {
+		t.Fatalf("Expected ErrInvalidErrorReporting without a DSN, got %v", err)
+	}
+
+	t.Setenv(EnvSentryDSN, "https://public@o1.ingest.sentry.io/2")
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.ErrorReporting.Enabled() || cfg.ErrorReporting.EffectiveDSN() != "https://public@o1.ingest.sentry.io/2" || cfg.ErrorReporting.Environment != "production" {
+		t.Errorf("Unexpected error reporting config %+v", cfg.ErrorReporting)
+	}
+	if cfg.ErrorReporting.EffectiveRepeatInterval() != DefaultErrorRepeatInterval {
+		t.Errorf("Expected the default repeat interval, got %v", cfg.ErrorReporting.EffectiveRepeatInterval())
+	}
+
+	content += "  dsn: https://key@glitchtip.example.com/7\n  repeat_interval_seconds: 600\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if cfg, err = LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.ErrorReporting.EffectiveDSN() != "https://key@glitchtip.example.com/7" || cfg.ErrorReporting.EffectiveRepeatInterval().Duration() != 10*time.Minute {
+		t.Errorf("Unexpected error reporting config %+v", cfg.ErrorReporting)
+	}
+
+	invalid := map[string]string{
+		"missing key":       strings.Replace(content, "https://key@", "https://", 1),
+		"missing project":   strings.Replace(content, "glitchtip.example.com/7", "glitchtip.example.com", 1),
+		"negative interval": strings.Replace(content, "repeat_interval_seconds: 600", "repeat_interval_seconds: -1", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidErrorReporting) {
+			t.Errorf("%s: expected ErrInvalidErrorReporting, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_Summary(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+notifications:
+  - type: slack
+    webhook_url: https://hooks.slack.com/services/x
+summary: {}
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.Summary.Enabled() || cfg.Summary.EffectiveInterval() != DefaultSummaryInterval {
+		t.Errorf("Unexpected summary config %+v", cfg.Summary)
+	}
+
+	content = strings.Replace(content, "summary: {}", "summary:\n  interval_seconds: 12h\n  at: \"09:00\"\n  timezone: Asia/Tokyo", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if cfg, err = LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Summary.EffectiveInterval().Duration() != 12*time.Hour || cfg.Summary.At != "09:00" || cfg.Summary.Timezone != "Asia/Tokyo" {
+		t.Errorf("Unexpected summary config %+v", cfg.Summary)
+	}
+
+	invalid := map[string]string{
+		"short interval":   strings.Replace(content, "interval_seconds: 12h", "interval_seconds: 10", 1),
+		"invalid time":     strings.Replace(content, `at: "09:00"`, `at: "9am"`, 1),
+		"unknown timezone": strings.Replace(content, "Asia/Tokyo", "Mars/Olympus", 1),
+		"no notifications": strings.Replace(content, "notifications:\n  - type: slack\n    webhook_url: https://hooks.slack.com/services/x\n", "", 1),
+		"only opsgenie":    strings.Replace(content, "type: slack\n    webhook_url: https://hooks.slack.com/services/x", "type: opsgenie\n    api_key: key", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidSummary) {
+			t.Errorf("%s: expected ErrInvalidSummary, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_Heartbeat(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+heartbeat:
+  url: https://hc-ping.com/uuid
+  fail_url: https://hc-ping.com/uuid/fail
+  timeout_seconds: 5
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.Heartbeat.Enabled() || cfg.Heartbeat.URL != "https://hc-ping.com/uuid" || cfg.Heartbeat.FailURL != "https://hc-ping.com/uuid/fail" || cfg.Heartbeat.TimeoutSeconds.Duration() != 5*time.Second {
+		t.Errorf("Unexpected heartbeat config %+v", cfg.Heartbeat)
+	}
+
+	invalid := map[string]string{
+		"missing url":      strings.Replace(content, "  url: https://hc-ping.com/uuid\n", "", 1),
+		"relative url":     strings.Replace(content, "url: https://hc-ping.com/uuid\n", "url: hc-ping.com/uuid\n", 1),
+		"invalid fail_url": strings.Replace(content, "fail_url: https://hc-ping.com/uuid/fail", "fail_url: ftp://hc-ping.com/uuid/fail", 1),
+		"negative timeout": strings.Replace(content, "timeout_seconds: 5", "timeout_seconds: -5", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidHeartbeat) {
+			t.Errorf("%s: expected ErrInvalidHeartbeat, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_Opsgenie(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+notifications:
+  - type: opsgenie
+    api_key: key
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if n := cfg.Notifications[0]; n.EffectiveAPIURL() != DefaultOpsgenieAPIURL || n.EffectivePriority() != DefaultOpsgeniePriority {
+		t.Errorf("Unexpected defaults %s %s", n.EffectiveAPIURL(), n.EffectivePriority())
+	}
+
+	content += "    api_url: https://api.eu.opsgenie.com\n    priority: P1\n    tags: [dns]\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if cfg, err = LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if n := cfg.Notifications[0]; n.EffectiveAPIURL() != "https://api.eu.opsgenie.com" || n.EffectivePriority() != "P1" || len(n.Tags) != 1 {
+		t.Errorf("Unexpected opsgenie config %+v", n)
+	}
+
+	invalid := map[string]string{
+		"missing api_key":  strings.Replace(content, "    api_key: key\n", "", 1),
+		"invalid api_url":  strings.Replace(content, "https://api.eu.opsgenie.com", "api.eu.opsgenie.com", 1),
+		"unknown priority": strings.Replace(content, "priority: P1", "priority: high", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidNotification) {
+			t.Errorf("%s: expected ErrInvalidNotification, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_Telegram(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+notifications:
+  - type: telegram
+    bot_token: "123456:ABC"
+    chat_id: "-1001234567890"
+summary: {}
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if n := cfg.Notifications[0]; n.BotToken != "123456:ABC" || n.ChatID != "-1001234567890" || n.EffectiveAPIURL() != DefaultTelegramAPIURL {
+		t.Errorf("Unexpected telegram config %+v", n)
+	}
+
+	invalid := map[string]string{
+		"missing bot_token": strings.Replace(content, "    bot_token: \"123456:ABC\"\n", "", 1),
+		"missing chat_id":   strings.Replace(content, "    chat_id: \"-1001234567890\"\n", "", 1),
+		"invalid api_url":   strings.Replace(content, "summary: {}", "    api_url: api.telegram.org\nsummary: {}", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidNotification) {
+			t.Errorf("%s: expected ErrInvalidNotification, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_SlackWebAPI(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+notifications:
+  - type: slack
+    bot_token: xoxb-test
+    channel: C0123456789
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if n := cfg.Notifications[0]; n.BotToken != "xoxb-test" || n.Channel != "C0123456789" || n.EffectiveAPIURL() != DefaultSlackAPIURL {
+		t.Errorf("Unexpected slack config %+v", n)
+	}
+
+	invalid := map[string]string{
+		"missing bot_token": strings.Replace(content, "    bot_token: xoxb-test\n", "", 1),
+		"missing channel":   strings.Replace(content, "    channel: C0123456789\n", "", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidNotification) {
+			t.Errorf("%s: expected ErrInvalidNotification, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_Discord(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+notifications:
+  - type: discord
+    webhook_url: https://discord.com/api/webhooks/general
+    discord:
+      mention_roles: ["123456789012345678"]
+      mention_users: ["876543210987654321"]
+      webhooks:
+        recovery: https://discord.com/api/webhooks/quiet
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	discord := cfg.Notifications[0].Discord
+	if discord.EffectiveMentionSeverity() != DefaultDiscordMentionSeverity || len(discord.MentionRoles) != 1 || discord.Webhooks["recovery"] != "https://discord.com/api/webhooks/quiet" {
+		t.Errorf("Unexpected discord config %+v", discord)
+	}
+
+	invalid := map[string]string{
+		"not discord":      strings.Replace(content, "type: discord", "type: slack", 1),
+		"role name":        strings.Replace(content, `["123456789012345678"]`, `["oncall"]`, 1),
+		"mention_severity": strings.Replace(content, "      webhooks:", "      mention_severity: urgent\n      webhooks:", 1),
+		"unknown event":    strings.Replace(content, "        recovery:", "        recovered:", 1),
+		"invalid webhook":  strings.Replace(content, "recovery: https://discord.com/api/webhooks/quiet", "recovery: discord.com/api/webhooks/quiet", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidNotification) {
+			t.Errorf("%s: expected ErrInvalidNotification, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_ExecNotification(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+notifications:
+  - type: exec
+    exec:
+      command: ["/usr/local/bin/page", "--team", "sre"]
+      env:
+        PAGER_KEY: secret
+      timeout_seconds: 10
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	exec := cfg.Notifications[0].Exec
+	if len(exec.Command) != 3 || exec.Timeout() != 10*time.Second || exec.EffectiveMaxConcurrent() != DefaultExecMaxConcurrent || !cfg.Notifications[0].SupportsSummary() {
+		t.Errorf("Unexpected exec config %+v", exec)
+	}
+
+	invalid := map[string]string{
+		"no command":     strings.Replace(content, `["/usr/local/bin/page", "--team", "sre"]`, "[]", 1),
+		"not exec":       strings.Replace(content, "type: exec", "type: ntfy\n    topic: alerts", 1),
+		"env name":       strings.Replace(content, "PAGER_KEY:", "\"PAGER=KEY\":", 1),
+		"max_concurrent": strings.Replace(content, "timeout_seconds: 10", "max_concurrent: -1", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidNotification) {
+			t.Errorf("%s: expected ErrInvalidNotification, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_MQTTNotification(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+notifications:
+  - type: mqtt
+    mqtt:
+      broker: ssl://broker.example.com
+      topic: gslb/events
+      qos: 1
+      username: gslb
+      password: secret
+      tls:
+        server_name: mqtt.example.com
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	publisher := cfg.Notifications[0].MQTT.PublisherConfig()
+	if publisher.Address != "broker.example.com:8883" || !publisher.TLS || publisher.ServerName != "mqtt.example.com" || publisher.ClientID == "" {
+		t.Errorf("Unexpected publisher config %+v", publisher)
+	}
+
+	invalid := map[string]string{
+		"broker scheme":  strings.Replace(content, "ssl://", "http://", 1),
+		"wildcard topic": strings.Replace(content, "gslb/events", "gslb/#", 1),
+		"qos":            strings.Replace(content, "qos: 1", "qos: 3", 1),
+		"plain tls":      strings.Replace(content, "ssl://", "tcp://", 1),
+		"no username":    strings.Replace(content, "      username: gslb\n", "", 1),
+		"not mqtt":       strings.Replace(content, "type: mqtt", "type: ntfy\n    topic: alerts", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidNotification) {
+			t.Errorf("%s: expected ErrInvalidNotification, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_NotificationNameAndTimeout(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+notifications:
+  - type: ntfy
+    name: oncall
+    timeout_seconds: 3
+    topic: alerts
+  - type: ntfy
+    topic: backup
+  - type: exec
+    exec:
+      command: ["/usr/local/bin/page"]
+      timeout_seconds: 45
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	named, unnamed, exec := cfg.Notifications[0], cfg.Notifications[1], cfg.Notifications[2]
+	if named.EffectiveName(0) != "oncall" || named.Timeout() != 3*time.Second {
+		t.Errorf("Unexpected name %q and timeout %v", named.EffectiveName(0), named.Timeout())
+	}
+	if unnamed.EffectiveName(1) != "notifications[1]" || unnamed.Timeout() != DefaultNotificationTimeout {
+		t.Errorf("Unexpected default name %q and timeout %v", unnamed.EffectiveName(1), unnamed.Timeout())
+	}
+	if exec.Timeout() != 45*time.Second {
+		t.Errorf("Expected exec to default to the command timeout, got %v", exec.Timeout())
+	}
+
+	invalid := map[string]string{
+		"duplicate name":    strings.Replace(content, "topic: backup", "topic: backup\n    name: oncall", 1),
+		"name of position":  strings.Replace(content, "name: oncall", "name: notifications[1]", 1),
+		"negative timeout":  strings.Replace(content, "timeout_seconds: 3", "timeout_seconds: -1", 1),
+		"excessive timeout": strings.Replace(content, "timeout_seconds: 3", "timeout_seconds: 301", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidNotification) {
+			t.Errorf("%s: expected ErrInvalidNotification, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_SlackActions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+slack_actions:
+  signing_secret: secret
+  allowed_users: ["U012AB3CD"]
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.SlackActions.Enabled() || cfg.SlackActions.EffectiveListen() != DefaultSlackActionsListen || len(cfg.SlackActions.AllowedUsers) != 1 {
+		t.Errorf("Unexpected slack_actions config %+v", cfg.SlackActions)
+	}
+
+	invalid := map[string]string{
+		"no signing_secret": strings.Replace(content, "  signing_secret: secret\n", "", 1),
+		"listen":            strings.Replace(content, "  signing_secret: secret", "  signing_secret: secret\n  listen: \"8081\"", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidSlackActions) {
+			t.Errorf("%s: expected ErrInvalidSlackActions, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_AdminAPI(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+admin_api:
+  token: secret
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.AdminAPI.Enabled() || cfg.AdminAPI.EffectiveListen() != DefaultAdminAPIListen || cfg.AdminAPI.Token != "secret" {
+		t.Errorf("Unexpected admin_api config %+v", cfg.AdminAPI)
+	}
+
+	invalid := map[string]string{
+		"no token": strings.Replace(content, "  token: secret\n", "  listen: \"127.0.0.1:8082\"\n", 1),
+		"listen":   strings.Replace(content, "  token: secret", "  token: secret\n  listen: \"8082\"", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidAdminAPI) {
+			t.Errorf("%s: expected ErrInvalidAdminAPI, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_GRPCAPI(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+grpc_api:
+  token: secret
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.GRPCAPI.Enabled() || cfg.GRPCAPI.EffectiveListen() != DefaultGRPCAPIListen || cfg.GRPCAPI.Token != "secret" {
+		t.Errorf("Unexpected grpc_api config %+v", cfg.GRPCAPI)
+	}
+
+	invalid := map[string]string{
+		"no token": strings.Replace(content, "  token: secret\n", "  listen: \"127.0.0.1:8083\"\n", 1),
+		"listen":   strings.Replace(content, "  token: secret", "  token: secret\n  listen: \"8083\"", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidGRPCAPI) {
+			t.Errorf("%s: expected ErrInvalidGRPCAPI, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_APITLS(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+status_api:
+  listen: "0.0.0.0:8080"
+  token: reader
+  tls:
+    cert_file: /etc/gslb/tls.crt
+    key_file: /etc/gslb/tls.key
+admin_api:
+  listen: "0.0.0.0:8082"
+  tls:
+    cert_file: /etc/gslb/tls.crt
+    key_file: /etc/gslb/tls.key
+    client_ca_file: /etc/gslb/clients.crt
+grpc_api:
+  listen: "0.0.0.0:8083"
+  token: secret
+  tls:
+    cert_file: /etc/gslb/tls.crt
+    key_file: /etc/gslb/tls.key
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.StatusAPI.Token != "reader" || !cfg.StatusAPI.TLS.Enabled() || cfg.StatusAPI.TLS.MutualTLS() {
+		t.Errorf("Unexpected status_api config %+v", cfg.StatusAPI)
+	}
+	if cfg.AdminAPI.Token != "" || !cfg.AdminAPI.TLS.MutualTLS() || cfg.AdminAPI.TLS.ServerConfig().ClientCAFile != "/etc/gslb/clients.crt" {
+		t.Errorf("Unexpected admin_api config %+v", cfg.AdminAPI)
+	}
+	if !cfg.GRPCAPI.TLS.Enabled() || cfg.GRPCAPI.TLS.MutualTLS() {
+		t.Errorf("Unexpected grpc_api config %+v", cfg.GRPCAPI)
+	}
+
+	invalid := map[string]struct {
+		content string
+		want    error
+	}{
+		"status without key":               {strings.Replace(content, "    key_file: /etc/gslb/tls.key\nadmin_api", "admin_api", 1), ErrInvalidStatusAPI},
+		"admin without token or client CA": {strings.Replace(content, "    client_ca_file: /etc/gslb/clients.crt\n", "", 1), ErrInvalidAdminAPI},
+		"grpc without cert":                {strings.Replace(content, "  token: secret\n  tls:\n    cert_file: /etc/gslb/tls.crt\n", "  token: secret\n  tls:\n", 1), ErrInvalidGRPCAPI},
+	}
+	for name, tt := range invalid {
+		if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", name, tt.want, err)
+		}
+	}
+}
+
+func TestLoadConfig_APITokens(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+admin_api:
+  tokens:
+    - name: noc
+      token: noc-token
+      role: viewer
+    - name: oncall
+      token: oncall-token
+      role: operator
+grpc_api:
+  token: secret
+  tokens:
+    - token: noc-token
+      role: viewer
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.AdminAPI.Tokens) != 2 || cfg.AdminAPI.Tokens[0].Name != "noc" || cfg.AdminAPI.Tokens[0].EffectiveRole() != "viewer" {
+		t.Errorf("Unexpected admin_api tokens %+v", cfg.AdminAPI.Tokens)
+	}
+	if len(cfg.GRPCAPI.Tokens) != 1 || cfg.GRPCAPI.Tokens[0].EffectiveRole() != "viewer" {
+		t.Errorf("Unexpected grpc_api tokens %+v", cfg.GRPCAPI.Tokens)
+	}
+
+	invalid := map[string]struct {
+		content string
+		want    error
+	}{
+		"unknown role":   {strings.Replace(content, "role: operator", "role: admin", 1), ErrInvalidAdminAPI},
+		"missing token":  {strings.Replace(content, "      token: noc-token\n      role: viewer\n    - name: oncall", "      role: viewer\n    - name: oncall", 1), ErrInvalidAdminAPI},
+		"repeated token": {strings.Replace(content, "token: oncall-token", "token: noc-token", 1), ErrInvalidAdminAPI},
+		"token reused":   {strings.Replace(content, "token: secret", "token: noc-token", 1), ErrInvalidGRPCAPI},
+	}
+	for name, tt := range invalid {
+		if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", name, tt.want, err)
+		}
+	}
+}
+
+func TestLoadConfig_APISockets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+status_api:
+  socket: /run/gslb/status.sock
+admin_api:
+  listen: unix:/run/gslb/admin.sock
+  socket_mode: "0660"
+  token: secret
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if got := cfg.StatusAPI.Addresses(); len(got) != 2 || got[0] != DefaultStatusAPIListen || got[1] != "unix:/run/gslb/status.sock" {
+		t.Errorf("Unexpected status_api addresses %v", got)
+	}
+	if cfg.StatusAPI.EffectiveSocketMode() != 0o600 {
+		t.Errorf("Expected the default socket mode, got %v", cfg.StatusAPI.EffectiveSocketMode())
+	}
+	if got := cfg.AdminAPI.Addresses(); len(got) != 1 || got[0] != "unix:/run/gslb/admin.sock" || cfg.AdminAPI.EffectiveSocketMode() != 0o660 {
+		t.Errorf("Unexpected admin_api addresses %v and mode %v", got, cfg.AdminAPI.EffectiveSocketMode())
+	}
+
+	invalid := map[string]struct {
+		content string
+		want    error
+	}{
+		"relative socket":      {strings.Replace(content, "socket: /run/gslb/status.sock", "socket: status.sock", 1), ErrInvalidStatusAPI},
+		"relative listen path": {strings.Replace(content, "unix:/run/gslb/admin.sock", "unix:admin.sock", 1), ErrInvalidAdminAPI},
+		"invalid mode":         {strings.Replace(content, `"0660"`, `"rw-rw----"`, 1), ErrInvalidAdminAPI},
+		"mode out of range":    {strings.Replace(content, `"0660"`, `"1777"`, 1), ErrInvalidAdminAPI},
+	}
+	for name, tt := range invalid {
+		if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", name, tt.want, err)
+		}
+	}
+}
+
+func TestLoadConfig_RemoteProbers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+grpc_api:
+  token: secret
+  tokens:
+    - name: tokyo
+      token: tokyo-token
+      role: prober
+  remote_probers:
+    quorum: all
+    local: false
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	probers := cfg.GRPCAPI.RemoteProbers
+	if !probers.Enabled() || probers.EffectiveQuorum() != RemoteProbersQuorumAll || probers.LocalEnabled() {
+		t.Errorf("Unexpected remote_probers %+v", probers)
+	}
+	if got := probers.EffectiveMaxAge(cfg.CheckInterval); got != 3*time.Minute {
+		t.Errorf("Expected a max age of three check intervals, got %v", got)
+	}
+	var disabled *RemoteProbersConfig
+	if disabled.Enabled() || disabled.EffectiveQuorum() != RemoteProbersQuorumMajority || !disabled.LocalEnabled() {
+		t.Error("Expected remote probers to be disabled by default")
+	}
+
+	invalid := map[string]string{
+		"unknown quorum":   strings.Replace(content, "quorum: all", "quorum: most", 1),
+		"negative max age": strings.Replace(content, "local: false", "max_age_seconds: -1", 1),
+		"unknown role":     strings.Replace(content, "role: prober", "role: probe", 1),
+	}
+	for name, content := range invalid {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidGRPCAPI) {
+			t.Errorf("%s: expected %v, got %v", name, ErrInvalidGRPCAPI, err)
+		}
+	}
+}
+
+func TestLoadConfig_DNSDiscovery(t *testing.T) {
+	base := `
+cloudflare_api_token: token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins:
+  - name: www.example.com
+    record_type: A
+    health_check: {type: icmp}
+    priority_levels:
+      - priority: 0
+        ips: ["backends.internal.example.com"]
+`
+	cfg, err := LoadConfigData("config.yaml", []byte(base))
+	if err != nil {
+		t.Fatalf("LoadConfigData returned error: %v", err)
+	}
+	if cfg.DNSDiscovery.EffectiveRefresh() != 0 || cfg.DNSDiscovery.EffectiveResolvers() != nil {
+		t.Errorf("expected hostnames to be resolved at every check with the system resolver")
+	}
+
+	cfg, err = LoadConfigData("config.yaml", []byte(base+"dns_discovery:\n  refresh_seconds: 5m\n  resolvers: [\"10.0.0.2:53\"]\n"))
+	if err != nil {
+		t.Fatalf("LoadConfigData returned error: %v", err)
+	}
+	if cfg.DNSDiscovery.EffectiveRefresh() != 5*time.Minute || len(cfg.DNSDiscovery.EffectiveResolvers()) != 1 {
+		t.Errorf("unexpected dns_discovery %+v", cfg.DNSDiscovery)
+	}
+
+	for _, invalid := range []string{
+		"dns_discovery:\n  refresh_seconds: -1\n",
+		"dns_discovery:\n  resolvers: [\"10.0.0.2\"]\n",
+	} {
+		if _, err := LoadConfigData("config.yaml", []byte(base+invalid)); !errors.Is(err, ErrInvalidDNSDiscovery) {
+			t.Errorf("expected %v for %q, got %v", ErrInvalidDNSDiscovery, invalid, err)
+		}
+	}
+}
+
+func TestLoadConfig_PushNotifications(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+notifications:
+  - type: ntfy
+    topic: gslb-alerts
+  - type: pushover
+    token: app-token
+    user_key: user-key
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if n := cfg.Notifications[0]; n.Topic != "gslb-alerts" || n.EffectiveAPIURL() != DefaultNtfyServerURL {
+		t.Errorf("Unexpected ntfy config %+v", n)
+	}
+	if n := cfg.Notifications[1]; n.Token != "app-token" || n.UserKey != "user-key" || n.EffectiveAPIURL() != DefaultPushoverAPIURL {
+		t.Errorf("Unexpected pushover config %+v", n)
+	}
+
+	invalid := map[string]string{
+		"missing topic":    strings.Replace(content, "    topic: gslb-alerts\n", "", 1),
+		"missing token":    strings.Replace(content, "    token: app-token\n", "", 1),
+		"missing user_key": strings.Replace(content, "    user_key: user-key\n", "", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidNotification) {
+			t.Errorf("%s: expected ErrInvalidNotification, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_NotificationRoute(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+notifications:
+  - type: slack
+    webhook_url: https://hooks.slack.com/services/x
+    route:
+      zones: [example.com]
+      origins: ["api.*"]
+      events: [failover, recovery]
+      min_severity: warning
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	route := cfg.Notifications[0].Route
+	if route == nil || len(route.Zones) != 1 || route.Origins[0] != "api.*" || len(route.Events) != 2 || route.MinSeverity != "warning" {
+		t.Errorf("Unexpected route %+v", route)
+	}
+
+	invalid := map[string]string{
+		"bad pattern":      strings.Replace(content, `"api.*"`, `"api.[x"`, 1),
+		"unknown event":    strings.Replace(content, "recovery]", "outage]", 1),
+		"unknown severity": strings.Replace(content, "min_severity: warning", "min_severity: high", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidNotification) {
+			t.Errorf("%s: expected ErrInvalidNotification, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_QuietHours(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+notifications:
+  - type: slack
+    webhook_url: https://hooks.slack.com/services/x
+    quiet_hours:
+      windows:
+        - start: "22:00"
+          end: "06:00"
+          days: [mon, tue]
+          timezone: Asia/Tokyo
+      deliver: summary
+      bypass_severity: none
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	quiet := cfg.Notifications[0].QuietHours
+	if quiet == nil || len(quiet.Windows) != 1 || quiet.EffectiveDeliver() != QuietDeliverSummary || quiet.EffectiveBypassSeverity() != QuietBypassNone {
+		t.Fatalf("Unexpected quiet hours %+v", quiet)
+	}
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	if !quiet.ActiveAt(time.Date(2026, 10, 13, 2, 0, 0, 0, tokyo)) {
+		t.Error("Expected Tuesday 02:00 to be in the window started on Monday")
+	}
+	if quiet.ActiveAt(time.Date(2026, 10, 14, 12, 0, 0, 0, tokyo)) {
+		t.Error("Expected Wednesday noon to be outside the window")
+	}
+
+	var defaults *QuietHoursConfig
+	if defaults.ActiveAt(time.Now()) || defaults.EffectiveDeliver() != QuietDeliverQueue || defaults.EffectiveBypassSeverity() != DefaultQuietBypassSeverity {
+		t.Error("Expected nil quiet hours to be inactive with the defaults")
+	}
+
+	invalid := map[string]string{
+		"no windows": strings.Replace(content, `windows:
+        - start: "22:00"
+          end: "06:00"
+          days: [mon, tue]
+          timezone: Asia/Tokyo`, "windows: []", 1),
+		"bad start":        strings.Replace(content, `"22:00"`, `"25:00"`, 1),
+		"empty window":     strings.Replace(content, `"06:00"`, `"22:00"`, 1),
+		"unknown day":      strings.Replace(content, "[mon, tue]", "[monday]", 1),
+		"unknown timezone": strings.Replace(content, "Asia/Tokyo", "Mars/Olympus", 1),
+		"unknown deliver":  strings.Replace(content, "deliver: summary", "deliver: later", 1),
+		"unknown bypass":   strings.Replace(content, "bypass_severity: none", "bypass_severity: info", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidNotification) {
+			t.Errorf("%s: expected ErrInvalidNotification, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_NotificationTemplate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins:
+  - name: www.example.com
+    record_type: A
+    health_check:
+      type: icmp
+    priority_levels:
+      - priority: 1
+        ips: ["192.0.2.1"]
+    labels:
+      asset_id: A-42
+notifications:
+  - type: slack
+    webhook_url: https://hooks.slack.com/services/x
+    template:
+      title: "{{ .Description }}: {{ .Origin }}"
+      body: "{{ .Reason }}"
+      fields:
+        - name: Asset
+          value: "{{ .Labels.asset_id }}"
+          inline: true
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Origins[0].Labels["asset_id"] != "A-42" {
+		t.Errorf("Labels = %v", cfg.Origins[0].Labels)
+	}
+	tmpl := cfg.Notifications[0].Template
+	if tmpl == nil || tmpl.Title == "" || len(tmpl.Fields) != 1 || !tmpl.Fields[0].Inline {
+		t.Fatalf("Unexpected template %+v", tmpl)
+	}
+	if built, err := tmpl.MessageTemplate(); err != nil || built == nil {
+		t.Errorf("MessageTemplate() = %v, %v", built, err)
+	}
+
+	invalid := map[string]string{
+		"bad syntax":    strings.Replace(content, "{{ .Reason }}", "{{ .Reason", 1),
+		"unknown field": strings.Replace(content, "{{ .Reason }}", "{{ .Runbook }}", 1),
+		"unnamed field": strings.Replace(content, "name: Asset", `name: ""`, 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidNotification) {
+			t.Errorf("%s: expected ErrInvalidNotification, got %v", name, err)
+		}
+	}
+}
+
+func TestConfig_FlappingFor(t *testing.T) {
+	global := &FlappingConfig{Transitions: 4}
+	cfg := &Config{Flapping: global}
+	if got := cfg.FlappingFor(OriginConfig{}); got != global {
+		t.Errorf("FlappingFor() = %+v, want the global config", got)
+	}
+	disabled := &FlappingConfig{}
+	if got := cfg.FlappingFor(OriginConfig{Flapping: disabled}); got.Enabled() {
+		t.Error("Expected the origin config to replace the global one")
+	}
+	if got := (&Config{}).FlappingFor(OriginConfig{}); got.Enabled() || got.Window() != DefaultFlappingWindow {
+		t.Error("Expected no flapping detection by default")
+	}
+	if window := (&FlappingConfig{Transitions: 1, WindowSeconds: Seconds(time.Minute)}).Window(); window != time.Minute {
+		t.Errorf("Window() = %s, want 1m", window)
+	}
+}
+
+func TestLoadConfig_Escalation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+escalation:
+  intervals: ["5m", 900]
+  max_repeats: 4
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	escalation := cfg.Escalation
+	if !escalation.Enabled() || escalation.Interval(0) != 5*time.Minute || escalation.Interval(1) != 15*time.Minute || escalation.Interval(7) != 15*time.Minute {
+		t.Errorf("Unexpected escalation %+v", escalation)
+	}
+	if escalation.Exhausted(3) || !escalation.Exhausted(4) {
+		t.Error("Expected escalation to stop after max_repeats")
+	}
+	if (&EscalationConfig{Intervals: []Seconds{Seconds(time.Minute)}}).Exhausted(1000) {
+		t.Error("Expected escalation without max_repeats to go on")
+	}
+
+	invalid := map[string]string{
+		"no intervals":     strings.Replace(content, `["5m", 900]`, "[]", 1),
+		"short interval":   strings.Replace(content, `"5m"`, `"30s"`, 1),
+		"negative repeats": strings.Replace(content, "max_repeats: 4", "max_repeats: -1", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidEscalation) {
+			t.Errorf("%s: expected ErrInvalidEscalation, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_NotificationDigest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+notifications:
+  - type: slack
+    webhook_url: https://hooks.slack.com/services/x
+    digest:
+      window_seconds: 45
+  - type: ntfy
+    topic: gslb
+    digest: {}
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if digest := cfg.Notifications[0].Digest; !digest.Enabled() || digest.Window() != 45*time.Second {
+		t.Errorf("Unexpected digest %+v", digest)
+	}
+	if digest := cfg.Notifications[1].Digest; !digest.Enabled() || digest.Window() != DefaultDigestWindow {
+		t.Errorf("Expected the default window, got %+v", digest)
+	}
+	var disabled *DigestConfig
+	if disabled.Enabled() {
+		t.Error("Expected a nil digest to be disabled")
+	}
+
+	invalid := map[string]string{
+		"negative window": strings.Replace(content, "window_seconds: 45", "window_seconds: -1", 1),
+		"too long window": strings.Replace(content, "window_seconds: 45", "window_seconds: 3600", 1),
+		"opsgenie":        strings.Replace(content, "type: ntfy\n    topic: gslb", "type: opsgenie\n    api_key: key", 1),
+	}
+	for name, broken := range invalid {
+		if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidNotification) {
+			t.Errorf("%s: expected ErrInvalidNotification, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_InvalidRecordBinding(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins:
+  - name: www.example.com
+    record_type: A
+    health_check:
+      type: icmp
+    priority_levels:
+      - priority: 1
+        ips: ["192.0.2.1"]
+    record_binding: {}
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidRecordBinding) {
+		t.Fatalf("Expected ErrInvalidRecordBinding, got %v", err)
+	}
+
+	content = strings.Replace(content, "record_binding: {}", "record_binding:\n      ids: [record-1]", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if binding := cfg.Origins[0].RecordBinding; !binding.Enabled() || len(binding.IDs) != 1 || binding.IDs[0] != "record-1" {
+		t.Errorf("Unexpected record binding %+v", binding)
+	}
+}
+
+func TestLoadConfig_InvalidSpectrum(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: test-token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins:
+  - name: ssh.example.com
+    record_type: A
+    health_check:
+      type: icmp
+    priority_levels:
+      - priority: 1
+        ips: ["192.0.2.1"]
+    spectrum:
+      app_id: app-1
+      port: 22
+      protocol: sctp
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidSpectrum) {
+		t.Fatalf("Expected ErrInvalidSpectrum, got %v", err)
+	}
+
+	content = strings.Replace(content, "      protocol: sctp\n", "", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Origins[0].Spectrum.EffectiveProtocol() != SpectrumProtocolTCP {
+		t.Errorf("Expected default protocol tcp, got %s", cfg.Origins[0].Spectrum.EffectiveProtocol())
+	}
+}
+
+func TestLoadConfig_Accounts(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+cloudflare_api_token: global-token
+cloudflare_accounts:
+  - account_id: acct-a
+    name: Customer A
+    api_token: token-a
+  - account_id: acct-b
+    api_key: key-b
+    api_email: ops@customer-b.example
+  - account_id: acct-c
+cloudflare_zones:
+  - zone_id: zone-1
+    name: customer-a.example
+    account_id: acct-a
+  - zone_id: zone-2
+    name: customer-b.example
+    account_id: acct-b
+  - zone_id: zone-3
+    name: customer-a-scoped.example
+    account_id: acct-a
+    api_token: scoped-token
+  - zone_id: zone-4
+    name: customer-c.example
+    account_id: acct-c
+check_interval_seconds: 60
+origins: []
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	tests := map[string]APICredentials{
+		"customer-a.example":        {APIToken: "token-a"},
+		"customer-b.example":        {APIKey: "key-b", APIEmail: "ops@customer-b.example"},
+		"customer-a-scoped.example": {APIToken: "scoped-token"},
+		// Accounts without credentials use the global ones
+		"customer-c.example": {APIToken: "global-token"},
+	}
+	for zone, want := range tests {
+		if got := cfg.CredentialsForZone(zone); got != want {
+			t.Errorf("CredentialsForZone(%s) = %+v, want %+v", zone, got, want)
+		}
+	}
+	if account, ok := cfg.Account("acct-a"); !ok || account.DisplayName() != "Customer A" {
+		t.Errorf("Unexpected account %+v", account)
+	}
+
+	content = strings.Replace(content, "account_id: acct-c\ncloudflare_zones", "account_id: acct-a\ncloudflare_zones", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidAccount) {
+		t.Fatalf("Expected ErrInvalidAccount, got %v", err)
+	}
+}
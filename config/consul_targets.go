@@ -0,0 +1,84 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidConsulTarget is returned when a consul: entry of ips does not name a service or has unknown options
+var ErrInvalidConsulTarget = errors.New("invalid consul target")
+
+// consulTargetPrefix はConsulのサービスを指すフェイルオーバー先の接頭辞
+const consulTargetPrefix = "consul:"
+
+// defaultConsulAddress はconsulのaddressを省略した場合に使うローカルのエージェント
+const defaultConsulAddress = "http://127.0.0.1:8500"
+
+// consul:のaddressで選ぶインスタンスのアドレス
+const (
+	ConsulAddressLAN = "lan"
+	ConsulAddressWAN = "wan"
+)
+
+// ConsulConfig はフェイルオーバー先のサービスを読み込むConsulエージェントの設定を表す構造体
+type ConsulConfig struct {
+	Address string `json:"address,omitempty" yaml:"address,omitempty"` // http://127.0.0.1:8500 のようなURL（省略時はローカルのエージェント）
+	Token   string `json:"token,omitempty" yaml:"token,omitempty"`     // ConsulのACLトークン
+}
+
+// EffectiveAddress はエージェントのURLを返す（未設定の場合はローカルのエージェント）
+func (c *ConsulConfig) EffectiveAddress() string {
+	if c == nil || c.Address == "" {
+		return defaultConsulAddress
+	}
+	return c.Address
+}
+
+// EffectiveToken はACLトークンを返す（未設定の場合は空）
+func (c *ConsulConfig) EffectiveToken() string {
+	if c == nil {
+		return ""
+	}
+	return c.Token
+}
+
+// ConsulTarget はフェイルオーバー先のIPを読み込むConsulのサービスを表す構造体
+// "consul:service?tag=primary&dc=tokyo&address=wan" の形式で指定する
+type ConsulTarget struct {
+	Service    string
+	Tags       []string // インスタンスにすべて付いている必要があるタグ
+	Datacenter string   // 省略時はエージェントのデータセンター
+	Address    string   // "lan"（デフォルト）または "wan"
+}
+
+// IsConsulTarget はsがConsulのサービスを指すフェイルオーバー先かどうかを返す
+func IsConsulTarget(s string) bool {
+	return strings.HasPrefix(s, consulTargetPrefix)
+}
+
+// ParseConsulTarget は "consul:service" 形式のフェイルオーバー先を解析する
+func ParseConsulTarget(s string) (ConsulTarget, error) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme+":" != consulTargetPrefix || u.Opaque == "" || strings.Contains(u.Opaque, "/") {
+		return ConsulTarget{}, fmt.Errorf("%w %q: expected consul:service", ErrInvalidConsulTarget, s)
+	}
+	target := ConsulTarget{Service: u.Opaque, Address: ConsulAddressLAN}
+	for key, values := range u.Query() {
+		switch key {
+		case "tag":
+			target.Tags = values
+		case "dc":
+			target.Datacenter = values[len(values)-1]
+		case "address":
+			target.Address = values[len(values)-1]
+		default:
+			return ConsulTarget{}, fmt.Errorf("%w %q: unknown option %s", ErrInvalidConsulTarget, s, key)
+		}
+	}
+	if target.Address != ConsulAddressLAN && target.Address != ConsulAddressWAN {
+		return ConsulTarget{}, fmt.Errorf("%w %q: address must be lan or wan", ErrInvalidConsulTarget, s)
+	}
+	return target, nil
+}
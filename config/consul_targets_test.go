@@ -0,0 +1,46 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseConsulTarget(t *testing.T) {
+	tests := []struct {
+		target  string
+		want    ConsulTarget
+		wantErr bool
+	}{
+		{target: "consul:web", want: ConsulTarget{Service: "web", Address: "lan"}},
+		{target: "consul:web?tag=primary&tag=v2&dc=tokyo&address=wan", want: ConsulTarget{Service: "web", Tags: []string{"primary", "v2"}, Datacenter: "tokyo", Address: "wan"}},
+		{target: "consul:", wantErr: true},
+		{target: "consul:web/api", wantErr: true},
+		{target: "consul:web?address=lb", wantErr: true},
+		{target: "consul:web?near=_agent", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseConsulTarget(tt.target)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseConsulTarget(%q) error = %v, wantErr %v", tt.target, err, tt.wantErr)
+			continue
+		}
+		if err != nil && !errors.Is(err, ErrInvalidConsulTarget) {
+			t.Errorf("ParseConsulTarget(%q) error = %v, want %v", tt.target, err, ErrInvalidConsulTarget)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseConsulTarget(%q) = %+v, want %+v", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestConsulConfig_Defaults(t *testing.T) {
+	var c *ConsulConfig
+	if c.EffectiveAddress() != "http://127.0.0.1:8500" || c.EffectiveToken() != "" {
+		t.Errorf("expected the local agent without a token, got %s", c.EffectiveAddress())
+	}
+	c = &ConsulConfig{Address: "https://consul.example.net", Token: "secret"}
+	if c.EffectiveAddress() != "https://consul.example.net" || c.EffectiveToken() != "secret" {
+		t.Errorf("unexpected settings %+v", c)
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultDigestWindow はdigestのwindow_seconds省略時にイベントをまとめる時間
+const DefaultDigestWindow = 30 * time.Second
+
+// MaxDigestWindow はdigestのwindow_secondsの上限（アラートが長く遅れないようにする）
+const MaxDigestWindow = 10 * time.Minute
+
+// DigestConfig は短い時間に起きたイベントを1件の通知にまとめる設定を表す構造体
+type DigestConfig struct {
+	WindowSeconds Seconds `json:"window_seconds,omitempty" yaml:"window_seconds,omitempty"` // 最初のイベントからまとめる時間（省略時は30秒）
+}
+
+// Enabled はイベントをまとめるかどうかを返す
+func (c *DigestConfig) Enabled() bool {
+	return c != nil
+}
+
+// Window は最初のイベントからまとめる時間を返す
+func (c *DigestConfig) Window() time.Duration {
+	if c == nil || c.WindowSeconds <= 0 {
+		return DefaultDigestWindow
+	}
+	return c.WindowSeconds.Duration()
+}
+
+func validateDigest(i int, c NotificationConfig) error {
+	if c.Digest == nil {
+		return nil
+	}
+	if c.Type == NotificationOpsgenie {
+		// Opsgenie closes alerts per origin, which a digest would break
+		return fmt.Errorf("%w: notifications[%d]: digest is not supported for opsgenie", ErrInvalidNotification, i)
+	}
+	if c.Digest.WindowSeconds < 0 || c.Digest.WindowSeconds.Duration() > MaxDigestWindow {
+		return fmt.Errorf("%w: notifications[%d]: digest window_seconds must be between 0 and %d", ErrInvalidNotification, i, int(MaxDigestWindow.Seconds()))
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// DefaultDiscordMentionSeverity はmention_severity省略時にメンションする最低の重要度
+const DefaultDiscordMentionSeverity = "critical"
+
+// DiscordConfig はDiscordの通知でメンションする相手とイベントの種類ごとの送信先を表す構造体
+type DiscordConfig struct {
+	MentionRoles    []string          `json:"mention_roles,omitempty" yaml:"mention_roles,omitempty"`       // メンションするロールのID
+	MentionUsers    []string          `json:"mention_users,omitempty" yaml:"mention_users,omitempty"`       // メンションするユーザーのID
+	MentionHere     bool              `json:"mention_here,omitempty" yaml:"mention_here,omitempty"`         // @hereでチャンネルのオンラインのメンバーにメンションするかどうか
+	MentionSeverity string            `json:"mention_severity,omitempty" yaml:"mention_severity,omitempty"` // メンションする最低の重要度（"info"、"warning"、"critical"、省略時は"critical"）
+	Webhooks        map[string]string `json:"webhooks,omitempty" yaml:"webhooks,omitempty"`                 // イベントの種類ごとのWebhookのURL（一致しないイベントはwebhook_urlに送信）
+}
+
+// EffectiveMentionSeverity はメンションする最低の重要度を返す
+func (c *DiscordConfig) EffectiveMentionSeverity() string {
+	if c == nil || c.MentionSeverity == "" {
+		return DefaultDiscordMentionSeverity
+	}
+	return c.MentionSeverity
+}
+
+func validateDiscord(i int, c NotificationConfig) error {
+	if c.Discord == nil {
+		return nil
+	}
+	if c.Type != NotificationDiscord {
+		return fmt.Errorf("%w: notifications[%d]: discord is only supported for discord", ErrInvalidNotification, i)
+	}
+	for _, id := range slices.Concat(c.Discord.MentionRoles, c.Discord.MentionUsers) {
+		if id == "" || strings.Trim(id, "0123456789") != "" {
+			return fmt.Errorf("%w: notifications[%d]: discord mention %q must be a numeric role or user ID", ErrInvalidNotification, i, id)
+		}
+	}
+	if c.Discord.MentionSeverity != "" && !slices.Contains(notificationSeverities, c.Discord.MentionSeverity) {
+		return fmt.Errorf("%w: notifications[%d]: discord mention_severity %q must be info, warning or critical", ErrInvalidNotification, i, c.Discord.MentionSeverity)
+	}
+	for event, webhookURL := range c.Discord.Webhooks {
+		if !slices.Contains(notificationRouteEvents, event) {
+			return fmt.Errorf("%w: notifications[%d]: unknown discord webhook event %q", ErrInvalidNotification, i, event)
+		}
+		u, err := url.Parse(webhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: notifications[%d]: discord webhook for %s must be an http(s) URL", ErrInvalidNotification, i, event)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ErrInvalidDNSDiscovery is returned when dns_discovery has a negative refresh interval or a resolver that is not host:port
+var ErrInvalidDNSDiscovery = errors.New("invalid dns_discovery")
+
+// DNSDiscoveryConfig はipsに書いたホスト名（ディスカバリ用のホスト名）を解決する方法を表す構造体
+type DNSDiscoveryConfig struct {
+	RefreshSeconds Seconds  `json:"refresh_seconds,omitempty" yaml:"refresh_seconds,omitempty"` // 解決した結果を使い続ける時間（省略時はチェックのたびに解決）
+	Resolvers      []string `json:"resolvers,omitempty" yaml:"resolvers,omitempty"`             // 問い合わせるリゾルバ（host:port、省略時はシステムのリゾルバ）
+}
+
+// EffectiveRefresh はホスト名を解決し直す間隔を返す（0はチェックのたび）
+func (c *DNSDiscoveryConfig) EffectiveRefresh() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return c.RefreshSeconds.Duration()
+}
+
+// EffectiveResolvers は問い合わせるリゾルバを返す（空の場合はシステムのリゾルバ）
+func (c *DNSDiscoveryConfig) EffectiveResolvers() []string {
+	if c == nil {
+		return nil
+	}
+	return c.Resolvers
+}
+
+func validateDNSDiscovery(c *DNSDiscoveryConfig) error {
+	if c == nil {
+		return nil
+	}
+	if c.RefreshSeconds < 0 {
+		return fmt.Errorf("%w: refresh_seconds must not be negative", ErrInvalidDNSDiscovery)
+	}
+	for _, resolver := range c.Resolvers {
+		if _, _, err := net.SplitHostPort(resolver); err != nil {
+			return fmt.Errorf("%w: resolver %q must be host:port", ErrInvalidDNSDiscovery, resolver)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlDocument はYAMLをdocNodeに変換する
+// エイリアスは参照先を、マージキー（<<）は統合される各キーを検証の対象にする
+func yamlDocument(data []byte) (*docNode, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return &docNode{kind: docNull}, nil
+	}
+	return yamlNode(root.Content[0]), nil
+}
+
+func yamlNode(n *yaml.Node) *docNode {
+	switch n.Kind {
+	case yaml.AliasNode:
+		return yamlNode(n.Alias)
+	case yaml.MappingNode:
+		node := &docNode{kind: docObject, line: n.Line}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.ShortTag() == "!!merge" {
+				node.keys = append(node.keys, yamlMergedEntries(value)...)
+				continue
+			}
+			node.keys = append(node.keys, docEntry{key: key.Value, line: key.Line, value: yamlNode(value)})
+		}
+		return node
+	case yaml.SequenceNode:
+		node := &docNode{kind: docArray, line: n.Line}
+		for _, item := range n.Content {
+			node.items = append(node.items, yamlNode(item))
+		}
+		return node
+	default:
+		if n.ShortTag() == "!!null" {
+			return &docNode{kind: docNull, line: n.Line}
+		}
+		return &docNode{kind: docScalar, line: n.Line}
+	}
+}
+
+// yamlMergedEntries は <<: *anchor または <<: [*a, *b] で統合されるキーを返す
+func yamlMergedEntries(n *yaml.Node) []docEntry {
+	merged := yamlNode(n)
+	switch merged.kind {
+	case docObject:
+		return merged.keys
+	case docArray:
+		var entries []docEntry
+		for _, item := range merged.items {
+			entries = append(entries, item.keys...)
+		}
+		return entries
+	default:
+		return nil
+	}
+}
+
+// jsonDocument はJSONをdocNodeに変換する
+func jsonDocument(data []byte) (*docNode, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	lines := newLineIndex(data)
+	return jsonValue(dec, lines)
+}
+
+func jsonValue(dec *json.Decoder, lines lineIndex) (*docNode, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	line := lines.at(dec.InputOffset())
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			node := &docNode{kind: docObject, line: line}
+			for dec.More() {
+				keyToken, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := keyToken.(string)
+				if !ok {
+					return nil, fmt.Errorf("unexpected %v", keyToken)
+				}
+				keyLine := lines.at(dec.InputOffset())
+				value, err := jsonValue(dec, lines)
+				if err != nil {
+					return nil, err
+				}
+				node.keys = append(node.keys, docEntry{key: key, line: keyLine, value: value})
+			}
+			_, err := dec.Token() // }
+			return node, err
+		case '[':
+			node := &docNode{kind: docArray, line: line}
+			for dec.More() {
+				item, err := jsonValue(dec, lines)
+				if err != nil {
+					return nil, err
+				}
+				node.items = append(node.items, item)
+			}
+			_, err := dec.Token() // ]
+			return node, err
+		}
+	case string:
+		return &docNode{kind: docString, line: line}, nil
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return &docNode{kind: docInteger, line: line}, nil
+		}
+		return &docNode{kind: docNumber, line: line}, nil
+	case bool:
+		return &docNode{kind: docBool, line: line}, nil
+	case nil:
+		return &docNode{kind: docNull, line: line}, nil
+	}
+	return nil, errors.New("unexpected JSON token")
+}
+
+// tomlDocument はTOMLをdocNodeに変換する（行番号は記録しない）
+func tomlDocument(data []byte) (*docNode, error) {
+	doc, err := parseTOML(string(data))
+	if err != nil {
+		return nil, err
+	}
+	return tomlValue(doc), nil
+}
+
+func tomlValue(value any) *docNode {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		node := &docNode{kind: docObject}
+		for _, key := range keys {
+			node.keys = append(node.keys, docEntry{key: key, value: tomlValue(v[key])})
+		}
+		return node
+	case []any:
+		return tomlArray(v)
+	case tomlTableArray:
+		return tomlArray(v)
+	case string:
+		return &docNode{kind: docString}
+	case int64:
+		return &docNode{kind: docInteger}
+	case float64:
+		return &docNode{kind: docNumber}
+	case bool:
+		return &docNode{kind: docBool}
+	default:
+		return &docNode{kind: docNull}
+	}
+}
+
+func tomlArray(values []any) *docNode {
+	node := &docNode{kind: docArray}
+	for _, value := range values {
+		node.items = append(node.items, tomlValue(value))
+	}
+	return node
+}
+
+// lineIndex はバイトオフセットを行番号に変換する
+type lineIndex []int
+
+func newLineIndex(data []byte) lineIndex {
+	index := lineIndex{0}
+	for i, b := range data {
+		if b == '\n' {
+			index = append(index, i+1)
+		}
+	}
+	return index
+}
+
+// at はoffsetの直前の文字がある行を返す（json.Decoder.InputOffsetはトークンの直後を指す）
+func (l lineIndex) at(offset int64) int {
+	return sort.Search(len(l), func(i int) bool { return int64(l[i]) >= offset })
+}
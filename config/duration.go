@@ -0,0 +1,136 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidDuration is returned when a duration is neither a number nor a Go duration string
+var ErrInvalidDuration = errors.New("invalid duration")
+
+// Seconds は秒単位の時間の設定値
+// 単位のない数値（小数も可）は秒として、文字列は"30s"や"1m30s"のようなGoの時間表記として読み込む
+type Seconds time.Duration
+
+// Milliseconds はミリ秒単位の時間の設定値（単位のない数値はミリ秒として読み込む）
+type Milliseconds time.Duration
+
+// Duration は設定値をtime.Durationとして返す
+func (s Seconds) Duration() time.Duration {
+	return time.Duration(s)
+}
+
+// UnmarshalJSON は数値または時間表記の文字列を読み込む
+func (s *Seconds) UnmarshalJSON(data []byte) error {
+	return unmarshalDurationJSON(data, time.Second, (*time.Duration)(s))
+}
+
+// UnmarshalYAML は数値または時間表記の文字列を読み込む
+func (s *Seconds) UnmarshalYAML(value *yaml.Node) error {
+	return unmarshalDurationYAML(value, time.Second, (*time.Duration)(s))
+}
+
+// UnmarshalText は数値または時間表記の文字列を読み込む（コマンドラインフラグや環境変数用）
+func (s *Seconds) UnmarshalText(text []byte) error {
+	d, err := parseDuration(string(text), time.Second)
+	if err != nil {
+		return err
+	}
+	*s = Seconds(d)
+	return nil
+}
+
+// MarshalJSON は秒の整数で表せる値を数値として、それ以外を時間表記として書き出す
+func (s Seconds) MarshalJSON() ([]byte, error) {
+	return json.Marshal(marshalDuration(time.Duration(s), time.Second))
+}
+
+// MarshalYAML はMarshalJSONと同じ形式で書き出す
+func (s Seconds) MarshalYAML() (any, error) {
+	return marshalDuration(time.Duration(s), time.Second), nil
+}
+
+// Duration は設定値をtime.Durationとして返す
+func (m Milliseconds) Duration() time.Duration {
+	return time.Duration(m)
+}
+
+// UnmarshalJSON は数値または時間表記の文字列を読み込む
+func (m *Milliseconds) UnmarshalJSON(data []byte) error {
+	return unmarshalDurationJSON(data, time.Millisecond, (*time.Duration)(m))
+}
+
+// UnmarshalYAML は数値または時間表記の文字列を読み込む
+func (m *Milliseconds) UnmarshalYAML(value *yaml.Node) error {
+	return unmarshalDurationYAML(value, time.Millisecond, (*time.Duration)(m))
+}
+
+// MarshalJSON はミリ秒の整数で表せる値を数値として、それ以外を時間表記として書き出す
+func (m Milliseconds) MarshalJSON() ([]byte, error) {
+	return json.Marshal(marshalDuration(time.Duration(m), time.Millisecond))
+}
+
+// MarshalYAML はMarshalJSONと同じ形式で書き出す
+func (m Milliseconds) MarshalYAML() (any, error) {
+	return marshalDuration(time.Duration(m), time.Millisecond), nil
+}
+
+func unmarshalDurationJSON(data []byte, unit time.Duration, d *time.Duration) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value := string(data)
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	}
+	parsed, err := parseDuration(value, unit)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func unmarshalDurationYAML(value *yaml.Node, unit time.Duration, d *time.Duration) error {
+	if value.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: %w: expected a number or a duration string", value.Line, ErrInvalidDuration)
+	}
+	if value.ShortTag() == "!!null" {
+		return nil
+	}
+	parsed, err := parseDuration(value.Value, unit)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	*d = parsed
+	return nil
+}
+
+// parseDuration は単位のない数値をunit単位として、それ以外をGoの時間表記として解釈する
+func parseDuration(value string, unit time.Duration) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if n, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(n, 0) && !math.IsNaN(n) {
+		return time.Duration(n * float64(unit)), nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q: use a number or a duration such as \"30s\" or \"500ms\"", ErrInvalidDuration, value)
+	}
+	return d, nil
+}
+
+func marshalDuration(d, unit time.Duration) any {
+	if d%unit == 0 {
+		return int64(d / unit)
+	}
+	return d.String()
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSeconds_Unmarshal(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{`30`, 30 * time.Second},
+		{`0.5`, 500 * time.Millisecond},
+		{`"45"`, 45 * time.Second},
+		{`"500ms"`, 500 * time.Millisecond},
+		{`"1m30s"`, 90 * time.Second},
+		{`null`, 0},
+	}
+	for _, tt := range tests {
+		var fromJSON Seconds
+		if err := json.Unmarshal([]byte(tt.value), &fromJSON); err != nil {
+			t.Errorf("JSON %s: unexpected error %v", tt.value, err)
+		} else if fromJSON.Duration() != tt.want {
+			t.Errorf("JSON %s: got %v, want %v", tt.value, fromJSON.Duration(), tt.want)
+		}
+
+		var fromYAML Seconds
+		if err := yaml.Unmarshal([]byte(tt.value), &fromYAML); err != nil {
+			t.Errorf("YAML %s: unexpected error %v", tt.value, err)
+		} else if fromYAML.Duration() != tt.want {
+			t.Errorf("YAML %s: got %v, want %v", tt.value, fromYAML.Duration(), tt.want)
+		}
+	}
+}
+
+func TestMilliseconds_Unmarshal(t *testing.T) {
+	var d Milliseconds
+	if err := json.Unmarshal([]byte(`250`), &d); err != nil || d.Duration() != 250*time.Millisecond {
+		t.Errorf("Expected 250ms, got %v (%v)", d.Duration(), err)
+	}
+	if err := yaml.Unmarshal([]byte(`2s`), &d); err != nil || d.Duration() != 2*time.Second {
+		t.Errorf("Expected 2s, got %v (%v)", d.Duration(), err)
+	}
+}
+
+func TestSeconds_UnmarshalInvalid(t *testing.T) {
+	for _, value := range []string{`"soon"`, `"10 minutes"`, `"Inf"`} {
+		var d Seconds
+		if err := json.Unmarshal([]byte(value), &d); !errors.Is(err, ErrInvalidDuration) {
+			t.Errorf("JSON %s: expected ErrInvalidDuration, got %v", value, err)
+		}
+	}
+	var d Seconds
+	if err := yaml.Unmarshal([]byte("[1, 2]"), &d); !errors.Is(err, ErrInvalidDuration) {
+		t.Errorf("Expected ErrInvalidDuration for a sequence, got %v", err)
+	}
+}
+
+func TestSeconds_Marshal(t *testing.T) {
+	for d, want := range map[Seconds]string{
+		Seconds(30 * time.Second):        `30`,
+		Seconds(1500 * time.Millisecond): `"1.5s"`,
+	} {
+		data, err := json.Marshal(d)
+		if err != nil || string(data) != want {
+			t.Errorf("Marshal(%v) = %s (%v), want %s", d.Duration(), data, err, want)
+		}
+		var back Seconds
+		if err := json.Unmarshal(data, &back); err != nil || back != d {
+			t.Errorf("Round trip of %v gave %v (%v)", d.Duration(), back.Duration(), err)
+		}
+	}
+	if data, _ := json.Marshal(Milliseconds(250 * time.Millisecond)); string(data) != `250` {
+		t.Errorf("Expected milliseconds to marshal as 250, got %s", data)
+	}
+}
+
+func TestLoadConfig_DurationStrings(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+cloudflare_api_token: token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 1m
+api_retry:
+  base_delay_ms: 1s
+origins:
+  - name: www
+    zone_name: example.com
+    record_type: A
+    health_check:
+      type: https
+      endpoint: /health
+      timeout: 750ms
+    priority_levels:
+      - priority: 0
+        ips: ["192.0.2.1"]
+`,
+		"config.json": `{
+  "cloudflare_api_token": "token",
+  "cloudflare_zones": [{"zone_id": "zone-1", "name": "example.com"}],
+  "check_interval_seconds": "1m",
+  "api_retry": {"base_delay_ms": "1s"},
+  "origins": [{
+    "name": "www",
+    "zone_name": "example.com",
+    "record_type": "A",
+    "health_check": {"type": "https", "endpoint": "/health", "timeout": "750ms"},
+    "priority_levels": [{"priority": 0, "ips": ["192.0.2.1"]}]
+  }]
+}`,
+		"config.toml": `
+cloudflare_api_token = "token"
+check_interval_seconds = "1m"
+
+[api_retry]
+base_delay_ms = "1s"
+
+[[cloudflare_zones]]
+zone_id = "zone-1"
+name = "example.com"
+
+[[origins]]
+name = "www"
+zone_name = "example.com"
+record_type = "A"
+health_check = { type = "https", endpoint = "/health", timeout = 0.75 }
+priority_levels = [{ priority = 0, ips = ["192.0.2.1"] }]
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			writeConfigFile(t, path, content)
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("LoadConfig returned error: %v", err)
+			}
+			if cfg.CheckInterval != time.Minute {
+				t.Errorf("Expected a 1m check interval, got %v", cfg.CheckInterval)
+			}
+			if cfg.APIRetry.BaseDelay() != time.Second {
+				t.Errorf("Expected a 1s base delay, got %v", cfg.APIRetry.BaseDelay())
+			}
+			if got := cfg.Origins[0].HealthCheck.Timeout.Duration(); got != 750*time.Millisecond {
+				t.Errorf("Expected a 750ms timeout, got %v", got)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_InvalidDuration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, path, "cloudflare_api_token: token\ncheck_interval_seconds: soon\n")
+	_, err := LoadConfig(path)
+	if !errors.Is(err, ErrInvalidDuration) || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected ErrInvalidDuration with the line number, got %v", err)
+	}
+}
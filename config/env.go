@@ -0,0 +1,47 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrUndefinedEnvVar is returned when the config references an environment variable that is not set and has no default
+var ErrUndefinedEnvVar = errors.New("undefined environment variable")
+
+// envRefPattern は ${VAR}、${VAR:-default}、およびエスケープされた $${...} に一致する
+var envRefPattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\}`)
+
+// expandEnv は設定ファイル中の ${VAR} を環境変数の値に置き換える
+// ${VAR:-default} は未設定または空の場合にdefaultを使用し、$${VAR} は ${VAR} のまま残す
+// 値はそのまま埋め込まれるため、JSONやYAMLとして特別な意味を持つ文字はエスケープされない
+func expandEnv(data []byte, lookup func(string) (string, bool)) ([]byte, error) {
+	undefined := make(map[string]struct{})
+	expanded := envRefPattern.ReplaceAllStringFunc(string(data), func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+		match := envRefPattern.FindStringSubmatch(ref)
+		name, fallback := match[1], match[2]
+		if value, ok := lookup(name); ok && (value != "" || fallback == "") {
+			return value
+		}
+		if fallback != "" {
+			return strings.TrimPrefix(fallback, ":-")
+		}
+		undefined[name] = struct{}{}
+		return ref
+	})
+
+	if len(undefined) > 0 {
+		names := make([]string, 0, len(undefined))
+		for name := range undefined {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%w: %s", ErrUndefinedEnvVar, strings.Join(names, ", "))
+	}
+	return []byte(expanded), nil
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	env := map[string]string{"CF_API_TOKEN": "secret", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "variable", input: `token: ${CF_API_TOKEN}`, want: `token: secret`},
+		{name: "default unused", input: `token: ${CF_API_TOKEN:-fallback}`, want: `token: secret`},
+		{name: "default for unset", input: `token: ${UNSET:-fallback}`, want: `token: fallback`},
+		{name: "default for empty", input: `token: ${EMPTY:-fallback}`, want: `token: fallback`},
+		{name: "empty without default", input: `token: "${EMPTY}"`, want: `token: ""`},
+		{name: "escaped", input: `token: $${CF_API_TOKEN}`, want: `token: ${CF_API_TOKEN}`},
+		{name: "bare dollar", input: `password: pa$$word $HOME`, want: `password: pa$$word $HOME`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandEnv([]byte(tt.input), lookup)
+			if err != nil {
+				t.Fatalf("expandEnv() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("expandEnv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := expandEnv([]byte(`a: ${UNSET_B} b: ${UNSET_A}`), lookup); !errors.Is(err, ErrUndefinedEnvVar) {
+		t.Fatalf("Expected ErrUndefinedEnvVar, got %v", err)
+	} else if err.Error() != "undefined environment variable: UNSET_A, UNSET_B" {
+		t.Errorf("Expected every undefined variable to be reported, got %v", err)
+	}
+}
+
+func TestLoadConfig_ExpandsEnv(t *testing.T) {
+	t.Setenv("GSLB_TEST_API_TOKEN", "token-from-env")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	content := `{
+  "cloudflare_api_token": "${GSLB_TEST_API_TOKEN}",
+  "cloudflare_zones": [{"zone_id": "${GSLB_TEST_ZONE_ID:-zone-1}", "name": "example.com"}],
+  "check_interval_seconds": 60,
+  "origins": []
+}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.CloudflareAPIToken != "token-from-env" {
+		t.Errorf("Expected token from the environment, got %q", cfg.CloudflareAPIToken)
+	}
+	if cfg.CloudflareZoneIDs[0].ZoneID != "zone-1" {
+		t.Errorf("Expected default zone ID, got %q", cfg.CloudflareZoneIDs[0].ZoneID)
+	}
+}
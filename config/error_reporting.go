@@ -0,0 +1,60 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/sentry"
+)
+
+// ErrInvalidErrorReporting is returned when error_reporting has no valid DSN or a negative repeat interval
+var ErrInvalidErrorReporting = errors.New("invalid error_reporting config")
+
+// EnvSentryDSN はdsnを省略したときに参照する環境変数
+const EnvSentryDSN = "SENTRY_DSN"
+
+// DefaultErrorRepeatInterval はrepeat_interval_secondsを省略したときに同じエラーを再送する間隔（1時間）
+const DefaultErrorRepeatInterval = Seconds(sentry.DefaultRepeatInterval)
+
+// ErrorReportingConfig はpanicや繰り返し発生するエラーをSentry互換のサービスへ送信する設定を表す構造体
+type ErrorReportingConfig struct {
+	DSN                   string  `json:"dsn,omitempty" yaml:"dsn,omitempty"`                                         // SentryのDSN（省略時はSENTRY_DSN）
+	Environment           string  `json:"environment,omitempty" yaml:"environment,omitempty"`                         // 送信するenvironment（"production" など）
+	Release               string  `json:"release,omitempty" yaml:"release,omitempty"`                                 // 送信するrelease
+	RepeatIntervalSeconds Seconds `json:"repeat_interval_seconds,omitempty" yaml:"repeat_interval_seconds,omitempty"` // 同じエラーが続くときに再送する間隔（省略時は1時間）
+}
+
+// Enabled はエラーの送信が有効かどうかを返す
+func (c *ErrorReportingConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveDSN は送信先のDSNを返す
+func (c *ErrorReportingConfig) EffectiveDSN() string {
+	if c != nil && c.DSN != "" {
+		return c.DSN
+	}
+	return os.Getenv(EnvSentryDSN)
+}
+
+// EffectiveRepeatInterval は同じエラーを再送する間隔を返す
+func (c *ErrorReportingConfig) EffectiveRepeatInterval() Seconds {
+	if c == nil || c.RepeatIntervalSeconds == 0 {
+		return DefaultErrorRepeatInterval
+	}
+	return c.RepeatIntervalSeconds
+}
+
+func validateErrorReporting(c *ErrorReportingConfig) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if err := sentry.ValidateDSN(c.EffectiveDSN()); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidErrorReporting, err)
+	}
+	if c.RepeatIntervalSeconds < 0 {
+		return fmt.Errorf("%w: repeat_interval_seconds must not be negative", ErrInvalidErrorReporting)
+	}
+	return nil
+}
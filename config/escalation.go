@@ -0,0 +1,55 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidEscalation is returned when escalation has no intervals or one shorter than a minute
+var ErrInvalidEscalation = errors.New("invalid escalation config")
+
+// MinEscalationInterval はescalationのintervalsに指定できる最短の間隔
+const MinEscalationInterval = time.Minute
+
+// EscalationConfig は正常なIPがなくなったオリジンの通知を解消まで繰り返す設定を表す構造体
+type EscalationConfig struct {
+	Intervals  []Seconds `json:"intervals" yaml:"intervals"`                         // 前回の通知から再送するまでの間隔（最後の間隔で繰り返す）
+	MaxRepeats int       `json:"max_repeats,omitempty" yaml:"max_repeats,omitempty"` // 再送の上限（省略時は解消まで）
+}
+
+// Enabled は通知の再送が有効かどうかを返す
+func (c *EscalationConfig) Enabled() bool {
+	return c != nil && len(c.Intervals) > 0
+}
+
+// Interval はrepeat回再送した後、次に再送するまでの間隔を返す
+func (c *EscalationConfig) Interval(repeat int) time.Duration {
+	if repeat < len(c.Intervals) {
+		return c.Intervals[repeat].Duration()
+	}
+	return c.Intervals[len(c.Intervals)-1].Duration()
+}
+
+// Exhausted はrepeat回再送した後に再送をやめるかどうかを返す
+func (c *EscalationConfig) Exhausted(repeat int) bool {
+	return c.MaxRepeats > 0 && repeat >= c.MaxRepeats
+}
+
+func validateEscalation(c *EscalationConfig) error {
+	if c == nil {
+		return nil
+	}
+	if len(c.Intervals) == 0 {
+		return fmt.Errorf("%w: intervals is required", ErrInvalidEscalation)
+	}
+	for _, interval := range c.Intervals {
+		if interval.Duration() < MinEscalationInterval {
+			return fmt.Errorf("%w: interval %s is shorter than %s", ErrInvalidEscalation, interval.Duration(), MinEscalationInterval)
+		}
+	}
+	if c.MaxRepeats < 0 {
+		return fmt.Errorf("%w: max_repeats must not be negative", ErrInvalidEscalation)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidEventHistory is returned when event_history has no file or a negative retention
+var ErrInvalidEventHistory = errors.New("invalid event_history config")
+
+// DefaultEventRetention はretention_secondsを省略したときのイベントの保持期間（30日）
+const DefaultEventRetention = Seconds(30 * 24 * time.Hour)
+
+// EventHistoryConfig はヘルス状態の変化とDNSの変更をファイルに記録し、後から参照できるようにする設定を表す構造体
+type EventHistoryConfig struct {
+	File             string  `json:"file" yaml:"file"`                                               // イベントを記録するファイルのパス
+	RetentionSeconds Seconds `json:"retention_seconds,omitempty" yaml:"retention_seconds,omitempty"` // イベントの保持期間（省略時は30日）
+}
+
+// Enabled はイベント履歴の記録が有効かどうかを返す
+func (c *EventHistoryConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveRetention はイベントの保持期間を返す
+func (c *EventHistoryConfig) EffectiveRetention() Seconds {
+	if c == nil || c.RetentionSeconds == 0 {
+		return DefaultEventRetention
+	}
+	return c.RetentionSeconds
+}
+
+func validateEventHistory(c *EventHistoryConfig) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.File == "" {
+		return fmt.Errorf("%w: file is required", ErrInvalidEventHistory)
+	}
+	if c.RetentionSeconds < 0 {
+		return fmt.Errorf("%w: retention_seconds must not be negative", ErrInvalidEventHistory)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultExecTimeout はexecのtimeout_seconds省略時にコマンドを止めるまでの時間
+const DefaultExecTimeout = 30 * time.Second
+
+// DefaultExecMaxConcurrent はexecのmax_concurrent省略時に同時に実行するコマンドの数
+const DefaultExecMaxConcurrent = 4
+
+// ExecConfig はイベントごとにローカルのコマンドを実行する通知の設定を表す構造体
+type ExecConfig struct {
+	Command        []string          `json:"command" yaml:"command"`                                     // 実行するコマンドと引数（シェルは介さない）
+	Env            map[string]string `json:"env,omitempty" yaml:"env,omitempty"`                         // コマンドに追加する環境変数
+	TimeoutSeconds Seconds           `json:"timeout_seconds,omitempty" yaml:"timeout_seconds,omitempty"` // コマンドを止めるまでの時間（省略時は30秒）
+	MaxConcurrent  int               `json:"max_concurrent,omitempty" yaml:"max_concurrent,omitempty"`   // 同時に実行するコマンドの数（省略時は4）
+}
+
+// Timeout はコマンドを止めるまでの時間を返す
+func (c *ExecConfig) Timeout() time.Duration {
+	if c == nil || c.TimeoutSeconds <= 0 {
+		return DefaultExecTimeout
+	}
+	return c.TimeoutSeconds.Duration()
+}
+
+// EffectiveMaxConcurrent は同時に実行するコマンドの数を返す
+func (c *ExecConfig) EffectiveMaxConcurrent() int {
+	if c == nil || c.MaxConcurrent <= 0 {
+		return DefaultExecMaxConcurrent
+	}
+	return c.MaxConcurrent
+}
+
+func validateExec(i int, c NotificationConfig) error {
+	if c.Type != NotificationExec {
+		if c.Exec != nil {
+			return fmt.Errorf("%w: notifications[%d]: exec is only supported for exec", ErrInvalidNotification, i)
+		}
+		return nil
+	}
+	if c.Exec == nil || len(c.Exec.Command) == 0 || c.Exec.Command[0] == "" {
+		return fmt.Errorf("%w: notifications[%d]: exec command is required for exec", ErrInvalidNotification, i)
+	}
+	for name := range c.Exec.Env {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return fmt.Errorf("%w: notifications[%d]: exec env name %q is invalid", ErrInvalidNotification, i, name)
+		}
+	}
+	if c.Exec.TimeoutSeconds < 0 {
+		return fmt.Errorf("%w: notifications[%d]: exec timeout_seconds must not be negative", ErrInvalidNotification, i)
+	}
+	if c.Exec.MaxConcurrent < 0 {
+		return fmt.Errorf("%w: notifications[%d]: exec max_concurrent must not be negative", ErrInvalidNotification, i)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import "time"
+
+// DefaultFlappingWindow はflappingのwindow_seconds省略時のウィンドウ長
+const DefaultFlappingWindow = 10 * time.Minute
+
+// FlappingConfig はDNSの切替を繰り返すオリジンを検出する設定を表す構造体
+type FlappingConfig struct {
+	Transitions   int     `json:"transitions" yaml:"transitions"`                           // ウィンドウ内でこの回数を超えて切り替わるとフラッピングとみなす（0は無効）
+	WindowSeconds Seconds `json:"window_seconds,omitempty" yaml:"window_seconds,omitempty"` // ウィンドウの長さ（省略時は10分）
+}
+
+// Enabled はフラッピングの検出が有効かどうかを返す
+func (c *FlappingConfig) Enabled() bool {
+	return c != nil && c.Transitions > 0
+}
+
+// Window はウィンドウの長さを返す
+func (c *FlappingConfig) Window() time.Duration {
+	if c == nil || c.WindowSeconds <= 0 {
+		return DefaultFlappingWindow
+	}
+	return c.WindowSeconds.Duration()
+}
+
+// FlappingFor はオリジンに適用するフラッピングの検出設定を返す（オリジン単位の設定が優先）
+func (c *Config) FlappingFor(origin OriginConfig) *FlappingConfig {
+	if origin.Flapping != nil {
+		return origin.Flapping
+	}
+	return c.Flapping
+}
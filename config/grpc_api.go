@@ -0,0 +1,58 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrInvalidGRPCAPI is returned when grpc_api has neither a token nor a client CA, an invalid token, a listen address that is not host:port, an incomplete tls or invalid remote_probers
+var ErrInvalidGRPCAPI = errors.New("invalid grpc_api config")
+
+// DefaultGRPCAPIListen はlistenを省略したときの待ち受けアドレス
+const DefaultGRPCAPIListen = "127.0.0.1:8083"
+
+// GRPCAPIConfig は管理用APIの操作とイベントの配信をgRPCで提供するAPIの設定を表す構造体
+type GRPCAPIConfig struct {
+	Listen string           `json:"listen,omitempty" yaml:"listen,omitempty"` // 待ち受けアドレス（省略時は "127.0.0.1:8083"）
+	Token  string           `json:"token,omitempty" yaml:"token,omitempty"`   // authorizationメタデータのBearerで要求するトークン（operatorの役割を持つ。tokensかtls.client_ca_fileを指定した場合は省略可、証明書と両方指定すると両方を要求する）
+	Tokens []APITokenConfig `json:"tokens,omitempty" yaml:"tokens,omitempty"` // 役割を持つ追加のトークン（tokenはoperatorの役割を持つ）
+	TLS    *ServerTLSConfig `json:"tls,omitempty" yaml:"tls,omitempty"`       // HTTPSで待ち受ける場合の証明書とクライアント証明書のCA
+
+	RemoteProbers *RemoteProbersConfig `json:"remote_probers,omitempty" yaml:"remote_probers,omitempty"` // proberの役割のトークンで登録したプローバーのチェック結果をIPの判定に使う設定
+}
+
+// Enabled はgRPC APIが有効かどうかを返す
+func (c *GRPCAPIConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveListen は待ち受けアドレスを返す
+func (c *GRPCAPIConfig) EffectiveListen() string {
+	if c == nil || c.Listen == "" {
+		return DefaultGRPCAPIListen
+	}
+	return c.Listen
+}
+
+func validateGRPCAPI(c *GRPCAPIConfig) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.Token == "" && len(c.Tokens) == 0 && !c.TLS.MutualTLS() {
+		return fmt.Errorf("%w: token, tokens or tls.client_ca_file is required", ErrInvalidGRPCAPI)
+	}
+	if err := validateAPITokens(c.Token, c.Tokens); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidGRPCAPI, err)
+	}
+	if err := validateServerTLS(c.TLS); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidGRPCAPI, err)
+	}
+	if _, _, err := net.SplitHostPort(c.EffectiveListen()); err != nil {
+		return fmt.Errorf("%w: listen %q: %v", ErrInvalidGRPCAPI, c.Listen, err)
+	}
+	if err := validateRemoteProbers(c.RemoteProbers); err != nil {
+		return fmt.Errorf("%w: remote_probers: %v", ErrInvalidGRPCAPI, err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrInvalidHeartbeat is returned when heartbeat has no valid URL or a negative timeout
+var ErrInvalidHeartbeat = errors.New("invalid heartbeat config")
+
+// HeartbeatConfig はチェックのサイクルが完了するたびにhealthchecks.ioやCronitorなどのURLへpingを送る設定を表す構造体
+type HeartbeatConfig struct {
+	URL            string  `json:"url" yaml:"url"`                                             // 全オリジンのチェックが完了したときに送るpingのURL
+	FailURL        string  `json:"fail_url,omitempty" yaml:"fail_url,omitempty"`               // チェックに失敗したオリジンがあるときに送るpingのURL（省略時は送らない）
+	TimeoutSeconds Seconds `json:"timeout_seconds,omitempty" yaml:"timeout_seconds,omitempty"` // pingのタイムアウト（省略時は10秒）
+}
+
+// Enabled はpingの送信が有効かどうかを返す
+func (c *HeartbeatConfig) Enabled() bool {
+	return c != nil
+}
+
+func validateHeartbeat(c *HeartbeatConfig) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.URL == "" {
+		return fmt.Errorf("%w: url is required", ErrInvalidHeartbeat)
+	}
+	if err := validateHeartbeatURL("url", c.URL); err != nil {
+		return err
+	}
+	if c.FailURL != "" {
+		if err := validateHeartbeatURL("fail_url", c.FailURL); err != nil {
+			return err
+		}
+	}
+	if c.TimeoutSeconds < 0 {
+		return fmt.Errorf("%w: timeout_seconds must not be negative", ErrInvalidHeartbeat)
+	}
+	return nil
+}
+
+func validateHeartbeatURL(name, value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %s %q must be an http(s) URL", ErrInvalidHeartbeat, name, value)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+var (
+	// ErrIncludeNotFound is returned when an include entry without wildcards names a file that does not exist
+	ErrIncludeNotFound = errors.New("included config file not found")
+	// ErrDuplicateOrigin is returned when more than one origin manages the same records
+	ErrDuplicateOrigin = errors.New("origin is defined more than once")
+	// ErrIncludeNotSupported is returned when a config that was not read from a local file uses include
+	ErrIncludeNotSupported = errors.New("include is only supported in local config files")
+)
+
+// includeFile はincludeで読み込むファイルの内容を表す構造体
+type includeFile struct {
+	Origins []OriginConfig `json:"origins" yaml:"origins"`
+}
+
+// includeSchema はincludeで読み込むファイルのJSON Schemaを返す
+func includeSchema() map[string]any {
+	return documentSchema(reflect.TypeOf(includeFile{}), "cloudflare-gslb included origins")
+}
+
+// loadIncludes はincludeのパターンに一致するファイルのオリジンをtmpConfigに追加する
+// パターンは設定ファイルのディレクトリからの相対パスで、一致したファイルは名前順に読み込む
+// ワイルドカードを含むパターンは一致するファイルがなくてもよい
+func loadIncludes(tmpConfig *rawConfig, baseDir string) error {
+	if len(tmpConfig.Include) > 0 && baseDir == "" {
+		return ErrIncludeNotSupported
+	}
+	owners := make(map[string]string, len(tmpConfig.Origins))
+	for _, origin := range tmpConfig.Origins {
+		owners[originKey(origin)] = "the main config file"
+	}
+
+	for _, pattern := range tmpConfig.Include {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(baseDir, pattern)
+		}
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid include pattern %s: %w", pattern, err)
+		}
+		if len(files) == 0 && !hasGlobMeta(pattern) {
+			return fmt.Errorf("%w: %s", ErrIncludeNotFound, pattern)
+		}
+
+		for _, file := range files {
+			origins, err := readIncludeFile(file)
+			if err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+			for _, origin := range origins {
+				key := originKey(origin)
+				if owner, ok := owners[key]; ok {
+					return fmt.Errorf("%w: %s (%s) in %s and %s", ErrDuplicateOrigin, origin.Name, origin.RecordType, owner, file)
+				}
+				owners[key] = file
+			}
+			tmpConfig.Origins = append(tmpConfig.Origins, origins...)
+		}
+	}
+	return nil
+}
+
+func readIncludeFile(path string) ([]OriginConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = expandEnv(data, os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	ext := fileExt(strings.ToLower(filepath.Ext(path)))
+	if err := validateSchema(includeSchema(), ext, data); err != nil {
+		return nil, err
+	}
+	var included includeFile
+	if err := decodeFile(ext, data, &included); err != nil {
+		return nil, err
+	}
+	return included.Origins, nil
+}
+
+// originKey はオリジンの重複を判定するキーを返す
+func originKey(origin OriginConfig) string {
+	return origin.ZoneName + "/" + origin.Name + "/" + origin.RecordType
+}
+
+func hasGlobMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
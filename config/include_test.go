@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+const includeMainConfig = `
+cloudflare_api_token: token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+include:
+  - origins.d/*.yaml
+  - origins.d/*.json
+origins:
+  - name: www.example.com
+    record_type: A
+    health_check: {type: icmp}
+    priority_levels:
+      - priority: 0
+        ips: ["192.0.2.1"]
+`
+
+func TestLoadConfig_Include(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeConfigFile(t, path, includeMainConfig)
+	writeConfigFile(t, filepath.Join(dir, "origins.d", "b-team.yaml"), `
+origins:
+  - name: api.example.com
+    record_type: A
+    health_check: {type: icmp}
+    priority_levels:
+      - priority: 0
+        ips: ["192.0.2.2"]
+`)
+	writeConfigFile(t, filepath.Join(dir, "origins.d", "a-team.yaml"), `
+origins:
+  - name: app.example.com
+    record_type: AAAA
+    health_check: {type: icmp}
+    priority_levels:
+      - priority: 0
+        ips: ["2001:db8::1"]
+`)
+	writeConfigFile(t, filepath.Join(dir, "origins.d", "c-team.json"), `{"origins": []}`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	var names []string
+	for _, origin := range cfg.Origins {
+		names = append(names, origin.Name)
+		if origin.ZoneName != "example.com" {
+			t.Errorf("Expected included origin %s to get the default zone, got %q", origin.Name, origin.ZoneName)
+		}
+	}
+	if got := strings.Join(names, ","); got != "www.example.com,app.example.com,api.example.com" {
+		t.Errorf("Expected origins in file name order, got %s", got)
+	}
+}
+
+func TestLoadConfig_IncludeErrors(t *testing.T) {
+	t.Run("empty directory", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config.yaml")
+		writeConfigFile(t, path, includeMainConfig)
+		if _, err := LoadConfig(path); err != nil {
+			t.Errorf("Expected patterns without matches to be allowed, got %v", err)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config.yaml")
+		writeConfigFile(t, path, strings.Replace(includeMainConfig, "origins.d/*.json", "team.yaml", 1))
+		if _, err := LoadConfig(path); !errors.Is(err, ErrIncludeNotFound) {
+			t.Errorf("Expected ErrIncludeNotFound, got %v", err)
+		}
+	})
+
+	t.Run("duplicate origin", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config.yaml")
+		writeConfigFile(t, path, includeMainConfig)
+		writeConfigFile(t, filepath.Join(dir, "origins.d", "team.yaml"), `
+origins:
+  - name: www.example.com
+    record_type: A
+`)
+		if _, err := LoadConfig(path); !errors.Is(err, ErrDuplicateOrigin) {
+			t.Errorf("Expected ErrDuplicateOrigin, got %v", err)
+		}
+	})
+
+	t.Run("other keys", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config.yaml")
+		writeConfigFile(t, path, includeMainConfig)
+		writeConfigFile(t, filepath.Join(dir, "origins.d", "team.yaml"), `
+cloudflare_api_token: other
+origins: []
+`)
+		_, err := LoadConfig(path)
+		if !errors.Is(err, ErrSchemaViolation) || !strings.Contains(err.Error(), "team.yaml") {
+			t.Errorf("Expected a schema error naming the file, got %v", err)
+		}
+	})
+
+	t.Run("remote config", func(t *testing.T) {
+		if _, err := LoadConfigData("/gslb/config.yaml", []byte(includeMainConfig)); !errors.Is(err, ErrIncludeNotSupported) {
+			t.Errorf("Expected ErrIncludeNotSupported, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/kubernetes"
+)
+
+var (
+	// ErrInvalidKubernetesTarget is returned when a k8s: entry of ips does not name a Service or has unknown options
+	ErrInvalidKubernetesTarget = errors.New("invalid kubernetes target")
+	// ErrInvalidKubernetesCluster is returned when a kubernetes_clusters entry misses required settings or a k8s: entry names an unknown cluster
+	ErrInvalidKubernetesCluster = errors.New("invalid kubernetes cluster")
+)
+
+// kubernetesTargetPrefix はKubernetesのServiceを指すフェイルオーバー先の接頭辞
+const kubernetesTargetPrefix = "k8s:"
+
+// KubernetesClusterConfig はフェイルオーバー先のServiceを読み込むKubernetesクラスタを表す構造体
+type KubernetesClusterConfig struct {
+	Name      string `json:"name" yaml:"name"`                           // k8s:のclusterで指定する名前
+	Server    string `json:"server" yaml:"server"`                       // APIサーバーのURL
+	TokenFile string `json:"token_file" yaml:"token_file"`               // Bearerトークンのファイル
+	CAFile    string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"` // APIサーバーの証明書を検証するCAのPEMファイル
+}
+
+// KubernetesTarget はフェイルオーバー先のIPを読み込むServiceを表す構造体
+// "k8s:namespace/service?addresses=nodes&cluster=east" の形式で指定する
+type KubernetesTarget struct {
+	Cluster   string // kubernetes_clustersの名前（省略時はPod内のクラスタ）
+	Namespace string
+	Service   string
+	Addresses string // "load_balancer"（デフォルト）、"nodes" または "endpoints"
+}
+
+// IsKubernetesTarget はsがKubernetesのServiceを指すフェイルオーバー先かどうかを返す
+func IsKubernetesTarget(s string) bool {
+	return strings.HasPrefix(s, kubernetesTargetPrefix)
+}
+
+// ParseKubernetesTarget は "k8s:namespace/service" 形式のフェイルオーバー先を解析する
+func ParseKubernetesTarget(s string) (KubernetesTarget, error) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme+":" != kubernetesTargetPrefix || u.Opaque == "" {
+		return KubernetesTarget{}, fmt.Errorf("%w %q: expected k8s:namespace/service", ErrInvalidKubernetesTarget, s)
+	}
+	namespace, service, ok := strings.Cut(u.Opaque, "/")
+	if !ok || namespace == "" || service == "" || strings.Contains(service, "/") {
+		return KubernetesTarget{}, fmt.Errorf("%w %q: expected k8s:namespace/service", ErrInvalidKubernetesTarget, s)
+	}
+	target := KubernetesTarget{Namespace: namespace, Service: service, Addresses: kubernetes.AddressesLoadBalancer}
+	for key, values := range u.Query() {
+		value := values[len(values)-1]
+		switch key {
+		case "cluster":
+			target.Cluster = value
+		case "addresses":
+			target.Addresses = value
+		default:
+			return KubernetesTarget{}, fmt.Errorf("%w %q: unknown option %s", ErrInvalidKubernetesTarget, s, key)
+		}
+	}
+	switch target.Addresses {
+	case kubernetes.AddressesLoadBalancer, kubernetes.AddressesNodes, kubernetes.AddressesEndpoints:
+	default:
+		return KubernetesTarget{}, fmt.Errorf("%w %q: addresses must be load_balancer, nodes or endpoints", ErrInvalidKubernetesTarget, s)
+	}
+	return target, nil
+}
+
+// KubernetesCluster は名前に対応するクラスタ設定を返す
+func (c *Config) KubernetesCluster(name string) (KubernetesClusterConfig, bool) {
+	for _, cluster := range c.KubernetesClusters {
+		if cluster.Name == name {
+			return cluster, true
+		}
+	}
+	return KubernetesClusterConfig{}, false
+}
+
+// ClientOptions はクラスタに接続するためのオプションを返す
+func (c KubernetesClusterConfig) ClientOptions() kubernetes.Options {
+	return kubernetes.Options{Server: c.Server, TokenFile: c.TokenFile, CAFile: c.CAFile}
+}
+
+// validateKubernetesClusters はkubernetes_clustersと、フェイルオーバー先が指すクラスタが設定されているかを確認する
+func validateKubernetesClusters(c *Config) error {
+	seen := make(map[string]bool, len(c.KubernetesClusters))
+	for _, cluster := range c.KubernetesClusters {
+		if cluster.Name == "" || cluster.Server == "" || cluster.TokenFile == "" {
+			return fmt.Errorf("%w: name, server and token_file are required", ErrInvalidKubernetesCluster)
+		}
+		if seen[cluster.Name] {
+			return fmt.Errorf("%w: cluster %s is listed more than once", ErrInvalidKubernetesCluster, cluster.Name)
+		}
+		seen[cluster.Name] = true
+	}
+	for _, origin := range c.Origins {
+		for _, target := range origin.Targets() {
+			if !IsKubernetesTarget(target) {
+				continue
+			}
+			// The syntax is checked with the other targets
+			parsed, err := ParseKubernetesTarget(target)
+			if err == nil && parsed.Cluster != "" && !seen[parsed.Cluster] {
+				return fmt.Errorf("%w: origin %s: %s is not in kubernetes_clusters", ErrInvalidKubernetesCluster, origin.Name, parsed.Cluster)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseKubernetesTarget(t *testing.T) {
+	tests := []struct {
+		target  string
+		want    KubernetesTarget
+		wantErr bool
+	}{
+		{target: "k8s:web/frontend", want: KubernetesTarget{Namespace: "web", Service: "frontend", Addresses: "load_balancer"}},
+		{target: "k8s:web/frontend?addresses=nodes&cluster=east", want: KubernetesTarget{Cluster: "east", Namespace: "web", Service: "frontend", Addresses: "nodes"}},
+		{target: "k8s:web/frontend?addresses=endpoints", want: KubernetesTarget{Namespace: "web", Service: "frontend", Addresses: "endpoints"}},
+		{target: "k8s:frontend", wantErr: true},
+		{target: "k8s:web/frontend/extra", wantErr: true},
+		{target: "k8s:web/frontend?addresses=pods", wantErr: true},
+		{target: "k8s:web/frontend?port=80", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseKubernetesTarget(tt.target)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseKubernetesTarget(%q) error = %v, wantErr %v", tt.target, err, tt.wantErr)
+			continue
+		}
+		if err != nil && !errors.Is(err, ErrInvalidKubernetesTarget) {
+			t.Errorf("ParseKubernetesTarget(%q) error = %v, want %v", tt.target, err, ErrInvalidKubernetesTarget)
+		}
+		if got != tt.want {
+			t.Errorf("ParseKubernetesTarget(%q) = %+v, want %+v", tt.target, got, tt.want)
+		}
+	}
+}
+
+const kubernetesTargetsConfig = `
+cloudflare_api_token: token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+kubernetes_clusters:
+  - name: east
+    server: https://east.example.net:6443
+    token_file: /var/run/secrets/east/token
+origins:
+  - name: www.example.com
+    record_type: A
+    health_check: {type: icmp}
+    priority_levels:
+      - priority: 100
+        ips: ["k8s:web/frontend"]
+      - priority: 0
+        ips: ["k8s:web/frontend?cluster=east&addresses=nodes"]
+`
+
+func TestLoadConfig_KubernetesTargets(t *testing.T) {
+	cfg, err := LoadConfigData("config.yaml", []byte(kubernetesTargetsConfig))
+	if err != nil {
+		t.Fatalf("LoadConfigData returned error: %v", err)
+	}
+	cluster, ok := cfg.KubernetesCluster("east")
+	if !ok || cluster.ClientOptions().Server != "https://east.example.net:6443" {
+		t.Errorf("unexpected cluster %+v", cluster)
+	}
+	if targets := cfg.Origins[0].Targets(); len(targets) != 2 || !IsKubernetesTarget(targets[1]) {
+		t.Errorf("unexpected targets %v", targets)
+	}
+
+	tests := []struct {
+		name    string
+		config  string
+		wantErr error
+	}{
+		{
+			name:    "unknown cluster",
+			config:  strings.Replace(kubernetesTargetsConfig, "cluster=east", "cluster=west", 1),
+			wantErr: ErrInvalidKubernetesCluster,
+		},
+		{
+			name:    "cluster without server",
+			config:  strings.Replace(kubernetesTargetsConfig, "    server: https://east.example.net:6443\n", "", 1),
+			wantErr: ErrInvalidKubernetesCluster,
+		},
+		{
+			name:    "unknown addresses",
+			config:  strings.Replace(kubernetesTargetsConfig, "addresses=nodes", "addresses=pods", 1),
+			wantErr: ErrInvalidKubernetesTarget,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfigData("config.yaml", []byte(tt.config))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
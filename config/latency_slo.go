@@ -0,0 +1,61 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidLatencySLO is returned when latency_slo has no threshold, an objective outside (0, 1) or a negative window
+var ErrInvalidLatencySLO = errors.New("invalid latency_slo config")
+
+const (
+	// DefaultLatencySLOObjective はobjective省略時のしきい値内に収まるべきチェックの割合
+	DefaultLatencySLOObjective = 0.99
+	// DefaultLatencySLOWindow はwindow_seconds省略時にバーンレートを計算する期間
+	DefaultLatencySLOWindow = Seconds(time.Hour)
+)
+
+// LatencySLOConfig はヘルスチェックのレイテンシのSLOと、その消費速度（バーンレート）を計算する設定を表す構造体
+type LatencySLOConfig struct {
+	ThresholdMs   Milliseconds `json:"threshold_ms" yaml:"threshold_ms"`                         // このレイテンシ以内に成功したチェックをSLO内とみなす
+	Objective     float64      `json:"objective,omitempty" yaml:"objective,omitempty"`           // SLO内であるべきチェックの割合（省略時は0.99）
+	WindowSeconds Seconds      `json:"window_seconds,omitempty" yaml:"window_seconds,omitempty"` // バーンレートを計算する期間（省略時は1時間）
+}
+
+// Enabled はレイテンシSLOが有効かどうかを返す
+func (c *LatencySLOConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveObjective はSLO内であるべきチェックの割合を返す
+func (c *LatencySLOConfig) EffectiveObjective() float64 {
+	if c == nil || c.Objective == 0 {
+		return DefaultLatencySLOObjective
+	}
+	return c.Objective
+}
+
+// EffectiveWindow はバーンレートを計算する期間を返す
+func (c *LatencySLOConfig) EffectiveWindow() Seconds {
+	if c == nil || c.WindowSeconds == 0 {
+		return DefaultLatencySLOWindow
+	}
+	return c.WindowSeconds
+}
+
+func validateLatencySLO(c *LatencySLOConfig) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.ThresholdMs <= 0 {
+		return fmt.Errorf("%w: threshold_ms must be positive", ErrInvalidLatencySLO)
+	}
+	if c.Objective < 0 || c.Objective >= 1 {
+		return fmt.Errorf("%w: objective must be between 0 and 1", ErrInvalidLatencySLO)
+	}
+	if c.WindowSeconds < 0 {
+		return fmt.Errorf("%w: window_seconds must not be negative", ErrInvalidLatencySLO)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"path/filepath"
+	"strconv"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/listenaddr"
+)
+
+// listenAddresses はlistenと、指定されていればsocketの待ち受けアドレスを返す
+func listenAddresses(listen, socket string) []string {
+	addresses := []string{listen}
+	if socket != "" {
+		addresses = append(addresses, listenaddr.UnixPrefix+socket)
+	}
+	return addresses
+}
+
+// socketMode はsocket_modeのパーミッションを返す（省略時は0600）
+func socketMode(mode string) fs.FileMode {
+	if mode == "" {
+		return listenaddr.DefaultSocketMode
+	}
+	perm, _ := strconv.ParseUint(mode, 8, 32)
+	return fs.FileMode(perm)
+}
+
+// validateListen はlistenがhost:portかunix:<絶対パス>であることと、socketとsocket_modeを検証する
+func validateListen(listen, socket, mode string) error {
+	if path, ok := listenaddr.SocketPath(listen); ok {
+		if !filepath.IsAbs(path) {
+			return fmt.Errorf("listen %q: the socket path must be absolute", listen)
+		}
+	} else if _, _, err := net.SplitHostPort(listen); err != nil {
+		return fmt.Errorf("listen %q: %v", listen, err)
+	}
+	if socket != "" && !filepath.IsAbs(socket) {
+		return fmt.Errorf("socket %q: the path must be absolute", socket)
+	}
+	if socket != "" && listenaddr.UnixPrefix+socket == listen {
+		return errors.New("socket must differ from listen")
+	}
+	if mode != "" {
+		if perm, err := strconv.ParseUint(mode, 8, 32); err != nil || perm > 0o777 {
+			return fmt.Errorf("socket_mode %q: must be octal permissions such as 0660", mode)
+		}
+	}
+	return nil
+}
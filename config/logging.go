@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/syslog"
+)
+
+// ErrInvalidLog is returned when log has neither a file nor syslog, negative rotation limits or an invalid syslog config
+var ErrInvalidLog = errors.New("invalid log config")
+
+const (
+	// DefaultLogMaxSizeMB はmax_size_mbを省略したときにローテーションするファイルサイズ（MB）
+	DefaultLogMaxSizeMB = 100
+	// DefaultLogMaxBackups はmax_backupsを省略したときに残すローテーション済みファイルの数
+	DefaultLogMaxBackups = 7
+	// DefaultSyslogFacility はfacilityを省略したときのsyslogのファシリティ
+	DefaultSyslogFacility = "daemon"
+	// DefaultSyslogAppName はapp_nameを省略したときにメッセージに記録するアプリケーション名
+	DefaultSyslogAppName = "cloudflare-gslb"
+)
+
+// syslogのnetworkに指定できる値
+const (
+	SyslogNetworkUDP = syslog.NetworkUDP // UDPで送信する（省略時）
+	SyslogNetworkTCP = syslog.NetworkTCP // TCPでオクテットカウント形式で送信する
+)
+
+// LogConfig はログを標準エラー出力の代わりにファイルやsyslogへ書き出す設定を表す構造体
+// ファイルはサイズと経過時間でローテーションする
+type LogConfig struct {
+	File          string        `json:"file,omitempty" yaml:"file,omitempty"`                       // ログを書き出すファイルのパス
+	MaxSizeMB     int           `json:"max_size_mb,omitempty" yaml:"max_size_mb,omitempty"`         // ファイルがこのサイズ（MB）を超えたらローテーションする（省略時は100）
+	MaxAgeSeconds Seconds       `json:"max_age_seconds,omitempty" yaml:"max_age_seconds,omitempty"` // ファイルを開いてからこの時間が経ったらローテーションする（省略時は経過時間ではローテーションしない）
+	MaxBackups    int           `json:"max_backups,omitempty" yaml:"max_backups,omitempty"`         // 残すローテーション済みファイルの数（省略時は7）
+	Compress      bool          `json:"compress,omitempty" yaml:"compress,omitempty"`               // ローテーション済みファイルをgzipで圧縮するかどうか
+	Syslog        *SyslogConfig `json:"syslog,omitempty" yaml:"syslog,omitempty"`                   // ログをRFC 5424形式でsyslogへ送信する設定
+}
+
+// SyslogConfig はログをローカルまたはリモートのsyslogへRFC 5424形式で送信する設定を表す構造体
+type SyslogConfig struct {
+	Network  string `json:"network,omitempty" yaml:"network,omitempty"`   // "udp" または "tcp"（省略時は "udp"、addressがなければ無視）
+	Address  string `json:"address,omitempty" yaml:"address,omitempty"`   // リモートのsyslogサーバのhost:port（省略時はローカルのsyslog）
+	Facility string `json:"facility,omitempty" yaml:"facility,omitempty"` // ファシリティ（"daemon" や "local0" など、省略時は "daemon"）
+	AppName  string `json:"app_name,omitempty" yaml:"app_name,omitempty"` // メッセージに記録するアプリケーション名（省略時は "cloudflare-gslb"）
+}
+
+// EffectiveNetwork はリモートのsyslogへ送信するプロトコルを返す
+func (c *SyslogConfig) EffectiveNetwork() string {
+	if c == nil || c.Network == "" {
+		return SyslogNetworkUDP
+	}
+	return c.Network
+}
+
+// EffectiveFacility はファシリティ名を返す
+func (c *SyslogConfig) EffectiveFacility() string {
+	if c == nil || c.Facility == "" {
+		return DefaultSyslogFacility
+	}
+	return c.Facility
+}
+
+// EffectiveAppName はメッセージに記録するアプリケーション名を返す
+func (c *SyslogConfig) EffectiveAppName() string {
+	if c == nil || c.AppName == "" {
+		return DefaultSyslogAppName
+	}
+	return c.AppName
+}
+
+// Enabled はファイルへのログ出力が有効かどうかを返す
+func (c *LogConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveMaxSizeMB はローテーションするファイルサイズ（MB）を返す
+func (c *LogConfig) EffectiveMaxSizeMB() int {
+	if c == nil || c.MaxSizeMB == 0 {
+		return DefaultLogMaxSizeMB
+	}
+	return c.MaxSizeMB
+}
+
+// EffectiveMaxBackups は残すローテーション済みファイルの数を返す
+func (c *LogConfig) EffectiveMaxBackups() int {
+	if c == nil || c.MaxBackups == 0 {
+		return DefaultLogMaxBackups
+	}
+	return c.MaxBackups
+}
+
+func validateLog(c *LogConfig) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.File == "" && c.Syslog == nil {
+		return fmt.Errorf("%w: file or syslog is required", ErrInvalidLog)
+	}
+	if c.MaxSizeMB < 0 {
+		return fmt.Errorf("%w: max_size_mb must not be negative", ErrInvalidLog)
+	}
+	if c.MaxAgeSeconds < 0 {
+		return fmt.Errorf("%w: max_age_seconds must not be negative", ErrInvalidLog)
+	}
+	if c.MaxBackups < 0 {
+		return fmt.Errorf("%w: max_backups must not be negative", ErrInvalidLog)
+	}
+	return validateSyslog(c.Syslog)
+}
+
+func validateSyslog(c *SyslogConfig) error {
+	if c == nil {
+		return nil
+	}
+	switch c.EffectiveNetwork() {
+	case SyslogNetworkUDP, SyslogNetworkTCP:
+	default:
+		return fmt.Errorf("%w: unknown syslog network %q", ErrInvalidLog, c.Network)
+	}
+	if c.Address != "" {
+		if _, _, err := net.SplitHostPort(c.Address); err != nil {
+			return fmt.Errorf("%w: syslog address %q: %v", ErrInvalidLog, c.Address, err)
+		}
+	}
+	if _, err := syslog.ParseFacility(c.EffectiveFacility()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidLog, err)
+	}
+	return nil
+}
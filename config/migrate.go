@@ -0,0 +1,313 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnsupportedFormat is returned when a document is written in a format other than JSON or YAML
+var ErrUnsupportedFormat = errors.New("unsupported output format")
+
+// 設定ファイルを書き出す形式
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// FormatOf はファイル名の拡張子から形式を返す（不明な拡張子はJSONとみなす）
+func FormatOf(name string) string {
+	switch fileExt(strings.ToLower(filepath.Ext(name))) {
+	case extYAML, extYML:
+		return FormatYAML
+	case extTOML:
+		return FormatTOML
+	default:
+		return FormatJSON
+	}
+}
+
+// ParseDocument は設定ファイルを値を解決せずにそのまま読み込む
+// 環境変数やシークレットの参照、キーの順序、YAMLのコメントは保持される（TOMLのキーは名前順になる）
+func ParseDocument(name string, data []byte) (*yaml.Node, error) {
+	switch FormatOf(name) {
+	case FormatTOML:
+		doc, err := parseTOML(string(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrParseTOML, err)
+		}
+	case FormatJSON:
+		// The YAML parser also accepts some documents that are not valid JSON
+		var v any
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrParseJSON, err)
+		}
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseYAML, err)
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%w: the top level must be a mapping", ErrParseYAML)
+	}
+	return &root, nil
+}
+
+// FormatDocument はParseDocumentで読み込んだ設定をformatの形式で書き出す
+func FormatDocument(doc *yaml.Node, format string) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(blockStyle(doc)); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case FormatJSON:
+		var compact bytes.Buffer
+		if err := writeJSONNode(&compact, doc); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, compact.Bytes(), "", "  "); err != nil {
+			return nil, err
+		}
+		buf.WriteByte('\n')
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+}
+
+// MigrateDocument は古いバージョンの設定を現在の形式に書き換え、変更内容の説明を返す
+// versionはCurrentVersionに更新する。その他のキーや値、順序、コメントはそのまま残す
+func MigrateDocument(doc *yaml.Node) ([]string, error) {
+	root := doc.Content[0]
+	from, err := documentVersion(root)
+	if err != nil {
+		return nil, err
+	}
+	changes := applyMigrations(root, from)
+	if from < CurrentVersion {
+		setDocumentVersion(root, CurrentVersion)
+		changes = append(changes, fmt.Sprintf("set version to %d", CurrentVersion))
+	}
+	return changes, nil
+}
+
+// migrateLegacyFields はバージョン1の設定をバージョン2に書き換える
+//   - cloudflare_zone_id はnameが"default"のcloudflare_zonesに移す
+//   - priority_failover_ips と failover_ips はpriority_levels（優先度100と0）に移す
+func migrateLegacyFields(root *yaml.Node) []string {
+	var changes []string
+
+	if zoneID := mappingValue(root, "cloudflare_zone_id"); zoneID != nil {
+		if mappingValue(root, "cloudflare_zones") == nil {
+			zone := mappingNode("zone_id", zoneID, "name", stringNode("default"))
+			replaceMappingKey(root, "cloudflare_zone_id", "cloudflare_zones", &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{zone}})
+			changes = append(changes, `moved cloudflare_zone_id to cloudflare_zones as the zone "default"`)
+		} else {
+			deleteMappingKey(root, "cloudflare_zone_id")
+			changes = append(changes, "removed cloudflare_zone_id, which is ignored when cloudflare_zones is set")
+		}
+	}
+
+	if origins := mappingValue(root, "origins"); origins != nil && origins.Kind == yaml.SequenceNode {
+		for i, origin := range origins.Content {
+			if origin.Kind != yaml.MappingNode {
+				continue
+			}
+			if change := migrateLegacyFailoverIPs(origin); change != "" {
+				label := fmt.Sprintf("origins[%d]", i)
+				if name := mappingValue(origin, "name"); name != nil {
+					label += " (" + name.Value + ")"
+				}
+				changes = append(changes, label+": "+change)
+			}
+		}
+	}
+	return changes
+}
+
+func migrateLegacyFailoverIPs(origin *yaml.Node) string {
+	priorityIPs := mappingValue(origin, "priority_failover_ips")
+	failoverIPs := mappingValue(origin, "failover_ips")
+	if priorityIPs == nil && failoverIPs == nil {
+		return ""
+	}
+	if levels := mappingValue(origin, "priority_levels"); levels != nil && len(levels.Content) > 0 {
+		deleteMappingKey(origin, "priority_failover_ips")
+		deleteMappingKey(origin, "failover_ips")
+		return "removed priority_failover_ips and failover_ips, which are ignored when priority_levels is set"
+	}
+	levels := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	if priorityIPs != nil && len(priorityIPs.Content) > 0 {
+		levels.Content = append(levels.Content, mappingNode("priority", intNode(LegacyPriorityHigh), "ips", priorityIPs))
+	}
+	if failoverIPs != nil && len(failoverIPs.Content) > 0 {
+		levels.Content = append(levels.Content, mappingNode("priority", intNode(LegacyPriorityLow), "ips", failoverIPs))
+	}
+	// priority_levels takes the place of the first legacy key
+	deleteMappingKey(origin, "priority_levels")
+	if priorityIPs != nil {
+		replaceMappingKey(origin, "priority_failover_ips", "priority_levels", levels)
+		deleteMappingKey(origin, "failover_ips")
+	} else {
+		replaceMappingKey(origin, "failover_ips", "priority_levels", levels)
+	}
+	return "moved priority_failover_ips and failover_ips to priority_levels"
+}
+
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// replaceMappingKey はoldKeyのキーと値を同じ位置でnewKeyとvalueに置き換える
+// キーに付いたコメントは残る
+func replaceMappingKey(m *yaml.Node, oldKey, newKey string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == oldKey {
+			key := *m.Content[i]
+			key.Value = newKey
+			m.Content[i], m.Content[i+1] = &key, value
+			return
+		}
+	}
+}
+
+func deleteMappingKey(m *yaml.Node, key string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+func mappingNode(keyValues ...any) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		node.Content = append(node.Content, stringNode(keyValues[i].(string)), keyValues[i+1].(*yaml.Node))
+	}
+	return node
+}
+
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func intNode(value int) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(value)}
+}
+
+// blockStyle はJSONから読み込んだフロー形式や引用符付きの書式を外したコピーを返す
+// YAMLから読み込んだ書式（リテラルなど）は残す
+func blockStyle(n *yaml.Node) *yaml.Node {
+	out := *n
+	if out.Style&(yaml.FlowStyle|yaml.DoubleQuotedStyle) != 0 {
+		out.Style = 0
+	}
+	out.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		out.Content[i] = blockStyle(child)
+	}
+	return &out
+}
+
+// writeJSONNode はノードをキーの順序を保ったままJSONとして書き出す
+// エイリアスとマージキー（<<）は展開する
+func writeJSONNode(buf *bytes.Buffer, n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		return writeJSONNode(buf, n.Content[0])
+	case yaml.AliasNode:
+		return writeJSONNode(buf, n.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i, entry := range mergedEntries(n) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(entry[0].Value)
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeJSONNode(buf, entry[1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range n.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONNode(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		var value any
+		if err := n.Decode(&value); err != nil {
+			return err
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		buf.Write(encoded)
+	}
+	return nil
+}
+
+// mergedEntries はマッピングのキーと値の組を返す
+// マージキーで取り込まれるキーは、同じキーが明示されていない場合に含める
+func mergedEntries(n *yaml.Node) [][2]*yaml.Node {
+	var entries, merged [][2]*yaml.Node
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if key.ShortTag() == "!!merge" {
+			sources := []*yaml.Node{value}
+			if value.Kind == yaml.SequenceNode {
+				sources = value.Content
+			}
+			for _, source := range sources {
+				if source.Kind == yaml.AliasNode {
+					source = source.Alias
+				}
+				merged = append(merged, mergedEntries(source)...)
+			}
+			continue
+		}
+		seen[key.Value] = true
+		entries = append(entries, [2]*yaml.Node{key, value})
+	}
+	for _, entry := range merged {
+		if !seen[entry[0].Value] {
+			seen[entry[0].Value] = true
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
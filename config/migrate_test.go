@@ -0,0 +1,194 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func migrate(t *testing.T, name, input, format string) (string, []string) {
+	t.Helper()
+	doc, err := ParseDocument(name, []byte(input))
+	if err != nil {
+		t.Fatalf("ParseDocument: %v", err)
+	}
+	changes, err := MigrateDocument(doc)
+	if err != nil {
+		t.Fatalf("MigrateDocument: %v", err)
+	}
+	out, err := FormatDocument(doc, format)
+	if err != nil {
+		t.Fatalf("FormatDocument: %v", err)
+	}
+	return string(out), changes
+}
+
+func TestMigrateDocument_YAML(t *testing.T) {
+	input := `# production
+cloudflare_api_token: ${CF_API_TOKEN}
+cloudflare_zone_id: zone-1 # old zone
+check_interval_seconds: 30s
+origins:
+  - name: www.example.com
+    zone_name: default
+    record_type: A
+    # primary first
+    priority_failover_ips: ["192.0.2.1"]
+    failover_ips: ["192.0.2.2"]
+    proxied: true
+`
+	want := `# production
+version: 2
+cloudflare_api_token: ${CF_API_TOKEN}
+cloudflare_zones:
+  - zone_id: zone-1 # old zone
+    name: default
+check_interval_seconds: 30s
+origins:
+  - name: www.example.com
+    zone_name: default
+    record_type: A
+    # primary first
+    priority_levels:
+      - priority: 100
+        ips:
+          - 192.0.2.1
+      - priority: 0
+        ips:
+          - 192.0.2.2
+    proxied: true
+`
+	got, changes := migrate(t, "config.yaml", input, FormatYAML)
+	if got != want {
+		t.Errorf("Migrated config:\n%s\nwant:\n%s", got, want)
+	}
+	if len(changes) != 3 || !strings.HasPrefix(changes[1], "origins[0] (www.example.com): ") || changes[2] != "set version to 2" {
+		t.Errorf("Unexpected changes: %q", changes)
+	}
+
+	// Migrating again changes nothing
+	if again, changes := migrate(t, "config.yaml", got, FormatYAML); again != got || len(changes) != 0 {
+		t.Errorf("Second migration changed %q:\n%s", changes, again)
+	}
+}
+
+func TestMigrateDocument_PriorityLevelsWin(t *testing.T) {
+	input := `{
+  "cloudflare_zone_id": "zone-1",
+  "cloudflare_zones": [{"zone_id": "zone-2", "name": "example.com"}],
+  "origins": [{
+    "name": "www",
+    "priority_levels": [{"priority": 1, "ips": ["192.0.2.3"]}],
+    "failover_ips": ["192.0.2.2"]
+  }]
+}`
+	want := `{
+  "version": 2,
+  "cloudflare_zones": [
+    {
+      "zone_id": "zone-2",
+      "name": "example.com"
+    }
+  ],
+  "origins": [
+    {
+      "name": "www",
+      "priority_levels": [
+        {
+          "priority": 1,
+          "ips": [
+            "192.0.2.3"
+          ]
+        }
+      ]
+    }
+  ]
+}
+`
+	got, changes := migrate(t, "config.json", input, FormatJSON)
+	if got != want {
+		t.Errorf("Migrated config:\n%s\nwant:\n%s", got, want)
+	}
+	if len(changes) != 3 || !strings.Contains(changes[1], "removed") {
+		t.Errorf("Unexpected changes: %q", changes)
+	}
+}
+
+func TestFormatDocument_JSONToYAML(t *testing.T) {
+	input := `{"version": 2, "cloudflare_zones": [{"zone_id": "zone-1", "name": "example.com"}], "check_interval_seconds": 60, "origins": []}`
+	want := `version: 2
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins: []
+`
+	got, changes := migrate(t, "config.json", input, FormatYAML)
+	if got != want {
+		t.Errorf("YAML:\n%s\nwant:\n%s", got, want)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Unexpected changes: %q", changes)
+	}
+}
+
+func TestFormatDocument_ExpandsMergeKeysInJSON(t *testing.T) {
+	input := `x-check: &check
+  type: http
+  path: /health
+origins:
+  - name: www
+    health_check:
+      <<: *check
+      path: /ready
+`
+	want := `{"version":2,"x-check":{"type":"http","path":"/health"},"origins":[{"name":"www","health_check":{"path":"/ready","type":"http"}}]}`
+	got, _ := migrate(t, "config.yaml", input, FormatJSON)
+	if compact := strings.Join(strings.Fields(got), ""); compact != want {
+		t.Errorf("JSON = %s, want %s", compact, want)
+	}
+}
+
+func TestParseDocument_TOML(t *testing.T) {
+	input := `cloudflare_zone_id = "zone-1"
+
+[[origins]]
+name = "www"
+failover_ips = ["192.0.2.2"]
+`
+	got, changes := migrate(t, "config.toml", input, FormatYAML)
+	want := `version: 2
+cloudflare_zones:
+  - zone_id: zone-1
+    name: default
+origins:
+  - priority_levels:
+      - priority: 0
+        ips:
+          - 192.0.2.2
+    name: www
+`
+	if got != want {
+		t.Errorf("Migrated config:\n%s\nwant:\n%s", got, want)
+	}
+	if len(changes) != 3 {
+		t.Errorf("Unexpected changes: %q", changes)
+	}
+}
+
+func TestParseDocument_Errors(t *testing.T) {
+	if _, err := ParseDocument("config.yaml", []byte("- a\n- b\n")); !errors.Is(err, ErrParseYAML) {
+		t.Errorf("Expected ErrParseYAML for a list, got %v", err)
+	}
+	if _, err := ParseDocument("config.toml", []byte("a = \n")); !errors.Is(err, ErrParseTOML) {
+		t.Errorf("Expected ErrParseTOML, got %v", err)
+	}
+
+	doc, err := ParseDocument("config.json", []byte(`{}`))
+	if err != nil {
+		t.Fatalf("ParseDocument: %v", err)
+	}
+	if _, err := FormatDocument(doc, FormatTOML); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected ErrUnsupportedFormat, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/mqtt"
+)
+
+// MQTTのbrokerに指定できるスキーム
+var (
+	mqttPlainSchemes = []string{"tcp", "mqtt"}
+	mqttTLSSchemes   = []string{"ssl", "tls", "mqtts"}
+)
+
+// MQTTのポートを省略したときのポート番号
+const (
+	DefaultMQTTPort    = "1883"
+	DefaultMQTTTLSPort = "8883"
+)
+
+// MQTTConfig はイベントをMQTTのトピックへ送信する通知の設定を表す構造体
+type MQTTConfig struct {
+	Broker   string         `json:"broker" yaml:"broker"`                           // ブローカーのURL（"tcp://host:1883"、TLSは "ssl://host:8883"）
+	Topic    string         `json:"topic" yaml:"topic"`                             // 送信先のトピック（ワイルドカードは不可）
+	QoS      int            `json:"qos,omitempty" yaml:"qos,omitempty"`             // QoS（0、1、2、省略時は0）
+	Retain   bool           `json:"retain,omitempty" yaml:"retain,omitempty"`       // 最後のメッセージをブローカーに保持させるかどうか
+	ClientID string         `json:"client_id,omitempty" yaml:"client_id,omitempty"` // クライアントID（省略時は "cloudflare-gslb-<ホスト名>"）
+	Username string         `json:"username,omitempty" yaml:"username,omitempty"`   // 認証のユーザー名
+	Password string         `json:"password,omitempty" yaml:"password,omitempty"`   // 認証のパスワード
+	TLS      *MQTTTLSConfig `json:"tls,omitempty" yaml:"tls,omitempty"`             // TLSの証明書の設定（TLSのスキームのみ）
+}
+
+// MQTTTLSConfig はMQTTのブローカーとのTLSの証明書の設定を表す構造体
+type MQTTTLSConfig struct {
+	CAFile             string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`                           // ブローカーの証明書を検証するCA証明書（PEM、省略時はシステムのルート証明書）
+	CertFile           string `json:"cert_file,omitempty" yaml:"cert_file,omitempty"`                       // クライアント証明書（PEM、key_fileと合わせて指定）
+	KeyFile            string `json:"key_file,omitempty" yaml:"key_file,omitempty"`                         // クライアント証明書の秘密鍵（PEM）
+	ServerName         string `json:"server_name,omitempty" yaml:"server_name,omitempty"`                   // 証明書を検証するホスト名（省略時はbrokerのホスト名）
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty"` // 証明書の検証をスキップするかどうか
+}
+
+// PublisherConfig はブローカーへ接続する設定を返す
+func (c *MQTTConfig) PublisherConfig() mqtt.Config {
+	u, _ := url.Parse(c.Broker)
+	useTLS := slices.Contains(mqttTLSSchemes, u.Scheme)
+	port := u.Port()
+	switch {
+	case port != "":
+	case useTLS:
+		port = DefaultMQTTTLSPort
+	default:
+		port = DefaultMQTTPort
+	}
+	cfg := mqtt.Config{
+		Address:  net.JoinHostPort(u.Hostname(), port),
+		TLS:      useTLS,
+		ClientID: c.EffectiveClientID(),
+		Username: c.Username,
+		Password: c.Password,
+	}
+	if c.TLS != nil {
+		cfg.CAFile = c.TLS.CAFile
+		cfg.CertFile = c.TLS.CertFile
+		cfg.KeyFile = c.TLS.KeyFile
+		cfg.ServerName = c.TLS.ServerName
+		cfg.InsecureSkipVerify = c.TLS.InsecureSkipVerify
+	}
+	return cfg
+}
+
+// EffectiveClientID はクライアントIDを返す
+func (c *MQTTConfig) EffectiveClientID() string {
+	if c.ClientID != "" {
+		return c.ClientID
+	}
+	hostname, _ := os.Hostname()
+	return "cloudflare-gslb-" + hostname
+}
+
+func validateMQTT(i int, c NotificationConfig) error {
+	if c.Type != NotificationMQTT {
+		if c.MQTT != nil {
+			return fmt.Errorf("%w: notifications[%d]: mqtt is only supported for mqtt", ErrInvalidNotification, i)
+		}
+		return nil
+	}
+	if c.MQTT == nil {
+		return fmt.Errorf("%w: notifications[%d]: mqtt broker and topic are required for mqtt", ErrInvalidNotification, i)
+	}
+	u, err := url.Parse(c.MQTT.Broker)
+	if err != nil || u.Hostname() == "" || (!slices.Contains(mqttPlainSchemes, u.Scheme) && !slices.Contains(mqttTLSSchemes, u.Scheme)) {
+		return fmt.Errorf("%w: notifications[%d]: mqtt broker %q must be a tcp://, mqtt://, ssl://, tls:// or mqtts:// URL", ErrInvalidNotification, i, c.MQTT.Broker)
+	}
+	if err := mqtt.ValidateTopic(c.MQTT.Topic); err != nil {
+		return fmt.Errorf("%w: notifications[%d]: mqtt %v", ErrInvalidNotification, i, err)
+	}
+	if c.MQTT.QoS < 0 || c.MQTT.QoS > 2 {
+		return fmt.Errorf("%w: notifications[%d]: mqtt qos must be 0, 1 or 2", ErrInvalidNotification, i)
+	}
+	if c.MQTT.Password != "" && c.MQTT.Username == "" {
+		return fmt.Errorf("%w: notifications[%d]: mqtt password requires a username", ErrInvalidNotification, i)
+	}
+	if tls := c.MQTT.TLS; tls != nil {
+		if !slices.Contains(mqttTLSSchemes, u.Scheme) {
+			return fmt.Errorf("%w: notifications[%d]: mqtt tls requires an ssl://, tls:// or mqtts:// broker", ErrInvalidNotification, i)
+		}
+		if (tls.CertFile == "") != (tls.KeyFile == "") {
+			return fmt.Errorf("%w: notifications[%d]: mqtt tls cert_file and key_file must be set together", ErrInvalidNotification, i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"time"
+)
+
+// ErrInvalidNotification is returned when a notification lacks the settings its type requires
+var ErrInvalidNotification = errors.New("invalid notification config")
+
+// 通知の種類
+const (
+	NotificationSlack    = "slack"
+	NotificationDiscord  = "discord"
+	NotificationOpsgenie = "opsgenie"
+	NotificationTelegram = "telegram"
+	NotificationNtfy     = "ntfy"
+	NotificationPushover = "pushover"
+	NotificationExec     = "exec"
+	NotificationMQTT     = "mqtt"
+)
+
+// DefaultOpsgenieAPIURL はapi_urlを省略したときのOpsgenieのAPIのURL（EUリージョンは https://api.eu.opsgenie.com）
+const DefaultOpsgenieAPIURL = "https://api.opsgenie.com"
+
+// DefaultSlackAPIURL はapi_urlを省略したときのSlackのWeb APIのURL
+const DefaultSlackAPIURL = "https://slack.com/api"
+
+// DefaultTelegramAPIURL はapi_urlを省略したときのTelegram Bot APIのURL
+const DefaultTelegramAPIURL = "https://api.telegram.org"
+
+// DefaultNtfyServerURL はapi_urlを省略したときのntfyのサーバーのURL
+const DefaultNtfyServerURL = "https://ntfy.sh"
+
+// DefaultPushoverAPIURL はapi_urlを省略したときのPushoverのAPIのURL
+const DefaultPushoverAPIURL = "https://api.pushover.net"
+
+// DefaultNotificationTimeout はtimeout_secondsを省略したときの1件の送信のタイムアウト
+const DefaultNotificationTimeout = 10 * time.Second
+
+// MaxNotificationTimeout はtimeout_secondsの上限（送信待ちの通知が溜まらないようにする）
+const MaxNotificationTimeout = 5 * time.Minute
+
+// DefaultOpsgeniePriority はpriorityを省略したときのアラートの優先度
+const DefaultOpsgeniePriority = "P3"
+
+// opsgeniePriorities はOpsgenieのアラートの優先度
+var opsgeniePriorities = []string{"P1", "P2", "P3", "P4", "P5"}
+
+// notificationRouteEvents はrouteのeventsに指定できるイベントの種類
+var notificationRouteEvents = []string{
+	"failover", "recovery", "change_limit_exceeded", "verification_failed", "min_healthy_violated", "allowlist_violation",
+	"all_ips_down", "dns_api_failure", "flapping", "flapping_stopped",
+	"health_checker_failed", "config_reload_failed",
+	"service_started", "service_stopped", "config_reloaded", "service_panicked",
+	"origin_held", "origin_released",
+}
+
+// notificationSeverities はrouteのmin_severityに指定できる重要度（低い順）
+var notificationSeverities = []string{"info", "warning", "critical"}
+
+// NotificationRouteConfig は通知先に送るイベントを選ぶ条件を表す構造体。省略した条件はすべてのイベントに一致する
+type NotificationRouteConfig struct {
+	Zones       []string `json:"zones,omitempty" yaml:"zones,omitempty"`               // ゾーン名
+	Origins     []string `json:"origins,omitempty" yaml:"origins,omitempty"`           // オリジン名のパターン（"*.example.com"など）
+	Events      []string `json:"events,omitempty" yaml:"events,omitempty"`             // イベントの種類（"failover"、"recovery"、アラートの種類）
+	MinSeverity string   `json:"min_severity,omitempty" yaml:"min_severity,omitempty"` // 送信する最低の重要度（"info"、"warning"、"critical"）
+}
+
+// EffectiveAPIURL はSlack、Opsgenie、Telegram、PushoverのAPIまたはntfyのサーバーのURLを返す
+func (c NotificationConfig) EffectiveAPIURL() string {
+	switch {
+	case c.APIURL != "":
+		return c.APIURL
+	case c.Type == NotificationSlack:
+		return DefaultSlackAPIURL
+	case c.Type == NotificationTelegram:
+		return DefaultTelegramAPIURL
+	case c.Type == NotificationNtfy:
+		return DefaultNtfyServerURL
+	case c.Type == NotificationPushover:
+		return DefaultPushoverAPIURL
+	default:
+		return DefaultOpsgenieAPIURL
+	}
+}
+
+// EffectiveName はi番目の通知先の名前を返す
+func (c NotificationConfig) EffectiveName(i int) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return fmt.Sprintf("notifications[%d]", i)
+}
+
+// Timeout は1件の送信のタイムアウトを返す（省略時、execはコマンドのタイムアウト）
+func (c NotificationConfig) Timeout() time.Duration {
+	switch {
+	case c.TimeoutSeconds > 0:
+		return c.TimeoutSeconds.Duration()
+	case c.Type == NotificationExec:
+		return c.Exec.Timeout()
+	default:
+		return DefaultNotificationTimeout
+	}
+}
+
+// SupportsSummary はサマリーを送信できる通知先かどうかを返す
+func (c NotificationConfig) SupportsSummary() bool {
+	switch c.Type {
+	case NotificationSlack, NotificationDiscord, NotificationTelegram, NotificationNtfy, NotificationPushover, NotificationExec, NotificationMQTT:
+		return true
+	default:
+		return false
+	}
+}
+
+// EffectivePriority はOpsgenieのアラートの優先度を返す
+func (c NotificationConfig) EffectivePriority() string {
+	if c.Priority == "" {
+		return DefaultOpsgeniePriority
+	}
+	return c.Priority
+}
+
+func validateNotifications(notifications []NotificationConfig) error {
+	names := make(map[string]bool)
+	for i, c := range notifications {
+		if names[c.EffectiveName(i)] {
+			return fmt.Errorf("%w: notifications[%d]: duplicate name %q", ErrInvalidNotification, i, c.EffectiveName(i))
+		}
+		names[c.EffectiveName(i)] = true
+		if c.TimeoutSeconds < 0 || c.TimeoutSeconds.Duration() > MaxNotificationTimeout {
+			return fmt.Errorf("%w: notifications[%d]: timeout_seconds must be between 0 and %d", ErrInvalidNotification, i, int(MaxNotificationTimeout.Seconds()))
+		}
+		switch c.Type {
+		case NotificationSlack:
+			if (c.BotToken == "") != (c.Channel == "") {
+				return fmt.Errorf("%w: notifications[%d]: bot_token and channel must be set together for slack", ErrInvalidNotification, i)
+			}
+		case NotificationOpsgenie:
+			if c.APIKey == "" {
+				return fmt.Errorf("%w: notifications[%d]: api_key is required for opsgenie", ErrInvalidNotification, i)
+			}
+			if c.Priority != "" && !slices.Contains(opsgeniePriorities, c.Priority) {
+				return fmt.Errorf("%w: notifications[%d]: priority %q must be one of P1 to P5", ErrInvalidNotification, i, c.Priority)
+			}
+		case NotificationTelegram:
+			if c.BotToken == "" || c.ChatID == "" {
+				return fmt.Errorf("%w: notifications[%d]: bot_token and chat_id are required for telegram", ErrInvalidNotification, i)
+			}
+		case NotificationNtfy:
+			if c.Topic == "" {
+				return fmt.Errorf("%w: notifications[%d]: topic is required for ntfy", ErrInvalidNotification, i)
+			}
+		case NotificationPushover:
+			if c.Token == "" || c.UserKey == "" {
+				return fmt.Errorf("%w: notifications[%d]: token and user_key are required for pushover", ErrInvalidNotification, i)
+			}
+		}
+		if err := validateNotificationRoute(i, c.Route); err != nil {
+			return err
+		}
+		if err := validateQuietHours(i, c.QuietHours); err != nil {
+			return err
+		}
+		if err := validateNotificationTemplate(i, c.Template); err != nil {
+			return err
+		}
+		if err := validateDigest(i, c); err != nil {
+			return err
+		}
+		if err := validateDiscord(i, c); err != nil {
+			return err
+		}
+		if err := validateExec(i, c); err != nil {
+			return err
+		}
+		if err := validateMQTT(i, c); err != nil {
+			return err
+		}
+		if c.APIURL != "" {
+			u, err := url.Parse(c.APIURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("%w: notifications[%d]: api_url %q must be an http(s) URL", ErrInvalidNotification, i, c.APIURL)
+			}
+		}
+	}
+	return nil
+}
+
+func validateNotificationRoute(i int, c *NotificationRouteConfig) error {
+	if c == nil {
+		return nil
+	}
+	for _, pattern := range c.Origins {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: notifications[%d]: route origin pattern %q: %v", ErrInvalidNotification, i, pattern, err)
+		}
+	}
+	for _, event := range c.Events {
+		if !slices.Contains(notificationRouteEvents, event) {
+			return fmt.Errorf("%w: notifications[%d]: unknown route event %q", ErrInvalidNotification, i, event)
+		}
+	}
+	if c.MinSeverity != "" && !slices.Contains(notificationSeverities, c.MinSeverity) {
+		return fmt.Errorf("%w: notifications[%d]: route min_severity %q must be info, warning or critical", ErrInvalidNotification, i, c.MinSeverity)
+	}
+	return nil
+}
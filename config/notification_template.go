@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+// NotificationTemplateConfig は通知メッセージをGoのテンプレートで変更する設定を表す構造体
+type NotificationTemplateConfig struct {
+	Title  string                    `json:"title,omitempty" yaml:"title,omitempty"`   // 見出し（省略時は既定の見出し）
+	Body   string                    `json:"body,omitempty" yaml:"body,omitempty"`     // 本文（省略時は既定の本文）
+	Fields []NotificationFieldConfig `json:"fields,omitempty" yaml:"fields,omitempty"` // 表示する項目（指定すると既定の項目を置き換える）
+}
+
+// NotificationFieldConfig は通知メッセージの項目を表す構造体
+type NotificationFieldConfig struct {
+	Name   string `json:"name" yaml:"name"`                         // 項目名
+	Value  string `json:"value" yaml:"value"`                       // 値のテンプレート
+	Inline bool   `json:"inline,omitempty" yaml:"inline,omitempty"` // SlackとDiscordで他の項目と横に並べるかどうか
+}
+
+// MessageTemplate はテンプレートを解析して通知に設定できる形で返す
+func (c *NotificationTemplateConfig) MessageTemplate() (*notifier.MessageTemplate, error) {
+	if c == nil {
+		return nil, nil
+	}
+	fields := make([]notifier.TemplateField, 0, len(c.Fields))
+	for _, field := range c.Fields {
+		fields = append(fields, notifier.TemplateField{Name: field.Name, Value: field.Value, Inline: field.Inline})
+	}
+	return notifier.NewMessageTemplate(c.Title, c.Body, fields)
+}
+
+func validateNotificationTemplate(i int, c *NotificationTemplateConfig) error {
+	if c == nil {
+		return nil
+	}
+	for _, field := range c.Fields {
+		if field.Name == "" {
+			return fmt.Errorf("%w: notifications[%d]: template fields need a name", ErrInvalidNotification, i)
+		}
+	}
+	if _, err := c.MessageTemplate(); err != nil {
+		return fmt.Errorf("%w: notifications[%d]: %v", ErrInvalidNotification, i, err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/kubernetes"
+)
+
+// ErrInvalidOriginsKubernetes is returned when origins_kubernetes cannot reach the Kubernetes API
+var ErrInvalidOriginsKubernetes = errors.New("invalid origins_kubernetes")
+
+// OriginsKubernetesConfig はKubernetesのGSLBOriginカスタムリソースからオリジンを読み込むための設定を表す構造体
+// 各リソースのspecが1つのオリジンになり、オリジンの状態をstatusに書き込む
+type OriginsKubernetesConfig struct {
+	Namespace     string `json:"namespace,omitempty" yaml:"namespace,omitempty"`           // リソースを読み込むnamespace（省略時はすべてのnamespace）
+	LabelSelector string `json:"label_selector,omitempty" yaml:"label_selector,omitempty"` // 読み込むリソースのラベルセレクタ（例: "gslb/instance=prod"）
+	Server        string `json:"server,omitempty" yaml:"server,omitempty"`                 // APIサーバーのURL（省略時はPod内のアドレス）
+	TokenFile     string `json:"token_file,omitempty" yaml:"token_file,omitempty"`         // Bearerトークンのファイル（省略時はサービスアカウントのトークン）
+	CAFile        string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`               // APIサーバーの証明書を検証するCAのPEMファイル（省略時はPod内ではサービスアカウントのCA）
+}
+
+// KubernetesOrigins はorigins_kubernetesから読み込んだリソースと、それぞれが定義するオリジン
+type KubernetesOrigins struct {
+	Snapshot kubernetes.Snapshot
+	Targets  []kubernetes.Target
+}
+
+// kubernetesOriginSource はGSLBOriginリソースを読み込み、変更を待つ（テストで差し替える）
+type kubernetesOriginSource interface {
+	List(ctx context.Context) (kubernetes.Snapshot, error)
+	Wait(ctx context.Context, snapshot kubernetes.Snapshot) error
+}
+
+// newKubernetesSource はorigins_kubernetesの設定からクライアントを生成する（テストで差し替える）
+var newKubernetesSource = func(c *OriginsKubernetesConfig) (kubernetesOriginSource, error) {
+	return NewKubernetesClient(c)
+}
+
+// NewKubernetesClient はorigins_kubernetesの設定からKubernetes APIのクライアントを生成する
+func NewKubernetesClient(c *OriginsKubernetesConfig) (*kubernetes.Client, error) {
+	return kubernetes.NewClient(kubernetes.Options{
+		Server:        c.Server,
+		TokenFile:     c.TokenFile,
+		CAFile:        c.CAFile,
+		Namespace:     c.Namespace,
+		LabelSelector: c.LabelSelector,
+	})
+}
+
+// loadOriginsKubernetes はGSLBOriginリソースが定義するオリジンをtmpConfigに追加する
+// specが不正なリソースや他と重複するリソースは読み込まず、理由をTargetのErrと警告に記録する
+func loadOriginsKubernetes(tmpConfig *rawConfig) (*KubernetesOrigins, []string, error) {
+	c := tmpConfig.OriginsKubernetes
+	if c == nil {
+		return nil, nil, nil
+	}
+	source, err := newKubernetesSource(c)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidOriginsKubernetes, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), originsKVTimeout)
+	defer cancel()
+	snapshot, err := source.List(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s resources: %w", kubernetes.Kind, err)
+	}
+
+	owners := make(map[string]string, len(tmpConfig.Origins))
+	for _, origin := range tmpConfig.Origins {
+		owners[originKey(origin)] = "the config files"
+	}
+	loaded := &KubernetesOrigins{Snapshot: snapshot}
+	var warnings []string
+	for _, resource := range snapshot.Origins {
+		target := kubernetes.Target{Origin: resource}
+		origin, err := decodeKubernetesOrigin(resource)
+		if err == nil {
+			key := originKey(origin)
+			if owner, ok := owners[key]; ok {
+				err = fmt.Errorf("%w: %s (%s) is already defined in %s", ErrDuplicateOrigin, origin.Name, origin.RecordType, owner)
+			} else {
+				owners[key] = kubernetes.Kind + " " + resource.Key()
+			}
+		}
+		if err != nil {
+			target.Err = err.Error()
+			warnings = append(warnings, fmt.Sprintf("%s %s was not loaded: %v", kubernetes.Kind, resource.Key(), err))
+		} else {
+			target.Zone, target.Host, target.RecordType = origin.ZoneName, origin.Name, origin.RecordType
+			tmpConfig.Origins = append(tmpConfig.Origins, origin)
+		}
+		loaded.Targets = append(loaded.Targets, target)
+	}
+	return loaded, warnings, nil
+}
+
+// decodeKubernetesOrigin はリソースのspecをオリジンとして読み込む（nameの省略時はリソース名）
+func decodeKubernetesOrigin(resource kubernetes.Origin) (OriginConfig, error) {
+	var origin OriginConfig
+	if len(resource.Spec) == 0 {
+		return origin, errors.New("spec is required")
+	}
+	if err := validateSchema(originSchema(), extJSON, resource.Spec); err != nil {
+		return origin, err
+	}
+	if err := decodeFile(extJSON, resource.Spec, &origin); err != nil {
+		return origin, err
+	}
+	if origin.Name == "" {
+		origin.Name = resource.Name
+	}
+	return origin, nil
+}
+
+// WaitOriginsKubernetes はorigins_kubernetesのリソースが追加、削除されるかspecが変更されるまで待つ
+func WaitOriginsKubernetes(ctx context.Context, c *OriginsKubernetesConfig, loaded *KubernetesOrigins) error {
+	source, err := newKubernetesSource(c)
+	if err != nil {
+		return err
+	}
+	var snapshot kubernetes.Snapshot
+	if loaded != nil {
+		snapshot = loaded.Snapshot
+	}
+	return source.Wait(ctx, snapshot)
+}
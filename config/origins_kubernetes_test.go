@@ -0,0 +1,108 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/kubernetes"
+)
+
+type fakeKubernetesSource struct {
+	snapshot kubernetes.Snapshot
+	err      error
+}
+
+func (s fakeKubernetesSource) List(context.Context) (kubernetes.Snapshot, error) {
+	return s.snapshot, s.err
+}
+
+func (s fakeKubernetesSource) Wait(context.Context, kubernetes.Snapshot) error {
+	return s.err
+}
+
+func useFakeKubernetesSource(t *testing.T, source fakeKubernetesSource, err error) {
+	t.Helper()
+	original := newKubernetesSource
+	newKubernetesSource = func(*OriginsKubernetesConfig) (kubernetesOriginSource, error) { return source, err }
+	t.Cleanup(func() { newKubernetesSource = original })
+}
+
+const originsKubernetesConfig = `
+cloudflare_api_token: token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins_kubernetes:
+  namespace: gslb
+  label_selector: gslb/instance=prod
+origins:
+  - name: www.example.com
+    record_type: A
+    health_check: {type: icmp}
+    priority_levels:
+      - priority: 0
+        ips: ["192.0.2.1"]
+`
+
+func kubernetesOrigin(name string, generation int64, spec string) kubernetes.Origin {
+	return kubernetes.Origin{Namespace: "gslb", Name: name, Generation: generation, Spec: json.RawMessage(spec)}
+}
+
+func TestLoadConfig_OriginsKubernetes(t *testing.T) {
+	useFakeKubernetesSource(t, fakeKubernetesSource{snapshot: kubernetes.Snapshot{ResourceVersion: "42", Origins: []kubernetes.Origin{
+		kubernetesOrigin("api.example.com", 1, `{"record_type": "A", "health_check": {"type": "icmp"}, "priority_levels": [{"priority": 0, "ips": ["192.0.2.2"]}]}`),
+		kubernetesOrigin("web", 3, `{"name": "web.example.com", "zone_name": "example.com", "record_type": "AAAA", "health_check": {"type": "https"}, "priority_levels": [{"priority": 0, "ips": ["2001:db8::1"]}]}`),
+		kubernetesOrigin("typo", 2, `{"record_typ": "A"}`),
+		kubernetesOrigin("copy", 1, `{"name": "www.example.com", "record_type": "A", "health_check": {"type": "icmp"}, "priority_levels": [{"priority": 0, "ips": ["192.0.2.3"]}]}`),
+	}}}, nil)
+
+	cfg, err := LoadConfigData("config.yaml", []byte(originsKubernetesConfig))
+	if err != nil {
+		t.Fatalf("LoadConfigData returned error: %v", err)
+	}
+	var names []string
+	for _, origin := range cfg.Origins {
+		names = append(names, origin.Name+"/"+origin.ZoneName)
+	}
+	if got := strings.Join(names, ","); got != "www.example.com/example.com,api.example.com/example.com,web.example.com/example.com" {
+		t.Errorf("Unexpected origins %s", got)
+	}
+
+	loaded := cfg.KubernetesOrigins
+	if loaded == nil || loaded.Snapshot.ResourceVersion != "42" || len(loaded.Targets) != 4 {
+		t.Fatalf("Unexpected resources %+v", loaded)
+	}
+	if target := loaded.Targets[1]; target.Host != "web.example.com" || target.Zone != "example.com" || target.RecordType != "AAAA" || target.Err != "" {
+		t.Errorf("Unexpected target %+v", target)
+	}
+	if target := loaded.Targets[2]; target.Host != "" || !strings.Contains(target.Err, "record_typ") {
+		t.Errorf("Expected the resource with an unknown field to be refused, got %+v", target)
+	}
+	if target := loaded.Targets[3]; !strings.Contains(target.Err, ErrDuplicateOrigin.Error()) {
+		t.Errorf("Expected the duplicate resource to be refused, got %+v", target)
+	}
+	if len(cfg.Warnings) != 2 || !strings.Contains(cfg.Warnings[0], "GSLBOrigin gslb/typo") {
+		t.Errorf("Expected a warning for each refused resource, got %v", cfg.Warnings)
+	}
+}
+
+func TestLoadConfig_OriginsKubernetesErrors(t *testing.T) {
+	t.Run("no API server", func(t *testing.T) {
+		useFakeKubernetesSource(t, fakeKubernetesSource{}, errors.New("not running in a pod"))
+		_, err := LoadConfigData("config.yaml", []byte(originsKubernetesConfig))
+		if !errors.Is(err, ErrInvalidOriginsKubernetes) {
+			t.Errorf("Expected %v, got %v", ErrInvalidOriginsKubernetes, err)
+		}
+	})
+	t.Run("unreachable API server", func(t *testing.T) {
+		useFakeKubernetesSource(t, fakeKubernetesSource{err: errors.New("connection refused")}, nil)
+		_, err := LoadConfigData("config.yaml", []byte(originsKubernetesConfig))
+		if err == nil || !strings.Contains(err.Error(), "failed to read GSLBOrigin resources") {
+			t.Errorf("Expected a read error, got %v", err)
+		}
+	})
+}
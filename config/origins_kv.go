@@ -0,0 +1,122 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/kvstore"
+)
+
+// ErrInvalidOriginsKV is returned when origins_kv has an unknown backend or misses required settings
+var ErrInvalidOriginsKV = errors.New("invalid origins_kv")
+
+// origins_kvのバックエンドの種類
+const (
+	OriginsKVBackendConsul = "consul"
+	OriginsKVBackendEtcd   = "etcd"
+)
+
+// originsKVTimeout は設定読み込み時にKVストアからオリジンを読み込む時間の上限
+const originsKVTimeout = 30 * time.Second
+
+// OriginsKVConfig はConsul KVまたはetcdからオリジンを読み込むための設定を表す構造体
+// プレフィックス以下の各キーの値が1つのオリジン（JSONまたはYAML）になる
+type OriginsKVConfig struct {
+	Backend  string `json:"backend" yaml:"backend"`                       // "consul" または "etcd"
+	Address  string `json:"address" yaml:"address"`                       // http://127.0.0.1:8500 のようなURL
+	Prefix   string `json:"prefix" yaml:"prefix"`                         // オリジンを格納するキーのプレフィックス
+	Token    string `json:"token,omitempty" yaml:"token,omitempty"`       // ConsulのACLトークン
+	Username string `json:"username,omitempty" yaml:"username,omitempty"` // etcdのユーザー名
+	Password string `json:"password,omitempty" yaml:"password,omitempty"` // etcdのパスワード
+}
+
+// newKVStore はorigins_kvの設定からストアを生成する（テストで差し替える）
+var newKVStore = func(c *OriginsKVConfig) kvstore.Store {
+	if c.Backend == OriginsKVBackendEtcd {
+		return kvstore.NewEtcd(c.Address, c.Username, c.Password)
+	}
+	return kvstore.NewConsul(c.Address, c.Token)
+}
+
+func validateOriginsKV(c *OriginsKVConfig) error {
+	if c == nil {
+		return nil
+	}
+	if c.Backend != OriginsKVBackendConsul && c.Backend != OriginsKVBackendEtcd {
+		return fmt.Errorf("%w: unknown backend %q", ErrInvalidOriginsKV, c.Backend)
+	}
+	if c.Address == "" || c.Prefix == "" {
+		return fmt.Errorf("%w: address and prefix are required", ErrInvalidOriginsKV)
+	}
+	return nil
+}
+
+// originSchema はKVストアに格納するオリジン1つ分のJSON Schemaを返す
+func originSchema() map[string]any {
+	return documentSchema(reflect.TypeOf(OriginConfig{}), "cloudflare-gslb origin")
+}
+
+// loadOriginsKV はorigins_kvのプレフィックス以下のオリジンをtmpConfigに追加し、
+// 読み込んだ時点のストアのインデックスを返す
+func loadOriginsKV(tmpConfig *rawConfig) (uint64, error) {
+	c := tmpConfig.OriginsKV
+	if c == nil {
+		return 0, nil
+	}
+	if err := validateOriginsKV(c); err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), originsKVTimeout)
+	defer cancel()
+	entries, index, err := newKVStore(c).List(ctx, c.Prefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read origins from %s: %w", c.Backend, err)
+	}
+
+	owners := make(map[string]string, len(tmpConfig.Origins))
+	for _, origin := range tmpConfig.Origins {
+		owners[originKey(origin)] = "the config files"
+	}
+	for _, entry := range entries {
+		// Consul folders and keys created without a value hold no origin
+		if len(strings.TrimSpace(string(entry.Value))) == 0 {
+			continue
+		}
+		origin, err := decodeKVOrigin(entry.Value)
+		if err != nil {
+			return 0, fmt.Errorf("%s key %s: %w", c.Backend, entry.Key, err)
+		}
+		if origin.Name == "" {
+			origin.Name = path.Base(entry.Key)
+		}
+		key := originKey(origin)
+		if owner, ok := owners[key]; ok {
+			return 0, fmt.Errorf("%w: %s (%s) in %s and %s key %s", ErrDuplicateOrigin, origin.Name, origin.RecordType, owner, c.Backend, entry.Key)
+		}
+		owners[key] = c.Backend + " key " + entry.Key
+		tmpConfig.Origins = append(tmpConfig.Origins, origin)
+	}
+	return index, nil
+}
+
+// decodeKVOrigin はキーの値をオリジンとして読み込む（JSONはYAMLとしても読める）
+func decodeKVOrigin(value []byte) (OriginConfig, error) {
+	var origin OriginConfig
+	if err := validateSchema(originSchema(), extYAML, value); err != nil {
+		return origin, err
+	}
+	err := decodeFile(extYAML, value, &origin)
+	return origin, err
+}
+
+// WaitOriginsKV はorigins_kvのプレフィックス以下のキーがindexより後に変更されるまで待ち、
+// 変更時のインデックスを返す
+func WaitOriginsKV(ctx context.Context, c *OriginsKVConfig, index uint64) (uint64, error) {
+	return newKVStore(c).Wait(ctx, c.Prefix, index)
+}
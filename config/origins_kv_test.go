@@ -0,0 +1,129 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/kvstore"
+)
+
+type fakeKVStore struct {
+	entries []kvstore.Entry
+	index   uint64
+	err     error
+}
+
+func (s fakeKVStore) List(context.Context, string) ([]kvstore.Entry, uint64, error) {
+	return s.entries, s.index, s.err
+}
+
+func (s fakeKVStore) Wait(_ context.Context, _ string, index uint64) (uint64, error) {
+	return index + 1, s.err
+}
+
+func useFakeKVStore(t *testing.T, store fakeKVStore) {
+	t.Helper()
+	original := newKVStore
+	newKVStore = func(*OriginsKVConfig) kvstore.Store { return store }
+	t.Cleanup(func() { newKVStore = original })
+}
+
+const originsKVConfig = `
+cloudflare_api_token: token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins_kv:
+  backend: consul
+  address: http://127.0.0.1:8500
+  prefix: gslb/origins/
+origins:
+  - name: www.example.com
+    record_type: A
+    health_check: {type: icmp}
+    priority_levels:
+      - priority: 0
+        ips: ["192.0.2.1"]
+`
+
+func TestLoadConfig_OriginsKV(t *testing.T) {
+	useFakeKVStore(t, fakeKVStore{index: 42, entries: []kvstore.Entry{
+		{Key: "gslb/origins/"},
+		{Key: "gslb/origins/api.example.com", Value: []byte(`{"record_type": "A", "health_check": {"type": "icmp"}, "priority_levels": [{"priority": 0, "ips": ["192.0.2.2"]}]}`)},
+		{Key: "gslb/origins/web", Value: []byte("name: web.example.com\nrecord_type: AAAA\nhealth_check: {type: https}\npriority_levels:\n  - priority: 0\n    ips: [\"2001:db8::1\"]\n")},
+	}})
+
+	cfg, err := LoadConfigData("config.yaml", []byte(originsKVConfig))
+	if err != nil {
+		t.Fatalf("LoadConfigData returned error: %v", err)
+	}
+	if cfg.OriginsKVIndex != 42 {
+		t.Errorf("Expected index 42, got %d", cfg.OriginsKVIndex)
+	}
+	var names []string
+	for _, origin := range cfg.Origins {
+		names = append(names, origin.Name+"/"+origin.ZoneName)
+	}
+	if got := strings.Join(names, ","); got != "www.example.com/example.com,api.example.com/example.com,web.example.com/example.com" {
+		t.Errorf("Unexpected origins %s", got)
+	}
+}
+
+func TestLoadConfig_OriginsKVErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		store   fakeKVStore
+		wantErr error
+		message string
+	}{
+		{
+			name:    "unknown backend",
+			config:  strings.Replace(originsKVConfig, "backend: consul", "backend: zookeeper", 1),
+			wantErr: ErrInvalidOriginsKV,
+		},
+		{
+			name:    "missing prefix",
+			config:  strings.Replace(originsKVConfig, "prefix: gslb/origins/", "", 1),
+			wantErr: ErrInvalidOriginsKV,
+		},
+		{
+			name:    "duplicate origin",
+			config:  originsKVConfig,
+			store:   fakeKVStore{entries: []kvstore.Entry{{Key: "gslb/origins/www", Value: []byte(`{"name": "www.example.com", "record_type": "A"}`)}}},
+			wantErr: ErrDuplicateOrigin,
+		},
+		{
+			name:    "unknown field",
+			config:  originsKVConfig,
+			store:   fakeKVStore{entries: []kvstore.Entry{{Key: "gslb/origins/api", Value: []byte(`{"record_typ": "A"}`)}}},
+			wantErr: ErrSchemaViolation,
+			message: "gslb/origins/api",
+		},
+		{
+			name:    "unreachable store",
+			config:  originsKVConfig,
+			store:   fakeKVStore{err: errors.New("connection refused")},
+			message: "failed to read origins from consul",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeKVStore(t, tt.store)
+			_, err := LoadConfigData("config.yaml", []byte(tt.config))
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Expected the error to mention %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
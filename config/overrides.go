@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 設定値を上書きする環境変数
+const (
+	EnvConfigPath    = "GSLB_CONFIG"
+	EnvCheckInterval = "GSLB_CHECK_INTERVAL"
+	EnvDryRun        = "GSLB_DRY_RUN"
+	EnvAPIToken      = "GSLB_API_TOKEN"
+)
+
+// Overrides はコマンドラインフラグや環境変数から指定され、設定ファイルの値より優先される値を表す構造体
+// ゼロ値の項目は上書きしない
+type Overrides struct {
+	CheckInterval time.Duration // ヘルスチェックの間隔
+	DryRun        bool          // すべてのオリジンを観測専用モードにするかどうか
+	APIToken      string        // グローバルのCloudflare APIトークン
+}
+
+// OverridesFromEnv は環境変数から上書きする値を読み込む
+func OverridesFromEnv(lookup func(string) (string, bool)) (Overrides, error) {
+	var o Overrides
+	if value, ok := lookup(EnvCheckInterval); ok && value != "" {
+		var interval Seconds
+		if err := interval.UnmarshalText([]byte(value)); err != nil {
+			return o, fmt.Errorf("%s: %w", EnvCheckInterval, err)
+		}
+		o.CheckInterval = interval.Duration()
+	}
+	if value, ok := lookup(EnvDryRun); ok && value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			return o, fmt.Errorf("%s: %w", EnvDryRun, err)
+		}
+		o.DryRun = dryRun
+	}
+	if value, ok := lookup(EnvAPIToken); ok {
+		o.APIToken = value
+	}
+	return o, nil
+}
+
+// Apply は設定に上書きする値を反映する
+// APIトークンはcloudflare_api_tokenとcloudflare_api_token_fileの代わりに使われ、
+// ゾーンやアカウントごとのトークンは上書きしない
+func (o Overrides) Apply(c *Config) {
+	if o.CheckInterval > 0 {
+		c.CheckInterval = o.CheckInterval
+	}
+	if o.DryRun {
+		for i := range c.Origins {
+			c.Origins[i].Mode = OriginModeObserve
+		}
+	}
+	if o.APIToken != "" {
+		c.CloudflareAPIToken = o.APIToken
+		c.APITokenFile = ""
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOverridesFromEnv(t *testing.T) {
+	env := map[string]string{
+		EnvCheckInterval: "15s",
+		EnvDryRun:        "true",
+		EnvAPIToken:      "env-token",
+	}
+	o, err := OverridesFromEnv(func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	})
+	if err != nil {
+		t.Fatalf("OverridesFromEnv returned error: %v", err)
+	}
+	if o.CheckInterval != 15*time.Second || !o.DryRun || o.APIToken != "env-token" {
+		t.Errorf("Unexpected overrides %+v", o)
+	}
+
+	env[EnvCheckInterval] = "30"
+	if o, err := OverridesFromEnv(func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}); err != nil || o.CheckInterval != 30*time.Second {
+		t.Errorf("Expected a bare number to be seconds, got %v (%v)", o.CheckInterval, err)
+	}
+}
+
+func TestOverridesFromEnv_Invalid(t *testing.T) {
+	for key, value := range map[string]string{EnvCheckInterval: "soon", EnvDryRun: "maybe"} {
+		_, err := OverridesFromEnv(func(k string) (string, bool) {
+			if k == key {
+				return value, true
+			}
+			return "", false
+		})
+		if err == nil {
+			t.Errorf("Expected an error for %s=%s", key, value)
+		}
+	}
+}
+
+func TestOverrides_Apply(t *testing.T) {
+	cfg := &Config{
+		CloudflareAPIToken: "file-token",
+		APITokenFile:       "/run/secrets/token",
+		CheckInterval:      time.Minute,
+		Origins:            []OriginConfig{{Name: "www"}, {Name: "api", Mode: OriginModeActive}},
+	}
+
+	Overrides{}.Apply(cfg)
+	if cfg.CheckInterval != time.Minute || cfg.CloudflareAPIToken != "file-token" || cfg.Origins[1].IsObserveOnly() {
+		t.Fatalf("Expected empty overrides to change nothing, got %+v", cfg)
+	}
+
+	Overrides{CheckInterval: 10 * time.Second, DryRun: true, APIToken: "override"}.Apply(cfg)
+	if cfg.CheckInterval != 10*time.Second {
+		t.Errorf("Expected the check interval to be overridden, got %v", cfg.CheckInterval)
+	}
+	if cfg.CloudflareAPIToken != "override" || cfg.APITokenFile != "" {
+		t.Errorf("Expected the token to replace the token file, got %q and %q", cfg.CloudflareAPIToken, cfg.APITokenFile)
+	}
+	for _, origin := range cfg.Origins {
+		if !origin.IsObserveOnly() {
+			t.Errorf("Expected %s to be observe only", origin.Name)
+		}
+	}
+}
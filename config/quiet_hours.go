@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 静かな時間帯が終わった後の通知の送り方
+const (
+	QuietDeliverQueue   = "queue"
+	QuietDeliverSummary = "summary"
+)
+
+// QuietBypassNone はすべてのイベントを静かな時間帯の後に送るbypass_severity
+const QuietBypassNone = "none"
+
+// DefaultQuietBypassSeverity はbypass_severityを省略したときにすぐ送る最低の重要度
+const DefaultQuietBypassSeverity = "critical"
+
+// QuietHoursConfig は通知先ごとに通知を控える時間帯を表す構造体
+type QuietHoursConfig struct {
+	Windows        []QuietWindowConfig `json:"windows" yaml:"windows"`                                     // 通知を控える時間帯
+	Deliver        string              `json:"deliver,omitempty" yaml:"deliver,omitempty"`                 // 時間帯の後の送り方（"queue"はすべて、"summary"はオリジンごとに1件、省略時は"queue"）
+	BypassSeverity string              `json:"bypass_severity,omitempty" yaml:"bypass_severity,omitempty"` // 時間帯中もすぐ送る最低の重要度（"warning"、"critical"、"none"、省略時は"critical"）
+}
+
+// QuietWindowConfig は通知を控える時間帯を表す構造体
+type QuietWindowConfig struct {
+	Start    string   `json:"start" yaml:"start"`                           // 開始時刻（"HH:MM"）
+	End      string   `json:"end" yaml:"end"`                               // 終了時刻（"HH:MM"、開始時刻より前なら翌日）
+	Days     []string `json:"days,omitempty" yaml:"days,omitempty"`         // 開始する曜日（"mon"〜"sun"、省略時は毎日）
+	Timezone string   `json:"timezone,omitempty" yaml:"timezone,omitempty"` // タイムゾーン（省略時はUTC）
+}
+
+// ActiveAt は時刻tがいずれかの時間帯に含まれるかどうかを返す
+func (c *QuietHoursConfig) ActiveAt(t time.Time) bool {
+	if c == nil {
+		return false
+	}
+	for _, window := range c.Windows {
+		if window.schedule().ActiveAt(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// EffectiveDeliver は時間帯の後の送り方を返す
+func (c *QuietHoursConfig) EffectiveDeliver() string {
+	if c == nil || c.Deliver == "" {
+		return QuietDeliverQueue
+	}
+	return c.Deliver
+}
+
+// EffectiveBypassSeverity は時間帯中もすぐ送る最低の重要度を返す
+func (c *QuietHoursConfig) EffectiveBypassSeverity() string {
+	if c == nil || c.BypassSeverity == "" {
+		return DefaultQuietBypassSeverity
+	}
+	return c.BypassSeverity
+}
+
+// schedule は時間帯の判定に計画切替と同じ実装を使うための変換
+func (w QuietWindowConfig) schedule() ScheduleConfig {
+	return ScheduleConfig{Start: w.Start, End: w.End, Days: w.Days, Timezone: w.Timezone}
+}
+
+func validateQuietHours(i int, c *QuietHoursConfig) error {
+	if c == nil {
+		return nil
+	}
+	if len(c.Windows) == 0 {
+		return fmt.Errorf("%w: notifications[%d]: quiet_hours needs at least one window", ErrInvalidNotification, i)
+	}
+	for _, window := range c.Windows {
+		start, err := parseClock(window.Start)
+		if err != nil {
+			return fmt.Errorf("%w: notifications[%d]: quiet_hours start %q must be HH:MM", ErrInvalidNotification, i, window.Start)
+		}
+		end, err := parseClock(window.End)
+		if err != nil {
+			return fmt.Errorf("%w: notifications[%d]: quiet_hours end %q must be HH:MM", ErrInvalidNotification, i, window.End)
+		}
+		if start == end {
+			return fmt.Errorf("%w: notifications[%d]: quiet_hours window %s-%s is empty", ErrInvalidNotification, i, window.Start, window.End)
+		}
+		for _, day := range window.Days {
+			if _, ok := weekdays[strings.ToLower(day)]; !ok {
+				return fmt.Errorf("%w: notifications[%d]: quiet_hours has an unknown day %q", ErrInvalidNotification, i, day)
+			}
+		}
+		if _, err := window.schedule().Location(); err != nil {
+			return fmt.Errorf("%w: notifications[%d]: quiet_hours timezone: %v", ErrInvalidNotification, i, err)
+		}
+	}
+	if c.Deliver != "" && c.Deliver != QuietDeliverQueue && c.Deliver != QuietDeliverSummary {
+		return fmt.Errorf("%w: notifications[%d]: quiet_hours deliver %q must be queue or summary", ErrInvalidNotification, i, c.Deliver)
+	}
+	if c.BypassSeverity != "" && !slices.Contains([]string{"warning", "critical", QuietBypassNone}, c.BypassSeverity) {
+		return fmt.Errorf("%w: notifications[%d]: quiet_hours bypass_severity %q must be warning, critical or none", ErrInvalidNotification, i, c.BypassSeverity)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidRecordBinding is returned when record_binding names neither record IDs nor a comment
+var ErrInvalidRecordBinding = errors.New("invalid record_binding")
+
+// RecordBindingConfig はオリジンが管理するDNSレコードを限定する設定を表す構造体
+type RecordBindingConfig struct {
+	IDs     []string `json:"ids,omitempty" yaml:"ids,omitempty"`         // 管理対象のCloudflareレコードID（その場で更新される）
+	Comment string   `json:"comment,omitempty" yaml:"comment,omitempty"` // コメントにこの文字列を含むレコードを管理対象とする
+}
+
+// Enabled は管理対象のレコードが限定されているかどうかを返す
+func (c *RecordBindingConfig) Enabled() bool {
+	return c != nil
+}
+
+func validateRecordBinding(c *RecordBindingConfig) error {
+	if c.Enabled() && len(c.IDs) == 0 && c.Comment == "" {
+		return fmt.Errorf("%w: ids or comment is required", ErrInvalidRecordBinding)
+	}
+	return nil
+}
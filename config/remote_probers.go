@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// リモートのプローバーとこのデーモン自身のチェックの結果の組み合わせ方
+const (
+	RemoteProbersQuorumMajority = "majority" // 過半数が正常な場合に正常（デフォルト）
+	RemoteProbersQuorumAny      = "any"      // いずれかが正常なら正常
+	RemoteProbersQuorumAll      = "all"      // すべてが正常な場合のみ正常
+)
+
+// remoteProbersMaxAgeChecks は結果の有効期間を省略したときのチェック間隔に対する倍数
+const remoteProbersMaxAgeChecks = 3
+
+// RemoteProbersConfig はgRPCで登録したリモートのプローバーのヘルスチェックの結果をIPの判定に使う設定を表す構造体
+type RemoteProbersConfig struct {
+	Quorum        string  `json:"quorum,omitempty" yaml:"quorum,omitempty"`                   // "majority"（デフォルト）、"any"、"all"
+	Local         *bool   `json:"local,omitempty" yaml:"local,omitempty"`                     // このデーモン自身のチェックを結果に含めるかどうか（省略時はtrue）
+	MaxAgeSeconds Seconds `json:"max_age_seconds,omitempty" yaml:"max_age_seconds,omitempty"` // これより古いプローバーの結果は使わない（省略時はcheck_interval_secondsの3倍）
+}
+
+// Enabled はリモートのプローバーの結果を使うかどうかを返す
+func (c *RemoteProbersConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveQuorum は結果の組み合わせ方を返す（省略時は "majority"）
+func (c *RemoteProbersConfig) EffectiveQuorum() string {
+	if c == nil || c.Quorum == "" {
+		return RemoteProbersQuorumMajority
+	}
+	return c.Quorum
+}
+
+// LocalEnabled はこのデーモン自身のチェックを結果に含めるかどうかを返す
+func (c *RemoteProbersConfig) LocalEnabled() bool {
+	return c == nil || c.Local == nil || *c.Local
+}
+
+// EffectiveMaxAge はプローバーの結果の有効期間を返す（省略時はチェック間隔の3倍）
+func (c *RemoteProbersConfig) EffectiveMaxAge(checkInterval time.Duration) time.Duration {
+	if c == nil || c.MaxAgeSeconds <= 0 {
+		return remoteProbersMaxAgeChecks * checkInterval
+	}
+	return c.MaxAgeSeconds.Duration()
+}
+
+func validateRemoteProbers(c *RemoteProbersConfig) error {
+	if !c.Enabled() {
+		return nil
+	}
+	switch c.EffectiveQuorum() {
+	case RemoteProbersQuorumMajority, RemoteProbersQuorumAny, RemoteProbersQuorumAll:
+	default:
+		return fmt.Errorf("unknown quorum %q", c.Quorum)
+	}
+	if c.MaxAgeSeconds < 0 {
+		return fmt.Errorf("max_age_seconds must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+var (
+	// ErrRuntimeFileNotSupported is returned when a config that was not read from a local file sets admin_api.runtime_file
+	ErrRuntimeFileNotSupported = errors.New("admin_api.runtime_file is only supported in local config files")
+	// ErrRuntimeEntryExists is returned when an origin or notification added through the admin API is already in the runtime file
+	ErrRuntimeEntryExists = errors.New("already in the runtime file")
+	// ErrRuntimeEntryNotFound is returned when an origin or notification removed through the admin API is not in the runtime file
+	ErrRuntimeEntryNotFound = errors.New("not in the runtime file")
+	// ErrInvalidRuntimeEntry is returned when a notification added through the admin API has no name or acts on the daemon host
+	ErrInvalidRuntimeEntry = errors.New("invalid runtime file entry")
+)
+
+// RuntimeFile は管理用APIで追加したオリジンと通知を保存するファイルの内容を表す構造体
+// ファイルはJSONで、設定ファイルのオリジンと通知に追加して読み込む
+type RuntimeFile struct {
+	Origins       []OriginConfig       `json:"origins,omitempty" yaml:"origins,omitempty"`
+	Notifications []NotificationConfig `json:"notifications,omitempty" yaml:"notifications,omitempty"`
+}
+
+// runtimeFileSchema はruntime_fileのJSON Schemaを返す
+func runtimeFileSchema() map[string]any {
+	return documentSchema(reflect.TypeOf(RuntimeFile{}), "cloudflare-gslb runtime origins and notifications")
+}
+
+// ReadRuntimeFile はpathのファイルを環境変数を展開せずに読み込む
+// ファイルがない場合は空の内容を返す
+func ReadRuntimeFile(path string) (*RuntimeFile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &RuntimeFile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeRuntimeFile(data)
+}
+
+func decodeRuntimeFile(data []byte) (*RuntimeFile, error) {
+	if err := validateSchema(runtimeFileSchema(), extJSON, data); err != nil {
+		return nil, err
+	}
+	var f RuntimeFile
+	if err := decodeFile(extJSON, data, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// WriteRuntimeFile はfをpathに書き込む
+// 途中で失敗しても元のファイルが壊れないよう、一時ファイルに書いてから置き換える
+func WriteRuntimeFile(path string, f *RuntimeFile) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write the runtime file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	// The file holds webhook URLs and API keys like the config file
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the runtime file: %w", err)
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the runtime file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the runtime file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write the runtime file: %w", err)
+	}
+	return nil
+}
+
+// AddOrigin はオリジンを追加する
+func (f *RuntimeFile) AddOrigin(origin OriginConfig) error {
+	key := originKey(origin)
+	for _, existing := range f.Origins {
+		if originKey(existing) == key {
+			return fmt.Errorf("origin %s (%s): %w", origin.Name, origin.RecordType, ErrRuntimeEntryExists)
+		}
+	}
+	f.Origins = append(f.Origins, origin)
+	return nil
+}
+
+// RemoveOrigin はゾーン、名前、レコードタイプが一致するオリジンを削除する
+// ゾーンを省略して追加したオリジンはデフォルトのゾーンを補われるため、どのゾーンとも一致する
+func (f *RuntimeFile) RemoveOrigin(zone, name, recordType string) error {
+	for i, origin := range f.Origins {
+		if (origin.ZoneName == zone || origin.ZoneName == "") && origin.Name == name && origin.RecordType == recordType {
+			f.Origins = append(f.Origins[:i:i], f.Origins[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("origin %s (%s): %w", name, recordType, ErrRuntimeEntryNotFound)
+}
+
+// AddNotification は通知を追加する
+// 削除するときに指定できるよう、nameは必須
+// APIの呼び出し元にデーモンのホストを操作させないよう、コマンドを実行するexecとホストのファイルを読むMQTTの証明書は追加できない
+func (f *RuntimeFile) AddNotification(notification NotificationConfig) error {
+	if notification.Name == "" {
+		return fmt.Errorf("%w: the notification needs a name", ErrInvalidRuntimeEntry)
+	}
+	if notification.Type == NotificationExec || notification.Exec != nil {
+		return fmt.Errorf("%w: exec notifications can only be added in the config file", ErrInvalidRuntimeEntry)
+	}
+	if notification.MQTT != nil && notification.MQTT.TLS != nil &&
+		(notification.MQTT.TLS.CAFile != "" || notification.MQTT.TLS.CertFile != "" || notification.MQTT.TLS.KeyFile != "") {
+		return fmt.Errorf("%w: mqtt certificate files can only be set in the config file", ErrInvalidRuntimeEntry)
+	}
+	for _, existing := range f.Notifications {
+		if existing.Name == notification.Name {
+			return fmt.Errorf("notification %s: %w", notification.Name, ErrRuntimeEntryExists)
+		}
+	}
+	f.Notifications = append(f.Notifications, notification)
+	return nil
+}
+
+// RemoveNotification は名前が一致する通知を削除する
+func (f *RuntimeFile) RemoveNotification(name string) error {
+	for i, notification := range f.Notifications {
+		if notification.Name == name {
+			f.Notifications = append(f.Notifications[:i:i], f.Notifications[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("notification %s: %w", name, ErrRuntimeEntryNotFound)
+}
+
+// loadRuntimeFile はadmin_api.runtime_fileのオリジンと通知をtmpConfigに追加し、ファイルのパスを返す
+// パスは設定ファイルのディレクトリからの相対パスで、ファイルがなくてもよい
+// 内容はAPIの呼び出し元が書いたものなので、デーモンの秘密情報を送らせないよう環境変数は展開しない
+func loadRuntimeFile(tmpConfig *rawConfig, baseDir string) (string, error) {
+	if tmpConfig.AdminAPI == nil || tmpConfig.AdminAPI.RuntimeFile == "" {
+		return "", nil
+	}
+	if baseDir == "" {
+		return "", ErrRuntimeFileNotSupported
+	}
+	path := tmpConfig.AdminAPI.RuntimeFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return path, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	f, err := decodeRuntimeFile(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+
+	owners := make(map[string]bool, len(tmpConfig.Origins))
+	for _, origin := range tmpConfig.Origins {
+		owners[originKey(origin)] = true
+	}
+	for _, origin := range f.Origins {
+		if owners[originKey(origin)] {
+			return "", fmt.Errorf("%w: %s (%s) in the config files and %s", ErrDuplicateOrigin, origin.Name, origin.RecordType, path)
+		}
+	}
+	tmpConfig.Origins = append(tmpConfig.Origins, f.Origins...)
+	tmpConfig.Notifications = append(tmpConfig.Notifications, f.Notifications...)
+	return path, nil
+}
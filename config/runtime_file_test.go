@@ -0,0 +1,147 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const runtimeMainConfig = `
+cloudflare_api_token: token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+admin_api:
+  token: s3cret
+  runtime_file: runtime.json
+notifications:
+  - type: slack
+    name: ops
+    webhook_url: https://hooks.slack.com/services/T000/B000/XXX
+origins:
+  - name: www.example.com
+    record_type: A
+    health_check: {type: icmp}
+    priority_levels:
+      - priority: 0
+        ips: ["192.0.2.1"]
+`
+
+func TestLoadConfig_RuntimeFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeConfigFile(t, path, runtimeMainConfig)
+	runtimePath := filepath.Join(dir, "runtime.json")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() without the runtime file error = %v", err)
+	}
+	if cfg.RuntimeFile != runtimePath || len(cfg.Origins) != 1 || len(cfg.Notifications) != 1 {
+		t.Fatalf("Expected only the config file entries and the runtime file %s, got %s with %d origins and %d notifications", runtimePath, cfg.RuntimeFile, len(cfg.Origins), len(cfg.Notifications))
+	}
+
+	f, err := ReadRuntimeFile(runtimePath)
+	if err != nil {
+		t.Fatalf("ReadRuntimeFile() of a missing file error = %v", err)
+	}
+	if err := f.AddOrigin(OriginConfig{
+		Name:           "api.example.com",
+		RecordType:     "A",
+		HealthCheck:    HealthCheck{Type: "icmp"},
+		PriorityLevels: []PriorityLevel{{Priority: 0, IPs: []string{"192.0.2.2"}}},
+	}); err != nil {
+		t.Fatalf("AddOrigin() error = %v", err)
+	}
+	if err := f.AddNotification(NotificationConfig{Type: NotificationSlack, Name: "team", WebhookURL: "${TEAM_WEBHOOK_URL}"}); err != nil {
+		t.Fatalf("AddNotification() error = %v", err)
+	}
+	if err := WriteRuntimeFile(runtimePath, f); err != nil {
+		t.Fatalf("WriteRuntimeFile() error = %v", err)
+	}
+	if info, err := os.Stat(runtimePath); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the runtime file to be private, got %v (%v)", info.Mode().Perm(), err)
+	}
+
+	t.Setenv("TEAM_WEBHOOK_URL", "https://hooks.slack.com/services/T000/B000/YYY")
+	cfg, err = LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.Origins) != 2 || cfg.Origins[1].Name != "api.example.com" || cfg.Origins[1].ZoneName != "example.com" {
+		t.Errorf("Expected the runtime origin after the config file ones with the default zone, got %+v", cfg.Origins)
+	}
+	// Entries written through the API must not read the environment of the daemon
+	if len(cfg.Notifications) != 2 || cfg.Notifications[1].WebhookURL != "${TEAM_WEBHOOK_URL}" {
+		t.Errorf("Expected the runtime notification with its webhook URL not expanded, got %+v", cfg.Notifications)
+	}
+
+	// The file keeps the reference, not the secret
+	f, err = ReadRuntimeFile(runtimePath)
+	if err != nil {
+		t.Fatalf("ReadRuntimeFile() error = %v", err)
+	}
+	if f.Notifications[0].WebhookURL != "${TEAM_WEBHOOK_URL}" {
+		t.Errorf("Expected the webhook URL reference, got %q", f.Notifications[0].WebhookURL)
+	}
+}
+
+func TestRuntimeFile_Edit(t *testing.T) {
+	var f RuntimeFile
+	origin := OriginConfig{Name: "api.example.com", RecordType: "A"}
+	if err := f.AddOrigin(origin); err != nil {
+		t.Fatalf("AddOrigin() error = %v", err)
+	}
+	if err := f.AddOrigin(origin); !errors.Is(err, ErrRuntimeEntryExists) {
+		t.Errorf("Expected ErrRuntimeEntryExists for the same origin, got %v", err)
+	}
+	if err := f.RemoveOrigin("example.com", "www.example.com", "A"); !errors.Is(err, ErrRuntimeEntryNotFound) {
+		t.Errorf("Expected ErrRuntimeEntryNotFound for another origin, got %v", err)
+	}
+	// The origin was added without a zone and takes the default one
+	if err := f.RemoveOrigin("example.com", "api.example.com", "A"); err != nil || len(f.Origins) != 0 {
+		t.Errorf("RemoveOrigin() error = %v, %d origins left", err, len(f.Origins))
+	}
+
+	if err := f.AddNotification(NotificationConfig{Type: NotificationSlack}); !errors.Is(err, ErrInvalidRuntimeEntry) {
+		t.Errorf("Expected ErrInvalidRuntimeEntry without a name, got %v", err)
+	}
+	if err := f.AddNotification(NotificationConfig{Type: NotificationExec, Name: "hook", Exec: &ExecConfig{Command: []string{"/bin/sh"}}}); !errors.Is(err, ErrInvalidRuntimeEntry) {
+		t.Errorf("Expected ErrInvalidRuntimeEntry for an exec notification, got %v", err)
+	}
+	mqtt := &MQTTConfig{Broker: "ssl://broker.example.com", Topic: "gslb", TLS: &MQTTTLSConfig{KeyFile: "/etc/ssl/private/key.pem"}}
+	if err := f.AddNotification(NotificationConfig{Type: NotificationMQTT, Name: "broker", MQTT: mqtt}); !errors.Is(err, ErrInvalidRuntimeEntry) {
+		t.Errorf("Expected ErrInvalidRuntimeEntry for mqtt certificate files, got %v", err)
+	}
+	if len(f.Notifications) != 0 {
+		t.Fatalf("Expected no notifications after the rejected ones, got %+v", f.Notifications)
+	}
+	if err := f.AddNotification(NotificationConfig{Type: NotificationSlack, Name: "team"}); err != nil {
+		t.Fatalf("AddNotification() error = %v", err)
+	}
+	if err := f.AddNotification(NotificationConfig{Type: NotificationSlack, Name: "team"}); !errors.Is(err, ErrRuntimeEntryExists) {
+		t.Errorf("Expected ErrRuntimeEntryExists for the same name, got %v", err)
+	}
+	if err := f.RemoveNotification("other"); !errors.Is(err, ErrRuntimeEntryNotFound) {
+		t.Errorf("Expected ErrRuntimeEntryNotFound for another name, got %v", err)
+	}
+	if err := f.RemoveNotification("team"); err != nil || len(f.Notifications) != 0 {
+		t.Errorf("RemoveNotification() error = %v, %d notifications left", err, len(f.Notifications))
+	}
+}
+
+func TestLoadConfig_RuntimeFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeConfigFile(t, path, runtimeMainConfig)
+	writeConfigFile(t, filepath.Join(dir, "runtime.json"), `{"origins": [{"name": "www.example.com", "record_type": "A", "health_check": {"type": "icmp"}, "priority_levels": [{"priority": 0, "ips": ["192.0.2.3"]}]}]}`)
+	if _, err := LoadConfig(path); !errors.Is(err, ErrDuplicateOrigin) {
+		t.Errorf("Expected ErrDuplicateOrigin for an origin of the config file, got %v", err)
+	}
+
+	if _, err := LoadConfigData("config.yaml", []byte(runtimeMainConfig)); !errors.Is(err, ErrRuntimeFileNotSupported) {
+		t.Errorf("Expected ErrRuntimeFileNotSupported for a remote config, got %v", err)
+	}
+}
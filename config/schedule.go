@@ -0,0 +1,130 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSchedule is returned when a scheduled switch has an invalid window or target
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// ScheduleConfig は指定した時間帯にヘルスチェックに関係なくIPを切り替える計画切替の設定を表す構造体
+type ScheduleConfig struct {
+	Name     string   `json:"name" yaml:"name"`                             // ログ・通知用の名前
+	Start    string   `json:"start" yaml:"start"`                           // 開始時刻（"HH:MM"）
+	End      string   `json:"end" yaml:"end"`                               // 終了時刻（"HH:MM"、開始時刻より前なら翌日）
+	Days     []string `json:"days,omitempty" yaml:"days,omitempty"`         // 開始する曜日（"mon"〜"sun"、省略時は毎日）
+	Timezone string   `json:"timezone,omitempty" yaml:"timezone,omitempty"` // タイムゾーン（省略時はUTC）
+	IPs      []string `json:"ips" yaml:"ips"`                               // 時間帯中に公開するIP
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// DisplayName はログ・通知用の名前を返す
+func (s ScheduleConfig) DisplayName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Start + "-" + s.End
+}
+
+// Location はスケジュールのタイムゾーンを返す
+func (s ScheduleConfig) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+// ActiveAt は時刻tがスケジュールの時間帯に含まれるかどうかを返す
+func (s ScheduleConfig) ActiveAt(t time.Time) bool {
+	loc, err := s.Location()
+	if err != nil {
+		return false
+	}
+	start, err := parseClock(s.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(s.End)
+	if err != nil {
+		return false
+	}
+
+	t = t.In(loc)
+	minute := t.Hour()*60 + t.Minute()
+	if start < end {
+		return minute >= start && minute < end && s.runsOn(t.Weekday())
+	}
+	// The window wraps past midnight, so the early part belongs to the previous day's window
+	if minute >= start {
+		return s.runsOn(t.Weekday())
+	}
+	return minute < end && s.runsOn(t.AddDate(0, 0, -1).Weekday())
+}
+
+func (s ScheduleConfig) runsOn(day time.Weekday) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+	for _, d := range s.Days {
+		if weekdays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveSchedule は時刻tに有効なスケジュールを返す（複数ある場合は最初のもの）
+func (o OriginConfig) ActiveSchedule(t time.Time) (ScheduleConfig, bool) {
+	for _, schedule := range o.Schedules {
+		if schedule.ActiveAt(t) {
+			return schedule, true
+		}
+	}
+	return ScheduleConfig{}, false
+}
+
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: time %q must be HH:MM", ErrInvalidSchedule, value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func validateSchedules(schedules []ScheduleConfig) error {
+	for _, schedule := range schedules {
+		if _, err := parseClock(schedule.Start); err != nil {
+			return err
+		}
+		if _, err := parseClock(schedule.End); err != nil {
+			return err
+		}
+		if schedule.Start == schedule.End {
+			return fmt.Errorf("%w: %s has an empty window", ErrInvalidSchedule, schedule.DisplayName())
+		}
+		for _, day := range schedule.Days {
+			if _, ok := weekdays[strings.ToLower(day)]; !ok {
+				return fmt.Errorf("%w: unknown day %q", ErrInvalidSchedule, day)
+			}
+		}
+		if _, err := schedule.Location(); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+		}
+		if len(schedule.IPs) == 0 {
+			return fmt.Errorf("%w: %s has no IPs", ErrInvalidSchedule, schedule.DisplayName())
+		}
+	}
+	return nil
+}
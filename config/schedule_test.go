@@ -0,0 +1,81 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduleConfig_ActiveAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule ScheduleConfig
+		at       time.Time
+		want     bool
+	}{
+		{
+			name:     "inside daily window",
+			schedule: ScheduleConfig{Start: "02:00", End: "04:00"},
+			at:       time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC),
+			want:     true,
+		},
+		{
+			name:     "end is exclusive",
+			schedule: ScheduleConfig{Start: "02:00", End: "04:00"},
+			at:       time.Date(2024, 1, 1, 4, 0, 0, 0, time.UTC),
+			want:     false,
+		},
+		{
+			name:     "window wrapping midnight after start",
+			schedule: ScheduleConfig{Start: "22:00", End: "02:00", Days: []string{"mon"}},
+			at:       time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC), // Monday
+			want:     true,
+		},
+		{
+			name:     "window wrapping midnight belongs to the start day",
+			schedule: ScheduleConfig{Start: "22:00", End: "02:00", Days: []string{"mon"}},
+			at:       time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC), // Tuesday
+			want:     true,
+		},
+		{
+			name:     "other day",
+			schedule: ScheduleConfig{Start: "02:00", End: "04:00", Days: []string{"Sat", "sun"}},
+			at:       time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC), // Monday
+			want:     false,
+		},
+		{
+			name:     "timezone",
+			schedule: ScheduleConfig{Start: "02:00", End: "04:00", Timezone: "Asia/Tokyo"},
+			at:       time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC), // 03:00 JST
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.ActiveAt(tt.at); got != tt.want {
+				t.Errorf("ActiveAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSchedules(t *testing.T) {
+	valid := ScheduleConfig{Start: "02:00", End: "04:00", IPs: []string{"203.0.113.10"}}
+	if err := validateSchedules([]ScheduleConfig{valid}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	invalid := []ScheduleConfig{
+		{Start: "2am", End: "04:00", IPs: []string{"203.0.113.10"}},
+		{Start: "02:00", End: "02:00", IPs: []string{"203.0.113.10"}},
+		{Start: "02:00", End: "04:00", Days: []string{"someday"}, IPs: []string{"203.0.113.10"}},
+		{Start: "02:00", End: "04:00", Timezone: "Nowhere/City", IPs: []string{"203.0.113.10"}},
+		{Start: "02:00", End: "04:00"},
+	}
+	for _, schedule := range invalid {
+		if err := validateSchedules([]ScheduleConfig{schedule}); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("expected ErrInvalidSchedule for %+v, got %v", schedule, err)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrSchemaViolation is returned when the config file does not match the configuration schema
+var ErrSchemaViolation = errors.New("config does not match schema")
+
+// extensionKeyPattern はトップレベルで無視される拡張キー（YAMLアンカーの置き場所など）
+const extensionKeyPattern = "^x-"
+
+// Schema は設定ファイルのJSON Schemaを返す
+// スキーマは設定の構造体から生成されるため、常にLoadConfigが受け付けるキーと一致する
+func Schema() ([]byte, error) {
+	data, err := json.MarshalIndent(configSchema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// configSchema はrawConfigからJSON Schemaを生成する
+func configSchema() map[string]any {
+	return documentSchema(reflect.TypeOf(rawConfig{}), "cloudflare-gslb configuration")
+}
+
+// documentSchema は設定ファイルとして読み込む構造体tのJSON Schemaを生成する
+func documentSchema(t reflect.Type, title string) map[string]any {
+	defs := make(map[string]any)
+	root := structSchema(t, defs)
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = title
+	root["patternProperties"] = map[string]any{extensionKeyPattern: map[string]any{}}
+	// JSONの設定ファイルからエディタがスキーマを参照できるようにする
+	root["properties"].(map[string]any)["$schema"] = map[string]any{"type": "string"}
+	root["$defs"] = defs
+	return root
+}
+
+// typeSchema はGoの型に対応するスキーマを返す
+// 構造体は$defsに登録して参照する
+func typeSchema(t reflect.Type, defs map[string]any) map[string]any {
+	switch t {
+	case reflect.TypeOf(Seconds(0)), reflect.TypeOf(Milliseconds(0)):
+		// 数値または"30s"のような時間表記
+		return map[string]any{"type": []any{"number", "string"}}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem(), defs)
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem(), defs)}
+	case reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			defs[t.Name()] = true // placeholder for recursive types
+			defs[t.Name()] = structSchema(t, defs)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	default:
+		return map[string]any{}
+	}
+}
+
+func structSchema(t reflect.Type, defs map[string]any) map[string]any {
+	properties := make(map[string]any)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = typeSchema(field.Type, defs)
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// docKind は設定ファイルの値の種類
+type docKind int
+
+const (
+	docNull docKind = iota
+	docString
+	docInteger
+	docNumber
+	docBool
+	docObject
+	docArray
+	// docScalar はYAMLのスカラー（型の誤りはYAMLのデコーダが行番号付きで報告する）
+	docScalar
+)
+
+// docNode はファイル形式によらない設定ファイルの値と、その行番号（不明な場合は0）
+type docNode struct {
+	kind  docKind
+	line  int
+	keys  []docEntry
+	items []*docNode
+}
+
+// is はノードがkindの値として読めるかどうかを返す
+func (n *docNode) is(kind docKind) bool {
+	return n.kind == kind || n.kind == docScalar
+}
+
+type docEntry struct {
+	key   string
+	line  int
+	value *docNode
+}
+
+type schemaError struct {
+	line    int
+	path    string
+	message string
+}
+
+func (e schemaError) String() string {
+	if e.line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", e.line, e.path, e.message)
+	}
+	return fmt.Sprintf("%s: %s", e.path, e.message)
+}
+
+// validateSchema は設定ファイルの内容をschemaと照合し、すべての違反をまとめて返す
+// 不明なキー（タイプミスなど）と型の誤りを検出する
+func validateSchema(schema map[string]any, ext fileExt, data []byte) error {
+	var doc *docNode
+	var err error
+	switch ext {
+	case extYAML, extYML:
+		doc, err = yamlDocument(data)
+	case extTOML:
+		doc, err = tomlDocument(data)
+	default:
+		doc, err = jsonDocument(data)
+	}
+	if err != nil {
+		// Syntax errors are reported by the decoder
+		return nil
+	}
+
+	v := schemaValidator{defs: schema["$defs"].(map[string]any)}
+	v.validate(doc, schema, "$")
+	if len(v.errors) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(v.errors, func(i, j int) bool { return v.errors[i].line < v.errors[j].line })
+	messages := make([]string, 0, len(v.errors))
+	for _, e := range v.errors {
+		messages = append(messages, e.String())
+	}
+	return fmt.Errorf("%w:\n  %s", ErrSchemaViolation, strings.Join(messages, "\n  "))
+}
+
+// schemaValidator はconfigSchemaが生成するキーワード（type、properties、
+// additionalProperties、patternProperties、items、$ref）を検証する
+type schemaValidator struct {
+	defs   map[string]any
+	errors []schemaError
+}
+
+func (v *schemaValidator) fail(node *docNode, path, format string, args ...any) {
+	v.errors = append(v.errors, schemaError{line: node.line, path: path, message: fmt.Sprintf(format, args...)})
+}
+
+func (v *schemaValidator) validate(node *docNode, schema map[string]any, path string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		schema = v.defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+	}
+	// Every decoder leaves a null value at its zero value
+	if node.kind == docNull {
+		return
+	}
+
+	if types, ok := schema["type"].([]any); ok {
+		descriptions := make([]string, 0, len(types))
+		for _, t := range types {
+			if node.matches(t.(string)) {
+				return
+			}
+			descriptions = append(descriptions, typeDescriptions[t.(string)])
+		}
+		v.fail(node, path, "expected %s", strings.Join(descriptions, " or "))
+		return
+	}
+
+	switch schema["type"] {
+	case "object":
+		if node.kind != docObject {
+			v.fail(node, path, "expected an object")
+			return
+		}
+		v.validateObject(node, schema, path)
+	case "array":
+		if node.kind != docArray {
+			v.fail(node, path, "expected an array")
+			return
+		}
+		items, _ := schema["items"].(map[string]any)
+		for i, item := range node.items {
+			v.validate(item, items, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case "string", "integer", "number", "boolean":
+		if typ := schema["type"].(string); !node.matches(typ) {
+			v.fail(node, path, "expected %s", typeDescriptions[typ])
+		}
+	}
+}
+
+// typeDescriptions はスカラーの型のエラーメッセージでの表記
+var typeDescriptions = map[string]string{
+	"string":  "a string",
+	"integer": "an integer",
+	"number":  "a number",
+	"boolean": "true or false",
+}
+
+// matches はノードがスカラーの型typの値として読めるかどうかを返す
+func (n *docNode) matches(typ string) bool {
+	switch typ {
+	case "string":
+		return n.is(docString)
+	case "integer":
+		return n.is(docInteger)
+	case "number":
+		return n.is(docNumber) || n.is(docInteger)
+	case "boolean":
+		return n.is(docBool)
+	default:
+		return false
+	}
+}
+
+func (v *schemaValidator) validateObject(node *docNode, schema map[string]any, path string) {
+	properties, _ := schema["properties"].(map[string]any)
+	patterns, _ := schema["patternProperties"].(map[string]any)
+	for _, entry := range node.keys {
+		entryPath := path + "." + entry.key
+		if property, ok := properties[entry.key].(map[string]any); ok {
+			v.validate(entry.value, property, entryPath)
+			continue
+		}
+		if matchesPattern(entry.key, patterns) {
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case map[string]any:
+			v.validate(entry.value, additional, entryPath)
+		case bool:
+			if !additional {
+				keyNode := &docNode{line: entry.line}
+				v.fail(keyNode, entryPath, "unknown field%s", suggestField(entry.key, properties))
+			}
+		}
+	}
+}
+
+func matchesPattern(key string, patterns map[string]any) bool {
+	for pattern := range patterns {
+		if regexp.MustCompile(pattern).MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestField は不明なキーに近い既知のキーがあれば候補として返す
+func suggestField(key string, properties map[string]any) string {
+	best, bestDistance := "", 3
+	for name := range properties {
+		d := editDistance(key, name)
+		if d < bestDistance || (best != "" && d == bestDistance && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// editDistance はレーベンシュタイン距離を返す
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(b)]
+}
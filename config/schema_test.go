@@ -0,0 +1,112 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateSchema_UnknownFieldJSON(t *testing.T) {
+	data := []byte(`{
+  "cloudflare_api_token": "token",
+  "origins": [
+    {
+      "name": "www.example.com",
+      "recod_type": "A"
+    }
+  ]
+}`)
+	err := validateSchema(configSchema(), extJSON, data)
+	if !errors.Is(err, ErrSchemaViolation) {
+		t.Fatalf("Expected ErrSchemaViolation, got %v", err)
+	}
+	want := `line 6: $.origins[0].recod_type: unknown field (did you mean "record_type"?)`
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected %q in %v", want, err)
+	}
+}
+
+func TestValidateSchema_YAML(t *testing.T) {
+	data := []byte(`cloudflare_api_token: token
+check_interval_seconds: 60
+x-defaults: &defaults
+  type: https
+  endpoint: /health
+origins:
+  - name: www.example.com
+    record_type: A
+    health_check:
+      <<: *defaults
+      timeot: 5
+    priority_levels: {priority: 1}
+`)
+	err := validateSchema(configSchema(), extYAML, data)
+	if !errors.Is(err, ErrSchemaViolation) {
+		t.Fatalf("Expected ErrSchemaViolation, got %v", err)
+	}
+	for _, want := range []string{
+		`line 11: $.origins[0].health_check.timeot: unknown field (did you mean "timeout"?)`,
+		`line 12: $.origins[0].priority_levels: expected an array`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %v", want, err)
+		}
+	}
+}
+
+func TestValidateSchema_Types(t *testing.T) {
+	data := []byte(`{
+  "check_interval_seconds": true,
+  "record_tags": "yes",
+  "origins": [{"proxied": null, "weights": {"192.0.2.1": 1.5}}]
+}`)
+	err := validateSchema(configSchema(), extJSON, data)
+	if !errors.Is(err, ErrSchemaViolation) {
+		t.Fatalf("Expected ErrSchemaViolation, got %v", err)
+	}
+	for _, want := range []string{
+		"line 2: $.check_interval_seconds: expected a number or a string",
+		"line 3: $.record_tags: expected true or false",
+		`line 4: $.origins[0].weights.192.0.2.1: expected an integer`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "proxied") {
+		t.Errorf("Expected null to be accepted, got %v", err)
+	}
+}
+
+func TestValidateSchema_Examples(t *testing.T) {
+	for _, name := range []string{"config.json.example", "config.yaml.example"} {
+		data, err := os.ReadFile(filepath.Join("..", name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		ext := extJSON
+		if strings.Contains(name, ".yaml") {
+			ext = extYAML
+		}
+		if err := validateSchema(configSchema(), ext, data); err != nil {
+			t.Errorf("%s does not match the schema: %v", name, err)
+		}
+	}
+}
+
+func TestSchema_MatchesPublishedFile(t *testing.T) {
+	generated, err := Schema()
+	if err != nil {
+		t.Fatalf("Schema() error = %v", err)
+	}
+	published, err := os.ReadFile(filepath.Join("..", "config.schema.json"))
+	if err != nil {
+		t.Fatalf("Failed to read config.schema.json: %v", err)
+	}
+	if !bytes.Equal(generated, published) {
+		t.Error("config.schema.json is out of date; regenerate it with: go run ./cmd/oneshot -schema > config.schema.json")
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/awssecrets"
+)
+
+// secretResolveTimeout は設定読み込み時のシークレット参照の解決全体にかける時間の上限
+const secretResolveTimeout = 30 * time.Second
+
+// secretResolver はaws-sm://やssm://の参照を値に解決するインターフェース
+type secretResolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// newSecretResolver は参照を含む設定を読み込むときに一度だけ呼ばれる（テストで差し替える）
+var newSecretResolver = func(ctx context.Context) (secretResolver, error) {
+	return awssecrets.NewResolver(ctx)
+}
+
+// resolveSecretRefs は設定中の文字列のうちaws-sm://またはssm://で始まるものを
+// AWS Secrets ManagerまたはSSM Parameter Storeの値で置き換える
+// 参照がなければAWSの設定は読み込まない
+func resolveSecretRefs(tmpConfig *rawConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretResolveTimeout)
+	defer cancel()
+
+	var resolver secretResolver
+	return replaceStrings(reflect.ValueOf(tmpConfig).Elem(), func(value string) (string, error) {
+		if !awssecrets.IsReference(value) {
+			return value, nil
+		}
+		if resolver == nil {
+			r, err := newSecretResolver(ctx)
+			if err != nil {
+				return "", err
+			}
+			resolver = r
+		}
+		resolved, err := resolver.Resolve(ctx, value)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", value, err)
+		}
+		return resolved, nil
+	})
+}
+
+// replaceStrings はv以下のすべての文字列（構造体の公開フィールド、スライス、マップの値）をreplaceの結果で置き換える
+func replaceStrings(v reflect.Value, replace func(string) (string, error)) error {
+	switch v.Kind() {
+	case reflect.String:
+		replaced, err := replace(v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(replaced)
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return replaceStrings(v.Elem(), replace)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := replaceStrings(v.Field(i), replace); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := replaceStrings(v.Index(i), replace); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			if err := replaceStrings(elem, replace); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, elem)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/servertls"
+)
+
+// ServerTLSConfig はHTTPSで待ち受けるAPIの証明書の設定を表す構造体
+type ServerTLSConfig struct {
+	CertFile     string `json:"cert_file" yaml:"cert_file"`                               // サーバー証明書（PEM、更新されると自動で読み直す）
+	KeyFile      string `json:"key_file" yaml:"key_file"`                                 // サーバー証明書の秘密鍵（PEM）
+	ClientCAFile string `json:"client_ca_file,omitempty" yaml:"client_ca_file,omitempty"` // クライアント証明書を検証するCA証明書（PEM、指定するとクライアント証明書を要求する）
+}
+
+// Enabled はTLSで待ち受けるかどうかを返す
+func (c *ServerTLSConfig) Enabled() bool {
+	return c != nil
+}
+
+// MutualTLS はクライアント証明書を要求するかどうかを返す
+func (c *ServerTLSConfig) MutualTLS() bool {
+	return c != nil && c.ClientCAFile != ""
+}
+
+// ServerConfig は証明書を読み込む設定を返す
+func (c *ServerTLSConfig) ServerConfig() servertls.Config {
+	return servertls.Config{CertFile: c.CertFile, KeyFile: c.KeyFile, ClientCAFile: c.ClientCAFile}
+}
+
+func validateServerTLS(c *ServerTLSConfig) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.CertFile == "" || c.KeyFile == "" {
+		return errors.New("tls cert_file and key_file are required")
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrInvalidSlackActions is returned when slack_actions has no signing secret or a listen address that is not host:port
+var ErrInvalidSlackActions = errors.New("invalid slack_actions config")
+
+// DefaultSlackActionsListen はlistenを省略したときの待ち受けアドレス
+const DefaultSlackActionsListen = ":8081"
+
+// SlackActionsConfig はSlackのメッセージのボタンからオリジンの自動切替を止める（acknowledge & hold）コールバックの設定を表す構造体
+type SlackActionsConfig struct {
+	Listen        string   `json:"listen,omitempty" yaml:"listen,omitempty"`               // 待ち受けアドレス（省略時は ":8081"）
+	SigningSecret string   `json:"signing_secret" yaml:"signing_secret"`                   // リクエストの署名を検証するSlackアプリのSigning Secret
+	AllowedUsers  []string `json:"allowed_users,omitempty" yaml:"allowed_users,omitempty"` // ボタンを操作できるSlackのユーザーID（省略時はメッセージを見られる全員）
+}
+
+// Enabled はSlackのボタンが有効かどうかを返す
+func (c *SlackActionsConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveListen は待ち受けアドレスを返す
+func (c *SlackActionsConfig) EffectiveListen() string {
+	if c == nil || c.Listen == "" {
+		return DefaultSlackActionsListen
+	}
+	return c.Listen
+}
+
+func validateSlackActions(c *SlackActionsConfig) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.SigningSecret == "" {
+		return fmt.Errorf("%w: signing_secret is required", ErrInvalidSlackActions)
+	}
+	if _, _, err := net.SplitHostPort(c.EffectiveListen()); err != nil {
+		return fmt.Errorf("%w: listen %q: %v", ErrInvalidSlackActions, c.Listen, err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidSpectrum is returned when spectrum has no app_id, an invalid port or an unknown protocol
+var ErrInvalidSpectrum = errors.New("invalid spectrum config")
+
+// Spectrumアプリケーションのオリジンのプロトコル
+const (
+	SpectrumProtocolTCP = "tcp"
+	SpectrumProtocolUDP = "udp"
+)
+
+// SpectrumConfig はDNSレコードと合わせてSpectrumアプリケーションのオリジンを切り替える設定を表す構造体
+type SpectrumConfig struct {
+	AppID    string `json:"app_id" yaml:"app_id"`                         // SpectrumアプリケーションのID
+	Port     int    `json:"port" yaml:"port"`                             // オリジン側のポート
+	Protocol string `json:"protocol,omitempty" yaml:"protocol,omitempty"` // "tcp"（デフォルト）または "udp"
+}
+
+// Enabled はSpectrumアプリケーションの切り替えが有効かどうかを返す
+func (c *SpectrumConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveProtocol はオリジンのプロトコルを返す（省略時は "tcp"）
+func (c *SpectrumConfig) EffectiveProtocol() string {
+	if c == nil || c.Protocol == "" {
+		return SpectrumProtocolTCP
+	}
+	return c.Protocol
+}
+
+func validateSpectrum(c *SpectrumConfig) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.AppID == "" {
+		return fmt.Errorf("%w: app_id is required", ErrInvalidSpectrum)
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("%w: port %d is out of range", ErrInvalidSpectrum, c.Port)
+	}
+	switch c.EffectiveProtocol() {
+	case SpectrumProtocolTCP, SpectrumProtocolUDP:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown protocol %q", ErrInvalidSpectrum, c.Protocol)
+	}
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidStateStore is returned when state_store has an unknown type or misses required settings
+var ErrInvalidStateStore = errors.New("invalid state_store")
+
+// StateStoreTypeWorkersKV はWorkers KVに状態を保存するストアの種類
+const StateStoreTypeWorkersKV = "workers_kv"
+
+// DefaultStateKeyPrefix は状態を保存するキーの既定のプレフィックス
+const DefaultStateKeyPrefix = "gslb:"
+
+// StateStoreConfig は複数のインスタンスでオリジンの状態を共有・復元するためのストアの設定を表す構造体
+type StateStoreConfig struct {
+	Type        string `json:"type" yaml:"type"`                                 // "workers_kv"
+	AccountID   string `json:"account_id" yaml:"account_id"`                     // KV名前空間を持つアカウントのID
+	NamespaceID string `json:"namespace_id" yaml:"namespace_id"`                 // KV名前空間のID
+	KeyPrefix   string `json:"key_prefix,omitempty" yaml:"key_prefix,omitempty"` // キーのプレフィックス（省略時は "gslb:"）
+}
+
+// Enabled は状態の共有が有効かどうかを返す
+func (c *StateStoreConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveKeyPrefix はキーのプレフィックスを返す
+func (c *StateStoreConfig) EffectiveKeyPrefix() string {
+	if c == nil || c.KeyPrefix == "" {
+		return DefaultStateKeyPrefix
+	}
+	return c.KeyPrefix
+}
+
+func validateStateStore(c *StateStoreConfig) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.Type != StateStoreTypeWorkersKV {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidStateStore, c.Type)
+	}
+	if c.AccountID == "" || c.NamespaceID == "" {
+		return fmt.Errorf("%w: account_id and namespace_id are required", ErrInvalidStateStore)
+	}
+	return nil
+}
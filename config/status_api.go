@@ -0,0 +1,84 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+)
+
+// ErrInvalidStatusAPI is returned when status_api has a listen address that is neither host:port nor a socket, an invalid socket or an incomplete tls
+var ErrInvalidStatusAPI = errors.New("invalid status_api config")
+
+// DefaultStatusAPIListen はlistenを省略したときの待ち受けアドレス
+const DefaultStatusAPIListen = "127.0.0.1:8080"
+
+// StatusAPIConfig は各オリジンの現在の状態をJSONで返す読み取り専用のHTTP APIの設定を表す構造体
+type StatusAPIConfig struct {
+	Listen     string           `json:"listen,omitempty" yaml:"listen,omitempty"`           // 待ち受けアドレス（省略時は "127.0.0.1:8080"、"unix:/run/gslb/status.sock" でUnixドメインソケット）
+	Socket     string           `json:"socket,omitempty" yaml:"socket,omitempty"`           // listenに加えて待ち受けるUnixドメインソケットのパス
+	SocketMode string           `json:"socket_mode,omitempty" yaml:"socket_mode,omitempty"` // ソケットのパーミッション（8進数、省略時は "0600"）
+	Token      string           `json:"token,omitempty" yaml:"token,omitempty"`             // /api/v1/ のエンドポイントでAuthorization: Bearerとして要求するトークン（省略時は認証なし、/healthzと/readyzは対象外）
+	TLS        *ServerTLSConfig `json:"tls,omitempty" yaml:"tls,omitempty"`                 // HTTPSで待ち受ける場合の証明書（デバッグ用エンドポイントの別の待ち受けにも使う）
+	Debug      *DebugConfig     `json:"debug,omitempty" yaml:"debug,omitempty"`             // pprofとランタイムの統計を返すデバッグ用エンドポイント
+}
+
+// DebugConfig はプロファイルを取得するためのデバッグ用エンドポイントの設定を表す構造体
+type DebugConfig struct {
+	Listen string `json:"listen,omitempty" yaml:"listen,omitempty"` // 別のアドレスで待ち受ける場合の待ち受けアドレス（省略時はステータスAPIと同じ）
+	Token  string `json:"token,omitempty" yaml:"token,omitempty"`   // Authorization: Bearerで要求するトークン（省略時は認証なし）
+}
+
+// Enabled はステータスAPIが有効かどうかを返す
+func (c *StatusAPIConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveListen は待ち受けアドレスを返す
+func (c *StatusAPIConfig) EffectiveListen() string {
+	if c == nil || c.Listen == "" {
+		return DefaultStatusAPIListen
+	}
+	return c.Listen
+}
+
+// Addresses はlistenとsocketの待ち受けアドレスを返す
+func (c *StatusAPIConfig) Addresses() []string {
+	return listenAddresses(c.EffectiveListen(), c.Socket)
+}
+
+// EffectiveSocketMode はソケットのパーミッションを返す
+func (c *StatusAPIConfig) EffectiveSocketMode() fs.FileMode {
+	return socketMode(c.SocketMode)
+}
+
+// Enabled はデバッグ用エンドポイントが有効かどうかを返す
+func (c *DebugConfig) Enabled() bool {
+	return c != nil
+}
+
+// SeparateListener はデバッグ用エンドポイントをステータスAPIとは別のアドレスで待ち受けるかどうかを返す
+func (c *DebugConfig) SeparateListener() bool {
+	return c != nil && c.Listen != ""
+}
+
+func validateStatusAPI(c *StatusAPIConfig) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if err := validateListen(c.EffectiveListen(), c.Socket, c.SocketMode); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidStatusAPI, err)
+	}
+	if err := validateServerTLS(c.TLS); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidStatusAPI, err)
+	}
+	if c.Debug.SeparateListener() {
+		if _, _, err := net.SplitHostPort(c.Debug.Listen); err != nil {
+			return fmt.Errorf("%w: debug.listen %q: %v", ErrInvalidStatusAPI, c.Debug.Listen, err)
+		}
+		if c.Debug.Listen == c.EffectiveListen() {
+			return fmt.Errorf("%w: debug.listen must differ from listen, omit it to share the listener", ErrInvalidStatusAPI)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ErrInvalidSummary is returned when summary has an invalid interval, time of day or timezone
+var ErrInvalidSummary = errors.New("invalid summary config")
+
+// DefaultSummaryInterval はinterval_secondsを省略したときのサマリーの送信間隔（1日）
+const DefaultSummaryInterval = Seconds(24 * time.Hour)
+
+// MinSummaryInterval はサマリーの送信間隔の下限
+const MinSummaryInterval = Seconds(time.Minute)
+
+// SummaryConfig は監視中のオリジン数や期間中のフェイルオーバー回数を定期的に通知する設定を表す構造体
+type SummaryConfig struct {
+	IntervalSeconds Seconds `json:"interval_seconds,omitempty" yaml:"interval_seconds,omitempty"` // 送信間隔（省略時は1日）
+	At              string  `json:"at,omitempty" yaml:"at,omitempty"`                             // 送信する時刻（"HH:MM"、省略時は起動から送信間隔ごと）
+	Timezone        string  `json:"timezone,omitempty" yaml:"timezone,omitempty"`                 // atのタイムゾーン（省略時はUTC）
+}
+
+// Enabled はサマリーの送信が有効かどうかを返す
+func (c *SummaryConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveInterval は送信間隔を返す
+func (c *SummaryConfig) EffectiveInterval() Seconds {
+	if c == nil || c.IntervalSeconds == 0 {
+		return DefaultSummaryInterval
+	}
+	return c.IntervalSeconds
+}
+
+// NextAfter は時刻tより後の次の送信時刻を返す。atを指定した場合はatから送信間隔ごとの時刻になる
+func (c *SummaryConfig) NextAfter(t time.Time) time.Time {
+	interval := c.EffectiveInterval().Duration()
+	if c == nil || c.At == "" {
+		return t.Add(interval)
+	}
+	loc := time.UTC
+	if c.Timezone != "" {
+		if l, err := time.LoadLocation(c.Timezone); err == nil {
+			loc = l
+		}
+	}
+	clock, err := time.Parse("15:04", c.At)
+	if err != nil {
+		return t.Add(interval)
+	}
+
+	local := t.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+	if next.After(t) {
+		// Go back to the earliest time after t on the same schedule
+		next = next.Add(-next.Sub(t) / interval * interval)
+		if !next.After(t) {
+			next = next.Add(interval)
+		}
+		return next
+	}
+	return next.Add((t.Sub(next)/interval + 1) * interval)
+}
+
+func validateSummary(c *SummaryConfig, notifications []NotificationConfig) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if !slices.ContainsFunc(notifications, NotificationConfig.SupportsSummary) {
+		return fmt.Errorf("%w: a notification other than opsgenie is required to send summaries", ErrInvalidSummary)
+	}
+	if c.IntervalSeconds != 0 && c.IntervalSeconds < MinSummaryInterval {
+		return fmt.Errorf("%w: interval_seconds must be at least %s", ErrInvalidSummary, MinSummaryInterval.Duration())
+	}
+	if c.At != "" {
+		if _, err := time.Parse("15:04", c.At); err != nil {
+			return fmt.Errorf("%w: at %q must be HH:MM", ErrInvalidSummary, c.At)
+		}
+	}
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			return fmt.Errorf("%w: timezone %q: %v", ErrInvalidSummary, c.Timezone, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummaryConfig_NextAfter(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("No time zone data: %v", err)
+	}
+	tests := []struct {
+		name    string
+		summary *SummaryConfig
+		after   time.Time
+		want    time.Time
+	}{
+		{
+			name:    "interval from now",
+			summary: &SummaryConfig{},
+			after:   time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC),
+			want:    time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:    "later today",
+			summary: &SummaryConfig{At: "09:00"},
+			after:   time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC),
+			want:    time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "tomorrow",
+			summary: &SummaryConfig{At: "09:00"},
+			after:   time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+			want:    time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "every 6 hours from the time of day",
+			summary: &SummaryConfig{At: "09:00", IntervalSeconds: Seconds(6 * time.Hour)},
+			after:   time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+			want:    time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "every 6 hours after the time of day",
+			summary: &SummaryConfig{At: "09:00", IntervalSeconds: Seconds(6 * time.Hour)},
+			after:   time.Date(2024, 1, 1, 16, 0, 0, 0, time.UTC),
+			want:    time.Date(2024, 1, 1, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "time zone",
+			summary: &SummaryConfig{At: "09:00", Timezone: "Asia/Tokyo"},
+			after:   time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+			want:    time.Date(2024, 1, 2, 9, 0, 0, 0, tokyo),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.summary.NextAfter(tt.after); !got.Equal(tt.want) {
+				t.Errorf("NextAfter() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
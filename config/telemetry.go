@@ -0,0 +1,189 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"time"
+)
+
+var (
+	// ErrInvalidTracing is returned when tracing has an endpoint that is not an http(s) URL
+	ErrInvalidTracing = errors.New("invalid tracing config")
+	// ErrInvalidMetrics is returned when metrics has an unknown exporter, an invalid endpoint or address, or a negative interval
+	ErrInvalidMetrics = errors.New("invalid metrics config")
+)
+
+// EnvOTLPEndpoint はendpointを省略したときに使うOTLPの標準の環境変数
+const EnvOTLPEndpoint = "OTEL_EXPORTER_OTLP_ENDPOINT"
+
+// DefaultOTLPEndpoint はendpointも環境変数もない場合のOTLP/HTTPの送信先
+const DefaultOTLPEndpoint = "http://localhost:4318"
+
+// DefaultOTLPServiceName はservice_nameを省略したときのサービス名
+const DefaultOTLPServiceName = "cloudflare-gslb"
+
+// DefaultMetricsInterval はinterval_secondsを省略したときのメトリクスの送信間隔
+const DefaultMetricsInterval = Seconds(60 * time.Second)
+
+// メトリクスの送信方式
+const (
+	MetricsExporterOTLP   = "otlp"   // OTLP/HTTPでコレクタへ送信する
+	MetricsExporterStatsD = "statsd" // DogStatsD形式のタグ付きでStatsDへ送信する
+)
+
+// EnvDDAgentHost はaddressを省略したときにStatsDの送信先ホストとして使うDatadogの標準の環境変数
+const EnvDDAgentHost = "DD_AGENT_HOST"
+
+// DefaultStatsDAddress はaddressも環境変数もない場合のStatsDの送信先
+const DefaultStatsDAddress = "127.0.0.1:8125"
+
+// TracingConfig はチェック・DNS変更・通知の処理をOpenTelemetryのトレースとして送信する設定を表す構造体
+type TracingConfig struct {
+	Endpoint    string            `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`         // OTLP/HTTPコレクタのURL（/v1/tracesは付けない）
+	ServiceName string            `json:"service_name,omitempty" yaml:"service_name,omitempty"` // service.nameとして送るサービス名（省略時は "cloudflare-gslb"）
+	Headers     map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`           // 送信時に付与するHTTPヘッダー（認証など）
+}
+
+// Enabled はトレースの送信が有効かどうかを返す
+func (c *TracingConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveEndpoint は送信先のURLを返す
+// 省略時はOTEL_EXPORTER_OTLP_ENDPOINT、それもなければ "http://localhost:4318"
+func (c *TracingConfig) EffectiveEndpoint() string {
+	if c == nil {
+		return otlpEndpoint("")
+	}
+	return otlpEndpoint(c.Endpoint)
+}
+
+// EffectiveServiceName はservice.nameとして送るサービス名を返す
+func (c *TracingConfig) EffectiveServiceName() string {
+	if c == nil {
+		return otlpServiceName("")
+	}
+	return otlpServiceName(c.ServiceName)
+}
+
+// MetricsConfig はメトリクスをOTLPのコレクタまたはStatsDへ送信する設定を表す構造体
+type MetricsConfig struct {
+	Exporter        string            `json:"exporter,omitempty" yaml:"exporter,omitempty"`                 // 送信方式（"otlp" または "statsd"、省略時は "otlp"）
+	Endpoint        string            `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`                 // OTLP/HTTPコレクタのURL（/v1/metricsは付けない）
+	ServiceName     string            `json:"service_name,omitempty" yaml:"service_name,omitempty"`         // service.nameとして送るサービス名（省略時は "cloudflare-gslb"）
+	Headers         map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`                   // 送信時に付与するHTTPヘッダー（認証など）
+	IntervalSeconds Seconds           `json:"interval_seconds,omitempty" yaml:"interval_seconds,omitempty"` // OTLPの送信間隔（省略時は60秒）
+	Address         string            `json:"address,omitempty" yaml:"address,omitempty"`                   // StatsDの送信先（host:port）
+	Prefix          string            `json:"prefix,omitempty" yaml:"prefix,omitempty"`                     // StatsDのメトリクス名に付けるプレフィックス
+	Tags            []string          `json:"tags,omitempty" yaml:"tags,omitempty"`                         // StatsDの全メトリクスに付けるタグ（"env:prod" など）
+}
+
+// Enabled はメトリクスの送信が有効かどうかを返す
+func (c *MetricsConfig) Enabled() bool {
+	return c != nil
+}
+
+// EffectiveExporter は送信方式を返す
+func (c *MetricsConfig) EffectiveExporter() string {
+	if c == nil || c.Exporter == "" {
+		return MetricsExporterOTLP
+	}
+	return c.Exporter
+}
+
+// EffectiveAddress はStatsDの送信先を返す
+// 省略時はDD_AGENT_HOSTの8125番ポート、それもなければ "127.0.0.1:8125"
+func (c *MetricsConfig) EffectiveAddress() string {
+	if c != nil && c.Address != "" {
+		return c.Address
+	}
+	if host := os.Getenv(EnvDDAgentHost); host != "" {
+		return net.JoinHostPort(host, "8125")
+	}
+	return DefaultStatsDAddress
+}
+
+// EffectiveEndpoint は送信先のURLを返す（省略時の扱いはTracingConfigと同じ）
+func (c *MetricsConfig) EffectiveEndpoint() string {
+	if c == nil {
+		return otlpEndpoint("")
+	}
+	return otlpEndpoint(c.Endpoint)
+}
+
+// EffectiveServiceName はservice.nameとして送るサービス名を返す
+func (c *MetricsConfig) EffectiveServiceName() string {
+	if c == nil {
+		return otlpServiceName("")
+	}
+	return otlpServiceName(c.ServiceName)
+}
+
+// EffectiveInterval はメトリクスの送信間隔を返す
+func (c *MetricsConfig) EffectiveInterval() Seconds {
+	if c == nil || c.IntervalSeconds == 0 {
+		return DefaultMetricsInterval
+	}
+	return c.IntervalSeconds
+}
+
+func otlpEndpoint(endpoint string) string {
+	if endpoint != "" {
+		return endpoint
+	}
+	if endpoint := os.Getenv(EnvOTLPEndpoint); endpoint != "" {
+		return endpoint
+	}
+	return DefaultOTLPEndpoint
+}
+
+func otlpServiceName(name string) string {
+	if name == "" {
+		return DefaultOTLPServiceName
+	}
+	return name
+}
+
+// validateOTLPEndpoint はendpointがhttp(s)のURLかどうかを確認する
+func validateOTLPEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("endpoint %q must be an http:// or https:// URL", endpoint)
+	}
+	return nil
+}
+
+func validateTracing(c *TracingConfig) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if err := validateOTLPEndpoint(c.EffectiveEndpoint()); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTracing, err)
+	}
+	return nil
+}
+
+func validateMetrics(c *MetricsConfig) error {
+	if !c.Enabled() {
+		return nil
+	}
+	switch c.EffectiveExporter() {
+	case MetricsExporterOTLP:
+		if err := validateOTLPEndpoint(c.EffectiveEndpoint()); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidMetrics, err)
+		}
+	case MetricsExporterStatsD:
+		if _, _, err := net.SplitHostPort(c.EffectiveAddress()); err != nil {
+			return fmt.Errorf("%w: address %q: %v", ErrInvalidMetrics, c.EffectiveAddress(), err)
+		}
+	default:
+		return fmt.Errorf("%w: unknown exporter %q", ErrInvalidMetrics, c.Exporter)
+	}
+	if c.IntervalSeconds < 0 {
+		return fmt.Errorf("%w: interval_seconds must not be negative", ErrInvalidMetrics)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrTemplate is returned when a .tmpl config or its values file cannot be rendered
+var ErrTemplate = errors.New("failed to render config template")
+
+// EnvConfigValues はテンプレートに渡す値のファイル（YAMLまたはJSON）を指定する環境変数
+const EnvConfigValues = "GSLB_CONFIG_VALUES"
+
+// templateExt はtext/templateで展開してから読み込む設定ファイルの拡張子（config.yaml.tmplなど）
+const templateExt = ".tmpl"
+
+// templateName は名前が.tmplで終わる場合に、形式の判定に使う.tmplを除いた名前を返す
+func templateName(name string) (string, bool) {
+	if !strings.EqualFold(filepath.Ext(name), templateExt) {
+		return name, false
+	}
+	return name[:len(name)-len(templateExt)], true
+}
+
+// renderTemplate は設定ファイルをtext/templateで展開する
+// 値のファイルの内容は . として参照できる。タイプミスに気付けるよう、存在しないキーの参照はエラーにする
+// （省略できる値は index . "key" で参照する）
+func renderTemplate(name string, data []byte, lookup func(string) (string, bool)) ([]byte, error) {
+	values, err := loadTemplateValues(lookup)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(filepath.Base(name)).
+		Option("missingkey=error").
+		Funcs(templateFuncs(lookup)).
+		Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTemplate, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, values); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTemplate, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func loadTemplateValues(lookup func(string) (string, bool)) (map[string]any, error) {
+	values := make(map[string]any)
+	path, ok := lookup(EnvConfigValues)
+	if !ok || path == "" {
+		return values, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTemplate, err)
+	}
+	// YAML is a superset of JSON, so one decoder reads both
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("%w: values file %s: %w", ErrTemplate, path, err)
+	}
+	if values == nil {
+		values = make(map[string]any)
+	}
+	return values, nil
+}
+
+// templateFuncs はテンプレートで使える関数
+//   - env "NAME": 環境変数の値（未設定の場合は空文字列）
+//   - default "x" .value: 値が空の場合に"x"を使う
+//   - toJSON .value: JSONに変換する（JSONにもYAMLのフロー形式にも埋め込める）
+func templateFuncs(lookup func(string) (string, bool)) template.FuncMap {
+	return template.FuncMap{
+		"env": func(name string) string {
+			value, _ := lookup(name)
+			return value
+		},
+		"default": func(fallback, value any) any {
+			if value == nil || value == "" {
+				return fallback
+			}
+			return value
+		},
+		"toJSON": func(value any) (string, error) {
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+	}
+}
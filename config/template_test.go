@@ -0,0 +1,105 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+const templateConfig = `
+cloudflare_api_token: {{ env "TEMPLATE_TEST_TOKEN" }}
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+origins:
+{{- range .sites }}
+  - name: {{ .name }}.example.com
+    record_type: A
+    health_check: {type: {{ index . "check" | default "icmp" }}, endpoint: /health}
+    priority_levels:
+      - priority: 100
+        ips: {{ toJSON .ips }}
+      - priority: 0
+        ips: {{ toJSON $.backup }}
+{{- end }}
+`
+
+func TestLoadConfig_Template(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml.tmpl")
+	writeConfigFile(t, path, templateConfig)
+	values := filepath.Join(dir, "values.yaml")
+	writeConfigFile(t, values, `
+backup: ["198.51.100.1"]
+sites:
+  - name: www
+    ips: ["192.0.2.1", "192.0.2.2"]
+  - name: api
+    check: https
+    ips: ["192.0.2.3"]
+`)
+	t.Setenv(EnvConfigValues, values)
+	t.Setenv("TEMPLATE_TEST_TOKEN", "token")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.CloudflareAPIToken != "token" {
+		t.Errorf("CloudflareAPIToken = %q", cfg.CloudflareAPIToken)
+	}
+	if len(cfg.Origins) != 2 {
+		t.Fatalf("Expected an origin per site, got %d", len(cfg.Origins))
+	}
+	www, api := cfg.Origins[0], cfg.Origins[1]
+	if www.Name != "www.example.com" || www.HealthCheck.Type != HealthCheckTypeICMP || len(www.PriorityLevels[0].IPs) != 2 {
+		t.Errorf("Unexpected origin %+v", www)
+	}
+	if api.HealthCheck.Type != HealthCheckTypeHTTPS || api.PriorityLevels[1].IPs[0] != "198.51.100.1" {
+		t.Errorf("Unexpected origin %+v", api)
+	}
+}
+
+func TestLoadConfig_TemplateErrors(t *testing.T) {
+	dir := t.TempDir()
+	values := filepath.Join(dir, "values.json")
+	writeConfigFile(t, values, `{"sites": []}`)
+
+	tests := map[string]struct {
+		template string
+		values   string
+	}{
+		"missing value":       {template: `{"cloudflare_api_token": "{{ .token }}"}`, values: values},
+		"syntax error":        {template: `{"cloudflare_api_token": "{{ .token "}`, values: values},
+		"missing values file": {template: `{}`, values: filepath.Join(dir, "missing.yaml")},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json.tmpl")
+			writeConfigFile(t, path, tt.template)
+			t.Setenv(EnvConfigValues, tt.values)
+			if _, err := LoadConfig(path); !errors.Is(err, ErrTemplate) {
+				t.Errorf("Expected ErrTemplate, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_NotATemplate(t *testing.T) {
+	// Without the .tmpl extension, braces are kept as they are
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, path, `
+cloudflare_api_token: "{{ not a template }}"
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+origins: []
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.CloudflareAPIToken != "{{ not a template }}" {
+		t.Errorf("CloudflareAPIToken = %q", cfg.CloudflareAPIToken)
+	}
+}
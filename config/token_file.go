@@ -0,0 +1,37 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	// ErrConflictingAPIToken is returned when both cloudflare_api_token and cloudflare_api_token_file are set
+	ErrConflictingAPIToken = errors.New("cloudflare_api_token and cloudflare_api_token_file cannot both be set")
+	// ErrEmptyAPITokenFile is returned when cloudflare_api_token_file contains no token
+	ErrEmptyAPITokenFile = errors.New("cloudflare_api_token_file is empty")
+)
+
+// loadAPITokenFile はcloudflare_api_token_fileからAPIトークンを読み込み、CloudflareAPITokenに設定する
+// 前後の空白と改行は取り除く
+func loadAPITokenFile(c *Config) error {
+	if c.APITokenFile == "" {
+		return nil
+	}
+	if c.CloudflareAPIToken != "" {
+		return ErrConflictingAPIToken
+	}
+
+	data, err := os.ReadFile(c.APITokenFile)
+	if err != nil {
+		return fmt.Errorf("failed to read cloudflare_api_token_file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return fmt.Errorf("%w: %s", ErrEmptyAPITokenFile, c.APITokenFile)
+	}
+	c.CloudflareAPIToken = token
+	return nil
+}
@@ -0,0 +1,590 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrParseTOML is returned when TOML parsing fails
+var ErrParseTOML = errors.New("failed to parse TOML")
+
+// tomlTableArray は [[name]] で定義されたテーブルの配列
+type tomlTableArray []any
+
+// decodeTOML はTOMLをJSONに変換してからvにデコードする
+// 設定の構造体はJSONタグのキー名をそのまま使用する
+// 日時型はサポートせず、エラーとする
+func decodeTOML(data []byte, v any) error {
+	doc, err := parseTOML(string(data))
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrParseTOML, err)
+	}
+	if err := json.Unmarshal(encoded, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrParseTOML, err)
+	}
+	return nil
+}
+
+// tomlParser はTOML v1.0の日時型以外を解析する
+type tomlParser struct {
+	src     string
+	pos     int
+	root    map[string]any
+	current map[string]any
+	// tables は [table] ヘッダで定義済みのテーブルのパス
+	tables map[string]bool
+}
+
+func parseTOML(src string) (map[string]any, error) {
+	if !utf8.ValidString(src) {
+		return nil, fmt.Errorf("%w: invalid UTF-8", ErrParseTOML)
+	}
+	root := make(map[string]any)
+	p := &tomlParser{src: src, root: root, current: root, tables: make(map[string]bool)}
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return root, nil
+		}
+		var err error
+		if p.peek() == '[' {
+			err = p.parseTableHeader()
+		} else {
+			err = p.parseKeyValue(p.current)
+		}
+		if err == nil {
+			err = p.expectLineEnd()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *tomlParser) errorf(format string, args ...any) error {
+	line := strings.Count(p.src[:min(p.pos, len(p.src))], "\n") + 1
+	return fmt.Errorf("%w: line %d: %s", ErrParseTOML, line, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(p.src[p.pos:], prefix)
+}
+
+// skipSpace はスペースとタブを読み飛ばす
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipComment は # から行末までを読み飛ばす
+func (p *tomlParser) skipComment() {
+	if p.peek() != '#' {
+		return
+	}
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+// skipBlank は空白、改行、コメントを読み飛ばす
+func (p *tomlParser) skipBlank() {
+	for {
+		p.skipSpace()
+		p.skipComment()
+		switch {
+		case p.hasPrefix("\n"):
+			p.pos++
+		case p.hasPrefix("\r\n"):
+			p.pos += 2
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) expectLineEnd() error {
+	p.skipSpace()
+	p.skipComment()
+	switch {
+	case p.eof():
+	case p.hasPrefix("\n"):
+		p.pos++
+	case p.hasPrefix("\r\n"):
+		p.pos += 2
+	default:
+		return p.errorf("unexpected %q after value", p.peek())
+	}
+	return nil
+}
+
+func (p *tomlParser) parseTableHeader() error {
+	isArray := p.hasPrefix("[[")
+	if isArray {
+		p.pos += 2
+	} else {
+		p.pos++
+	}
+	p.skipSpace()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	closing := "]"
+	if isArray {
+		closing = "]]"
+	}
+	if !p.hasPrefix(closing) {
+		return p.errorf("expected %s after table name", closing)
+	}
+	p.pos += len(closing)
+
+	parent, err := p.descend(p.root, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	path := strings.Join(keys, "\x00")
+
+	if isArray {
+		existing, ok := parent[last]
+		if !ok {
+			existing = tomlTableArray{}
+		}
+		array, ok := existing.(tomlTableArray)
+		if !ok {
+			return p.errorf("key %s is already defined as a non-array", strings.Join(keys, "."))
+		}
+		table := make(map[string]any)
+		parent[last] = append(array, table)
+		p.current = table
+		return nil
+	}
+
+	if p.tables[path] {
+		return p.errorf("table %s is defined twice", strings.Join(keys, "."))
+	}
+	p.tables[path] = true
+	table, err := p.descend(parent, []string{last})
+	if err != nil {
+		return err
+	}
+	p.current = table
+	return nil
+}
+
+// descend はkeysのテーブルをたどり、なければ作成する
+// テーブルの配列は最後の要素をたどる
+func (p *tomlParser) descend(table map[string]any, keys []string) (map[string]any, error) {
+	for _, key := range keys {
+		switch next := table[key].(type) {
+		case nil:
+			child := make(map[string]any)
+			table[key] = child
+			table = child
+		case map[string]any:
+			table = next
+		case tomlTableArray:
+			table = next[len(next)-1].(map[string]any)
+		default:
+			return nil, p.errorf("key %s is not a table", key)
+		}
+	}
+	return table, nil
+}
+
+func (p *tomlParser) parseKeyValue(table map[string]any) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	if p.peek() != '=' {
+		return p.errorf("expected = after key %s", strings.Join(keys, "."))
+	}
+	p.pos++
+	p.skipSpace()
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+
+	parent, err := p.descend(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, ok := parent[last]; ok {
+		return p.errorf("key %s is defined twice", strings.Join(keys, "."))
+	}
+	parent[last] = value
+	return nil
+}
+
+// parseKey は a."b".c のようなドット区切りのキーを解析し、後続の空白を読み飛ばす
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace()
+		var key string
+		var err error
+		switch c := p.peek(); {
+		case c == '"':
+			if p.hasPrefix(`"""`) {
+				return nil, p.errorf("multi-line strings cannot be keys")
+			}
+			key, err = p.parseBasicString()
+		case c == '\'':
+			if p.hasPrefix("'''") {
+				return nil, p.errorf("multi-line strings cannot be keys")
+			}
+			key, err = p.parseLiteralString()
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("expected a key")
+			}
+			key = p.src[start:p.pos]
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		p.skipSpace()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) parseValue() (any, error) {
+	switch c := p.peek(); {
+	case p.hasPrefix(`"""`):
+		return p.parseMultilineBasicString()
+	case c == '"':
+		return p.parseBasicString()
+	case p.hasPrefix("'''"):
+		return p.parseMultilineLiteralString()
+	case c == '\'':
+		return p.parseLiteralString()
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseInlineTable()
+	case p.eof() || c == '\n' || c == '\r' || c == '#':
+		return nil, p.errorf("missing value")
+	default:
+		return p.parseScalar()
+	}
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++ // "
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.peek()
+		switch {
+		case c == '"':
+			p.pos++
+			return b.String(), nil
+		case c == '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		case isControlChar(c):
+			return "", p.errorf("control character in string")
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *tomlParser) parseMultilineBasicString() (string, error) {
+	p.pos += 3
+	p.skipNewline()
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line string")
+		}
+		if p.hasPrefix(`"""`) {
+			// Up to two quotes right before the closing delimiter belong to the string
+			extra := 0
+			for extra < 2 && strings.HasPrefix(p.src[p.pos+3+extra:], `"`) {
+				extra++
+			}
+			b.WriteString(strings.Repeat(`"`, extra))
+			p.pos += 3 + extra
+			return b.String(), nil
+		}
+		c := p.peek()
+		switch {
+		case c == '\\' && p.isLineEndingBackslash():
+			p.pos++
+			for !p.eof() && strings.ContainsRune(" \t\r\n", rune(p.peek())) {
+				p.pos++
+			}
+		case c == '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		case isControlChar(c) && c != '\n' && !p.hasPrefix("\r\n"):
+			return "", p.errorf("control character in string")
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// isLineEndingBackslash は現在位置の \ の後に空白と改行だけが続くかどうかを返す
+func (p *tomlParser) isLineEndingBackslash() bool {
+	rest := strings.TrimLeft(p.src[p.pos+1:], " \t")
+	return strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n")
+}
+
+func (p *tomlParser) parseEscape(b *strings.Builder) error {
+	p.pos++ // backslash
+	if p.eof() {
+		return p.errorf("unterminated escape sequence")
+	}
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.src) {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("invalid unicode escape")
+		}
+		b.WriteRune(rune(code))
+		p.pos += size
+	default:
+		return p.errorf("invalid escape sequence \\%c", c)
+	}
+	return nil
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++ // '
+	start := p.pos
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.peek()
+		if c == '\'' {
+			value := p.src[start:p.pos]
+			p.pos++
+			return value, nil
+		}
+		if isControlChar(c) && c != '\t' {
+			return "", p.errorf("control character in string")
+		}
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parseMultilineLiteralString() (string, error) {
+	p.pos += 3
+	p.skipNewline()
+	end := strings.Index(p.src[p.pos:], "'''")
+	if end < 0 {
+		return "", p.errorf("unterminated multi-line string")
+	}
+	end += p.pos
+	// Up to two quotes right before the closing delimiter belong to the string
+	for i := 0; i < 2 && strings.HasPrefix(p.src[end+3:], "'"); i++ {
+		end++
+	}
+	value := p.src[p.pos:end]
+	p.pos = end + 3
+	return value, nil
+}
+
+// skipNewline は複数行文字列の開始直後の改行を読み飛ばす
+func (p *tomlParser) skipNewline() {
+	if p.hasPrefix("\n") {
+		p.pos++
+	} else if p.hasPrefix("\r\n") {
+		p.pos += 2
+	}
+}
+
+func isControlChar(c byte) bool {
+	return c < 0x20 && c != '\t' || c == 0x7f
+}
+
+func (p *tomlParser) parseArray() (any, error) {
+	p.pos++ // [
+	values := []any{}
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.pos++
+			return values, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return values, nil
+		default:
+			return nil, p.errorf("expected , or ] in array")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (any, error) {
+	p.pos++ // {
+	table := make(map[string]any)
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+		return table, nil
+	}
+	for {
+		if err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return table, nil
+		default:
+			return nil, p.errorf("expected , or } in inline table")
+		}
+	}
+}
+
+// parseScalar は真偽値と数値を解析する
+func (p *tomlParser) parseScalar() (any, error) {
+	start := p.pos
+	for !p.eof() && (isBareKeyChar(p.peek()) || strings.IndexByte("+.:", p.peek()) >= 0) {
+		p.pos++
+	}
+	token := p.src[start:p.pos]
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "inf", "+inf", "-inf", "nan", "+nan", "-nan":
+		return nil, p.errorf("%s cannot be used in the configuration", token)
+	}
+	if token == "" {
+		return nil, p.errorf("unexpected %q", p.peek())
+	}
+	if strings.Contains(token, ":") || len(token) >= 10 && token[4] == '-' && token[7] == '-' {
+		return nil, p.errorf("dates and times are not supported: %s", token)
+	}
+	if err := validUnderscores(token); err != nil {
+		return nil, p.errorf("invalid number %s", token)
+	}
+	digits := strings.ReplaceAll(token, "_", "")
+
+	for prefix, base := range map[string]int{"0x": 16, "0o": 8, "0b": 2} {
+		if strings.HasPrefix(digits, prefix) {
+			n, err := strconv.ParseInt(digits[2:], base, 64)
+			if err != nil {
+				return nil, p.errorf("invalid integer %s", token)
+			}
+			return n, nil
+		}
+	}
+
+	unsigned := strings.TrimLeft(digits, "+-")
+	if len(unsigned) > 1 && unsigned[0] == '0' && unsigned[1] != '.' && unsigned[1] != 'e' && unsigned[1] != 'E' {
+		return nil, p.errorf("leading zeros are not allowed: %s", token)
+	}
+	if strings.ContainsAny(digits, ".eE") {
+		f, err := strconv.ParseFloat(digits, 64)
+		if err != nil || math.IsInf(f, 0) || strings.HasPrefix(unsigned, ".") || strings.Contains(digits, ".e") || strings.HasSuffix(digits, ".") {
+			return nil, p.errorf("invalid float %s", token)
+		}
+		return f, nil
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return nil, p.errorf("invalid value %s", token)
+	}
+	return n, nil
+}
+
+// validUnderscores は数値の _ が数字に挟まれているかどうかを確認する
+func validUnderscores(token string) error {
+	for i := 0; i < len(token); i++ {
+		if token[i] != '_' {
+			continue
+		}
+		if i == 0 || i == len(token)-1 || !isHexDigit(token[i-1]) || !isHexDigit(token[i+1]) {
+			return errors.New("misplaced underscore")
+		}
+	}
+	return nil
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	src := `# comment
+title = "basic \"quoted\" \u00e9"
+literal = 'C:\path'   # trailing comment
+"quoted key" = 1
+dotted.key = true
+int = +1_000
+hex = 0xff
+oct = 0o17
+bin = 0b101
+neg = -3
+float = 3.5e2
+multi = """
+first \
+  second
+third"""
+raw = '''
+line1
+line2'''
+empty = []
+nested = [[1, 2], ["a"],]
+inline = { a = 1, b.c = "x" }
+multiline_array = [
+  "x", # comment
+  "y",
+]
+
+[table.sub]
+key = "value"
+
+[[items]]
+name = "one"
+
+[[items]]
+name = "two"
+[items.extra]
+flag = false
+`
+	got, err := parseTOML(src)
+	if err != nil {
+		t.Fatalf("parseTOML() error = %v", err)
+	}
+
+	want := map[string]any{
+		"title":           `basic "quoted" é`,
+		"literal":         `C:\path`,
+		"quoted key":      int64(1),
+		"dotted":          map[string]any{"key": true},
+		"int":             int64(1000),
+		"hex":             int64(255),
+		"oct":             int64(15),
+		"bin":             int64(5),
+		"neg":             int64(-3),
+		"float":           350.0,
+		"multi":           "first second\nthird",
+		"raw":             "line1\nline2",
+		"empty":           []any{},
+		"nested":          []any{[]any{int64(1), int64(2)}, []any{"a"}},
+		"inline":          map[string]any{"a": int64(1), "b": map[string]any{"c": "x"}},
+		"multiline_array": []any{"x", "y"},
+		"table":           map[string]any{"sub": map[string]any{"key": "value"}},
+		"items": tomlTableArray{
+			map[string]any{"name": "one"},
+			map[string]any{"name": "two", "extra": map[string]any{"flag": false}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTOML() =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestParseTOML_Errors(t *testing.T) {
+	tests := map[string]string{
+		"duplicate key":        "a = 1\na = 2\n",
+		"duplicate table":      "[a]\n[a]\n",
+		"missing value":        "a =\n",
+		"unterminated string":  "a = \"abc\n",
+		"invalid escape":       `a = "\q"` + "\n",
+		"datetime":             "a = 1979-05-27T07:32:00Z\n",
+		"local date":           "a = 1979-05-27\n",
+		"leading zero":         "a = 012\n",
+		"bad underscore":       "a = 1__0\n",
+		"two values on a line": "a = 1 b = 2\n",
+		"key is not a table":   "a = 1\n[a.b]\n",
+		"array then table":     "a = [1]\n[[a]]\n",
+		"unclosed header":      "[a\n",
+		"unclosed array":       "a = [1, 2\n",
+		"inf":                  "a = inf\n",
+	}
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseTOML(src)
+			if !errors.Is(err, ErrParseTOML) {
+				t.Errorf("parseTOML(%q) error = %v, want ErrParseTOML", src, err)
+			}
+		})
+	}
+}
+
+func TestParseTOML_ErrorLine(t *testing.T) {
+	_, err := parseTOML("a = 1\n\nb = \"x\n")
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected the error to name line 3, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrInvalidIP is returned when an origin IP is neither an address nor a hostname, or does not match the origin's record type
+	ErrInvalidIP = errors.New("invalid IP address")
+	// ErrUnknownHealthCheckType is returned when a health check type is not one of http, https and icmp
+	ErrUnknownHealthCheckType = errors.New("unknown health check type")
+	// ErrUnknownZone is returned when an origin's zone_name does not match any configured zone
+	ErrUnknownZone = errors.New("unknown zone")
+)
+
+// ヘルスチェックの種類
+const (
+	HealthCheckTypeHTTP  = "http"
+	HealthCheckTypeHTTPS = "https"
+	HealthCheckTypeICMP  = "icmp"
+)
+
+// ValidateOrigins はオリジンの設定の意味的な誤りを検出し、すべてまとめて返す
+// IPアドレスがレコードタイプ（AはIPv4、AAAAはIPv6）と一致するか（フェイルオーバー先はホスト名でもよい）、ヘルスチェックの種類が既知か、
+// zone_nameが設定されたゾーンを指すか、同じレコードを管理するオリジンが複数ないかを確認する
+func ValidateOrigins(c *Config) error {
+	zones := make(map[string]bool, len(c.CloudflareZoneIDs))
+	for _, zone := range c.CloudflareZoneIDs {
+		zones[zone.Name] = true
+	}
+
+	var errs []error
+	targets := make(map[string]int, len(c.Origins))
+	for i, origin := range c.Origins {
+		for _, err := range validateOrigin(origin, zones) {
+			errs = append(errs, fmt.Errorf("origin %s: %w", origin.Name, err))
+		}
+		// Origins of the same record would overwrite each other's changes
+		target := originTarget(origin)
+		if first, ok := targets[target]; ok {
+			errs = append(errs, fmt.Errorf("origin %s: %w: origins[%d] and origins[%d] both manage the %s records of %s in zone %s",
+				origin.Name, ErrDuplicateOrigin, first, i, origin.RecordType, origin.Name, origin.ZoneName))
+			continue
+		}
+		targets[target] = i
+	}
+	return errors.Join(errs...)
+}
+
+// originTarget はオリジンが管理するレコードを表すキーを返す（DNS名は大文字小文字と末尾のドットを区別しない）
+func originTarget(origin OriginConfig) string {
+	name := strings.TrimSuffix(strings.ToLower(origin.Name), ".")
+	return origin.ZoneName + "/" + name + "/" + strings.ToUpper(origin.RecordType)
+}
+
+func validateOrigin(origin OriginConfig, zones map[string]bool) []error {
+	var errs []error
+	if !zones[origin.ZoneName] {
+		errs = append(errs, fmt.Errorf("%w %q in zone_name", ErrUnknownZone, origin.ZoneName))
+	}
+	switch origin.HealthCheck.Type {
+	case HealthCheckTypeHTTP, HealthCheckTypeHTTPS, HealthCheckTypeICMP:
+	default:
+		errs = append(errs, fmt.Errorf("%w %q", ErrUnknownHealthCheckType, origin.HealthCheck.Type))
+	}
+	// An unsupported record type is reported on its own
+	if validateRecordType(origin.RecordType) != nil {
+		return errs
+	}
+
+	// Legacy failover IPs are also copied into priority_levels, so each IP is reported once
+	reported := make(map[string]bool)
+	check := func(field string, ips []string, validate func(ip, recordType string) error) {
+		for _, ip := range ips {
+			if reported[ip] {
+				continue
+			}
+			if err := validate(ip, origin.RecordType); err != nil {
+				reported[ip] = true
+				errs = append(errs, fmt.Errorf("%s: %w", field, err))
+			}
+		}
+	}
+	check("priority_failover_ips", origin.PriorityFailoverIPs, validateOriginTarget)
+	check("failover_ips", origin.FailoverIPs, validateOriginTarget)
+	for i, level := range origin.PriorityLevels {
+		check(fmt.Sprintf("priority_levels[%d]", i), level.IPs, validateOriginTarget)
+	}
+	for _, name := range sortedKeys(origin.IPSets) {
+		for i, level := range origin.IPSets[name] {
+			check(fmt.Sprintf("ip_sets.%s[%d]", name, i), level.IPs, validateOriginTarget)
+		}
+	}
+	// Weights apply to the published addresses, so they cannot name a host
+	check("weights", sortedKeys(origin.Weights), validateOriginIP)
+	return errs
+}
+
+// validateOriginTarget はフェイルオーバー先がホスト名かKubernetesのServiceかConsulのサービスか、レコードタイプに合うIPアドレスかどうかを確認する
+func validateOriginTarget(target, recordType string) error {
+	if IsHostname(target) {
+		return nil
+	}
+	if IsKubernetesTarget(target) {
+		_, err := ParseKubernetesTarget(target)
+		return err
+	}
+	if IsConsulTarget(target) {
+		_, err := ParseConsulTarget(target)
+		return err
+	}
+	return validateOriginIP(target, recordType)
+}
+
+// IsHostname はsがIPアドレスではなくホスト名（末尾のドットは任意）かどうかを返す
+// 最後のラベルが数字だけのもの（"192.0.2.300"など）はIPアドレスの誤りとみなす
+func IsHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	labels := strings.Split(s, ".")
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return strings.Trim(labels[len(labels)-1], "0123456789") != ""
+}
+
+// validateOriginIP はipがレコードタイプに合うIPアドレスかどうかを確認する
+func validateOriginIP(ip, recordType string) error {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return fmt.Errorf("%w %q", ErrInvalidIP, ip)
+	}
+	if recordType == "A" && !addr.Is4() {
+		return fmt.Errorf("%w %s: A records need an IPv4 address", ErrInvalidIP, ip)
+	}
+	if recordType == "AAAA" && !addr.Is6() {
+		return fmt.Errorf("%w %s: AAAA records need an IPv6 address", ErrInvalidIP, ip)
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
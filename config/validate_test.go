@@ -0,0 +1,173 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateOrigins(t *testing.T) {
+	cfg := &Config{
+		CloudflareZoneIDs: []ZoneConfig{{ZoneID: "zone-1", Name: "example.com"}},
+		Origins: []OriginConfig{
+			{
+				Name:           "www",
+				ZoneName:       "example.com",
+				RecordType:     "A",
+				HealthCheck:    HealthCheck{Type: HealthCheckTypeHTTPS},
+				PriorityLevels: []PriorityLevel{{Priority: 1, IPs: []string{"192.0.2.1"}}, {IPs: []string{"backup.example.net"}}},
+				IPSets:         map[string][]PriorityLevel{"blue": {{IPs: []string{"192.0.2.2"}}}},
+				Weights:        map[string]int{"192.0.2.1": 1},
+			},
+			{
+				Name:           "v6",
+				ZoneName:       "example.com",
+				RecordType:     "AAAA",
+				HealthCheck:    HealthCheck{Type: HealthCheckTypeICMP},
+				PriorityLevels: []PriorityLevel{{IPs: []string{"2001:db8::1"}}},
+			},
+		},
+	}
+	if err := ValidateOrigins(cfg); err != nil {
+		t.Fatalf("Expected a valid config, got %v", err)
+	}
+
+	cfg.Origins[0].ZoneName = "example.org"
+	cfg.Origins[0].HealthCheck.Type = "tcp"
+	cfg.Origins[0].IPSets["blue"][0].IPs = []string{"2001:db8::2"}
+	cfg.Origins[0].Weights = map[string]int{"not-an-ip": 1}
+	cfg.Origins[1].PriorityLevels[0].IPs = []string{"192.0.2.3"}
+
+	err := ValidateOrigins(cfg)
+	for _, target := range []error{ErrUnknownZone, ErrUnknownHealthCheckType, ErrInvalidIP} {
+		if !errors.Is(err, target) {
+			t.Errorf("Expected %v, got %v", target, err)
+		}
+	}
+	for _, want := range []string{
+		`origin www: unknown zone "example.org"`,
+		`origin www: unknown health check type "tcp"`,
+		"origin www: ip_sets.blue[0]: invalid IP address 2001:db8::2: A records need an IPv4 address",
+		`origin www: weights: invalid IP address "not-an-ip"`,
+		"origin v6: priority_levels[0]: invalid IP address 192.0.2.3: AAAA records need an IPv6 address",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in:\n%v", want, err)
+		}
+	}
+}
+
+func TestIsHostname(t *testing.T) {
+	for target, want := range map[string]bool{
+		"backup.example.net":  true,
+		"backup.example.net.": true,
+		"backup":              true,
+		"dr-1.example.net":    true,
+		"192.0.2.1":           false,
+		"192.0.2.300":         false,
+		"2001:db8::1":         false,
+		"-bad.example.net":    false,
+		"bad..example.net":    false,
+		"under_score.net":     false,
+		"":                    false,
+	} {
+		if got := IsHostname(target); got != want {
+			t.Errorf("IsHostname(%q) = %v, want %v", target, got, want)
+		}
+	}
+}
+
+func TestLoadConfig_ReportsAllOriginErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, path, `
+version: 2
+cloudflare_api_token: token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+  - zone_id: zone-2
+    name: example.net
+check_interval_seconds: 60
+origins:
+  - name: www
+    zone_name: example.com
+    record_type: A
+    health_check: {type: https, endpoint: /health}
+    failover_ips: ["192.0.2.1", "2001:db8::1"]
+  - name: api
+    zone_name: example.org
+    record_type: A
+    mode: passive
+    health_check: {type: http}
+    priority_levels:
+      - ips: ["192.0.2.300"]
+`)
+	_, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	for _, want := range []string{
+		"origin www: failover_ips: invalid IP address 2001:db8::1",
+		"invalid mode for origin api",
+		`origin api: unknown zone "example.org"`,
+		`origin api: priority_levels[0]: invalid IP address "192.0.2.300"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in:\n%v", want, err)
+		}
+	}
+	if strings.Count(err.Error(), "2001:db8::1") != 1 {
+		t.Errorf("Expected a legacy failover IP to be reported once, got:\n%v", err)
+	}
+}
+
+func TestValidateOrigins_Duplicates(t *testing.T) {
+	origin := func(name, zone, recordType string) OriginConfig {
+		return OriginConfig{Name: name, ZoneName: zone, RecordType: recordType, HealthCheck: HealthCheck{Type: HealthCheckTypeICMP}}
+	}
+	cfg := &Config{
+		CloudflareZoneIDs: []ZoneConfig{{Name: "example.com"}, {Name: "example.net"}},
+		Origins: []OriginConfig{
+			origin("www.example.com", "example.com", "A"),
+			origin("www.example.com", "example.com", "AAAA"),
+			origin("www.example.com", "example.net", "A"),
+			origin("WWW.example.com.", "example.com", "A"),
+		},
+	}
+
+	err := ValidateOrigins(cfg)
+	if !errors.Is(err, ErrDuplicateOrigin) {
+		t.Fatalf("Expected ErrDuplicateOrigin, got %v", err)
+	}
+	if want := "origins[0] and origins[3] both manage the A records"; !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected %q in %v", want, err)
+	}
+	if strings.Count(err.Error(), "both manage") != 1 {
+		t.Errorf("Expected only one conflict, got %v", err)
+	}
+}
+
+func TestLoadConfig_DuplicateOrigins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, path, `
+cloudflare_api_token: token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+check_interval_seconds: 60
+origins:
+  - name: www.example.com
+    record_type: A
+    health_check: {type: icmp}
+    priority_levels: [{ips: ["192.0.2.1"]}]
+  - name: www.example.com
+    zone_name: example.com
+    record_type: A
+    health_check: {type: https}
+    priority_levels: [{ips: ["192.0.2.2"]}]
+`)
+	if _, err := LoadConfig(path); !errors.Is(err, ErrDuplicateOrigin) {
+		t.Errorf("Expected ErrDuplicateOrigin once the default zone is applied, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnsupportedVersion is returned when the config version is not one this build can read
+var ErrUnsupportedVersion = errors.New("unsupported config version")
+
+// CurrentVersion は現在の設定ファイルの形式のバージョン
+// versionのない設定ファイルはバージョン1とみなす
+const CurrentVersion = 2
+
+// migration は設定ファイルをfromからfrom+1のバージョンに書き換える処理
+type migration struct {
+	from  int
+	apply func(root *yaml.Node) []string
+}
+
+// migrations はバージョン順に並べた書き換え処理
+// 設定ファイルの形式を変えるときは、ここに追加してCurrentVersionを上げる
+var migrations = []migration{
+	{from: 1, apply: migrateLegacyFields},
+}
+
+// documentVersion は設定ファイルのversionを返す
+func documentVersion(root *yaml.Node) (int, error) {
+	node := mappingValue(root, "version")
+	if node == nil || node.ShortTag() == "!!null" {
+		return 1, nil
+	}
+	version, err := strconv.Atoi(node.Value)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("%w: %q", ErrUnsupportedVersion, node.Value)
+	}
+	if version > CurrentVersion {
+		return 0, fmt.Errorf("%w: %d is newer than %d, the latest this gslb supports", ErrUnsupportedVersion, version, CurrentVersion)
+	}
+	return version, nil
+}
+
+// setDocumentVersion はversionを更新する。ない場合は先頭（$schemaの次）に追加する
+func setDocumentVersion(root *yaml.Node, version int) {
+	if node := mappingValue(root, "version"); node != nil {
+		*node = *intNode(version)
+		return
+	}
+	i := 0
+	if len(root.Content) > 0 && root.Content[0].Value == "$schema" {
+		i = 2
+	}
+	key := stringNode("version")
+	if i < len(root.Content) {
+		// Keep the comment at the top of the file above the new key
+		key.HeadComment, root.Content[i].HeadComment = root.Content[i].HeadComment, ""
+	}
+	root.Content = append(root.Content[:i], append([]*yaml.Node{key, intNode(version)}, root.Content[i:]...)...)
+}
+
+// applyMigrations はバージョンfromの設定に以降の書き換えを順に適用し、変更内容の説明を返す
+func applyMigrations(root *yaml.Node, from int) []string {
+	var changes []string
+	for _, m := range migrations {
+		if m.from >= from {
+			changes = append(changes, m.apply(root)...)
+		}
+	}
+	return changes
+}
+
+// migrateConfig は古いバージョンの設定ファイルを読み込み前に現在の形式に書き換える
+// 書き換えた場合はJSONにした設定と、設定ファイルの更新を促す警告を返す
+func migrateConfig(ext fileExt, data []byte) (fileExt, []byte, []string, error) {
+	doc, err := ParseDocument("config"+string(ext), data)
+	if err != nil {
+		// Syntax errors are reported by the decoder
+		return ext, data, nil, nil
+	}
+	root := doc.Content[0]
+	from, err := documentVersion(root)
+	if err != nil || from == CurrentVersion {
+		return ext, data, nil, err
+	}
+	changes := applyMigrations(root, from)
+	if len(changes) == 0 {
+		return ext, data, nil, nil
+	}
+	migrated, err := FormatDocument(doc, FormatJSON)
+	if err != nil {
+		return ext, data, nil, err
+	}
+	warnings := make([]string, 0, len(changes))
+	for _, change := range changes {
+		warnings = append(warnings, fmt.Sprintf("config version %d is deprecated: %s (run gslb-migrate to update the file)", from, change))
+	}
+	return extJSON, migrated, warnings, nil
+}
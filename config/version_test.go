@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrations_Chain(t *testing.T) {
+	if len(migrations) != CurrentVersion-1 {
+		t.Fatalf("Expected %d migrations up to version %d, got %d", CurrentVersion-1, CurrentVersion, len(migrations))
+	}
+	for i, m := range migrations {
+		if m.from != i+1 {
+			t.Errorf("migrations[%d] migrates from version %d, want %d", i, m.from, i+1)
+		}
+	}
+}
+
+func TestLoadConfig_MigratesOldVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, path, `
+cloudflare_api_token: token
+cloudflare_zone_id: zone-1
+check_interval_seconds: 60
+origins:
+  - name: www
+    record_type: A
+    health_check: {type: icmp}
+    priority_failover_ips: ["192.0.2.1"]
+    failover_ips: ["192.0.2.2"]
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(cfg.CloudflareZoneIDs) != 1 || cfg.CloudflareZoneIDs[0].ZoneID != "zone-1" || cfg.Origins[0].ZoneName != "default" {
+		t.Errorf("Unexpected zones %+v, origin zone %q", cfg.CloudflareZoneIDs, cfg.Origins[0].ZoneName)
+	}
+	origin := cfg.Origins[0]
+	if len(origin.PriorityLevels) != 2 || origin.PriorityLevels[0].Priority != LegacyPriorityHigh || len(origin.FailoverIPs) != 0 {
+		t.Errorf("Unexpected origin %+v", origin)
+	}
+	if len(cfg.Warnings) != 2 {
+		t.Fatalf("Expected a warning for each migrated setting, got %q", cfg.Warnings)
+	}
+	for _, warning := range cfg.Warnings {
+		if !strings.HasPrefix(warning, "config version 1 is deprecated: ") || !strings.Contains(warning, "gslb-migrate") {
+			t.Errorf("Unexpected warning %q", warning)
+		}
+	}
+}
+
+func TestLoadConfig_CurrentVersionHasNoWarnings(t *testing.T) {
+	for name, version := range map[string]string{"without version": "", "current version": "version: 2\n"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			writeConfigFile(t, path, version+`
+cloudflare_api_token: token
+cloudflare_zones:
+  - zone_id: zone-1
+    name: example.com
+origins:
+  - name: www
+    record_type: A
+    health_check: {type: icmp}
+    priority_levels:
+      - ips: ["192.0.2.1"]
+`)
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if len(cfg.Warnings) != 0 {
+				t.Errorf("Unexpected warnings %q", cfg.Warnings)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_UnsupportedVersion(t *testing.T) {
+	for _, version := range []string{"0", "3"} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		writeConfigFile(t, path, `{"version": `+version+`, "cloudflare_api_token": "token"}`)
+		if _, err := LoadConfig(path); !errors.Is(err, ErrUnsupportedVersion) {
+			t.Errorf("version %s: expected ErrUnsupportedVersion, got %v", version, err)
+		}
+	}
+}
+
+func TestMigrateDocument_VersionAfterSchemaKey(t *testing.T) {
+	got, _ := migrate(t, "config.json", `{"$schema": "./config.schema.json", "origins": []}`, FormatJSON)
+	want := `{
+  "$schema": "./config.schema.json",
+  "version": 2,
+  "origins": []
+}
+`
+	if got != want {
+		t.Errorf("Migrated config:\n%s\nwant:\n%s", got, want)
+	}
+}
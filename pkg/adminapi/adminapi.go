@@ -0,0 +1,464 @@
+// Package adminapi serves an authenticated HTTP API for operating the daemon
+// without editing its configuration: pausing and resuming origins, forcing
+// failovers and failbacks, checking an origin right away, reloading the
+// configuration, and adding and removing origins and notifications.
+package adminapi
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/apiauth"
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/history"
+	"github.com/bootjp/cloudflare-gslb/pkg/servertls"
+	"github.com/bootjp/cloudflare-gslb/pkg/statusapi"
+)
+
+// Endpoints of the API. The origin endpoints take the zone, name and record
+// type of the origin in the path, e.g.
+// POST /admin/v1/origins/example.com/www.example.com/A/pause.
+const (
+	// OriginsPath lists every origin and its state. A POST adds the origin
+	// in its body and a DELETE of an origin removes it, when it was added
+	// that way.
+	OriginsPath = "/admin/v1/origins"
+	// NotificationsPath takes the POST of a notification to add and the
+	// DELETE of a notification by name, e.g.
+	// DELETE /admin/v1/notifications/ops-slack, like the origins.
+	NotificationsPath = "/admin/v1/notifications"
+	// EventsPath returns the recorded event history, with the query
+	// parameters of the events of the status API.
+	EventsPath = "/admin/v1/events"
+	// ReloadPath reloads the configuration.
+	ReloadPath = "/admin/v1/reload"
+)
+
+// Actions on an origin, the last element of its path.
+const (
+	ActionPause    = "pause"
+	ActionResume   = "resume"
+	ActionFailover = "failover"
+	ActionFailback = "failback"
+	ActionCheck    = "check"
+)
+
+// maxBodySize is the largest origin or notification a request may add.
+const maxBodySize = 1 << 20
+
+// DefaultActor is who the actions are attributed to when the request does
+// not name anyone with the by query parameter and its token has no name.
+const DefaultActor = "admin API"
+
+// Controller operates the origins, normally a *gslb.Service.
+type Controller interface {
+	OriginReports() []gslb.OriginReport
+	Events(filter history.Filter) ([]history.Event, error)
+	HoldOrigin(ctx context.Context, zone, name, recordType, by string) error
+	ReleaseOrigin(ctx context.Context, zone, name, recordType, by string) error
+	ForceFailover(ctx context.Context, zone, name, recordType, by string) error
+	ForceFailback(ctx context.Context, zone, name, recordType, by string) error
+	CheckOrigin(ctx context.Context, zone, name, recordType string) error
+}
+
+// Server authenticates the requests with a bearer token, a client
+// certificate or both, and applies them to the current controller. Tokens of
+// the viewer role may only list the origins and the events. The controller
+// can be replaced while serving, e.g. when the configuration is reloaded.
+type Server struct {
+	mu         sync.RWMutex
+	controller Controller
+
+	tokens            apiauth.Tokens
+	requireClientCert bool
+	reload            func(ctx context.Context) error
+
+	// runtimeMu serializes the changes to the runtime file
+	runtimeMu   sync.Mutex
+	runtimeFile string
+
+	server *http.Server
+}
+
+// originsResponse is the body of GET /admin/v1/origins.
+type originsResponse struct {
+	Origins []gslb.OriginReport `json:"origins"`
+}
+
+// originResponse is the body of a successful action on an origin.
+type originResponse struct {
+	Origin gslb.OriginReport `json:"origin"`
+}
+
+// eventsResponse is the body of GET /admin/v1/events.
+type eventsResponse struct {
+	Events []history.Event `json:"events"`
+}
+
+// statusResponse is the body of POST /admin/v1/reload.
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+// errorResponse is the body of a failed request.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewServer returns a server that accepts the requests with token as their
+// bearer token of the operator role, unless it is empty, and reloads the
+// configuration with reload. Without any token, every request that passes
+// the client certificate check may do everything.
+func NewServer(token string, reload func(ctx context.Context) error) *Server {
+	mux := http.NewServeMux()
+	s := &Server{
+		reload: reload,
+		server: &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second},
+	}
+	if token != "" {
+		s.tokens.Add(token, apiauth.Credential{Role: apiauth.RoleOperator})
+	}
+	mux.Handle("GET "+OriginsPath, s.authorize(apiauth.RoleViewer, s.handleOrigins))
+	mux.Handle("POST "+OriginsPath, s.authorize(apiauth.RoleOperator, s.handleAddOrigin))
+	mux.Handle("DELETE "+OriginsPath+"/{zone}/{name}/{type}", s.authorize(apiauth.RoleOperator, s.handleRemoveOrigin))
+	mux.Handle("POST "+OriginsPath+"/{zone}/{name}/{type}/{action}", s.authorize(apiauth.RoleOperator, s.handleAction))
+	mux.Handle("POST "+NotificationsPath, s.authorize(apiauth.RoleOperator, s.handleAddNotification))
+	mux.Handle("DELETE "+NotificationsPath+"/{name}", s.authorize(apiauth.RoleOperator, s.handleRemoveNotification))
+	mux.Handle("GET "+EventsPath, s.authorize(apiauth.RoleViewer, s.handleEvents))
+	mux.Handle("POST "+ReloadPath, s.authorize(apiauth.RoleOperator, s.handleReload))
+	return s
+}
+
+// AddToken accepts token as a bearer token of role, attributing the changes
+// made with it to name unless the request names someone. It must be called
+// before serving.
+func (s *Server) AddToken(name, token string, role apiauth.Role) {
+	s.tokens.Add(token, apiauth.Credential{Name: name, Role: role})
+}
+
+// SetController replaces the controller the requests are applied to.
+func (s *Server) SetController(controller Controller) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.controller = controller
+}
+
+// SetRuntimeFile enables adding and removing origins and notifications,
+// which are saved to the runtime file at path and applied by reloading the
+// configuration. It must be called before serving.
+func (s *Server) SetRuntimeFile(path string) {
+	s.runtimeFile = path
+}
+
+// RequireClientCert makes the server accept only requests with a verified
+// client certificate, in addition to the token. It must be called before
+// serving.
+func (s *Server) RequireClientCert() {
+	s.requireClientCert = true
+}
+
+// Handler returns the HTTP handler of the API.
+func (s *Server) Handler() http.Handler {
+	return s.server.Handler
+}
+
+// ListenAndServe listens on addr and serves the API in the background, over
+// TLS with tlsConfig unless it is nil.
+func (s *Server) ListenAndServe(addr string, tlsConfig *tls.Config) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.Serve(listener, tlsConfig)
+	return nil
+}
+
+// Serve serves the API on listener in the background, like ListenAndServe.
+// It can be called for several listeners, such as a TCP port and a unix
+// socket.
+func (s *Server) Serve(listener net.Listener, tlsConfig *tls.Config) {
+	go func() {
+		if err := servertls.Serve(s.server, listener, tlsConfig); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Admin API stopped: %v", err)
+		}
+	}()
+}
+
+// Shutdown stops the server, waiting for in-flight requests until ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+func (s *Server) currentController() Controller {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.controller
+}
+
+// authorize rejects requests without "Authorization: Bearer <token>" or
+// without a client certificate, as configured, and requests whose token does
+// not grant required.
+func (s *Server) authorize(required apiauth.Role, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.requireClientCert && !servertls.Verified(r) {
+			writeError(w, http.StatusUnauthorized, "a client certificate is required")
+			return
+		}
+		credential, ok := s.tokens.Authenticate(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			writeError(w, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+			return
+		}
+		if !credential.Role.Allows(required) {
+			log.Printf("Admin API: denied %s %s to the %s token %q", r.Method, r.URL.Path, credential.Role, credential.Name)
+			writeError(w, http.StatusForbidden, "the "+string(credential.Role)+" role may not do this")
+			return
+		}
+		next(w, r.WithContext(apiauth.WithCredential(r.Context(), credential)))
+	})
+}
+
+func (s *Server) handleOrigins(w http.ResponseWriter, r *http.Request) {
+	controller := s.currentController()
+	if controller == nil {
+		writeError(w, http.StatusServiceUnavailable, "no service is running")
+		return
+	}
+	writeJSON(w, http.StatusOK, originsResponse{Origins: append([]gslb.OriginReport{}, controller.OriginReports()...)})
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := statusapi.ParseEventFilter(r.URL.Query(), time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	controller := s.currentController()
+	if controller == nil {
+		writeError(w, http.StatusServiceUnavailable, "no service is running")
+		return
+	}
+	events, err := controller.Events(filter)
+	if errors.Is(err, gslb.ErrEventHistoryDisabled) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Admin API: failed to query event history: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to query event history")
+		return
+	}
+	writeJSON(w, http.StatusOK, eventsResponse{Events: append([]history.Event{}, events...)})
+}
+
+func (s *Server) handleAction(w http.ResponseWriter, r *http.Request) {
+	controller := s.currentController()
+	if controller == nil {
+		writeError(w, http.StatusServiceUnavailable, "no service is running")
+		return
+	}
+	zone, name, recordType := r.PathValue("zone"), r.PathValue("name"), r.PathValue("type")
+	by := actor(r)
+
+	// A client that gives up must not abort a DNS change halfway
+	ctx := context.WithoutCancel(r.Context())
+	var err error
+	action := r.PathValue("action")
+	switch action {
+	case ActionPause:
+		err = controller.HoldOrigin(ctx, zone, name, recordType, by)
+	case ActionResume:
+		err = controller.ReleaseOrigin(ctx, zone, name, recordType, by)
+	case ActionFailover:
+		err = controller.ForceFailover(ctx, zone, name, recordType, by)
+	case ActionFailback:
+		err = controller.ForceFailback(ctx, zone, name, recordType, by)
+	case ActionCheck:
+		err = controller.CheckOrigin(ctx, zone, name, recordType)
+	default:
+		writeError(w, http.StatusNotFound, "unknown action "+action)
+		return
+	}
+	if err != nil {
+		log.Printf("Admin API: %s of %s.%s (%s) by %s failed: %v", action, name, zone, recordType, by, err)
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+	log.Printf("Admin API: %s of %s.%s (%s) by %s", action, name, zone, recordType, by)
+
+	for _, report := range controller.OriginReports() {
+		if report.Zone == zone && report.Name == name && report.RecordType == recordType {
+			writeJSON(w, http.StatusOK, originResponse{Origin: report})
+			return
+		}
+	}
+	// The configuration was reloaded without the origin in the meantime
+	writeError(w, http.StatusNotFound, "origin not found")
+}
+
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if s.reload == nil {
+		writeError(w, http.StatusNotImplemented, "reloading is not supported")
+		return
+	}
+	log.Printf("Admin API: reloading the configuration")
+	if err := s.reload(r.Context()); err != nil {
+		log.Printf("Admin API: reload failed: %v", err)
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusAccepted, statusResponse{Status: "reloading"})
+}
+
+func (s *Server) handleAddOrigin(w http.ResponseWriter, r *http.Request) {
+	var origin config.OriginConfig
+	if !decodeBody(w, r, &origin) {
+		return
+	}
+	s.editRuntimeFile(w, r, fmt.Sprintf("add origin %s.%s (%s)", origin.Name, origin.ZoneName, origin.RecordType), func(f *config.RuntimeFile) error {
+		return f.AddOrigin(origin)
+	})
+}
+
+func (s *Server) handleRemoveOrigin(w http.ResponseWriter, r *http.Request) {
+	zone, name, recordType := r.PathValue("zone"), r.PathValue("name"), r.PathValue("type")
+	s.editRuntimeFile(w, r, fmt.Sprintf("remove origin %s.%s (%s)", name, zone, recordType), func(f *config.RuntimeFile) error {
+		return f.RemoveOrigin(zone, name, recordType)
+	})
+}
+
+func (s *Server) handleAddNotification(w http.ResponseWriter, r *http.Request) {
+	var notification config.NotificationConfig
+	if !decodeBody(w, r, &notification) {
+		return
+	}
+	s.editRuntimeFile(w, r, fmt.Sprintf("add notification %q", notification.Name), func(f *config.RuntimeFile) error {
+		return f.AddNotification(notification)
+	})
+}
+
+func (s *Server) handleRemoveNotification(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	s.editRuntimeFile(w, r, fmt.Sprintf("remove notification %q", name), func(f *config.RuntimeFile) error {
+		return f.RemoveNotification(name)
+	})
+}
+
+// editRuntimeFile applies edit to the runtime file and reloads the
+// configuration, like POST /admin/v1/reload, so that the change takes effect
+// without restarting the daemon and losing the state of the origins. The
+// file is restored when the new configuration cannot be applied.
+func (s *Server) editRuntimeFile(w http.ResponseWriter, r *http.Request, change string, edit func(f *config.RuntimeFile) error) {
+	if s.runtimeFile == "" || s.reload == nil {
+		writeError(w, http.StatusNotImplemented, "admin_api.runtime_file is not set")
+		return
+	}
+	by := actor(r)
+	s.runtimeMu.Lock()
+	defer s.runtimeMu.Unlock()
+
+	previous, err := config.ReadRuntimeFile(s.runtimeFile)
+	if err != nil {
+		log.Printf("Admin API: failed to read the runtime file: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to read the runtime file")
+		return
+	}
+	edited := &config.RuntimeFile{
+		Origins:       append([]config.OriginConfig{}, previous.Origins...),
+		Notifications: append([]config.NotificationConfig{}, previous.Notifications...),
+	}
+	if err := edit(edited); err != nil {
+		writeError(w, runtimeStatusFor(err), err.Error())
+		return
+	}
+	if err := config.WriteRuntimeFile(s.runtimeFile, edited); err != nil {
+		log.Printf("Admin API: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to write the runtime file")
+		return
+	}
+	if err := s.reload(r.Context()); err != nil {
+		log.Printf("Admin API: %s by %s failed: %v", change, by, err)
+		if restoreErr := config.WriteRuntimeFile(s.runtimeFile, previous); restoreErr != nil {
+			log.Printf("Admin API: failed to restore the runtime file: %v", restoreErr)
+		}
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	log.Printf("Admin API: %s by %s", change, by)
+	writeJSON(w, http.StatusAccepted, statusResponse{Status: "reloading"})
+}
+
+// decodeBody decodes the JSON body of r into v, rejecting unknown fields,
+// and writes the error response when it cannot.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// actor returns who the changes of r are attributed to: the name of its
+// token, so that a caller cannot act as someone else, or else the by query
+// parameter.
+func actor(r *http.Request) string {
+	if credential, _ := apiauth.CredentialFrom(r.Context()); credential.Name != "" {
+		return credential.Name
+	}
+	by := r.URL.Query().Get("by")
+	if by == "" {
+		by = DefaultActor
+	}
+	return by
+}
+
+// runtimeStatusFor maps the errors of editing the runtime file to HTTP
+// statuses.
+func runtimeStatusFor(err error) int {
+	switch {
+	case errors.Is(err, config.ErrRuntimeEntryExists):
+		return http.StatusConflict
+	case errors.Is(err, config.ErrRuntimeEntryNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// statusFor maps the errors of the controller to HTTP statuses.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, gslb.ErrOriginNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, gslb.ErrAlreadyHeld), errors.Is(err, gslb.ErrNotHeld),
+		errors.Is(err, gslb.ErrNoFailoverTarget), errors.Is(err, gslb.ErrForceNotApplied):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Failed to write admin API response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, errorResponse{Error: message})
+}
@@ -0,0 +1,363 @@
+package adminapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/apiauth"
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/history"
+)
+
+const testToken = "s3cret"
+
+type fakeController struct {
+	mu     sync.Mutex
+	calls  []string
+	err    error
+	filter history.Filter
+}
+
+func (c *fakeController) OriginReports() []gslb.OriginReport {
+	return []gslb.OriginReport{{Name: "www.example.com", Zone: "example.com", RecordType: "A", Health: gslb.HealthHealthy}}
+}
+
+func (c *fakeController) Events(filter history.Filter) ([]history.Event, error) {
+	c.filter = filter
+	if c.err != nil {
+		return nil, c.err
+	}
+	return []history.Event{{Type: history.TypeDNSChange, Origin: "www.example.com", RecordType: "A"}}, nil
+}
+
+func (c *fakeController) HoldOrigin(ctx context.Context, zone, name, recordType, by string) error {
+	return c.record(ActionPause, zone, name, recordType, by)
+}
+
+func (c *fakeController) ReleaseOrigin(ctx context.Context, zone, name, recordType, by string) error {
+	return c.record(ActionResume, zone, name, recordType, by)
+}
+
+func (c *fakeController) ForceFailover(ctx context.Context, zone, name, recordType, by string) error {
+	return c.record(ActionFailover, zone, name, recordType, by)
+}
+
+func (c *fakeController) ForceFailback(ctx context.Context, zone, name, recordType, by string) error {
+	return c.record(ActionFailback, zone, name, recordType, by)
+}
+
+func (c *fakeController) CheckOrigin(ctx context.Context, zone, name, recordType string) error {
+	return c.record(ActionCheck, zone, name, recordType, "")
+}
+
+func (c *fakeController) record(action, zone, name, recordType, by string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, fmt.Sprintf("%s %s/%s/%s by %s", action, zone, name, recordType, by))
+	return c.err
+}
+
+func request(method, path, token string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestServer_Actions(t *testing.T) {
+	server := NewServer(testToken, nil)
+	controller := &fakeController{}
+	server.SetController(controller)
+
+	for _, action := range []string{ActionPause, ActionResume, ActionFailover, ActionFailback, ActionCheck} {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, request(http.MethodPost, OriginsPath+"/example.com/www.example.com/A/"+action+"?by=alice", testToken))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", action, rec.Code, rec.Body)
+		}
+		var response originResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil || response.Origin.Name != "www.example.com" {
+			t.Errorf("%s: unexpected response %+v, %v", action, response, err)
+		}
+	}
+	want := []string{
+		"pause example.com/www.example.com/A by alice",
+		"resume example.com/www.example.com/A by alice",
+		"failover example.com/www.example.com/A by alice",
+		"failback example.com/www.example.com/A by alice",
+		"check example.com/www.example.com/A by ",
+	}
+	if fmt.Sprint(controller.calls) != fmt.Sprint(want) {
+		t.Errorf("calls = %v, want %v", controller.calls, want)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, request(http.MethodGet, OriginsPath, testToken))
+	var origins originsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&origins); err != nil || rec.Code != http.StatusOK || len(origins.Origins) != 1 {
+		t.Errorf("GET origins = %d %+v, %v", rec.Code, origins, err)
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, request(http.MethodPost, OriginsPath+"/example.com/www.example.com/A/explode", testToken))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown action: expected 404, got %d", rec.Code)
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("www.example.com: %w", gslb.ErrOriginNotFound), http.StatusNotFound},
+		{fmt.Errorf("www.example.com: %w", gslb.ErrAlreadyHeld), http.StatusConflict},
+		{fmt.Errorf("www.example.com: %w", gslb.ErrNoFailoverTarget), http.StatusConflict},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		server := NewServer(testToken, nil)
+		server.SetController(&fakeController{err: tt.err})
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, request(http.MethodPost, OriginsPath+"/example.com/www.example.com/A/pause", testToken))
+		var response errorResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil || rec.Code != tt.want || response.Error == "" {
+			t.Errorf("%v: got %d %+v, want %d", tt.err, rec.Code, response, tt.want)
+		}
+	}
+
+	// Before the service is started there is nothing to operate
+	rec := httptest.NewRecorder()
+	NewServer(testToken, nil).Handler().ServeHTTP(rec, request(http.MethodGet, OriginsPath, testToken))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without a controller: expected 503, got %d", rec.Code)
+	}
+}
+
+func TestServer_RequiresToken(t *testing.T) {
+	server := NewServer(testToken, nil)
+	controller := &fakeController{}
+	server.SetController(controller)
+
+	for _, token := range []string{"", "wrong"} {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, request(http.MethodPost, OriginsPath+"/example.com/www.example.com/A/failover", token))
+		if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("token %q: expected 401 with a challenge, got %d", token, rec.Code)
+		}
+	}
+	if len(controller.calls) != 0 {
+		t.Errorf("expected no calls without the token, got %v", controller.calls)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, request(http.MethodGet, OriginsPath+"/example.com/www.example.com/A/failover", testToken))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET of an action: expected 405, got %d", rec.Code)
+	}
+}
+
+func TestServer_Roles(t *testing.T) {
+	server := NewServer(testToken, func(ctx context.Context) error { return nil })
+	server.AddToken("noc", "noc-token", apiauth.RoleViewer)
+	server.AddToken("oncall", "oncall-token", apiauth.RoleOperator)
+	controller := &fakeController{}
+	server.SetController(controller)
+
+	for _, path := range []string{OriginsPath, EventsPath} {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, request(http.MethodGet, path, "noc-token"))
+		if rec.Code != http.StatusOK {
+			t.Errorf("viewer GET %s: expected 200, got %d: %s", path, rec.Code, rec.Body)
+		}
+	}
+	for _, path := range []string{OriginsPath + "/example.com/www.example.com/A/failover", ReloadPath} {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, request(http.MethodPost, path, "noc-token"))
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("viewer POST %s: expected 403, got %d", path, rec.Code)
+		}
+	}
+	if len(controller.calls) != 0 {
+		t.Errorf("expected no changes by the viewer, got %v", controller.calls)
+	}
+
+	// Changes are attributed to the name of the token, whatever by says
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, request(http.MethodPost, OriginsPath+"/example.com/www.example.com/A/pause?by=mallory", "oncall-token"))
+	if rec.Code != http.StatusOK {
+		t.Errorf("operator: expected 200, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, request(http.MethodPost, OriginsPath+"/example.com/www.example.com/A/resume", testToken))
+	if rec.Code != http.StatusOK {
+		t.Errorf("token without a name: expected 200, got %d", rec.Code)
+	}
+	want := []string{"pause example.com/www.example.com/A by oncall", "resume example.com/www.example.com/A by " + DefaultActor}
+	if fmt.Sprint(controller.calls) != fmt.Sprint(want) {
+		t.Errorf("calls = %v, want %v", controller.calls, want)
+	}
+}
+
+func TestServer_ClientCert(t *testing.T) {
+	// With a client CA and no token, the certificate alone authenticates
+	server := NewServer("", nil)
+	server.RequireClientCert()
+	controller := &fakeController{}
+	server.SetController(controller)
+
+	req := request(http.MethodPost, OriginsPath+"/example.com/www.example.com/A/failover", "")
+	req.TLS = &tls.ConnectionState{}
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("without a client certificate: expected 401, got %d", rec.Code)
+	}
+
+	req = request(http.MethodPost, OriginsPath+"/example.com/www.example.com/A/failover", "")
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || len(controller.calls) != 1 {
+		t.Errorf("with a client certificate: expected 200 and a call, got %d and %v", rec.Code, controller.calls)
+	}
+}
+
+func TestServer_Events(t *testing.T) {
+	server := NewServer(testToken, nil)
+	controller := &fakeController{}
+	server.SetController(controller)
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, request(http.MethodGet, EventsPath+"?origin=www.example.com&type=dns_change&limit=5", testToken))
+	var response eventsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil || rec.Code != http.StatusOK || len(response.Events) != 1 {
+		t.Fatalf("GET events = %d %+v, %v", rec.Code, response, err)
+	}
+	if filter := controller.filter; filter.Origin != "www.example.com" || filter.Type != history.TypeDNSChange || filter.Limit != 5 {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, request(http.MethodGet, EventsPath+"?since=yesterday", testToken))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid since: expected 400, got %d", rec.Code)
+	}
+
+	server.SetController(&fakeController{err: gslb.ErrEventHistoryDisabled})
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, request(http.MethodGet, EventsPath, testToken))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("without event history: expected 404, got %d", rec.Code)
+	}
+}
+
+func TestServer_Reload(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewServer(testToken, nil).Handler().ServeHTTP(rec, request(http.MethodPost, ReloadPath, testToken))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("without a reload function: expected 501, got %d", rec.Code)
+	}
+
+	var reloadErr error
+	reloads := 0
+	server := NewServer(testToken, func(ctx context.Context) error {
+		reloads++
+		return reloadErr
+	})
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, request(http.MethodPost, ReloadPath, testToken))
+	if rec.Code != http.StatusAccepted || reloads != 1 {
+		t.Errorf("expected 202 after one reload, got %d after %d", rec.Code, reloads)
+	}
+
+	reloadErr = errors.New("invalid config")
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, request(http.MethodPost, ReloadPath, testToken))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("failed reload: expected 422, got %d", rec.Code)
+	}
+}
+
+func TestServer_RuntimeFile(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewServer(testToken, func(ctx context.Context) error { return nil }).Handler().ServeHTTP(rec, request(http.MethodDelete, NotificationsPath+"/team", testToken))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("without a runtime file: expected 501, got %d", rec.Code)
+	}
+
+	path := filepath.Join(t.TempDir(), "runtime.json")
+	var reloadErr error
+	reloads := 0
+	server := NewServer(testToken, func(ctx context.Context) error {
+		reloads++
+		return reloadErr
+	})
+	server.SetRuntimeFile(path)
+	send := func(method, target, body string) int {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+testToken)
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	origin := `{"name": "api.example.com", "record_type": "A", "health_check": {"type": "icmp"}, "priority_levels": [{"priority": 0, "ips": ["192.0.2.2"]}]}`
+	if code := send(http.MethodPost, OriginsPath, origin); code != http.StatusAccepted || reloads != 1 {
+		t.Fatalf("add origin: expected 202 after one reload, got %d after %d", code, reloads)
+	}
+	if code := send(http.MethodPost, OriginsPath, origin); code != http.StatusConflict {
+		t.Errorf("add the same origin: expected 409, got %d", code)
+	}
+	if code := send(http.MethodPost, NotificationsPath, `{"type": "slack", "name": "team", "webhook_url": "${TEAM_WEBHOOK_URL}"}`); code != http.StatusAccepted {
+		t.Errorf("add notification: expected 202, got %d", code)
+	}
+	if code := send(http.MethodPost, NotificationsPath, `{"type": "slack", "webhook_url": "https://hooks.slack.com/x"}`); code != http.StatusBadRequest {
+		t.Errorf("add notification without a name: expected 400, got %d", code)
+	}
+	if code := send(http.MethodPost, NotificationsPath, `{"type": "slack", "name": "x", "unknown": true}`); code != http.StatusBadRequest {
+		t.Errorf("add notification with an unknown field: expected 400, got %d", code)
+	}
+	if code := send(http.MethodPost, NotificationsPath, `{"type": "exec", "name": "x", "exec": {"command": ["/bin/sh", "-c", "id"]}}`); code != http.StatusBadRequest || reloads != 2 {
+		t.Errorf("add exec notification: expected 400 without a reload, got %d after %d reloads", code, reloads)
+	}
+
+	// A change the daemon cannot apply is not kept
+	reloadErr = errors.New("invalid config")
+	if code := send(http.MethodDelete, OriginsPath+"/example.com/api.example.com/A", ""); code != http.StatusUnprocessableEntity {
+		t.Errorf("failed reload: expected 422, got %d", code)
+	}
+	f, err := config.ReadRuntimeFile(path)
+	if err != nil {
+		t.Fatalf("ReadRuntimeFile() error = %v", err)
+	}
+	if len(f.Origins) != 1 || len(f.Notifications) != 1 || f.Notifications[0].WebhookURL != "${TEAM_WEBHOOK_URL}" {
+		t.Errorf("Expected the runtime file to be restored, got %+v", f)
+	}
+
+	reloadErr = nil
+	if code := send(http.MethodDelete, OriginsPath+"/example.com/api.example.com/A", ""); code != http.StatusAccepted {
+		t.Errorf("remove origin: expected 202, got %d", code)
+	}
+	if code := send(http.MethodDelete, OriginsPath+"/example.com/www.example.com/A", ""); code != http.StatusNotFound {
+		t.Errorf("remove an origin of the config file: expected 404, got %d", code)
+	}
+	if code := send(http.MethodDelete, NotificationsPath+"/team", ""); code != http.StatusAccepted {
+		t.Errorf("remove notification: expected 202, got %d", code)
+	}
+	if f, err := config.ReadRuntimeFile(path); err != nil || len(f.Origins) != 0 || len(f.Notifications) != 0 {
+		t.Errorf("Expected an empty runtime file, got %+v (%v)", f, err)
+	}
+}
@@ -0,0 +1,100 @@
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/history"
+)
+
+// Client calls the admin API of a running daemon.
+type Client struct {
+	// BaseURL is the URL of the server, e.g. http://127.0.0.1:8082
+	BaseURL string
+	// Token is sent as the bearer token of every request, unless it is empty
+	Token string
+	// HTTPClient sends the requests (default: http.DefaultClient)
+	HTTPClient *http.Client
+}
+
+// Origins returns the reports of every origin.
+func (c *Client) Origins(ctx context.Context) ([]gslb.OriginReport, error) {
+	var response originsResponse
+	if err := c.do(ctx, http.MethodGet, OriginsPath, &response); err != nil {
+		return nil, err
+	}
+	return response.Origins, nil
+}
+
+// Events returns the recorded events selected by query, which takes the
+// origin, type, since, until and limit parameters.
+func (c *Client) Events(ctx context.Context, query url.Values) ([]history.Event, error) {
+	path := EventsPath
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	var response eventsResponse
+	if err := c.do(ctx, http.MethodGet, path, &response); err != nil {
+		return nil, err
+	}
+	return response.Events, nil
+}
+
+// Act applies action, such as ActionFailover, to an origin on behalf of by
+// and returns the report of the origin afterwards. An empty by leaves it to
+// the server.
+func (c *Client) Act(ctx context.Context, zone, name, recordType, action, by string) (gslb.OriginReport, error) {
+	path := OriginsPath + "/" + url.PathEscape(zone) + "/" + url.PathEscape(name) + "/" + url.PathEscape(recordType) + "/" + url.PathEscape(action)
+	if by != "" {
+		path += "?" + url.Values{"by": {by}}.Encode()
+	}
+	var response originResponse
+	if err := c.do(ctx, http.MethodPost, path, &response); err != nil {
+		return gslb.OriginReport{}, err
+	}
+	return response.Origin, nil
+}
+
+// Reload makes the daemon load its configuration again.
+func (c *Client) Reload(ctx context.Context) error {
+	var response statusResponse
+	return c.do(ctx, http.MethodPost, ReloadPath, &response)
+}
+
+// do sends a request to path and decodes the JSON body of the answer into
+// response, or returns the error the server answered with.
+func (c *Client) do(ctx context.Context, method, path string, response any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		var failure errorResponse
+		if json.Unmarshal(body, &failure) == nil && failure.Error != "" {
+			return fmt.Errorf("admin API returned %s: %s", resp.Status, failure.Error)
+		}
+		return fmt.Errorf("admin API returned %s: %s", resp.Status, body)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("failed to decode admin API response: %w", err)
+	}
+	return nil
+}
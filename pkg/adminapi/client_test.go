@@ -0,0 +1,54 @@
+package adminapi
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+)
+
+func TestClient(t *testing.T) {
+	controller := &fakeController{}
+	reloads := 0
+	server := NewServer(testToken, func(ctx context.Context) error {
+		reloads++
+		return nil
+	})
+	server.SetController(controller)
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	client := &Client{BaseURL: ts.URL, Token: testToken, HTTPClient: ts.Client()}
+	ctx := context.Background()
+
+	origins, err := client.Origins(ctx)
+	if err != nil || len(origins) != 1 || origins[0].Name != "www.example.com" {
+		t.Errorf("Origins() = %+v, %v", origins, err)
+	}
+	events, err := client.Events(ctx, url.Values{"origin": {"www.example.com"}})
+	if err != nil || len(events) != 1 || controller.filter.Origin != "www.example.com" {
+		t.Errorf("Events() = %+v, %v with filter %+v", events, err, controller.filter)
+	}
+	report, err := client.Act(ctx, "example.com", "www.example.com", "A", ActionFailover, "alice smith")
+	if err != nil || report.Name != "www.example.com" {
+		t.Errorf("Act() = %+v, %v", report, err)
+	}
+	if got := controller.calls[0]; got != "failover example.com/www.example.com/A by alice smith" {
+		t.Errorf("unexpected call %q", got)
+	}
+	if err := client.Reload(ctx); err != nil || reloads != 1 {
+		t.Errorf("Reload() = %v after %d reloads", err, reloads)
+	}
+
+	// The error of the server is returned as is
+	controller.err = gslb.ErrAlreadyHeld
+	if _, err := client.Act(ctx, "example.com", "www.example.com", "A", ActionPause, ""); err == nil || !strings.Contains(err.Error(), gslb.ErrAlreadyHeld.Error()) {
+		t.Errorf("expected the error of the server, got %v", err)
+	}
+	client.Token = "wrong"
+	if _, err := client.Origins(ctx); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected 401 with a wrong token, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+// Package apiauth checks the bearer tokens of the admin and gRPC APIs and the
+// roles they grant, so that some credentials can only read the state of the
+// daemon or report the results of remote probers.
+package apiauth
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Role is what a credential may do.
+type Role string
+
+const (
+	// RoleViewer may list the origins and the events.
+	RoleViewer Role = "viewer"
+	// RoleOperator may also change the origins and reload the configuration.
+	RoleOperator Role = "operator"
+	// RoleProber may only register as a remote prober and report its
+	// results.
+	RoleProber Role = "prober"
+)
+
+// ParseRole returns the role named s.
+func ParseRole(s string) (Role, error) {
+	switch role := Role(s); role {
+	case RoleViewer, RoleOperator, RoleProber:
+		return role, nil
+	default:
+		return "", fmt.Errorf("unknown role %q, must be %s, %s or %s", s, RoleViewer, RoleOperator, RoleProber)
+	}
+}
+
+// Allows reports whether the role may do what required grants. Operators
+// may do everything, the other roles only what they grant themselves.
+func (r Role) Allows(required Role) bool {
+	return r == RoleOperator || r == required
+}
+
+// Credential is who made a request, as given by its token.
+type Credential struct {
+	// Name identifies the token, e.g. "noc"; it is empty for the token
+	// without a name and for requests authenticated by a certificate alone.
+	Name string
+	Role Role
+}
+
+// Tokens maps bearer tokens to the credentials they grant.
+type Tokens struct {
+	entries []entry
+}
+
+type entry struct {
+	token      []byte
+	credential Credential
+}
+
+// Add accepts token as the bearer token of credential.
+func (t *Tokens) Add(token string, credential Credential) {
+	t.entries = append(t.entries, entry{token: []byte(token), credential: credential})
+}
+
+// Empty reports whether no token was added, i.e. requests need no token.
+func (t *Tokens) Empty() bool {
+	return len(t.entries) == 0
+}
+
+// Authenticate returns the credential of the bearer token in the
+// Authorization header of r. Without tokens every request is an operator.
+func (t *Tokens) Authenticate(r *http.Request) (Credential, bool) {
+	if t.Empty() {
+		return Credential{Role: RoleOperator}, true
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return Credential{}, false
+	}
+	// Compare with every token, so that the time taken does not tell which
+	// one matched
+	var credential Credential
+	found := false
+	for _, e := range t.entries {
+		if subtle.ConstantTimeCompare([]byte(got), e.token) == 1 && !found {
+			credential, found = e.credential, true
+		}
+	}
+	return credential, found
+}
+
+type credentialKey struct{}
+
+// WithCredential returns a copy of ctx carrying credential.
+func WithCredential(ctx context.Context, credential Credential) context.Context {
+	return context.WithValue(ctx, credentialKey{}, credential)
+}
+
+// CredentialFrom returns the credential carried by ctx, if any.
+func CredentialFrom(ctx context.Context) (Credential, bool) {
+	credential, ok := ctx.Value(credentialKey{}).(Credential)
+	return credential, ok
+}
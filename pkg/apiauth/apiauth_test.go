@@ -0,0 +1,65 @@
+package apiauth
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTokens_Authenticate(t *testing.T) {
+	var tokens Tokens
+	if credential, ok := tokens.Authenticate(httptest.NewRequest("GET", "/", nil)); !ok || credential.Role != RoleOperator {
+		t.Errorf("without tokens: got %+v, %v, want an operator", credential, ok)
+	}
+
+	tokens.Add("admin-token", Credential{Role: RoleOperator})
+	tokens.Add("noc-token", Credential{Name: "noc", Role: RoleViewer})
+	tests := []struct {
+		header string
+		want   Credential
+		ok     bool
+	}{
+		{header: "Bearer admin-token", want: Credential{Role: RoleOperator}, ok: true},
+		{header: "Bearer noc-token", want: Credential{Name: "noc", Role: RoleViewer}, ok: true},
+		{header: "Bearer wrong"},
+		{header: "noc-token"},
+		{header: ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		if got, ok := tokens.Authenticate(req); got != tt.want || ok != tt.ok {
+			t.Errorf("Authenticate(%q) = %+v, %v, want %+v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRole(t *testing.T) {
+	if !RoleOperator.Allows(RoleOperator) || !RoleOperator.Allows(RoleViewer) || !RoleViewer.Allows(RoleViewer) {
+		t.Error("expected operators to do everything and viewers to read")
+	}
+	if RoleViewer.Allows(RoleOperator) {
+		t.Error("expected viewers not to operate")
+	}
+	if !RoleOperator.Allows(RoleProber) || !RoleProber.Allows(RoleProber) || RoleProber.Allows(RoleViewer) || RoleViewer.Allows(RoleProber) {
+		t.Error("expected probers only to report, along with operators")
+	}
+	if role, err := ParseRole("viewer"); err != nil || role != RoleViewer {
+		t.Errorf("ParseRole(viewer) = %q, %v", role, err)
+	}
+	if _, err := ParseRole("admin"); err == nil {
+		t.Error("expected an error for an unknown role")
+	}
+}
+
+func TestCredentialContext(t *testing.T) {
+	if _, ok := CredentialFrom(context.Background()); ok {
+		t.Error("expected no credential in an empty context")
+	}
+	ctx := WithCredential(context.Background(), Credential{Name: "noc", Role: RoleViewer})
+	if credential, ok := CredentialFrom(ctx); !ok || credential.Name != "noc" {
+		t.Errorf("CredentialFrom() = %+v, %v", credential, ok)
+	}
+}
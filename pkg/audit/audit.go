@@ -0,0 +1,140 @@
+// Package audit records every DNS provider API call as a structured event.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Results of an audited call.
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+// Event describes a single API call.
+type Event struct {
+	Time       time.Time `json:"time"`
+	Actor      string    `json:"actor,omitempty"`
+	Operation  string    `json:"operation"`
+	ZoneID     string    `json:"zone_id"`
+	Record     string    `json:"record,omitempty"`
+	RecordType string    `json:"record_type,omitempty"`
+	RecordID   string    `json:"record_id,omitempty"`
+	OldContent []string  `json:"old_content,omitempty"`
+	NewContent []string  `json:"new_content,omitempty"`
+	// Reason is the GSLB state that caused the change, e.g. "failover".
+	Reason    string `json:"reason,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+	Result    string `json:"result"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Sink receives audit events.
+type Sink interface {
+	Write(ctx context.Context, event Event) error
+}
+
+// FileSink appends events as JSON lines to a file that is only ever appended
+// to. The file is opened for each event, so a sink holds no file open and
+// needs no closing when the service is replaced.
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileSink checks that path can be opened for appending, creating it if
+// needed.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &FileSink{path: path}, nil
+}
+
+// Write appends event as one JSON line.
+func (s *FileSink) Write(ctx context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write audit event: %w", err)
+	}
+	return file.Close()
+}
+
+// WebhookSink posts each event as JSON to a webhook URL.
+type WebhookSink struct {
+	webhookURL string
+	httpClient *http.Client
+}
+
+// NewWebhookSink creates a sink posting to webhookURL.
+func NewWebhookSink(webhookURL string) *WebhookSink {
+	return &WebhookSink{
+		webhookURL: webhookURL,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Write posts event to the webhook.
+func (s *WebhookSink) Write(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.webhookURL, bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create audit webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send audit event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("audit webhook returned status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// MultiSink writes every event to all of its sinks.
+type MultiSink []Sink
+
+// Write writes event to every sink and joins their errors.
+func (m MultiSink) Write(ctx context.Context, event Event) error {
+	var errs error
+	for _, sink := range m {
+		if err := sink.Write(ctx, event); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileSink_AppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	for i := 0; i < 2; i++ {
+		// Reopening must append rather than truncate
+		sink, err := NewFileSink(path)
+		if err != nil {
+			t.Fatalf("NewFileSink returned error: %v", err)
+		}
+		event := Event{Time: time.Now(), Operation: "batch", ZoneID: "zone", Record: "www.example.com", NewContent: []string{"192.0.2.1"}, Result: ResultSuccess}
+		if err := sink.Write(context.Background(), event); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open audit log: %v", err)
+	}
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("line %d is not a JSON event: %v", lines+1, err)
+		}
+		if event.Operation != "batch" || event.NewContent[0] != "192.0.2.1" {
+			t.Errorf("unexpected event %+v", event)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("expected 2 lines, got %d", lines)
+	}
+}
+
+func TestWebhookSink_PostsEvent(t *testing.T) {
+	var received Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected content type %s", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("failed to decode event: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL)
+	if err := sink.Write(context.Background(), Event{Operation: "delete", RecordID: "record-1", Result: ResultError, Error: "boom"}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if received.Operation != "delete" || received.RecordID != "record-1" || received.Error != "boom" {
+		t.Errorf("unexpected event %+v", received)
+	}
+}
+
+func TestWebhookSink_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if err := NewWebhookSink(server.URL).Write(context.Background(), Event{}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+type failingSink struct{ calls int }
+
+func (f *failingSink) Write(ctx context.Context, event Event) error {
+	f.calls++
+	return errors.New("unavailable")
+}
+
+func TestMultiSink_WritesToAllSinks(t *testing.T) {
+	first, second := &failingSink{}, &failingSink{}
+	if err := (MultiSink{first, second}).Write(context.Background(), Event{}); err == nil {
+		t.Fatal("expected joined error")
+	}
+	if first.calls != 1 || second.calls != 1 {
+		t.Errorf("expected both sinks to be called, got %d and %d", first.calls, second.calls)
+	}
+}
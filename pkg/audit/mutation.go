@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Actors of a mutation.
+const (
+	// ActorAuto is a change the service made on its own, e.g. after a health check.
+	ActorAuto = "auto"
+	// ActorManual is a change an operator asked for, e.g. a snapshot import.
+	ActorManual = "manual"
+)
+
+// Mutation describes a change of the records published for an origin.
+type Mutation struct {
+	Time time.Time `json:"time"`
+	// Actor is ActorAuto or ActorManual, and Instance names the service
+	// instance that made the change.
+	Actor      string   `json:"actor"`
+	Instance   string   `json:"instance,omitempty"`
+	Origin     string   `json:"origin"`
+	Zone       string   `json:"zone"`
+	RecordType string   `json:"record_type"`
+	Before     []string `json:"before"`
+	After      []string `json:"after"`
+	// State is the GSLB state written to the records, e.g. "failover", and
+	// Reason explains what triggered the change.
+	State  string `json:"state,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// MutationLog appends mutations as JSON lines to a file that is only ever
+// appended to. The file is opened for each write, so it may be rotated by
+// renaming it and several logs of the same process may share it across
+// reloads.
+type MutationLog struct {
+	path string
+
+	mu sync.Mutex
+}
+
+// NewMutationLog returns a log appending to path, creating it if needed.
+func NewMutationLog(path string) (*MutationLog, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mutation log: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to open mutation log: %w", err)
+	}
+	return &MutationLog{path: path}, nil
+}
+
+// Write appends mutation as one JSON line.
+func (l *MutationLog) Write(mutation Mutation) error {
+	line, err := json.Marshal(mutation)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mutation: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mutation log: %w", err)
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write mutation: %w", err)
+	}
+	return file.Close()
+}
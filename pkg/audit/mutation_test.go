@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMutationLog_AppendsAcrossRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mutations.jsonl")
+	mutations, err := NewMutationLog(path)
+	if err != nil {
+		t.Fatalf("NewMutationLog returned error: %v", err)
+	}
+
+	first := Mutation{Time: time.Now(), Actor: ActorAuto, Origin: "www.example.com", Before: []string{"192.0.2.1"}, After: []string{"198.51.100.1"}, Result: ResultSuccess}
+	if err := mutations.Write(first); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	// A rotated file is recreated by the next write
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("failed to rotate: %v", err)
+	}
+	second := Mutation{Time: time.Now(), Actor: ActorManual, Origin: "www.example.com", After: []string{"192.0.2.1"}, Result: ResultError, Error: "boom"}
+	if err := mutations.Write(second); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	for file, want := range map[string]Mutation{path + ".1": first, path: second} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file, err)
+		}
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		if len(lines) != 1 {
+			t.Fatalf("expected one line in %s, got %q", file, data)
+		}
+		var got Mutation
+		if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+			t.Fatalf("line is not a JSON mutation: %v", err)
+		}
+		if got.Actor != want.Actor || got.Result != want.Result || got.Error != want.Error || len(got.After) != 1 || got.After[0] != want.After[0] {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+	}
+}
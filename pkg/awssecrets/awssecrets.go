@@ -0,0 +1,237 @@
+// Package awssecrets resolves configuration values stored in AWS Secrets
+// Manager and SSM Parameter Store.
+package awssecrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/cockroachdb/errors"
+)
+
+// Reference schemes understood by Resolver.
+const (
+	SchemeSecretsManager = "aws-sm://"
+	SchemeSSM            = "ssm://"
+)
+
+var (
+	// ErrInvalidReference is returned for references with an unknown scheme or no name.
+	ErrInvalidReference = errors.New("invalid AWS secret reference")
+	// ErrNoSecretString is returned for secrets that only have a binary value.
+	ErrNoSecretString = errors.New("secret has no string value")
+	// ErrSecretKeyNotFound is returned when a #key selector does not match the secret's JSON.
+	ErrSecretKeyNotFound = errors.New("key not found in secret")
+	// ErrNoRegion is returned when a name is resolved without a configured AWS region.
+	ErrNoRegion = errors.New("no AWS region configured")
+)
+
+// IsReference reports whether value is an aws-sm:// or ssm:// reference.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, SchemeSecretsManager) || strings.HasPrefix(value, SchemeSSM)
+}
+
+// Resolver fetches the values behind aws-sm:// and ssm:// references. It
+// speaks the JSON protocol of both services directly, signed with the
+// default AWS credential chain, and caches each reference for its lifetime.
+type Resolver struct {
+	httpClient  *http.Client
+	credentials aws.CredentialsProvider
+	region      string
+	signer      *v4.Signer
+	// endpoint returns the API URL of service in region.
+	endpoint func(service, region string) string
+	cache    map[string]string
+}
+
+// NewResolver returns a resolver that uses the default AWS configuration
+// (environment, shared config, ECS task role or instance profile).
+func NewResolver(ctx context.Context) (*Resolver, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load AWS configuration")
+	}
+	return newResolver(cfg.Credentials, cfg.Region, defaultEndpoint), nil
+}
+
+func newResolver(credentials aws.CredentialsProvider, region string, endpoint func(service, region string) string) *Resolver {
+	return &Resolver{
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		credentials: credentials,
+		region:      region,
+		signer:      v4.NewSigner(),
+		endpoint:    endpoint,
+		cache:       make(map[string]string),
+	}
+}
+
+func defaultEndpoint(service, region string) string {
+	return fmt.Sprintf("https://%s.%s.amazonaws.com/", service, region)
+}
+
+// Resolve returns the value behind ref.
+//
+//   - aws-sm://<secret-id>[#<key>] reads the secret's string value. The
+//     secret ID may be a name or an ARN. With #key the secret must be a JSON
+//     object and the value of key is returned.
+//   - ssm://<name> reads the parameter, decrypting SecureString values.
+//     Hierarchical names keep their leading slash: ssm:///gslb/api-token.
+//
+// ARNs are resolved in their own region; names in the default region.
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	if value, ok := r.cache[ref]; ok {
+		return value, nil
+	}
+
+	var value string
+	var err error
+	switch {
+	case strings.HasPrefix(ref, SchemeSecretsManager):
+		value, err = r.secretValue(ctx, strings.TrimPrefix(ref, SchemeSecretsManager))
+	case strings.HasPrefix(ref, SchemeSSM):
+		value, err = r.parameterValue(ctx, strings.TrimPrefix(ref, SchemeSSM))
+	default:
+		err = errors.Wrapf(ErrInvalidReference, "%s", ref)
+	}
+	if err != nil {
+		return "", err
+	}
+	r.cache[ref] = value
+	return value, nil
+}
+
+func (r *Resolver) secretValue(ctx context.Context, target string) (string, error) {
+	secretID, key, _ := strings.Cut(target, "#")
+	if secretID == "" {
+		return "", errors.Wrapf(ErrInvalidReference, "%s%s", SchemeSecretsManager, target)
+	}
+
+	var out struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := r.call(ctx, "secretsmanager", "secretsmanager.GetSecretValue", regionOf(secretID, r.region), map[string]any{
+		"SecretId": secretID,
+	}, &out); err != nil {
+		return "", errors.Wrapf(err, "failed to read secret %s", secretID)
+	}
+	if out.SecretString == nil {
+		return "", errors.Wrapf(ErrNoSecretString, "%s", secretID)
+	}
+	if key == "" {
+		return *out.SecretString, nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(*out.SecretString), &fields); err != nil {
+		return "", errors.Wrapf(err, "secret %s is not a JSON object", secretID)
+	}
+	field, ok := fields[key]
+	if !ok {
+		return "", errors.Wrapf(ErrSecretKeyNotFound, "%s#%s", secretID, key)
+	}
+	if s, ok := field.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(field), nil
+}
+
+func (r *Resolver) parameterValue(ctx context.Context, name string) (string, error) {
+	if name == "" {
+		return "", errors.Wrapf(ErrInvalidReference, "%s", SchemeSSM)
+	}
+
+	var out struct {
+		Parameter struct {
+			Value string `json:"Value"`
+		} `json:"Parameter"`
+	}
+	if err := r.call(ctx, "ssm", "AmazonSSM.GetParameter", regionOf(name, r.region), map[string]any{
+		"Name":           name,
+		"WithDecryption": true,
+	}, &out); err != nil {
+		return "", errors.Wrapf(err, "failed to read SSM parameter %s", name)
+	}
+	return out.Parameter.Value, nil
+}
+
+// call invokes a JSON 1.1 protocol operation and decodes its result into out.
+func (r *Resolver) call(ctx context.Context, service, target, region string, in, out any) error {
+	if region == "" {
+		return errors.WithStack(ErrNoRegion)
+	}
+	body, err := json.Marshal(in)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint(service, region), bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+
+	creds, err := r.credentials.Retrieve(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve AWS credentials")
+	}
+	hash := sha256.Sum256(body)
+	if err := r.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), service, region, time.Now()); err != nil {
+		return errors.Wrap(err, "failed to sign AWS request")
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return apiError(resp.StatusCode, data)
+	}
+	return errors.WithStack(json.Unmarshal(data, out))
+}
+
+// apiError turns an AWS JSON error body into an error naming the exception.
+func apiError(status int, data []byte) error {
+	// Field names are matched case-insensitively, covering both "message" and "Message"
+	var body struct {
+		Type    string `json:"__type"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(data, &body)
+	// __type may be prefixed with a namespace, e.g. "com.amazon...#ResourceNotFoundException"
+	if i := strings.LastIndex(body.Type, "#"); i >= 0 {
+		body.Type = body.Type[i+1:]
+	}
+	if body.Type == "" {
+		return errors.Newf("AWS API returned status %d", status)
+	}
+	return errors.Newf("%s: %s (status %d)", body.Type, body.Message, status)
+}
+
+// regionOf returns the region of an ARN, or fallback for plain names.
+func regionOf(id, fallback string) string {
+	if !strings.HasPrefix(id, "arn:") {
+		return fallback
+	}
+	parts := strings.SplitN(id, ":", 5)
+	if len(parts) < 5 || parts[3] == "" {
+		return fallback
+	}
+	return parts[3]
+}
@@ -0,0 +1,115 @@
+package awssecrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/cockroachdb/errors"
+)
+
+func newTestResolver(t *testing.T, handler http.HandlerFunc) (*Resolver, *[]string) {
+	t.Helper()
+	var regions []string
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	creds := credentials.NewStaticCredentialsProvider("AKID", "SECRET", "")
+	return newResolver(creds, "ap-northeast-1", func(service, region string) string {
+		regions = append(regions, service+"/"+region)
+		return server.URL
+	}), &regions
+}
+
+func TestResolveSecretsManager(t *testing.T) {
+	calls := 0
+	resolver, regions := newTestResolver(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if got := r.Header.Get("X-Amz-Target"); got != "secretsmanager.GetSecretValue" {
+			t.Errorf("unexpected target %q", got)
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			t.Errorf("request is not signed: %q", r.Header.Get("Authorization"))
+		}
+		var in map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		switch in["SecretId"] {
+		case "gslb/token":
+			_, _ = w.Write([]byte(`{"SecretString":"plain-token"}`))
+		case "arn:aws:secretsmanager:us-east-1:123456789012:secret:gslb":
+			_, _ = w.Write([]byte(`{"SecretString":"{\"token\":\"json-token\",\"port\":8080}"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","Message":"not found"}`))
+		}
+	})
+	ctx := context.Background()
+
+	value, err := resolver.Resolve(ctx, "aws-sm://gslb/token")
+	if err != nil || value != "plain-token" {
+		t.Fatalf("expected plain-token, got %q (%v)", value, err)
+	}
+	if _, err := resolver.Resolve(ctx, "aws-sm://gslb/token"); err != nil || calls != 1 {
+		t.Errorf("expected cached value, got %d calls (%v)", calls, err)
+	}
+
+	value, err = resolver.Resolve(ctx, "aws-sm://arn:aws:secretsmanager:us-east-1:123456789012:secret:gslb#token")
+	if err != nil || value != "json-token" {
+		t.Fatalf("expected json-token, got %q (%v)", value, err)
+	}
+	if got := (*regions)[len(*regions)-1]; got != "secretsmanager/us-east-1" {
+		t.Errorf("expected the ARN's region, got %s", got)
+	}
+	if value, _ := resolver.Resolve(ctx, "aws-sm://arn:aws:secretsmanager:us-east-1:123456789012:secret:gslb#port"); value != "8080" {
+		t.Errorf("expected non-string values to be formatted, got %q", value)
+	}
+	if _, err := resolver.Resolve(ctx, "aws-sm://arn:aws:secretsmanager:us-east-1:123456789012:secret:gslb#missing"); !errors.Is(err, ErrSecretKeyNotFound) {
+		t.Errorf("expected ErrSecretKeyNotFound, got %v", err)
+	}
+
+	_, err = resolver.Resolve(ctx, "aws-sm://missing")
+	if err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException: not found") {
+		t.Errorf("expected the AWS error to be reported, got %v", err)
+	}
+}
+
+func TestResolveSSM(t *testing.T) {
+	resolver, regions := newTestResolver(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Amz-Target"); got != "AmazonSSM.GetParameter" {
+			t.Errorf("unexpected target %q", got)
+		}
+		var in map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		if in["Name"] != "/gslb/webhook" || in["WithDecryption"] != true {
+			t.Errorf("unexpected request %v", in)
+		}
+		_, _ = w.Write([]byte(`{"Parameter":{"Name":"/gslb/webhook","Value":"https://hooks.example.com/x"}}`))
+	})
+
+	value, err := resolver.Resolve(context.Background(), "ssm:///gslb/webhook")
+	if err != nil || value != "https://hooks.example.com/x" {
+		t.Fatalf("expected webhook URL, got %q (%v)", value, err)
+	}
+	if got := (*regions)[0]; got != "ssm/ap-northeast-1" {
+		t.Errorf("expected the default region, got %s", got)
+	}
+}
+
+func TestResolveInvalidReference(t *testing.T) {
+	resolver, _ := newTestResolver(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected request")
+	})
+	for _, ref := range []string{"aws-sm://", "aws-sm://#key", "ssm://", "vault://x"} {
+		if _, err := resolver.Resolve(context.Background(), ref); !errors.Is(err, ErrInvalidReference) {
+			t.Errorf("%s: expected ErrInvalidReference, got %v", ref, err)
+		}
+	}
+
+	resolver.region = ""
+	if _, err := resolver.Resolve(context.Background(), "ssm://name"); !errors.Is(err, ErrNoRegion) {
+		t.Errorf("expected ErrNoRegion, got %v", err)
+	}
+}
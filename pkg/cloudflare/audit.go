@@ -0,0 +1,42 @@
+package cloudflare
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/audit"
+)
+
+// WithAuditSink records every API call made by the client to sink. actor
+// identifies this instance in the events.
+func WithAuditSink(sink audit.Sink, actor string) ClientOption {
+	return func(o *clientOptions) {
+		o.auditSink = sink
+		o.auditActor = actor
+	}
+}
+
+// audit completes event with the call's outcome and writes it to the audit
+// sink. Failing to write an event is logged but never fails the call.
+func (c *DNSClient) audit(ctx context.Context, event audit.Event, start time.Time, err error) {
+	if c.auditSink == nil {
+		return
+	}
+
+	event.Time = start.UTC()
+	event.Actor = c.auditActor
+	event.ZoneID = c.zoneID
+	event.Reason = recordMetadataFrom(ctx).State
+	event.LatencyMs = time.Since(start).Milliseconds()
+	event.Result = audit.ResultSuccess
+	if err != nil {
+		event.Result = audit.ResultError
+		event.Error = err.Error()
+	}
+
+	// The trail must be written even if the call was cancelled
+	if writeErr := c.auditSink.Write(context.WithoutCancel(ctx), event); writeErr != nil {
+		log.Printf("Failed to write audit event for %s %s: %v", event.Operation, event.Record, writeErr)
+	}
+}
@@ -0,0 +1,61 @@
+package cloudflare
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/audit"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+type recordingSink struct {
+	events []audit.Event
+}
+
+func (r *recordingSink) Write(ctx context.Context, event audit.Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestDNSClientAuditsAPICalls(t *testing.T) {
+	api := &fakeCloudflareAPI{
+		listResp: []dns.RecordResponse{
+			{ID: "record-1", Name: "example.com", Type: dns.RecordResponseTypeA, Content: "198.51.100.1"},
+		},
+	}
+	sink := &recordingSink{}
+	client := &DNSClient{api: api, zoneID: "zone", ttl: 60, auditSink: sink, auditActor: "gslb-1"}
+
+	ctx := WithRecordMetadata(context.Background(), RecordMetadata{State: "failover"})
+	if err := client.ReplaceRecords(ctx, "example.com", "A", []string{"203.0.113.10"}); err != nil {
+		t.Fatalf("ReplaceRecords returned error: %v", err)
+	}
+
+	if len(sink.events) != 2 {
+		t.Fatalf("expected list and batch events, got %+v", sink.events)
+	}
+	list, batch := sink.events[0], sink.events[1]
+	if list.Operation != "list" || list.Record != "example.com" {
+		t.Errorf("unexpected list event %+v", list)
+	}
+	if batch.Operation != "batch" || batch.Actor != "gslb-1" || batch.ZoneID != "zone" || batch.Reason != "failover" || batch.Result != audit.ResultSuccess {
+		t.Errorf("unexpected batch event %+v", batch)
+	}
+	if len(batch.OldContent) != 1 || batch.OldContent[0] != "198.51.100.1" || len(batch.NewContent) != 1 || batch.NewContent[0] != "203.0.113.10" {
+		t.Errorf("unexpected batch contents old=%v new=%v", batch.OldContent, batch.NewContent)
+	}
+}
+
+func TestDNSClientAuditsFailures(t *testing.T) {
+	api := &fakeCloudflareAPI{deleteErr: errors.New("delete failed")}
+	sink := &recordingSink{}
+	client := &DNSClient{api: api, zoneID: "zone", auditSink: sink}
+
+	if err := client.DeleteDNSRecord(context.Background(), "record-1"); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(sink.events) != 1 || sink.events[0].Result != audit.ResultError || sink.events[0].RecordID != "record-1" || sink.events[0].Error == "" {
+		t.Errorf("unexpected events %+v", sink.events)
+	}
+}
@@ -0,0 +1,95 @@
+package cloudflare
+
+import (
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+// recordBinding limits a client to the records it is bound to, so that other
+// records sharing the same name and type are never listed, changed or deleted.
+type recordBinding struct {
+	ids     map[string]struct{}
+	comment string
+}
+
+// WithRecordBinding binds the client to the records with the given IDs, the
+// records whose comment contains comment, and the records the client created
+// itself. Every other record is invisible to the client. Bound records whose
+// ID is pinned are updated in place instead of being replaced, and comment is
+// added to the comment of every record the client writes.
+func WithRecordBinding(ids []string, comment string) ClientOption {
+	return func(o *clientOptions) {
+		binding := &recordBinding{ids: make(map[string]struct{}, len(ids)), comment: comment}
+		for _, id := range ids {
+			binding.ids[id] = struct{}{}
+		}
+		o.binding = binding
+	}
+}
+
+// owns reports whether record is one of the client's records. Without a
+// binding the client owns every record.
+func (b *recordBinding) owns(record dns.RecordResponse) bool {
+	if b == nil || b.pinned(record.ID) || IsManagedRecord(record) {
+		return true
+	}
+	return b.comment != "" && strings.Contains(record.Comment, b.comment)
+}
+
+func (b *recordBinding) pinned(id string) bool {
+	if b == nil {
+		return false
+	}
+	_, ok := b.ids[id]
+	return ok
+}
+
+func (b *recordBinding) filter(records []dns.RecordResponse) []dns.RecordResponse {
+	if b == nil {
+		return records
+	}
+	owned := make([]dns.RecordResponse, 0, len(records))
+	for _, record := range records {
+		if b.owns(record) {
+			owned = append(owned, record)
+		}
+	}
+	return owned
+}
+
+// decorate appends the binding comment to a record comment.
+func (b *recordBinding) decorate(comment string) string {
+	if b == nil || b.comment == "" {
+		return comment
+	}
+	return comment + "; " + b.comment
+}
+
+// recordPatch rewrites an existing record to new content.
+type recordPatch struct {
+	record  dns.RecordResponse
+	content string
+}
+
+// reusePinned turns the deletion of pinned records into in-place updates to
+// missing contents, so that pinned record IDs survive a change of IPs. It
+// returns the patches along with the contents still to be created and the
+// records still to be deleted.
+func (b *recordBinding) reusePinned(missing []string, recordsToDelete []dns.RecordResponse) ([]recordPatch, []string, []dns.RecordResponse) {
+	if b == nil || len(b.ids) == 0 {
+		return nil, missing, recordsToDelete
+	}
+
+	var patches []recordPatch
+	remaining := make([]dns.RecordResponse, 0, len(recordsToDelete))
+	for _, record := range recordsToDelete {
+		if len(missing) > 0 && b.pinned(record.ID) {
+			patches = append(patches, recordPatch{record: record, content: missing[0]})
+			missing = missing[1:]
+			continue
+		}
+		remaining = append(remaining, record)
+	}
+	return patches, missing, remaining
+}
@@ -0,0 +1,95 @@
+package cloudflare
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestDNSClientRecordBindingIgnoresUnboundRecords(t *testing.T) {
+	api := &fakeCloudflareAPI{
+		listResp: []dns.RecordResponse{
+			{ID: "operator", Name: "example.com", Type: dns.RecordResponseTypeA, Content: "198.51.100.9", Comment: "office VPN"},
+			{ID: "tagged", Name: "example.com", Type: dns.RecordResponseTypeA, Content: "198.51.100.1", Comment: "gslb:api"},
+		},
+	}
+	options := clientOptions{}
+	WithRecordBinding(nil, "gslb:api")(&options)
+	client := &DNSClient{api: api, zoneID: "zone", ttl: 60, binding: options.binding}
+
+	records, err := client.GetDNSRecords(context.Background(), "example.com", "A")
+	if err != nil {
+		t.Fatalf("GetDNSRecords returned error: %v", err)
+	}
+	if len(records) != 1 || records[0].ID != "tagged" {
+		t.Fatalf("expected only the bound record, got %+v", records)
+	}
+
+	if err := client.ReplaceRecords(context.Background(), "example.com", "A", []string{"203.0.113.10"}); err != nil {
+		t.Fatalf("ReplaceRecords returned error: %v", err)
+	}
+	if len(api.deleteCalls) != 1 || api.deleteCalls[0] != "tagged" {
+		t.Errorf("expected only the bound record to be deleted, got %v", api.deleteCalls)
+	}
+	if len(api.createCalls) != 1 || api.createCalls[0].content != "203.0.113.10" {
+		t.Fatalf("expected one created record, got %+v", api.createCalls)
+	}
+	if comment := api.createCalls[0].comment; comment != managedByComment+"; gslb:api" {
+		t.Errorf("expected the binding comment on the created record, got %q", comment)
+	}
+}
+
+func TestDNSClientRecordBindingUpdatesPinnedRecordsInPlace(t *testing.T) {
+	api := &fakeCloudflareAPI{
+		listResp: []dns.RecordResponse{
+			{ID: "pinned", Name: "example.com", Type: dns.RecordResponseTypeA, Content: "198.51.100.1"},
+			{ID: "operator", Name: "example.com", Type: dns.RecordResponseTypeA, Content: "198.51.100.9"},
+		},
+	}
+	options := clientOptions{}
+	WithRecordBinding([]string{"pinned"}, "")(&options)
+	client := &DNSClient{api: api, zoneID: "zone", ttl: 60, binding: options.binding}
+
+	if err := client.ReplaceRecords(context.Background(), "example.com", "A", []string{"203.0.113.10", "203.0.113.11"}); err != nil {
+		t.Fatalf("ReplaceRecords returned error: %v", err)
+	}
+	if len(api.deleteCalls) != 0 {
+		t.Errorf("expected no deletes, got %v", api.deleteCalls)
+	}
+	if len(api.updateCalls) != 1 || api.updateCalls[0].recordID != "pinned" || api.updateCalls[0].content != "203.0.113.10" {
+		t.Errorf("expected the pinned record to be updated in place, got %+v", api.updateCalls)
+	}
+	if len(api.createCalls) != 1 || api.createCalls[0].content != "203.0.113.11" {
+		t.Errorf("expected the remaining IP to be created, got %+v", api.createCalls)
+	}
+}
+
+func TestRecordBindingOwns(t *testing.T) {
+	options := clientOptions{}
+	WithRecordBinding([]string{"pinned"}, "gslb:api")(&options)
+	binding := options.binding
+
+	tests := []struct {
+		name   string
+		record dns.RecordResponse
+		want   bool
+	}{
+		{name: "pinned", record: dns.RecordResponse{ID: "pinned"}, want: true},
+		{name: "comment", record: dns.RecordResponse{ID: "other", Comment: "owner: gslb:api"}, want: true},
+		{name: "managed", record: dns.RecordResponse{ID: "other", Comment: managedByComment + "; state=primary"}, want: true},
+		{name: "unbound", record: dns.RecordResponse{ID: "other", Comment: "office VPN"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := binding.owns(tt.record); got != tt.want {
+				t.Errorf("owns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var unbound *recordBinding
+	if !unbound.owns(dns.RecordResponse{ID: "anything"}) {
+		t.Error("a client without a binding should own every record")
+	}
+}
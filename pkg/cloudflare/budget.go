@@ -0,0 +1,232 @@
+package cloudflare
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/metrics"
+	"github.com/cloudflare/cloudflare-go/v6/option"
+)
+
+// Cloudflare allows each user 1200 API requests per five minutes, across all
+// of their tokens.
+const (
+	APIBudgetLimit  = 1200
+	APIBudgetWindow = 5 * time.Minute
+)
+
+const (
+	// apiBudgetWarnRatio is the share of the budget used at which a warning is logged.
+	apiBudgetWarnRatio = 0.8
+	// apiBudgetRearmRatio is the share the usage has to fall below before the
+	// next warning, so that usage hovering at the threshold logs only once.
+	apiBudgetRearmRatio = 0.6
+)
+
+// apiBudgets holds the budget of every credential for the life of the
+// process, so that the count survives clients being rebuilt on reload.
+var apiBudgets = struct {
+	mu      sync.Mutex
+	budgets map[string]*apiBudget
+}{budgets: make(map[string]*apiBudget)}
+
+// apiBudgetFor returns the shared budget of the credentials in options, or
+// of apiToken when no API key is set.
+func apiBudgetFor(apiToken string, options clientOptions) *apiBudget {
+	secret := apiToken
+	if options.apiKey != "" {
+		secret = options.apiEmail + ":" + options.apiKey
+	}
+	sum := sha256.Sum256([]byte(secret))
+	// The fingerprint tells credentials apart in metrics without revealing them
+	credential := hex.EncodeToString(sum[:4])
+
+	apiBudgets.mu.Lock()
+	defer apiBudgets.mu.Unlock()
+	budget, ok := apiBudgets.budgets[credential]
+	if !ok {
+		budget = newAPIBudget(credential)
+		apiBudgets.budgets[credential] = budget
+	}
+	return budget
+}
+
+// apiBudget counts the API requests of one credential over the last
+// APIBudgetWindow and warns when they approach Cloudflare's rate limit.
+type apiBudget struct {
+	credential string
+	now        func() time.Time
+
+	mu     sync.Mutex
+	sent   []time.Time
+	warned bool
+}
+
+func newAPIBudget(credential string) *apiBudget {
+	return &apiBudget{credential: credential, now: time.Now}
+}
+
+// budgetUsage is the state of a budget after a request.
+type budgetUsage struct {
+	used      int
+	remaining int
+	limit     int
+	warn      bool
+}
+
+// middleware counts every API request, including retries, and records its
+// status and any Cloudflare error codes.
+func (b *apiBudget) middleware(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+	resp, err := next(req)
+
+	status := "error"
+	var header http.Header
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+		header = resp.Header
+	}
+	apiRequestsMetric.Add(1,
+		metrics.String("method", req.Method),
+		metrics.String("access", requestAccess(req.Method)),
+		metrics.String("status", status),
+		metrics.String("credential", b.credential))
+	if err == nil && resp.StatusCode >= http.StatusBadRequest {
+		for _, code := range errorCodes(resp) {
+			apiErrorsMetric.Add(1, metrics.String("status", status), metrics.String("code", code))
+		}
+	}
+
+	throttled := err == nil && resp.StatusCode == http.StatusTooManyRequests
+	usage := b.record(header, throttled)
+	attrs := metrics.String("credential", b.credential)
+	apiBudgetUsedMetric.Set(float64(usage.used), attrs)
+	apiRateLimitRemainingMetric.Set(float64(usage.remaining), attrs)
+	if throttled {
+		log.Printf("Warning: Cloudflare API rate limit reached (%d requests in the last %s)", usage.used, APIBudgetWindow)
+	} else if usage.warn {
+		log.Printf("Warning: Cloudflare API rate limit is close: %d of %d requests left (%d requests in the last %s)",
+			usage.remaining, usage.limit, usage.used, APIBudgetWindow)
+	}
+	return resp, err
+}
+
+// record counts a request and returns the usage. The rate limit headers of
+// the response take precedence over the local count, since they include the
+// requests of other clients sharing the credentials.
+func (b *apiBudget) record(header http.Header, throttled bool) budgetUsage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	cutoff := now.Add(-APIBudgetWindow)
+	kept := 0
+	for _, sent := range b.sent {
+		if sent.After(cutoff) {
+			b.sent[kept] = sent
+			kept++
+		}
+	}
+	b.sent = append(b.sent[:kept], now)
+
+	usage := budgetUsage{used: len(b.sent), limit: APIBudgetLimit}
+	usage.remaining = max(usage.limit-usage.used, 0)
+	if remaining, limit, ok := parseRateLimitHeaders(header); ok {
+		usage.remaining = remaining
+		if limit > 0 {
+			usage.limit = limit
+		}
+	}
+	if throttled {
+		usage.remaining = 0
+	}
+
+	ratio := 1 - float64(usage.remaining)/float64(usage.limit)
+	switch {
+	case ratio >= apiBudgetWarnRatio && !b.warned:
+		b.warned = true
+		usage.warn = !throttled
+	case ratio < apiBudgetRearmRatio:
+		b.warned = false
+	}
+	return usage
+}
+
+// requestAccess returns "read" for requests that do not change anything and
+// "write" for the others.
+func requestAccess(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return "read"
+	default:
+		return "write"
+	}
+}
+
+// errorCodes returns the codes in the errors of a Cloudflare API response,
+// leaving the body readable for the caller.
+func errorCodes(resp *http.Response) []string {
+	if resp.Body == nil {
+		return nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+
+	var envelope struct {
+		Errors []struct {
+			Code int `json:"code"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(body, &envelope) != nil {
+		return nil
+	}
+	codes := make([]string, 0, len(envelope.Errors))
+	for _, e := range envelope.Errors {
+		codes = append(codes, strconv.Itoa(e.Code))
+	}
+	return codes
+}
+
+// parseRateLimitHeaders reads the remaining requests from the Ratelimit
+// header and the limit from the Ratelimit-Policy header, such as
+// `"default";r=1150;t=120` and `"default";q=1200;w=300`. The limit is zero
+// when only the first is present.
+func parseRateLimitHeaders(header http.Header) (remaining, limit int, ok bool) {
+	remaining, ok = rateLimitParam(header.Get("Ratelimit"), "r")
+	if !ok {
+		return 0, 0, false
+	}
+	limit, _ = rateLimitParam(header.Get("Ratelimit-Policy"), "q")
+	return remaining, limit, true
+}
+
+// rateLimitParam returns the integer parameter key of the first item of a
+// structured rate limit header.
+func rateLimitParam(value, key string) (int, bool) {
+	item, _, _ := strings.Cut(value, ",")
+	params := strings.Split(item, ";")
+	for _, param := range params[1:] {
+		name, raw, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found || name != key {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		return n, true
+	}
+	return 0, false
+}
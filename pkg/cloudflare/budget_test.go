@@ -0,0 +1,130 @@
+package cloudflare
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIBudget_CountsRequestsInWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newAPIBudget("test")
+	b.now = func() time.Time { return now }
+
+	for range 3 {
+		b.record(nil, false)
+	}
+	now = now.Add(APIBudgetWindow - time.Second)
+	if usage := b.record(nil, false); usage.used != 4 || usage.remaining != APIBudgetLimit-4 {
+		t.Errorf("expected 4 used, got %+v", usage)
+	}
+	now = now.Add(2 * time.Second)
+	if usage := b.record(nil, false); usage.used != 2 {
+		t.Errorf("expected requests older than the window to be dropped, got %+v", usage)
+	}
+}
+
+func TestAPIBudget_WarnsOnceWhenClose(t *testing.T) {
+	b := newAPIBudget("test")
+	header := func(remaining int) http.Header {
+		h := http.Header{}
+		h.Set("Ratelimit", `"default";r=`+strconv.Itoa(remaining)+`;t=60`)
+		h.Set("Ratelimit-Policy", `"default";q=100;w=300`)
+		return h
+	}
+
+	steps := []struct {
+		remaining int
+		want      bool
+	}{
+		{remaining: 50, want: false},
+		{remaining: 20, want: true},
+		{remaining: 10, want: false},
+		{remaining: 30, want: false},
+		{remaining: 50, want: false},
+		{remaining: 15, want: true},
+	}
+	for i, step := range steps {
+		usage := b.record(header(step.remaining), false)
+		if usage.warn != step.want {
+			t.Errorf("step %d: warn = %v, want %v", i, usage.warn, step.want)
+		}
+		if usage.remaining != step.remaining || usage.limit != 100 {
+			t.Errorf("step %d: expected the headers to be used, got %+v", i, usage)
+		}
+	}
+
+	// Being throttled counts as exhausted without another warning
+	b = newAPIBudget("test")
+	if usage := b.record(nil, true); usage.remaining != 0 || usage.warn {
+		t.Errorf("expected a throttled request to exhaust the budget without a warning, got %+v", usage)
+	}
+}
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	tests := []struct {
+		ratelimit     string
+		policy        string
+		wantRemaining int
+		wantLimit     int
+		wantOK        bool
+	}{
+		{ratelimit: `"default";r=1150;t=120`, policy: `"default";q=1200;w=300`, wantRemaining: 1150, wantLimit: 1200, wantOK: true},
+		{ratelimit: `"default";r=5;t=10, "burst";r=1;t=1`, wantRemaining: 5, wantOK: true},
+		{ratelimit: `"default";t=10`, wantOK: false},
+		{ratelimit: `"default";r=many`, wantOK: false},
+		{ratelimit: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		header.Set("Ratelimit", tt.ratelimit)
+		header.Set("Ratelimit-Policy", tt.policy)
+		remaining, limit, ok := parseRateLimitHeaders(header)
+		if remaining != tt.wantRemaining || limit != tt.wantLimit || ok != tt.wantOK {
+			t.Errorf("parseRateLimitHeaders(%q, %q) = %d, %d, %v; want %d, %d, %v",
+				tt.ratelimit, tt.policy, remaining, limit, ok, tt.wantRemaining, tt.wantLimit, tt.wantOK)
+		}
+	}
+}
+
+func TestErrorCodesKeepsBody(t *testing.T) {
+	body := `{"success":false,"errors":[{"code":81057,"message":"Record already exists."},{"code":1004,"message":"DNS Validation Error"}]}`
+	resp := &http.Response{Body: io.NopCloser(strings.NewReader(body))}
+
+	codes := errorCodes(resp)
+	if len(codes) != 2 || codes[0] != "81057" || codes[1] != "1004" {
+		t.Errorf("expected codes 81057 and 1004, got %v", codes)
+	}
+	rest, err := io.ReadAll(resp.Body)
+	if err != nil || string(rest) != body {
+		t.Errorf("expected the body to stay readable, got %q, %v", rest, err)
+	}
+}
+
+func TestDNSClientCountsAPIRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"success":true,"errors":[],"messages":[],"result":[],"result_info":{"page":1,"per_page":100,"count":0,"total_count":0}}`))
+	}))
+	defer server.Close()
+	t.Setenv("CLOUDFLARE_BASE_URL", server.URL)
+
+	token := "budget-test-token"
+	client, err := NewDNSClient(token, "zone", false, 60)
+	if err != nil {
+		t.Fatalf("NewDNSClient returned error: %v", err)
+	}
+	budget := apiBudgetFor(token, clientOptions{})
+	before := budget.record(nil, false).used
+	if _, err := client.GetDNSRecords(context.Background(), "example.com", "A"); err != nil {
+		t.Fatalf("GetDNSRecords returned error: %v", err)
+	}
+	if after := budget.record(nil, false).used; after != before+2 {
+		t.Errorf("expected the request to be counted, got %d then %d", before, after)
+	}
+}
@@ -0,0 +1,90 @@
+package cloudflare
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+// recordCache keeps GetDNSRecords results for a short time so that steady
+// state check cycles do not list the same records over and over. Any write
+// through the client invalidates the affected entries.
+type recordCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]recordCacheEntry
+	now     func() time.Time
+}
+
+type recordCacheEntry struct {
+	records []dns.RecordResponse
+	expires time.Time
+}
+
+func newRecordCache(ttl time.Duration) *recordCache {
+	return &recordCache{
+		ttl:     ttl,
+		entries: make(map[string]recordCacheEntry),
+		now:     time.Now,
+	}
+}
+
+func recordCacheKey(name, recordType string) string {
+	return name + "|" + recordType
+}
+
+func (c *recordCache) get(name, recordType string) ([]dns.RecordResponse, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := recordCacheKey(name, recordType)
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return append([]dns.RecordResponse(nil), entry.records...), true
+}
+
+func (c *recordCache) put(name, recordType string, records []dns.RecordResponse) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[recordCacheKey(name, recordType)] = recordCacheEntry{
+		records: append([]dns.RecordResponse(nil), records...),
+		expires: c.now().Add(c.ttl),
+	}
+}
+
+func (c *recordCache) invalidate(name, recordType string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, recordCacheKey(name, recordType))
+}
+
+// invalidateAll is used when a write cannot be attributed to a name, e.g. a delete by record ID.
+func (c *recordCache) invalidateAll() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]recordCacheEntry)
+}
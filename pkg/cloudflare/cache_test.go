@@ -0,0 +1,74 @@
+package cloudflare
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestDNSClientRecordCache(t *testing.T) {
+	api := &fakeCloudflareAPI{
+		listResp: []dns.RecordResponse{{ID: "record-1", Name: "example.com", Type: dns.RecordResponseTypeA, Content: "192.0.2.1"}},
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newRecordCache(time.Minute)
+	cache.now = func() time.Time { return now }
+	client := &DNSClient{api: api, zoneID: "zone", ttl: 60, cache: cache}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetDNSRecords(ctx, "example.com", "A"); err != nil {
+			t.Fatalf("GetDNSRecords returned error: %v", err)
+		}
+	}
+	if api.listCalls != 1 {
+		t.Fatalf("expected cached records to be reused, got %d list calls", api.listCalls)
+	}
+
+	// Other names are cached separately
+	if _, err := client.GetDNSRecords(ctx, "www.example.com", "A"); err != nil {
+		t.Fatalf("GetDNSRecords returned error: %v", err)
+	}
+	if api.listCalls != 2 {
+		t.Fatalf("expected a list call for another name, got %d", api.listCalls)
+	}
+
+	// ReplaceRecords always lists fresh records and invalidates the cache
+	if err := client.ReplaceRecords(ctx, "example.com", "A", []string{"192.0.2.2"}); err != nil {
+		t.Fatalf("ReplaceRecords returned error: %v", err)
+	}
+	if api.listCalls != 3 {
+		t.Fatalf("expected ReplaceRecords to bypass the cache, got %d list calls", api.listCalls)
+	}
+	if _, err := client.GetDNSRecords(ctx, "example.com", "A"); err != nil {
+		t.Fatalf("GetDNSRecords returned error: %v", err)
+	}
+	if api.listCalls != 4 {
+		t.Fatalf("expected the cache to be invalidated after a write, got %d list calls", api.listCalls)
+	}
+
+	// Entries expire after the TTL
+	now = now.Add(2 * time.Minute)
+	if _, err := client.GetDNSRecords(ctx, "example.com", "A"); err != nil {
+		t.Fatalf("GetDNSRecords returned error: %v", err)
+	}
+	if api.listCalls != 5 {
+		t.Fatalf("expected expired entry to be refreshed, got %d list calls", api.listCalls)
+	}
+}
+
+func TestDNSClientWithoutRecordCache(t *testing.T) {
+	api := &fakeCloudflareAPI{}
+	client := &DNSClient{api: api, zoneID: "zone", ttl: 60}
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetDNSRecords(context.Background(), "example.com", "A"); err != nil {
+			t.Fatalf("GetDNSRecords returned error: %v", err)
+		}
+	}
+	if api.listCalls != 2 {
+		t.Errorf("expected every call to hit the API without a cache, got %d list calls", api.listCalls)
+	}
+}
@@ -2,8 +2,10 @@ package cloudflare
 
 import (
 	"context"
+	"log"
 	"time"
 
+	"github.com/bootjp/cloudflare-gslb/pkg/audit"
 	cf "github.com/cloudflare/cloudflare-go/v6"
 	"github.com/cloudflare/cloudflare-go/v6/dns"
 	"github.com/cloudflare/cloudflare-go/v6/option"
@@ -16,6 +18,7 @@ type cloudflareAPI interface {
 	Delete(ctx context.Context, dnsRecordID string, body dns.RecordDeleteParams, opts ...option.RequestOption) (*dns.RecordDeleteResponse, error)
 	List(ctx context.Context, params dns.RecordListParams, opts ...option.RequestOption) (*pagination.V4PagePaginationArray[dns.RecordResponse], error)
 	Update(ctx context.Context, dnsRecordID string, params dns.RecordUpdateParams, opts ...option.RequestOption) (*dns.RecordResponse, error)
+	Batch(ctx context.Context, params dns.RecordBatchParams, opts ...option.RequestOption) (*dns.RecordBatchResponse, error)
 }
 
 type DNSClientInterface interface {
@@ -28,30 +31,151 @@ type DNSClientInterface interface {
 }
 
 type DNSClient struct {
-	api     cloudflareAPI
-	zoneID  string
-	proxied bool
-	ttl     int
+	api        cloudflareAPI
+	zoneID     string
+	proxied    bool
+	ttl        int
+	recordTags bool
+	cache      *recordCache
+	auditSink  audit.Sink
+	auditActor string
+	binding    *recordBinding
 }
 
-func NewDNSClient(apiToken, zoneID string, proxied bool, ttl int) (*DNSClient, error) {
-	client := cf.NewClient(
-		option.WithAPIToken(apiToken),
-	)
+// ClientOption customizes a DNSClient created by NewDNSClient.
+type ClientOption func(*clientOptions)
+
+// DefaultRequestTimeout bounds each API request unless WithRequestTimeout overrides it.
+const DefaultRequestTimeout = 30 * time.Second
+
+type clientOptions struct {
+	retryPolicy    RetryPolicy
+	requestTimeout time.Duration
+	limiter        *RateLimiter
+	apiKey         string
+	apiEmail       string
+	recordTags     bool
+	cacheTTL       time.Duration
+	auditSink      audit.Sink
+	auditActor     string
+	binding        *recordBinding
+	tokenFile      *tokenFile
+}
+
+// WithRetryPolicy overrides DefaultRetryPolicy for transient API errors.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(o *clientOptions) {
+		o.retryPolicy = policy
+	}
+}
+
+// WithRequestTimeout bounds each API request, so that a hung connection
+// fails the attempt instead of stalling the caller. Retries get a fresh timeout.
+func WithRequestTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.requestTimeout = timeout
+	}
+}
+
+// WithAPIKey authenticates with a legacy Global API Key and account email
+// instead of the API token passed to NewDNSClient.
+func WithAPIKey(apiKey, email string) ClientOption {
+	return func(o *clientOptions) {
+		o.apiKey = apiKey
+		o.apiEmail = email
+	}
+}
+
+// WithRecordTags tags written records with ManagedByTag and their state in
+// addition to the comment. Record tags require a paid Cloudflare plan.
+func WithRecordTags() ClientOption {
+	return func(o *clientOptions) {
+		o.recordTags = true
+	}
+}
+
+// WithRecordCache caches GetDNSRecords results for ttl. Writes made through
+// the client invalidate the cache; changes made elsewhere are seen after ttl.
+func WithRecordCache(ttl time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.cacheTTL = ttl
+	}
+}
+
+// WithRateLimiter makes the client take a token from limiter before every API request.
+// Pass the same limiter to every client sharing an API token.
+func WithRateLimiter(limiter *RateLimiter) ClientOption {
+	return func(o *clientOptions) {
+		o.limiter = limiter
+	}
+}
+
+func NewDNSClient(apiToken, zoneID string, proxied bool, ttl int, opts ...ClientOption) (*DNSClient, error) {
+	options := newClientOptions(opts)
+	client := newCloudflareClient(apiToken, options)
+
+	var cache *recordCache
+	if options.cacheTTL > 0 {
+		cache = newRecordCache(options.cacheTTL)
+	}
 
 	return &DNSClient{
-		api:     client.DNS.Records,
-		zoneID:  zoneID,
-		proxied: proxied,
-		ttl:     ttl,
+		api:        newRetryingAPI(client.DNS.Records, options.retryPolicy, options.limiter),
+		zoneID:     zoneID,
+		proxied:    proxied,
+		ttl:        ttl,
+		recordTags: options.recordTags,
+		cache:      cache,
+		auditSink:  options.auditSink,
+		auditActor: options.auditActor,
+		binding:    options.binding,
 	}, nil
 }
 
+func newClientOptions(opts []ClientOption) clientOptions {
+	options := clientOptions{retryPolicy: DefaultRetryPolicy, requestTimeout: DefaultRequestTimeout}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+func newCloudflareClient(apiToken string, options clientOptions) *cf.Client {
+	requestOptions := []option.RequestOption{
+		// Retries are handled by retryingAPI so that they follow our policy
+		option.WithMaxRetries(0),
+		option.WithRequestTimeout(options.requestTimeout),
+		option.WithMiddleware(traceMiddleware, apiBudgetFor(apiToken, options).middleware),
+	}
+	if options.apiKey != "" {
+		requestOptions = append(requestOptions, option.WithAPIKey(options.apiKey), option.WithAPIEmail(options.apiEmail))
+	} else {
+		requestOptions = append(requestOptions, option.WithAPIToken(apiToken))
+		if options.tokenFile != nil {
+			requestOptions = append(requestOptions, option.WithMiddleware(options.tokenFile.middleware))
+		}
+	}
+	return cf.NewClient(requestOptions...)
+}
+
 func (c *DNSClient) GetZoneID() string {
 	return c.zoneID
 }
 
 func (c *DNSClient) GetDNSRecords(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+	if records, ok := c.cache.get(name, recordType); ok {
+		return records, nil
+	}
+
+	records, err := c.listRecords(ctx, name, recordType)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.put(name, recordType, records)
+	return records, nil
+}
+
+func (c *DNSClient) listRecords(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
 	params := dns.RecordListParams{
 		ZoneID: cf.F(c.zoneID),
 		Name: cf.F(dns.RecordListParamsName{
@@ -60,53 +184,73 @@ func (c *DNSClient) GetDNSRecords(ctx context.Context, name, recordType string)
 		Type: cf.F(dns.RecordListParamsType(recordType)),
 	}
 
+	start := time.Now()
 	result, err := c.api.List(ctx, params)
+	c.audit(ctx, audit.Event{Operation: "list", Record: name, RecordType: recordType}, start, err)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	return result.Result, nil
+	return c.binding.filter(result.Result), nil
 }
 
 func (c *DNSClient) DeleteDNSRecord(ctx context.Context, recordID string) error {
+	defer c.cache.invalidateAll()
+
+	start := time.Now()
 	_, err := c.api.Delete(ctx, recordID, dns.RecordDeleteParams{
 		ZoneID: cf.F(c.zoneID),
 	})
+	c.audit(ctx, audit.Event{Operation: "delete", RecordID: recordID}, start, err)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 	return nil
 }
 
-func (c *DNSClient) buildARecord(name, content string) dns.ARecordParam {
-	return dns.ARecordParam{
+func (c *DNSClient) buildARecord(ctx context.Context, name, content string) dns.ARecordParam {
+	meta := recordMetadataFrom(ctx)
+	record := dns.ARecordParam{
 		Type:    cf.F(dns.ARecordTypeA),
 		Name:    cf.F(name),
 		Content: cf.F(content),
 		TTL:     cf.F(dns.TTL(c.ttl)),
 		Proxied: cf.F(c.proxied),
+		Comment: cf.F(c.binding.decorate(meta.Comment())),
+	}
+	if c.recordTags {
+		record.Tags = cf.F(meta.Tags())
 	}
+	return record
 }
 
-func (c *DNSClient) buildAAAARecord(name, content string) dns.AAAARecordParam {
-	return dns.AAAARecordParam{
+func (c *DNSClient) buildAAAARecord(ctx context.Context, name, content string) dns.AAAARecordParam {
+	meta := recordMetadataFrom(ctx)
+	record := dns.AAAARecordParam{
 		Type:    cf.F(dns.AAAARecordTypeAAAA),
 		Name:    cf.F(name),
 		Content: cf.F(content),
 		TTL:     cf.F(dns.TTL(c.ttl)),
 		Proxied: cf.F(c.proxied),
+		Comment: cf.F(c.binding.decorate(meta.Comment())),
 	}
+	if c.recordTags {
+		record.Tags = cf.F(meta.Tags())
+	}
+	return record
 }
 
 func (c *DNSClient) CreateDNSRecord(ctx context.Context, name, recordType, content string) (dns.RecordResponse, error) {
+	defer c.cache.invalidate(name, recordType)
+
 	var body dns.RecordNewParamsBodyUnion
 	switch recordType {
 	case "A":
-		body = c.buildARecord(name, content)
+		body = c.buildARecord(ctx, name, content)
 	case "AAAA":
-		body = c.buildAAAARecord(name, content)
+		body = c.buildAAAARecord(ctx, name, content)
 	default:
-		body = c.buildARecord(name, content)
+		body = c.buildARecord(ctx, name, content)
 	}
 
 	params := dns.RecordNewParams{
@@ -114,7 +258,13 @@ func (c *DNSClient) CreateDNSRecord(ctx context.Context, name, recordType, conte
 		Body:   body,
 	}
 
+	start := time.Now()
 	record, err := c.api.New(ctx, params)
+	event := audit.Event{Operation: "create", Record: name, RecordType: recordType, NewContent: []string{content}}
+	if record != nil {
+		event.RecordID = record.ID
+	}
+	c.audit(ctx, event, start, err)
 	if err != nil {
 		return dns.RecordResponse{}, errors.WithStack(err)
 	}
@@ -123,14 +273,17 @@ func (c *DNSClient) CreateDNSRecord(ctx context.Context, name, recordType, conte
 }
 
 func (c *DNSClient) UpdateDNSRecord(ctx context.Context, recordID, name, recordType, content string) (dns.RecordResponse, error) {
+	// The record may have been renamed, so drop every entry rather than just the new name
+	defer c.cache.invalidateAll()
+
 	var body dns.RecordUpdateParamsBodyUnion
 	switch recordType {
 	case "A":
-		body = c.buildARecord(name, content)
+		body = c.buildARecord(ctx, name, content)
 	case "AAAA":
-		body = c.buildAAAARecord(name, content)
+		body = c.buildAAAARecord(ctx, name, content)
 	default:
-		body = c.buildARecord(name, content)
+		body = c.buildARecord(ctx, name, content)
 	}
 
 	params := dns.RecordUpdateParams{
@@ -138,7 +291,9 @@ func (c *DNSClient) UpdateDNSRecord(ctx context.Context, recordID, name, recordT
 		Body:   body,
 	}
 
+	start := time.Now()
 	record, err := c.api.Update(ctx, recordID, params)
+	c.audit(ctx, audit.Event{Operation: "update", Record: name, RecordType: recordType, RecordID: recordID, NewContent: []string{content}}, start, err)
 	if err != nil {
 		return dns.RecordResponse{}, errors.WithStack(err)
 	}
@@ -146,16 +301,6 @@ func (c *DNSClient) UpdateDNSRecord(ctx context.Context, recordID, name, recordT
 	return *record, nil
 }
 
-func (c *DNSClient) deleteRecords(ctx context.Context, recordsToDelete []dns.RecordResponse) error {
-	for _, record := range recordsToDelete {
-		if err := c.DeleteDNSRecord(ctx, record.ID); err != nil {
-			return err
-		}
-		time.Sleep(500 * time.Millisecond)
-	}
-	return nil
-}
-
 func (c *DNSClient) ReplaceRecords(ctx context.Context, name, recordType string, newContents []string) error {
 	if len(newContents) == 0 {
 		return errors.New("no record contents provided")
@@ -163,36 +308,78 @@ func (c *DNSClient) ReplaceRecords(ctx context.Context, name, recordType string,
 
 	desired := dedupeContents(newContents)
 
-	records, err := c.GetDNSRecords(ctx, name, recordType)
+	// Always decide on fresh records, never on a cached listing
+	records, err := c.listRecords(ctx, name, recordType)
 	if err != nil {
 		return err
 	}
 
-	if len(records) == 0 {
-		return c.createRecords(ctx, name, recordType, desired)
-	}
-
 	desiredSet := buildContentSet(desired)
 	recordsByContent := groupRecordsByContent(records)
 	missing, recordsToDelete := diffRecords(desired, desiredSet, recordsByContent)
+	patches, missing, recordsToDelete := c.binding.reusePinned(missing, recordsToDelete)
 
-	if err := c.createRecords(ctx, name, recordType, missing); err != nil {
-		return err
-	}
-
-	if len(recordsToDelete) == 0 {
+	if len(missing) == 0 && len(recordsToDelete) == 0 && len(patches) == 0 {
 		return nil
 	}
 
-	return c.deleteRecords(ctx, recordsToDelete)
+	defer c.cache.invalidate(name, recordType)
+	return c.batchReplace(ctx, name, recordType, missing, patches, recordsToDelete)
 }
 
-func (c *DNSClient) createRecords(ctx context.Context, name, recordType string, contents []string) error {
+// batchReplace creates, updates and deletes records in a single atomic batch
+// request, so there is never a window where both the old and new records are live.
+func (c *DNSClient) batchReplace(ctx context.Context, name, recordType string, contents []string, patches []recordPatch, recordsToDelete []dns.RecordResponse) error {
+	posts := make([]dns.RecordBatchParamsPostUnion, 0, len(contents))
 	for _, content := range contents {
-		if _, err := c.CreateDNSRecord(ctx, name, recordType, content); err != nil {
-			return err
+		if recordType == "AAAA" {
+			posts = append(posts, c.buildAAAARecord(ctx, name, content))
+		} else {
+			posts = append(posts, c.buildARecord(ctx, name, content))
 		}
 	}
+
+	batchPatches := make([]dns.BatchPatchUnionParam, 0, len(patches))
+	oldContents := make([]string, 0, len(patches)+len(recordsToDelete))
+	newContents := append([]string{}, contents...)
+	for _, patch := range patches {
+		oldContents = append(oldContents, patch.record.Content)
+		newContents = append(newContents, patch.content)
+		if recordType == "AAAA" {
+			batchPatches = append(batchPatches, dns.BatchPatchAAAARecordParam{ID: cf.F(patch.record.ID), AAAARecordParam: c.buildAAAARecord(ctx, name, patch.content)})
+		} else {
+			batchPatches = append(batchPatches, dns.BatchPatchARecordParam{ID: cf.F(patch.record.ID), ARecordParam: c.buildARecord(ctx, name, patch.content)})
+		}
+	}
+
+	deletes := make([]dns.RecordBatchParamsDelete, 0, len(recordsToDelete))
+	for _, record := range recordsToDelete {
+		oldContents = append(oldContents, record.Content)
+		if !IsManagedRecord(record) {
+			log.Printf("Taking over record %s (%s %s) that was not created by %s", record.ID, name, record.Content, ManagedBy)
+		}
+		deletes = append(deletes, dns.RecordBatchParamsDelete{ID: cf.F(record.ID)})
+	}
+
+	params := dns.RecordBatchParams{
+		ZoneID: cf.F(c.zoneID),
+	}
+	if len(posts) > 0 {
+		params.Posts = cf.F(posts)
+	}
+	if len(batchPatches) > 0 {
+		params.Patches = cf.F(batchPatches)
+	}
+	if len(deletes) > 0 {
+		params.Deletes = cf.F(deletes)
+	}
+
+	start := time.Now()
+	_, err := c.api.Batch(ctx, params)
+	c.audit(ctx, audit.Event{Operation: "batch", Record: name, RecordType: recordType, OldContent: oldContents, NewContent: newContents}, start, err)
+	if err != nil {
+		return errors.WithStack(err)
+	}
 	return nil
 }
 
@@ -3,6 +3,8 @@ package cloudflare
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -19,6 +21,8 @@ type createCall struct {
 	content string
 	ttl     int
 	proxied bool
+	comment string
+	tags    []string
 }
 
 // updateCall represents an update DNS record call
@@ -34,15 +38,18 @@ type updateCall struct {
 type fakeCloudflareAPI struct {
 	listResp    []dns.RecordResponse
 	listErr     error
+	listCalls   int
 	createCalls []createCall
 	updateCalls []updateCall
 	deleteCalls []string
+	batchCalls  int
 	createErr   error
 	updateErr   error
 	deleteErr   error
 }
 
 func (f *fakeCloudflareAPI) List(ctx context.Context, params dns.RecordListParams, opts ...option.RequestOption) (*pagination.V4PagePaginationArray[dns.RecordResponse], error) {
+	f.listCalls++
 	if f.listErr != nil {
 		return nil, f.listErr
 	}
@@ -100,6 +107,51 @@ func (f *fakeCloudflareAPI) Delete(ctx context.Context, dnsRecordID string, body
 	return &dns.RecordDeleteResponse{}, nil
 }
 
+// Batch records the posts, patches and deletes as create, update and delete calls. Like the
+// real endpoint it is atomic: on error, nothing is recorded.
+func (f *fakeCloudflareAPI) Batch(ctx context.Context, params dns.RecordBatchParams, opts ...option.RequestOption) (*dns.RecordBatchResponse, error) {
+	f.batchCalls++
+
+	posts := params.Posts.Value
+	deletes := params.Deletes.Value
+	if len(posts) > 0 && f.createErr != nil {
+		return nil, f.createErr
+	}
+	if len(deletes) > 0 && f.deleteErr != nil {
+		return nil, f.deleteErr
+	}
+
+	for _, post := range posts {
+		call := createCall{}
+		if record, ok := post.(dns.ARecordParam); ok {
+			call = createCall{
+				name:    record.Name.Value,
+				rtype:   string(record.Type.Value),
+				content: record.Content.Value,
+				ttl:     int(record.TTL.Value),
+				proxied: record.Proxied.Value,
+				comment: record.Comment.Value,
+				tags:    record.Tags.Value,
+			}
+		}
+		f.createCalls = append(f.createCalls, call)
+	}
+	for _, d := range deletes {
+		f.deleteCalls = append(f.deleteCalls, d.ID.Value)
+	}
+	for _, patch := range params.Patches.Value {
+		if record, ok := patch.(dns.BatchPatchARecordParam); ok {
+			f.updateCalls = append(f.updateCalls, updateCall{
+				recordID: record.ID.Value,
+				name:     record.Name.Value,
+				rtype:    string(record.Type.Value),
+				content:  record.Content.Value,
+			})
+		}
+	}
+	return &dns.RecordBatchResponse{}, nil
+}
+
 func TestDNSClientReplaceRecordsCreatesWhenNoRecords(t *testing.T) {
 	api := &fakeCloudflareAPI{}
 	client := &DNSClient{
@@ -147,7 +199,10 @@ func TestDNSClientReplaceRecordsUpdatesExistingRecord(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	// With atomic approach: create new record first, then delete old one
+	// The new record is created and the old one deleted in one batch
+	if api.batchCalls != 1 {
+		t.Fatalf("expected one batch call, got %d", api.batchCalls)
+	}
 	if len(api.createCalls) != 1 {
 		t.Fatalf("expected create to be called once, got %d", len(api.createCalls))
 	}
@@ -180,16 +235,14 @@ func TestDNSClientReplaceRecordsDeletesDuplicateRecords(t *testing.T) {
 		ttl:     60,
 	}
 
-	start := time.Now()
 	if err := client.ReplaceRecords(context.Background(), "example.com", "A", []string{"203.0.113.30"}); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	// With two records to delete, expect at least 500ms delay
-	if time.Since(start) < 500*time.Millisecond {
-		t.Fatalf("expected deletion to respect delay between operations")
-	}
 
-	// With atomic approach: create new record first, then delete both old ones
+	// The create and both deletes are sent in a single atomic batch
+	if api.batchCalls != 1 {
+		t.Fatalf("expected one batch call, got %d", api.batchCalls)
+	}
 	if len(api.createCalls) != 1 {
 		t.Fatalf("expected one create call, got %d", len(api.createCalls))
 	}
@@ -202,7 +255,7 @@ func TestDNSClientReplaceRecordsDeletesDuplicateRecords(t *testing.T) {
 }
 
 func TestDNSClientReplaceRecordsUpdateError(t *testing.T) {
-	// With atomic approach, we create instead of update, so test create error
+	// A failed batch must not leave any partial change behind
 	expected := crerrors.New("create failed")
 	api := &fakeCloudflareAPI{
 		listResp:  []dns.RecordResponse{{ID: "record-1", Name: "example.com", Type: dns.RecordResponseTypeA, Proxied: false}},
@@ -254,6 +307,9 @@ func TestDNSClientReplaceRecordsIdempotent(t *testing.T) {
 	}
 
 	// No operations should be performed since content matches
+	if api.batchCalls != 0 {
+		t.Fatalf("expected no batch calls, got %d", api.batchCalls)
+	}
 	if len(api.createCalls) != 0 {
 		t.Fatalf("expected no create calls, got %d", len(api.createCalls))
 	}
@@ -1001,3 +1057,32 @@ func TestDNSClientReplaceRecordsDeleteError(t *testing.T) {
 		t.Fatalf("expected error %v, got %v", expectedErr, err)
 	}
 }
+
+func TestDNSClientRequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	t.Setenv("CLOUDFLARE_BASE_URL", server.URL)
+
+	client, err := NewDNSClient("token", "zone", false, 60,
+		WithRequestTimeout(50*time.Millisecond),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+	)
+	if err != nil {
+		t.Fatalf("NewDNSClient returned error: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := client.GetDNSRecords(context.Background(), "example.com", "A"); err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request was not bounded by the timeout, took %s", elapsed)
+	}
+}
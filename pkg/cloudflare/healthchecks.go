@@ -0,0 +1,88 @@
+package cloudflare
+
+import (
+	"context"
+
+	cf "github.com/cloudflare/cloudflare-go/v6"
+	"github.com/cloudflare/cloudflare-go/v6/healthchecks"
+	"github.com/cloudflare/cloudflare-go/v6/option"
+	"github.com/cloudflare/cloudflare-go/v6/packages/pagination"
+	"github.com/cockroachdb/errors"
+)
+
+const healthCheckPageSize = 50
+
+type healthcheckAPI interface {
+	List(ctx context.Context, params healthchecks.HealthcheckListParams, opts ...option.RequestOption) (*pagination.V4PagePaginationArray[healthchecks.Healthcheck], error)
+}
+
+// HealthCheckStatus is the latest result of a Cloudflare standalone Health Check.
+type HealthCheckStatus struct {
+	ID            string
+	Name          string
+	Address       string
+	Status        string
+	FailureReason string
+}
+
+// Healthy reports whether Cloudflare's probes currently consider the address healthy.
+func (s HealthCheckStatus) Healthy() bool {
+	return s.Status == string(healthchecks.HealthcheckStatusHealthy)
+}
+
+// Known reports whether the status carries a health signal. Unknown and
+// suspended checks say nothing about the address.
+func (s HealthCheckStatus) Known() bool {
+	return s.Status == string(healthchecks.HealthcheckStatusHealthy) || s.Status == string(healthchecks.HealthcheckStatusUnhealthy)
+}
+
+// HealthCheckClient reads the status of the standalone Health Checks of a zone.
+type HealthCheckClient struct {
+	api     healthcheckAPI
+	zoneID  string
+	limiter *RateLimiter
+}
+
+// NewHealthCheckClient returns a client for the Health Checks of zoneID. It
+// accepts the same options as NewDNSClient; only credentials and the rate
+// limiter apply.
+func NewHealthCheckClient(apiToken, zoneID string, opts ...ClientOption) *HealthCheckClient {
+	options := newClientOptions(opts)
+	client := newCloudflareClient(apiToken, options)
+	return &HealthCheckClient{
+		api:     client.Healthchecks,
+		zoneID:  zoneID,
+		limiter: options.limiter,
+	}
+}
+
+// Statuses returns the status of every Health Check in the zone.
+func (c *HealthCheckClient) Statuses(ctx context.Context) ([]HealthCheckStatus, error) {
+	var statuses []HealthCheckStatus
+	for page := 1; ; page++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		result, err := c.api.List(ctx, healthchecks.HealthcheckListParams{
+			ZoneID:  cf.F(c.zoneID),
+			Page:    cf.F(float64(page)),
+			PerPage: cf.F(float64(healthCheckPageSize)),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list Cloudflare health checks")
+		}
+
+		for _, hc := range result.Result {
+			statuses = append(statuses, HealthCheckStatus{
+				ID:            hc.ID,
+				Name:          hc.Name,
+				Address:       hc.Address,
+				Status:        string(hc.Status),
+				FailureReason: hc.FailureReason,
+			})
+		}
+		if len(result.Result) < healthCheckPageSize {
+			return statuses, nil
+		}
+	}
+}
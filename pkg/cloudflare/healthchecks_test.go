@@ -0,0 +1,71 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go/v6/healthchecks"
+	"github.com/cloudflare/cloudflare-go/v6/option"
+	"github.com/cloudflare/cloudflare-go/v6/packages/pagination"
+)
+
+type fakeHealthcheckAPI struct {
+	checks []healthchecks.Healthcheck
+	pages  []float64
+}
+
+func (f *fakeHealthcheckAPI) List(ctx context.Context, params healthchecks.HealthcheckListParams, opts ...option.RequestOption) (*pagination.V4PagePaginationArray[healthchecks.Healthcheck], error) {
+	page := params.Page.Value
+	perPage := int(params.PerPage.Value)
+	f.pages = append(f.pages, page)
+
+	start := (int(page) - 1) * perPage
+	end := start + perPage
+	if start > len(f.checks) {
+		start = len(f.checks)
+	}
+	if end > len(f.checks) {
+		end = len(f.checks)
+	}
+	return &pagination.V4PagePaginationArray[healthchecks.Healthcheck]{Result: f.checks[start:end]}, nil
+}
+
+func TestHealthCheckClient_StatusesPaginates(t *testing.T) {
+	api := &fakeHealthcheckAPI{}
+	for i := 0; i < healthCheckPageSize+3; i++ {
+		api.checks = append(api.checks, healthchecks.Healthcheck{
+			ID:      fmt.Sprintf("hc-%d", i),
+			Address: fmt.Sprintf("192.0.2.%d", i),
+			Status:  healthchecks.HealthcheckStatusHealthy,
+		})
+	}
+	api.checks[0].Status = healthchecks.HealthcheckStatusUnhealthy
+	api.checks[0].FailureReason = "timeout"
+
+	client := &HealthCheckClient{api: api, zoneID: "zone"}
+	statuses, err := client.Statuses(context.Background())
+	if err != nil {
+		t.Fatalf("Statuses returned error: %v", err)
+	}
+	if len(statuses) != len(api.checks) {
+		t.Fatalf("expected %d statuses, got %d", len(api.checks), len(statuses))
+	}
+	if len(api.pages) != 2 {
+		t.Errorf("expected 2 pages, got %v", api.pages)
+	}
+	if statuses[0].Healthy() || !statuses[0].Known() || statuses[0].FailureReason != "timeout" {
+		t.Errorf("unexpected status %+v", statuses[0])
+	}
+	if !statuses[1].Healthy() {
+		t.Errorf("expected healthy status, got %+v", statuses[1])
+	}
+}
+
+func TestHealthCheckStatus_Known(t *testing.T) {
+	for _, status := range []healthchecks.HealthcheckStatus{healthchecks.HealthcheckStatusUnknown, healthchecks.HealthcheckStatusSuspended} {
+		if (HealthCheckStatus{Status: string(status)}).Known() {
+			t.Errorf("expected %s to carry no health signal", status)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package cloudflare
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	cf "github.com/cloudflare/cloudflare-go/v6"
+	"github.com/cloudflare/cloudflare-go/v6/kv"
+	"github.com/cloudflare/cloudflare-go/v6/option"
+	"github.com/cockroachdb/errors"
+)
+
+type kvAPI interface {
+	Get(ctx context.Context, namespaceID string, keyName string, query kv.NamespaceValueGetParams, opts ...option.RequestOption) (*http.Response, error)
+	Update(ctx context.Context, namespaceID string, keyName string, params kv.NamespaceValueUpdateParams, opts ...option.RequestOption) (*kv.NamespaceValueUpdateResponse, error)
+}
+
+// KVStore reads and writes values in a Workers KV namespace.
+type KVStore struct {
+	api         kvAPI
+	accountID   string
+	namespaceID string
+	limiter     *RateLimiter
+}
+
+// NewKVStore returns a store for the Workers KV namespace namespaceID. It
+// accepts the same options as NewDNSClient; only credentials and the rate
+// limiter apply.
+func NewKVStore(apiToken, accountID, namespaceID string, opts ...ClientOption) *KVStore {
+	options := newClientOptions(opts)
+	client := newCloudflareClient(apiToken, options)
+	return &KVStore{
+		api:         client.KV.Namespaces.Values,
+		accountID:   accountID,
+		namespaceID: namespaceID,
+		limiter:     options.limiter,
+	}
+}
+
+// Get returns the value stored under key, or false if the key does not exist.
+func (s *KVStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+
+	resp, err := s.api.Get(ctx, s.namespaceID, url.PathEscape(key), kv.NamespaceValueGetParams{
+		AccountID: cf.F(s.accountID),
+	})
+	if err != nil {
+		var apiErr *cf.Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, false, nil
+		}
+		return nil, false, errors.Wrapf(err, "failed to read KV key %s", key)
+	}
+	defer resp.Body.Close()
+
+	value, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "failed to read KV key %s", key)
+	}
+	return value, true, nil
+}
+
+// Put stores value under key.
+func (s *KVStore) Put(ctx context.Context, key string, value []byte) error {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err := s.api.Update(ctx, s.namespaceID, url.PathEscape(key), kv.NamespaceValueUpdateParams{
+		AccountID: cf.F(s.accountID),
+		Value:     cf.F(string(value)),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to write KV key %s", key)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go/v6/kv"
+	"github.com/cloudflare/cloudflare-go/v6/option"
+)
+
+type fakeKVAPI struct {
+	values map[string]string
+	getErr error
+}
+
+func (f *fakeKVAPI) Get(ctx context.Context, namespaceID string, keyName string, query kv.NamespaceValueGetParams, opts ...option.RequestOption) (*http.Response, error) {
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	value, ok := f.values[keyName]
+	if !ok {
+		return nil, newAPIError(http.StatusNotFound, "")
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(value))}, nil
+}
+
+func (f *fakeKVAPI) Update(ctx context.Context, namespaceID string, keyName string, params kv.NamespaceValueUpdateParams, opts ...option.RequestOption) (*kv.NamespaceValueUpdateResponse, error) {
+	f.values[keyName] = params.Value.Value
+	return &kv.NamespaceValueUpdateResponse{}, nil
+}
+
+func TestKVStore_PutAndGet(t *testing.T) {
+	api := &fakeKVAPI{values: map[string]string{}}
+	store := &KVStore{api: api, accountID: "account", namespaceID: "namespace"}
+	ctx := context.Background()
+
+	if _, ok, err := store.Get(ctx, "gslb:missing"); err != nil || ok {
+		t.Fatalf("expected a missing key without error, got ok=%v err=%v", ok, err)
+	}
+
+	if err := store.Put(ctx, "gslb:example.com-www-A", []byte(`{"current_priority":100}`)); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+	value, ok, err := store.Get(ctx, "gslb:example.com-www-A")
+	if err != nil || !ok {
+		t.Fatalf("Get returned ok=%v err=%v", ok, err)
+	}
+	if string(value) != `{"current_priority":100}` {
+		t.Errorf("unexpected value %s", value)
+	}
+}
+
+func TestKVStore_GetError(t *testing.T) {
+	api := &fakeKVAPI{values: map[string]string{}, getErr: newAPIError(http.StatusForbidden, "")}
+	store := &KVStore{api: api, accountID: "account", namespaceID: "namespace"}
+
+	if _, _, err := store.Get(context.Background(), "gslb:key"); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,82 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+const (
+	// ManagedBy identifies records written by this tool in comments and tags.
+	ManagedBy = "cloudflare-gslb"
+	// ManagedByTag is the record tag set on every record this tool creates.
+	ManagedByTag = "managed-by:" + ManagedBy
+
+	managedByComment = "managed-by=" + ManagedBy
+)
+
+// RecordMetadata describes why a record was written, for anyone looking at the dashboard.
+type RecordMetadata struct {
+	// State is the GSLB state the record represents, e.g. "primary" or "failover".
+	State string
+	// Since is when the record entered that state.
+	Since time.Time
+}
+
+// Comment renders the metadata as a record comment.
+func (m RecordMetadata) Comment() string {
+	parts := []string{managedByComment}
+	if m.State != "" {
+		parts = append(parts, "state="+m.State)
+	}
+	if !m.Since.IsZero() {
+		parts = append(parts, "since="+m.Since.UTC().Format(time.RFC3339))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Tags renders the metadata as record tags.
+func (m RecordMetadata) Tags() []string {
+	tags := []string{ManagedByTag}
+	if m.State != "" {
+		tags = append(tags, "gslb-state:"+m.State)
+	}
+	return tags
+}
+
+type recordMetadataKey struct{}
+
+// WithRecordMetadata returns a context whose record writes are annotated with meta.
+func WithRecordMetadata(ctx context.Context, meta RecordMetadata) context.Context {
+	return context.WithValue(ctx, recordMetadataKey{}, meta)
+}
+
+func recordMetadataFrom(ctx context.Context) RecordMetadata {
+	meta, _ := ctx.Value(recordMetadataKey{}).(RecordMetadata)
+	return meta
+}
+
+// IsManagedRecord reports whether record was written by this tool, based on its tags or comment.
+func IsManagedRecord(record dns.RecordResponse) bool {
+	if strings.HasPrefix(record.Comment, managedByComment) {
+		return true
+	}
+	switch tags := record.Tags.(type) {
+	case []dns.RecordTags:
+		for _, tag := range tags {
+			if tag == ManagedByTag {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, tag := range tags {
+			if fmt.Sprint(tag) == ManagedByTag {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package cloudflare
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestRecordMetadataComment(t *testing.T) {
+	meta := RecordMetadata{State: "failover", Since: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	want := "managed-by=cloudflare-gslb; state=failover; since=2024-01-02T03:04:05Z"
+	if got := meta.Comment(); got != want {
+		t.Errorf("Comment() = %q, want %q", got, want)
+	}
+	if got := (RecordMetadata{}).Comment(); got != "managed-by=cloudflare-gslb" {
+		t.Errorf("Comment() without state = %q", got)
+	}
+}
+
+func TestIsManagedRecord(t *testing.T) {
+	tests := []struct {
+		name   string
+		record dns.RecordResponse
+		want   bool
+	}{
+		{name: "comment", record: dns.RecordResponse{Comment: "managed-by=cloudflare-gslb; state=primary"}, want: true},
+		{name: "tag", record: dns.RecordResponse{Tags: []interface{}{"team:web", ManagedByTag}}, want: true},
+		{name: "typed tags", record: dns.RecordResponse{Tags: []dns.RecordTags{ManagedByTag}}, want: true},
+		{name: "unmanaged", record: dns.RecordResponse{Comment: "created by hand"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsManagedRecord(tt.record); got != tt.want {
+				t.Errorf("IsManagedRecord() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDNSClientReplaceRecordsAnnotatesRecords(t *testing.T) {
+	api := &fakeCloudflareAPI{}
+	client := &DNSClient{
+		api:        api,
+		zoneID:     "zone",
+		ttl:        60,
+		recordTags: true,
+	}
+
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	ctx := WithRecordMetadata(context.Background(), RecordMetadata{State: "failover", Since: since})
+	if err := client.ReplaceRecords(ctx, "example.com", "A", []string{"203.0.113.10"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(api.createCalls) != 1 {
+		t.Fatalf("expected one create call, got %d", len(api.createCalls))
+	}
+	call := api.createCalls[0]
+	if call.comment != "managed-by=cloudflare-gslb; state=failover; since=2024-01-02T03:04:05Z" {
+		t.Errorf("unexpected comment %q", call.comment)
+	}
+	if len(call.tags) != 2 || call.tags[0] != ManagedByTag || call.tags[1] != "gslb-state:failover" {
+		t.Errorf("unexpected tags %v", call.tags)
+	}
+}
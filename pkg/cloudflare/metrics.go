@@ -0,0 +1,14 @@
+package cloudflare
+
+import "github.com/bootjp/cloudflare-gslb/pkg/metrics"
+
+var (
+	apiCallsMetric    = metrics.NewCounter("cloudflare.dns.calls", "{call}", "Cloudflare DNS API calls, by operation and result")
+	apiRetriesMetric  = metrics.NewCounter("cloudflare.dns.retries", "{retry}", "Retries of Cloudflare DNS API calls after transient errors")
+	apiDurationMetric = metrics.NewHistogram("cloudflare.dns.duration", "ms", "Duration of Cloudflare DNS API calls including retries", metrics.DurationBuckets)
+
+	apiRequestsMetric           = metrics.NewCounter("cloudflare.api.requests", "{request}", "Cloudflare API requests including retries, by method, access and status")
+	apiErrorsMetric             = metrics.NewCounter("cloudflare.api.errors", "{error}", "Error codes returned by the Cloudflare API")
+	apiBudgetUsedMetric         = metrics.NewGauge("cloudflare.api.budget.used", "{request}", "Cloudflare API requests sent in the last five minutes")
+	apiRateLimitRemainingMetric = metrics.NewGauge("cloudflare.api.rate_limit.remaining", "{request}", "Cloudflare API requests left before being rate limited")
+)
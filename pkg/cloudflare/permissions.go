@@ -0,0 +1,175 @@
+package cloudflare
+
+import (
+	"context"
+	"log"
+
+	cf "github.com/cloudflare/cloudflare-go/v6"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+	"github.com/cloudflare/cloudflare-go/v6/option"
+	"github.com/cloudflare/cloudflare-go/v6/packages/pagination"
+	"github.com/cloudflare/cloudflare-go/v6/shared"
+	"github.com/cloudflare/cloudflare-go/v6/user"
+	"github.com/cockroachdb/errors"
+)
+
+var (
+	// ErrTokenNotActive is returned when the API token is disabled or expired
+	ErrTokenNotActive = errors.New("API token is not active")
+	// ErrMissingZoneAccess is returned when the credentials cannot read the DNS records of a zone
+	ErrMissingZoneAccess = errors.New("credentials cannot access the zone's DNS records")
+	// ErrMissingDNSEditPermission is returned when the API token's policies do not grant DNS Write on a zone
+	ErrMissingDNSEditPermission = errors.New("API token lacks DNS edit permission")
+)
+
+// dnsWritePermissionGroup is the name of the permission group that allows editing DNS records.
+const dnsWritePermissionGroup = "DNS Write"
+
+const (
+	zoneResourcePrefix = "com.cloudflare.api.account.zone."
+	allZonesResource   = zoneResourcePrefix + "*"
+)
+
+type tokenAPI interface {
+	Verify(ctx context.Context, opts ...option.RequestOption) (*user.TokenVerifyResponse, error)
+	Get(ctx context.Context, tokenID string, opts ...option.RequestOption) (*shared.Token, error)
+}
+
+type recordListAPI interface {
+	List(ctx context.Context, params dns.RecordListParams, opts ...option.RequestOption) (*pagination.V4PagePaginationArray[dns.RecordResponse], error)
+}
+
+// PermissionChecker confirms at startup that credentials can manage the DNS
+// records of the configured zones, instead of finding out during a failover.
+type PermissionChecker struct {
+	tokens  tokenAPI
+	records recordListAPI
+	limiter *RateLimiter
+	// usesAPIKey is set for Global API Key credentials, which have no token to verify.
+	usesAPIKey bool
+}
+
+// NewPermissionChecker returns a checker for apiToken. It accepts the same
+// options as NewDNSClient; only credentials, the request timeout and the rate
+// limiter apply.
+func NewPermissionChecker(apiToken string, opts ...ClientOption) *PermissionChecker {
+	options := newClientOptions(opts)
+	client := newCloudflareClient(apiToken, options)
+	return &PermissionChecker{
+		tokens:     client.User.Tokens,
+		records:    client.DNS.Records,
+		limiter:    options.limiter,
+		usesAPIKey: options.apiKey != "",
+	}
+}
+
+// CheckZones verifies that the API token is active and can read and edit the
+// DNS records of every zone in zoneIDs. Edit permission is confirmed from the
+// token's policies, which can only be read if the token is also allowed to
+// read API tokens; otherwise the check is limited to read access and a
+// warning is logged.
+func (c *PermissionChecker) CheckZones(ctx context.Context, zoneIDs []string) error {
+	var token *shared.Token
+	if !c.usesAPIKey {
+		var err error
+		if token, err = c.verifyToken(ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, zoneID := range zoneIDs {
+		if err := c.checkRecordAccess(ctx, zoneID); err != nil {
+			return err
+		}
+		if token != nil && !grantsDNSEdit(token.Policies, zoneID) {
+			return errors.Wrapf(ErrMissingDNSEditPermission, "token %s, zone %s", token.ID, zoneID)
+		}
+	}
+	return nil
+}
+
+// verifyToken returns the token with its policies, or nil if the policies
+// cannot be read.
+func (c *PermissionChecker) verifyToken(ctx context.Context) (*shared.Token, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	verified, err := c.tokens.Verify(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to verify API token")
+	}
+	if verified.Status != user.TokenVerifyResponseStatusActive {
+		return nil, errors.Wrapf(ErrTokenNotActive, "token %s is %s", verified.ID, verified.Status)
+	}
+
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	token, err := c.tokens.Get(ctx, verified.ID)
+	if err != nil {
+		log.Printf("Could not read the policies of API token %s to confirm DNS edit permission (grant \"API Tokens Read\" to enable this check): %v", verified.ID, err)
+		return nil, nil
+	}
+	return token, nil
+}
+
+func (c *PermissionChecker) checkRecordAccess(ctx context.Context, zoneID string) error {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err := c.records.List(ctx, dns.RecordListParams{
+		ZoneID:  cf.F(zoneID),
+		PerPage: cf.F(1.0),
+	})
+	if err != nil {
+		return errors.Wrapf(ErrMissingZoneAccess, "zone %s: %v", zoneID, err)
+	}
+	return nil
+}
+
+// grantsDNSEdit reports whether policies allow DNS Write on zoneID and no
+// policy denies it.
+func grantsDNSEdit(policies []shared.TokenPolicy, zoneID string) bool {
+	allowed := false
+	for _, policy := range policies {
+		if !hasDNSWrite(policy) || !policyCoversZone(policy.Resources, zoneID) {
+			continue
+		}
+		if policy.Effect == shared.TokenPolicyEffectDeny {
+			return false
+		}
+		allowed = true
+	}
+	return allowed
+}
+
+func hasDNSWrite(policy shared.TokenPolicy) bool {
+	for _, group := range policy.PermissionGroups {
+		if group.Name == dnsWritePermissionGroup {
+			return true
+		}
+	}
+	return false
+}
+
+// policyCoversZone reports whether resources include zoneID, either directly,
+// through all zones, or through all zones of an account.
+func policyCoversZone(resources shared.TokenPolicyResourcesUnion, zoneID string) bool {
+	switch r := resources.(type) {
+	case shared.TokenPolicyResourcesIAMResourcesTypeObjectString:
+		return coversZone(r, zoneID)
+	case shared.TokenPolicyResourcesIAMResourcesTypeObjectNested:
+		for _, zones := range r {
+			if coversZone(zones, zoneID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func coversZone(resources map[string]string, zoneID string) bool {
+	_, zone := resources[zoneResourcePrefix+zoneID]
+	_, all := resources[allZonesResource]
+	return zone || all
+}
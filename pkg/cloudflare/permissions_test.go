@@ -0,0 +1,135 @@
+package cloudflare
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+	"github.com/cloudflare/cloudflare-go/v6/option"
+	"github.com/cloudflare/cloudflare-go/v6/packages/pagination"
+	"github.com/cloudflare/cloudflare-go/v6/shared"
+	"github.com/cloudflare/cloudflare-go/v6/user"
+)
+
+type fakeTokenAPI struct {
+	status user.TokenVerifyResponseStatus
+	token  *shared.Token
+	getErr error
+}
+
+func (f *fakeTokenAPI) Verify(ctx context.Context, opts ...option.RequestOption) (*user.TokenVerifyResponse, error) {
+	return &user.TokenVerifyResponse{ID: "token-1", Status: f.status}, nil
+}
+
+func (f *fakeTokenAPI) Get(ctx context.Context, tokenID string, opts ...option.RequestOption) (*shared.Token, error) {
+	return f.token, f.getErr
+}
+
+type fakeRecordListAPI struct {
+	denied map[string]bool
+}
+
+func (f *fakeRecordListAPI) List(ctx context.Context, params dns.RecordListParams, opts ...option.RequestOption) (*pagination.V4PagePaginationArray[dns.RecordResponse], error) {
+	if f.denied[params.ZoneID.Value] {
+		return nil, errors.New("403 Forbidden")
+	}
+	return &pagination.V4PagePaginationArray[dns.RecordResponse]{}, nil
+}
+
+func dnsWritePolicy(effect shared.TokenPolicyEffect, resources shared.TokenPolicyResourcesUnion) shared.TokenPolicy {
+	return shared.TokenPolicy{
+		Effect:           effect,
+		PermissionGroups: []shared.TokenPolicyPermissionGroup{{Name: dnsWritePermissionGroup}},
+		Resources:        resources,
+	}
+}
+
+func TestPermissionCheckerCheckZones(t *testing.T) {
+	zoneOne := shared.TokenPolicyResourcesIAMResourcesTypeObjectString{zoneResourcePrefix + "zone-1": "*"}
+
+	tests := []struct {
+		name    string
+		tokens  *fakeTokenAPI
+		records *fakeRecordListAPI
+		wantErr error
+	}{
+		{
+			name:    "allowed",
+			tokens:  &fakeTokenAPI{status: user.TokenVerifyResponseStatusActive, token: &shared.Token{Policies: []shared.TokenPolicy{dnsWritePolicy(shared.TokenPolicyEffectAllow, zoneOne)}}},
+			records: &fakeRecordListAPI{},
+		},
+		{
+			name:    "expired token",
+			tokens:  &fakeTokenAPI{status: user.TokenVerifyResponseStatusExpired},
+			records: &fakeRecordListAPI{},
+			wantErr: ErrTokenNotActive,
+		},
+		{
+			name:    "zone not readable",
+			tokens:  &fakeTokenAPI{status: user.TokenVerifyResponseStatusActive, token: &shared.Token{Policies: []shared.TokenPolicy{dnsWritePolicy(shared.TokenPolicyEffectAllow, zoneOne)}}},
+			records: &fakeRecordListAPI{denied: map[string]bool{"zone-1": true}},
+			wantErr: ErrMissingZoneAccess,
+		},
+		{
+			name:    "read only",
+			tokens:  &fakeTokenAPI{status: user.TokenVerifyResponseStatusActive, token: &shared.Token{}},
+			records: &fakeRecordListAPI{},
+			wantErr: ErrMissingDNSEditPermission,
+		},
+		{
+			name:    "policies not readable",
+			tokens:  &fakeTokenAPI{status: user.TokenVerifyResponseStatusActive, getErr: errors.New("403 Forbidden")},
+			records: &fakeRecordListAPI{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := &PermissionChecker{tokens: tt.tokens, records: tt.records}
+			err := checker.CheckZones(context.Background(), []string{"zone-1"})
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGrantsDNSEdit(t *testing.T) {
+	allZones := shared.TokenPolicyResourcesIAMResourcesTypeObjectString{allZonesResource: "*"}
+	accountZones := shared.TokenPolicyResourcesIAMResourcesTypeObjectNested{
+		"com.cloudflare.api.account.acct-1": {allZonesResource: "*"},
+	}
+	otherZone := shared.TokenPolicyResourcesIAMResourcesTypeObjectString{zoneResourcePrefix + "zone-2": "*"}
+	zoneOne := shared.TokenPolicyResourcesIAMResourcesTypeObjectString{zoneResourcePrefix + "zone-1": "*"}
+
+	tests := []struct {
+		name     string
+		policies []shared.TokenPolicy
+		want     bool
+	}{
+		{name: "all zones", policies: []shared.TokenPolicy{dnsWritePolicy(shared.TokenPolicyEffectAllow, allZones)}, want: true},
+		{name: "all zones of an account", policies: []shared.TokenPolicy{dnsWritePolicy(shared.TokenPolicyEffectAllow, accountZones)}, want: true},
+		{name: "other zone", policies: []shared.TokenPolicy{dnsWritePolicy(shared.TokenPolicyEffectAllow, otherZone)}, want: false},
+		{name: "denied", policies: []shared.TokenPolicy{
+			dnsWritePolicy(shared.TokenPolicyEffectAllow, allZones),
+			dnsWritePolicy(shared.TokenPolicyEffectDeny, zoneOne),
+		}, want: false},
+		{name: "read only", policies: []shared.TokenPolicy{{
+			Effect:           shared.TokenPolicyEffectAllow,
+			PermissionGroups: []shared.TokenPolicyPermissionGroup{{Name: "DNS Read"}},
+			Resources:        zoneOne,
+		}}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grantsDNSEdit(tt.policies, "zone-1"); got != tt.want {
+				t.Errorf("grantsDNSEdit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package cloudflare
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket shared by every DNSClient that uses the same
+// API token, so that all origins together stay under Cloudflare's API limit.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewRateLimiter returns a limiter allowing requestsPerSecond on average with
+// bursts of up to burst requests. A non-positive rate disables limiting.
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// Wait blocks until a request may be sent or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil || l.rate <= 0 {
+		return nil
+	}
+
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+	return SleepContext(ctx, delay)
+}
+
+// reserve takes a token and returns how long the caller has to wait for it.
+// Tokens may go negative so that concurrent waiters are queued in order.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
@@ -0,0 +1,59 @@
+package cloudflare
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2, 2)
+	limiter.now = func() time.Time { return now }
+
+	// The burst is available immediately
+	for i := 0; i < 2; i++ {
+		if delay := limiter.reserve(); delay != 0 {
+			t.Fatalf("request %d within burst should not wait, got %s", i, delay)
+		}
+	}
+
+	// Further requests queue up at the configured rate
+	if delay := limiter.reserve(); delay != 500*time.Millisecond {
+		t.Errorf("expected 500ms wait, got %s", delay)
+	}
+	if delay := limiter.reserve(); delay != time.Second {
+		t.Errorf("expected 1s wait for the next queued request, got %s", delay)
+	}
+
+	// Tokens refill over time up to the burst
+	now = now.Add(10 * time.Second)
+	if delay := limiter.reserve(); delay != 0 {
+		t.Errorf("expected refilled bucket, got %s", delay)
+	}
+}
+
+func TestRateLimiter_Disabled(t *testing.T) {
+	var nilLimiter *RateLimiter
+	if err := nilLimiter.Wait(context.Background()); err != nil {
+		t.Errorf("nil limiter should not block: %v", err)
+	}
+
+	limiter := NewRateLimiter(0, 1)
+	for i := 0; i < 10; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("disabled limiter returned error: %v", err)
+		}
+	}
+}
+
+func TestRateLimiter_WaitHonorsContext(t *testing.T) {
+	limiter := NewRateLimiter(0.001, 1)
+	_ = limiter.Wait(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); err == nil {
+		t.Error("expected context error while waiting for a token")
+	}
+}
@@ -0,0 +1,218 @@
+package cloudflare
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/metrics"
+	"github.com/bootjp/cloudflare-gslb/pkg/tracing"
+	cf "github.com/cloudflare/cloudflare-go/v6"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+	"github.com/cloudflare/cloudflare-go/v6/option"
+	"github.com/cloudflare/cloudflare-go/v6/packages/pagination"
+	"github.com/cockroachdb/errors"
+)
+
+// RetryPolicy controls how transient Cloudflare API errors (429 and 5xx) are
+// retried. Creates and batches are not idempotent, so they are only retried
+// on 429, when Cloudflare rejected the request without applying it.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first request.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry, doubled on every further retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff. A Retry-After longer than MaxDelay aborts the retries.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used when no policy is given to NewDNSClient.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// retryingAPI wraps a cloudflareAPI and retries transient failures. Every
+// attempt, including retries, first takes a token from the shared limiter.
+type retryingAPI struct {
+	api     cloudflareAPI
+	policy  RetryPolicy
+	limiter *RateLimiter
+	sleep   func(ctx context.Context, d time.Duration) error
+	now     func() time.Time
+}
+
+func newRetryingAPI(api cloudflareAPI, policy RetryPolicy, limiter *RateLimiter) *retryingAPI {
+	return &retryingAPI{
+		api:     api,
+		policy:  policy,
+		limiter: limiter,
+		sleep:   SleepContext,
+		now:     time.Now,
+	}
+}
+
+func (r *retryingAPI) New(ctx context.Context, params dns.RecordNewParams, opts ...option.RequestOption) (*dns.RecordResponse, error) {
+	var resp *dns.RecordResponse
+	err := r.do(ctx, "create", false, func(ctx context.Context) error {
+		var err error
+		resp, err = r.api.New(ctx, params, opts...)
+		return err
+	})
+	return resp, err
+}
+
+func (r *retryingAPI) Delete(ctx context.Context, dnsRecordID string, body dns.RecordDeleteParams, opts ...option.RequestOption) (*dns.RecordDeleteResponse, error) {
+	var resp *dns.RecordDeleteResponse
+	err := r.do(ctx, "delete", true, func(ctx context.Context) error {
+		var err error
+		resp, err = r.api.Delete(ctx, dnsRecordID, body, opts...)
+		return err
+	})
+	return resp, err
+}
+
+func (r *retryingAPI) List(ctx context.Context, params dns.RecordListParams, opts ...option.RequestOption) (*pagination.V4PagePaginationArray[dns.RecordResponse], error) {
+	var resp *pagination.V4PagePaginationArray[dns.RecordResponse]
+	err := r.do(ctx, "list", true, func(ctx context.Context) error {
+		var err error
+		resp, err = r.api.List(ctx, params, opts...)
+		return err
+	})
+	return resp, err
+}
+
+func (r *retryingAPI) Update(ctx context.Context, dnsRecordID string, params dns.RecordUpdateParams, opts ...option.RequestOption) (*dns.RecordResponse, error) {
+	var resp *dns.RecordResponse
+	err := r.do(ctx, "update", true, func(ctx context.Context) error {
+		var err error
+		resp, err = r.api.Update(ctx, dnsRecordID, params, opts...)
+		return err
+	})
+	return resp, err
+}
+
+func (r *retryingAPI) Batch(ctx context.Context, params dns.RecordBatchParams, opts ...option.RequestOption) (*dns.RecordBatchResponse, error) {
+	var resp *dns.RecordBatchResponse
+	err := r.do(ctx, "batch", false, func(ctx context.Context) error {
+		var err error
+		resp, err = r.api.Batch(ctx, params, opts...)
+		return err
+	})
+	return resp, err
+}
+
+// do calls call, retrying transient failures. When idempotent is false, a 5xx
+// may come after the change was applied, so only 429 is retried.
+func (r *retryingAPI) do(ctx context.Context, operation string, idempotent bool, call func(context.Context) error) (err error) {
+	attempts := r.policy.MaxAttempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+
+	ctx, span := tracing.Start(ctx, "cloudflare.dns."+operation, tracing.String("cloudflare.operation", operation))
+	start := r.now()
+	defer func() {
+		span.RecordError(err)
+		span.End()
+		result := "success"
+		if err != nil {
+			result = "error"
+		}
+		apiCallsMetric.Add(1, metrics.String("operation", operation), metrics.String("result", result))
+		apiDurationMetric.Record(float64(r.now().Sub(start))/float64(time.Millisecond), metrics.String("operation", operation))
+	}()
+
+	for attempt := 1; ; attempt++ {
+		span.SetAttributes(tracing.Int("cloudflare.attempts", attempt))
+		waitStart := r.now()
+		if err := r.limiter.Wait(ctx); err != nil {
+			return errors.WithStack(err)
+		}
+		if wait := r.now().Sub(waitStart); wait >= time.Millisecond {
+			span.AddEvent("rate_limited", tracing.Milliseconds("wait_ms", wait))
+		}
+		err = call(ctx)
+		if err == nil || attempt >= attempts {
+			return err
+		}
+
+		delay, ok := r.retryDelay(err, attempt, idempotent)
+		if !ok {
+			return err
+		}
+		log.Printf("Cloudflare API %s failed (attempt %d/%d), retrying in %s: %v", operation, attempt, attempts, delay, err)
+		apiRetriesMetric.Add(1, metrics.String("operation", operation))
+		span.AddEvent("retry",
+			tracing.Int("attempt", attempt),
+			tracing.Milliseconds("delay_ms", delay),
+			tracing.String("error", err.Error()))
+		if sleepErr := r.sleep(ctx, delay); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// retryDelay reports whether err is transient and how long to wait before the
+// next attempt, preferring the server's Retry-After over the backoff.
+func (r *retryingAPI) retryDelay(err error, attempt int, idempotent bool) (time.Duration, bool) {
+	var apiErr *cf.Error
+	if !errors.As(err, &apiErr) || !isRetryableStatus(apiErr.StatusCode, idempotent) {
+		return 0, false
+	}
+
+	if apiErr.Response != nil {
+		if delay, ok := parseRetryAfter(apiErr.Response.Header.Get("Retry-After"), r.now()); ok {
+			if r.policy.MaxDelay > 0 && delay > r.policy.MaxDelay {
+				return 0, false
+			}
+			return delay, true
+		}
+	}
+
+	delay := r.policy.BaseDelay << (attempt - 1)
+	if r.policy.MaxDelay > 0 && (delay > r.policy.MaxDelay || delay <= 0) {
+		delay = r.policy.MaxDelay
+	}
+	return delay, true
+}
+
+func isRetryableStatus(status int, idempotent bool) bool {
+	return status == http.StatusTooManyRequests || (idempotent && status >= http.StatusInternalServerError)
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		delay := at.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// SleepContext waits for d, returning the error of ctx if it is done first.
+func SleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
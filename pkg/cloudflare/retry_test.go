@@ -0,0 +1,259 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/tracing"
+	cf "github.com/cloudflare/cloudflare-go/v6"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+	"github.com/cloudflare/cloudflare-go/v6/option"
+	"github.com/cloudflare/cloudflare-go/v6/packages/pagination"
+)
+
+func newAPIError(status int, retryAfter string) *cf.Error {
+	req, _ := http.NewRequest(http.MethodGet, "https://api.cloudflare.com/client/v4/zones/zone/dns_records", nil)
+	resp := &http.Response{StatusCode: status, Header: http.Header{}}
+	if retryAfter != "" {
+		resp.Header.Set("Retry-After", retryAfter)
+	}
+	return &cf.Error{StatusCode: status, Request: req, Response: resp}
+}
+
+// flakyListAPI fails List with the queued errors before succeeding.
+type flakyListAPI struct {
+	fakeCloudflareAPI
+	errs  []error
+	calls int
+}
+
+func (f *flakyListAPI) List(ctx context.Context, params dns.RecordListParams, opts ...option.RequestOption) (*pagination.V4PagePaginationArray[dns.RecordResponse], error) {
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+	return f.fakeCloudflareAPI.List(ctx, params, opts...)
+}
+
+func newTestRetryingAPI(api cloudflareAPI, policy RetryPolicy, delays *[]time.Duration) *retryingAPI {
+	r := newRetryingAPI(api, policy, nil)
+	r.sleep = func(ctx context.Context, d time.Duration) error {
+		*delays = append(*delays, d)
+		return nil
+	}
+	r.now = func() time.Time {
+		return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return r
+}
+
+func TestRetryingAPI_RetriesTransientErrors(t *testing.T) {
+	api := &flakyListAPI{errs: []error{newAPIError(502, ""), newAPIError(429, "3")}}
+	var delays []time.Duration
+	r := newTestRetryingAPI(api, RetryPolicy{MaxAttempts: 4, BaseDelay: 100 * time.Millisecond, MaxDelay: 10 * time.Second}, &delays)
+
+	if _, err := r.List(context.Background(), dns.RecordListParams{}); err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if api.calls != 3 {
+		t.Errorf("expected 3 calls, got %d", api.calls)
+	}
+	want := []time.Duration{100 * time.Millisecond, 3 * time.Second}
+	if len(delays) != len(want) || delays[0] != want[0] || delays[1] != want[1] {
+		t.Errorf("expected delays %v, got %v", want, delays)
+	}
+}
+
+func TestRetryingAPI_DoesNotRetryClientErrors(t *testing.T) {
+	api := &flakyListAPI{errs: []error{newAPIError(400, "")}}
+	var delays []time.Duration
+	r := newTestRetryingAPI(api, DefaultRetryPolicy, &delays)
+
+	if _, err := r.List(context.Background(), dns.RecordListParams{}); err == nil {
+		t.Fatal("expected error")
+	}
+	if api.calls != 1 {
+		t.Errorf("expected a single call, got %d", api.calls)
+	}
+}
+
+func TestRetryingAPI_GivesUpAfterMaxAttempts(t *testing.T) {
+	api := &flakyListAPI{errs: []error{newAPIError(500, ""), newAPIError(500, ""), newAPIError(500, "")}}
+	var delays []time.Duration
+	r := newTestRetryingAPI(api, RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Second}, &delays)
+
+	if _, err := r.List(context.Background(), dns.RecordListParams{}); err == nil {
+		t.Fatal("expected error")
+	}
+	if api.calls != 2 {
+		t.Errorf("expected 2 calls, got %d", api.calls)
+	}
+}
+
+// flakyWriteAPI fails New and Batch with the queued errors before succeeding.
+type flakyWriteAPI struct {
+	fakeCloudflareAPI
+	errs  []error
+	calls int
+}
+
+func (f *flakyWriteAPI) fail() error {
+	f.calls++
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func (f *flakyWriteAPI) New(ctx context.Context, params dns.RecordNewParams, opts ...option.RequestOption) (*dns.RecordResponse, error) {
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return f.fakeCloudflareAPI.New(ctx, params, opts...)
+}
+
+func (f *flakyWriteAPI) Batch(ctx context.Context, params dns.RecordBatchParams, opts ...option.RequestOption) (*dns.RecordBatchResponse, error) {
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return f.fakeCloudflareAPI.Batch(ctx, params, opts...)
+}
+
+func TestRetryingAPI_RetriesNonIdempotentWritesOnlyWhenRateLimited(t *testing.T) {
+	// A 5xx may come after the records were created, so retrying would duplicate them
+	api := &flakyWriteAPI{errs: []error{newAPIError(502, ""), newAPIError(504, "")}}
+	var delays []time.Duration
+	r := newTestRetryingAPI(api, DefaultRetryPolicy, &delays)
+	if _, err := r.New(context.Background(), dns.RecordNewParams{}); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := r.Batch(context.Background(), dns.RecordBatchParams{}); err == nil {
+		t.Fatal("expected error")
+	}
+	if api.calls != 2 {
+		t.Errorf("expected a single call each after 5xx, got %d", api.calls)
+	}
+
+	api = &flakyWriteAPI{errs: []error{newAPIError(429, ""), newAPIError(429, "")}}
+	r = newTestRetryingAPI(api, DefaultRetryPolicy, &delays)
+	if _, err := r.New(context.Background(), dns.RecordNewParams{}); err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	if _, err := r.Batch(context.Background(), dns.RecordBatchParams{}); err != nil {
+		t.Fatalf("Batch returned error: %v", err)
+	}
+	if api.calls != 4 {
+		t.Errorf("expected a retry each after 429, got %d calls", api.calls)
+	}
+}
+
+func TestRetryingAPI_RetryAfterBeyondMaxDelay(t *testing.T) {
+	api := &flakyListAPI{errs: []error{newAPIError(429, "120")}}
+	var delays []time.Duration
+	r := newTestRetryingAPI(api, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Minute}, &delays)
+
+	if _, err := r.List(context.Background(), dns.RecordListParams{}); err == nil {
+		t.Fatal("expected error when Retry-After exceeds MaxDelay")
+	}
+	if api.calls != 1 {
+		t.Errorf("expected a single call, got %d", api.calls)
+	}
+}
+
+func TestRetryingAPI_TracesRetries(t *testing.T) {
+	type span struct {
+		Name       string `json:"name"`
+		Attributes []struct {
+			Key   string `json:"key"`
+			Value struct {
+				IntValue string `json:"intValue"`
+			} `json:"value"`
+		} `json:"attributes"`
+		Events []struct {
+			Name string `json:"name"`
+		} `json:"events"`
+	}
+	var spans []span
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []span `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}))
+	defer collector.Close()
+
+	shutdown, err := tracing.Setup(tracing.Config{Endpoint: collector.URL})
+	if err != nil {
+		t.Fatalf("tracing.Setup() error = %v", err)
+	}
+	api := &flakyListAPI{errs: []error{newAPIError(502, ""), newAPIError(503, "")}}
+	var delays []time.Duration
+	r := newTestRetryingAPI(api, DefaultRetryPolicy, &delays)
+	if _, err := r.List(context.Background(), dns.RecordListParams{}); err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+
+	if len(spans) != 1 || spans[0].Name != "cloudflare.dns.list" {
+		t.Fatalf("expected a single cloudflare.dns.list span, got %+v", spans)
+	}
+	retries := 0
+	for _, event := range spans[0].Events {
+		if event.Name == "retry" {
+			retries++
+		}
+	}
+	if retries != 2 {
+		t.Errorf("expected 2 retry events, got %+v", spans[0].Events)
+	}
+	for _, attr := range spans[0].Attributes {
+		if attr.Key == "cloudflare.attempts" && attr.Value.IntValue != "3" {
+			t.Errorf("expected 3 attempts, got %s", attr.Value.IntValue)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "5", want: 5 * time.Second, wantOK: true},
+		{value: "Mon, 01 Jan 2024 00:00:30 GMT", want: 30 * time.Second, wantOK: true},
+		{value: "Sun, 31 Dec 2023 23:59:00 GMT", want: 0, wantOK: true},
+		{value: "", wantOK: false},
+		{value: "soon", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudflare/cloudflare-go/v6/option"
+	"github.com/cockroachdb/errors"
+)
+
+// spectrumAPI is the subset of the generic Cloudflare client used for Spectrum.
+// Applications are read and written as raw JSON so that updating the origin
+// leaves every other setting of the application exactly as it was.
+type spectrumAPI interface {
+	Get(ctx context.Context, path string, params interface{}, res interface{}, opts ...option.RequestOption) error
+	Put(ctx context.Context, path string, params interface{}, res interface{}, opts ...option.RequestOption) error
+}
+
+// spectrumReadOnlyFields are returned by the API but must not be sent back.
+var spectrumReadOnlyFields = []string{"id", "created_on", "modified_on"}
+
+type spectrumAppEnvelope struct {
+	Result map[string]interface{} `json:"result"`
+}
+
+// SpectrumClient reads and updates the origins of the Spectrum applications of a zone.
+type SpectrumClient struct {
+	api     spectrumAPI
+	zoneID  string
+	limiter *RateLimiter
+}
+
+// NewSpectrumClient returns a client for the Spectrum applications of zoneID.
+// It accepts the same options as NewDNSClient; only credentials, the request
+// timeout and the rate limiter apply.
+func NewSpectrumClient(apiToken, zoneID string, opts ...ClientOption) *SpectrumClient {
+	options := newClientOptions(opts)
+	return &SpectrumClient{
+		api:     newCloudflareClient(apiToken, options),
+		zoneID:  zoneID,
+		limiter: options.limiter,
+	}
+}
+
+// Origins returns the origin_direct addresses of the application appID, such
+// as "tcp://192.0.2.1:22".
+func (c *SpectrumClient) Origins(ctx context.Context, appID string) ([]string, error) {
+	app, err := c.getApp(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	return originDirect(app), nil
+}
+
+// SetOrigins replaces the origin_direct addresses of the application appID.
+func (c *SpectrumClient) SetOrigins(ctx context.Context, appID string, origins []string) error {
+	app, err := c.getApp(ctx, appID)
+	if err != nil {
+		return err
+	}
+	if app["origin_dns"] != nil {
+		return errors.Newf("Spectrum application %s uses origin_dns, not origin_direct", appID)
+	}
+	for _, field := range spectrumReadOnlyFields {
+		delete(app, field)
+	}
+	app["origin_direct"] = origins
+
+	if err := c.limiter.Wait(ctx); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := c.api.Put(ctx, c.appPath(appID), app, nil); err != nil {
+		return errors.Wrapf(err, "failed to update Spectrum application %s", appID)
+	}
+	return nil
+}
+
+func (c *SpectrumClient) getApp(ctx context.Context, appID string) (map[string]interface{}, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var envelope spectrumAppEnvelope
+	if err := c.api.Get(ctx, c.appPath(appID), nil, &envelope); err != nil {
+		return nil, errors.Wrapf(err, "failed to get Spectrum application %s", appID)
+	}
+	if envelope.Result == nil {
+		return nil, errors.Newf("Spectrum application %s not found", appID)
+	}
+	return envelope.Result, nil
+}
+
+func (c *SpectrumClient) appPath(appID string) string {
+	return fmt.Sprintf("zones/%s/spectrum/apps/%s", c.zoneID, appID)
+}
+
+func originDirect(app map[string]interface{}) []string {
+	values, _ := app["origin_direct"].([]interface{})
+	origins := make([]string, 0, len(values))
+	for _, value := range values {
+		if origin, ok := value.(string); ok {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
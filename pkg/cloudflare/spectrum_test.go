@@ -0,0 +1,76 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go/v6/option"
+)
+
+type fakeSpectrumAPI struct {
+	app     string
+	putPath string
+	putBody map[string]interface{}
+}
+
+func (f *fakeSpectrumAPI) Get(ctx context.Context, path string, params interface{}, res interface{}, opts ...option.RequestOption) error {
+	return json.Unmarshal([]byte(`{"success":true,"result":`+f.app+`}`), res)
+}
+
+func (f *fakeSpectrumAPI) Put(ctx context.Context, path string, params interface{}, res interface{}, opts ...option.RequestOption) error {
+	f.putPath = path
+	f.putBody = params.(map[string]interface{})
+	return nil
+}
+
+func TestSpectrumClientSetOrigins(t *testing.T) {
+	api := &fakeSpectrumAPI{app: `{
+		"id": "app-1",
+		"created_on": "2024-01-01T00:00:00Z",
+		"modified_on": "2024-01-01T00:00:00Z",
+		"protocol": "tcp/22",
+		"dns": {"type": "CNAME", "name": "ssh.example.com"},
+		"origin_direct": ["tcp://192.0.2.1:22"],
+		"ip_firewall": true
+	}`}
+	client := &SpectrumClient{api: api, zoneID: "zone"}
+
+	origins, err := client.Origins(context.Background(), "app-1")
+	if err != nil {
+		t.Fatalf("Origins returned error: %v", err)
+	}
+	if len(origins) != 1 || origins[0] != "tcp://192.0.2.1:22" {
+		t.Errorf("unexpected origins %v", origins)
+	}
+
+	if err := client.SetOrigins(context.Background(), "app-1", []string{"tcp://198.51.100.1:22"}); err != nil {
+		t.Fatalf("SetOrigins returned error: %v", err)
+	}
+	if api.putPath != "zones/zone/spectrum/apps/app-1" {
+		t.Errorf("unexpected path %s", api.putPath)
+	}
+	for _, field := range spectrumReadOnlyFields {
+		if _, ok := api.putBody[field]; ok {
+			t.Errorf("read-only field %s was sent back", field)
+		}
+	}
+	if api.putBody["protocol"] != "tcp/22" || api.putBody["ip_firewall"] != true {
+		t.Errorf("other settings were not preserved: %v", api.putBody)
+	}
+	if got, ok := api.putBody["origin_direct"].([]string); !ok || len(got) != 1 || got[0] != "tcp://198.51.100.1:22" {
+		t.Errorf("unexpected origin_direct %v", api.putBody["origin_direct"])
+	}
+}
+
+func TestSpectrumClientSetOriginsRejectsOriginDNS(t *testing.T) {
+	api := &fakeSpectrumAPI{app: `{"id": "app-1", "protocol": "tcp/22", "origin_dns": {"name": "origin.example.com"}}`}
+	client := &SpectrumClient{api: api, zoneID: "zone"}
+
+	if err := client.SetOrigins(context.Background(), "app-1", []string{"tcp://198.51.100.1:22"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if api.putBody != nil {
+		t.Error("application should not have been updated")
+	}
+}
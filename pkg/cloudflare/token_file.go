@@ -0,0 +1,88 @@
+package cloudflare
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go/v6/option"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrEmptyTokenFile is returned when an API token file contains no token.
+var ErrEmptyTokenFile = errors.New("API token file is empty")
+
+// WithAPITokenFile authenticates with the token stored in path instead of the
+// API token passed to the constructor. The file is re-read whenever it
+// changes, so a rotated secret mount is picked up without a restart.
+func WithAPITokenFile(path string) ClientOption {
+	return func(o *clientOptions) {
+		o.tokenFile = &tokenFile{path: path}
+	}
+}
+
+// ReadTokenFile returns the API token stored in path, without surrounding whitespace.
+func ReadTokenFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read API token file %s", path)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", errors.Wrapf(ErrEmptyTokenFile, "%s", path)
+	}
+	return token, nil
+}
+
+// tokenFile caches the token read from a file until the file changes.
+type tokenFile struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	token   string
+}
+
+// current returns the token in the file, re-reading it if the file changed
+// since the last read. If the file cannot be read the last token is kept.
+func (f *tokenFile) current() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return f.token, errors.Wrapf(err, "failed to stat API token file %s", f.path)
+	}
+	if f.token != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.token, nil
+	}
+
+	token, err := ReadTokenFile(f.path)
+	if err != nil {
+		return f.token, err
+	}
+	if f.token != "" && token != f.token {
+		log.Printf("Reloaded API token from %s", f.path)
+	}
+	f.token = token
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	return token, nil
+}
+
+// middleware sets the Authorization header of every request from the file.
+func (f *tokenFile) middleware(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+	token, err := f.current()
+	if err != nil {
+		if token == "" {
+			return nil, err
+		}
+		log.Printf("Using previous API token: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return next(req)
+}
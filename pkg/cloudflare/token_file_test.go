@@ -0,0 +1,94 @@
+package cloudflare
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+func TestReadTokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("  secret\n"), 0o600); err != nil {
+		t.Fatalf("failed to write token: %v", err)
+	}
+	token, err := ReadTokenFile(path)
+	if err != nil {
+		t.Fatalf("ReadTokenFile returned error: %v", err)
+	}
+	if token != "secret" {
+		t.Errorf("expected trimmed token, got %q", token)
+	}
+
+	if err := os.WriteFile(path, []byte("\n"), 0o600); err != nil {
+		t.Fatalf("failed to write token: %v", err)
+	}
+	if _, err := ReadTokenFile(path); !errors.Is(err, ErrEmptyTokenFile) {
+		t.Errorf("expected ErrEmptyTokenFile, got %v", err)
+	}
+}
+
+func TestDNSClientTokenFileRotation(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"success":true,"errors":[],"messages":[],"result":[],"result_info":{"page":1,"per_page":100,"count":0,"total_count":0}}`))
+	}))
+	defer server.Close()
+	t.Setenv("CLOUDFLARE_BASE_URL", server.URL)
+
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+		t.Fatalf("failed to write token: %v", err)
+	}
+
+	client, err := NewDNSClient("first", "zone", false, 60, WithAPITokenFile(path))
+	if err != nil {
+		t.Fatalf("NewDNSClient returned error: %v", err)
+	}
+	if _, err := client.GetDNSRecords(context.Background(), "example.com", "A"); err != nil {
+		t.Fatalf("GetDNSRecords returned error: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("second\n"), 0o600); err != nil {
+		t.Fatalf("failed to write token: %v", err)
+	}
+	// Make the change visible on filesystems with coarse timestamps
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("failed to touch token: %v", err)
+	}
+	if _, err := client.GetDNSRecords(context.Background(), "example.com", "A"); err != nil {
+		t.Fatalf("GetDNSRecords returned error: %v", err)
+	}
+
+	// A broken file keeps the last good token
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("failed to remove token: %v", err)
+	}
+	if _, err := client.GetDNSRecords(context.Background(), "example.com", "A"); err != nil {
+		t.Fatalf("GetDNSRecords returned error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"Bearer first", "Bearer second", "Bearer second"}
+	if len(seen) != len(want) {
+		t.Fatalf("expected %d requests, got %v", len(want), seen)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Errorf("request %d: expected %q, got %q", i, want[i], seen[i])
+		}
+	}
+}
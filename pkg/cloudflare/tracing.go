@@ -0,0 +1,37 @@
+package cloudflare
+
+import (
+	"net/http"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/tracing"
+	"github.com/cloudflare/cloudflare-go/v6/option"
+	"github.com/cockroachdb/errors"
+)
+
+// traceMiddleware records every Cloudflare API request, including each retry
+// of the same call, as a client span.
+func traceMiddleware(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+	ctx, span := tracing.StartClient(req.Context(), "HTTP "+req.Method,
+		tracing.String("http.request.method", req.Method),
+		tracing.String("server.address", req.URL.Host),
+		tracing.String("url.path", req.URL.Path))
+	if span == nil {
+		return next(req)
+	}
+	defer span.End()
+
+	resp, err := next(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		return resp, err
+	}
+	span.SetAttributes(tracing.Int("http.response.status_code", resp.StatusCode))
+	// The Ray ID identifies the request when asking Cloudflare support about it
+	if ray := resp.Header.Get("Cf-Ray"); ray != "" {
+		span.SetAttributes(tracing.String("cloudflare.ray_id", ray))
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.RecordError(errors.Newf("status %d", resp.StatusCode))
+	}
+	return resp, nil
+}
@@ -0,0 +1,130 @@
+// Package consulcatalog reads the healthy instances of a service from the
+// Consul health API and waits for them to change with blocking queries.
+package consulcatalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// wait is how long a blocking query waits before Consul answers with an
+// unchanged index.
+const wait = 5 * time.Minute
+
+// Query selects the instances of a service.
+type Query struct {
+	Service string
+	// Tags must all be set on an instance.
+	Tags []string
+	// Datacenter defaults to the datacenter of the agent.
+	Datacenter string
+}
+
+// Instance is a healthy instance of a service.
+type Instance struct {
+	// Address is the address of the service, or of its node when the
+	// service has none.
+	Address string
+	// WANAddress is the WAN tagged address of the service or its node, or
+	// Address when neither has one.
+	WANAddress string
+}
+
+// Catalog reads services from a Consul agent.
+type Catalog struct {
+	address    string
+	token      string
+	httpClient *http.Client
+}
+
+// New returns a catalog for the Consul agent at address, such as
+// http://127.0.0.1:8500. token is the ACL token and may be empty.
+func New(address, token string) *Catalog {
+	return &Catalog{
+		address: strings.TrimSuffix(address, "/"),
+		token:   token,
+		// Blocking queries are bounded by the caller's context
+		httpClient: &http.Client{},
+	}
+}
+
+type healthEntry struct {
+	Node struct {
+		Address         string
+		TaggedAddresses map[string]string
+	}
+	Service struct {
+		Address         string
+		TaggedAddresses map[string]struct {
+			Address string
+		}
+	}
+}
+
+// Healthy returns the instances of the service whose checks all pass,
+// together with the index of the answer. With a non-zero index, it blocks
+// until the instances change after index or the wait time passes, in which
+// case the index is unchanged.
+func (c *Catalog) Healthy(ctx context.Context, q Query, index uint64) ([]Instance, uint64, error) {
+	query := url.Values{"passing": {"true"}}
+	for _, tag := range q.Tags {
+		query.Add("tag", tag)
+	}
+	if q.Datacenter != "" {
+		query.Set("dc", q.Datacenter)
+	}
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", fmt.Sprintf("%ds", int(wait.Seconds())))
+	}
+	u := fmt.Sprintf("%s/v1/health/service/%s?%s", c.address, url.PathEscape(q.Service), query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "consul request failed")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, errors.Newf("consul returned status %d", resp.StatusCode)
+	}
+	next, err := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if err != nil {
+		return nil, 0, errors.New("consul response has no X-Consul-Index header")
+	}
+
+	var entries []healthEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to decode consul response")
+	}
+	instances := make([]Instance, 0, len(entries))
+	for _, entry := range entries {
+		instance := Instance{Address: entry.Service.Address}
+		if instance.Address == "" {
+			instance.Address = entry.Node.Address
+		}
+		instance.WANAddress = entry.Service.TaggedAddresses["wan"].Address
+		if instance.WANAddress == "" {
+			instance.WANAddress = entry.Node.TaggedAddresses["wan"]
+		}
+		if instance.WANAddress == "" {
+			instance.WANAddress = instance.Address
+		}
+		instances = append(instances, instance)
+	}
+	return instances, next, nil
+}
@@ -0,0 +1,64 @@
+package consulcatalog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHealthy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/v1/health/service/web" || query.Get("passing") != "true" || query.Get("dc") != "tokyo" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if tags := query["tag"]; !reflect.DeepEqual(tags, []string{"primary", "v2"}) {
+			t.Errorf("expected both tags, got %v", tags)
+		}
+		if r.Header.Get("X-Consul-Token") != "secret" {
+			t.Errorf("missing ACL token")
+		}
+		w.Header().Set("X-Consul-Index", "42")
+		fmt.Fprint(w, `[
+			{"Node": {"Address": "10.0.0.1", "TaggedAddresses": {"wan": "203.0.113.1"}}, "Service": {"Address": ""}},
+			{"Node": {"Address": "10.0.0.2"}, "Service": {"Address": "10.0.1.2", "TaggedAddresses": {"wan": {"Address": "203.0.113.2"}}}},
+			{"Node": {"Address": "10.0.0.3"}, "Service": {"Address": "10.0.1.3"}}
+		]`)
+	}))
+	defer server.Close()
+
+	instances, index, err := New(server.URL+"/", "secret").Healthy(context.Background(), Query{Service: "web", Tags: []string{"primary", "v2"}, Datacenter: "tokyo"}, 0)
+	if err != nil {
+		t.Fatalf("Healthy returned error: %v", err)
+	}
+	if index != 42 {
+		t.Errorf("expected index 42, got %d", index)
+	}
+	want := []Instance{
+		{Address: "10.0.0.1", WANAddress: "203.0.113.1"},
+		{Address: "10.0.1.2", WANAddress: "203.0.113.2"},
+		{Address: "10.0.1.3", WANAddress: "10.0.1.3"},
+	}
+	if !reflect.DeepEqual(instances, want) {
+		t.Errorf("Healthy() = %+v, want %+v", instances, want)
+	}
+}
+
+func TestHealthy_Blocking(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("index") != "42" || r.URL.Query().Get("wait") == "" {
+			t.Errorf("expected a blocking query, got %s", r.URL)
+		}
+		w.Header().Set("X-Consul-Index", "43")
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	instances, index, err := New(server.URL, "").Healthy(context.Background(), Query{Service: "web"}, 42)
+	if err != nil || index != 43 || len(instances) != 0 {
+		t.Errorf("expected no instances at index 43, got %+v, %d, %v", instances, index, err)
+	}
+}
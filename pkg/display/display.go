@@ -0,0 +1,140 @@
+// Package display formats the reports of the daemon as the tables printed
+// by the command line tools.
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/history"
+)
+
+// Status prints a table of the state of every origin.
+func Status(w io.Writer, origins []gslb.OriginReport, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ORIGIN\tZONE\tHEALTH\tCURRENT IPS\tPRIORITY\tLAST CHECK\tLAST CHANGE\tRESULT")
+	for _, origin := range origins {
+		lastChange := "-"
+		if origin.LastFailover != nil {
+			lastChange = Age(now.Sub(origin.LastFailover.Time)) + " ago"
+		}
+		lastCheck := "never"
+		if origin.LastCheck != nil {
+			lastCheck = Age(now.Sub(*origin.LastCheck)) + " ago"
+		}
+		priority := "-"
+		if origin.CurrentPriority != 0 || origin.MaxPriority != 0 {
+			priority = fmt.Sprintf("%d/%d", origin.CurrentPriority, origin.MaxPriority)
+		}
+		health := origin.Health
+		if origin.Hold != nil {
+			health += ", held by " + origin.Hold.By
+		}
+		result := origin.LastResult
+		if origin.LastError != "" {
+			result += ": " + origin.LastError
+		}
+		if result == "" {
+			result = "-"
+		}
+		fmt.Fprintf(tw, "%s (%s)\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			origin.Name, origin.RecordType, origin.Zone, health, IPs(origin.CurrentIPs),
+			priority, lastCheck, lastChange, strings.ReplaceAll(result, "\n", " "))
+	}
+	return tw.Flush()
+}
+
+// FailingNotifications lists the notifications whose last sends all
+// failed, so that a broken webhook is noticed before the next failover.
+func FailingNotifications(w io.Writer, notifications []gslb.NotificationReport, now time.Time) error {
+	first := true
+	for _, n := range notifications {
+		if n.Status != gslb.NotificationFailing {
+			continue
+		}
+		if first {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+			first = false
+		}
+		since := ""
+		if n.LastSuccess != nil {
+			since = ", last success " + Age(now.Sub(*n.LastSuccess)) + " ago"
+		}
+		if _, err := fmt.Fprintf(w, "WARNING: notification %s (%s) failed %d times in a row%s: %s\n",
+			n.Name, n.Type, n.ConsecutiveFailures, since, strings.ReplaceAll(n.LastError, "\n", " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Events prints a table of recorded events.
+func Events(w io.Writer, events []history.Event) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tTYPE\tORIGIN\tDETAIL")
+	for _, event := range events {
+		fmt.Fprintf(tw, "%s\t%s\t%s (%s)\t%s\n", event.Time.Local().Format(time.RFC3339), event.Type, event.Origin, event.RecordType, eventDetail(event))
+	}
+	return tw.Flush()
+}
+
+// eventDetail describes event in a line.
+func eventDetail(event history.Event) string {
+	switch event.Type {
+	case history.TypeHealth:
+		if event.Healthy != nil && *event.Healthy {
+			return event.IP + " is healthy"
+		}
+		return event.IP + " is unhealthy"
+	case history.TypeDNSChange:
+		detail := fmt.Sprintf("%s -> %s: %s", IPs(event.OldIPs), IPs(event.NewIPs), event.Result)
+		if event.State != "" {
+			detail += " (" + event.State + ")"
+		}
+		for _, extra := range []string{event.Reason, event.Error} {
+			if extra != "" {
+				detail += ": " + extra
+			}
+		}
+		return detail
+	case history.TypeAvailability:
+		up := 0
+		if event.Up != nil {
+			up = *event.Up
+		}
+		return fmt.Sprintf("%d of %d checks found a healthy IP in the hour", up, event.Checks)
+	default:
+		return ""
+	}
+}
+
+// Age formats d coarsely, such as 45s, 12m, 3h12m or 2d4h.
+func Age(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
+
+// IPs joins ips with commas, or returns "-" for none.
+func IPs(ips []string) string {
+	if len(ips) == 0 {
+		return "-"
+	}
+	return strings.Join(ips, ",")
+}
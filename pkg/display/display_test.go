@@ -0,0 +1,80 @@
+package display
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/history"
+)
+
+func TestStatus(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	lastCheck := now.Add(-30 * time.Second)
+	origins := []gslb.OriginReport{{
+		Name: "www.example.com", Zone: "example.com", RecordType: "A", Health: gslb.HealthFailover,
+		CurrentIPs: []string{"198.51.100.1", "198.51.100.2"}, CurrentPriority: 50, MaxPriority: 100,
+		LastCheck: &lastCheck, LastResult: gslb.CheckResultChanged, Hold: &gslb.Hold{By: "alice"},
+	}}
+
+	var out strings.Builder
+	if err := Status(&out, origins, now); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "ORIGIN") {
+		t.Fatalf("expected a header and a row, got %q", out.String())
+	}
+	for _, want := range []string{"www.example.com (A)", "failover, held by alice", "198.51.100.1,198.51.100.2", "50/100", "30s ago", "changed"} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("expected %q in %q", want, lines[1])
+		}
+	}
+}
+
+func TestFailingNotifications(t *testing.T) {
+	var out strings.Builder
+	err := FailingNotifications(&out, []gslb.NotificationReport{
+		{Name: "oncall", Type: "slack", Status: gslb.NotificationFailing, ConsecutiveFailures: 3, LastError: "status: 500"},
+		{Name: "notifications[1]", Type: "ntfy", Status: gslb.NotificationOK},
+	}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "\nWARNING: notification oncall (slack) failed 3 times in a row: status: 500\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	healthy := false
+	var out strings.Builder
+	err := Events(&out, []history.Event{
+		{Time: time.Now(), Type: history.TypeHealth, Origin: "www.example.com", RecordType: "A", IP: "192.0.2.1", Healthy: &healthy},
+		{Time: time.Now(), Type: history.TypeDNSChange, Origin: "www.example.com", RecordType: "A", OldIPs: []string{"192.0.2.1"}, NewIPs: []string{"198.51.100.1"}, Result: "changed"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"192.0.2.1 is unhealthy", "192.0.2.1 -> 198.51.100.1: changed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in %q", want, out.String())
+		}
+	}
+}
+
+func TestAge(t *testing.T) {
+	tests := map[time.Duration]string{
+		-time.Second:                 "0s",
+		45 * time.Second:             "45s",
+		12 * time.Minute:             "12m",
+		3*time.Hour + 12*time.Minute: "3h12m",
+		52 * time.Hour:               "2d4h",
+	}
+	for d, want := range tests {
+		if got := Age(d); got != want {
+			t.Errorf("Age(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
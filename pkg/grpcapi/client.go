@@ -0,0 +1,145 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+)
+
+// Client calls the methods of the remote probers on a server, over
+// cleartext HTTP/2 or over TLS.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// StatusError is a call that failed with a gRPC status other than OK.
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("gRPC status %d: %s", e.Code, e.Message)
+}
+
+// NewClient returns a client of the server at addr (host:port) that sends
+// token as its bearer token unless it is empty. With tlsConfig the server is
+// called over TLS, presenting the client certificate of tlsConfig if any.
+func NewClient(addr, token string, tlsConfig *tls.Config) *Client {
+	protocols := new(http.Protocols)
+	scheme := "http"
+	if tlsConfig != nil {
+		protocols.SetHTTP2(true)
+		scheme = "https"
+	} else {
+		protocols.SetUnencryptedHTTP2(true)
+	}
+	return &Client{
+		baseURL: scheme + "://" + addr,
+		token:   token,
+		http: &http.Client{Transport: &http.Transport{
+			Protocols:       protocols,
+			TLSClientConfig: tlsConfig,
+		}},
+	}
+}
+
+// Register registers the prober name in region and returns the checks it
+// should run and how often.
+func (c *Client) Register(ctx context.Context, name, region string) ([]gslb.RemoteCheck, time.Duration, error) {
+	req := registerRequest{Name: name, Region: region}
+	message, err := c.call(ctx, "Register", req.marshal)
+	if err != nil {
+		return nil, 0, err
+	}
+	return decodeAssignment(message)
+}
+
+// ReportResults passes the results of the checks of prober on to the server.
+func (c *Client) ReportResults(ctx context.Context, prober string, results []gslb.RemoteResult) error {
+	_, err := c.call(ctx, "ReportResults", func(e *encoder) { encodeReport(e, prober, results) })
+	return err
+}
+
+// IsNotRegistered reports whether err tells that the prober has to register
+// again.
+func IsNotRegistered(err error) bool {
+	var status *StatusError
+	return errors.As(err, &status) && status.Code == codeFailedPrecondition
+}
+
+// call runs the unary method with the request written by encode and returns
+// the response message.
+func (c *Client) call(ctx context.Context, method string, encode func(*encoder)) ([]byte, error) {
+	var e encoder
+	encode(&e)
+	body := make([]byte, 5, 5+len(e.buf))
+	binary.BigEndian.PutUint32(body[1:], uint32(len(e.buf)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/"+ServiceName+"/"+method, bytes.NewReader(append(body, e.buf...)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected HTTP status %s", method, resp.Status)
+	}
+
+	var message []byte
+	var prefix [5]byte
+	switch _, err := io.ReadFull(resp.Body, prefix[:]); {
+	case errors.Is(err, io.EOF):
+		// Trailers-only response of a failed call
+	case err != nil:
+		return nil, fmt.Errorf("%s: reading the response: %w", method, err)
+	default:
+		length := binary.BigEndian.Uint32(prefix[1:])
+		if length > maxMessageSize {
+			return nil, fmt.Errorf("%s: the response message is larger than %d bytes", method, maxMessageSize)
+		}
+		message = make([]byte, length)
+		if _, err := io.ReadFull(resp.Body, message); err != nil {
+			return nil, fmt.Errorf("%s: reading the response: %w", method, err)
+		}
+	}
+	// The trailers are only available once the body was read to the end
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return nil, fmt.Errorf("%s: reading the response: %w", method, err)
+	}
+
+	status, statusMessage := resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		status, statusMessage = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid grpc-status %q", method, status)
+	}
+	if code != codeOK {
+		if decoded, err := url.PathUnescape(statusMessage); err == nil {
+			statusMessage = decoded
+		}
+		return nil, &StatusError{Code: code, Message: statusMessage}
+	}
+	return message, nil
+}
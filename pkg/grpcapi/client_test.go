@@ -0,0 +1,82 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/apiauth"
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+)
+
+func TestClient_RemoteProber(t *testing.T) {
+	hub := gslb.NewProbeHub()
+	hub.SetChecks([]gslb.RemoteCheck{{
+		Zone: "example.com", Name: "www.example.com", RecordType: "A", IP: "192.0.2.1",
+		HealthCheck: config.HealthCheck{
+			Type: "https", Endpoint: "/health", Host: "www.example.com",
+			Timeout:            config.Seconds(1500 * time.Millisecond),
+			InsecureSkipVerify: true,
+			Headers:            map[string]string{"X-Probe": "1"},
+		},
+	}}, 30*time.Second)
+	server := NewServer(testToken, nil, nil)
+	server.AddToken("tokyo", "tokyo-token", apiauth.RoleProber)
+	server.AddToken("noc", "noc-token", apiauth.RoleViewer)
+	server.SetProbeHub(hub)
+	addr := strings.TrimPrefix(newClient(t, server).url, "http://")
+	ctx := context.Background()
+
+	client := NewClient(addr, "tokyo-token", nil)
+	if err := client.ReportResults(ctx, "", nil); !IsNotRegistered(err) {
+		t.Errorf("expected the prober to need registering, got %v", err)
+	}
+	checks, interval, err := client.Register(ctx, "", "ap-northeast-1")
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if len(checks) != 1 || interval != 30*time.Second {
+		t.Fatalf("Register() = %+v, %v", checks, interval)
+	}
+	hc := checks[0].HealthCheck
+	if checks[0].IP != "192.0.2.1" || checks[0].RecordType != "A" || hc.Type != "https" || hc.Host != "www.example.com" ||
+		hc.Timeout.Duration() != 1500*time.Millisecond || !hc.InsecureSkipVerify || hc.Headers["X-Probe"] != "1" {
+		t.Errorf("unexpected check %+v", checks[0])
+	}
+
+	results := []gslb.RemoteResult{{Zone: "example.com", Name: "www.example.com", RecordType: "A", IP: "192.0.2.1", Error: "timeout", Latency: time.Second}}
+	if err := client.ReportResults(ctx, "", results); err != nil {
+		t.Fatalf("ReportResults() error = %v", err)
+	}
+	probers := hub.Probers()
+	if len(probers) != 1 || probers[0].Name != "tokyo" || probers[0].Region != "ap-northeast-1" || probers[0].LastReport.IsZero() {
+		t.Errorf("expected tokyo to be registered and to have reported, got %+v", probers)
+	}
+
+	// Probers may not operate, and viewers may not register
+	if _, _, err := NewClient(addr, "noc-token", nil).Register(ctx, "noc", ""); !isCode(err, codePermissionDenied) {
+		t.Errorf("viewer Register: expected PERMISSION_DENIED, got %v", err)
+	}
+	if r := newClient(t, server).call("ForceFailover", "tokyo-token", originRequestOf("")); r.code != codePermissionDenied {
+		t.Errorf("prober ForceFailover: expected PERMISSION_DENIED, got %+v", r)
+	}
+	// The token without a name needs the prober to name itself
+	if _, _, err := NewClient(addr, testToken, nil).Register(ctx, "", ""); !isCode(err, codeInvalidArgument) {
+		t.Errorf("Register without a name: expected INVALID_ARGUMENT, got %v", err)
+	}
+}
+
+func TestClient_WithoutProbeHub(t *testing.T) {
+	addr := strings.TrimPrefix(newClient(t, NewServer(testToken, nil, nil)).url, "http://")
+	if _, _, err := NewClient(addr, testToken, nil).Register(context.Background(), "tokyo", ""); !isCode(err, codeUnimplemented) {
+		t.Errorf("expected UNIMPLEMENTED, got %v", err)
+	}
+}
+
+func isCode(err error, code int) bool {
+	var status *StatusError
+	return errors.As(err, &status) && status.Code == code
+}
@@ -0,0 +1,506 @@
+// Package grpcapi serves the operations of the admin API, a stream of the
+// events of the daemon and the registration of remote probers over gRPC, as
+// described by control.proto, so that orchestration tools can use clients
+// generated from it. Client calls the methods of the remote probers.
+//
+// The server speaks gRPC over HTTP/2, with or without TLS, with the standard
+// library and does not support compressed messages.
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/apiauth"
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/servertls"
+)
+
+// ServiceName is the full name of the service of control.proto; its methods
+// are served at /gslb.control.v1.ControlPlane/<method>.
+const ServiceName = "gslb.control.v1.ControlPlane"
+
+// DefaultActor is who the changes are attributed to when the request does
+// not name anyone in its by field and its token has no name.
+const DefaultActor = "gRPC API"
+
+const (
+	// maxMessageSize is the largest request message accepted
+	maxMessageSize = 1 << 20
+	// eventBuffer is how many events a WatchEvents stream may fall behind
+	// before it is ended
+	eventBuffer = 256
+)
+
+// Status codes of gRPC returned by the server.
+const (
+	codeOK                 = 0
+	codeCanceled           = 1
+	codeInvalidArgument    = 3
+	codeNotFound           = 5
+	codePermissionDenied   = 7
+	codeResourceExhausted  = 8
+	codeFailedPrecondition = 9
+	codeUnimplemented      = 12
+	codeInternal           = 13
+	codeUnavailable        = 14
+	codeUnauthenticated    = 16
+)
+
+// statusError is an error with the gRPC status code it is returned with.
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+func statusErrorf(code int, format string, args ...any) error {
+	return &statusError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// Controller operates the origins, normally a *gslb.Service.
+type Controller interface {
+	OriginReports() []gslb.OriginReport
+	HoldOrigin(ctx context.Context, zone, name, recordType, by string) error
+	ReleaseOrigin(ctx context.Context, zone, name, recordType, by string) error
+	ForceFailover(ctx context.Context, zone, name, recordType, by string) error
+	ForceFailback(ctx context.Context, zone, name, recordType, by string) error
+	CheckOrigin(ctx context.Context, zone, name, recordType string) error
+}
+
+// Server authenticates the calls with a bearer token, a client certificate
+// or both, and applies them to the current controller. Tokens of the viewer
+// role may only call ListOrigins and WatchEvents, and tokens of the prober
+// role only Register and ReportResults. The controller can be
+// replaced while serving, e.g. when the configuration is reloaded; the event
+// streams are not interrupted as long as the new service publishes to the
+// same feed.
+type Server struct {
+	mu         sync.RWMutex
+	controller Controller
+
+	tokens            apiauth.Tokens
+	requireClientCert bool
+	feed              *gslb.EventFeed
+	probes            *gslb.ProbeHub
+	reload            func(ctx context.Context) error
+
+	stopping chan struct{}
+	stopOnce sync.Once
+	server   *http.Server
+}
+
+// NewServer returns a server that accepts the calls with token as their
+// bearer token of the operator role, unless it is empty, streams the events of feed and reloads
+// the configuration with reload. Without feed WatchEvents and without reload
+// Reload are unimplemented.
+func NewServer(token string, feed *gslb.EventFeed, reload func(ctx context.Context) error) *Server {
+	s := &Server{
+		feed:     feed,
+		reload:   reload,
+		stopping: make(chan struct{}),
+	}
+	if token != "" {
+		s.tokens.Add(token, apiauth.Credential{Role: apiauth.RoleOperator})
+	}
+	protocols := new(http.Protocols)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+	s.server = &http.Server{
+		Handler:           http.HandlerFunc(s.serveHTTP),
+		Protocols:         protocols,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// SetController replaces the controller the calls are applied to.
+func (s *Server) SetController(controller Controller) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.controller = controller
+}
+
+// AddToken accepts token as a bearer token of role, attributing the changes
+// made with it to name unless the request names someone. It must be called
+// before serving.
+func (s *Server) AddToken(name, token string, role apiauth.Role) {
+	s.tokens.Add(token, apiauth.Credential{Name: name, Role: role})
+}
+
+// SetProbeHub registers the remote probers with hub and passes their results
+// on to it. Without a hub Register and ReportResults are unimplemented. It
+// must be called before serving.
+func (s *Server) SetProbeHub(hub *gslb.ProbeHub) {
+	s.probes = hub
+}
+
+// RequireClientCert makes the server accept only calls with a verified
+// client certificate, in addition to the token. It must be called before
+// serving.
+func (s *Server) RequireClientCert() {
+	s.requireClientCert = true
+}
+
+// Handler returns the HTTP/2 handler of the API.
+func (s *Server) Handler() http.Handler {
+	return s.server.Handler
+}
+
+// ListenAndServe listens on addr and serves the API in the background, over
+// TLS with tlsConfig unless it is nil.
+func (s *Server) ListenAndServe(addr string, tlsConfig *tls.Config) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := servertls.Serve(s.server, listener, tlsConfig); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("gRPC API stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Shutdown ends the event streams and stops the server, waiting for
+// in-flight calls until ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stopping) })
+	return s.server.Shutdown(ctx)
+}
+
+func (s *Server) currentController() Controller {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.controller
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "application/grpc" && contentType != "application/grpc+proto" {
+		http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+		return
+	}
+	stream := &response{w: w}
+	stream.finish(s.serve(r, stream))
+}
+
+// serve runs the method of r, sending its messages to stream.
+func (s *Server) serve(r *http.Request, stream *response) error {
+	if s.requireClientCert && !servertls.Verified(r) {
+		return statusErrorf(codeUnauthenticated, "a client certificate is required")
+	}
+	credential, ok := s.tokens.Authenticate(r)
+	if !ok {
+		return statusErrorf(codeUnauthenticated, "a valid bearer token is required")
+	}
+	method, ok := strings.CutPrefix(r.URL.Path, "/"+ServiceName+"/")
+	if !ok {
+		return statusErrorf(codeUnimplemented, "unknown service of %s", r.URL.Path)
+	}
+	if !credential.Role.Allows(requiredRole(method)) {
+		log.Printf("gRPC API: denied %s to the %s token %q", method, credential.Role, credential.Name)
+		return statusErrorf(codePermissionDenied, "the %s role may not call %s", credential.Role, method)
+	}
+	body, err := readMessage(r.Body)
+	if err != nil {
+		return err
+	}
+
+	switch method {
+	case "ListOrigins":
+		controller := s.currentController()
+		if controller == nil {
+			return statusErrorf(codeUnavailable, "no service is running")
+		}
+		return stream.send(func(e *encoder) {
+			for _, report := range controller.OriginReports() {
+				e.message(1, func(e *encoder) { encodeOrigin(e, report) })
+			}
+		})
+	case "PauseOrigin", "ResumeOrigin", "ForceFailover", "ForceFailback", "CheckOrigin":
+		return s.operate(apiauth.WithCredential(r.Context(), credential), method, body, stream)
+	case "Reload":
+		if s.reload == nil {
+			return statusErrorf(codeUnimplemented, "reloading is not supported")
+		}
+		log.Printf("gRPC API: reloading the configuration")
+		if err := s.reload(r.Context()); err != nil {
+			log.Printf("gRPC API: reload failed: %v", err)
+			return statusErrorf(codeFailedPrecondition, "%v", err)
+		}
+		return stream.send(func(e *encoder) { e.string(1, "reloading") })
+	case "WatchEvents":
+		return s.watchEvents(r.Context(), body, stream)
+	case "Register", "ReportResults":
+		return s.probe(credential, method, body, stream)
+	default:
+		return statusErrorf(codeUnimplemented, "unknown method %s", method)
+	}
+}
+
+// requiredRole returns the role a call of method needs.
+func requiredRole(method string) apiauth.Role {
+	switch method {
+	case "ListOrigins", "WatchEvents":
+		return apiauth.RoleViewer
+	case "Register", "ReportResults":
+		return apiauth.RoleProber
+	default:
+		return apiauth.RoleOperator
+	}
+}
+
+// operate applies the method to the origin of the OriginRequest in body and
+// sends the Origin afterwards.
+func (s *Server) operate(ctx context.Context, method string, body []byte, stream *response) error {
+	controller := s.currentController()
+	if controller == nil {
+		return statusErrorf(codeUnavailable, "no service is running")
+	}
+	var req originRequest
+	if err := req.unmarshal(body); err != nil {
+		return statusErrorf(codeInvalidArgument, "%v", err)
+	}
+	// A named token is always who acts, so that a caller cannot act as someone else
+	by := req.By
+	if credential, _ := apiauth.CredentialFrom(ctx); credential.Name != "" {
+		by = credential.Name
+	}
+	if by == "" {
+		by = DefaultActor
+	}
+
+	// A client that gives up must not abort a DNS change halfway
+	ctx = context.WithoutCancel(ctx)
+	var err error
+	switch method {
+	case "PauseOrigin":
+		err = controller.HoldOrigin(ctx, req.Zone, req.Name, req.RecordType, by)
+	case "ResumeOrigin":
+		err = controller.ReleaseOrigin(ctx, req.Zone, req.Name, req.RecordType, by)
+	case "ForceFailover":
+		err = controller.ForceFailover(ctx, req.Zone, req.Name, req.RecordType, by)
+	case "ForceFailback":
+		err = controller.ForceFailback(ctx, req.Zone, req.Name, req.RecordType, by)
+	case "CheckOrigin":
+		err = controller.CheckOrigin(ctx, req.Zone, req.Name, req.RecordType)
+	}
+	if err != nil {
+		log.Printf("gRPC API: %s of %s.%s (%s) by %s failed: %v", method, req.Name, req.Zone, req.RecordType, by, err)
+		return statusErrorf(codeFor(err), "%v", err)
+	}
+	log.Printf("gRPC API: %s of %s.%s (%s) by %s", method, req.Name, req.Zone, req.RecordType, by)
+
+	for _, report := range controller.OriginReports() {
+		if report.Zone == req.Zone && report.Name == req.Name && report.RecordType == req.RecordType {
+			return stream.send(func(e *encoder) {
+				e.message(1, func(e *encoder) { encodeOrigin(e, report) })
+			})
+		}
+	}
+	// The configuration was reloaded without the origin in the meantime
+	return statusErrorf(codeNotFound, "origin not found")
+}
+
+// watchEvents sends the events of the feed that match the WatchEventsRequest
+// in body until the client cancels the call or the server stops.
+func (s *Server) watchEvents(ctx context.Context, body []byte, stream *response) error {
+	if s.feed == nil {
+		return statusErrorf(codeUnimplemented, "the event feed is not available")
+	}
+	route, err := decodeWatchRequest(body)
+	if err != nil {
+		return statusErrorf(codeInvalidArgument, "%v", err)
+	}
+	sub := s.feed.Subscribe(eventBuffer)
+	defer sub.Close()
+
+	// Send the headers right away, so that the client knows it is subscribed
+	if err := stream.start(); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return statusErrorf(codeCanceled, "%v", ctx.Err())
+		case <-s.stopping:
+			return statusErrorf(codeUnavailable, "the server is stopping")
+		case event, ok := <-sub.Events():
+			if !ok {
+				if sub.Overflowed() {
+					return statusErrorf(codeResourceExhausted, "the client fell more than %d events behind", eventBuffer)
+				}
+				return statusErrorf(codeUnavailable, "the event feed was closed")
+			}
+			if !route.Matches(event) {
+				continue
+			}
+			if err := stream.send(func(e *encoder) { encodeEvent(e, event) }); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// probe runs Register or ReportResults of a remote prober. Probers are named
+// after their token unless they give a name.
+func (s *Server) probe(credential apiauth.Credential, method string, body []byte, stream *response) error {
+	if s.probes == nil {
+		return statusErrorf(codeUnimplemented, "remote probers are not supported")
+	}
+	if method == "Register" {
+		var req registerRequest
+		if err := req.unmarshal(body); err != nil {
+			return statusErrorf(codeInvalidArgument, "%v", err)
+		}
+		if req.Name == "" {
+			req.Name = credential.Name
+		}
+		if req.Name == "" {
+			return statusErrorf(codeInvalidArgument, "the name of the prober is required")
+		}
+		checks, interval := s.probes.Register(req.Name, req.Region)
+		return stream.send(func(e *encoder) { encodeAssignment(e, checks, interval) })
+	}
+
+	prober, results, err := decodeReport(body)
+	if err != nil {
+		return statusErrorf(codeInvalidArgument, "%v", err)
+	}
+	if prober == "" {
+		prober = credential.Name
+	}
+	if err := s.probes.Report(prober, results); err != nil {
+		if errors.Is(err, gslb.ErrProberNotRegistered) {
+			return statusErrorf(codeFailedPrecondition, "%v", err)
+		}
+		return statusErrorf(codeInternal, "%v", err)
+	}
+	return stream.send(func(*encoder) {})
+}
+
+// codeFor maps the errors of the controller to gRPC status codes.
+func codeFor(err error) int {
+	switch {
+	case errors.Is(err, gslb.ErrOriginNotFound):
+		return codeNotFound
+	case errors.Is(err, gslb.ErrAlreadyHeld), errors.Is(err, gslb.ErrNotHeld),
+		errors.Is(err, gslb.ErrNoFailoverTarget), errors.Is(err, gslb.ErrForceNotApplied):
+		return codeFailedPrecondition
+	default:
+		return codeInternal
+	}
+}
+
+// readMessage reads the only message of a unary call or of the request of a
+// server stream: a flag for compression, the length and the message.
+func readMessage(body io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(body, prefix[:]); err != nil {
+		return nil, statusErrorf(codeInvalidArgument, "reading the request message: %v", err)
+	}
+	if prefix[0] != 0 {
+		return nil, statusErrorf(codeUnimplemented, "compressed messages are not supported")
+	}
+	length := binary.BigEndian.Uint32(prefix[1:])
+	if length > maxMessageSize {
+		return nil, statusErrorf(codeResourceExhausted, "the request message is larger than %d bytes", maxMessageSize)
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(body, message); err != nil {
+		return nil, statusErrorf(codeInvalidArgument, "reading the request message: %v", err)
+	}
+	return message, nil
+}
+
+// response writes the messages of a call, followed by its status in the
+// trailers.
+type response struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+// start sends the response headers.
+func (r *response) start() error {
+	if r.started {
+		return nil
+	}
+	r.started = true
+	r.w.Header().Set("Content-Type", "application/grpc")
+	r.w.WriteHeader(http.StatusOK)
+	return http.NewResponseController(r.w).Flush()
+}
+
+// send writes the message encoded by encode.
+func (r *response) send(encode func(*encoder)) error {
+	if err := r.start(); err != nil {
+		return err
+	}
+	var e encoder
+	encode(&e)
+	frame := make([]byte, 5, 5+len(e.buf))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(e.buf)))
+	if _, err := r.w.Write(append(frame, e.buf...)); err != nil {
+		return err
+	}
+	return http.NewResponseController(r.w).Flush()
+}
+
+// finish writes the status of err. A call that failed before sending
+// anything gets a response with only headers, as gRPC expects.
+func (r *response) finish(err error) {
+	code, message := codeOK, ""
+	if err != nil {
+		var status *statusError
+		if !errors.As(err, &status) {
+			// The client went away while a message was written
+			return
+		}
+		code, message = status.code, status.message
+	}
+	prefix := http.TrailerPrefix
+	if !r.started {
+		prefix = ""
+		r.w.Header().Set("Content-Type", "application/grpc")
+	}
+	r.w.Header().Set(prefix+"Grpc-Status", strconv.Itoa(code))
+	if message != "" {
+		r.w.Header().Set(prefix+"Grpc-Message", encodeGRPCMessage(message))
+	}
+	if !r.started {
+		r.w.WriteHeader(http.StatusOK)
+	}
+}
+
+// encodeGRPCMessage percent-encodes the bytes of message that are not
+// printable ASCII, as required for the grpc-message trailer.
+func encodeGRPCMessage(message string) string {
+	var b strings.Builder
+	for i := 0; i < len(message); i++ {
+		c := message[i]
+		if c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,475 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/apiauth"
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/bootjp/cloudflare-gslb/pkg/servertls"
+	"github.com/bootjp/cloudflare-gslb/pkg/servertls/tlstest"
+)
+
+const testToken = "s3cret"
+
+type fakeController struct {
+	mu    sync.Mutex
+	calls []string
+	err   error
+}
+
+func (c *fakeController) OriginReports() []gslb.OriginReport {
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return []gslb.OriginReport{{
+		Name: "www.example.com", Zone: "example.com", RecordType: "A", Health: gslb.HealthFailover,
+		CurrentIPs: []string{"198.51.100.1"}, CurrentPriority: 50, MaxPriority: 100,
+		Hold: &gslb.Hold{By: "alice", Since: since},
+	}}
+}
+
+func (c *fakeController) HoldOrigin(ctx context.Context, zone, name, recordType, by string) error {
+	return c.record("PauseOrigin", zone, name, recordType, by)
+}
+
+func (c *fakeController) ReleaseOrigin(ctx context.Context, zone, name, recordType, by string) error {
+	return c.record("ResumeOrigin", zone, name, recordType, by)
+}
+
+func (c *fakeController) ForceFailover(ctx context.Context, zone, name, recordType, by string) error {
+	return c.record("ForceFailover", zone, name, recordType, by)
+}
+
+func (c *fakeController) ForceFailback(ctx context.Context, zone, name, recordType, by string) error {
+	return c.record("ForceFailback", zone, name, recordType, by)
+}
+
+func (c *fakeController) CheckOrigin(ctx context.Context, zone, name, recordType string) error {
+	return c.record("CheckOrigin", zone, name, recordType, "")
+}
+
+func (c *fakeController) record(method, zone, name, recordType, by string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, fmt.Sprintf("%s %s/%s/%s by %s", method, zone, name, recordType, by))
+	return c.err
+}
+
+// client calls a test server over cleartext HTTP/2.
+type client struct {
+	t      *testing.T
+	url    string
+	client *http.Client
+}
+
+func newClient(t *testing.T, server *Server) *client {
+	ts := httptest.NewUnstartedServer(server.Handler())
+	ts.Config.Protocols = server.server.Protocols
+	ts.Start()
+	t.Cleanup(ts.Close)
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	return &client{t: t, url: ts.URL, client: &http.Client{Transport: &http.Transport{Protocols: protocols}}}
+}
+
+// result is the outcome of a call.
+type result struct {
+	messages [][]byte
+	code     int
+	message  string
+}
+
+// open starts a call and returns its response.
+func (c *client) open(ctx context.Context, method, token string, encode func(*encoder)) *http.Response {
+	c.t.Helper()
+	var e encoder
+	encode(&e)
+	body := make([]byte, 5, 5+len(e.buf))
+	binary.BigEndian.PutUint32(body[1:], uint32(len(e.buf)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/"+ServiceName+"/"+method, bytes.NewReader(append(body, e.buf...)))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s: %v", method, err)
+	}
+	if resp.ProtoMajor != 2 || resp.Header.Get("Content-Type") != "application/grpc" {
+		c.t.Fatalf("%s: expected a gRPC response over HTTP/2, got %s %s", method, resp.Proto, resp.Header.Get("Content-Type"))
+	}
+	return resp
+}
+
+// call runs a call and reads all of its messages.
+func (c *client) call(method, token string, encode func(*encoder)) result {
+	c.t.Helper()
+	resp := c.open(context.Background(), method, token, encode)
+	defer resp.Body.Close()
+	var r result
+	for {
+		message, err := readFrame(resp.Body)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			c.t.Fatalf("%s: %v", method, err)
+		}
+		r.messages = append(r.messages, message)
+	}
+	return finish(c.t, resp, r)
+}
+
+// finish reads the status of a response whose body was read.
+func finish(t *testing.T, resp *http.Response, r result) result {
+	t.Helper()
+	status := resp.Trailer.Get("Grpc-Status")
+	r.message = resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		// Trailers-only response
+		status = resp.Header.Get("Grpc-Status")
+		r.message = resp.Header.Get("Grpc-Message")
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		t.Fatalf("invalid grpc-status %q", status)
+	}
+	r.code = code
+	return r
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, err
+	}
+	message := make([]byte, binary.BigEndian.Uint32(prefix[1:]))
+	_, err := io.ReadFull(r, message)
+	return message, err
+}
+
+// fieldsOf decodes a message into its fields by number.
+func fieldsOf(t *testing.T, b []byte) map[int][]field {
+	t.Helper()
+	fields := make(map[int][]field)
+	if err := decodeFields(b, func(f field) error {
+		fields[f.num] = append(fields[f.num], f)
+		return nil
+	}); err != nil {
+		t.Fatalf("decoding %x: %v", b, err)
+	}
+	return fields
+}
+
+func originRequestOf(by string) func(*encoder) {
+	return func(e *encoder) {
+		e.string(1, "example.com")
+		e.string(2, "www.example.com")
+		e.string(3, "A")
+		e.string(4, by)
+	}
+}
+
+func TestServer_Operations(t *testing.T) {
+	server := NewServer(testToken, nil, nil)
+	controller := &fakeController{}
+	server.SetController(controller)
+	c := newClient(t, server)
+
+	for _, method := range []string{"PauseOrigin", "ResumeOrigin", "ForceFailover", "ForceFailback", "CheckOrigin"} {
+		r := c.call(method, testToken, originRequestOf("alice"))
+		if r.code != codeOK || len(r.messages) != 1 {
+			t.Fatalf("%s: expected one message and OK, got %+v", method, r)
+		}
+		origin := fieldsOf(t, fieldsOf(t, r.messages[0])[1][0].data)
+		if string(origin[1][0].data) != "www.example.com" {
+			t.Errorf("%s: unexpected origin %+v", method, origin)
+		}
+	}
+	want := []string{
+		"PauseOrigin example.com/www.example.com/A by alice",
+		"ResumeOrigin example.com/www.example.com/A by alice",
+		"ForceFailover example.com/www.example.com/A by alice",
+		"ForceFailback example.com/www.example.com/A by alice",
+		"CheckOrigin example.com/www.example.com/A by ",
+	}
+	if fmt.Sprint(controller.calls) != fmt.Sprint(want) {
+		t.Errorf("calls = %v, want %v", controller.calls, want)
+	}
+	c.call("PauseOrigin", testToken, originRequestOf(""))
+	if got := controller.calls[len(controller.calls)-1]; got != "PauseOrigin example.com/www.example.com/A by "+DefaultActor {
+		t.Errorf("expected the default actor, got %q", got)
+	}
+
+	r := c.call("ListOrigins", testToken, func(*encoder) {})
+	if r.code != codeOK || len(r.messages) != 1 {
+		t.Fatalf("ListOrigins: expected one message and OK, got %+v", r)
+	}
+	origins := fieldsOf(t, r.messages[0])[1]
+	if len(origins) != 1 {
+		t.Fatalf("expected one origin, got %d", len(origins))
+	}
+	origin := fieldsOf(t, origins[0].data)
+	if string(origin[4][0].data) != gslb.HealthFailover || string(origin[5][0].data) != "198.51.100.1" ||
+		origin[6][0].value != 50 || origin[7][0].value != 100 {
+		t.Errorf("unexpected origin %+v", origin)
+	}
+	hold := fieldsOf(t, origin[11][0].data)
+	since := fieldsOf(t, hold[2][0].data)
+	if string(hold[1][0].data) != "alice" || int64(since[1][0].value) != time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Unix() {
+		t.Errorf("unexpected hold %+v", hold)
+	}
+
+	if r := c.call("Explode", testToken, func(*encoder) {}); r.code != codeUnimplemented {
+		t.Errorf("unknown method: expected UNIMPLEMENTED, got %+v", r)
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("www.example.com: %w", gslb.ErrOriginNotFound), codeNotFound},
+		{fmt.Errorf("www.example.com: %w", gslb.ErrAlreadyHeld), codeFailedPrecondition},
+		{fmt.Errorf("www.example.com: %w", gslb.ErrForceNotApplied), codeFailedPrecondition},
+		{errors.New("boom: 100%"), codeInternal},
+	}
+	for _, tt := range tests {
+		server := NewServer(testToken, nil, nil)
+		server.SetController(&fakeController{err: tt.err})
+		r := newClient(t, server).call("PauseOrigin", testToken, originRequestOf("alice"))
+		if r.code != tt.want || r.message == "" || len(r.messages) != 0 {
+			t.Errorf("%v: got %+v, want code %d", tt.err, r, tt.want)
+		}
+	}
+
+	// Before the service is started there is nothing to operate
+	r := newClient(t, NewServer(testToken, nil, nil)).call("ListOrigins", testToken, func(*encoder) {})
+	if r.code != codeUnavailable {
+		t.Errorf("without a controller: expected UNAVAILABLE, got %+v", r)
+	}
+
+	if got := encodeGRPCMessage("boom: 100%\n"); got != "boom: 100%25%0A" {
+		t.Errorf("encodeGRPCMessage() = %q", got)
+	}
+}
+
+func TestServer_RequiresToken(t *testing.T) {
+	server := NewServer(testToken, nil, nil)
+	controller := &fakeController{}
+	server.SetController(controller)
+	c := newClient(t, server)
+
+	for _, token := range []string{"", "wrong"} {
+		if r := c.call("ForceFailover", token, originRequestOf("alice")); r.code != codeUnauthenticated {
+			t.Errorf("token %q: expected UNAUTHENTICATED, got %+v", token, r)
+		}
+	}
+	if len(controller.calls) != 0 {
+		t.Errorf("expected no calls without the token, got %v", controller.calls)
+	}
+}
+
+func TestServer_Roles(t *testing.T) {
+	server := NewServer(testToken, nil, nil)
+	server.AddToken("noc", "noc-token", apiauth.RoleViewer)
+	server.AddToken("oncall", "oncall-token", apiauth.RoleOperator)
+	controller := &fakeController{}
+	server.SetController(controller)
+	c := newClient(t, server)
+
+	if r := c.call("ListOrigins", "noc-token", func(*encoder) {}); r.code != codeOK {
+		t.Errorf("viewer ListOrigins: expected OK, got %+v", r)
+	}
+	for _, method := range []string{"ForceFailover", "CheckOrigin"} {
+		if r := c.call(method, "noc-token", originRequestOf("")); r.code != codePermissionDenied {
+			t.Errorf("viewer %s: expected PERMISSION_DENIED, got %+v", method, r)
+		}
+	}
+	if r := c.call("Reload", "noc-token", func(*encoder) {}); r.code != codePermissionDenied {
+		t.Errorf("viewer Reload: expected PERMISSION_DENIED, got %+v", r)
+	}
+	if r := c.call("PauseOrigin", "oncall-token", originRequestOf("mallory")); r.code != codeOK {
+		t.Errorf("operator PauseOrigin: expected OK, got %+v", r)
+	}
+	want := []string{"PauseOrigin example.com/www.example.com/A by oncall"}
+	if fmt.Sprint(controller.calls) != fmt.Sprint(want) {
+		t.Errorf("calls = %v, want %v", controller.calls, want)
+	}
+}
+
+func TestServer_ClientCertOverTLS(t *testing.T) {
+	files := tlstest.Write(t, t.TempDir())
+	tlsConfig, err := servertls.Load(servertls.Config{CertFile: files.ServerCert, KeyFile: files.ServerKey, ClientCAFile: files.CA})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer("", nil, nil)
+	server.RequireClientCert()
+	controller := &fakeController{}
+	server.SetController(controller)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = servertls.Serve(server.server, listener, tlsConfig) }()
+	t.Cleanup(func() { _ = server.Shutdown(context.Background()) })
+
+	clientOf := func(certFile, keyFile string) *client {
+		tlsConfig, err := servertls.ClientConfig(files.CA, certFile, keyFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		protocols := new(http.Protocols)
+		protocols.SetHTTP2(true)
+		transport := &http.Transport{TLSClientConfig: tlsConfig, Protocols: protocols}
+		return &client{t: t, url: "https://" + listener.Addr().String(), client: &http.Client{Transport: transport}}
+	}
+	if r := clientOf("", "").call("ForceFailover", "", originRequestOf("alice")); r.code != codeUnauthenticated {
+		t.Errorf("without a client certificate: expected UNAUTHENTICATED, got %+v", r)
+	}
+	if r := clientOf(files.ClientCert, files.ClientKey).call("ForceFailover", "", originRequestOf("alice")); r.code != codeOK {
+		t.Errorf("with a client certificate: expected OK, got %+v", r)
+	}
+	if len(controller.calls) != 1 {
+		t.Errorf("expected one call, got %v", controller.calls)
+	}
+}
+
+func TestServer_Reload(t *testing.T) {
+	r := newClient(t, NewServer(testToken, nil, nil)).call("Reload", testToken, func(*encoder) {})
+	if r.code != codeUnimplemented {
+		t.Errorf("without a reload function: expected UNIMPLEMENTED, got %+v", r)
+	}
+
+	var reloadErr error
+	reloads := 0
+	c := newClient(t, NewServer(testToken, nil, func(ctx context.Context) error {
+		reloads++
+		return reloadErr
+	}))
+	r = c.call("Reload", testToken, func(*encoder) {})
+	if r.code != codeOK || reloads != 1 || len(r.messages) != 1 || string(fieldsOf(t, r.messages[0])[1][0].data) != "reloading" {
+		t.Errorf("expected reloading after one reload, got %+v after %d", r, reloads)
+	}
+
+	reloadErr = errors.New("invalid config")
+	if r := c.call("Reload", testToken, func(*encoder) {}); r.code != codeFailedPrecondition || r.message != "invalid config" {
+		t.Errorf("failed reload: expected FAILED_PRECONDITION, got %+v", r)
+	}
+}
+
+func TestServer_WatchEvents(t *testing.T) {
+	feed := gslb.NewEventFeed()
+	server := NewServer(testToken, feed, nil)
+	c := newClient(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp := c.open(ctx, "WatchEvents", testToken, func(e *encoder) {
+		e.strings(1, []string{"example.com"})
+		e.string(4, "warning")
+	})
+	defer resp.Body.Close()
+
+	// The headers arrive once the stream is subscribed
+	timestamp := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	feed.Publish(notifier.FailoverEvent{Type: notifier.EventTypeFailover, OriginName: "www.example.net", ZoneName: "example.net"})
+	feed.Publish(notifier.FailoverEvent{Type: notifier.EventTypeFailover, OriginName: "www.example.com", ZoneName: "example.com", IsPriorityIP: true})
+	feed.Publish(notifier.FailoverEvent{
+		Type: notifier.EventTypeFailover, OriginName: "www.example.com", ZoneName: "example.com", RecordType: "A",
+		OldIP: "192.0.2.1", NewIPs: []string{"198.51.100.1"}, OldPriority: 100, NewPriority: 50,
+		Labels: map[string]string{"team": "web"}, Timestamp: timestamp,
+	})
+
+	message, err := readFrame(resp.Body)
+	if err != nil {
+		t.Fatalf("reading the event: %v", err)
+	}
+	event := fieldsOf(t, message)
+	if string(event[1][0].data) != notifier.KindFailover || string(event[2][0].data) != "warning" || string(event[3][0].data) != "www.example.com" {
+		t.Errorf("expected the failover of www.example.com, got %+v", event)
+	}
+	if string(event[6][0].data) != "192.0.2.1" || string(event[7][0].data) != "198.51.100.1" || event[9][0].value != 50 {
+		t.Errorf("unexpected IPs or priorities %+v", event)
+	}
+	label := fieldsOf(t, event[13][0].data)
+	seconds := fieldsOf(t, event[14][0].data)
+	if string(label[1][0].data) != "team" || string(label[2][0].data) != "web" || int64(seconds[1][0].value) != timestamp.Unix() || seconds[2][0].value != 6 {
+		t.Errorf("unexpected labels or timestamp %+v", event)
+	}
+
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readFrame(resp.Body); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected the stream to end, got %v", err)
+	}
+	if r := finish(t, resp, result{}); r.code != codeUnavailable {
+		t.Errorf("expected UNAVAILABLE on shutdown, got %+v", r)
+	}
+
+	r := newClient(t, NewServer(testToken, nil, nil)).call("WatchEvents", testToken, func(*encoder) {})
+	if r.code != codeUnimplemented {
+		t.Errorf("without a feed: expected UNIMPLEMENTED, got %+v", r)
+	}
+	r = newClient(t, NewServer(testToken, feed, nil)).call("WatchEvents", testToken, func(e *encoder) { e.string(4, "loud") })
+	if r.code != codeInvalidArgument {
+		t.Errorf("unknown severity: expected INVALID_ARGUMENT, got %+v", r)
+	}
+}
+
+// TestProto_MatchesServer keeps control.proto and the server in sync.
+func TestProto_MatchesServer(t *testing.T) {
+	proto, err := os.ReadFile("control.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(proto, []byte("package gslb.control.v1;")) || !bytes.Contains(proto, []byte("service ControlPlane {")) {
+		t.Fatalf("control.proto does not declare %s", ServiceName)
+	}
+
+	server := NewServer(testToken, gslb.NewEventFeed(), func(ctx context.Context) error { return nil })
+	server.SetController(&fakeController{})
+	server.SetProbeHub(gslb.NewProbeHub())
+	c := newClient(t, server)
+	rpcs := regexp.MustCompile(`rpc (\w+)\(\w+\) returns \((stream )?\w+\)`).FindAllSubmatch(proto, -1)
+	if len(rpcs) != 10 {
+		t.Fatalf("expected 10 methods in control.proto, got %d", len(rpcs))
+	}
+	for _, rpc := range rpcs {
+		method := string(rpc[1])
+		if len(rpc[2]) > 0 {
+			ctx, cancel := context.WithCancel(context.Background())
+			resp := c.open(ctx, method, testToken, func(*encoder) {})
+			cancel()
+			resp.Body.Close()
+			continue
+		}
+		request := originRequestOf("alice")
+		if method == "ReportResults" {
+			// Report as the prober Register registered with the same request
+			request = func(e *encoder) { e.string(1, "example.com") }
+		}
+		if r := c.call(method, testToken, request); r.code != codeOK {
+			t.Errorf("%s: expected OK, got %+v", method, r)
+		}
+	}
+}
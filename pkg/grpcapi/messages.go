@@ -0,0 +1,291 @@
+package grpcapi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+// originRequest is the OriginRequest message.
+type originRequest struct {
+	Zone       string
+	Name       string
+	RecordType string
+	By         string
+}
+
+func (r *originRequest) unmarshal(b []byte) error {
+	return decodeFields(b, func(f field) error {
+		var target *string
+		switch f.num {
+		case 1:
+			target = &r.Zone
+		case 2:
+			target = &r.Name
+		case 3:
+			target = &r.RecordType
+		case 4:
+			target = &r.By
+		default:
+			return nil
+		}
+		value, err := decodeString(f)
+		*target = value
+		return err
+	})
+}
+
+// decodeWatchRequest returns the route of the WatchEventsRequest message in b.
+func decodeWatchRequest(b []byte) (notifier.Route, error) {
+	var route notifier.Route
+	err := decodeFields(b, func(f field) error {
+		if f.num < 1 || f.num > 4 {
+			return nil
+		}
+		value, err := decodeString(f)
+		if err != nil {
+			return err
+		}
+		switch f.num {
+		case 1:
+			route.Zones = append(route.Zones, value)
+		case 2:
+			route.Origins = append(route.Origins, value)
+		case 3:
+			route.Kinds = append(route.Kinds, value)
+		case 4:
+			severity, ok := notifier.ParseSeverity(value)
+			if !ok {
+				return fmt.Errorf("unknown severity %q", value)
+			}
+			route.MinSeverity = severity
+		}
+		return nil
+	})
+	return route, err
+}
+
+// encodeOrigin writes report as an Origin message.
+func encodeOrigin(e *encoder, report gslb.OriginReport) {
+	e.string(1, report.Name)
+	e.string(2, report.Zone)
+	e.string(3, report.RecordType)
+	e.string(4, report.Health)
+	e.strings(5, report.CurrentIPs)
+	e.int(6, int64(report.CurrentPriority))
+	e.int(7, int64(report.MaxPriority))
+	if report.LastCheck != nil {
+		e.timestamp(8, *report.LastCheck)
+	}
+	e.string(9, report.LastResult)
+	e.string(10, report.LastError)
+	if hold := report.Hold; hold != nil {
+		e.message(11, func(e *encoder) {
+			e.string(1, hold.By)
+			e.timestamp(2, hold.Since)
+		})
+	}
+}
+
+// encodeEvent writes event as an Event message.
+func encodeEvent(e *encoder, event notifier.FailoverEvent) {
+	e.string(1, notifier.EventKind(event))
+	e.string(2, notifier.SeverityFor(event).String())
+	e.string(3, event.OriginName)
+	e.string(4, event.ZoneName)
+	e.string(5, event.RecordType)
+	e.strings(6, eventIPs(event.OldIPs, event.OldIP))
+	e.strings(7, eventIPs(event.NewIPs, event.NewIP))
+	e.int(8, int64(event.OldPriority))
+	e.int(9, int64(event.NewPriority))
+	e.int(10, int64(event.MaxPriority))
+	e.string(11, event.Reason)
+	e.bool(12, event.ObserveOnly)
+	e.stringMap(13, event.Labels)
+	e.timestamp(14, event.Timestamp)
+}
+
+// eventIPs returns ips, or the single ip of events that only set one.
+func eventIPs(ips []string, ip string) []string {
+	if len(ips) == 0 && ip != "" {
+		return []string{ip}
+	}
+	return ips
+}
+
+// registerRequest is the RegisterRequest message.
+type registerRequest struct {
+	Name   string
+	Region string
+}
+
+func (r *registerRequest) marshal(e *encoder) {
+	e.string(1, r.Name)
+	e.string(2, r.Region)
+}
+
+func (r *registerRequest) unmarshal(b []byte) error {
+	return decodeFields(b, func(f field) error {
+		var err error
+		switch f.num {
+		case 1:
+			r.Name, err = decodeString(f)
+		case 2:
+			r.Region, err = decodeString(f)
+		}
+		return err
+	})
+}
+
+// encodeAssignment writes checks and interval as an Assignment message.
+func encodeAssignment(e *encoder, checks []gslb.RemoteCheck, interval time.Duration) {
+	for _, check := range checks {
+		e.message(1, func(e *encoder) {
+			e.string(1, check.Zone)
+			e.string(2, check.Name)
+			e.string(3, check.RecordType)
+			e.string(4, check.IP)
+			e.message(5, func(e *encoder) {
+				e.string(1, check.HealthCheck.Type)
+				e.string(2, check.HealthCheck.Endpoint)
+				e.string(3, check.HealthCheck.Host)
+				e.int(4, check.HealthCheck.Timeout.Duration().Milliseconds())
+				e.bool(5, check.HealthCheck.InsecureSkipVerify)
+				e.stringMap(6, check.HealthCheck.Headers)
+			})
+		})
+	}
+	e.int(2, interval.Milliseconds())
+}
+
+// decodeAssignment returns the checks and the interval of the Assignment
+// message in b.
+func decodeAssignment(b []byte) ([]gslb.RemoteCheck, time.Duration, error) {
+	var checks []gslb.RemoteCheck
+	var interval time.Duration
+	err := decodeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			var check gslb.RemoteCheck
+			if err := decodeMessage(f, func(f field) error { return decodeCheckField(&check, f) }); err != nil {
+				return err
+			}
+			checks = append(checks, check)
+		case 2:
+			ms, err := decodeInt(f)
+			if err != nil {
+				return err
+			}
+			interval = time.Duration(ms) * time.Millisecond
+		}
+		return nil
+	})
+	return checks, interval, err
+}
+
+// decodeCheckField sets the field f of the Check message on check.
+func decodeCheckField(check *gslb.RemoteCheck, f field) error {
+	var err error
+	switch f.num {
+	case 1:
+		check.Zone, err = decodeString(f)
+	case 2:
+		check.Name, err = decodeString(f)
+	case 3:
+		check.RecordType, err = decodeString(f)
+	case 4:
+		check.IP, err = decodeString(f)
+	case 5:
+		hc := &check.HealthCheck
+		err = decodeMessage(f, func(f field) error {
+			var err error
+			switch f.num {
+			case 1:
+				hc.Type, err = decodeString(f)
+			case 2:
+				hc.Endpoint, err = decodeString(f)
+			case 3:
+				hc.Host, err = decodeString(f)
+			case 4:
+				var ms int64
+				ms, err = decodeInt(f)
+				hc.Timeout = config.Seconds(time.Duration(ms) * time.Millisecond)
+			case 5:
+				hc.InsecureSkipVerify, err = decodeBool(f)
+			case 6:
+				var key, value string
+				key, value, err = decodeMapEntry(f)
+				if hc.Headers == nil {
+					hc.Headers = make(map[string]string)
+				}
+				hc.Headers[key] = value
+			}
+			return err
+		})
+	}
+	return err
+}
+
+// encodeReport writes the results of prober as a ReportResultsRequest
+// message.
+func encodeReport(e *encoder, prober string, results []gslb.RemoteResult) {
+	e.string(1, prober)
+	for _, result := range results {
+		e.message(2, func(e *encoder) {
+			e.string(1, result.Zone)
+			e.string(2, result.Name)
+			e.string(3, result.RecordType)
+			e.string(4, result.IP)
+			e.bool(5, result.Healthy)
+			e.int(6, result.Latency.Milliseconds())
+			e.string(7, result.Error)
+		})
+	}
+}
+
+// decodeReport returns the prober and the results of the
+// ReportResultsRequest message in b.
+func decodeReport(b []byte) (string, []gslb.RemoteResult, error) {
+	var prober string
+	var results []gslb.RemoteResult
+	err := decodeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			var err error
+			prober, err = decodeString(f)
+			return err
+		case 2:
+			var result gslb.RemoteResult
+			if err := decodeMessage(f, func(f field) error {
+				var err error
+				switch f.num {
+				case 1:
+					result.Zone, err = decodeString(f)
+				case 2:
+					result.Name, err = decodeString(f)
+				case 3:
+					result.RecordType, err = decodeString(f)
+				case 4:
+					result.IP, err = decodeString(f)
+				case 5:
+					result.Healthy, err = decodeBool(f)
+				case 6:
+					var ms int64
+					ms, err = decodeInt(f)
+					result.Latency = time.Duration(ms) * time.Millisecond
+				case 7:
+					result.Error, err = decodeString(f)
+				}
+				return err
+			}); err != nil {
+				return err
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	return prober, results, err
+}
@@ -0,0 +1,193 @@
+package grpcapi
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// The protobuf encoding of the few messages of control.proto is written by
+// hand, like the other protocols of the daemon, rather than generated.
+
+// Wire types of the protobuf encoding.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// errMalformed is returned for messages that are not valid protobuf.
+var errMalformed = errors.New("malformed protobuf message")
+
+// encoder appends the fields of a message to buf. Like proto3, it omits
+// fields with their zero value.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) tag(num, wireType int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(num)<<3|uint64(wireType))
+}
+
+func (e *encoder) bytes(num int, data []byte) {
+	e.tag(num, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(data)))
+	e.buf = append(e.buf, data...)
+}
+
+func (e *encoder) string(num int, value string) {
+	if value != "" {
+		e.bytes(num, []byte(value))
+	}
+}
+
+func (e *encoder) strings(num int, values []string) {
+	for _, value := range values {
+		e.bytes(num, []byte(value))
+	}
+}
+
+func (e *encoder) int(num int, value int64) {
+	if value != 0 {
+		e.tag(num, wireVarint)
+		// Negative numbers are sign-extended to ten bytes, as int32 and int64 are
+		e.buf = binary.AppendUvarint(e.buf, uint64(value))
+	}
+}
+
+func (e *encoder) bool(num int, value bool) {
+	if value {
+		e.tag(num, wireVarint)
+		e.buf = append(e.buf, 1)
+	}
+}
+
+// message appends the message written by encode as field num.
+func (e *encoder) message(num int, encode func(*encoder)) {
+	var inner encoder
+	encode(&inner)
+	e.bytes(num, inner.buf)
+}
+
+// timestamp appends t as a google.protobuf.Timestamp, unless it is zero.
+func (e *encoder) timestamp(num int, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	e.message(num, func(e *encoder) {
+		e.int(1, t.Unix())
+		e.int(2, int64(t.Nanosecond()))
+	})
+}
+
+// stringMap appends values as a map<string, string>, whose entries are
+// messages with the key and the value as fields 1 and 2.
+func (e *encoder) stringMap(num int, values map[string]string) {
+	for key, value := range values {
+		e.message(num, func(e *encoder) {
+			e.string(1, key)
+			e.string(2, value)
+		})
+	}
+}
+
+// field is a field of a message read by decodeFields. value holds varints
+// and fixed-size numbers; data holds length-delimited fields.
+type field struct {
+	num      int
+	wireType int
+	value    uint64
+	data     []byte
+}
+
+// decodeFields calls fn with every field of the message in b, in order.
+func decodeFields(b []byte, fn func(field) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 || key>>3 == 0 {
+			return errMalformed
+		}
+		b = b[n:]
+		f := field{num: int(key >> 3), wireType: int(key & 7)}
+		switch f.wireType {
+		case wireVarint:
+			f.value, n = binary.Uvarint(b)
+			if n <= 0 {
+				return errMalformed
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return errMalformed
+			}
+			f.value, b = binary.LittleEndian.Uint64(b), b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return errMalformed
+			}
+			f.value, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || length > uint64(len(b)-n) {
+				return errMalformed
+			}
+			f.data, b = b[n:n+int(length)], b[n+int(length):]
+		default:
+			return errMalformed
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeString returns the string of a length-delimited field.
+func decodeString(f field) (string, error) {
+	if f.wireType != wireBytes {
+		return "", errMalformed
+	}
+	return string(f.data), nil
+}
+
+// decodeInt returns the number of a varint field, such as int32 and int64.
+func decodeInt(f field) (int64, error) {
+	if f.wireType != wireVarint {
+		return 0, errMalformed
+	}
+	return int64(f.value), nil
+}
+
+// decodeBool returns the value of a bool field.
+func decodeBool(f field) (bool, error) {
+	if f.wireType != wireVarint {
+		return false, errMalformed
+	}
+	return f.value != 0, nil
+}
+
+// decodeMessage calls fn with every field of a message field.
+func decodeMessage(f field, fn func(field) error) error {
+	if f.wireType != wireBytes {
+		return errMalformed
+	}
+	return decodeFields(f.data, fn)
+}
+
+// decodeMapEntry returns the key and the value of an entry of a
+// map<string, string>.
+func decodeMapEntry(f field) (string, string, error) {
+	var key, value string
+	err := decodeMessage(f, func(f field) error {
+		var err error
+		switch f.num {
+		case 1:
+			key, err = decodeString(f)
+		case 2:
+			value, err = decodeString(f)
+		}
+		return err
+	})
+	return key, value, err
+}
@@ -0,0 +1,118 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+// alertTracker remembers which conditions of an origin have been alerted,
+// so that a condition that persists across checks is only alerted once.
+// The zero value is ready to use.
+type alertTracker struct {
+	mu   sync.Mutex
+	open map[string]*openAlert
+}
+
+// openAlert is an alerted condition that has not cleared yet.
+type openAlert struct {
+	since time.Time
+	// sent is when the alert was last sent, and repeats how many times it
+	// has been sent again by escalation
+	sent    time.Time
+	repeats int
+}
+
+// raise reports whether eventType was not yet alerted for originKey, and
+// marks it as alerted.
+func (t *alertTracker) raise(originKey string, eventType notifier.EventType, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := originKey + "/" + string(eventType)
+	if t.open[key] != nil {
+		return false
+	}
+	if t.open == nil {
+		t.open = make(map[string]*openAlert)
+	}
+	t.open[key] = &openAlert{since: now, sent: now}
+	return true
+}
+
+// escalate reports whether the open alert of eventType for originKey is due
+// to be sent again at now under escalation, and if so, marks it as sent and
+// returns the number of the repeat and when the condition started.
+func (t *alertTracker) escalate(originKey string, eventType notifier.EventType, escalation *config.EscalationConfig, now time.Time) (repeat int, since time.Time, due bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	alert := t.open[originKey+"/"+string(eventType)]
+	if alert == nil || escalation.Exhausted(alert.repeats) || now.Sub(alert.sent) < escalation.Interval(alert.repeats) {
+		return 0, time.Time{}, false
+	}
+	alert.repeats++
+	alert.sent = now
+	return alert.repeats, alert.since, true
+}
+
+// clear marks the condition as resolved, so that it is alerted again the
+// next time it happens.
+func (t *alertTracker) clear(originKey string, eventType notifier.EventType) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.open, originKey+"/"+string(eventType))
+}
+
+// raiseAlert sends an alert the first time a condition of the origin is
+// seen since it last cleared.
+func (s *Service) raiseAlert(ctx context.Context, eventType notifier.EventType, origin config.OriginConfig, originKey string, oldIPs, newIPs []string, reason string) {
+	if s.alerts.raise(originKey, eventType, s.now()) {
+		s.sendAlert(ctx, eventType, origin, oldIPs, newIPs, reason)
+	}
+}
+
+// escalateAllIPsDown sends the all IPs down alert of an origin again on the
+// escalation schedule, for as long as the origin has no healthy IP.
+func (s *Service) escalateAllIPsDown(ctx context.Context, origin config.OriginConfig, originKey string, ips []string) {
+	if !s.config.Escalation.Enabled() {
+		return
+	}
+	now := s.now()
+	repeat, since, due := s.alerts.escalate(originKey, notifier.EventTypeAllIPsDown, s.config.Escalation, now)
+	if !due {
+		return
+	}
+	down := now.Sub(since).Round(time.Second)
+	s.logf("Escalating: %s has had no healthy IP for %s (repeat %d)", origin.Name, down, repeat)
+	event := s.alertEvent(notifier.EventTypeAllIPsDown, origin, ips, ips,
+		fmt.Sprintf("Still no healthy IP after %s; the DNS records are left unchanged", down))
+	event.Repeat = repeat
+	if s.hasEventReceivers() {
+		s.dispatchEvent(ctx, event)
+	}
+}
+
+// checkerFailed records that the health checker of the origin could not be
+// created, and alerts since the origin is not monitored.
+func (s *Service) checkerFailed(ctx context.Context, origin config.OriginConfig, err error) {
+	originKey := originKeyFor(origin)
+	reportError("health_checker", origin, err)
+	s.recordCheckResult(originKey, checkOutcome{result: CheckResultCheckerFailed, err: err})
+	s.raiseAlert(ctx, notifier.EventTypeCheckerFailed, origin, originKey, nil, nil,
+		fmt.Sprintf("Failed to create the health checker, so the origin is not monitored: %v", err))
+}
+
+// ReportReload reports the outcome of loading a changed configuration while
+// the service keeps running the current one. A failure is alerted once until
+// a reload succeeds again, which is reported with a nil err.
+func (s *Service) ReportReload(ctx context.Context, err error) {
+	if err == nil {
+		s.alerts.clear("", notifier.EventTypeReloadFailed)
+		return
+	}
+	s.raiseAlert(ctx, notifier.EventTypeReloadFailed, config.OriginConfig{}, "", nil, nil,
+		fmt.Sprintf("Failed to reload the configuration, the current one keeps running: %v", err))
+}
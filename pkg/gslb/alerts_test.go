@@ -0,0 +1,236 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+// recordingNotifier records the events it receives.
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []notifier.FailoverEvent
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, event notifier.FailoverEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+// take returns the recorded events and forgets them.
+func (r *recordingNotifier) take() []notifier.FailoverEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := r.events
+	r.events = nil
+	return events
+}
+
+func TestServiceCheckOrigin_AllIPsDownAlertsOnce(t *testing.T) {
+	origin := statusTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+	recorder := &recordingNotifier{}
+	service.notifiers = []notifier.Notifier{recorder}
+
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "1", Content: "192.0.2.1"}}, nil
+	}
+	down := hcmock.NewCheckerMock(func(ip string) error { return errors.New("down") })
+	up := hcmock.NewCheckerMock(func(ip string) error { return nil })
+
+	check := func(checker *hcmock.CheckerMock) []notifier.FailoverEvent {
+		service.checkOrigin(context.Background(), origin, checker)
+		waitForNotifications(t, service)
+		return recorder.take()
+	}
+
+	events := check(down)
+	if len(events) != 1 || events[0].Type != notifier.EventTypeAllIPsDown || events[0].Severity != notifier.SeverityCritical {
+		t.Fatalf("Expected a critical all IPs down alert, got %+v", events)
+	}
+	if events := check(down); len(events) != 0 {
+		t.Errorf("Expected no repeated alert while the origin stays down, got %+v", events)
+	}
+	if events := check(up); len(events) != 0 {
+		t.Errorf("Expected no alert once healthy, got %+v", events)
+	}
+	if events := check(down); len(events) != 1 {
+		t.Errorf("Expected a new alert after the origin recovered, got %+v", events)
+	}
+}
+
+func TestServiceCheckOrigin_AllIPsDownEscalates(t *testing.T) {
+	origin := statusTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+	service.config.Escalation = &config.EscalationConfig{
+		Intervals:  []config.Seconds{config.Seconds(time.Minute), config.Seconds(5 * time.Minute)},
+		MaxRepeats: 3,
+	}
+	recorder := &recordingNotifier{}
+	service.notifiers = []notifier.Notifier{recorder}
+
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "1", Content: "192.0.2.1"}}, nil
+	}
+	down := hcmock.NewCheckerMock(func(ip string) error { return errors.New("down") })
+	up := hcmock.NewCheckerMock(func(ip string) error { return nil })
+
+	check := func(checker *hcmock.CheckerMock) []notifier.FailoverEvent {
+		service.checkOrigin(context.Background(), origin, checker)
+		waitForNotifications(t, service)
+		return recorder.take()
+	}
+	// rewind moves the last send of the alert into the past
+	rewind := func(d time.Duration) {
+		alert := service.alerts.open[originKeyFor(origin)+"/"+string(notifier.EventTypeAllIPsDown)]
+		alert.sent = alert.sent.Add(-d)
+		alert.since = alert.since.Add(-d)
+	}
+
+	if events := check(down); len(events) != 1 || events[0].Repeat != 0 {
+		t.Fatalf("Expected the first alert, got %+v", events)
+	}
+	if events := check(down); len(events) != 0 {
+		t.Fatalf("Expected no repeat before the first interval, got %+v", events)
+	}
+
+	rewind(time.Minute)
+	events := check(down)
+	if len(events) != 1 || events[0].Type != notifier.EventTypeAllIPsDown || events[0].Repeat != 1 || events[0].Severity != notifier.SeverityCritical {
+		t.Fatalf("Expected the first repeat after a minute, got %+v", events)
+	}
+	if !strings.HasPrefix(events[0].Reason, "Still no healthy IP after 1m0s") {
+		t.Errorf("Reason = %q", events[0].Reason)
+	}
+
+	// The last interval repeats until max_repeats
+	rewind(time.Minute)
+	if events := check(down); len(events) != 0 {
+		t.Errorf("Expected the second interval to be 5 minutes, got %+v", events)
+	}
+	for _, want := range []int{2, 3} {
+		rewind(5 * time.Minute)
+		if events := check(down); len(events) != 1 || events[0].Repeat != want {
+			t.Fatalf("Expected repeat %d, got %+v", want, events)
+		}
+	}
+	rewind(5 * time.Minute)
+	if events := check(down); len(events) != 0 {
+		t.Errorf("Expected no more repeats after max_repeats, got %+v", events)
+	}
+
+	// Recovering starts over
+	check(up)
+	if events := check(down); len(events) != 1 || events[0].Repeat != 0 {
+		t.Errorf("Expected a new first alert after recovering, got %+v", events)
+	}
+}
+
+func TestServiceCheckOrigin_APIFailureAlertsOnce(t *testing.T) {
+	origin := statusTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+	recorder := &recordingNotifier{}
+	service.notifiers = []notifier.Notifier{recorder}
+
+	readErr := errors.New("connection refused")
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		if readErr != nil {
+			return nil, readErr
+		}
+		return []dns.RecordResponse{{ID: "1", Content: "198.51.100.1"}}, nil
+	}
+	updateErr := errors.New("internal server error")
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		return updateErr
+	}
+	checker := hcmock.NewCheckerMock(func(ip string) error { return nil })
+
+	check := func() []notifier.FailoverEvent {
+		service.checkOrigin(context.Background(), origin, checker)
+		waitForNotifications(t, service)
+		return recorder.take()
+	}
+
+	events := check()
+	if len(events) != 1 || events[0].Type != notifier.EventTypeAPIFailure {
+		t.Fatalf("Expected an API failure alert, got %+v", events)
+	}
+
+	// Reading works again but the update still fails: the same failure
+	readErr = nil
+	if events := check(); len(events) != 0 {
+		t.Errorf("Expected no repeated alert while the API keeps failing, got %+v", events)
+	}
+
+	// Once the records are updated, the failover is notified and the alert cleared
+	updateErr = nil
+	if events := check(); len(events) != 1 || events[0].Type != notifier.EventTypeFailover || events[0].Severity != notifier.SeverityInfo {
+		t.Errorf("Expected the return to the priority IP, got %+v", events)
+	}
+	updateErr = errors.New("internal server error")
+	readErr = errors.New("connection refused")
+	if events := check(); len(events) != 1 || events[0].Type != notifier.EventTypeAPIFailure {
+		t.Errorf("Expected a new alert after the API recovered, got %+v", events)
+	}
+}
+
+func TestServiceRunOriginCheck_CheckerFailureAlertsOnce(t *testing.T) {
+	origin := statusTestOrigin()
+	origin.HealthCheck.Type = "gopher"
+	service, _ := createTestService(origin)
+	recorder := &recordingNotifier{}
+	service.notifiers = []notifier.Notifier{recorder}
+
+	for range 2 {
+		if err := service.runOriginCheck(context.Background(), origin); err == nil {
+			t.Fatal("Expected an error for an unknown health check type")
+		}
+	}
+	waitForNotifications(t, service)
+	events := recorder.take()
+	if len(events) != 1 || events[0].Type != notifier.EventTypeCheckerFailed || events[0].Severity != notifier.SeverityCritical {
+		t.Fatalf("Expected one critical health checker alert, got %+v", events)
+	}
+	if events[0].OriginName != origin.Name {
+		t.Errorf("OriginName = %q", events[0].OriginName)
+	}
+}
+
+func TestServiceReportReload(t *testing.T) {
+	service, _ := createTestService(statusTestOrigin())
+	recorder := &recordingNotifier{}
+	service.notifiers = []notifier.Notifier{recorder}
+
+	report := func(err error) []notifier.FailoverEvent {
+		service.ReportReload(context.Background(), err)
+		waitForNotifications(t, service)
+		return recorder.take()
+	}
+
+	events := report(errors.New("invalid config"))
+	if len(events) != 1 || events[0].Type != notifier.EventTypeReloadFailed || events[0].Severity != notifier.SeverityWarning {
+		t.Fatalf("Expected a reload failure alert, got %+v", events)
+	}
+	if events[0].OriginName != "" || !strings.HasSuffix(events[0].Reason, "invalid config") {
+		t.Errorf("Unexpected event %+v", events[0])
+	}
+	if events := report(errors.New("invalid config")); len(events) != 0 {
+		t.Errorf("Expected no repeated alert while reloading keeps failing, got %+v", events)
+	}
+	if events := report(nil); len(events) != 0 {
+		t.Errorf("Expected no alert for a successful reload, got %+v", events)
+	}
+	if events := report(errors.New("invalid config")); len(events) != 1 {
+		t.Errorf("Expected a new alert after a successful reload, got %+v", events)
+	}
+}
@@ -0,0 +1,88 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrOutsideAllowlist is returned when a record would publish an IP outside the allowed CIDRs
+var ErrOutsideAllowlist = errors.New("IP is outside the allowed CIDRs")
+
+// buildAllowlists parses the allowed CIDRs of every zone. Zones without
+// any, directly or through the global list, are not restricted.
+func buildAllowlists(cfg *config.Config) (map[string][]netip.Prefix, error) {
+	allowlists := make(map[string][]netip.Prefix)
+	for _, zone := range cfg.CloudflareZoneIDs {
+		cidrs := cfg.ZoneAllowedCIDRs(zone.Name)
+		if len(cidrs) == 0 {
+			continue
+		}
+		prefixes, err := config.ParseAllowlist(cidrs)
+		if err != nil {
+			return nil, errors.Wrapf(err, "zone %s", zone.Name)
+		}
+		allowlists[zone.Name] = prefixes
+	}
+	return allowlists, nil
+}
+
+// disallowedIPs returns the IPs that the zone's allowlist does not cover.
+func (s *Service) disallowedIPs(zoneName string, ips []string) []string {
+	prefixes, ok := s.allowlists[zoneName]
+	if !ok {
+		return nil
+	}
+	var disallowed []string
+	for _, ip := range ips {
+		addr, err := netip.ParseAddr(ip)
+		if err == nil && containsAddr(prefixes, addr.Unmap()) {
+			continue
+		}
+		disallowed = append(disallowed, ip)
+	}
+	return disallowed
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// guardAllowlist reports whether selectedIPs may be published for the
+// origin. Refused changes are alerted once until the refused IPs change.
+func (s *Service) guardAllowlist(ctx context.Context, origin config.OriginConfig, originKey string, currentIPs, selectedIPs []string) bool {
+	disallowed := s.disallowedIPs(origin.ZoneName, selectedIPs)
+	if !s.setBlocked(originKey, disallowed) {
+		return len(disallowed) == 0
+	}
+	reason := fmt.Sprintf("Refusing to publish %v: outside the allowed CIDRs", disallowed)
+	s.logf("%s for %s (%s)", reason, origin.Name, origin.RecordType)
+	s.sendAlert(ctx, notifier.EventTypeAllowlistViolation, origin, currentIPs, selectedIPs, reason)
+	return false
+}
+
+// setBlocked records the IPs refused by the allowlist and reports whether
+// a new set of IPs has just been refused.
+func (s *Service) setBlocked(originKey string, blocked []string) bool {
+	s.originStatusMutex.Lock()
+	defer s.originStatusMutex.Unlock()
+
+	status := s.originStatus[originKey]
+	if status == nil {
+		status = &OriginStatus{}
+		s.originStatus[originKey] = status
+	}
+
+	changed := len(blocked) > 0 && !sameIPSet(status.BlockedIPs, blocked)
+	status.BlockedIPs = blocked
+	return changed
+}
@@ -0,0 +1,122 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestServiceCheckOrigin_RefusesIPsOutsideAllowlist(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		HealthCheck: config.HealthCheck{
+			Type:     "http",
+			Endpoint: "/health",
+			Timeout:  config.Seconds(5 * time.Second),
+		},
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.0.2.1"}},
+			// A typo: 198.51.100.1 was meant
+			{Priority: 50, IPs: []string{"198.51.10.1"}},
+		},
+	}
+	service, dnsClientMock := createTestService(origin)
+	service.allowlists = map[string][]netip.Prefix{
+		"default": {netip.MustParsePrefix("192.0.2.0/24"), netip.MustParsePrefix("198.51.100.0/24")},
+	}
+	recorder := &recordingNotifier{}
+	service.notifiers = []notifier.Notifier{recorder}
+
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "1", Content: "192.0.2.1"}}, nil
+	}
+	replaceCallCount := 0
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		replaceCallCount++
+		return nil
+	}
+	checker := hcmock.NewCheckerMock(func(ip string) error {
+		if ip == "192.0.2.1" {
+			return fmt.Errorf("unhealthy")
+		}
+		return nil
+	})
+
+	service.checkOrigin(context.Background(), origin, checker)
+	service.checkOrigin(context.Background(), origin, checker)
+	waitForNotifications(t, service)
+
+	if replaceCallCount != 0 {
+		t.Fatalf("expected no DNS change, got %d", replaceCallCount)
+	}
+	if events := recorder.take(); len(events) != 1 || events[0].Type != notifier.EventTypeAllowlistViolation {
+		t.Fatalf("expected a single allowlist alert, got %+v", events)
+	}
+	status := service.OriginStatuses()[originKeyFor(origin)]
+	if !sameStringSet(status.BlockedIPs, []string{"198.51.10.1"}) || !sameStringSet(status.CurrentIPs, []string{"192.0.2.1"}) {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	// Once the primary recovers, nothing is blocked any more
+	checker = hcmock.NewCheckerMock(func(ip string) error { return nil })
+	service.checkOrigin(context.Background(), origin, checker)
+	if status := service.OriginStatuses()[originKeyFor(origin)]; len(status.BlockedIPs) != 0 {
+		t.Errorf("expected no blocked IPs, got %v", status.BlockedIPs)
+	}
+}
+
+func TestBuildAllowlists(t *testing.T) {
+	cfg := &config.Config{
+		AllowedCIDRs: []string{"192.0.2.0/24"},
+		CloudflareZoneIDs: []config.ZoneConfig{
+			{Name: "example.com"},
+			{Name: "example.net", AllowedCIDRs: []string{"2001:db8::/32", "198.51.100.7"}},
+		},
+	}
+	allowlists, err := buildAllowlists(cfg)
+	if err != nil {
+		t.Fatalf("buildAllowlists: %v", err)
+	}
+	service := &Service{allowlists: allowlists}
+
+	for _, tt := range []struct {
+		zone string
+		ips  []string
+		want []string
+	}{
+		{"example.com", []string{"192.0.2.10", "198.51.100.7"}, []string{"198.51.100.7"}},
+		{"example.net", []string{"198.51.100.7", "2001:db8::1", "192.0.2.10"}, []string{"192.0.2.10"}},
+		{"unrestricted.org", []string{"203.0.113.1"}, nil},
+	} {
+		if got := service.disallowedIPs(tt.zone, tt.ips); !sameStringSet(got, tt.want) {
+			t.Errorf("disallowedIPs(%s, %v) = %v, want %v", tt.zone, tt.ips, got, tt.want)
+		}
+	}
+}
+
+func TestApplySnapshot_RefusesIPsOutsideAllowlist(t *testing.T) {
+	origin := config.OriginConfig{Name: "example.com", ZoneName: "default", RecordType: "A"}
+	service, dnsClientMock := createTestService(origin)
+	service.allowlists = map[string][]netip.Prefix{"default": {netip.MustParsePrefix("192.0.2.0/24")}}
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		t.Error("ReplaceRecords should not be called")
+		return nil
+	}
+
+	err := service.ApplySnapshot(context.Background(), Snapshot{Records: []SnapshotRecord{
+		{Zone: "default", Name: "example.com", Type: "A", IPs: []string{"203.0.113.1"}},
+	}})
+	if !errors.Is(err, ErrOutsideAllowlist) {
+		t.Fatalf("expected ErrOutsideAllowlist, got %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package gslb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/history"
+	"github.com/bootjp/cloudflare-gslb/pkg/metrics"
+)
+
+var availabilityMetric = metrics.NewGauge("gslb.availability", "1", "Ratio of checks in which the origin had a healthy IP, by window")
+
+// availabilityWindows are the rolling windows availability is reported over.
+var availabilityWindows = []struct {
+	name   string
+	length time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// availabilityRetention is the longest window; older buckets are dropped.
+const availabilityRetention = 30 * 24 * time.Hour
+
+// AvailabilityReport is the availability of an origin over a rolling window.
+type AvailabilityReport struct {
+	Window string `json:"window"`
+	Checks int    `json:"checks"`
+	Up     int    `json:"up"`
+	// Percent is the share of checks in which the origin had a healthy IP,
+	// or 100 when there were none.
+	Percent float64 `json:"percent"`
+}
+
+// availabilityBucket counts the checks of an origin in one hour. saved* are
+// the counts already written to the event history.
+type availabilityBucket struct {
+	hour        time.Time
+	last        time.Time
+	checks, up  int
+	savedChecks int
+	savedUp     int
+}
+
+// availabilityTracker keeps hourly check counts of each origin for the
+// longest availability window. The zero value is ready to use.
+type availabilityTracker struct {
+	mu      sync.Mutex
+	buckets map[string][]*availabilityBucket
+}
+
+// checkCountsTowardsAvailability reports whether a check with result says
+// anything about the origin, and if so, whether it was up. Checks that could
+// not run are not counted.
+func checkCountsTowardsAvailability(result string) (counted, up bool) {
+	switch result {
+	case CheckResultRecordsFailed, CheckResultCheckerFailed, CheckResultNoLevels:
+		return false, false
+	case CheckResultNoHealthyIPs:
+		return true, false
+	default:
+		return true, true
+	}
+}
+
+// observe counts a check of originKey and returns the buckets of earlier
+// hours that have unsaved counts.
+func (t *availabilityTracker) observe(originKey string, up bool, now time.Time) []availabilityBucket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	bucket := t.bucketLocked(originKey, now.Truncate(time.Hour))
+	bucket.checks++
+	if up {
+		bucket.up++
+	}
+	bucket.last = now
+	return t.unsavedLocked(originKey, func(b *availabilityBucket) bool { return b != bucket })
+}
+
+// unsaved returns every bucket of originKey with unsaved counts, e.g. before
+// the service stops.
+func (t *availabilityTracker) unsaved(originKey string) []availabilityBucket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.unsavedLocked(originKey, func(*availabilityBucket) bool { return true })
+}
+
+// unsavedLocked returns copies of the selected buckets with unsaved counts,
+// holding only the unsaved part, and marks them saved. t.mu must be held.
+func (t *availabilityTracker) unsavedLocked(originKey string, include func(*availabilityBucket) bool) []availabilityBucket {
+	var unsaved []availabilityBucket
+	for _, b := range t.buckets[originKey] {
+		if b.checks == b.savedChecks || !include(b) {
+			continue
+		}
+		unsaved = append(unsaved, availabilityBucket{hour: b.hour, last: b.last, checks: b.checks - b.savedChecks, up: b.up - b.savedUp})
+		b.savedChecks, b.savedUp = b.checks, b.up
+	}
+	return unsaved
+}
+
+// restore adds counts loaded from the event history, which are already saved.
+func (t *availabilityTracker) restore(originKey string, at time.Time, checks, up int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	bucket := t.bucketLocked(originKey, at.Truncate(time.Hour))
+	bucket.checks += checks
+	bucket.up += up
+	bucket.savedChecks += checks
+	bucket.savedUp += up
+	if at.After(bucket.last) {
+		bucket.last = at
+	}
+}
+
+// bucketLocked returns the bucket of hour, creating it and dropping buckets
+// beyond the retention. t.mu must be held.
+func (t *availabilityTracker) bucketLocked(originKey string, hour time.Time) *availabilityBucket {
+	buckets := t.buckets[originKey]
+	for _, b := range buckets {
+		if b.hour.Equal(hour) {
+			return b
+		}
+	}
+	cutoff := hour.Add(-availabilityRetention)
+	kept := buckets[:0]
+	for _, b := range buckets {
+		if b.hour.After(cutoff) {
+			kept = append(kept, b)
+		}
+	}
+	bucket := &availabilityBucket{hour: hour}
+	if t.buckets == nil {
+		t.buckets = make(map[string][]*availabilityBucket)
+	}
+	t.buckets[originKey] = append(kept, bucket)
+	return bucket
+}
+
+// report returns the availability of originKey over each window ending at now.
+func (t *availabilityTracker) report(originKey string, now time.Time) []AvailabilityReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	reports := make([]AvailabilityReport, 0, len(availabilityWindows))
+	for _, window := range availabilityWindows {
+		// Hourly buckets make the windows start on the hour
+		since := now.Add(-window.length).Truncate(time.Hour)
+		report := AvailabilityReport{Window: window.name, Percent: 100}
+		for _, b := range t.buckets[originKey] {
+			if !b.hour.Before(since) {
+				report.Checks += b.checks
+				report.Up += b.up
+			}
+		}
+		if report.Checks > 0 {
+			report.Percent = 100 * float64(report.Up) / float64(report.Checks)
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// recordAvailability counts a check of origin with result towards its
+// availability, and saves the counts of past hours to the event history.
+func (s *Service) recordAvailability(origin config.OriginConfig, originKey, result string) {
+	counted, up := checkCountsTowardsAvailability(result)
+	if !counted {
+		return
+	}
+	now := s.now()
+	s.saveAvailability(origin, s.availability.observe(originKey, up, now))
+	for _, report := range s.availability.report(originKey, now) {
+		availabilityMetric.Set(report.Percent/100, originAttributes(origin, metrics.String("window", report.Window))...)
+	}
+}
+
+// saveAvailability writes buckets to the event history.
+func (s *Service) saveAvailability(origin config.OriginConfig, buckets []availabilityBucket) {
+	for _, b := range buckets {
+		up := b.up
+		s.recordEvent(origin, history.Event{Time: b.last, Type: history.TypeAvailability, Checks: b.checks, Up: &up})
+	}
+}
+
+// loadAvailability restores the availability counts saved in the event
+// history, so that the windows survive restarts and reloads.
+func (s *Service) loadAvailability() {
+	if s.history == nil {
+		return
+	}
+	events, err := s.history.Query(history.Filter{Type: history.TypeAvailability, Since: s.now().Add(-availabilityRetention)})
+	if err != nil {
+		s.logf("Failed to load availability from the event history: %v", err)
+		return
+	}
+	keys := make(map[string]string, len(s.config.Origins))
+	for _, origin := range s.config.Origins {
+		keys[origin.ZoneName+"\x00"+origin.Name+"\x00"+origin.RecordType] = originKeyFor(origin)
+	}
+	for _, event := range events {
+		originKey, ok := keys[event.Zone+"\x00"+event.Origin+"\x00"+event.RecordType]
+		if !ok || event.Up == nil {
+			continue
+		}
+		s.availability.restore(originKey, event.Time, event.Checks, *event.Up)
+	}
+}
+
+// flushAvailability saves the counts of the current hour, e.g. before the
+// service stops.
+func (s *Service) flushAvailability() {
+	for _, origin := range s.config.Origins {
+		s.saveAvailability(origin, s.availability.unsaved(originKeyFor(origin)))
+	}
+}
@@ -0,0 +1,109 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/bootjp/cloudflare-gslb/pkg/history"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestAvailabilityTracker(t *testing.T) {
+	var tracker availabilityTracker
+	now := time.Date(2024, 5, 31, 12, 30, 0, 0, time.UTC)
+
+	// Down for the first 10 of 40 days, up since; the check exactly 30 days
+	// ago falls outside the 30d window
+	for day := 40; day > 0; day-- {
+		at := now.Add(-time.Duration(day) * 24 * time.Hour)
+		tracker.observe("origin", day <= 30, at)
+	}
+	tracker.observe("origin", false, now.Add(-time.Hour))
+	if saved := tracker.observe("origin", true, now); len(saved) != 1 || saved[0].checks != 1 || saved[0].up != 0 {
+		t.Errorf("Expected the previous hour to be saved, got %+v", saved)
+	}
+
+	reports := tracker.report("origin", now)
+	want := []AvailabilityReport{
+		{Window: "24h", Checks: 3, Up: 2},
+		{Window: "7d", Checks: 9, Up: 8},
+		{Window: "30d", Checks: 31, Up: 30},
+	}
+	if len(reports) != len(want) {
+		t.Fatalf("report() = %+v", reports)
+	}
+	for i, report := range reports {
+		if report.Window != want[i].Window || report.Checks != want[i].Checks || report.Up != want[i].Up {
+			t.Errorf("report %s = %+v, want %+v", want[i].Window, report, want[i])
+		}
+	}
+	if percent := reports[2].Percent; percent < 96.7 || percent > 96.8 {
+		t.Errorf("30d percent = %v, want 30/31", percent)
+	}
+
+	// Buckets beyond 30 days are dropped
+	if n := len(tracker.buckets["origin"]); n > 33 {
+		t.Errorf("Expected old buckets to be dropped, got %d", n)
+	}
+
+	if reports := tracker.report("unchecked", now); reports[0].Checks != 0 || reports[0].Percent != 100 {
+		t.Errorf("Expected 100%% without checks, got %+v", reports[0])
+	}
+}
+
+func TestAvailabilityTracker_SavesOnlyNewCounts(t *testing.T) {
+	var tracker availabilityTracker
+	now := time.Date(2024, 5, 31, 12, 30, 0, 0, time.UTC)
+
+	tracker.restore("origin", now.Add(-10*time.Minute), 5, 4)
+	tracker.observe("origin", true, now)
+	saved := tracker.unsaved("origin")
+	if len(saved) != 1 || saved[0].checks != 1 || saved[0].up != 1 {
+		t.Fatalf("unsaved() = %+v, want only the new check", saved)
+	}
+	if saved := tracker.unsaved("origin"); len(saved) != 0 {
+		t.Errorf("Expected nothing left to save, got %+v", saved)
+	}
+	if report := tracker.report("origin", now)[0]; report.Checks != 6 || report.Up != 5 {
+		t.Errorf("report() = %+v, want restored and new checks", report)
+	}
+}
+
+func TestService_AvailabilitySurvivesRestart(t *testing.T) {
+	origin := statusTestOrigin()
+	store, err := history.Open(filepath.Join(t.TempDir(), "events.log"), 0)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	service, dnsClientMock := createTestService(origin)
+	service.history = store
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "1", Content: "192.0.2.1"}}, nil
+	}
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		return nil
+	}
+	service.checkOrigin(context.Background(), origin, hcmock.NewCheckerMock(func(ip string) error { return nil }))
+	service.checkOrigin(context.Background(), origin, hcmock.NewCheckerMock(func(ip string) error { return errors.New("down") }))
+	service.flushAvailability()
+
+	restarted, _ := createTestService(origin)
+	restarted.history = store
+	restarted.loadAvailability()
+	report := restarted.OriginReports()[0].Availability[0]
+	if report.Checks != 2 || report.Up != 1 || report.Percent != 50 {
+		t.Errorf("availability after restart = %+v, want 1 of 2 checks up", report)
+	}
+
+	// Flushing again writes nothing new
+	restarted.flushAvailability()
+	events, err := store.Query(history.Filter{Type: history.TypeAvailability})
+	if err != nil || len(events) != 1 {
+		t.Errorf("Expected one availability event, got %d (%v)", len(events), err)
+	}
+}
@@ -0,0 +1,95 @@
+package gslb
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+)
+
+const globalChangeLimitKey = ""
+
+// changeLimiter tracks DNS mutations in sliding windows and freezes further
+// changes once the global or per-origin budget has been spent.
+type changeLimiter struct {
+	mu      sync.Mutex
+	global  config.ChangeLimitConfig
+	history map[string][]time.Time
+	frozen  map[string]bool
+}
+
+func newChangeLimiter(global config.ChangeLimitConfig) *changeLimiter {
+	return &changeLimiter{
+		global:  global,
+		history: make(map[string][]time.Time),
+		frozen:  make(map[string]bool),
+	}
+}
+
+// allow reports whether another change for originKey fits within the
+// configured limits. When it does not, reason describes the exhausted limit
+// and firstBlock is true only for the first blocked attempt of a freeze so
+// callers can alert once instead of every cycle.
+func (l *changeLimiter) allow(originKey string, originLimit *config.ChangeLimitConfig, now time.Time) (ok bool, reason string, firstBlock bool) {
+	if l == nil {
+		return true, "", false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if originLimit != nil && originLimit.Enabled() {
+		if ok, reason, firstBlock := l.allowScope(originKey, "origin", *originLimit, now); !ok {
+			return false, reason, firstBlock
+		}
+	}
+
+	if l.global.Enabled() {
+		if ok, reason, firstBlock := l.allowScope(globalChangeLimitKey, "global", l.global, now); !ok {
+			return false, reason, firstBlock
+		}
+	}
+
+	return true, "", false
+}
+
+func (l *changeLimiter) allowScope(key, scope string, limit config.ChangeLimitConfig, now time.Time) (bool, string, bool) {
+	window := limit.Window()
+	l.history[key] = pruneBefore(l.history[key], now.Add(-window))
+
+	if len(l.history[key]) < limit.MaxChanges {
+		l.frozen[key] = false
+		return true, "", false
+	}
+
+	firstBlock := !l.frozen[key]
+	l.frozen[key] = true
+	reason := fmt.Sprintf("%s change limit of %d changes per %s exceeded, DNS changes frozen", scope, limit.MaxChanges, window)
+	return false, reason, firstBlock
+}
+
+// record registers a successful change against the origin and global budgets.
+func (l *changeLimiter) record(originKey string, originLimit *config.ChangeLimitConfig, now time.Time) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if originLimit != nil && originLimit.Enabled() {
+		l.history[originKey] = append(l.history[originKey], now)
+	}
+	if l.global.Enabled() {
+		l.history[globalChangeLimitKey] = append(l.history[globalChangeLimitKey], now)
+	}
+}
+
+func pruneBefore(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	return times[i:]
+}
@@ -0,0 +1,124 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestChangeLimiter_OriginLimit(t *testing.T) {
+	limiter := newChangeLimiter(config.ChangeLimitConfig{})
+	limit := &config.ChangeLimitConfig{MaxChanges: 2, WindowSeconds: config.Seconds(time.Minute)}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if ok, _, _ := limiter.allow("origin", limit, now); !ok {
+			t.Fatalf("change %d should be allowed", i+1)
+		}
+		limiter.record("origin", limit, now)
+	}
+
+	ok, reason, firstBlock := limiter.allow("origin", limit, now)
+	if ok {
+		t.Fatal("third change should be blocked")
+	}
+	if reason == "" {
+		t.Error("expected a reason for the blocked change")
+	}
+	if !firstBlock {
+		t.Error("first blocked change should be reported as firstBlock")
+	}
+
+	if _, _, firstBlock := limiter.allow("origin", limit, now); firstBlock {
+		t.Error("subsequent blocked changes should not be reported as firstBlock")
+	}
+
+	if ok, _, _ := limiter.allow("other", limit, now); !ok {
+		t.Error("other origins should not be affected by the origin limit")
+	}
+
+	if ok, _, _ := limiter.allow("origin", limit, now.Add(61*time.Second)); !ok {
+		t.Error("change should be allowed again once the window has passed")
+	}
+}
+
+func TestChangeLimiter_GlobalLimit(t *testing.T) {
+	limiter := newChangeLimiter(config.ChangeLimitConfig{MaxChanges: 1})
+	now := time.Now()
+
+	if ok, _, _ := limiter.allow("a", nil, now); !ok {
+		t.Fatal("first change should be allowed")
+	}
+	limiter.record("a", nil, now)
+
+	if ok, _, _ := limiter.allow("b", nil, now.Add(30*time.Minute)); ok {
+		t.Error("global limit should block changes for any origin")
+	}
+	if ok, _, _ := limiter.allow("b", nil, now.Add(config.DefaultChangeLimitWindow+time.Second)); !ok {
+		t.Error("global limit should use the default window when unset")
+	}
+}
+
+func TestServiceCheckOrigin_ChangeLimitFreezesUpdates(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.168.1.1"}},
+			{Priority: 50, IPs: []string{"192.168.1.2"}},
+		},
+		ReturnToPriority: true,
+		ChangeLimit:      &config.ChangeLimitConfig{MaxChanges: 1},
+	}
+
+	service, dnsClientMock := createTestService(origin)
+	service.changeLimiter = newChangeLimiter(config.ChangeLimitConfig{})
+	recorder := &recordingNotifier{}
+	service.notifiers = []notifier.Notifier{recorder}
+
+	current := "192.168.1.1"
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "record-1", Name: name, Type: dns.RecordResponseTypeA, Content: current}}, nil
+	}
+	replaceCallCount := 0
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		replaceCallCount++
+		current = newContents[0]
+		return nil
+	}
+
+	unhealthy := "192.168.1.1"
+	checker := hcmock.NewCheckerMock(func(ip string) error {
+		if ip == unhealthy {
+			return fmt.Errorf("unhealthy")
+		}
+		return nil
+	})
+
+	service.checkOrigin(context.Background(), origin, checker)
+	if replaceCallCount != 1 {
+		t.Fatalf("ReplaceRecords was called %d times, expected 1", replaceCallCount)
+	}
+
+	unhealthy = "192.168.1.2"
+	service.checkOrigin(context.Background(), origin, checker)
+	if replaceCallCount != 1 {
+		t.Fatalf("ReplaceRecords was called %d times after limit, expected 1", replaceCallCount)
+	}
+
+	waitForNotifications(t, service)
+	events := recorder.take()
+	if !slices.ContainsFunc(events, func(event notifier.FailoverEvent) bool {
+		return event.Type == notifier.EventTypeChangeLimitExceeded
+	}) {
+		t.Errorf("expected change limit alert, got %+v", events)
+	}
+}
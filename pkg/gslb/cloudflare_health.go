@@ -0,0 +1,128 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/cloudflare"
+	"github.com/bootjp/cloudflare-gslb/pkg/healthcheck"
+)
+
+// healthStatusSource provides the latest Cloudflare Health Check results of a zone.
+type healthStatusSource interface {
+	Statuses(ctx context.Context) ([]cloudflare.HealthCheckStatus, error)
+}
+
+// buildHealthCheckClients creates one Health Check client per Cloudflare zone
+// used by an origin with cloudflare_health_check enabled.
+func buildHealthCheckClients(cfg *config.Config, limiter *cloudflare.RateLimiter, logger *log.Logger) map[string]healthStatusSource {
+	zones := make(map[string]config.ZoneConfig)
+	for _, zone := range cfg.CloudflareZoneIDs {
+		zones[zone.Name] = zone
+	}
+
+	clients := make(map[string]healthStatusSource)
+	for _, origin := range cfg.Origins {
+		if !origin.CloudflareHealth.Enabled() {
+			continue
+		}
+		zone, ok := zones[origin.ZoneName]
+		if !ok || clients[zone.Name] != nil {
+			continue
+		}
+		if zone.EffectiveProvider() != config.ProviderCloudflare {
+			logger.Printf("Origin %s enables cloudflare_health_check, but zone %s is not on Cloudflare; ignoring", origin.Name, zone.Name)
+			continue
+		}
+
+		credentials := cfg.CredentialsForZone(zone.Name)
+		clients[zone.Name] = cloudflare.NewHealthCheckClient(credentials.APIToken, zone.ZoneID, cloudflareClientOptions(cfg, credentials, limiter)...)
+	}
+	return clients
+}
+
+// withCloudflareHealth returns a checker that combines checker with the
+// origin's Cloudflare Health Check results. If the results cannot be read,
+// the local checker is used alone.
+func (s *Service) withCloudflareHealth(ctx context.Context, origin config.OriginConfig, checker healthcheck.Checker) healthcheck.Checker {
+	if !origin.CloudflareHealth.Enabled() {
+		return checker
+	}
+	source, ok := s.healthCheckClients[origin.ZoneName]
+	if !ok {
+		return checker
+	}
+
+	statuses, err := source.Statuses(ctx)
+	if err != nil {
+		s.logf("Failed to read Cloudflare health checks for %s, using local checks only: %v", origin.Name, err)
+		return checker
+	}
+
+	return &cloudflareHealthChecker{
+		local:    checker,
+		statuses: s.matchHealthChecks(origin.CloudflareHealth, statuses),
+		mode:     origin.CloudflareHealth.EffectiveMode(),
+	}
+}
+
+// matchHealthChecks maps each IP to its Health Check, using the configured
+// IDs first and the check's address otherwise.
+func (s *Service) matchHealthChecks(cfg *config.CloudflareHealthCheckConfig, statuses []cloudflare.HealthCheckStatus) map[string]cloudflare.HealthCheckStatus {
+	byID := make(map[string]cloudflare.HealthCheckStatus, len(statuses))
+	byIP := make(map[string]cloudflare.HealthCheckStatus, len(statuses))
+	for _, status := range statuses {
+		byID[status.ID] = status
+		if status.Address != "" {
+			byIP[status.Address] = status
+		}
+	}
+	for ip, id := range cfg.IDs {
+		if status, ok := byID[id]; ok {
+			byIP[ip] = status
+		} else {
+			s.logf("Cloudflare health check %s for %s not found", id, ip)
+		}
+	}
+	return byIP
+}
+
+// cloudflareHealthChecker combines a local checker with Cloudflare Health
+// Check results. IPs without a known Cloudflare result use the local checker.
+type cloudflareHealthChecker struct {
+	local    healthcheck.Checker
+	statuses map[string]cloudflare.HealthCheckStatus
+	mode     string
+}
+
+func (c *cloudflareHealthChecker) Check(ip string) error {
+	status, ok := c.statuses[ip]
+	if !ok || !status.Known() {
+		return c.local.Check(ip)
+	}
+
+	var remoteErr error
+	if !status.Healthy() {
+		remoteErr = fmt.Errorf("cloudflare health check %s is %s: %s", status.Name, status.Status, status.FailureReason)
+	}
+
+	switch c.mode {
+	case config.CloudflareHealthModeOnly:
+		return remoteErr
+	case config.CloudflareHealthModeAny:
+		if remoteErr == nil {
+			return nil
+		}
+		if err := c.local.Check(ip); err != nil {
+			return fmt.Errorf("%v; local check: %w", remoteErr, err)
+		}
+		return nil
+	default:
+		if remoteErr != nil {
+			return remoteErr
+		}
+		return c.local.Check(ip)
+	}
+}
@@ -0,0 +1,118 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/cloudflare"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+type fakeHealthStatusSource struct {
+	statuses []cloudflare.HealthCheckStatus
+	err      error
+}
+
+func (f *fakeHealthStatusSource) Statuses(ctx context.Context) ([]cloudflare.HealthCheckStatus, error) {
+	return f.statuses, f.err
+}
+
+func TestCloudflareHealthChecker_Modes(t *testing.T) {
+	statuses := map[string]cloudflare.HealthCheckStatus{
+		"192.0.2.1": {Name: "primary", Status: "unhealthy", FailureReason: "timeout"},
+		"192.0.2.2": {Name: "backup", Status: "healthy"},
+		"192.0.2.3": {Name: "paused", Status: "suspended"},
+	}
+	localDown := map[string]bool{"192.0.2.2": true}
+	local := hcmock.NewCheckerMock(func(ip string) error {
+		if localDown[ip] {
+			return errors.New("local failure")
+		}
+		return nil
+	})
+
+	tests := []struct {
+		mode    string
+		ip      string
+		healthy bool
+	}{
+		{mode: config.CloudflareHealthModeAll, ip: "192.0.2.1", healthy: false},
+		{mode: config.CloudflareHealthModeAll, ip: "192.0.2.2", healthy: false},
+		{mode: config.CloudflareHealthModeAny, ip: "192.0.2.1", healthy: true},
+		{mode: config.CloudflareHealthModeAny, ip: "192.0.2.2", healthy: true},
+		{mode: config.CloudflareHealthModeOnly, ip: "192.0.2.1", healthy: false},
+		{mode: config.CloudflareHealthModeOnly, ip: "192.0.2.2", healthy: true},
+		// Suspended and unmatched checks carry no signal, so the local result is used
+		{mode: config.CloudflareHealthModeOnly, ip: "192.0.2.3", healthy: true},
+		{mode: config.CloudflareHealthModeAll, ip: "192.0.2.4", healthy: true},
+	}
+
+	for _, tt := range tests {
+		checker := &cloudflareHealthChecker{local: local, statuses: statuses, mode: tt.mode}
+		if err := checker.Check(tt.ip); (err == nil) != tt.healthy {
+			t.Errorf("mode %s, ip %s: got err=%v, want healthy=%v", tt.mode, tt.ip, err, tt.healthy)
+		}
+	}
+}
+
+func TestMatchHealthChecks_PrefersConfiguredIDs(t *testing.T) {
+	statuses := []cloudflare.HealthCheckStatus{
+		{ID: "hc-1", Address: "origin.example.com", Status: "healthy"},
+		{ID: "hc-2", Address: "192.0.2.2", Status: "unhealthy"},
+	}
+	cfg := &config.CloudflareHealthCheckConfig{IDs: map[string]string{"192.0.2.1": "hc-1"}}
+
+	matched := (&Service{}).matchHealthChecks(cfg, statuses)
+	if matched["192.0.2.1"].ID != "hc-1" {
+		t.Errorf("expected 192.0.2.1 to use hc-1, got %+v", matched["192.0.2.1"])
+	}
+	if matched["192.0.2.2"].ID != "hc-2" {
+		t.Errorf("expected 192.0.2.2 to match by address, got %+v", matched["192.0.2.2"])
+	}
+}
+
+func TestServiceCheckOrigin_CloudflareHealthFailover(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.168.1.1"}},
+			{Priority: 50, IPs: []string{"192.168.1.2"}},
+		},
+		CloudflareHealth: &config.CloudflareHealthCheckConfig{},
+	}
+
+	service, dnsClientMock := createTestService(origin)
+	source := &fakeHealthStatusSource{statuses: []cloudflare.HealthCheckStatus{
+		{ID: "hc-1", Name: "primary", Address: "192.168.1.1", Status: "unhealthy"},
+	}}
+	service.healthCheckClients = map[string]healthStatusSource{"default": source}
+
+	current := "192.168.1.1"
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "record-1", Name: name, Type: dns.RecordResponseTypeA, Content: current}}, nil
+	}
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		current = newContents[0]
+		return nil
+	}
+
+	checker := hcmock.NewCheckerMock(func(ip string) error { return nil })
+
+	service.checkOrigin(context.Background(), origin, checker)
+	if current != "192.168.1.2" {
+		t.Fatalf("expected failover when Cloudflare reports the primary unhealthy, got %s", current)
+	}
+
+	// A failed status read falls back to the local checks
+	source.err = errors.New("api unavailable")
+	origin.ReturnToPriority = true
+	service.checkOrigin(context.Background(), origin, checker)
+	if current != "192.168.1.1" {
+		t.Errorf("expected local checks to be used when Cloudflare results are unavailable, got %s", current)
+	}
+}
@@ -0,0 +1,146 @@
+package gslb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/consulcatalog"
+	"github.com/cockroachdb/errors"
+)
+
+// consulRetryDelay is how long to wait before querying Consul again after an
+// error.
+const consulRetryDelay = 10 * time.Second
+
+// consulCatalog reads the healthy instances of a Consul service.
+type consulCatalog interface {
+	Healthy(ctx context.Context, q consulcatalog.Query, index uint64) ([]consulcatalog.Instance, uint64, error)
+}
+
+// consulPool holds the latest healthy instances of the service of a consul:
+// target, kept current by a blocking query while the service runs.
+type consulPool struct {
+	query consulcatalog.Query
+	wan   bool
+
+	mu        sync.RWMutex
+	loaded    bool
+	addresses []string
+}
+
+// consulPools are the pools of every consul: target of the origins.
+type consulPools struct {
+	catalog consulCatalog
+	pools   map[string]*consulPool
+}
+
+func buildConsulPools(cfg *config.Config) *consulPools {
+	pools := make(map[string]*consulPool)
+	for _, origin := range cfg.Origins {
+		for _, target := range origin.Targets() {
+			if !config.IsConsulTarget(target) || pools[target] != nil {
+				continue
+			}
+			parsed, err := config.ParseConsulTarget(target)
+			if err != nil {
+				continue
+			}
+			pools[target] = &consulPool{
+				query: consulcatalog.Query{Service: parsed.Service, Tags: parsed.Tags, Datacenter: parsed.Datacenter},
+				wan:   parsed.Address == config.ConsulAddressWAN,
+			}
+		}
+	}
+	if len(pools) == 0 {
+		return nil
+	}
+	return &consulPools{
+		catalog: consulcatalog.New(cfg.Consul.EffectiveAddress(), cfg.Consul.EffectiveToken()),
+		pools:   pools,
+	}
+}
+
+// startConsulPools watches the service of every pool until ctx is done.
+func (s *Service) startConsulPools(ctx context.Context) {
+	if s.consulPools == nil {
+		return
+	}
+	for target, pool := range s.consulPools.pools {
+		s.wg.Add(1)
+		go s.watchConsulPool(ctx, target, pool)
+	}
+}
+
+// watchConsulPool refreshes pool whenever the healthy instances of its
+// service change. The last known instances are kept while Consul cannot be
+// reached.
+func (s *Service) watchConsulPool(ctx context.Context, target string, pool *consulPool) {
+	defer s.wg.Done()
+	defer s.recoverPanic(map[string]string{"task": "consul_pool"})
+
+	var index uint64
+	for {
+		instances, next, err := s.consulPools.catalog.Healthy(ctx, pool.query, index)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			s.logf("Failed to watch %s: %v", target, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(consulRetryDelay):
+			}
+			continue
+		}
+		// The index goes backwards when the Raft state is restored from a
+		// snapshot; Consul then expects the query to start over
+		if next < index {
+			next = 0
+		}
+		index = next
+		pool.set(instances)
+	}
+}
+
+func (p *consulPool) set(instances []consulcatalog.Instance) {
+	addresses := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		if p.wan {
+			addresses = append(addresses, instance.WANAddress)
+		} else {
+			addresses = append(addresses, instance.Address)
+		}
+	}
+	p.mu.Lock()
+	p.loaded, p.addresses = true, addresses
+	p.mu.Unlock()
+}
+
+func (p *consulPool) get() ([]string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.addresses, p.loaded
+}
+
+// resolveConsulTarget returns the addresses of the healthy instances of the
+// service of a consul: target that match the record type of origin. Until
+// the watch has an answer, as in one-shot runs, Consul is queried directly.
+func (s *Service) resolveConsulTarget(ctx context.Context, origin config.OriginConfig, target string, resolved map[string][]string) ([]string, error) {
+	if s.consulPools == nil || s.consulPools.pools[target] == nil {
+		return nil, errors.Newf("%s is not watched", target)
+	}
+	pool := s.consulPools.pools[target]
+	addresses, loaded := pool.get()
+	if !loaded {
+		instances, _, err := s.consulPools.catalog.Healthy(ctx, pool.query, 0)
+		if err != nil {
+			return nil, err
+		}
+		pool.set(instances)
+		addresses, _ = pool.get()
+	}
+	return s.discoveredAddresses(ctx, origin, addresses, resolved), nil
+}
@@ -0,0 +1,94 @@
+package gslb
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/consulcatalog"
+)
+
+// fakeConsulCatalog answers blocking queries from a channel of answers per
+// tag.
+type fakeConsulCatalog struct {
+	mu      sync.Mutex
+	queries []uint64
+	answers map[string]chan []consulcatalog.Instance
+	current []consulcatalog.Instance
+}
+
+func (c *fakeConsulCatalog) Healthy(ctx context.Context, q consulcatalog.Query, index uint64) ([]consulcatalog.Instance, uint64, error) {
+	c.mu.Lock()
+	c.queries = append(c.queries, index)
+	current := c.current
+	c.mu.Unlock()
+	if index == 0 {
+		return current, 1, nil
+	}
+	select {
+	case instances := <-c.answers[q.Tags[0]]:
+		return instances, index + 1, nil
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	}
+}
+
+func TestConsulTargets(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "www.example.com",
+		RecordType: "A",
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"consul:web?tag=primary"}},
+			{Priority: 0, IPs: []string{"consul:web?tag=backup&address=wan"}},
+		},
+	}
+	pools := buildConsulPools(&config.Config{Origins: []config.OriginConfig{origin}})
+	if pools == nil || len(pools.pools) != 2 {
+		t.Fatalf("expected a pool per target, got %+v", pools)
+	}
+	catalog := &fakeConsulCatalog{
+		answers: map[string]chan []consulcatalog.Instance{
+			"primary": make(chan []consulcatalog.Instance),
+			"backup":  make(chan []consulcatalog.Instance),
+		},
+		current: []consulcatalog.Instance{
+			{Address: "10.0.0.1", WANAddress: "198.51.100.1"},
+			{Address: "2001:db8::1", WANAddress: "2001:db8::1"},
+		},
+	}
+	pools.catalog = catalog
+	service := &Service{consulPools: pools}
+
+	// Without the watch, Consul is queried directly
+	resolved := service.resolveOriginHosts(context.Background(), origin)
+	if len(resolved.PriorityLevels) != 2 || !sameStringSet(resolved.PriorityLevels[0].IPs, []string{"10.0.0.1"}) || !sameStringSet(resolved.PriorityLevels[1].IPs, []string{"198.51.100.1"}) {
+		t.Fatalf("unexpected levels %+v", resolved.PriorityLevels)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service.startConsulPools(ctx)
+	catalog.answers["primary"] <- []consulcatalog.Instance{{Address: "10.0.0.2", WANAddress: "198.51.100.2"}}
+	catalog.answers["backup"] <- []consulcatalog.Instance{{Address: "10.0.0.3", WANAddress: "198.51.100.3"}}
+	// Two direct queries, then per pool a first query, the answered one and the
+	// next one, which is sent once the answer is stored
+	deadline := time.Now().Add(time.Second)
+	for {
+		catalog.mu.Lock()
+		blocked := len(catalog.queries) >= 8
+		catalog.mu.Unlock()
+		if blocked || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	resolved = service.resolveOriginHosts(context.Background(), origin)
+	if !sameStringSet(resolved.PriorityLevels[0].IPs, []string{"10.0.0.2"}) || !sameStringSet(resolved.PriorityLevels[1].IPs, []string{"198.51.100.3"}) {
+		t.Errorf("expected the instances of the blocking query, got %+v", resolved.PriorityLevels)
+	}
+	cancel()
+	service.wg.Wait()
+}
@@ -0,0 +1,92 @@
+package gslb
+
+import (
+	"context"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/healthcheck"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+type probeRecorderKey struct{}
+
+// probeRecorder collects the health checks and candidate priority levels of
+// one origin check, so that the notifications it sends can tell responders
+// why the IPs changed. It is used by a single check at a time; the methods
+// do nothing on a nil recorder.
+type probeRecorder struct {
+	checks     []notifier.CheckDiagnostic
+	candidates []notifier.FailoverCandidate
+	selected   int
+}
+
+// withProbeRecorder returns a context carrying a new recorder.
+func withProbeRecorder(ctx context.Context) (context.Context, *probeRecorder) {
+	recorder := &probeRecorder{selected: -1}
+	return context.WithValue(ctx, probeRecorderKey{}, recorder), recorder
+}
+
+// probeRecorderFrom returns the recorder of ctx, or nil outside a check.
+func probeRecorderFrom(ctx context.Context) *probeRecorder {
+	recorder, _ := ctx.Value(probeRecorderKey{}).(*probeRecorder)
+	return recorder
+}
+
+// record adds the health check of ip. err is why it failed, if it did.
+func (r *probeRecorder) record(origin config.OriginConfig, ip string, priority int, healthy bool, latency time.Duration, err error) {
+	if r == nil {
+		return
+	}
+	diagnostics := healthcheck.Diagnose(err)
+	check := notifier.CheckDiagnostic{
+		IP:         ip,
+		Priority:   priority,
+		Healthy:    healthy,
+		Latency:    latency,
+		CheckType:  origin.HealthCheck.Type,
+		StatusCode: diagnostics.StatusCode,
+		TLSError:   diagnostics.TLSError,
+	}
+	if diagnostics.NoReply {
+		check.PacketLoss = 1
+	}
+	if err != nil {
+		check.Error = err.Error()
+	}
+	r.checks = append(r.checks, check)
+}
+
+// setCandidates records the priority levels of the check, most preferred first.
+func (r *probeRecorder) setCandidates(levels []config.PriorityLevel) {
+	if r == nil {
+		return
+	}
+	r.candidates = make([]notifier.FailoverCandidate, 0, len(levels))
+	for _, level := range levels {
+		r.candidates = append(r.candidates, notifier.FailoverCandidate{Priority: level.Priority, IPs: level.IPs})
+	}
+}
+
+// selectPriority records the priority level the check selected.
+func (r *probeRecorder) selectPriority(priority int) {
+	if r == nil {
+		return
+	}
+	for i, candidate := range r.candidates {
+		if candidate.Priority == priority {
+			r.selected = i
+			return
+		}
+	}
+}
+
+// annotate adds the recorded checks and candidates to event.
+func (r *probeRecorder) annotate(event *notifier.FailoverEvent) {
+	if r == nil {
+		return
+	}
+	event.Checks = append([]notifier.CheckDiagnostic(nil), r.checks...)
+	event.Candidates = r.candidates
+	event.FailoverIndex = r.selected
+}
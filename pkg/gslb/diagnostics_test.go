@@ -0,0 +1,74 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/healthcheck"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestServiceCheckOrigin_EventDiagnostics(t *testing.T) {
+	origin := statusTestOrigin()
+	origin.HealthCheck.Type = "https"
+	service, dnsClientMock := createTestService(origin)
+	recorder := &recordingNotifier{}
+	service.notifiers = []notifier.Notifier{recorder}
+
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "1", Content: "192.0.2.1"}}, nil
+	}
+	checker := hcmock.NewCheckerMock(func(ip string) error {
+		if ip == "192.0.2.1" {
+			return fmt.Errorf("check failed: %w", &healthcheck.StatusCodeError{StatusCode: 503})
+		}
+		return nil
+	})
+
+	service.checkOrigin(context.Background(), origin, checker)
+	waitForNotifications(t, service)
+	events := recorder.take()
+	if len(events) != 1 {
+		t.Fatalf("Expected one failover, got %+v", events)
+	}
+	event := events[0]
+	if len(event.Checks) != 2 {
+		t.Fatalf("Checks = %+v", event.Checks)
+	}
+	failed := event.Checks[0]
+	if failed.IP != "192.0.2.1" || failed.Priority != 100 || failed.Healthy || failed.StatusCode != 503 || failed.CheckType != "https" || failed.Error == "" {
+		t.Errorf("Unexpected failed check %+v", failed)
+	}
+	if healthy := event.Checks[1]; healthy.IP != "198.51.100.1" || !healthy.Healthy || healthy.Error != "" {
+		t.Errorf("Unexpected healthy check %+v", healthy)
+	}
+	if len(event.Candidates) != 2 || event.Candidates[0].Priority != 100 || event.Candidates[1].Priority != 50 || event.FailoverIndex != 1 {
+		t.Errorf("Candidates = %+v, FailoverIndex = %d", event.Candidates, event.FailoverIndex)
+	}
+}
+
+func TestServiceCheckOrigin_AllIPsDownDiagnostics(t *testing.T) {
+	origin := statusTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+	recorder := &recordingNotifier{}
+	service.notifiers = []notifier.Notifier{recorder}
+
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "1", Content: "192.0.2.1"}}, nil
+	}
+	checker := hcmock.NewCheckerMock(func(ip string) error { return errors.New("connection refused") })
+
+	service.checkOrigin(context.Background(), origin, checker)
+	waitForNotifications(t, service)
+	events := recorder.take()
+	if len(events) != 1 || events[0].Type != notifier.EventTypeAllIPsDown {
+		t.Fatalf("Expected an all IPs down alert, got %+v", events)
+	}
+	if len(events[0].Checks) != 2 || events[0].Checks[1].Error != "connection refused" || events[0].FailoverIndex != -1 {
+		t.Errorf("Checks = %+v, FailoverIndex = %d", events[0].Checks, events[0].FailoverIndex)
+	}
+}
@@ -0,0 +1,112 @@
+package gslb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/cockroachdb/errors"
+)
+
+// Actions of a RecordDiff
+const (
+	DiffUnchanged = "unchanged" // the live records are the desired ones
+	DiffCreate    = "create"    // the record does not exist
+	DiffUpdate    = "update"    // the record exists with other IPs
+)
+
+// RecordDiff compares the live records of an origin with the IPs of its
+// highest priority level.
+type RecordDiff struct {
+	Zone       string   `json:"zone"`
+	Name       string   `json:"name"`
+	RecordType string   `json:"record_type"`
+	Action     string   `json:"action"`
+	Live       []string `json:"live"`
+	Desired    []string `json:"desired"`
+	// LivePriority is the priority level the live IPs belong to, or 0 if they
+	// match none.
+	LivePriority int `json:"live_priority,omitempty"`
+	// Unmanaged are the live IPs that are in no priority level of the origin.
+	Unmanaged []string `json:"unmanaged,omitempty"`
+	// ProxiedMismatch is set when a live record is proxied differently than
+	// the origin asks for, which the service does not correct.
+	ProxiedMismatch bool `json:"proxied_mismatch,omitempty"`
+	// ObserveOnly is set for origins in observe mode, whose records the
+	// service never changes.
+	ObserveOnly bool `json:"observe_only,omitempty"`
+}
+
+// Changed reports whether the live records differ from the desired ones.
+func (d RecordDiff) Changed() bool {
+	return d.Action != DiffUnchanged || d.ProxiedMismatch
+}
+
+// Diff compares the live records of every origin with the IPs of its highest
+// priority level, in the active IP set, without changing anything. Health
+// checks are not run, so an origin that failed over shows up as an update.
+func (s *Service) Diff(ctx context.Context) ([]RecordDiff, error) {
+	diffs := make([]RecordDiff, 0, len(s.config.Origins))
+	for _, origin := range s.config.Origins {
+		diff, err := s.diffOrigin(ctx, origin)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+func (s *Service) diffOrigin(ctx context.Context, origin config.OriginConfig) (RecordDiff, error) {
+	records, err := s.getDNSClientForOrigin(origin).GetDNSRecords(ctx, origin.Name, origin.RecordType)
+	if err != nil {
+		return RecordDiff{}, errors.Wrapf(err, "failed to get DNS records for %s", origin.Name)
+	}
+	live := collectRecordIPs(records)
+	sort.Strings(live)
+
+	_, levels := s.activePriorityLevels(s.resolveOriginHosts(ctx, origin), originKeyFor(origin), live)
+	levels = sortPriorityLevels(levels)
+	var desired []string
+	if len(levels) > 0 {
+		desired = append(desired, levels[0].IPs...)
+	}
+	sort.Strings(desired)
+
+	diff := RecordDiff{
+		Zone:        origin.ZoneName,
+		Name:        origin.Name,
+		RecordType:  origin.RecordType,
+		Action:      DiffUnchanged,
+		Live:        live,
+		Desired:     desired,
+		ObserveOnly: origin.IsObserveOnly(),
+	}
+	switch {
+	case len(live) == 0:
+		diff.Action = DiffCreate
+	case !sameIPSet(live, desired):
+		diff.Action = DiffUpdate
+	}
+	if priority, ok := detectCurrentPriority(levels, live); ok {
+		diff.LivePriority = priority
+	}
+
+	managed := make(map[string]bool)
+	for _, level := range levels {
+		for _, ip := range level.IPs {
+			managed[ip] = true
+		}
+	}
+	for _, ip := range live {
+		if !managed[ip] {
+			diff.Unmanaged = append(diff.Unmanaged, ip)
+		}
+	}
+	for _, record := range records {
+		if record.Content != "" && record.Proxied != origin.Proxied {
+			diff.ProxiedMismatch = true
+		}
+	}
+	return diff, nil
+}
@@ -0,0 +1,77 @@
+package gslb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestService_Diff(t *testing.T) {
+	origin := snapshotTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+
+	var live []string
+	proxied := false
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		records := make([]dns.RecordResponse, 0, len(live))
+		for _, ip := range live {
+			records = append(records, dns.RecordResponse{Name: name, Type: dns.RecordResponseTypeA, Content: ip, Proxied: proxied})
+		}
+		return records, nil
+	}
+
+	tests := []struct {
+		name    string
+		live    []string
+		proxied bool
+		want    RecordDiff
+	}{
+		{
+			name: "missing record",
+			want: RecordDiff{Action: DiffCreate, Desired: []string{"192.168.1.1"}},
+		},
+		{
+			name: "desired record",
+			live: []string{"192.168.1.1"},
+			want: RecordDiff{Action: DiffUnchanged, Live: []string{"192.168.1.1"}, Desired: []string{"192.168.1.1"}, LivePriority: 100},
+		},
+		{
+			name: "failed over record",
+			live: []string{"192.168.1.2"},
+			want: RecordDiff{Action: DiffUpdate, Live: []string{"192.168.1.2"}, Desired: []string{"192.168.1.1"}, LivePriority: 50},
+		},
+		{
+			name: "unmanaged IP",
+			live: []string{"192.168.1.9"},
+			want: RecordDiff{Action: DiffUpdate, Live: []string{"192.168.1.9"}, Desired: []string{"192.168.1.1"}, Unmanaged: []string{"192.168.1.9"}},
+		},
+		{
+			name:    "proxied record",
+			live:    []string{"192.168.1.1"},
+			proxied: true,
+			want:    RecordDiff{Action: DiffUnchanged, Live: []string{"192.168.1.1"}, Desired: []string{"192.168.1.1"}, LivePriority: 100, ProxiedMismatch: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			live, proxied = tt.live, tt.proxied
+			diffs, err := service.Diff(context.Background())
+			if err != nil {
+				t.Fatalf("Diff() error = %v", err)
+			}
+			want := tt.want
+			want.Zone, want.Name, want.RecordType = origin.ZoneName, origin.Name, origin.RecordType
+			if want.Live == nil {
+				want.Live = []string{}
+			}
+			if len(diffs) != 1 || !reflect.DeepEqual(diffs[0], want) {
+				t.Errorf("Diff() = %+v, want %+v", diffs, want)
+			}
+			if diffs[0].Changed() != (want.Action != DiffUnchanged || want.ProxiedMismatch) {
+				t.Errorf("Changed() = %v for %+v", diffs[0].Changed(), diffs[0])
+			}
+		})
+	}
+}
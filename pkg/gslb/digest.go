@@ -0,0 +1,98 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+// digestBuffer collects the events of each notifier with a digest until its
+// window is over. The zero value is ready to use.
+type digestBuffer struct {
+	mu     sync.Mutex
+	events map[notifier.Notifier][]notifier.FailoverEvent
+}
+
+// add buffers event for n and reports whether it starts a new digest.
+func (b *digestBuffer) add(n notifier.Notifier, event notifier.FailoverEvent) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.events == nil {
+		b.events = make(map[notifier.Notifier][]notifier.FailoverEvent)
+	}
+	b.events[n] = append(b.events[n], event)
+	return len(b.events[n]) == 1
+}
+
+// take returns the events buffered for n and forgets them.
+func (b *digestBuffer) take(n notifier.Notifier) []notifier.FailoverEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	events := b.events[n]
+	delete(b.events, n)
+	return events
+}
+
+func (b *digestBuffer) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	buffered := 0
+	for _, events := range b.events {
+		buffered += len(events)
+	}
+	return buffered
+}
+
+// batchForDigest reports whether event is buffered for the digest of n
+// instead of being sent now. The first event of a digest starts its window.
+func (s *Service) batchForDigest(n notifier.Notifier, event notifier.FailoverEvent) bool {
+	digest := s.options[n].digest
+	if !digest.Enabled() {
+		return false
+	}
+	if s.digests.add(n, event) {
+		time.AfterFunc(digest.Window(), func() { s.flushDigest(n) })
+	}
+	return true
+}
+
+// flushDigest sends the events buffered for n: a single event as it is, and
+// several as one digest.
+func (s *Service) flushDigest(n notifier.Notifier) {
+	events := s.digests.take(n)
+	if len(events) == 0 {
+		return
+	}
+	event := events[0]
+	if len(events) > 1 {
+		event = digestEvent(events)
+		s.logf("Sending %d notifications as one digest", len(events))
+	}
+	s.pendingNotifications.Add(1)
+	defer s.pendingNotifications.Add(-1)
+	s.notify(context.Background(), n, event)
+}
+
+// flushDigests sends every buffered digest right away, so that stopping the
+// service does not lose them.
+func (s *Service) flushDigests() {
+	for _, n := range s.notifiers {
+		s.flushDigest(n)
+	}
+}
+
+// digestEvent combines events into one digest event.
+func digestEvent(events []notifier.FailoverEvent) notifier.FailoverEvent {
+	first, last := events[0].Timestamp, events[len(events)-1].Timestamp
+	event := notifier.FailoverEvent{
+		Type:      notifier.EventTypeDigest,
+		Reason:    fmt.Sprintf("%d events within %s", len(events), last.Sub(first).Round(time.Second)),
+		Timestamp: last,
+		Events:    events,
+	}
+	event.Severity = notifier.SeverityFor(event)
+	return event
+}
@@ -0,0 +1,76 @@
+package gslb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+func TestServiceDispatchEvent_Digest(t *testing.T) {
+	digested := &recordingNotifier{}
+	direct := &recordingNotifier{}
+	service := &Service{
+		config:    &config.Config{},
+		notifiers: []notifier.Notifier{digested, direct},
+		options: map[notifier.Notifier]notifierOptions{
+			digested: {digest: &config.DigestConfig{WindowSeconds: config.Seconds(time.Hour)}},
+		},
+	}
+
+	for _, name := range []string{"www", "api", "www"} {
+		origin := config.OriginConfig{Name: name, ZoneName: "example.com", RecordType: "A"}
+		service.sendAlert(context.Background(), notifier.EventTypeAllIPsDown, origin, []string{"192.0.2.1"}, []string{"192.0.2.1"}, "down")
+	}
+	waitForNotifications(t, service)
+	if events := direct.take(); len(events) != 3 {
+		t.Errorf("Expected the notifier without a digest to get every event, got %+v", events)
+	}
+	if events := digested.take(); len(events) != 0 {
+		t.Fatalf("Expected the events to wait for the digest window, got %+v", events)
+	}
+	if vars := service.Vars(); vars.DigestNotifications != 3 {
+		t.Errorf("DigestNotifications = %d", vars.DigestNotifications)
+	}
+
+	service.flushDigest(digested)
+	events := digested.take()
+	if len(events) != 1 || events[0].Type != notifier.EventTypeDigest || len(events[0].Events) != 3 || events[0].Severity != notifier.SeverityCritical {
+		t.Fatalf("Expected one critical digest of three events, got %+v", events)
+	}
+	if events[0].Events[1].OriginName != "api" {
+		t.Errorf("Expected the events in the order they happened, got %+v", events[0].Events)
+	}
+
+	// A single event is sent as it is
+	service.sendAlert(context.Background(), notifier.EventTypeAllIPsDown, config.OriginConfig{Name: "www", ZoneName: "example.com", RecordType: "A"}, nil, nil, "down")
+	service.flushDigests()
+	if events := digested.take(); len(events) != 1 || events[0].Type != notifier.EventTypeAllIPsDown {
+		t.Errorf("Expected the single event itself, got %+v", events)
+	}
+}
+
+func TestServiceDispatchEvent_DigestWindow(t *testing.T) {
+	digested := &recordingNotifier{}
+	service := &Service{
+		config:    &config.Config{},
+		notifiers: []notifier.Notifier{digested},
+		options: map[notifier.Notifier]notifierOptions{
+			digested: {digest: &config.DigestConfig{WindowSeconds: config.Seconds(20 * time.Millisecond)}},
+		},
+	}
+	origin := config.OriginConfig{Name: "www", ZoneName: "example.com", RecordType: "A"}
+	service.sendAlert(context.Background(), notifier.EventTypeAllIPsDown, origin, nil, nil, "down")
+	service.sendAlert(context.Background(), notifier.EventTypeAPIFailure, origin, nil, nil, "api down")
+
+	deadline := time.Now().Add(time.Second)
+	for service.Vars().DigestNotifications > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	waitForNotifications(t, service)
+	if events := digested.take(); len(events) != 1 || len(events[0].Events) != 2 {
+		t.Errorf("Expected one digest once the window was over, got %+v", events)
+	}
+}
@@ -0,0 +1,22 @@
+package gslb
+
+import (
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/sentry"
+)
+
+// originTags returns the tags identifying origin in error reports.
+func originTags(origin config.OriginConfig) map[string]string {
+	return map[string]string{
+		"origin":      origin.Name,
+		"zone":        origin.ZoneName,
+		"record_type": origin.RecordType,
+	}
+}
+
+// reportError reports a failed operation on origin. Repeated failures of the
+// same operation on the same origin are grouped and rate limited by the
+// reporter, so this may be called on every check cycle.
+func reportError(operation string, origin config.OriginConfig, err error) {
+	sentry.CaptureError(operation+":"+originKeyFor(origin), err, originTags(origin))
+}
@@ -0,0 +1,50 @@
+package gslb
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/sentry"
+)
+
+func TestMonitorOrigin_ReportsCheckerFailure(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+	}))
+	defer server.Close()
+
+	shutdown, err := sentry.Setup(sentry.Config{DSN: "http://key@" + strings.TrimPrefix(server.URL, "http://") + "/1"})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	defer shutdown(context.Background())
+
+	origin := statusTestOrigin()
+	origin.HealthCheck = config.HealthCheck{Type: "smtp"}
+	service, _ := createTestService(origin)
+
+	service.wg.Add(1)
+	service.monitorOrigin(context.Background(), origin)
+
+	status := service.OriginStatuses()[originKeyFor(origin)]
+	if status.LastResult != CheckResultCheckerFailed || status.LastError == "" {
+		t.Errorf("Expected the checker failure in the status, got %q (%q)", status.LastResult, status.LastError)
+	}
+
+	select {
+	case body := <-received:
+		if !strings.Contains(body, `"origin":"`+origin.Name+`"`) || !strings.Contains(body, `"fingerprint":["health_checker:`) {
+			t.Errorf("Unexpected report %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the checker failure to be reported")
+	}
+}
@@ -0,0 +1,82 @@
+package gslb
+
+import (
+	"sync"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+// EventFeed passes the events of the services it is attached to on to its
+// subscribers, such as the streams of the gRPC API. It outlives the services,
+// so that subscriptions survive configuration reloads.
+type EventFeed struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives the events of a feed until it is closed.
+type Subscription struct {
+	feed       *EventFeed
+	events     chan notifier.FailoverEvent
+	overflowed bool
+}
+
+// NewEventFeed returns a feed without subscribers.
+func NewEventFeed() *EventFeed {
+	return &EventFeed{subscribers: make(map[*Subscription]struct{})}
+}
+
+// Subscribe returns a subscription that buffers up to buffer events. A
+// subscriber that falls further behind is dropped, see Overflowed.
+func (f *EventFeed) Subscribe(buffer int) *Subscription {
+	sub := &Subscription{feed: f, events: make(chan notifier.FailoverEvent, buffer)}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Publish passes event on to every subscriber without waiting for them.
+func (f *EventFeed) Publish(event notifier.FailoverEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for sub := range f.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			sub.overflowed = true
+			delete(f.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Events returns the channel of the events. It is closed when the
+// subscription is closed or overflows.
+func (s *Subscription) Events() <-chan notifier.FailoverEvent {
+	return s.events
+}
+
+// Overflowed reports whether the subscription was dropped because its buffer
+// was full, i.e. whether events were missed.
+func (s *Subscription) Overflowed() bool {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	return s.overflowed
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	if _, ok := s.feed.subscribers[s]; ok {
+		delete(s.feed.subscribers, s)
+		close(s.events)
+	}
+}
+
+// SetEventFeed publishes the events of the service to feed. Call it before
+// Start.
+func (s *Service) SetEventFeed(feed *EventFeed) {
+	s.eventFeed = feed
+}
@@ -0,0 +1,37 @@
+package gslb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+func TestServiceEventFeed(t *testing.T) {
+	service, _ := createTestService(statusTestOrigin())
+	service.notifiers = nil
+	feed := NewEventFeed()
+	service.SetEventFeed(feed)
+
+	sub := feed.Subscribe(1)
+	slow := feed.Subscribe(0)
+	<-service.dispatchEvent(context.Background(), notifier.FailoverEvent{Type: notifier.EventTypeFailover, OriginName: "example.com"})
+
+	event, ok := <-sub.Events()
+	if !ok || event.OriginName != "example.com" || event.Severity != notifier.SeverityWarning {
+		t.Errorf("expected the failover with its severity, got %+v (%v)", event, ok)
+	}
+	if _, ok := <-slow.Events(); ok || !slow.Overflowed() {
+		t.Error("expected the subscriber without room to be dropped")
+	}
+	if sub.Overflowed() {
+		t.Error("expected the subscriber with room to stay subscribed")
+	}
+
+	sub.Close()
+	sub.Close()
+	if _, ok := <-sub.Events(); ok {
+		t.Error("expected no events after Close")
+	}
+	<-service.dispatchEvent(context.Background(), notifier.FailoverEvent{Type: notifier.EventTypeFailover, OriginName: "example.com"})
+}
@@ -0,0 +1,99 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+// flappingTracker counts the transitions of each origin to tell when it is
+// flapping. The zero value is ready to use.
+type flappingTracker struct {
+	mu      sync.Mutex
+	origins map[string]*flappingState
+}
+
+type flappingState struct {
+	transitions []time.Time
+	flapping    bool
+	// ips are the IPs published by the latest transition
+	ips []string
+}
+
+// record counts a transition of originKey to ips. It returns the transitions
+// within the window, whether the origin started flapping with this one, and
+// whether it is flapping.
+func (t *flappingTracker) record(originKey string, limit *config.FlappingConfig, ips []string, now time.Time) (count int, started, flapping bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.origins == nil {
+		t.origins = make(map[string]*flappingState)
+	}
+	state := t.origins[originKey]
+	if state == nil {
+		state = &flappingState{}
+		t.origins[originKey] = state
+	}
+	state.transitions = append(pruneBefore(state.transitions, now.Add(-limit.Window())), now)
+	state.ips = ips
+	started = !state.flapping && len(state.transitions) > limit.Transitions
+	state.flapping = state.flapping || started
+	return len(state.transitions), started, state.flapping
+}
+
+// settle reports whether originKey was flapping and has had no transition
+// within the window, and marks it stable. ips are its published IPs.
+func (t *flappingTracker) settle(originKey string, limit *config.FlappingConfig, now time.Time) (ips []string, settled bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state := t.origins[originKey]
+	if state == nil || !state.flapping {
+		return nil, false
+	}
+	if last := state.transitions[len(state.transitions)-1]; now.Sub(last) < limit.Window() {
+		return nil, false
+	}
+	delete(t.origins, originKey)
+	return state.ips, true
+}
+
+// trackFlapping counts a transition of origin from oldIPs to newIPs and
+// alerts once when the origin starts flapping. It reports whether the
+// notification of the transition itself is suppressed, which it is while the
+// origin is flapping.
+func (s *Service) trackFlapping(ctx context.Context, origin config.OriginConfig, originKey string, oldIPs, newIPs []string) bool {
+	limit := s.config.FlappingFor(origin)
+	if !limit.Enabled() {
+		return false
+	}
+	count, started, flapping := s.flapping.record(originKey, limit, newIPs, s.now())
+	if started {
+		reason := fmt.Sprintf("%d transitions within %s (more than %d); changes are not notified until there is none for %s",
+			count, limit.Window(), limit.Transitions, limit.Window())
+		s.logf("Origin %s is flapping: %s", origin.Name, reason)
+		s.sendAlert(ctx, notifier.EventTypeFlapping, origin, oldIPs, newIPs, reason)
+	} else if flapping {
+		s.logf("Not notifying the change of flapping origin %s from %v to %v", origin.Name, oldIPs, newIPs)
+	}
+	return flapping
+}
+
+// settleFlapping notifies once a flapping origin has been stable for the
+// flapping window.
+func (s *Service) settleFlapping(ctx context.Context, origin config.OriginConfig, originKey string) {
+	limit := s.config.FlappingFor(origin)
+	if !limit.Enabled() {
+		return
+	}
+	ips, settled := s.flapping.settle(originKey, limit, s.now())
+	if !settled {
+		return
+	}
+	s.logf("Origin %s stopped flapping", origin.Name)
+	s.sendAlert(ctx, notifier.EventTypeFlappingStopped, origin, nil, ips,
+		fmt.Sprintf("No transitions within %s, serving %v", limit.Window(), ips))
+}
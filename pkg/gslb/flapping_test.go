@@ -0,0 +1,115 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestFlappingTracker(t *testing.T) {
+	var tracker flappingTracker
+	limit := &config.FlappingConfig{Transitions: 2}
+	start := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	ips := []string{"192.0.2.1"}
+
+	for i := range 2 {
+		if _, started, flapping := tracker.record("origin", limit, ips, start.Add(time.Duration(i)*time.Minute)); started || flapping {
+			t.Fatalf("Expected transition %d to be within the limit", i+1)
+		}
+	}
+	if count, started, flapping := tracker.record("origin", limit, ips, start.Add(2*time.Minute)); count != 3 || !started || !flapping {
+		t.Fatalf("record() = %d, %v, %v, want 3 transitions starting to flap", count, started, flapping)
+	}
+	if _, started, flapping := tracker.record("origin", limit, ips, start.Add(3*time.Minute)); started || !flapping {
+		t.Errorf("Expected the origin to keep flapping without starting again")
+	}
+	if _, _, flapping := tracker.record("other", limit, ips, start); flapping {
+		t.Error("Expected origins to be tracked separately")
+	}
+
+	if _, settled := tracker.settle("origin", limit, start.Add(12*time.Minute)); settled {
+		t.Error("Expected no settling within the window of the last transition")
+	}
+	settledIPs, settled := tracker.settle("origin", limit, start.Add(13*time.Minute))
+	if !settled || len(settledIPs) != 1 || settledIPs[0] != "192.0.2.1" {
+		t.Fatalf("settle() = %v, %v, want the published IPs", settledIPs, settled)
+	}
+	if _, settled := tracker.settle("origin", limit, start.Add(14*time.Minute)); settled {
+		t.Error("Expected the origin to settle once")
+	}
+
+	// Transitions before settling no longer count
+	if count, _, flapping := tracker.record("origin", limit, ips, start.Add(14*time.Minute)); count != 1 || flapping {
+		t.Errorf("record() = %d, %v after settling, want 1 transition", count, flapping)
+	}
+}
+
+func TestServiceCheckOrigin_FlappingAlertsOnce(t *testing.T) {
+	origin := statusTestOrigin()
+	origin.Flapping = &config.FlappingConfig{Transitions: 2}
+	service, dnsClientMock := createTestService(origin)
+	recorder := &recordingNotifier{}
+	service.notifiers = []notifier.Notifier{recorder}
+
+	records := []string{"192.0.2.1"}
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		var responses []dns.RecordResponse
+		for _, ip := range records {
+			responses = append(responses, dns.RecordResponse{ID: ip, Content: ip})
+		}
+		return responses, nil
+	}
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		records = newContents
+		return nil
+	}
+	down := hcmock.NewCheckerMock(func(ip string) error {
+		if ip == "192.0.2.1" {
+			return errors.New("down")
+		}
+		return nil
+	})
+	up := hcmock.NewCheckerMock(func(ip string) error { return nil })
+
+	check := func(checker *hcmock.CheckerMock) []notifier.FailoverEvent {
+		service.checkOrigin(context.Background(), origin, checker)
+		waitForNotifications(t, service)
+		return recorder.take()
+	}
+
+	if events := check(down); len(events) != 1 || events[0].Type != notifier.EventTypeFailover {
+		t.Fatalf("Expected the first failover to be notified, got %+v", events)
+	}
+	if events := check(up); len(events) != 1 || events[0].Type != notifier.EventTypeFailover {
+		t.Fatalf("Expected the recovery to be notified, got %+v", events)
+	}
+	events := check(down)
+	if len(events) != 1 || events[0].Type != notifier.EventTypeFlapping || events[0].Severity != notifier.SeverityWarning {
+		t.Fatalf("Expected one flapping alert instead of the third failover, got %+v", events)
+	}
+	if events := check(up); len(events) != 0 {
+		t.Errorf("Expected changes of a flapping origin not to be notified, got %+v", events)
+	}
+	if events := check(up); len(events) != 0 {
+		t.Errorf("Expected no notification within the window, got %+v", events)
+	}
+
+	// Move the transitions out of the window
+	state := service.flapping.origins[originKeyFor(origin)]
+	for i := range state.transitions {
+		state.transitions[i] = state.transitions[i].Add(-config.DefaultFlappingWindow)
+	}
+	events = check(up)
+	if len(events) != 1 || events[0].Type != notifier.EventTypeFlappingStopped || events[0].Severity != notifier.SeverityInfo {
+		t.Fatalf("Expected the origin to stop flapping, got %+v", events)
+	}
+	if len(events[0].NewIPs) != 1 || events[0].NewIPs[0] != "192.0.2.1" {
+		t.Errorf("Expected the published IPs in the event, got %v", events[0].NewIPs)
+	}
+}
@@ -0,0 +1,136 @@
+package gslb
+
+import (
+	"context"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/cockroachdb/errors"
+)
+
+var (
+	// ErrNoFailoverTarget is returned when forcing a failover of an origin
+	// that already publishes its lowest priority level
+	ErrNoFailoverTarget = errors.New("no lower priority level to fail over to")
+	// ErrForceNotApplied is returned when a forced change could not be
+	// published, e.g. because the allowlist refused the IPs
+	ErrForceNotApplied = errors.New("forced change was not applied")
+)
+
+// forcedPriorityKey marks a context whose check publishes the given priority
+// level regardless of the health checks.
+type forcedPriorityKey struct{}
+
+// withForcedPriority makes the check run with ctx publish the priority level
+// priority.
+func withForcedPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, forcedPriorityKey{}, priority)
+}
+
+// forcedTarget returns the IPs of the forced priority level of ctx, if any.
+func forcedTarget(ctx context.Context, levels []config.PriorityLevel) (int, []string, bool) {
+	priority, ok := ctx.Value(forcedPriorityKey{}).(int)
+	if !ok {
+		return 0, nil, false
+	}
+	level, ok := findPriorityLevel(levels, priority)
+	if !ok || len(level.IPs) == 0 {
+		return 0, nil, false
+	}
+	return priority, level.IPs, true
+}
+
+// CheckOrigin checks an origin right away instead of waiting for its next
+// check cycle. Holds still apply.
+func (s *Service) CheckOrigin(ctx context.Context, zone, name, recordType string) error {
+	origin, err := s.findOrigin(zone, name, recordType)
+	if err != nil {
+		return err
+	}
+	s.logf("Checking %s on request", originLabel(origin))
+	return s.runOriginCheck(ctx, origin)
+}
+
+// ForceFailover publishes the priority level below the current one of an
+// origin on behalf of by, regardless of the health checks, and holds the
+// origin so that the next check does not fail back. ForceFailback undoes it.
+func (s *Service) ForceFailover(ctx context.Context, zone, name, recordType, by string) error {
+	origin, err := s.findOrigin(zone, name, recordType)
+	if err != nil {
+		return err
+	}
+	originKey := originKeyFor(origin)
+	_, levels := s.activePriorityLevels(origin, originKey, s.publishedIPs(originKey))
+	levels = sortPriorityLevels(levels)
+	if len(levels) == 0 {
+		return errors.Wrapf(ErrNoFailoverTarget, "%s has no priority levels", originLabel(origin))
+	}
+	current := levels[0].Priority
+	if status := s.OriginStatuses()[originKey]; status.Initialized {
+		current = status.CurrentPriority
+	}
+	target := -1
+	for _, level := range levels {
+		if level.Priority < current {
+			target = level.Priority
+			break
+		}
+	}
+	if target < 0 {
+		return errors.Wrapf(ErrNoFailoverTarget, "%s publishes priority level %d", originLabel(origin), current)
+	}
+
+	if err := s.forcePriority(ctx, origin, target, "failover forced by "+by); err != nil {
+		return err
+	}
+	if err := s.HoldOrigin(ctx, zone, name, recordType, by); err != nil && !errors.Is(err, ErrAlreadyHeld) {
+		return err
+	}
+	return nil
+}
+
+// ForceFailback publishes the highest priority level of an origin on behalf
+// of by, regardless of the health checks, and releases the origin if it is
+// held. Later checks fail over again if the level is unhealthy.
+func (s *Service) ForceFailback(ctx context.Context, zone, name, recordType, by string) error {
+	origin, err := s.findOrigin(zone, name, recordType)
+	if err != nil {
+		return err
+	}
+	originKey := originKeyFor(origin)
+	_, levels := s.activePriorityLevels(origin, originKey, s.publishedIPs(originKey))
+	levels = sortPriorityLevels(levels)
+	if len(levels) == 0 {
+		return errors.Wrapf(ErrForceNotApplied, "%s has no priority levels", originLabel(origin))
+	}
+	target := levels[0].Priority
+
+	if err := s.forcePriority(ctx, origin, target, "failback forced by "+by); err != nil {
+		return err
+	}
+	if err := s.ReleaseOrigin(ctx, zone, name, recordType, by); err != nil && !errors.Is(err, ErrNotHeld) {
+		return err
+	}
+	return nil
+}
+
+// forcePriority runs a check of origin that publishes the priority level
+// target, and fails unless the level is published afterwards.
+func (s *Service) forcePriority(ctx context.Context, origin config.OriginConfig, target int, action string) error {
+	s.logf("Publishing priority level %d of %s: %s", target, originLabel(origin), action)
+	ctx = withForcedPriority(withManualChange(ctx, action), target)
+	if err := s.runOriginCheck(ctx, origin); err != nil {
+		return err
+	}
+	status := s.OriginStatuses()[originKeyFor(origin)]
+	switch status.LastResult {
+	case CheckResultChanged, CheckResultUnchanged, CheckResultObserved:
+		if status.CurrentPriority == target {
+			return nil
+		}
+	}
+	detail := status.LastResult
+	if status.LastError != "" {
+		detail += ": " + status.LastError
+	}
+	return errors.Wrapf(ErrForceNotApplied, "%s: %s", originLabel(origin), detail)
+}
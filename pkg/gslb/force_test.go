@@ -0,0 +1,90 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestServiceForceFailoverAndFailback(t *testing.T) {
+	origin := statusTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+	recorder := &recordingNotifier{}
+	service.notifiers = []notifier.Notifier{recorder}
+
+	published := []string{"192.0.2.1"}
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		records := make([]dns.RecordResponse, 0, len(published))
+		for _, ip := range published {
+			records = append(records, dns.RecordResponse{ID: ip, Content: ip})
+		}
+		return records, nil
+	}
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		published = append([]string(nil), newContents...)
+		return nil
+	}
+
+	ctx := context.Background()
+	if err := service.ForceFailover(ctx, "default", "example.com", "A", "alice"); err != nil {
+		t.Fatalf("ForceFailover failed: %v", err)
+	}
+	if !sameStringSet(published, []string{"198.51.100.1"}) {
+		t.Fatalf("expected the failover IPs to be published, got %v", published)
+	}
+	report := service.OriginReports()[0]
+	if report.CurrentPriority != 50 || report.Hold == nil || report.Hold.By != "alice" {
+		t.Errorf("expected priority 50 held by alice, got %+v", report)
+	}
+	if err := service.ForceFailover(ctx, "default", "example.com", "A", "alice"); !errors.Is(err, ErrNoFailoverTarget) {
+		t.Errorf("expected ErrNoFailoverTarget at the lowest level, got %v", err)
+	}
+	waitForNotifications(t, service)
+	events := recorder.take()
+	isType := func(eventType notifier.EventType) func(notifier.FailoverEvent) bool {
+		return func(event notifier.FailoverEvent) bool { return event.Type == eventType }
+	}
+	if len(events) != 2 || !slices.ContainsFunc(events, isType(notifier.EventTypeFailover)) || !slices.ContainsFunc(events, isType(notifier.EventTypeOriginHeld)) {
+		t.Errorf("expected a failover and a hold event, got %+v", events)
+	}
+
+	if err := service.ForceFailback(ctx, "default", "example.com", "A", "bob"); err != nil {
+		t.Fatalf("ForceFailback failed: %v", err)
+	}
+	if !sameStringSet(published, []string{"192.0.2.1"}) {
+		t.Fatalf("expected the primary IPs to be published, got %v", published)
+	}
+	if report := service.OriginReports()[0]; report.CurrentPriority != 100 || report.Hold != nil {
+		t.Errorf("expected priority 100 and no hold, got %+v", report)
+	}
+
+	if err := service.ForceFailover(ctx, "default", "unknown.example.com", "A", "alice"); !errors.Is(err, ErrOriginNotFound) {
+		t.Errorf("expected ErrOriginNotFound, got %v", err)
+	}
+	if err := service.CheckOrigin(ctx, "default", "unknown.example.com", "A"); !errors.Is(err, ErrOriginNotFound) {
+		t.Errorf("expected ErrOriginNotFound, got %v", err)
+	}
+}
+
+func TestServiceForceFailover_NotApplied(t *testing.T) {
+	origin := statusTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "1", Content: "192.0.2.1"}}, nil
+	}
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		return errors.New("cloudflare is down")
+	}
+
+	err := service.ForceFailover(context.Background(), "default", "example.com", "A", "alice")
+	if !errors.Is(err, ErrForceNotApplied) {
+		t.Fatalf("expected ErrForceNotApplied, got %v", err)
+	}
+	if report := service.OriginReports()[0]; report.Hold != nil {
+		t.Errorf("expected no hold after a failed failover, got %+v", report.Hold)
+	}
+}
@@ -0,0 +1,96 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/heartbeat"
+)
+
+// cycleTracker collects the result of the latest check of every origin
+// since the last heartbeat, to tell when a full check cycle has completed.
+type cycleTracker struct {
+	mu      sync.Mutex
+	results map[string]error
+}
+
+// record stores the result of a check of originKey. Once every origin in
+// originKeys has a result, it returns true with the errors by origin and
+// starts a new cycle.
+func (t *cycleTracker) record(originKey string, err error, originKeys []string) (bool, map[string]error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.results == nil {
+		t.results = make(map[string]error, len(originKeys))
+	}
+	t.results[originKey] = err
+	for _, key := range originKeys {
+		if _, ok := t.results[key]; !ok {
+			return false, nil
+		}
+	}
+	failures := make(map[string]error)
+	for _, key := range originKeys {
+		if t.results[key] != nil {
+			failures[key] = t.results[key]
+		}
+	}
+	t.results = nil
+	return true, failures
+}
+
+func buildHeartbeat(cfg *config.Config, logger *log.Logger) *heartbeat.Pinger {
+	if !cfg.Heartbeat.Enabled() {
+		return nil
+	}
+	logger.Printf("Heartbeat configured")
+	return heartbeat.New(cfg.Heartbeat.URL, cfg.Heartbeat.FailURL, cfg.Heartbeat.TimeoutSeconds.Duration())
+}
+
+// recordCycle records a check of originKey and pings the heartbeat once
+// every origin has been checked since the last ping. A cycle in which a
+// check failed pings the failure URL instead.
+func (s *Service) recordCycle(originKey string, err error) {
+	if s.heartbeat == nil {
+		return
+	}
+	originKeys := make([]string, 0, len(s.config.Origins))
+	for _, origin := range s.config.Origins {
+		originKeys = append(originKeys, originKeyFor(origin))
+	}
+	complete, failures := s.cycles.record(originKey, err, originKeys)
+	if !complete {
+		return
+	}
+
+	// Pinged in the background, as checks hold the check lock
+	s.heartbeatWG.Add(1)
+	go func() {
+		defer s.heartbeatWG.Done()
+		ctx := context.Background()
+		if len(failures) == 0 {
+			if err := s.heartbeat.Success(ctx); err != nil {
+				s.logf("Failed to ping heartbeat: %v", err)
+			}
+			return
+		}
+		if err := s.heartbeat.Fail(ctx, describeFailures(failures)); err != nil {
+			s.logf("Failed to ping heartbeat: %v", err)
+		}
+	}()
+}
+
+// describeFailures formats the failed checks of a cycle, one origin per line.
+func describeFailures(failures map[string]error) string {
+	lines := make([]string, 0, len(failures))
+	for originKey, err := range failures {
+		lines = append(lines, fmt.Sprintf("%s: %v", originKey, err))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,70 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/bootjp/cloudflare-gslb/pkg/heartbeat"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestCycleTracker(t *testing.T) {
+	var tracker cycleTracker
+	keys := []string{"a", "b"}
+
+	if complete, _ := tracker.record("a", nil, keys); complete {
+		t.Fatal("Expected the cycle to wait for b")
+	}
+	// A later check of the same origin replaces its result
+	tracker.record("a", errors.New("timeout"), keys)
+	complete, failures := tracker.record("b", nil, keys)
+	if !complete || len(failures) != 1 || failures["a"] == nil {
+		t.Fatalf("record() = %v, %v, want a complete cycle with a failed", complete, failures)
+	}
+
+	if complete, _ := tracker.record("b", nil, keys); complete {
+		t.Error("Expected a new cycle to start")
+	}
+}
+
+func TestService_Heartbeat(t *testing.T) {
+	var mu sync.Mutex
+	var pings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		pings = append(pings, r.URL.Path+" "+string(body))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	origin := statusTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+	service.heartbeat = heartbeat.New(server.URL+"/ping", server.URL+"/ping/fail", 0)
+
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "1", Content: "192.0.2.1"}}, nil
+	}
+	healthy := hcmock.NewCheckerMock(func(ip string) error { return nil })
+	service.checkOrigin(context.Background(), origin, healthy)
+	service.heartbeatWG.Wait()
+
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return nil, errors.New("api unavailable")
+	}
+	service.checkOrigin(context.Background(), origin, healthy)
+	service.heartbeatWG.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(pings) != 2 || pings[0] != "/ping " || !strings.HasPrefix(pings[1], "/ping/fail "+originKeyFor(origin)+": ") {
+		t.Errorf("pings = %q, want a success and a failure", pings)
+	}
+}
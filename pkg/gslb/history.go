@@ -0,0 +1,81 @@
+package gslb
+
+import (
+	"log"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/history"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrEventHistoryDisabled is returned by Events when event_history is not configured.
+var ErrEventHistoryDisabled = errors.New("event history is not configured")
+
+// buildEventHistory opens the configured event history, or returns nil if it is disabled.
+func buildEventHistory(cfg *config.Config, logger *log.Logger) (*history.Store, error) {
+	if !cfg.EventHistory.Enabled() {
+		return nil, nil
+	}
+	store, err := history.Open(cfg.EventHistory.File, cfg.EventHistory.EffectiveRetention().Duration())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	logger.Printf("Event history configured: %s", cfg.EventHistory.File)
+	return store, nil
+}
+
+// recordEvent adds event to the history of origin. Failures are logged, as
+// the history must never affect checks.
+func (s *Service) recordEvent(origin config.OriginConfig, event history.Event) {
+	if s.history == nil {
+		return
+	}
+	event.Origin = origin.Name
+	event.Zone = origin.ZoneName
+	event.RecordType = origin.RecordType
+	if err := s.history.Record(event); err != nil {
+		s.logf("Failed to record %s event for %s: %v", event.Type, origin.Name, err)
+	}
+}
+
+// recordHealthEvent records the health of ip when it differs from the last
+// probe. The first probe of an IP is only recorded when it is unhealthy.
+func (s *Service) recordHealthEvent(origin config.OriginConfig, ip string, healthy, changed bool) {
+	if !changed {
+		return
+	}
+	s.recordEvent(origin, history.Event{Type: history.TypeHealth, IP: ip, Healthy: &healthy})
+}
+
+// recordDNSChangeEvent records an attempt to publish newIPs.
+func (s *Service) recordDNSChangeEvent(origin config.OriginConfig, oldIPs, newIPs []string, state, result, reason string, err error) {
+	event := history.Event{
+		Type:   history.TypeDNSChange,
+		OldIPs: oldIPs,
+		NewIPs: newIPs,
+		State:  state,
+		Result: result,
+		Reason: reason,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	s.recordEvent(origin, event)
+}
+
+// resultOf returns the history result of a DNS change that returned err.
+func resultOf(err error) string {
+	if err != nil {
+		return history.ResultError
+	}
+	return history.ResultSuccess
+}
+
+// Events returns the recorded events selected by filter, oldest first.
+func (s *Service) Events(filter history.Filter) ([]history.Event, error) {
+	if s.history == nil {
+		return nil, ErrEventHistoryDisabled
+	}
+	events, err := s.history.Query(filter)
+	return events, errors.WithStack(err)
+}
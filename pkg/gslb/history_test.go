@@ -0,0 +1,69 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/bootjp/cloudflare-gslb/pkg/history"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestServiceCheckOrigin_RecordsEventHistory(t *testing.T) {
+	origin := statusTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+	store, err := history.Open(filepath.Join(t.TempDir(), "events.log"), 0)
+	if err != nil {
+		t.Fatalf("history.Open: %v", err)
+	}
+	service.history = store
+
+	published := []string{"192.0.2.1"}
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "1", Content: published[0]}}, nil
+	}
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		published = newContents
+		return nil
+	}
+
+	healthy := hcmock.NewCheckerMock(func(ip string) error { return nil })
+	primaryDown := hcmock.NewCheckerMock(func(ip string) error {
+		if ip == "192.0.2.1" {
+			return errors.New("unhealthy")
+		}
+		return nil
+	})
+	// Healthy results of a new IP and repeated results are not transitions
+	service.checkOrigin(context.Background(), origin, healthy)
+	service.checkOrigin(context.Background(), origin, primaryDown)
+	service.checkOrigin(context.Background(), origin, primaryDown)
+
+	events, err := service.Events(history.Filter{})
+	if err != nil {
+		t.Fatalf("Events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(events), events)
+	}
+	health, change := events[0], events[1]
+	if health.Type != history.TypeHealth || health.IP != "192.0.2.1" || health.Healthy == nil || *health.Healthy {
+		t.Errorf("unexpected health event %+v", health)
+	}
+	if change.Type != history.TypeDNSChange || change.Result != history.ResultSuccess || change.State != "failover" ||
+		!sameStringSet(change.OldIPs, []string{"192.0.2.1"}) || !sameStringSet(change.NewIPs, []string{"198.51.100.1"}) {
+		t.Errorf("unexpected DNS change event %+v", change)
+	}
+	if change.Origin != origin.Name || change.Zone != origin.ZoneName || change.RecordType != origin.RecordType {
+		t.Errorf("event does not identify the origin: %+v", change)
+	}
+}
+
+func TestServiceEvents_WithoutHistory(t *testing.T) {
+	service, _ := createTestService(statusTestOrigin())
+	if _, err := service.Events(history.Filter{}); !errors.Is(err, ErrEventHistoryDisabled) {
+		t.Errorf("expected ErrEventHistoryDisabled, got %v", err)
+	}
+}
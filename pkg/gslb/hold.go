@@ -0,0 +1,125 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/cockroachdb/errors"
+)
+
+var (
+	// ErrAlreadyHeld is returned when holding an origin that someone already holds
+	ErrAlreadyHeld = errors.New("origin is already held")
+	// ErrNotHeld is returned when releasing an origin that nobody holds
+	ErrNotHeld = errors.New("origin is not held")
+)
+
+// Hold is an operator's acknowledgment of an origin, which pauses its
+// automatic DNS changes until it is released.
+type Hold struct {
+	By    string    `json:"by"`
+	Since time.Time `json:"since"`
+}
+
+// holdTracker remembers the held origins. The zero value is ready to use.
+type holdTracker struct {
+	mu    sync.Mutex
+	holds map[string]Hold
+}
+
+// get returns the hold of originKey, if it is held.
+func (t *holdTracker) get(originKey string) (Hold, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	hold, ok := t.holds[originKey]
+	return hold, ok
+}
+
+// hold holds originKey, unless it is already held, in which case it returns
+// the existing hold and false.
+func (t *holdTracker) hold(originKey string, hold Hold) (Hold, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if existing, ok := t.holds[originKey]; ok {
+		return existing, false
+	}
+	if t.holds == nil {
+		t.holds = make(map[string]Hold)
+	}
+	t.holds[originKey] = hold
+	return hold, true
+}
+
+// release removes the hold of originKey and returns it, if it was held.
+func (t *holdTracker) release(originKey string) (Hold, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	hold, ok := t.holds[originKey]
+	delete(t.holds, originKey)
+	return hold, ok
+}
+
+// findOrigin returns the configured origin of the zone, name and record type.
+func (s *Service) findOrigin(zone, name, recordType string) (config.OriginConfig, error) {
+	for _, origin := range s.config.Origins {
+		if origin.ZoneName == zone && origin.Name == name && origin.RecordType == recordType {
+			return origin, nil
+		}
+	}
+	return config.OriginConfig{}, errors.Wrapf(ErrOriginNotFound, "%s.%s (%s)", name, zone, recordType)
+}
+
+// HoldOrigin pauses the automatic DNS changes of an origin on behalf of by,
+// until ReleaseOrigin. The origin is still checked and alerted on, and
+// manual changes such as SwitchIPSet still apply.
+func (s *Service) HoldOrigin(ctx context.Context, zone, name, recordType, by string) error {
+	origin, err := s.findOrigin(zone, name, recordType)
+	if err != nil {
+		return err
+	}
+	originKey := originKeyFor(origin)
+	if existing, ok := s.holds.hold(originKey, Hold{By: by, Since: s.now()}); !ok {
+		return errors.Wrapf(ErrAlreadyHeld, "%s is held by %s since %s", originLabel(origin), existing.By, existing.Since.Format(time.RFC3339))
+	}
+	s.logf("%s held by %s, pausing its automatic DNS changes", originLabel(origin), by)
+	ips := s.publishedIPs(originKey)
+	s.sendAlert(ctx, notifier.EventTypeOriginHeld, origin, ips, ips,
+		fmt.Sprintf("Acknowledged by %s; automatic DNS changes are paused until the origin is released", by))
+	return nil
+}
+
+// ReleaseOrigin resumes the automatic DNS changes of a held origin on behalf
+// of by. The next check publishes whatever the health checks select.
+func (s *Service) ReleaseOrigin(ctx context.Context, zone, name, recordType, by string) error {
+	origin, err := s.findOrigin(zone, name, recordType)
+	if err != nil {
+		return err
+	}
+	originKey := originKeyFor(origin)
+	hold, ok := s.holds.release(originKey)
+	if !ok {
+		return errors.Wrapf(ErrNotHeld, "%s", originLabel(origin))
+	}
+	held := s.now().Sub(hold.Since).Round(time.Second)
+	s.logf("%s released by %s after %s, resuming its automatic DNS changes", originLabel(origin), by, held)
+	ips := s.publishedIPs(originKey)
+	s.sendAlert(ctx, notifier.EventTypeOriginReleased, origin, ips, ips,
+		fmt.Sprintf("Released by %s after being held by %s for %s; automatic DNS changes resume", by, hold.By, held))
+	return nil
+}
+
+// InheritHolds keeps the holds of previous on the origins that are still
+// configured, so that reloading the configuration does not release them.
+// Call it before Start.
+func (s *Service) InheritHolds(previous *Service) {
+	for _, origin := range s.config.Origins {
+		originKey := originKeyFor(origin)
+		if hold, ok := previous.holds.get(originKey); ok {
+			s.holds.hold(originKey, hold)
+		}
+	}
+}
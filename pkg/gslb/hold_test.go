@@ -0,0 +1,111 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestServiceHoldOrigin(t *testing.T) {
+	origin := statusTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+	recorder := &recordingNotifier{}
+	service.notifiers = []notifier.Notifier{recorder}
+
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "1", Content: "192.0.2.1"}}, nil
+	}
+	replaceCallCount := 0
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		replaceCallCount++
+		return nil
+	}
+	checker := hcmock.NewCheckerMock(func(ip string) error {
+		if ip == "192.0.2.1" {
+			return fmt.Errorf("unhealthy")
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+	if err := service.HoldOrigin(ctx, "default", "example.com", "A", "alice"); err != nil {
+		t.Fatalf("HoldOrigin failed: %v", err)
+	}
+	if err := service.HoldOrigin(ctx, "default", "example.com", "A", "bob"); !errors.Is(err, ErrAlreadyHeld) {
+		t.Errorf("expected ErrAlreadyHeld, got %v", err)
+	}
+	if err := service.HoldOrigin(ctx, "default", "unknown.example.com", "A", "bob"); !errors.Is(err, ErrOriginNotFound) {
+		t.Errorf("expected ErrOriginNotFound, got %v", err)
+	}
+
+	// The primary is down, but the held origin is left alone
+	service.checkOrigin(ctx, origin, checker)
+	if replaceCallCount != 0 {
+		t.Fatalf("expected no DNS change while held, got %d", replaceCallCount)
+	}
+	status := service.OriginStatuses()[originKeyFor(origin)]
+	if status.LastResult != CheckResultHeld || !sameStringSet(status.CurrentIPs, []string{"192.0.2.1"}) {
+		t.Errorf("unexpected status %+v", status)
+	}
+	reports := service.OriginReports()
+	if len(reports) != 1 || reports[0].Hold == nil || reports[0].Hold.By != "alice" {
+		t.Errorf("expected the report to show the hold, got %+v", reports)
+	}
+
+	if err := service.ReleaseOrigin(ctx, "default", "example.com", "A", "carol"); err != nil {
+		t.Fatalf("ReleaseOrigin failed: %v", err)
+	}
+	if err := service.ReleaseOrigin(ctx, "default", "example.com", "A", "carol"); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("expected ErrNotHeld, got %v", err)
+	}
+	if reports := service.OriginReports(); reports[0].Hold != nil {
+		t.Errorf("expected no hold after release, got %+v", reports[0].Hold)
+	}
+
+	// Once released, the next check fails over
+	service.checkOrigin(ctx, origin, checker)
+	if replaceCallCount != 1 {
+		t.Errorf("expected a DNS change after release, got %d", replaceCallCount)
+	}
+
+	waitForNotifications(t, service)
+	var types []notifier.EventType
+	for _, event := range recorder.take() {
+		types = append(types, event.Type)
+	}
+	// Notifications are sent concurrently, so their order is not checked
+	for _, want := range []notifier.EventType{notifier.EventTypeOriginHeld, notifier.EventTypeOriginReleased, notifier.EventTypeFailover} {
+		if !slices.Contains(types, want) {
+			t.Errorf("expected a %s notification, got %v", want, types)
+		}
+	}
+}
+
+func TestServiceInheritHolds(t *testing.T) {
+	origin := statusTestOrigin()
+	previous, _ := createTestService(origin)
+	if err := previous.HoldOrigin(context.Background(), "default", "example.com", "A", "alice"); err != nil {
+		t.Fatalf("HoldOrigin failed: %v", err)
+	}
+
+	service, _ := createTestService(origin)
+	service.InheritHolds(previous)
+	hold, ok := service.holds.get(originKeyFor(origin))
+	if !ok || hold.By != "alice" {
+		t.Errorf("expected the hold to be inherited, got %+v (%v)", hold, ok)
+	}
+
+	other := statusTestOrigin()
+	other.Name = "other.example.com"
+	unrelated, _ := createTestService(other)
+	unrelated.InheritHolds(previous)
+	if _, ok := unrelated.holds.get(originKeyFor(other)); ok {
+		t.Error("expected no hold on an origin that was not held")
+	}
+}
@@ -0,0 +1,209 @@
+package gslb
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/cockroachdb/errors"
+)
+
+// hostLookupFunc resolves host with the system resolver and returns the addresses found.
+type hostLookupFunc func(ctx context.Context, network, host string) ([]string, error)
+
+func lookupHost(ctx context.Context, network, host string) ([]string, error) {
+	ips, err := net.DefaultResolver.LookupIP(ctx, network, host)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	out := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		out = append(out, ip.String())
+	}
+	return out, nil
+}
+
+// lookupWithResolvers returns a lookup that asks resolvers in turn until one
+// answers.
+func lookupWithResolvers(resolvers []string) hostLookupFunc {
+	return func(ctx context.Context, network, host string) ([]string, error) {
+		var errs error
+		for _, resolver := range resolvers {
+			addresses, err := lookupWithResolver(ctx, resolver, network, host)
+			if err == nil {
+				return addresses, nil
+			}
+			errs = errors.CombineErrors(errs, err)
+		}
+		return nil, errs
+	}
+}
+
+// hostCache keeps the addresses of hostname targets for
+// dns_discovery.refresh_seconds, shared by the checks of every origin.
+type hostCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedHost
+}
+
+type cachedHost struct {
+	addresses []string
+	resolved  time.Time
+}
+
+// lookupHostname resolves a hostname target. With a refresh interval, the
+// addresses are reused until it passes, and kept when a later lookup fails.
+func (s *Service) lookupHostname(ctx context.Context, network, host string) ([]string, error) {
+	lookup := s.lookupHost
+	var discovery *config.DNSDiscoveryConfig
+	if s.config != nil {
+		discovery = s.config.DNSDiscovery
+	}
+	if lookup == nil {
+		lookup = lookupHost
+		if resolvers := discovery.EffectiveResolvers(); len(resolvers) > 0 {
+			lookup = lookupWithResolvers(resolvers)
+		}
+	}
+	refresh := discovery.EffectiveRefresh()
+	if refresh <= 0 {
+		return lookup(ctx, network, host)
+	}
+
+	key := network + "/" + host
+	s.hosts.mu.Lock()
+	cached, ok := s.hosts.entries[key]
+	s.hosts.mu.Unlock()
+	if ok && s.now().Sub(cached.resolved) < refresh {
+		return cached.addresses, nil
+	}
+	addresses, err := lookup(ctx, network, host)
+	if err != nil {
+		if ok {
+			return cached.addresses, errors.Wrap(err, "using the addresses resolved before")
+		}
+		return nil, err
+	}
+	s.hosts.mu.Lock()
+	if s.hosts.entries == nil {
+		s.hosts.entries = make(map[string]cachedHost)
+	}
+	s.hosts.entries[key] = cachedHost{addresses: addresses, resolved: s.now()}
+	s.hosts.mu.Unlock()
+	return addresses, nil
+}
+
+// resolveOriginHosts returns a copy of origin in which the hostnames and
+// Kubernetes Services in the priority levels and IP sets are replaced by the
+// addresses they currently resolve to for the record type. A target that
+// does not resolve is left out, so its level fails over like a level without
+// healthy IPs.
+func (s *Service) resolveOriginHosts(ctx context.Context, origin config.OriginConfig) config.OriginConfig {
+	if !hasHostnames(origin) {
+		return origin
+	}
+
+	resolved := make(map[string][]string)
+	resolveLevels := func(levels []config.PriorityLevel) []config.PriorityLevel {
+		out := make([]config.PriorityLevel, len(levels))
+		for i, level := range levels {
+			out[i] = level
+			out[i].IPs = s.resolveHosts(ctx, origin, level.IPs, resolved)
+		}
+		return config.NormalizePriorityLevels(out)
+	}
+	origin.PriorityLevels = resolveLevels(origin.EffectivePriorityLevels())
+	origin.PriorityFailoverIPs = nil
+	origin.FailoverIPs = nil
+	if origin.HasIPSets() {
+		sets := make(map[string][]config.PriorityLevel, len(origin.IPSets))
+		for name, levels := range origin.IPSets {
+			sets[name] = resolveLevels(levels)
+		}
+		origin.IPSets = sets
+	}
+	return origin
+}
+
+// resolveHosts replaces the hostnames and Services in targets with their
+// addresses. Each target is looked up once per origin check, and cached in
+// resolved.
+func (s *Service) resolveHosts(ctx context.Context, origin config.OriginConfig, targets []string, resolved map[string][]string) []string {
+	network := "ip4"
+	if origin.RecordType == "AAAA" {
+		network = "ip6"
+	}
+
+	ips := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if !isDynamicTarget(target) {
+			ips = append(ips, target)
+			continue
+		}
+		addresses, ok := resolved[target]
+		if !ok {
+			var err error
+			switch {
+			case config.IsKubernetesTarget(target):
+				addresses, err = s.resolveKubernetesTarget(ctx, origin, target, resolved)
+			case config.IsConsulTarget(target):
+				addresses, err = s.resolveConsulTarget(ctx, origin, target, resolved)
+			default:
+				addresses, err = s.lookupHostname(ctx, network, target)
+			}
+			if err != nil {
+				s.logf("Failed to resolve %s for %s (%s): %v", target, origin.Name, origin.RecordType, err)
+			}
+			resolved[target] = addresses
+		}
+		ips = append(ips, addresses...)
+	}
+	return ips
+}
+
+// discoveredAddresses returns the addresses found by service discovery that
+// match the record type of origin, as services may have addresses of both
+// families. Hostnames among them are resolved like hostname targets.
+func (s *Service) discoveredAddresses(ctx context.Context, origin config.OriginConfig, addresses []string, resolved map[string][]string) []string {
+	var ips []string
+	for _, address := range addresses {
+		if config.IsHostname(address) {
+			ips = append(ips, s.resolveHosts(ctx, origin, []string{address}, resolved)...)
+			continue
+		}
+		addr, err := netip.ParseAddr(address)
+		if err != nil || addr.Is4() != (origin.RecordType == "A") {
+			continue
+		}
+		ips = append(ips, addr.String())
+	}
+	return ips
+}
+
+// isDynamicTarget reports whether target is resolved at every check.
+func isDynamicTarget(target string) bool {
+	return config.IsHostname(target) || config.IsKubernetesTarget(target) || config.IsConsulTarget(target)
+}
+
+func hasHostnames(origin config.OriginConfig) bool {
+	for _, level := range origin.EffectivePriorityLevels() {
+		for _, target := range level.IPs {
+			if isDynamicTarget(target) {
+				return true
+			}
+		}
+	}
+	for _, levels := range origin.IPSets {
+		for _, level := range levels {
+			for _, target := range level.IPs {
+				if isDynamicTarget(target) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestServiceCheckOrigin_FailsOverToHostname(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "www.example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		HealthCheck: config.HealthCheck{
+			Type:     "http",
+			Endpoint: "/health",
+			Timeout:  config.Seconds(5 * time.Second),
+		},
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.0.2.1"}},
+			{Priority: 0, IPs: []string{"backup.example.net"}},
+		},
+	}
+	service, dnsClientMock := createTestService(origin)
+
+	backup := []string{"198.51.100.1", "198.51.100.2"}
+	lookups := 0
+	service.lookupHost = func(ctx context.Context, network, host string) ([]string, error) {
+		lookups++
+		if network != "ip4" || host != "backup.example.net" {
+			t.Errorf("unexpected lookup %s %s", network, host)
+		}
+		return backup, nil
+	}
+	current := []string{"192.0.2.1"}
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		records := make([]dns.RecordResponse, 0, len(current))
+		for _, ip := range current {
+			records = append(records, dns.RecordResponse{Name: name, Type: dns.RecordResponseTypeA, Content: ip})
+		}
+		return records, nil
+	}
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		current = append([]string{}, newContents...)
+		return nil
+	}
+	checker := hcmock.NewCheckerMock(func(ip string) error {
+		if ip == "192.0.2.1" {
+			return errors.New("down")
+		}
+		return nil
+	})
+
+	service.checkOrigin(context.Background(), origin, checker)
+	if !sameStringSet(current, backup) {
+		t.Fatalf("expected the addresses of the backup host, got %v", current)
+	}
+	if lookups != 1 {
+		t.Errorf("expected one lookup per check, got %d", lookups)
+	}
+
+	// The backup site moved: the next check publishes its new address
+	backup = []string{"203.0.113.1"}
+	service.checkOrigin(context.Background(), origin, checker)
+	if !sameStringSet(current, backup) {
+		t.Fatalf("expected the new address of the backup host, got %v", current)
+	}
+	if status := service.OriginStatuses()[originKeyFor(origin)]; status.CurrentPriority != 0 {
+		t.Errorf("expected the failover priority, got %d", status.CurrentPriority)
+	}
+}
+
+func TestResolveOriginHosts(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "www.example.com",
+		RecordType: "AAAA",
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"2001:db8::1", "primary.example.net"}},
+			{Priority: 0, IPs: []string{"missing.example.net"}},
+		},
+		IPSets: map[string][]config.PriorityLevel{
+			"blue": {{Priority: 0, IPs: []string{"primary.example.net", "2001:db8::1"}}},
+		},
+	}
+	service := &Service{lookupHost: func(ctx context.Context, network, host string) ([]string, error) {
+		if network != "ip6" {
+			t.Errorf("expected an ip6 lookup for AAAA records, got %s", network)
+		}
+		if host == "missing.example.net" {
+			return nil, errors.New("no such host")
+		}
+		return []string{"2001:db8::1", "2001:db8::2"}, nil
+	}}
+
+	resolved := service.resolveOriginHosts(context.Background(), origin)
+	want := []config.PriorityLevel{{Priority: 100, IPs: []string{"2001:db8::1", "2001:db8::2"}}}
+	if len(resolved.PriorityLevels) != 1 || !sameStringSet(resolved.PriorityLevels[0].IPs, want[0].IPs) {
+		t.Errorf("PriorityLevels = %+v, want %+v", resolved.PriorityLevels, want)
+	}
+	if blue := resolved.IPSets["blue"]; len(blue) != 1 || !sameStringSet(blue[0].IPs, want[0].IPs) {
+		t.Errorf("IP set blue = %+v", blue)
+	}
+	if origin.PriorityLevels[0].IPs[1] != "primary.example.net" || origin.IPSets["blue"][0].IPs[0] != "primary.example.net" {
+		t.Error("the configured origin was modified")
+	}
+}
+
+func TestLookupHostname_Refresh(t *testing.T) {
+	answer, lookupErr := []string{"192.0.2.1"}, error(nil)
+	lookups := 0
+	service := &Service{
+		config: &config.Config{DNSDiscovery: &config.DNSDiscoveryConfig{RefreshSeconds: config.Seconds(time.Hour)}},
+		lookupHost: func(ctx context.Context, network, host string) ([]string, error) {
+			lookups++
+			return answer, lookupErr
+		},
+	}
+
+	for range 3 {
+		if addresses, err := service.lookupHostname(context.Background(), "ip4", "backends.internal.example.com"); err != nil || !sameStringSet(addresses, []string{"192.0.2.1"}) {
+			t.Fatalf("lookupHostname() = %v, %v", addresses, err)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("expected one lookup within the refresh interval, got %d", lookups)
+	}
+
+	// Once the interval passed, a failed lookup keeps the addresses
+	service.hosts.entries["ip4/backends.internal.example.com"] = cachedHost{addresses: []string{"192.0.2.1"}, resolved: time.Now().Add(-2 * time.Hour)}
+	answer, lookupErr = nil, errors.New("server misbehaving")
+	addresses, err := service.lookupHostname(context.Background(), "ip4", "backends.internal.example.com")
+	if err == nil || !sameStringSet(addresses, []string{"192.0.2.1"}) {
+		t.Errorf("expected the previous addresses with the error, got %v, %v", addresses, err)
+	}
+	if lookups != 2 {
+		t.Errorf("expected a lookup after the refresh interval, got %d", lookups)
+	}
+}
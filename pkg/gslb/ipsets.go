@@ -0,0 +1,108 @@
+package gslb
+
+import (
+	"context"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/cockroachdb/errors"
+)
+
+var (
+	// ErrOriginNotFound is returned when no configured origin matches the requested name
+	ErrOriginNotFound = errors.New("origin not found")
+	// ErrIPSetNotHealthy is returned when a switch target set has no healthy IPs to publish
+	ErrIPSetNotHealthy = errors.New("IP set has no healthy IPs")
+)
+
+// activePriorityLevels returns the priority levels health checks should run
+// against. For origins with named IP sets, the active set is an explicit
+// switch made through SwitchIPSet, otherwise the set the live records belong
+// to, otherwise the configured default.
+func (s *Service) activePriorityLevels(origin config.OriginConfig, originKey string, currentIPs []string) (string, []config.PriorityLevel) {
+	if !origin.HasIPSets() {
+		return "", origin.EffectivePriorityLevels()
+	}
+
+	s.activeSetsMutex.RLock()
+	name, switched := s.activeSets[originKey]
+	s.activeSetsMutex.RUnlock()
+
+	if !switched {
+		name = detectIPSet(origin, currentIPs)
+	}
+	return name, origin.IPSets[name]
+}
+
+func detectIPSet(origin config.OriginConfig, currentIPs []string) string {
+	if len(currentIPs) > 0 {
+		currentSet := sliceToSet(currentIPs)
+		for _, name := range origin.IPSetNames() {
+			if findHighestMatchingPriority(origin.IPSets[name], currentSet) != nil {
+				return name
+			}
+		}
+	}
+	return origin.DefaultIPSet()
+}
+
+// SwitchIPSet makes setName the active IP set for every origin named
+// originName and immediately publishes its healthiest priority level. The
+// switch is rolled back if the target set has no healthy IPs.
+func (s *Service) SwitchIPSet(ctx context.Context, originName, setName string) error {
+	ctx = withManualChange(ctx, "switched to IP set "+setName)
+	found := false
+	for _, origin := range s.config.Origins {
+		if origin.Name != originName {
+			continue
+		}
+		found = true
+		if err := s.switchOriginIPSet(ctx, origin, setName); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return errors.Wrapf(ErrOriginNotFound, "%s", originName)
+	}
+	return nil
+}
+
+func (s *Service) switchOriginIPSet(ctx context.Context, origin config.OriginConfig, setName string) error {
+	if _, ok := origin.IPSets[setName]; !ok {
+		return errors.Wrapf(config.ErrUnknownIPSet, "%s for origin %s", setName, origin.Name)
+	}
+
+	originKey := originKeyFor(origin)
+
+	s.activeSetsMutex.Lock()
+	if s.activeSets == nil {
+		s.activeSets = make(map[string]string)
+	}
+	previous, hadPrevious := s.activeSets[originKey]
+	s.activeSets[originKey] = setName
+	s.activeSetsMutex.Unlock()
+
+	s.logf("Switching origin %s (%s) to IP set %s", origin.Name, origin.RecordType, setName)
+	if err := s.runOriginCheck(ctx, origin); err != nil {
+		s.restoreActiveSet(originKey, previous, hadPrevious)
+		return err
+	}
+
+	published := s.publishedIPs(originKey)
+
+	levels := s.resolveOriginHosts(ctx, origin).IPSets[setName]
+	if len(published) == 0 || findHighestMatchingPriority(levels, sliceToSet(published)) == nil {
+		s.restoreActiveSet(originKey, previous, hadPrevious)
+		return errors.Wrapf(ErrIPSetNotHealthy, "%s for origin %s", setName, origin.Name)
+	}
+	return nil
+}
+
+func (s *Service) restoreActiveSet(originKey, previous string, hadPrevious bool) {
+	s.activeSetsMutex.Lock()
+	defer s.activeSetsMutex.Unlock()
+	if hadPrevious {
+		s.activeSets[originKey] = previous
+	} else {
+		delete(s.activeSets, originKey)
+	}
+}
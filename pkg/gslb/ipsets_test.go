@@ -0,0 +1,97 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func blueGreenTestOrigin() config.OriginConfig {
+	return config.OriginConfig{
+		Name:       "example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		HealthCheck: config.HealthCheck{
+			Type:    "http",
+			Timeout: config.Seconds(1 * time.Second),
+		},
+		IPSets: map[string][]config.PriorityLevel{
+			"blue":  {{Priority: 100, IPs: []string{"192.168.1.1"}}, {Priority: 50, IPs: []string{"192.168.1.2"}}},
+			"green": {{Priority: 100, IPs: []string{"192.168.2.1"}}},
+		},
+		ActiveSet:        "blue",
+		ReturnToPriority: true,
+	}
+}
+
+func TestDetectIPSet(t *testing.T) {
+	origin := blueGreenTestOrigin()
+
+	if got := detectIPSet(origin, []string{"192.168.2.1"}); got != "green" {
+		t.Errorf("expected green to be detected from live records, got %s", got)
+	}
+	if got := detectIPSet(origin, []string{"192.168.1.2"}); got != "blue" {
+		t.Errorf("expected blue to be detected from a failover level, got %s", got)
+	}
+	if got := detectIPSet(origin, []string{"203.0.113.1"}); got != "blue" {
+		t.Errorf("expected configured default for unknown records, got %s", got)
+	}
+}
+
+func TestServiceCheckOrigin_ChecksActiveIPSet(t *testing.T) {
+	origin := blueGreenTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "1", Content: "192.168.2.1"}}, nil
+	}
+	replaceCallCount := 0
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		replaceCallCount++
+		return nil
+	}
+
+	checked := map[string]bool{}
+	checker := &recordingChecker{checked: checked}
+	service.checkOrigin(context.Background(), origin, checker)
+
+	if replaceCallCount != 0 {
+		t.Fatalf("ReplaceRecords was called %d times, expected 0", replaceCallCount)
+	}
+	if checked["192.168.1.1"] || !checked["192.168.2.1"] {
+		t.Errorf("expected only the active green set to be checked, got %v", checked)
+	}
+}
+
+func TestServiceSwitchIPSet_UnknownSet(t *testing.T) {
+	origin := blueGreenTestOrigin()
+	service, _ := createTestService(origin)
+
+	err := service.SwitchIPSet(context.Background(), "example.com", "purple")
+	if !errors.Is(err, config.ErrUnknownIPSet) {
+		t.Fatalf("expected ErrUnknownIPSet, got %v", err)
+	}
+
+	err = service.SwitchIPSet(context.Background(), "missing.example.com", "green")
+	if !errors.Is(err, ErrOriginNotFound) {
+		t.Fatalf("expected ErrOriginNotFound, got %v", err)
+	}
+}
+
+type recordingChecker struct {
+	checked map[string]bool
+	failing map[string]bool
+}
+
+func (c *recordingChecker) Check(ip string) error {
+	c.checked[ip] = true
+	if c.failing[ip] {
+		return fmt.Errorf("unhealthy")
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package gslb
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/kubernetes"
+)
+
+// kubernetesStatusClient updates the status of GSLBOrigin resources.
+type kubernetesStatusClient interface {
+	UpdateStatus(ctx context.Context, namespace, name string, status kubernetes.Status) error
+}
+
+// kubernetesStatusWriter writes the state of the origins loaded from
+// origins_kubernetes to their resources, skipping the resources whose status
+// did not change since it was last written.
+type kubernetesStatusWriter struct {
+	client  kubernetesStatusClient
+	targets []kubernetes.Target
+	written map[string]kubernetes.Status
+	logger  *log.Logger
+	now     func() time.Time
+}
+
+func buildKubernetesStatus(cfg *config.Config, logger *log.Logger, now func() time.Time) *kubernetesStatusWriter {
+	if cfg.OriginsKubernetes == nil || cfg.KubernetesOrigins == nil {
+		return nil
+	}
+	client, err := config.NewKubernetesClient(cfg.OriginsKubernetes)
+	if err != nil {
+		logger.Printf("Failed to configure the status of %s resources: %v", kubernetes.Kind, err)
+		return nil
+	}
+	return &kubernetesStatusWriter{
+		client:  client,
+		targets: cfg.KubernetesOrigins.Targets,
+		written: make(map[string]kubernetes.Status),
+		logger:  logger,
+		now:     now,
+	}
+}
+
+// runKubernetesStatus writes the status of the GSLBOrigin resources at
+// start and after every check interval.
+func (s *Service) runKubernetesStatus(ctx context.Context) {
+	defer s.wg.Done()
+	defer s.recoverPanic(map[string]string{"task": "kubernetes_status"})
+
+	ticker := time.NewTicker(s.config.CheckInterval)
+	defer ticker.Stop()
+	for {
+		s.kubernetesStatus.write(ctx, s.OriginReports())
+		select {
+		case <-s.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// write updates the status of every resource from the report of its origin.
+// Failed updates are logged and retried on the next call.
+func (w *kubernetesStatusWriter) write(ctx context.Context, reports []OriginReport) {
+	for _, target := range w.targets {
+		key := target.Key()
+		previous, written := w.written[key]
+		status := w.statusOf(target, findTargetReport(target, reports), previous)
+		if written && reflect.DeepEqual(status, previous) {
+			continue
+		}
+		if err := w.client.UpdateStatus(ctx, target.Namespace, target.Name, status); err != nil {
+			w.logger.Printf("Failed to update the status of %s %s: %v", kubernetes.Kind, key, err)
+			continue
+		}
+		w.written[key] = status
+	}
+}
+
+// statusOf returns the status of target. The transition time of the Ready
+// condition is kept from previous while the condition holds.
+func (w *kubernetesStatusWriter) statusOf(target kubernetes.Target, report *OriginReport, previous kubernetes.Status) kubernetes.Status {
+	status := kubernetes.Status{ObservedGeneration: target.Generation, Health: HealthUnknown}
+	ready, reason, message := "False", kubernetes.ReasonPending, "The origin was not checked yet"
+	switch {
+	case target.Err != "":
+		reason, message = kubernetes.ReasonInvalid, target.Err
+	case report != nil:
+		status.Health = report.Health
+		status.CurrentIPs = report.CurrentIPs
+		status.CurrentPriority = report.CurrentPriority
+		status.MaxPriority = report.MaxPriority
+		status.LastCheck = report.LastCheck
+		status.LastResult = report.LastResult
+		status.LastError = report.LastError
+		if report.Hold != nil {
+			status.HeldBy = report.Hold.By
+		}
+		ready, reason, message = kubernetesReadiness(*report)
+	}
+
+	condition := kubernetes.Condition{
+		Type:               kubernetes.ConditionReady,
+		Status:             ready,
+		ObservedGeneration: target.Generation,
+		LastTransitionTime: w.now().UTC().Truncate(time.Second),
+		Reason:             reason,
+		Message:            message,
+	}
+	for _, c := range previous.Conditions {
+		if c.Type == kubernetes.ConditionReady && c.Status == ready {
+			condition.LastTransitionTime = c.LastTransitionTime
+		}
+	}
+	status.Conditions = []kubernetes.Condition{condition}
+	return status
+}
+
+// kubernetesReadiness returns the Ready condition of an origin that was
+// reported.
+func kubernetesReadiness(report OriginReport) (string, string, string) {
+	switch report.Health {
+	case HealthHealthy:
+		return "True", kubernetes.ReasonHealthy, "The highest priority level is published"
+	case HealthFailover:
+		return "True", kubernetes.ReasonFailover, "A lower priority level is published"
+	case HealthDegraded:
+		return "True", kubernetes.ReasonDegraded, "Some published IPs are unhealthy"
+	case HealthDown:
+		return "False", kubernetes.ReasonDown, "No IP of the origin is healthy"
+	default:
+		return "False", kubernetes.ReasonPending, "The origin was not checked yet"
+	}
+}
+
+// findTargetReport returns the report of the origin of target.
+func findTargetReport(target kubernetes.Target, reports []OriginReport) *OriginReport {
+	for i, report := range reports {
+		if report.Name == target.Host && report.RecordType == target.RecordType && (target.Zone == "" || report.Zone == target.Zone) {
+			return &reports[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/kubernetes"
+)
+
+type fakeKubernetesStatusClient struct {
+	updates []kubernetes.Status
+	err     error
+}
+
+func (c *fakeKubernetesStatusClient) UpdateStatus(_ context.Context, _, _ string, status kubernetes.Status) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.updates = append(c.updates, status)
+	return nil
+}
+
+func TestKubernetesStatusWriter(t *testing.T) {
+	client := &fakeKubernetesStatusClient{}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	w := &kubernetesStatusWriter{
+		client: client,
+		targets: []kubernetes.Target{
+			{Origin: kubernetes.Origin{Namespace: "gslb", Name: "www", Generation: 2}, Host: "www.example.com", RecordType: "A"},
+		},
+		written: make(map[string]kubernetes.Status),
+		logger:  log.Default(),
+		now:     func() time.Time { return now },
+	}
+	reports := []OriginReport{{Name: "www.example.com", Zone: "example.com", RecordType: "A", Health: HealthUnknown}}
+
+	w.write(context.Background(), reports)
+	w.write(context.Background(), reports)
+	if len(client.updates) != 1 {
+		t.Fatalf("expected an unchanged status to be written once, got %d updates", len(client.updates))
+	}
+	pending := client.updates[0]
+	if pending.ObservedGeneration != 2 || pending.Conditions[0].Reason != kubernetes.ReasonPending || pending.Conditions[0].Status != "False" {
+		t.Errorf("unexpected status %+v", pending)
+	}
+
+	now = now.Add(time.Minute)
+	reports[0].Health = HealthFailover
+	reports[0].CurrentIPs = []string{"192.0.2.2"}
+	w.write(context.Background(), reports)
+	now = now.Add(time.Minute)
+	reports[0].Health = HealthHealthy
+	w.write(context.Background(), reports)
+	if len(client.updates) != 3 {
+		t.Fatalf("expected every change to be written, got %d updates", len(client.updates))
+	}
+	healthy := client.updates[2]
+	if healthy.Health != HealthHealthy || healthy.CurrentIPs[0] != "192.0.2.2" || healthy.Conditions[0].Reason != kubernetes.ReasonHealthy {
+		t.Errorf("unexpected status %+v", healthy)
+	}
+	if !healthy.Conditions[0].LastTransitionTime.Equal(client.updates[1].Conditions[0].LastTransitionTime) {
+		t.Error("expected the transition time to be kept while the origin stays ready")
+	}
+}
+
+func TestKubernetesStatusWriter_Invalid(t *testing.T) {
+	client := &fakeKubernetesStatusClient{err: errors.New("forbidden")}
+	w := &kubernetesStatusWriter{
+		client: client,
+		targets: []kubernetes.Target{
+			{Origin: kubernetes.Origin{Namespace: "gslb", Name: "typo", Generation: 1}, Err: "unknown field record_typ"},
+		},
+		written: make(map[string]kubernetes.Status),
+		logger:  log.Default(),
+		now:     time.Now,
+	}
+
+	w.write(context.Background(), nil)
+	if len(w.written) != 0 {
+		t.Fatal("expected a failed update to be retried")
+	}
+	client.err = nil
+	w.write(context.Background(), nil)
+	if len(client.updates) != 1 {
+		t.Fatalf("expected the status to be written on the next call, got %d updates", len(client.updates))
+	}
+	condition := client.updates[0].Conditions[0]
+	if condition.Reason != kubernetes.ReasonInvalid || condition.Message != "unknown field record_typ" {
+		t.Errorf("unexpected condition %+v", condition)
+	}
+}
@@ -0,0 +1,62 @@
+package gslb
+
+import (
+	"context"
+	"log"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/kubernetes"
+	"github.com/cockroachdb/errors"
+)
+
+// serviceResolver resolves a Kubernetes Service to addresses.
+type serviceResolver interface {
+	ServiceAddresses(ctx context.Context, namespace, name, addresses string) ([]string, error)
+}
+
+// buildServiceResolvers returns a client for every cluster that the k8s:
+// targets of the origins name, by cluster name; the cluster the daemon runs
+// in has the empty name. A cluster whose client cannot be built is logged,
+// and its targets resolve to no addresses.
+func buildServiceResolvers(cfg *config.Config, logger *log.Logger) map[string]serviceResolver {
+	resolvers := make(map[string]serviceResolver)
+	failed := make(map[string]bool)
+	for _, origin := range cfg.Origins {
+		for _, target := range origin.Targets() {
+			if !config.IsKubernetesTarget(target) {
+				continue
+			}
+			parsed, err := config.ParseKubernetesTarget(target)
+			if err != nil || resolvers[parsed.Cluster] != nil || failed[parsed.Cluster] {
+				continue
+			}
+			cluster, _ := cfg.KubernetesCluster(parsed.Cluster)
+			client, err := kubernetes.NewClient(cluster.ClientOptions())
+			if err != nil {
+				logger.Printf("Failed to configure the Kubernetes cluster %q: %v", parsed.Cluster, err)
+				failed[parsed.Cluster] = true
+				continue
+			}
+			resolvers[parsed.Cluster] = client
+		}
+	}
+	return resolvers
+}
+
+// resolveKubernetesTarget returns the addresses of the Service of a k8s:
+// target that match the record type of origin.
+func (s *Service) resolveKubernetesTarget(ctx context.Context, origin config.OriginConfig, target string, resolved map[string][]string) ([]string, error) {
+	parsed, err := config.ParseKubernetesTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	resolver := s.services[parsed.Cluster]
+	if resolver == nil {
+		return nil, errors.Newf("the Kubernetes cluster %q is not available", parsed.Cluster)
+	}
+	addresses, err := resolver.ServiceAddresses(ctx, parsed.Namespace, parsed.Service, parsed.Addresses)
+	if err != nil {
+		return nil, err
+	}
+	return s.discoveredAddresses(ctx, origin, addresses, resolved), nil
+}
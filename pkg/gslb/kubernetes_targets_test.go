@@ -0,0 +1,63 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+)
+
+type fakeServiceResolver map[string][]string
+
+func (r fakeServiceResolver) ServiceAddresses(ctx context.Context, namespace, name, addresses string) ([]string, error) {
+	found, ok := r[namespace+"/"+name+"/"+addresses]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return found, nil
+}
+
+func TestResolveOriginHosts_KubernetesServices(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "www.example.com",
+		RecordType: "A",
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.0.2.1", "k8s:web/frontend"}},
+			{Priority: 50, IPs: []string{"k8s:web/frontend?cluster=east&addresses=nodes"}},
+			{Priority: 0, IPs: []string{"k8s:web/missing?cluster=west"}},
+		},
+	}
+	lookups := 0
+	service := &Service{
+		services: map[string]serviceResolver{
+			"": fakeServiceResolver{
+				"web/frontend/load_balancer": {"198.51.100.1", "2001:db8::1", "lb.example.net"},
+			},
+			"east": fakeServiceResolver{
+				"web/frontend/nodes": {"203.0.113.1", "203.0.113.2"},
+			},
+		},
+		lookupHost: func(ctx context.Context, network, host string) ([]string, error) {
+			lookups++
+			if network != "ip4" || host != "lb.example.net" {
+				t.Errorf("unexpected lookup %s %s", network, host)
+			}
+			return []string{"198.51.100.2"}, nil
+		},
+	}
+
+	resolved := service.resolveOriginHosts(context.Background(), origin)
+	if len(resolved.PriorityLevels) != 2 {
+		t.Fatalf("expected the level of the unknown cluster to be dropped, got %+v", resolved.PriorityLevels)
+	}
+	if ips := resolved.PriorityLevels[0].IPs; !sameStringSet(ips, []string{"192.0.2.1", "198.51.100.1", "198.51.100.2"}) {
+		t.Errorf("expected the IPv4 load balancer addresses, got %v", ips)
+	}
+	if ips := resolved.PriorityLevels[1].IPs; !sameStringSet(ips, []string{"203.0.113.1", "203.0.113.2"}) {
+		t.Errorf("expected the node addresses of the east cluster, got %v", ips)
+	}
+	if lookups != 1 {
+		t.Errorf("expected the load balancer hostname to be looked up once, got %d", lookups)
+	}
+}
@@ -0,0 +1,154 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/bootjp/cloudflare-gslb/pkg/sentry"
+)
+
+// panicNotifyTimeout bounds how long a panicking goroutine waits for the
+// notifications before the process exits.
+const panicNotifyTimeout = 10 * time.Second
+
+// lifecycleEvent returns an event about the service itself.
+func (s *Service) lifecycleEvent(eventType notifier.EventType, reason string) notifier.FailoverEvent {
+	return notifier.FailoverEvent{Type: eventType, Reason: reason, Timestamp: s.now()}
+}
+
+// hostname names the instance in lifecycle events, which matters when
+// several instances send to the same channel.
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown host"
+	}
+	return name
+}
+
+// notifyLifecycle sends a lifecycle event and waits until it is sent or ctx
+// is done, so that the notifiers see the lifecycle of a service in order.
+func (s *Service) notifyLifecycle(ctx context.Context, event notifier.FailoverEvent) {
+	select {
+	case <-s.dispatchEvent(ctx, event):
+	case <-ctx.Done():
+	}
+}
+
+// NotifyStarted sends the notification that the service started monitoring
+// and waits until it is sent or ctx is done.
+func (s *Service) NotifyStarted(ctx context.Context) {
+	if !s.hasEventReceivers() {
+		return
+	}
+	s.notifyLifecycle(ctx, s.lifecycleEvent(notifier.EventTypeServiceStarted,
+		fmt.Sprintf("Started monitoring %d origins on %s", len(s.config.Origins), hostname())))
+}
+
+// NotifyStopping sends the notification of a clean shutdown and waits until
+// it is sent or ctx is done. Call it before Stop, which sends the events
+// still waiting for a digest.
+func (s *Service) NotifyStopping(ctx context.Context) {
+	if !s.hasEventReceivers() {
+		return
+	}
+	s.notifyLifecycle(ctx, s.lifecycleEvent(notifier.EventTypeServiceStopped,
+		fmt.Sprintf("Stopping on %s", hostname())))
+}
+
+// NotifyReloaded sends the notification that the service replaced previous
+// with a changed configuration, listing the origins added, removed and
+// changed, and waits until it is sent or ctx is done.
+func (s *Service) NotifyReloaded(ctx context.Context, previous *Service) {
+	if !s.hasEventReceivers() {
+		return
+	}
+	s.notifyLifecycle(ctx, s.lifecycleEvent(notifier.EventTypeConfigReloaded,
+		"Configuration reloaded: "+describeOriginChanges(previous.config.Origins, s.config.Origins)))
+}
+
+// describeOriginChanges summarizes how the origins changed from before to
+// after, e.g. "1 origin added (api (A)), 1 origin removed (www (AAAA))".
+func describeOriginChanges(before, after []config.OriginConfig) string {
+	old := make(map[string]config.OriginConfig, len(before))
+	for _, origin := range before {
+		old[originKeyFor(origin)] = origin
+	}
+	var added, changed, removed []string
+	for _, origin := range after {
+		key := originKeyFor(origin)
+		previous, ok := old[key]
+		delete(old, key)
+		switch {
+		case !ok:
+			added = append(added, originLabel(origin))
+		case !reflect.DeepEqual(previous, origin):
+			changed = append(changed, originLabel(origin))
+		}
+	}
+	for _, origin := range old {
+		removed = append(removed, originLabel(origin))
+	}
+	if len(added)+len(changed)+len(removed) == 0 {
+		return "no origins added, removed or changed"
+	}
+	var parts []string
+	for _, group := range []struct {
+		verb    string
+		origins []string
+	}{{"added", added}, {"removed", removed}, {"changed", changed}} {
+		if len(group.origins) == 0 {
+			continue
+		}
+		sort.Strings(group.origins)
+		noun := "origins"
+		if len(group.origins) == 1 {
+			noun = "origin"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s %s (%s)", len(group.origins), noun, group.verb, strings.Join(group.origins, ", ")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// recoverPanic notifies that the service is exiting because of a panic in
+// progress, reports it like sentry.Recover and panics again. Use it with
+// defer at the top of a goroutine.
+func (s *Service) recoverPanic(tags map[string]string) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	s.notifyPanic(recovered, tags)
+	sentry.ReportPanic(recovered, tags)
+	panic(recovered)
+}
+
+// notifyPanic sends the notification of a panic and waits for it, as the
+// process exits right after.
+func (s *Service) notifyPanic(recovered any, tags map[string]string) {
+	if !s.hasEventReceivers() {
+		return
+	}
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	where := make([]string, 0, len(keys))
+	for _, key := range keys {
+		where = append(where, key+"="+tags[key])
+	}
+	s.logf("Panic in %s, notifying before exiting: %v", strings.Join(where, " "), recovered)
+
+	ctx, cancel := context.WithTimeout(context.Background(), panicNotifyTimeout)
+	defer cancel()
+	s.notifyLifecycle(ctx, s.lifecycleEvent(notifier.EventTypePanic,
+		fmt.Sprintf("Panicked on %s in %s, exiting: %v", hostname(), strings.Join(where, " "), recovered)))
+}
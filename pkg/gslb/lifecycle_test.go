@@ -0,0 +1,90 @@
+package gslb
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+func TestDescribeOriginChanges(t *testing.T) {
+	www := config.OriginConfig{Name: "www", ZoneName: "example.com", RecordType: "A"}
+	api := config.OriginConfig{Name: "api", ZoneName: "example.com", RecordType: "A"}
+	cdn := config.OriginConfig{Name: "cdn", ZoneName: "example.com", RecordType: "AAAA"}
+	moved := www
+	moved.ReturnToPriority = true
+
+	tests := []struct {
+		name          string
+		before, after []config.OriginConfig
+		want          string
+	}{
+		{"unchanged", []config.OriginConfig{www}, []config.OriginConfig{www}, "no origins added, removed or changed"},
+		{"added and removed", []config.OriginConfig{www, cdn}, []config.OriginConfig{www, api}, "1 origin added (api (A)), 1 origin removed (cdn (AAAA))"},
+		{"changed", []config.OriginConfig{www, api}, []config.OriginConfig{moved, api, cdn}, "1 origin added (cdn (AAAA)), 1 origin changed (www (A))"},
+		{"several", nil, []config.OriginConfig{www, api}, "2 origins added (api (A), www (A))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeOriginChanges(tt.before, tt.after); got != tt.want {
+				t.Errorf("describeOriginChanges() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceLifecycleNotifications(t *testing.T) {
+	recorder := &recordingNotifier{}
+	origin := config.OriginConfig{Name: "www", ZoneName: "example.com", RecordType: "A"}
+	previous := &Service{config: &config.Config{}}
+	service := &Service{
+		config:    &config.Config{Origins: []config.OriginConfig{origin}},
+		notifiers: []notifier.Notifier{recorder},
+	}
+
+	service.NotifyStarted(context.Background())
+	service.NotifyReloaded(context.Background(), previous)
+	service.NotifyStopping(context.Background())
+
+	events := recorder.take()
+	want := []notifier.EventType{notifier.EventTypeServiceStarted, notifier.EventTypeConfigReloaded, notifier.EventTypeServiceStopped}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %+v", len(want), events)
+	}
+	for i, event := range events {
+		if event.Type != want[i] || event.Severity != notifier.SeverityInfo || event.OriginName != "" {
+			t.Errorf("events[%d] = %+v, want an info %s", i, event, want[i])
+		}
+	}
+	if !strings.HasPrefix(events[0].Reason, "Started monitoring 1 origins on ") {
+		t.Errorf("Reason = %q", events[0].Reason)
+	}
+	if events[1].Reason != "Configuration reloaded: 1 origin added (www (A))" {
+		t.Errorf("Reason = %q", events[1].Reason)
+	}
+}
+
+func TestServiceRecoverPanic(t *testing.T) {
+	recorder := &recordingNotifier{}
+	service := &Service{config: &config.Config{}, notifiers: []notifier.Notifier{recorder}}
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != "boom" {
+				t.Errorf("Expected the panic to continue, got %v", recovered)
+			}
+		}()
+		defer service.recoverPanic(map[string]string{"task": "summary"})
+		panic("boom")
+	}()
+
+	events := recorder.take()
+	if len(events) != 1 || events[0].Type != notifier.EventTypePanic || events[0].Severity != notifier.SeverityCritical {
+		t.Fatalf("Expected a critical panic notification before the panic continued, got %+v", events)
+	}
+	if !strings.Contains(events[0].Reason, "task=summary") || !strings.HasSuffix(events[0].Reason, "exiting: boom") {
+		t.Errorf("Reason = %q", events[0].Reason)
+	}
+}
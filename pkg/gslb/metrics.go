@@ -0,0 +1,47 @@
+package gslb
+
+import (
+	"strconv"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/metrics"
+)
+
+var (
+	checksMetric        = metrics.NewCounter("gslb.checks", "{check}", "Check cycles run per origin")
+	probesMetric        = metrics.NewCounter("gslb.probes", "{probe}", "Health checks of a single IP, by result")
+	probeDurationMetric = metrics.NewHistogram("gslb.probe.duration", "ms", "Duration of the health check of a single IP", metrics.DurationBuckets)
+	dnsChangesMetric    = metrics.NewCounter("gslb.dns_changes", "{change}", "DNS record changes, by result")
+	priorityMetric      = metrics.NewGauge("gslb.priority", "1", "Priority of the published IPs")
+	publishedIPsMetric  = metrics.NewGauge("gslb.published_ips", "{ip}", "Number of IPs published for the origin")
+	notificationsMetric = metrics.NewCounter("gslb.notifications", "{notification}", "Notifications sent, by event and result")
+
+	notificationDurationMetric = metrics.NewHistogram("gslb.notification.duration", "ms", "Duration of sending a notification, by notification and result", metrics.DurationBuckets)
+	notificationFailuresMetric = metrics.NewGauge("gslb.notification.consecutive_failures", "{notification}", "Sends in a row that failed per notification")
+)
+
+// originAttributes identifies the series of an origin.
+func originAttributes(origin config.OriginConfig, extra ...metrics.Attribute) []metrics.Attribute {
+	return append([]metrics.Attribute{
+		metrics.String("origin", origin.Name),
+		metrics.String("zone", origin.ZoneName),
+		metrics.String("record_type", origin.RecordType),
+	}, extra...)
+}
+
+func resultAttribute(ok bool) metrics.Attribute {
+	if ok {
+		return metrics.String("result", "success")
+	}
+	return metrics.String("result", "error")
+}
+
+// recordPublished records the priority and number of IPs the origin now serves.
+func recordPublished(origin config.OriginConfig, priority int, ips []string) {
+	priorityMetric.Set(float64(priority), originAttributes(origin)...)
+	publishedIPsMetric.Set(float64(len(ips)), originAttributes(origin)...)
+}
+
+func healthyAttribute(healthy bool) metrics.Attribute {
+	return metrics.String("healthy", strconv.FormatBool(healthy))
+}
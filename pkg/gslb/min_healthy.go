@@ -0,0 +1,102 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/healthcheck"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+// levelHealth holds the per-IP health results of a priority level.
+type levelHealth struct {
+	level     config.PriorityLevel
+	healthy   []string
+	unhealthy []string
+}
+
+func (s *Service) checkLevelIPs(ctx context.Context, origin config.OriginConfig, originKey string, checker healthcheck.Checker, level config.PriorityLevel) levelHealth {
+	s.logf("Checking priority level %d (%d IPs)", level.Priority, len(level.IPs))
+
+	result := levelHealth{level: level}
+	for _, ip := range level.IPs {
+		if s.checkIP(ctx, origin, originKey, checker, ip, level.Priority) {
+			result.healthy = append(result.healthy, ip)
+		} else {
+			result.unhealthy = append(result.unhealthy, ip)
+		}
+	}
+	return result
+}
+
+// selectWithMinHealthy publishes the healthy IPs of the best priority level
+// that still has at least origin.MinHealthy of them. When no level meets the
+// floor, it keeps serving the current level padded with unhealthy IPs up to
+// the floor rather than shrinking the answer, and escalates.
+func (s *Service) selectWithMinHealthy(ctx context.Context, origin config.OriginConfig, checker healthcheck.Checker, originKey string, levels []config.PriorityLevel, currentPriority int, currentPrioritySet bool, currentIPs []string) (int, []string, bool) {
+	var results []levelHealth
+	evaluate := func(level config.PriorityLevel) ([]string, bool) {
+		result := s.checkLevelIPs(ctx, origin, originKey, checker, level)
+		results = append(results, result)
+		return result.healthy, len(result.healthy) >= origin.MinHealthy
+	}
+
+	if priority, ips, ok := selectPriorityLevel(origin, evaluate, levels, currentPriority, currentPrioritySet); ok {
+		s.setDegraded(originKey, origin, false)
+		return priority, ips, true
+	}
+	if len(results) == 0 {
+		return 0, nil, false
+	}
+
+	chosen := results[0]
+	for _, result := range results {
+		if result.level.Priority == currentPriority {
+			chosen = result
+			break
+		}
+	}
+
+	ips := padToFloor(chosen, origin.MinHealthy)
+	s.logf("Only %d healthy IPs at priority %d for %s (min_healthy %d), serving degraded set %v",
+		len(chosen.healthy), chosen.level.Priority, origin.Name, origin.MinHealthy, ips)
+
+	if s.setDegraded(originKey, origin, true) {
+		s.sendAlert(ctx, notifier.EventTypeMinHealthyViolated, origin, currentIPs, ips,
+			fmt.Sprintf("Only %d of %d IPs at priority %d are healthy (min_healthy %d); serving possibly degraded IPs",
+				len(chosen.healthy), len(chosen.level.IPs), chosen.level.Priority, origin.MinHealthy))
+	}
+	return chosen.level.Priority, ips, true
+}
+
+func padToFloor(result levelHealth, floor int) []string {
+	ips := append([]string{}, result.healthy...)
+	for _, ip := range result.unhealthy {
+		if len(ips) >= floor {
+			break
+		}
+		ips = append(ips, ip)
+	}
+	return ips
+}
+
+// setDegraded records whether the origin is serving a degraded set and
+// reports whether it has just become degraded.
+func (s *Service) setDegraded(originKey string, origin config.OriginConfig, degraded bool) bool {
+	s.originStatusMutex.Lock()
+	defer s.originStatusMutex.Unlock()
+
+	status := s.originStatus[originKey]
+	if status == nil {
+		status = &OriginStatus{}
+		s.originStatus[originKey] = status
+	}
+
+	wasDegraded := status.Degraded
+	status.Degraded = degraded
+	if wasDegraded && !degraded {
+		s.logf("Origin %s has recovered to at least %d healthy IPs", origin.Name, origin.MinHealthy)
+	}
+	return degraded && !wasDegraded
+}
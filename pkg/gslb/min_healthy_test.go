@@ -0,0 +1,97 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func minHealthyTestOrigin() config.OriginConfig {
+	return config.OriginConfig{
+		Name:       "example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.168.1.1", "192.168.1.2", "192.168.1.3"}},
+			{Priority: 50, IPs: []string{"192.168.1.4"}},
+		},
+		ReturnToPriority: true,
+		MinHealthy:       2,
+	}
+}
+
+func TestServiceCheckOrigin_MinHealthyPublishesHealthySubset(t *testing.T) {
+	origin := minHealthyTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{
+			{ID: "1", Content: "192.168.1.1"},
+			{ID: "2", Content: "192.168.1.2"},
+			{ID: "3", Content: "192.168.1.3"},
+		}, nil
+	}
+	var replaced []string
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		replaced = append([]string{}, newContents...)
+		return nil
+	}
+
+	checker := hcmock.NewCheckerMock(func(ip string) error {
+		if ip == "192.168.1.3" {
+			return fmt.Errorf("unhealthy")
+		}
+		return nil
+	})
+
+	service.checkOrigin(context.Background(), origin, checker)
+
+	if !sameStringSet(replaced, []string{"192.168.1.1", "192.168.1.2"}) {
+		t.Fatalf("expected healthy subset of the priority level, got %v", replaced)
+	}
+}
+
+func TestServiceCheckOrigin_MinHealthyServesDegradedAndEscalates(t *testing.T) {
+	origin := minHealthyTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+	recorder := &recordingNotifier{}
+	service.notifiers = []notifier.Notifier{recorder}
+
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{
+			{ID: "1", Content: "192.168.1.1"},
+			{ID: "2", Content: "192.168.1.2"},
+			{ID: "3", Content: "192.168.1.3"},
+		}, nil
+	}
+	var replaced []string
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		replaced = append([]string{}, newContents...)
+		return nil
+	}
+
+	checker := hcmock.NewCheckerMock(func(ip string) error {
+		if ip == "192.168.1.1" {
+			return nil
+		}
+		return fmt.Errorf("unhealthy")
+	})
+
+	service.checkOrigin(context.Background(), origin, checker)
+	waitForNotifications(t, service)
+
+	if !sameStringSet(replaced, []string{"192.168.1.1", "192.168.1.2"}) {
+		t.Fatalf("expected set padded to min_healthy, got %v", replaced)
+	}
+	if events := recorder.take(); len(events) != 2 {
+		t.Fatalf("expected escalation and change notifications, got %+v", events)
+	}
+	if !service.originStatus["default-example.com-A"].Degraded {
+		t.Error("expected origin to be marked degraded")
+	}
+}
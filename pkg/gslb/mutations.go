@@ -0,0 +1,76 @@
+package gslb
+
+import (
+	"context"
+	"log"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/audit"
+	"github.com/cockroachdb/errors"
+)
+
+// manualChangeKey marks a context as an operator action.
+type manualChangeKey struct{}
+
+// withManualChange marks the DNS changes made with ctx as requested by an
+// operator, described by action.
+func withManualChange(ctx context.Context, action string) context.Context {
+	return context.WithValue(ctx, manualChangeKey{}, action)
+}
+
+// buildMutationLog opens the configured mutation log, or returns nil if it is disabled.
+func buildMutationLog(cfg *config.Config, logger *log.Logger) (*audit.MutationLog, error) {
+	if !cfg.Audit.Enabled() || cfg.Audit.MutationsFile == "" {
+		return nil, nil
+	}
+	mutationLog, err := audit.NewMutationLog(cfg.Audit.MutationsFile)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	logger.Printf("Mutation audit log configured: %s", cfg.Audit.MutationsFile)
+	return mutationLog, nil
+}
+
+// recordMutation writes a change of the records of origin from before to
+// after to the mutation log. Failures are logged, as the log must never
+// affect the change itself.
+func (s *Service) recordMutation(ctx context.Context, origin config.OriginConfig, before, after []string, state, reason string, err error) {
+	if s.mutationLog == nil {
+		return
+	}
+	mutation := audit.Mutation{
+		Time:       s.now(),
+		Actor:      audit.ActorAuto,
+		Instance:   s.config.Audit.EffectiveActor(),
+		Origin:     origin.Name,
+		Zone:       origin.ZoneName,
+		RecordType: origin.RecordType,
+		Before:     before,
+		After:      after,
+		State:      state,
+		Reason:     reason,
+		Result:     audit.ResultSuccess,
+	}
+	if action, ok := ctx.Value(manualChangeKey{}).(string); ok {
+		mutation.Actor = audit.ActorManual
+		mutation.Reason = action
+		if reason != "" {
+			mutation.Reason += ": " + reason
+		}
+	}
+	if err != nil {
+		mutation.Result = audit.ResultError
+		mutation.Error = err.Error()
+	}
+	if writeErr := s.mutationLog.Write(mutation); writeErr != nil {
+		s.logf("Failed to write mutation of %s to the audit log: %v", origin.Name, writeErr)
+	}
+}
+
+// publishedIPs returns the IPs last published for the origin with originKey.
+func (s *Service) publishedIPs(originKey string) []string {
+	status := s.getOrInitOriginStatus(originKey)
+	s.originStatusMutex.RLock()
+	defer s.originStatusMutex.RUnlock()
+	return append([]string(nil), status.CurrentIPs...)
+}
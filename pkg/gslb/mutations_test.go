@@ -0,0 +1,109 @@
+package gslb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/audit"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func readMutations(t *testing.T, path string) []audit.Mutation {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open mutation log: %v", err)
+	}
+	defer file.Close()
+
+	var mutations []audit.Mutation
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var mutation audit.Mutation
+		if err := json.Unmarshal(scanner.Bytes(), &mutation); err != nil {
+			t.Fatalf("line is not a JSON mutation: %v", err)
+		}
+		mutations = append(mutations, mutation)
+	}
+	return mutations
+}
+
+func TestServiceCheckOrigin_RecordsMutation(t *testing.T) {
+	origin := statusTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+	path := filepath.Join(t.TempDir(), "mutations.jsonl")
+	mutationLog, err := audit.NewMutationLog(path)
+	if err != nil {
+		t.Fatalf("NewMutationLog: %v", err)
+	}
+	service.mutationLog = mutationLog
+
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "1", Content: "192.0.2.1"}}, nil
+	}
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		return nil
+	}
+
+	primaryDown := hcmock.NewCheckerMock(func(ip string) error {
+		if ip == "192.0.2.1" {
+			return errors.New("unhealthy")
+		}
+		return nil
+	})
+	service.checkOrigin(context.Background(), origin, primaryDown)
+
+	mutations := readMutations(t, path)
+	if len(mutations) != 1 {
+		t.Fatalf("expected 1 mutation, got %+v", mutations)
+	}
+	mutation := mutations[0]
+	if mutation.Actor != audit.ActorAuto || mutation.Result != audit.ResultSuccess || mutation.State != "failover" ||
+		!sameStringSet(mutation.Before, []string{"192.0.2.1"}) || !sameStringSet(mutation.After, []string{"198.51.100.1"}) {
+		t.Errorf("unexpected mutation %+v", mutation)
+	}
+	if mutation.Origin != origin.Name || mutation.Zone != origin.ZoneName || mutation.Reason == "" {
+		t.Errorf("mutation does not identify the origin and reason: %+v", mutation)
+	}
+}
+
+func TestApplySnapshot_RecordsManualMutation(t *testing.T) {
+	origin := snapshotTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+	path := filepath.Join(t.TempDir(), "mutations.jsonl")
+	mutationLog, err := audit.NewMutationLog(path)
+	if err != nil {
+		t.Fatalf("NewMutationLog: %v", err)
+	}
+	service.mutationLog = mutationLog
+	service.updateOriginStatus(originKeyFor(origin), 100, []string{"192.168.1.1"}, true)
+
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		return errors.New("API down")
+	}
+
+	snapshot := Snapshot{Records: []SnapshotRecord{{Zone: "default", Name: "example.com", Type: "A", IPs: []string{"192.168.1.2"}}}}
+	if err := service.ApplySnapshot(context.Background(), snapshot); err == nil {
+		t.Fatal("expected ApplySnapshot to fail")
+	}
+
+	mutations := readMutations(t, path)
+	if len(mutations) != 1 {
+		t.Fatalf("expected 1 mutation, got %+v", mutations)
+	}
+	mutation := mutations[0]
+	if mutation.Actor != audit.ActorManual || !strings.HasPrefix(mutation.Reason, "snapshot restored") {
+		t.Errorf("expected a manual snapshot mutation, got %+v", mutation)
+	}
+	if mutation.Result != audit.ResultError || mutation.Error != "API down" ||
+		!sameStringSet(mutation.Before, []string{"192.168.1.1"}) || !sameStringSet(mutation.After, []string{"192.168.1.2"}) {
+		t.Errorf("unexpected mutation %+v", mutation)
+	}
+}
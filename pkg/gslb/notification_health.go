@@ -0,0 +1,181 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/metrics"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+// notificationFailingAfter is how many sends in a row must fail before a
+// notification is reported as failing, so that a single network hiccup is not.
+const notificationFailingAfter = 3
+
+// Statuses of a NotificationReport.
+const (
+	// NotificationIdle is a notification that nothing was sent to yet.
+	NotificationIdle = "idle"
+	// NotificationOK is a notification whose last sends succeeded.
+	NotificationOK = "ok"
+	// NotificationFailing is a notification whose last sends all failed.
+	NotificationFailing = "failing"
+)
+
+// NotificationReport is what the service knows about the sends to one
+// notification since it started, as served by the status API.
+type NotificationReport struct {
+	Name                string     `json:"name"`
+	Type                string     `json:"type"`
+	Status              string     `json:"status"`
+	Sent                int        `json:"sent"`
+	Failed              int        `json:"failed"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastDurationMs      int64      `json:"last_duration_ms,omitempty"`
+}
+
+// notificationStats counts the sends to one notifier.
+type notificationStats struct {
+	sent                int
+	failed              int
+	consecutiveFailures int
+	lastSuccess         time.Time
+	lastFailure         time.Time
+	lastError           string
+	lastDuration        time.Duration
+}
+
+// notificationHealth tracks the sends to every notifier. The zero value is
+// ready to use.
+type notificationHealth struct {
+	mu    sync.Mutex
+	stats map[notifier.Notifier]*notificationStats
+}
+
+// record counts a send to n and returns how many sends in a row failed
+// before and after it.
+func (h *notificationHealth) record(n notifier.Notifier, err error, duration time.Duration, at time.Time) (before, after int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stats == nil {
+		h.stats = make(map[notifier.Notifier]*notificationStats)
+	}
+	stats, ok := h.stats[n]
+	if !ok {
+		stats = &notificationStats{}
+		h.stats[n] = stats
+	}
+	before = stats.consecutiveFailures
+	stats.lastDuration = duration
+	if err != nil {
+		stats.failed++
+		stats.consecutiveFailures++
+		stats.lastFailure = at
+		stats.lastError = err.Error()
+	} else {
+		stats.sent++
+		stats.consecutiveFailures = 0
+		stats.lastSuccess = at
+	}
+	return before, stats.consecutiveFailures
+}
+
+// get returns a copy of the stats of n.
+func (h *notificationHealth) get(n notifier.Notifier) (notificationStats, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stats, ok := h.stats[n]
+	if !ok {
+		return notificationStats{}, false
+	}
+	return *stats, true
+}
+
+// notifierName returns the name of n in logs, metrics and the status API.
+func (s *Service) notifierName(n notifier.Notifier) string {
+	if name := s.options[n].name; name != "" {
+		return name
+	}
+	return fmt.Sprintf("%T", n)
+}
+
+// notifierTimeout returns how long a send to n may take.
+func (s *Service) notifierTimeout(n notifier.Notifier) time.Duration {
+	if timeout := s.options[n].timeout; timeout > 0 {
+		return timeout
+	}
+	return config.DefaultNotificationTimeout
+}
+
+// sendWithTimeout calls send with a context bounded by the timeout of n and
+// records the outcome as a send of kind, e.g. the event type or "summary".
+func (s *Service) sendWithTimeout(ctx context.Context, n notifier.Notifier, kind string, send func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, s.notifierTimeout(n))
+	defer cancel()
+	start := time.Now()
+	err := send(ctx)
+	s.recordNotification(n, kind, time.Since(start), err)
+	return err
+}
+
+// recordNotification records the metrics of a send to n, and logs when the
+// notification starts failing persistently and when it works again.
+func (s *Service) recordNotification(n notifier.Notifier, kind string, duration time.Duration, err error) {
+	name := s.notifierName(n)
+	notificationsMetric.Add(1,
+		metrics.String("notifier", fmt.Sprintf("%T", n)),
+		metrics.String("notification", name),
+		metrics.String("event", kind),
+		resultAttribute(err == nil))
+	notificationDurationMetric.Record(float64(duration)/float64(time.Millisecond),
+		metrics.String("notification", name),
+		resultAttribute(err == nil))
+
+	before, after := s.notificationHealth.record(n, err, duration, s.now())
+	notificationFailuresMetric.Set(float64(after), metrics.String("notification", name))
+	switch {
+	case err != nil && after == notificationFailingAfter:
+		s.logf("Notification %s is failing: the last %d sends failed, most recently with: %v", name, after, err)
+	case err == nil && before >= notificationFailingAfter:
+		s.logf("Notification %s works again after %d failed sends", name, before)
+	}
+}
+
+// NotificationReports returns what the service knows about the sends to each
+// notification, in the order of the configuration.
+func (s *Service) NotificationReports() []NotificationReport {
+	reports := make([]NotificationReport, 0, len(s.notifiers))
+	for _, n := range s.notifiers {
+		report := NotificationReport{
+			Name:   s.notifierName(n),
+			Type:   s.options[n].notificationType,
+			Status: NotificationIdle,
+		}
+		stats, ok := s.notificationHealth.get(n)
+		if ok {
+			report.Status = NotificationOK
+			if stats.consecutiveFailures >= notificationFailingAfter {
+				report.Status = NotificationFailing
+			}
+			report.Sent = stats.sent
+			report.Failed = stats.failed
+			report.ConsecutiveFailures = stats.consecutiveFailures
+			report.LastError = stats.lastError
+			report.LastDurationMs = stats.lastDuration.Milliseconds()
+			if !stats.lastSuccess.IsZero() {
+				report.LastSuccess = &stats.lastSuccess
+			}
+			if !stats.lastFailure.IsZero() {
+				report.LastFailure = &stats.lastFailure
+			}
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
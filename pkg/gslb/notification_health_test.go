@@ -0,0 +1,75 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+// blockingNotifier waits for the context of every send to be done.
+type blockingNotifier struct{}
+
+func (blockingNotifier) Notify(ctx context.Context, event notifier.FailoverEvent) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestServiceNotificationReports(t *testing.T) {
+	flaky := &MockNotifier{NotifyError: errors.New("webhook returned status: 500")}
+	idle := &MockNotifier{}
+	service := &Service{
+		notifiers: []notifier.Notifier{flaky, idle},
+		options: map[notifier.Notifier]notifierOptions{
+			flaky: {name: "oncall", notificationType: "slack"},
+			idle:  {name: "notifications[1]", notificationType: "ntfy"},
+		},
+	}
+	event := notifier.FailoverEvent{Type: notifier.EventTypeFailover, OriginName: "www"}
+
+	for i := 1; i <= notificationFailingAfter; i++ {
+		service.notify(context.Background(), flaky, event)
+		report := service.NotificationReports()[0]
+		want := NotificationOK
+		if i == notificationFailingAfter {
+			want = NotificationFailing
+		}
+		if report.Status != want || report.ConsecutiveFailures != i {
+			t.Fatalf("after %d failures: status %q with %d failures, want %q", i, report.Status, report.ConsecutiveFailures, want)
+		}
+	}
+	reports := service.NotificationReports()
+	if reports[0].Name != "oncall" || reports[0].Type != "slack" || reports[0].LastError != "webhook returned status: 500" || reports[0].LastFailure == nil || reports[0].LastSuccess != nil {
+		t.Errorf("Unexpected report of the failing notification %+v", reports[0])
+	}
+	if reports[1].Status != NotificationIdle || reports[1].Sent != 0 {
+		t.Errorf("Unexpected report of the idle notification %+v", reports[1])
+	}
+
+	flaky.NotifyError = nil
+	service.notify(context.Background(), flaky, event)
+	report := service.NotificationReports()[0]
+	if report.Status != NotificationOK || report.ConsecutiveFailures != 0 || report.Sent != 1 || report.Failed != notificationFailingAfter || report.LastSuccess == nil {
+		t.Errorf("Unexpected report after recovering %+v", report)
+	}
+}
+
+func TestServiceNotify_Timeout(t *testing.T) {
+	slow := blockingNotifier{}
+	service := &Service{
+		notifiers: []notifier.Notifier{slow},
+		options:   map[notifier.Notifier]notifierOptions{slow: {name: "slow", timeout: 50 * time.Millisecond}},
+	}
+
+	start := time.Now()
+	service.notify(context.Background(), slow, notifier.FailoverEvent{Type: notifier.EventTypeFailover})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("notify() took %v despite a 50ms timeout", elapsed)
+	}
+	report := service.NotificationReports()[0]
+	if report.Failed != 1 || report.LastError != context.DeadlineExceeded.Error() {
+		t.Errorf("Unexpected report after a timeout %+v", report)
+	}
+}
@@ -0,0 +1,89 @@
+package gslb
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrNotifierUnavailable is reported for a notification whose notifier could not be created
+var ErrNotifierUnavailable = errors.New("notifier could not be created")
+
+// notifyTestOrigin names the origin of the test event, so that it cannot be
+// mistaken for a real one or update the alert of a real origin
+const notifyTestOrigin = "gslb-notify-test"
+
+// NotifyTestResult is the outcome of sending the test event to one notification
+type NotifyTestResult struct {
+	// Index is the position of the notification in the configuration
+	Index int
+	// Name is the name of the notification, or its position if it has none
+	Name     string
+	Type     string
+	Duration time.Duration
+	Err      error
+}
+
+// TestEvent returns the synthetic failover sent by SendTestNotifications,
+// in the first configured zone
+func TestEvent(cfg *config.Config) notifier.FailoverEvent {
+	zone := "example.com"
+	if len(cfg.CloudflareZoneIDs) > 0 && cfg.CloudflareZoneIDs[0].Name != "" {
+		zone = cfg.CloudflareZoneIDs[0].Name
+	}
+	event := notifier.FailoverEvent{
+		Type:         notifier.EventTypeFailover,
+		OriginName:   notifyTestOrigin,
+		ZoneName:     zone,
+		RecordType:   "A",
+		OldIP:        "192.0.2.1",
+		NewIP:        "198.51.100.1",
+		OldIPs:       []string{"192.0.2.1"},
+		NewIPs:       []string{"198.51.100.1"},
+		Reason:       "Test notification sent by gslb notify-test; no DNS records were changed",
+		Timestamp:    time.Now(),
+		IsFailoverIP: true,
+		OldPriority:  100,
+		NewPriority:  50,
+		MaxPriority:  100,
+		Checks: []notifier.CheckDiagnostic{
+			{IP: "192.0.2.1", Priority: 100, CheckType: "https", StatusCode: 503, Latency: 120 * time.Millisecond, Error: "unexpected status code: 503"},
+			{IP: "198.51.100.1", Priority: 50, CheckType: "https", Healthy: true, Latency: 80 * time.Millisecond},
+		},
+		Candidates: []notifier.FailoverCandidate{
+			{Priority: 100, IPs: []string{"192.0.2.1"}},
+			{Priority: 50, IPs: []string{"198.51.100.1"}},
+		},
+		FailoverIndex: 1,
+	}
+	event.Severity = notifier.SeverityFor(event)
+	return event
+}
+
+// SendTestNotifications sends the test event to every configured
+// notification, ignoring their routes, quiet hours and digests, and reports
+// the outcome of each in the order of the configuration
+func SendTestNotifications(ctx context.Context, cfg *config.Config) []NotifyTestResult {
+	event := TestEvent(cfg)
+	results := make([]NotifyTestResult, 0, len(cfg.Notifications))
+	for i, nc := range cfg.Notifications {
+		result := NotifyTestResult{Index: i, Name: nc.EffectiveName(i), Type: nc.Type}
+		n := buildNotifier(cfg, nc, log.Default())
+		if n == nil {
+			result.Err = ErrNotifierUnavailable
+			results = append(results, result)
+			continue
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, nc.Timeout())
+		start := time.Now()
+		result.Err = n.Notify(sendCtx, event)
+		result.Duration = time.Since(start)
+		cancel()
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,59 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+func TestSendTestNotifications(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failing.Close()
+
+	cfg := &config.Config{
+		CloudflareZoneIDs: []config.ZoneConfig{{ZoneID: "zone-1", Name: "example.net"}},
+		Notifications: []config.NotificationConfig{
+			// Routes are ignored, so that every notification is tested
+			{Type: config.NotificationSlack, WebhookURL: ok.URL, Route: &config.NotificationRouteConfig{Events: []string{"recovery"}}},
+			{Type: config.NotificationDiscord, WebhookURL: failing.URL},
+			{Type: config.NotificationMQTT, MQTT: &config.MQTTConfig{
+				Broker: "ssl://127.0.0.1:8883",
+				Topic:  "gslb/events",
+				TLS:    &config.MQTTTLSConfig{CAFile: "/nonexistent/ca.pem"},
+			}},
+		},
+	}
+
+	results := SendTestNotifications(context.Background(), cfg)
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %+v", results)
+	}
+	if results[0].Err != nil || results[0].Type != config.NotificationSlack {
+		t.Errorf("expected slack to succeed, got %+v", results[0])
+	}
+	if results[1].Err == nil || results[1].Index != 1 || results[1].Name != "notifications[1]" {
+		t.Errorf("expected discord to fail, got %+v", results[1])
+	}
+	if !errors.Is(results[2].Err, ErrNotifierUnavailable) {
+		t.Errorf("expected ErrNotifierUnavailable for mqtt, got %v", results[2].Err)
+	}
+}
+
+func TestTestEvent(t *testing.T) {
+	event := TestEvent(&config.Config{CloudflareZoneIDs: []config.ZoneConfig{{ZoneID: "zone-1", Name: "example.net"}}})
+	if event.OriginName != notifyTestOrigin || event.ZoneName != "example.net" || event.Severity != notifier.SeverityWarning {
+		t.Errorf("unexpected test event %+v", event)
+	}
+	if event := TestEvent(&config.Config{}); event.ZoneName != "example.com" {
+		t.Errorf("expected the example zone without zones, got %q", event.ZoneName)
+	}
+}
@@ -0,0 +1,121 @@
+package gslb
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/cloudflare"
+	"github.com/bootjp/cloudflare-gslb/pkg/healthcheck"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+// Option customizes a Service created by NewService, for programs that embed
+// the engine instead of running the gslb daemon.
+type Option func(*serviceOptions)
+
+// DNSClientFactory returns the DNS client that manages the records of origin
+// in zone. The service falls back to the client of the first zone, built
+// with an empty origin, for records without an origin of their own.
+type DNSClientFactory func(ctx context.Context, zone config.ZoneConfig, origin config.OriginConfig) (cloudflare.DNSClientInterface, error)
+
+// CheckerFactory returns the health checker of origin.
+type CheckerFactory func(origin config.OriginConfig) (healthcheck.Checker, error)
+
+// EventHandler is called with every event of a service, in the order they
+// occur, before the notifiers send it. It must not block, since checks wait
+// for it.
+type EventHandler func(ctx context.Context, event notifier.FailoverEvent)
+
+type serviceOptions struct {
+	logger        *log.Logger
+	clock         func() time.Time
+	dnsClients    DNSClientFactory
+	checkers      CheckerFactory
+	eventHandlers []EventHandler
+}
+
+// WithLogger sends the log messages of the service to logger instead of the
+// standard logger. Selection strategies, IP quarantines and a ProbeHub shared
+// between services still log to the standard logger.
+func WithLogger(logger *log.Logger) Option {
+	return func(o *serviceOptions) {
+		o.logger = logger
+	}
+}
+
+// WithClock makes the service tell the time it decides by with now instead
+// of time.Now: schedules, holds, change limits, quarantines, flapping and the
+// timestamps of events and states. Check intervals and timeouts always run
+// on the system clock.
+func WithClock(now func() time.Time) Option {
+	return func(o *serviceOptions) {
+		o.clock = now
+	}
+}
+
+// WithDNSClientFactory makes the service manage the records with the clients
+// factory returns instead of the providers of the zones. The API token check
+// of NewService is skipped, since the clients may not use Cloudflare.
+func WithDNSClientFactory(factory DNSClientFactory) Option {
+	return func(o *serviceOptions) {
+		o.dnsClients = factory
+	}
+}
+
+// WithCheckerFactory makes the service check the origins with the checkers
+// factory returns instead of the checkers of their health_check.
+func WithCheckerFactory(factory CheckerFactory) Option {
+	return func(o *serviceOptions) {
+		o.checkers = factory
+	}
+}
+
+// WithEventHandler calls handler with every event of the service. It can be
+// given more than once.
+func WithEventHandler(handler EventHandler) Option {
+	return func(o *serviceOptions) {
+		o.eventHandlers = append(o.eventHandlers, handler)
+	}
+}
+
+func buildServiceOptions(opts []Option) serviceOptions {
+	options := serviceOptions{logger: log.Default(), clock: time.Now}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// logf logs through the logger of the service. Services built without
+// NewService, as in tests, use the standard logger.
+func (s *Service) logf(format string, v ...any) {
+	if s.logger == nil {
+		log.Printf(format, v...)
+		return
+	}
+	s.logger.Printf(format, v...)
+}
+
+// now returns the time by the clock of the service.
+func (s *Service) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock()
+}
+
+// newChecker returns the health checker of origin.
+func (s *Service) newChecker(origin config.OriginConfig) (healthcheck.Checker, error) {
+	if s.checkers != nil {
+		return s.checkers(origin)
+	}
+	return healthcheck.NewChecker(origin.HealthCheck)
+}
+
+// hasEventReceivers reports whether the events of the service go anywhere:
+// to a notifier or an event handler.
+func (s *Service) hasEventReceivers() bool {
+	return len(s.notifiers) > 0 || len(s.eventHandlers) > 0
+}
@@ -0,0 +1,101 @@
+package gslb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/cloudflare"
+	cfmock "github.com/bootjp/cloudflare-gslb/pkg/cloudflare/mock"
+	"github.com/bootjp/cloudflare-gslb/pkg/healthcheck"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestNewService_Options(t *testing.T) {
+	cfg := &config.Config{
+		CloudflareZoneIDs: []config.ZoneConfig{{ZoneID: "zone-1", Name: "example.com"}},
+		CheckInterval:     time.Minute,
+		Origins: []config.OriginConfig{{
+			Name:       "www.example.com",
+			ZoneName:   "example.com",
+			RecordType: "A",
+			HealthCheck: config.HealthCheck{
+				Type: "icmp",
+			},
+			PriorityLevels: []config.PriorityLevel{
+				{Priority: 100, IPs: []string{"192.0.2.1"}},
+				{Priority: 50, IPs: []string{"192.0.2.2"}},
+			},
+		}},
+	}
+
+	dnsClient := cfmock.NewDNSClientMock()
+	dnsClient.Records["www.example.com-A"] = []dns.RecordResponse{{ID: "record-1", Name: "www.example.com", Type: dns.RecordResponseTypeA, Content: "192.0.2.1"}}
+	var replaced []string
+	dnsClient.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		replaced = newContents
+		return nil
+	}
+	var zones []string
+	dnsClients := func(ctx context.Context, zone config.ZoneConfig, origin config.OriginConfig) (cloudflare.DNSClientInterface, error) {
+		zones = append(zones, zone.Name+"/"+origin.Name)
+		return dnsClient, nil
+	}
+	checkers := func(origin config.OriginConfig) (healthcheck.Checker, error) {
+		return hcmock.NewCheckerMock(func(ip string) error {
+			if ip == "192.0.2.1" {
+				return errors.New("unreachable")
+			}
+			return nil
+		}), nil
+	}
+	var mu sync.Mutex
+	var events []notifier.FailoverEvent
+	handler := func(ctx context.Context, event notifier.FailoverEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var logs bytes.Buffer
+
+	service, err := NewService(cfg,
+		WithDNSClientFactory(dnsClients),
+		WithCheckerFactory(checkers),
+		WithEventHandler(handler),
+		WithClock(func() time.Time { return now }),
+		WithLogger(log.New(&logs, "", 0)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if strings.Join(zones, ",") != "example.com/,example.com/www.example.com" {
+		t.Errorf("Expected a client for the fallback and for the origin, got %v", zones)
+	}
+	if err := service.RunOneShot(context.Background()); err != nil {
+		t.Fatalf("RunOneShot() error = %v", err)
+	}
+
+	if strings.Join(replaced, ",") != "192.0.2.2" {
+		t.Errorf("Expected a failover to 192.0.2.2, got %v", replaced)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) == 0 || events[0].Type != notifier.EventTypeFailover || !events[0].Timestamp.Equal(now) {
+		t.Errorf("Expected a failover event at %s, got %+v", now, events)
+	}
+	if !strings.Contains(logs.String(), "Running one-shot health check") {
+		t.Errorf("Expected the messages in the logger, got %q", logs.String())
+	}
+	if got := service.OriginReports()[0].LastCheck; !got.Equal(now) {
+		t.Errorf("Expected the check at %s, got %s", now, got)
+	}
+}
@@ -0,0 +1,59 @@
+package gslb
+
+import (
+	"context"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/cloudflare"
+	"github.com/cockroachdb/errors"
+)
+
+// credentialZones is the set of Cloudflare zones managed with one set of credentials.
+type credentialZones struct {
+	credentials config.APICredentials
+	zoneNames   []string
+	zoneIDs     []string
+}
+
+// groupZonesByCredentials groups the Cloudflare zones of cfg by the
+// credentials used for them, in configuration order.
+func groupZonesByCredentials(cfg *config.Config) []*credentialZones {
+	var groups []*credentialZones
+	byCredentials := make(map[config.APICredentials]*credentialZones)
+	for _, zone := range cfg.CloudflareZoneIDs {
+		if zone.EffectiveProvider() != config.ProviderCloudflare {
+			continue
+		}
+		credentials := cfg.CredentialsForZone(zone.Name)
+		group, ok := byCredentials[credentials]
+		if !ok {
+			group = &credentialZones{credentials: credentials}
+			byCredentials[credentials] = group
+			groups = append(groups, group)
+		}
+		group.zoneNames = append(group.zoneNames, zone.Name)
+		group.zoneIDs = append(group.zoneIDs, zone.ZoneID)
+	}
+	return groups
+}
+
+// checkPermissions fails if any credentials cannot manage the DNS records of
+// their Cloudflare zones.
+func checkPermissions(ctx context.Context, cfg *config.Config, limiter *cloudflare.RateLimiter) error {
+	for _, group := range groupZonesByCredentials(cfg) {
+		if err := checkGroupPermissions(ctx, cfg, group, limiter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkGroupPermissions fails if the credentials of group cannot manage the
+// DNS records of its zones.
+func checkGroupPermissions(ctx context.Context, cfg *config.Config, group *credentialZones, limiter *cloudflare.RateLimiter) error {
+	checker := cloudflare.NewPermissionChecker(group.credentials.APIToken, cloudflareClientOptions(cfg, group.credentials, limiter)...)
+	if err := checker.CheckZones(ctx, group.zoneIDs); err != nil {
+		return errors.Wrapf(err, "permission check for zones %v failed", group.zoneNames)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package gslb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+)
+
+func TestGroupZonesByCredentials(t *testing.T) {
+	cfg := &config.Config{
+		CloudflareAPIToken: "global-token",
+		CloudflareZoneIDs: []config.ZoneConfig{
+			{ZoneID: "zone-1", Name: "example.com"},
+			{ZoneID: "zone-2", Name: "example.net", APIToken: "scoped-token"},
+			{ZoneID: "zone-3", Name: "example.org"},
+			{ZoneID: "Z123", Name: "example.io", Provider: config.ProviderRoute53},
+		},
+	}
+
+	groups := groupZonesByCredentials(cfg)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 credential groups, got %d", len(groups))
+	}
+	if groups[0].credentials.APIToken != "global-token" || !reflect.DeepEqual(groups[0].zoneIDs, []string{"zone-1", "zone-3"}) {
+		t.Errorf("unexpected first group %+v", groups[0])
+	}
+	if groups[1].credentials.APIToken != "scoped-token" || !reflect.DeepEqual(groups[1].zoneIDs, []string{"zone-2"}) {
+		t.Errorf("unexpected second group %+v", groups[1])
+	}
+}
@@ -0,0 +1,315 @@
+package gslb
+
+import (
+	"fmt"
+	"log"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/healthcheck"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrProberNotRegistered is returned for the results of a prober that did not
+// register, e.g. because the daemon restarted since; it should register again.
+var ErrProberNotRegistered = errors.New("prober not registered")
+
+// RemoteCheck is a health check of an IP of an origin assigned to the remote
+// probers.
+type RemoteCheck struct {
+	Zone        string
+	Name        string
+	RecordType  string
+	IP          string
+	HealthCheck config.HealthCheck
+}
+
+// RemoteResult is the outcome of a RemoteCheck on a remote prober.
+type RemoteResult struct {
+	Zone       string
+	Name       string
+	RecordType string
+	IP         string
+	Healthy    bool
+	Latency    time.Duration
+	Error      string
+}
+
+// ProberInfo describes a registered remote prober.
+type ProberInfo struct {
+	Name         string
+	Region       string
+	RegisteredAt time.Time
+	LastReport   time.Time
+}
+
+// ProbeHub hands the checks of the origins out to the remote probers and
+// collects their results for the service. Like EventFeed, it outlives the
+// services, so that the probers stay registered across configuration
+// reloads.
+type ProbeHub struct {
+	mu       sync.RWMutex
+	checks   []RemoteCheck
+	interval time.Duration
+	probers  map[string]*ProberInfo
+	// results holds the latest result of each prober by origin and IP
+	results map[remoteCheckKey]map[string]remoteVote
+	now     func() time.Time
+}
+
+type remoteCheckKey struct {
+	originKey string
+	ip        string
+}
+
+// remoteVote is the result of one vantage point for an IP.
+type remoteVote struct {
+	prober   string
+	healthy  bool
+	err      string
+	received time.Time
+}
+
+// NewProbeHub returns a hub without checks and probers.
+func NewProbeHub() *ProbeHub {
+	return &ProbeHub{
+		probers: make(map[string]*ProberInfo),
+		results: make(map[remoteCheckKey]map[string]remoteVote),
+		now:     time.Now,
+	}
+}
+
+// SetChecks replaces the checks assigned to the probers, to run every
+// interval. Results of IPs no longer assigned are dropped.
+func (h *ProbeHub) SetChecks(checks []RemoteCheck, interval time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks, h.interval = checks, interval
+	assigned := make(map[remoteCheckKey]bool, len(checks))
+	for _, check := range checks {
+		assigned[check.key()] = true
+	}
+	for key := range h.results {
+		if !assigned[key] {
+			delete(h.results, key)
+		}
+	}
+}
+
+// Register records the prober name in region and returns the checks it
+// should run and their interval. Registering again, e.g. to refresh the
+// assignment, keeps its results.
+func (h *ProbeHub) Register(name, region string) ([]RemoteCheck, time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	prober, ok := h.probers[name]
+	if !ok || prober.Region != region {
+		log.Printf("Remote prober %s registered from %s", name, displayRegion(region))
+		prober = &ProberInfo{Name: name, Region: region, RegisteredAt: h.now()}
+		h.probers[name] = prober
+	}
+	return append([]RemoteCheck(nil), h.checks...), h.interval
+}
+
+// Report stores the results of the prober name. Results of checks that are
+// not assigned are ignored.
+func (h *ProbeHub) Report(name string, results []RemoteResult) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	prober, ok := h.probers[name]
+	if !ok {
+		return errors.Wrapf(ErrProberNotRegistered, "prober %s", name)
+	}
+	now := h.now()
+	prober.LastReport = now
+	assigned := make(map[remoteCheckKey]bool, len(h.checks))
+	for _, check := range h.checks {
+		assigned[check.key()] = true
+	}
+	for _, result := range results {
+		key := remoteCheckKey{
+			originKey: originKeyFor(config.OriginConfig{ZoneName: result.Zone, Name: result.Name, RecordType: result.RecordType}),
+			ip:        result.IP,
+		}
+		if !assigned[key] {
+			continue
+		}
+		if h.results[key] == nil {
+			h.results[key] = make(map[string]remoteVote)
+		}
+		h.results[key][name] = remoteVote{prober: name, healthy: result.Healthy, err: result.Error, received: now}
+	}
+	return nil
+}
+
+// Probers returns the registered probers by name.
+func (h *ProbeHub) Probers() []ProberInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	probers := make([]ProberInfo, 0, len(h.probers))
+	for _, prober := range h.probers {
+		probers = append(probers, *prober)
+	}
+	sort.Slice(probers, func(i, j int) bool { return probers[i].Name < probers[j].Name })
+	return probers
+}
+
+// votes returns the results of ip reported within maxAge, by prober.
+func (h *ProbeHub) votes(originKey, ip string, maxAge time.Duration) []remoteVote {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	byProber := h.results[remoteCheckKey{originKey: originKey, ip: ip}]
+	votes := make([]remoteVote, 0, len(byProber))
+	for _, vote := range byProber {
+		if h.now().Sub(vote.received) <= maxAge {
+			votes = append(votes, vote)
+		}
+	}
+	sort.Slice(votes, func(i, j int) bool { return votes[i].prober < votes[j].prober })
+	return votes
+}
+
+func (c RemoteCheck) key() remoteCheckKey {
+	return remoteCheckKey{
+		originKey: originKeyFor(config.OriginConfig{ZoneName: c.Zone, Name: c.Name, RecordType: c.RecordType}),
+		ip:        c.IP,
+	}
+}
+
+func displayRegion(region string) string {
+	if region == "" {
+		return "an unknown region"
+	}
+	return region
+}
+
+// SetProbeHub assigns the checks of the service to the probers of hub and
+// combines their results with the local checks, as configured by
+// grpc_api.remote_probers. Call it before Start.
+func (s *Service) SetProbeHub(hub *ProbeHub) {
+	s.probeHub = hub
+}
+
+// remoteProbers returns the remote_probers block of the configuration, or
+// nil when the results of the probers are not used.
+func (s *Service) remoteProbers() *config.RemoteProbersConfig {
+	if s.probeHub == nil || !s.config.GRPCAPI.Enabled() {
+		return nil
+	}
+	return s.config.GRPCAPI.RemoteProbers
+}
+
+// assignRemoteChecks hands the checks of every origin to the probers.
+func (s *Service) assignRemoteChecks() {
+	if s.probeHub == nil {
+		return
+	}
+	var checks []RemoteCheck
+	if s.remoteProbers().Enabled() {
+		checks = remoteChecksFor(s.config.Origins)
+	}
+	s.probeHub.SetChecks(checks, s.config.CheckInterval)
+}
+
+// remoteChecksFor returns a check of every IP of origins, in every priority
+// level and IP set. Hostnames and services are resolved by the daemon, which
+// may get other addresses than the probers, so they are only checked locally.
+func remoteChecksFor(origins []config.OriginConfig) []RemoteCheck {
+	var checks []RemoteCheck
+	for _, origin := range origins {
+		levels := origin.EffectivePriorityLevels()
+		for _, name := range origin.IPSetNames() {
+			levels = append(levels, origin.IPSets[name]...)
+		}
+		seen := make(map[string]bool)
+		for _, level := range levels {
+			for _, ip := range level.IPs {
+				if _, err := netip.ParseAddr(ip); err != nil || seen[ip] {
+					continue
+				}
+				seen[ip] = true
+				checks = append(checks, RemoteCheck{
+					Zone:        origin.ZoneName,
+					Name:        origin.Name,
+					RecordType:  origin.RecordType,
+					IP:          ip,
+					HealthCheck: origin.HealthCheck,
+				})
+			}
+		}
+	}
+	return checks
+}
+
+// withRemoteProbers returns a checker that combines checker with the fresh
+// results of the remote probers for the origin.
+func (s *Service) withRemoteProbers(origin config.OriginConfig, checker healthcheck.Checker) healthcheck.Checker {
+	cfg := s.remoteProbers()
+	if !cfg.Enabled() {
+		return checker
+	}
+	return &remoteProbeChecker{
+		local:     checker,
+		hub:       s.probeHub,
+		originKey: originKeyFor(origin),
+		quorum:    cfg.EffectiveQuorum(),
+		useLocal:  cfg.LocalEnabled(),
+		maxAge:    cfg.EffectiveMaxAge(s.config.CheckInterval),
+	}
+}
+
+// remoteProbeChecker decides the health of an IP by the quorum of the fresh
+// results of the remote probers and, unless disabled, the local checker.
+// Without any fresh remote result the local checker decides alone.
+type remoteProbeChecker struct {
+	local     healthcheck.Checker
+	hub       *ProbeHub
+	originKey string
+	quorum    string
+	useLocal  bool
+	maxAge    time.Duration
+}
+
+func (c *remoteProbeChecker) Check(ip string) error {
+	votes := c.hub.votes(c.originKey, ip, c.maxAge)
+	if c.useLocal {
+		vote := remoteVote{prober: "local", healthy: true}
+		if err := c.local.Check(ip); err != nil {
+			vote.healthy, vote.err = false, err.Error()
+		}
+		votes = append(votes, vote)
+	}
+	if len(votes) == 0 {
+		return fmt.Errorf("no remote prober reported %s in the last %s", ip, c.maxAge)
+	}
+
+	healthy := 0
+	var failures []string
+	for _, vote := range votes {
+		if vote.healthy {
+			healthy++
+			continue
+		}
+		failures = append(failures, fmt.Sprintf("%s: %s", vote.prober, vote.err))
+	}
+	if quorumReached(c.quorum, healthy, len(votes)) {
+		return nil
+	}
+	return fmt.Errorf("healthy from %d of %d vantage points (%s): %s", healthy, len(votes), c.quorum, strings.Join(failures, "; "))
+}
+
+// quorumReached reports whether healthy of total results make an IP healthy.
+func quorumReached(quorum string, healthy, total int) bool {
+	switch quorum {
+	case config.RemoteProbersQuorumAny:
+		return healthy > 0
+	case config.RemoteProbersQuorumAll:
+		return healthy == total
+	default:
+		return healthy*2 > total
+	}
+}
@@ -0,0 +1,149 @@
+package gslb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+)
+
+func TestRemoteChecksFor(t *testing.T) {
+	origins := []config.OriginConfig{{
+		Name: "www.example.com", ZoneName: "example.com", RecordType: "A",
+		HealthCheck: config.HealthCheck{Type: "http", Endpoint: "/health"},
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.0.2.1", "origin.example.net"}},
+			{Priority: 0, IPs: []string{"192.0.2.2", "192.0.2.1"}},
+		},
+	}}
+	checks := remoteChecksFor(origins)
+	if len(checks) != 2 || checks[0].IP != "192.0.2.1" || checks[1].IP != "192.0.2.2" {
+		t.Fatalf("Expected a check of each IP but not of hostnames, got %+v", checks)
+	}
+	if checks[0].HealthCheck.Endpoint != "/health" || checks[0].Zone != "example.com" {
+		t.Errorf("Expected the health check of the origin, got %+v", checks[0])
+	}
+}
+
+func TestProbeHub_Report(t *testing.T) {
+	hub := NewProbeHub()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	hub.now = func() time.Time { return now }
+	check := RemoteCheck{Zone: "example.com", Name: "www.example.com", RecordType: "A", IP: "192.0.2.1"}
+	hub.SetChecks([]RemoteCheck{check}, time.Minute)
+	originKey := "example.com-www.example.com-A"
+
+	if err := hub.Report("tokyo", nil); !errors.Is(err, ErrProberNotRegistered) {
+		t.Errorf("Expected ErrProberNotRegistered before registering, got %v", err)
+	}
+	checks, interval := hub.Register("tokyo", "ap-northeast-1")
+	if len(checks) != 1 || checks[0].key() != check.key() || interval != time.Minute {
+		t.Errorf("Register() = %+v, %v", checks, interval)
+	}
+	hub.Register("frankfurt", "eu-central-1")
+	if err := hub.Report("tokyo", []RemoteResult{
+		{Zone: "example.com", Name: "www.example.com", RecordType: "A", IP: "192.0.2.1", Healthy: true},
+		{Zone: "example.com", Name: "www.example.com", RecordType: "A", IP: "203.0.113.9", Healthy: true},
+	}); err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	now = now.Add(30 * time.Second)
+	if err := hub.Report("frankfurt", []RemoteResult{
+		{Zone: "example.com", Name: "www.example.com", RecordType: "A", IP: "192.0.2.1", Error: "timeout"},
+	}); err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+
+	votes := hub.votes(originKey, "192.0.2.1", time.Minute)
+	if len(votes) != 2 || votes[0].prober != "frankfurt" || votes[0].healthy || !votes[1].healthy {
+		t.Errorf("Unexpected votes %+v", votes)
+	}
+	if votes := hub.votes(originKey, "203.0.113.9", time.Minute); len(votes) != 0 {
+		t.Errorf("Expected results of unassigned IPs to be ignored, got %+v", votes)
+	}
+	// The result of tokyo expires first
+	now = now.Add(45 * time.Second)
+	if votes := hub.votes(originKey, "192.0.2.1", time.Minute); len(votes) != 1 || votes[0].prober != "frankfurt" {
+		t.Errorf("Expected only the fresh vote, got %+v", votes)
+	}
+	if probers := hub.Probers(); len(probers) != 2 || probers[1].Name != "tokyo" || probers[1].Region != "ap-northeast-1" {
+		t.Errorf("Unexpected probers %+v", probers)
+	}
+
+	// Unassigned IPs lose their results
+	hub.SetChecks(nil, time.Minute)
+	if votes := hub.votes(originKey, "192.0.2.1", time.Hour); len(votes) != 0 {
+		t.Errorf("Expected the results to be dropped, got %+v", votes)
+	}
+}
+
+func TestRemoteProbeChecker_Quorum(t *testing.T) {
+	hub := NewProbeHub()
+	check := RemoteCheck{Zone: "example.com", Name: "www.example.com", RecordType: "A", IP: "192.0.2.1"}
+	hub.SetChecks([]RemoteCheck{check}, time.Minute)
+	for prober, healthy := range map[string]bool{"tokyo": true, "frankfurt": false} {
+		hub.Register(prober, "")
+		result := RemoteResult{Zone: check.Zone, Name: check.Name, RecordType: check.RecordType, IP: check.IP, Healthy: healthy}
+		if !healthy {
+			result.Error = "timeout"
+		}
+		if err := hub.Report(prober, []RemoteResult{result}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	localUp := hcmock.NewCheckerMock(func(string) error { return nil })
+	localDown := hcmock.NewCheckerMock(func(string) error { return errors.New("connection refused") })
+
+	tests := []struct {
+		name    string
+		local   bool
+		checker *hcmock.CheckerMock
+		quorum  string
+		ip      string
+		healthy bool
+	}{
+		// tokyo and the local check against frankfurt
+		{name: "majority with local up", local: true, checker: localUp, quorum: config.RemoteProbersQuorumMajority, ip: "192.0.2.1", healthy: true},
+		{name: "majority with local down", local: true, checker: localDown, quorum: config.RemoteProbersQuorumMajority, ip: "192.0.2.1", healthy: false},
+		{name: "any", local: true, checker: localDown, quorum: config.RemoteProbersQuorumAny, ip: "192.0.2.1", healthy: true},
+		{name: "all", local: true, checker: localUp, quorum: config.RemoteProbersQuorumAll, ip: "192.0.2.1", healthy: false},
+		// One of two is not a majority
+		{name: "majority without local", checker: localUp, quorum: config.RemoteProbersQuorumMajority, ip: "192.0.2.1", healthy: false},
+		// Without remote results the local check decides
+		{name: "local only", local: true, checker: localUp, quorum: config.RemoteProbersQuorumAll, ip: "192.0.2.2", healthy: true},
+		{name: "no results", checker: localUp, quorum: config.RemoteProbersQuorumAny, ip: "192.0.2.2", healthy: false},
+	}
+	for _, tt := range tests {
+		checker := &remoteProbeChecker{
+			local:     tt.checker,
+			hub:       hub,
+			originKey: "example.com-www.example.com-A",
+			quorum:    tt.quorum,
+			useLocal:  tt.local,
+			maxAge:    time.Minute,
+		}
+		if err := checker.Check(tt.ip); (err == nil) != tt.healthy {
+			t.Errorf("%s: got err=%v, want healthy=%v", tt.name, err, tt.healthy)
+		}
+	}
+}
+
+func TestService_WithRemoteProbers(t *testing.T) {
+	local := hcmock.NewCheckerMock(func(string) error { return nil })
+	origin := config.OriginConfig{Name: "www.example.com", ZoneName: "example.com", RecordType: "A"}
+	service := &Service{config: &config.Config{CheckInterval: time.Minute, GRPCAPI: &config.GRPCAPIConfig{}}}
+	if service.withRemoteProbers(origin, local) != local {
+		t.Error("Expected the local checker without a probe hub")
+	}
+	service.SetProbeHub(NewProbeHub())
+	if service.withRemoteProbers(origin, local) != local {
+		t.Error("Expected the local checker without remote_probers")
+	}
+	service.config.GRPCAPI.RemoteProbers = &config.RemoteProbersConfig{}
+	checker, ok := service.withRemoteProbers(origin, local).(*remoteProbeChecker)
+	if !ok || checker.maxAge != 3*time.Minute || !checker.useLocal || checker.quorum != config.RemoteProbersQuorumMajority {
+		t.Errorf("Unexpected checker %+v", checker)
+	}
+}
@@ -0,0 +1,154 @@
+package gslb
+
+import (
+	"context"
+	"log"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/audit"
+	"github.com/bootjp/cloudflare-gslb/pkg/cloudflare"
+	"github.com/bootjp/cloudflare-gslb/pkg/provider"
+	"github.com/bootjp/cloudflare-gslb/pkg/route53"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrUnknownProvider is returned when a zone references a DNS provider that is neither built in nor registered
+var ErrUnknownProvider = provider.ErrUnknownProvider
+
+// providerOptions carries what a built-in provider needs to build the DNS client for one zone.
+type providerOptions struct {
+	Config  *config.Config
+	Zone    config.ZoneConfig
+	Proxied bool
+	// RateLimiter is the Cloudflare API budget shared by all Cloudflare clients.
+	RateLimiter *cloudflare.RateLimiter
+	// AuditSink receives an event for every Cloudflare API call, if auditing is enabled.
+	AuditSink audit.Sink
+	// RecordBinding limits the client to the origin's bound records, if configured.
+	RecordBinding *config.RecordBindingConfig
+	// Logger receives the warnings about the zone.
+	Logger *log.Logger
+}
+
+type builtinProvider func(ctx context.Context, opts providerOptions) (cloudflare.DNSClientInterface, error)
+
+// builtinProviders take precedence over providers registered with provider.Register.
+var builtinProviders = map[string]builtinProvider{
+	config.ProviderCloudflare: newCloudflareProvider,
+	config.ProviderRoute53:    newRoute53Provider,
+}
+
+// loadProviderPlugins opens every configured provider plugin so that the
+// providers they register can be referenced by zones.
+func loadProviderPlugins(cfg *config.Config, logger *log.Logger) error {
+	for _, path := range cfg.ProviderPlugins {
+		if err := provider.LoadPlugin(path); err != nil {
+			return errors.WithStack(err)
+		}
+		logger.Printf("Loaded DNS provider plugin %s", path)
+	}
+	return nil
+}
+
+// newProviderClient builds the DNS client for zone using the zone's provider.
+func newProviderClient(ctx context.Context, opts providerOptions) (cloudflare.DNSClientInterface, error) {
+	name := opts.Zone.EffectiveProvider()
+	if opts.RecordBinding.Enabled() && name != config.ProviderCloudflare {
+		opts.Logger.Printf("Zone %s uses provider %s, which does not support record_binding; it is ignored", opts.Zone.Name, name)
+	}
+	if builtin, ok := builtinProviders[name]; ok {
+		return builtin(ctx, opts)
+	}
+
+	factory, err := provider.Lookup(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "zone %s (registered providers: %v)", opts.Zone.Name, provider.Names())
+	}
+	p, err := factory(ctx, provider.Options{Zone: opts.Zone, TTL: 60})
+	if err != nil {
+		return nil, errors.Wrapf(err, "zone %s", opts.Zone.Name)
+	}
+
+	caps := p.Capabilities()
+	if opts.Proxied && !caps.Proxy {
+		opts.Logger.Printf("Zone %s uses provider %s, which has no proxy; proxied is ignored", opts.Zone.Name, name)
+	}
+	if !caps.AtomicReplace {
+		opts.Logger.Printf("Zone %s uses provider %s, which replaces records non-atomically", opts.Zone.Name, name)
+	}
+	return &providerClient{provider: p, proxied: opts.Proxied && caps.Proxy}, nil
+}
+
+func newCloudflareProvider(_ context.Context, opts providerOptions) (cloudflare.DNSClientInterface, error) {
+	return newZoneDNSClient(opts)
+}
+
+func newRoute53Provider(ctx context.Context, opts providerOptions) (cloudflare.DNSClientInterface, error) {
+	if opts.Proxied {
+		opts.Logger.Printf("Zone %s uses Route 53, which has no proxy; proxied is ignored", opts.Zone.Name)
+	}
+	return route53.NewDNSClient(ctx, opts.Zone.ZoneID, route53.Options{
+		Region:          opts.Zone.AWSRegion,
+		AccessKeyID:     opts.Zone.AWSAccessKeyID,
+		SecretAccessKey: opts.Zone.AWSSecretAccessKey,
+		TTL:             60,
+	})
+}
+
+// providerClient adapts a provider.Provider to the client interface used by the service.
+type providerClient struct {
+	provider provider.Provider
+	proxied  bool
+}
+
+func (c *providerClient) GetDNSRecords(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+	records, err := c.provider.GetRecords(ctx, name, recordType)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]dns.RecordResponse, 0, len(records))
+	for _, record := range records {
+		responses = append(responses, toRecordResponse(record))
+	}
+	return responses, nil
+}
+
+func (c *providerClient) DeleteDNSRecord(ctx context.Context, recordID string) error {
+	return c.provider.DeleteRecord(ctx, recordID)
+}
+
+func (c *providerClient) CreateDNSRecord(ctx context.Context, name, recordType, content string) (dns.RecordResponse, error) {
+	record, err := c.provider.CreateRecord(ctx, name, recordType, content, c.proxied)
+	if err != nil {
+		return dns.RecordResponse{}, err
+	}
+	return toRecordResponse(record), nil
+}
+
+func (c *providerClient) UpdateDNSRecord(ctx context.Context, recordID, name, recordType, content string) (dns.RecordResponse, error) {
+	record, err := c.provider.UpdateRecord(ctx, recordID, name, recordType, content, c.proxied)
+	if err != nil {
+		return dns.RecordResponse{}, err
+	}
+	return toRecordResponse(record), nil
+}
+
+func (c *providerClient) ReplaceRecords(ctx context.Context, name, recordType string, newContents []string) error {
+	return c.provider.ReplaceRecords(ctx, name, recordType, newContents, c.proxied)
+}
+
+func (c *providerClient) GetZoneID() string {
+	return c.provider.ZoneID()
+}
+
+func toRecordResponse(record provider.Record) dns.RecordResponse {
+	return dns.RecordResponse{
+		ID:      record.ID,
+		Name:    record.Name,
+		Type:    dns.RecordResponseType(record.Type),
+		Content: record.Content,
+		TTL:     dns.TTL(record.TTL),
+		Proxied: record.Proxied,
+	}
+}
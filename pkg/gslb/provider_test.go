@@ -0,0 +1,102 @@
+package gslb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/provider"
+	"github.com/cockroachdb/errors"
+)
+
+// fakeProvider keeps records in memory and reports the given capabilities.
+type fakeProvider struct {
+	zoneID   string
+	caps     provider.Capabilities
+	records  []provider.Record
+	replaced []string
+	proxied  bool
+}
+
+func (f *fakeProvider) GetRecords(ctx context.Context, name, recordType string) ([]provider.Record, error) {
+	return f.records, nil
+}
+
+func (f *fakeProvider) CreateRecord(ctx context.Context, name, recordType, content string, proxied bool) (provider.Record, error) {
+	record := provider.Record{ID: content, Name: name, Type: recordType, Content: content, Proxied: proxied}
+	f.records = append(f.records, record)
+	return record, nil
+}
+
+func (f *fakeProvider) UpdateRecord(ctx context.Context, id, name, recordType, content string, proxied bool) (provider.Record, error) {
+	return provider.Record{ID: id, Name: name, Type: recordType, Content: content, Proxied: proxied}, nil
+}
+
+func (f *fakeProvider) DeleteRecord(ctx context.Context, id string) error {
+	return nil
+}
+
+func (f *fakeProvider) ReplaceRecords(ctx context.Context, name, recordType string, contents []string, proxied bool) error {
+	f.replaced = contents
+	f.proxied = proxied
+	return nil
+}
+
+func (f *fakeProvider) ZoneID() string {
+	return f.zoneID
+}
+
+func (f *fakeProvider) Capabilities() provider.Capabilities {
+	return f.caps
+}
+
+func TestBuildDNSClients_RegisteredProvider(t *testing.T) {
+	fake := &fakeProvider{records: []provider.Record{{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 60}}}
+	provider.Register("fake-test", func(ctx context.Context, opts provider.Options) (provider.Provider, error) {
+		fake.zoneID = opts.Zone.ZoneID
+		return fake, nil
+	})
+
+	cfg := &config.Config{
+		CloudflareZoneIDs: []config.ZoneConfig{{Name: "example.com", ZoneID: "zone", Provider: "fake-test"}},
+		Origins:           []config.OriginConfig{{Name: "www", ZoneName: "example.com", RecordType: "A", Proxied: true}},
+	}
+
+	clients, err := buildDNSClients(context.Background(), cfg, nil, nil, buildServiceOptions(nil))
+	if err != nil {
+		t.Fatalf("buildDNSClients returned error: %v", err)
+	}
+	client := clients[originKeyFor(cfg.Origins[0])]
+	if client.GetZoneID() != "zone" {
+		t.Errorf("expected zone ID from the registered provider, got %q", client.GetZoneID())
+	}
+
+	records, err := client.GetDNSRecords(context.Background(), "www.example.com", "A")
+	if err != nil {
+		t.Fatalf("GetDNSRecords returned error: %v", err)
+	}
+	if ips := collectRecordIPs(records); !sameStringSet(ips, []string{"192.0.2.1"}) {
+		t.Errorf("unexpected records %v", ips)
+	}
+
+	if err := client.ReplaceRecords(context.Background(), "www.example.com", "A", []string{"192.0.2.2"}); err != nil {
+		t.Fatalf("ReplaceRecords returned error: %v", err)
+	}
+	if !sameStringSet(fake.replaced, []string{"192.0.2.2"}) {
+		t.Errorf("unexpected replaced contents %v", fake.replaced)
+	}
+	if fake.proxied {
+		t.Error("expected proxied to be dropped for a provider without proxy support")
+	}
+}
+
+func TestBuildDNSClients_UnknownProvider(t *testing.T) {
+	cfg := &config.Config{
+		CloudflareZoneIDs: []config.ZoneConfig{{Name: "example.com", ZoneID: "zone", Provider: "nope"}},
+		Origins:           []config.OriginConfig{{Name: "www", ZoneName: "example.com", RecordType: "A"}},
+	}
+
+	if _, err := buildDNSClients(context.Background(), cfg, nil, nil, buildServiceOptions(nil)); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("expected ErrUnknownProvider, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package gslb
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+)
+
+// quarantineTracker keeps per-IP failure history and temporarily removes IPs
+// that repeatedly fail shortly after being promoted into the published set.
+type quarantineTracker struct {
+	mu         sync.Mutex
+	promotedAt map[string]time.Time
+	strikes    map[string]int
+	until      map[string]time.Time
+}
+
+func newQuarantineTracker() *quarantineTracker {
+	return &quarantineTracker{
+		promotedAt: make(map[string]time.Time),
+		strikes:    make(map[string]int),
+		until:      make(map[string]time.Time),
+	}
+}
+
+func originIPKey(originKey, ip string) string {
+	return originKey + "|" + ip
+}
+
+// markPromoted records that ips have just been published for the origin.
+func (q *quarantineTracker) markPromoted(originKey string, ips []string, now time.Time) {
+	if q == nil {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, ip := range ips {
+		q.promotedAt[originIPKey(originKey, ip)] = now
+	}
+}
+
+// recordResult updates the failure history of ip and reports whether the IP
+// has just been quarantined.
+func (q *quarantineTracker) recordResult(originKey, ip string, cfg *config.QuarantineConfig, healthy bool, now time.Time) bool {
+	if q == nil || !cfg.Enabled() {
+		return false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	key := originIPKey(originKey, ip)
+	promotedAt, promoted := q.promotedAt[key]
+	if !promoted {
+		return false
+	}
+
+	withinWindow := now.Sub(promotedAt) <= cfg.Window()
+	if healthy {
+		if !withinWindow {
+			// The IP survived the window after promotion, so it is no longer considered flapping
+			delete(q.promotedAt, key)
+			delete(q.strikes, key)
+		}
+		return false
+	}
+
+	delete(q.promotedAt, key)
+	if !withinWindow {
+		delete(q.strikes, key)
+		return false
+	}
+
+	q.strikes[key]++
+	if q.strikes[key] < cfg.FailureThreshold() {
+		return false
+	}
+
+	delete(q.strikes, key)
+	q.until[key] = now.Add(cfg.Duration())
+	return true
+}
+
+// isQuarantined reports whether ip is currently excluded for the origin.
+func (q *quarantineTracker) isQuarantined(originKey, ip string, now time.Time) bool {
+	if q == nil {
+		return false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	key := originIPKey(originKey, ip)
+	until, ok := q.until[key]
+	if !ok {
+		return false
+	}
+	if now.Before(until) {
+		return true
+	}
+
+	delete(q.until, key)
+	log.Printf("IP %s released from quarantine for %s", ip, originKey)
+	return false
+}
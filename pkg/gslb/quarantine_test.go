@@ -0,0 +1,140 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestQuarantineTracker(t *testing.T) {
+	cfg := &config.QuarantineConfig{Failures: 2, WindowSeconds: config.Seconds(time.Minute), DurationSeconds: config.Seconds(10 * time.Minute)}
+	tracker := newQuarantineTracker()
+	now := time.Now()
+
+	// Failures of IPs that were never promoted are ignored
+	if tracker.recordResult("origin", "192.0.2.1", cfg, false, now) {
+		t.Fatal("IP that was never promoted should not be quarantined")
+	}
+
+	tracker.markPromoted("origin", []string{"192.0.2.1"}, now)
+	if tracker.recordResult("origin", "192.0.2.1", cfg, false, now.Add(10*time.Second)) {
+		t.Fatal("first failure should not quarantine the IP")
+	}
+
+	tracker.markPromoted("origin", []string{"192.0.2.1"}, now.Add(20*time.Second))
+	if !tracker.recordResult("origin", "192.0.2.1", cfg, false, now.Add(30*time.Second)) {
+		t.Fatal("second failure after promotion should quarantine the IP")
+	}
+
+	if !tracker.isQuarantined("origin", "192.0.2.1", now.Add(time.Minute)) {
+		t.Error("IP should be quarantined")
+	}
+	if tracker.isQuarantined("other", "192.0.2.1", now.Add(time.Minute)) {
+		t.Error("quarantine should be scoped to the origin")
+	}
+	if tracker.isQuarantined("origin", "192.0.2.1", now.Add(31*time.Second+cfg.Duration())) {
+		t.Error("IP should be released after the quarantine duration")
+	}
+}
+
+func TestQuarantineTracker_SurvivingWindowResetsStrikes(t *testing.T) {
+	cfg := &config.QuarantineConfig{Failures: 2, WindowSeconds: config.Seconds(time.Minute), DurationSeconds: config.Seconds(10 * time.Minute)}
+	tracker := newQuarantineTracker()
+	now := time.Now()
+
+	tracker.markPromoted("origin", []string{"192.0.2.1"}, now)
+	tracker.recordResult("origin", "192.0.2.1", cfg, false, now.Add(10*time.Second))
+
+	tracker.markPromoted("origin", []string{"192.0.2.1"}, now.Add(20*time.Second))
+	tracker.recordResult("origin", "192.0.2.1", cfg, true, now.Add(2*time.Minute))
+
+	tracker.markPromoted("origin", []string{"192.0.2.1"}, now.Add(3*time.Minute))
+	if tracker.recordResult("origin", "192.0.2.1", cfg, false, now.Add(3*time.Minute+10*time.Second)) {
+		t.Error("strikes should reset once the IP stays healthy beyond the window")
+	}
+}
+
+func TestServiceCheckOrigin_QuarantinesFlappingIP(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.168.1.1"}},
+			{Priority: 50, IPs: []string{"192.168.1.2"}},
+		},
+		ReturnToPriority: true,
+		Quarantine:       &config.QuarantineConfig{Failures: 2, WindowSeconds: config.Seconds(5 * time.Minute), DurationSeconds: config.Seconds(10 * time.Minute)},
+	}
+
+	service, dnsClientMock := createTestService(origin)
+	service.quarantine = newQuarantineTracker()
+
+	current := "192.168.1.2"
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "record-1", Name: name, Type: dns.RecordResponseTypeA, Content: current}}, nil
+	}
+	replaceCallCount := 0
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		replaceCallCount++
+		current = newContents[0]
+		return nil
+	}
+
+	healthy := true
+	checker := hcmock.NewCheckerMock(func(ip string) error {
+		if ip == "192.168.1.1" && !healthy {
+			return fmt.Errorf("unhealthy")
+		}
+		return nil
+	})
+
+	// Promote, fail, promote, fail: the second failure quarantines the priority IP
+	for _, h := range []bool{true, false, true, false} {
+		healthy = h
+		service.checkOrigin(context.Background(), origin, checker)
+	}
+	if replaceCallCount != 4 {
+		t.Fatalf("ReplaceRecords was called %d times, expected 4", replaceCallCount)
+	}
+
+	healthy = true
+	service.checkOrigin(context.Background(), origin, checker)
+	if replaceCallCount != 4 {
+		t.Fatalf("quarantined IP was promoted again (ReplaceRecords called %d times)", replaceCallCount)
+	}
+	if current != "192.168.1.2" {
+		t.Errorf("expected fallback IP to stay published, got %s", current)
+	}
+}
+
+func TestServiceCheckOrigin_ObserveModeDoesNotMarkPromotion(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		Mode:       config.OriginModeObserve,
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.168.1.1"}},
+			{Priority: 50, IPs: []string{"192.168.1.2"}},
+		},
+		ReturnToPriority: true,
+		Quarantine:       &config.QuarantineConfig{Failures: 2, WindowSeconds: config.Seconds(5 * time.Minute), DurationSeconds: config.Seconds(10 * time.Minute)},
+	}
+
+	service, dnsClientMock := createTestService(origin)
+	service.quarantine = newQuarantineTracker()
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "record-1", Name: name, Type: dns.RecordResponseTypeA, Content: "192.168.1.2"}}, nil
+	}
+
+	service.checkOrigin(context.Background(), origin, hcmock.NewCheckerMock(func(ip string) error { return nil }))
+	if len(service.quarantine.promotedAt) != 0 {
+		t.Errorf("expected no promotion to be tracked in observe mode, got %v", service.quarantine.promotedAt)
+	}
+}
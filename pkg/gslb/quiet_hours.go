@@ -0,0 +1,166 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+// quietHoursCheckInterval is how often held notifications are checked for
+// delivery. Quiet hours are set in minutes.
+var quietHoursCheckInterval = time.Minute
+
+// notifierOptions name a notifier and narrow down which events it is sent and when.
+type notifierOptions struct {
+	name             string                   // name in logs, metrics and the status API
+	notificationType string                   // type of the notification, e.g. "slack"
+	timeout          time.Duration            // bound of one send, 0 for the default
+	route            *notifier.Route          // nil for all events
+	quietHours       *config.QuietHoursConfig // nil to send events right away
+	digest           *config.DigestConfig     // nil to send events one by one
+}
+
+// quietQueue holds the events of each notifier during its quiet hours. The
+// zero value is ready to use.
+type quietQueue struct {
+	mu     sync.Mutex
+	events map[notifier.Notifier][]notifier.FailoverEvent
+}
+
+func (q *quietQueue) hold(n notifier.Notifier, event notifier.FailoverEvent) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.events == nil {
+		q.events = make(map[notifier.Notifier][]notifier.FailoverEvent)
+	}
+	q.events[n] = append(q.events[n], event)
+}
+
+// take returns the events held for n and forgets them.
+func (q *quietQueue) take(n notifier.Notifier) []notifier.FailoverEvent {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	events := q.events[n]
+	delete(q.events, n)
+	return events
+}
+
+func (q *quietQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	held := 0
+	for _, events := range q.events {
+		held += len(events)
+	}
+	return held
+}
+
+func (s *Service) hasQuietHours() bool {
+	for _, opts := range s.options {
+		if opts.quietHours != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// holdForQuietHours reports whether event is held for n until its quiet hours
+// are over instead of being sent now. Events at or above the bypass severity
+// are never held.
+func (s *Service) holdForQuietHours(n notifier.Notifier, event notifier.FailoverEvent, now time.Time) bool {
+	quiet := s.options[n].quietHours
+	if !quiet.ActiveAt(now) {
+		return false
+	}
+	if bypass, ok := notifier.ParseSeverity(quiet.EffectiveBypassSeverity()); ok && event.Severity >= bypass {
+		return false
+	}
+	s.quiet.hold(n, event)
+	s.logf("Holding %s notification for %s.%s during quiet hours", event.Type, event.OriginName, event.ZoneName)
+	return true
+}
+
+// runQuietHours delivers held notifications once the quiet hours of their
+// notifier are over, until the service stops.
+func (s *Service) runQuietHours(ctx context.Context) {
+	defer s.wg.Done()
+	defer s.recoverPanic(map[string]string{"task": "quiet_hours"})
+
+	ticker := time.NewTicker(quietHoursCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.deliverHeldNotifications(ctx, s.now())
+		}
+	}
+}
+
+// deliverHeldNotifications sends the events held for every notifier whose
+// quiet hours are over at now, one by one or summarized per origin.
+func (s *Service) deliverHeldNotifications(ctx context.Context, now time.Time) {
+	for _, n := range s.notifiers {
+		quiet := s.options[n].quietHours
+		if quiet == nil || quiet.ActiveAt(now) {
+			continue
+		}
+		events := s.quiet.take(n)
+		if len(events) == 0 {
+			continue
+		}
+		if quiet.EffectiveDeliver() == config.QuietDeliverSummary {
+			events = summarizeHeld(events)
+		}
+		s.logf("Quiet hours are over, sending %d held notifications", len(events))
+		for _, event := range events {
+			s.notify(ctx, n, event)
+		}
+	}
+}
+
+// summarizeHeld collapses held events into one per origin: the latest event,
+// changed to span from the IPs before the first one, with a reason that
+// counts the events.
+func summarizeHeld(events []notifier.FailoverEvent) []notifier.FailoverEvent {
+	index := make(map[string]int)
+	var summarized []notifier.FailoverEvent
+	var counts []int
+	for _, event := range events {
+		key := event.ZoneName + "\x00" + event.OriginName + "\x00" + event.RecordType
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(summarized)
+			summarized = append(summarized, event)
+			counts = append(counts, 1)
+			continue
+		}
+		first := summarized[i]
+		event.OldIP, event.OldIPs, event.OldPriority = first.OldIP, first.OldIPs, first.OldPriority
+		summarized[i] = event
+		counts[i]++
+	}
+	for i := range summarized {
+		if counts[i] > 1 {
+			summarized[i].Reason = fmt.Sprintf("%d events during quiet hours, latest: %s", counts[i], summarized[i].Reason)
+		}
+	}
+	return summarized
+}
+
+// dropHeldNotifications logs the notifications still held when the service
+// stops. They are not sent, as that would break the quiet hours.
+func (s *Service) dropHeldNotifications() {
+	for _, n := range s.notifiers {
+		if events := s.quiet.take(n); len(events) > 0 {
+			s.logf("Dropping %d notifications held for quiet hours", len(events))
+		}
+	}
+}
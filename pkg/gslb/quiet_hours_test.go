@@ -0,0 +1,100 @@
+package gslb
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+func quietHoursTestService(deliver, bypass string) (*Service, *recordingNotifier) {
+	recorder := &recordingNotifier{}
+	quiet := &config.QuietHoursConfig{
+		Windows:        []config.QuietWindowConfig{{Start: "01:00", End: "05:00"}},
+		Deliver:        deliver,
+		BypassSeverity: bypass,
+	}
+	service := &Service{
+		config:    &config.Config{},
+		notifiers: []notifier.Notifier{recorder},
+		options:   map[notifier.Notifier]notifierOptions{recorder: {quietHours: quiet}},
+	}
+	return service, recorder
+}
+
+func quietTestEvent(eventType notifier.EventType, oldIP, newIP string, severity notifier.Severity) notifier.FailoverEvent {
+	return notifier.FailoverEvent{
+		Type: eventType, OriginName: "www", ZoneName: "example.com", RecordType: "A",
+		OldIP: oldIP, NewIP: newIP, OldIPs: []string{oldIP}, NewIPs: []string{newIP},
+		Reason: "Health check failed", Severity: severity,
+	}
+}
+
+func TestService_holdForQuietHours(t *testing.T) {
+	service, recorder := quietHoursTestService("", "")
+	night := time.Date(2026, 10, 15, 2, 0, 0, 0, time.UTC)
+	failover := quietTestEvent(notifier.EventTypeFailover, "192.0.2.1", "198.51.100.1", notifier.SeverityWarning)
+
+	if !service.holdForQuietHours(recorder, failover, night) {
+		t.Error("Expected a warning to be held during quiet hours")
+	}
+	if service.holdForQuietHours(recorder, failover, night.Add(4*time.Hour)) {
+		t.Error("Expected nothing to be held after quiet hours")
+	}
+	alert := quietTestEvent(notifier.EventTypeAllIPsDown, "192.0.2.1", "192.0.2.1", notifier.SeverityCritical)
+	if service.holdForQuietHours(recorder, alert, night) {
+		t.Error("Expected a critical event to bypass quiet hours by default")
+	}
+	if service.holdForQuietHours(&recordingNotifier{}, failover, night) {
+		t.Error("Expected nothing to be held for a notifier without quiet hours")
+	}
+	if got := service.Vars().HeldNotifications; got != 1 {
+		t.Errorf("Vars().HeldNotifications = %d, want 1", got)
+	}
+
+	service, recorder = quietHoursTestService("", config.QuietBypassNone)
+	if !service.holdForQuietHours(recorder, alert, night) {
+		t.Error("Expected a critical event to be held with bypass_severity none")
+	}
+}
+
+func TestService_deliverHeldNotifications(t *testing.T) {
+	night := time.Date(2026, 10, 15, 2, 0, 0, 0, time.UTC)
+	events := []notifier.FailoverEvent{
+		quietTestEvent(notifier.EventTypeFailover, "192.0.2.1", "198.51.100.1", notifier.SeverityWarning),
+		quietTestEvent(notifier.EventTypeFailover, "198.51.100.1", "203.0.113.1", notifier.SeverityWarning),
+	}
+
+	service, recorder := quietHoursTestService(config.QuietDeliverQueue, "")
+	for _, event := range events {
+		service.holdForQuietHours(recorder, event, night)
+	}
+	service.deliverHeldNotifications(context.Background(), night)
+	if got := recorder.take(); len(got) != 0 {
+		t.Fatalf("Expected nothing to be sent during quiet hours, got %+v", got)
+	}
+	service.deliverHeldNotifications(context.Background(), night.Add(4*time.Hour))
+	if got := recorder.take(); len(got) != 2 || got[0].NewIP != "198.51.100.1" || got[1].NewIP != "203.0.113.1" {
+		t.Fatalf("Expected both held events in order, got %+v", got)
+	}
+	service.deliverHeldNotifications(context.Background(), night.Add(5*time.Hour))
+	if got := recorder.take(); len(got) != 0 {
+		t.Errorf("Expected held events to be sent once, got %+v", got)
+	}
+
+	service, recorder = quietHoursTestService(config.QuietDeliverSummary, "")
+	for _, event := range events {
+		service.holdForQuietHours(recorder, event, night)
+	}
+	service.deliverHeldNotifications(context.Background(), night.Add(4*time.Hour))
+	got := recorder.take()
+	if len(got) != 1 {
+		t.Fatalf("Expected one summarized event, got %+v", got)
+	}
+	if got[0].OldIP != "192.0.2.1" || got[0].NewIP != "203.0.113.1" || !strings.HasPrefix(got[0].Reason, "2 events during quiet hours") {
+		t.Errorf("Unexpected summarized event %+v", got[0])
+	}
+}
@@ -0,0 +1,52 @@
+package gslb
+
+import "fmt"
+
+// Names of the readiness checks.
+const (
+	ReadinessConfig   = "config"
+	ReadinessToken    = "token"
+	ReadinessMonitors = "monitors"
+)
+
+// ReadinessCheck is one condition the service must meet to be ready.
+type ReadinessCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Readiness reports whether the service is ready: the configuration is
+// loaded, the API token was verified and every origin is being monitored.
+func (s *Service) Readiness() []ReadinessCheck {
+	token := ReadinessCheck{Name: ReadinessToken, OK: true, Detail: "verified"}
+	if s.config.SkipTokenCheck {
+		token.Detail = "skipped by skip_token_check"
+	}
+
+	running := int(s.runningMonitors.Load())
+	monitors := ReadinessCheck{
+		Name:   ReadinessMonitors,
+		OK:     s.started.Load() && running == len(s.config.Origins),
+		Detail: fmt.Sprintf("%d of %d origins monitored", running, len(s.config.Origins)),
+	}
+	if !s.started.Load() {
+		monitors.Detail = "service is not running"
+	}
+
+	return []ReadinessCheck{
+		{Name: ReadinessConfig, OK: true, Detail: fmt.Sprintf("%d origins", len(s.config.Origins))},
+		token,
+		monitors,
+	}
+}
+
+// Ready reports whether every readiness check passes.
+func Ready(checks []ReadinessCheck) bool {
+	for _, check := range checks {
+		if !check.OK {
+			return false
+		}
+	}
+	return true
+}
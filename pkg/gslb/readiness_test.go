@@ -0,0 +1,66 @@
+package gslb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+)
+
+func readinessOf(checks []ReadinessCheck, name string) ReadinessCheck {
+	for _, check := range checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	return ReadinessCheck{}
+}
+
+func TestServiceReadiness_FollowsMonitors(t *testing.T) {
+	service, _ := createTestService(statusTestOrigin())
+	if Ready(service.Readiness()) {
+		t.Fatal("service should not be ready before Start")
+	}
+
+	if err := service.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !Ready(service.Readiness()) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	checks := service.Readiness()
+	if !Ready(checks) {
+		t.Fatalf("service should be ready once every origin is monitored: %+v", checks)
+	}
+	if monitors := readinessOf(checks, ReadinessMonitors); monitors.Detail != "1 of 1 origins monitored" {
+		t.Errorf("unexpected monitors detail %q", monitors.Detail)
+	}
+
+	service.Stop()
+	if checks := service.Readiness(); Ready(checks) || readinessOf(checks, ReadinessMonitors).OK {
+		t.Errorf("service should not be ready after Stop: %+v", checks)
+	}
+}
+
+func TestServiceReadiness_BrokenMonitor(t *testing.T) {
+	origin := statusTestOrigin()
+	origin.HealthCheck = config.HealthCheck{Type: "unknown"}
+	service, _ := createTestService(origin)
+	service.config.SkipTokenCheck = true
+
+	if err := service.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer service.Stop()
+	time.Sleep(50 * time.Millisecond)
+
+	checks := service.Readiness()
+	if Ready(checks) {
+		t.Errorf("service whose monitor failed to start should not be ready: %+v", checks)
+	}
+	if token := readinessOf(checks, ReadinessToken); !token.OK || token.Detail != "skipped by skip_token_check" {
+		t.Errorf("unexpected token check %+v", token)
+	}
+}
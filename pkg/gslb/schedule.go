@@ -0,0 +1,18 @@
+package gslb
+
+import (
+	"github.com/bootjp/cloudflare-gslb/config"
+)
+
+// scheduledTarget publishes the IPs of an active schedule regardless of health
+// checks. The priority is taken from the level the IPs belong to, so that
+// normal management resumes from the right level once the window ends.
+func scheduledTarget(levels []config.PriorityLevel, currentPriority int, schedule config.ScheduleConfig) (int, []string, bool) {
+	if len(schedule.IPs) == 0 {
+		return 0, nil, false
+	}
+	if priority, ok := detectCurrentPriority(levels, schedule.IPs); ok {
+		return priority, schedule.IPs, true
+	}
+	return currentPriority, schedule.IPs, true
+}
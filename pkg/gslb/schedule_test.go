@@ -0,0 +1,63 @@
+package gslb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestServiceCheckOrigin_ScheduledSwitch(t *testing.T) {
+	now := time.Now().UTC()
+	origin := config.OriginConfig{
+		Name:       "example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.168.1.1"}},
+			{Priority: 50, IPs: []string{"192.168.1.2"}},
+		},
+		ReturnToPriority: true,
+		Schedules: []config.ScheduleConfig{{
+			Name:  "maintenance",
+			Start: now.Add(-time.Hour).Format("15:04"),
+			End:   now.Add(time.Hour).Format("15:04"),
+			IPs:   []string{"203.0.113.10"},
+		}},
+	}
+
+	service, dnsClientMock := createTestService(origin)
+
+	current := "192.168.1.1"
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "record-1", Name: name, Type: dns.RecordResponseTypeA, Content: current}}, nil
+	}
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		current = newContents[0]
+		return nil
+	}
+
+	checked := false
+	checker := hcmock.NewCheckerMock(func(ip string) error {
+		checked = true
+		return nil
+	})
+
+	service.checkOrigin(context.Background(), origin, checker)
+	if current != "203.0.113.10" {
+		t.Fatalf("expected scheduled IP to be published, got %s", current)
+	}
+	if checked {
+		t.Error("health checks should not drive selection while a schedule is active")
+	}
+
+	// Once the window is over, health-based management resumes
+	origin.Schedules = nil
+	service.checkOrigin(context.Background(), origin, checker)
+	if current != "192.168.1.1" {
+		t.Errorf("expected return to the priority IP after the schedule, got %s", current)
+	}
+}
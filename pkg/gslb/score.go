@@ -0,0 +1,90 @@
+package gslb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+)
+
+// healthScorer keeps the recent check history of each IP and turns it into a
+// composite health score between 0 and 100.
+type healthScorer struct {
+	mu      sync.Mutex
+	history map[string][]bool
+}
+
+func newHealthScorer() *healthScorer {
+	return &healthScorer{history: make(map[string][]bool)}
+}
+
+// observe records a check result for ip and returns its updated score.
+func (h *healthScorer) observe(originKey, ip string, cfg *config.ScoringConfig, healthy bool, latency time.Duration) float64 {
+	if !cfg.Enabled() {
+		return 0
+	}
+	if h == nil {
+		failureRatio := 0.0
+		if !healthy {
+			failureRatio = 1
+		}
+		return computeScore(cfg, healthy, failureRatio, latency)
+	}
+
+	h.mu.Lock()
+	key := originIPKey(originKey, ip)
+	history := append(h.history[key], healthy)
+	if window := cfg.EffectiveWindow(); len(history) > window {
+		history = history[len(history)-window:]
+	}
+	h.history[key] = history
+	h.mu.Unlock()
+
+	failures := 0
+	for _, ok := range history {
+		if !ok {
+			failures++
+		}
+	}
+	return computeScore(cfg, healthy, float64(failures)/float64(len(history)), latency)
+}
+
+// computeScore combines the current check result, the recent failure ratio
+// and the check latency into a weighted score between 0 and 100.
+func computeScore(cfg *config.ScoringConfig, healthy bool, failureRatio float64, latency time.Duration) float64 {
+	checkWeight, failureWeight, latencyWeight := cfg.Weights()
+	total := checkWeight + failureWeight + latencyWeight
+	if total == 0 {
+		return 0
+	}
+
+	checkScore := 0.0
+	latencyScore := 0.0
+	if healthy {
+		checkScore = 1
+		// A failed check says nothing useful about latency, so it only scores when healthy
+		latencyScore = 1 - float64(latency)/float64(cfg.MaxLatency())
+		if latencyScore < 0 {
+			latencyScore = 0
+		}
+	}
+
+	score := checkWeight*checkScore + failureWeight*(1-failureRatio) + latencyWeight*latencyScore
+	return 100 * score / total
+}
+
+// recordScore stores the latest score of ip in the origin status.
+func (s *Service) recordScore(originKey, ip string, score float64) {
+	s.originStatusMutex.Lock()
+	defer s.originStatusMutex.Unlock()
+
+	status := s.originStatus[originKey]
+	if status == nil {
+		status = &OriginStatus{}
+		s.originStatus[originKey] = status
+	}
+	if status.Scores == nil {
+		status.Scores = make(map[string]float64)
+	}
+	status.Scores[ip] = score
+}
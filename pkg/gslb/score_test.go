@@ -0,0 +1,105 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestComputeScore(t *testing.T) {
+	cfg := &config.ScoringConfig{MaxLatencyMs: config.Milliseconds(time.Second)}
+
+	tests := []struct {
+		name         string
+		healthy      bool
+		failureRatio float64
+		latency      time.Duration
+		want         float64
+	}{
+		{name: "perfect", healthy: true, latency: 0, want: 100},
+		{name: "half latency budget", healthy: true, latency: 500 * time.Millisecond, want: 90},
+		{name: "slow and flapping", healthy: true, failureRatio: 0.5, latency: 2 * time.Second, want: 60},
+		{name: "failed check", healthy: false, failureRatio: 0.1, latency: 10 * time.Millisecond, want: 36},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeScore(cfg, tt.healthy, tt.failureRatio, tt.latency)
+			if math.Abs(got-tt.want) > 0.001 {
+				t.Errorf("computeScore() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthScorer_Window(t *testing.T) {
+	cfg := &config.ScoringConfig{Window: 2, CheckWeight: 0, FailureWeight: 1, LatencyWeight: 0}
+	scorer := newHealthScorer()
+
+	scorer.observe("origin", "192.0.2.1", cfg, false, 0)
+	if got := scorer.observe("origin", "192.0.2.1", cfg, true, 0); got != 50 {
+		t.Errorf("expected score 50 with one failure in window, got %v", got)
+	}
+	if got := scorer.observe("origin", "192.0.2.1", cfg, true, 0); got != 100 {
+		t.Errorf("expected failure to leave the window, got %v", got)
+	}
+}
+
+func TestServiceCheckOrigin_FailsOverOnLowScore(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.168.1.1"}},
+			{Priority: 50, IPs: []string{"192.168.1.2"}},
+		},
+		ReturnToPriority: true,
+		Scoring:          &config.ScoringConfig{Threshold: 85, Window: 4},
+	}
+
+	service, dnsClientMock := createTestService(origin)
+	service.scorer = newHealthScorer()
+
+	current := "192.168.1.1"
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "record-1", Name: name, Type: dns.RecordResponseTypeA, Content: current}}, nil
+	}
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		current = newContents[0]
+		return nil
+	}
+
+	// The priority IP fails every other check: each passing check alone is fine,
+	// but the failure ratio drags the score below the threshold
+	checks := 0
+	checker := hcmock.NewCheckerMock(func(ip string) error {
+		if ip != "192.168.1.1" {
+			return nil
+		}
+		checks++
+		if checks%2 == 1 {
+			return fmt.Errorf("unhealthy")
+		}
+		return nil
+	})
+
+	for i := 0; i < 4; i++ {
+		service.checkOrigin(context.Background(), origin, checker)
+	}
+
+	if current != "192.168.1.2" {
+		t.Errorf("expected failover to 192.168.1.2, got %s", current)
+	}
+
+	status := service.OriginStatuses()[originKeyFor(origin)]
+	if score, ok := status.Scores["192.168.1.1"]; !ok || score >= 85 {
+		t.Errorf("expected low score for 192.168.1.1 in status, got %v (present=%v)", score, ok)
+	}
+}
@@ -1,3 +1,7 @@
+// Package gslb is the failover engine of the daemon: it checks the origins,
+// publishes the IPs of their healthy priority level to DNS and notifies about
+// the changes. Other programs can embed it with NewService and the options
+// that replace its DNS clients, health checkers, logger and clock.
 package gslb
 
 import (
@@ -5,14 +9,23 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"net/netip"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/audit"
 	"github.com/bootjp/cloudflare-gslb/pkg/cloudflare"
 	"github.com/bootjp/cloudflare-gslb/pkg/healthcheck"
+	"github.com/bootjp/cloudflare-gslb/pkg/heartbeat"
+	"github.com/bootjp/cloudflare-gslb/pkg/history"
+	"github.com/bootjp/cloudflare-gslb/pkg/metrics"
+	"github.com/bootjp/cloudflare-gslb/pkg/mqtt"
 	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/bootjp/cloudflare-gslb/pkg/sentry"
+	"github.com/bootjp/cloudflare-gslb/pkg/tracing"
 	"github.com/cloudflare/cloudflare-go/v6/dns"
 	"github.com/cockroachdb/errors"
 )
@@ -30,6 +43,14 @@ type OriginStatus struct {
 	CurrentIPs      []string
 	Initialized     bool
 	LastCheck       time.Time
+	Degraded        bool
+	BlockedIPs      []string // IPs the allowlist refused to publish in the last check
+	Scores          map[string]float64
+	MaxPriority     int
+	LastResult      string          // outcome of the last check, one of the CheckResult constants
+	LastError       string          // error of the last check, if any
+	IPHealth        map[string]bool // result of the last probe of each IP
+	LastFailover    *FailoverRecord
 }
 
 type Service struct {
@@ -37,6 +58,7 @@ type Service struct {
 	dnsClient  cloudflare.DNSClientInterface
 	checkMutex sync.Mutex
 	stopCh     chan struct{}
+	cancel     context.CancelFunc // aborts in-flight API calls on Stop
 	wg         sync.WaitGroup
 
 	dnsClientsMutex sync.RWMutex
@@ -49,6 +71,64 @@ type Service struct {
 	zoneIDMap map[string]string
 
 	notifiers []notifier.Notifier
+	options   map[notifier.Notifier]notifierOptions
+	quiet     quietQueue
+	digests   digestBuffer
+
+	notificationHealth notificationHealth
+	eventFeed          *EventFeed
+	probeHub           *ProbeHub
+
+	activeSetsMutex sync.RWMutex
+	activeSets      map[string]string
+
+	changeLimiter *changeLimiter
+	quarantine    *quarantineTracker
+	scorer        *healthScorer
+	slo           *sloTracker
+	lookup        lookupFunc
+	lookupHost    hostLookupFunc
+	hosts         hostCache
+	services      map[string]serviceResolver
+	consulPools   *consulPools
+	allowlists    map[string][]netip.Prefix
+
+	healthCheckClients map[string]healthStatusSource
+	spectrumClients    map[string]spectrumOrigins
+
+	stateStore  stateStore
+	stateMutex  sync.Mutex
+	savedStates map[string]string
+
+	history      *history.Store
+	mutationLog  *audit.MutationLog
+	summary      summaryTracker
+	availability availabilityTracker
+	alerts       alertTracker
+	flapping     flappingTracker
+	holds        holdTracker
+
+	heartbeat   *heartbeat.Pinger
+	cycles      cycleTracker
+	heartbeatWG sync.WaitGroup
+
+	kubernetesStatus *kubernetesStatusWriter
+
+	// logger, clock, checkers and eventHandlers are set by the options of
+	// NewService
+	logger        *log.Logger
+	clock         func() time.Time
+	checkers      CheckerFactory
+	eventHandlers []EventHandler
+
+	// started is set between Start and Stop; runningMonitors counts the
+	// origins whose monitor loop is running, for Readiness.
+	started         atomic.Bool
+	runningMonitors atomic.Int32
+
+	// startedAt and pendingNotifications are reported by Vars.
+	startedAt            atomic.Pointer[time.Time]
+	pendingNotifications atomic.Int32
 }
 
 func buildZoneMaps(cfg *config.Config) (map[string]string, map[string]string) {
@@ -63,23 +143,37 @@ func buildZoneMaps(cfg *config.Config) (map[string]string, map[string]string) {
 	return zoneMap, zoneIDMap
 }
 
-func buildDNSClients(cfg *config.Config, zoneIDMap map[string]string) (map[string]cloudflare.DNSClientInterface, error) {
+func buildDNSClients(ctx context.Context, cfg *config.Config, limiter *cloudflare.RateLimiter, auditSink audit.Sink, options serviceOptions) (map[string]cloudflare.DNSClientInterface, error) {
 	dnsClients := make(map[string]cloudflare.DNSClientInterface)
 
+	zones := make(map[string]config.ZoneConfig)
+	for _, zone := range cfg.CloudflareZoneIDs {
+		zones[zone.Name] = zone
+	}
+
 	for _, origin := range cfg.Origins {
-		zoneID, exists := zoneIDMap[origin.ZoneName]
+		zone, exists := zones[origin.ZoneName]
 		if !exists {
 			return nil, errors.Newf("zone name %s not found in configuration", origin.ZoneName)
 		}
 
-		originKey := fmt.Sprintf("%s-%s-%s", origin.ZoneName, origin.Name, origin.RecordType)
-
-		client, err := cloudflare.NewDNSClient(
-			cfg.CloudflareAPIToken,
-			zoneID,
-			origin.Proxied,
-			60,
-		)
+		originKey := originKeyFor(origin)
+
+		var client cloudflare.DNSClientInterface
+		var err error
+		if options.dnsClients != nil {
+			client, err = options.dnsClients(ctx, zone, origin)
+		} else {
+			client, err = newProviderClient(ctx, providerOptions{
+				Config:        cfg,
+				Zone:          zone,
+				Proxied:       origin.Proxied,
+				RateLimiter:   limiter,
+				AuditSink:     auditSink,
+				RecordBinding: origin.RecordBinding,
+				Logger:        options.logger,
+			})
+		}
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
@@ -89,46 +183,250 @@ func buildDNSClients(cfg *config.Config, zoneIDMap map[string]string) (map[strin
 	return dnsClients, nil
 }
 
-func buildNotifiers(cfg *config.Config) []notifier.Notifier {
+func newZoneDNSClient(options providerOptions) (*cloudflare.DNSClient, error) {
+	cfg := options.Config
+	credentials := cfg.CredentialsForZone(options.Zone.Name)
+	opts := append(cloudflareClientOptions(cfg, credentials, options.RateLimiter), cloudflare.WithRetryPolicy(retryPolicyFor(cfg.APIRetry)))
+	if cfg.RecordTags {
+		opts = append(opts, cloudflare.WithRecordTags())
+	}
+	if cfg.RecordCacheTTL > 0 {
+		opts = append(opts, cloudflare.WithRecordCache(cfg.RecordCacheTTL))
+	}
+	if options.AuditSink != nil {
+		opts = append(opts, cloudflare.WithAuditSink(options.AuditSink, cfg.Audit.EffectiveActor()))
+	}
+	if binding := options.RecordBinding; binding.Enabled() {
+		opts = append(opts, cloudflare.WithRecordBinding(binding.IDs, binding.Comment))
+	}
+	return cloudflare.NewDNSClient(credentials.APIToken, options.Zone.ZoneID, options.Proxied, 60, opts...)
+}
+
+// cloudflareClientOptions returns the options shared by every Cloudflare API client.
+func cloudflareClientOptions(cfg *config.Config, credentials config.APICredentials, limiter *cloudflare.RateLimiter) []cloudflare.ClientOption {
+	opts := []cloudflare.ClientOption{cloudflare.WithRateLimiter(limiter)}
+	if cfg.APITimeout > 0 {
+		opts = append(opts, cloudflare.WithRequestTimeout(cfg.APITimeout))
+	}
+	if credentials.APIToken == "" && credentials.APIKey != "" {
+		opts = append(opts, cloudflare.WithAPIKey(credentials.APIKey, credentials.APIEmail))
+	}
+	if credentials.APITokenFile != "" {
+		opts = append(opts, cloudflare.WithAPITokenFile(credentials.APITokenFile))
+	}
+	return opts
+}
+
+func retryPolicyFor(cfg config.APIRetryConfig) cloudflare.RetryPolicy {
+	return cloudflare.RetryPolicy{
+		MaxAttempts: cfg.EffectiveMaxAttempts(),
+		BaseDelay:   cfg.BaseDelay(),
+		MaxDelay:    cfg.MaxDelay(),
+	}
+}
+
+// buildAuditSink returns the configured audit sinks, or nil if auditing is disabled.
+func buildAuditSink(cfg *config.Config, logger *log.Logger) (audit.Sink, error) {
+	if !cfg.Audit.Enabled() {
+		return nil, nil
+	}
+
+	var sinks audit.MultiSink
+	if cfg.Audit.File != "" {
+		sink, err := audit.NewFileSink(cfg.Audit.File)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		sinks = append(sinks, sink)
+		logger.Printf("Audit log configured: %s", cfg.Audit.File)
+	}
+	if cfg.Audit.WebhookURL != "" {
+		sinks = append(sinks, audit.NewWebhookSink(cfg.Audit.WebhookURL))
+		logger.Printf("Audit webhook configured")
+	}
+	if len(sinks) == 0 {
+		// Only the mutation log is configured
+		return nil, nil
+	}
+	return sinks, nil
+}
+
+// buildNotifiers returns the configured notifiers and their options: their
+// names and timeouts, and which events they receive and when
+func buildNotifiers(cfg *config.Config, logger *log.Logger) ([]notifier.Notifier, map[notifier.Notifier]notifierOptions) {
 	notifiers := make([]notifier.Notifier, 0)
-	for _, nc := range cfg.Notifications {
-		switch nc.Type {
-		case "slack":
-			notifiers = append(notifiers, notifier.NewSlackNotifier(nc.WebhookURL))
-			log.Printf("Slack notifier configured")
-		case "discord":
-			notifiers = append(notifiers, notifier.NewDiscordNotifier(nc.WebhookURL))
-			log.Printf("Discord notifier configured")
-		default:
-			log.Printf("Unknown notification type: %s", nc.Type)
+	options := make(map[notifier.Notifier]notifierOptions)
+	for i, nc := range cfg.Notifications {
+		n := buildNotifier(cfg, nc, logger)
+		if n == nil {
+			continue
+		}
+		notifiers = append(notifiers, n)
+		opts := notifierOptions{
+			name:             nc.EffectiveName(i),
+			notificationType: nc.Type,
+			timeout:          nc.Timeout(),
+			quietHours:       nc.QuietHours,
+			digest:           nc.Digest,
+		}
+		if nc.Route != nil {
+			route := buildRoute(nc.Route)
+			opts.route = &route
 		}
+		options[n] = opts
 	}
-	return notifiers
+	return notifiers, options
 }
 
-func NewService(cfg *config.Config) (*Service, error) {
+// buildNotifier returns the notifier of nc with its template, or nil if it
+// cannot be created.
+func buildNotifier(cfg *config.Config, nc config.NotificationConfig, logger *log.Logger) notifier.Notifier {
+	var n notifier.Notifier
+	switch nc.Type {
+	case config.NotificationSlack:
+		slack := notifier.NewSlackNotifier(nc.WebhookURL)
+		if nc.BotToken != "" {
+			slack = notifier.NewSlackAPINotifier(nc.EffectiveAPIURL(), nc.BotToken, nc.Channel)
+		}
+		if cfg.SlackActions.Enabled() {
+			slack.EnableActions()
+		}
+		n = slack
+		logger.Printf("Slack notifier configured")
+	case config.NotificationDiscord:
+		discord := notifier.NewDiscordNotifier(nc.WebhookURL)
+		if nc.Discord != nil {
+			severity, _ := notifier.ParseSeverity(nc.Discord.EffectiveMentionSeverity())
+			discord.SetMentions(notifier.DiscordMentions{
+				Roles:       nc.Discord.MentionRoles,
+				Users:       nc.Discord.MentionUsers,
+				Here:        nc.Discord.MentionHere,
+				MinSeverity: severity,
+			})
+			discord.SetEventWebhooks(nc.Discord.Webhooks)
+		}
+		n = discord
+		logger.Printf("Discord notifier configured")
+	case config.NotificationOpsgenie:
+		n = notifier.NewOpsgenieNotifier(nc.EffectiveAPIURL(), nc.APIKey, nc.EffectivePriority(), nc.Tags)
+		logger.Printf("Opsgenie notifier configured")
+	case config.NotificationTelegram:
+		n = notifier.NewTelegramNotifier(nc.EffectiveAPIURL(), nc.BotToken, nc.ChatID)
+		logger.Printf("Telegram notifier configured")
+	case config.NotificationNtfy:
+		n = notifier.NewNtfyNotifier(nc.EffectiveAPIURL(), nc.Topic, nc.Token)
+		logger.Printf("ntfy notifier configured")
+	case config.NotificationPushover:
+		n = notifier.NewPushoverNotifier(nc.EffectiveAPIURL(), nc.Token, nc.UserKey)
+		logger.Printf("Pushover notifier configured")
+	case config.NotificationExec:
+		n = notifier.NewExecNotifier(nc.Exec.Command, nc.Exec.Env, nc.Exec.Timeout(), nc.Exec.EffectiveMaxConcurrent())
+		logger.Printf("Exec notifier configured: %s", nc.Exec.Command[0])
+	case config.NotificationMQTT:
+		publisherConfig := nc.MQTT.PublisherConfig()
+		publisher, err := mqtt.NewPublisher(publisherConfig)
+		if err != nil {
+			logger.Printf("Failed to configure the MQTT notifier: %v", err)
+			return nil
+		}
+		n = notifier.NewMQTTNotifier(publisher, nc.MQTT.Topic, byte(nc.MQTT.QoS), nc.MQTT.Retain)
+		logger.Printf("MQTT notifier configured: %s on %s", nc.MQTT.Topic, publisherConfig.Address)
+	default:
+		logger.Printf("Unknown notification type: %s", nc.Type)
+		return nil
+	}
+	// Templates were checked when the configuration was loaded
+	if tmpl, err := nc.Template.MessageTemplate(); err != nil {
+		logger.Printf("Using the default messages: %v", err)
+	} else if templated, ok := n.(notifier.TemplatedNotifier); ok && tmpl != nil {
+		templated.SetTemplate(tmpl)
+	}
+	if timeoutNotifier, ok := n.(notifier.TimeoutNotifier); ok {
+		timeoutNotifier.SetTimeout(nc.Timeout())
+	}
+	return n
+}
+
+func buildRoute(c *config.NotificationRouteConfig) notifier.Route {
+	severity, _ := notifier.ParseSeverity(c.MinSeverity)
+	return notifier.Route{Zones: c.Zones, Origins: c.Origins, Kinds: c.Events, MinSeverity: severity}
+}
+
+// NewService returns a service that manages the origins of cfg, customized
+// by opts. It does not check anything until Start or RunOneShot is called.
+func NewService(cfg *config.Config, opts ...Option) (*Service, error) {
+	settings := buildServiceOptions(opts)
 	if len(cfg.CloudflareZoneIDs) == 0 {
 		return nil, ErrNoCloudflareZoneConfig
 	}
+	if err := config.ValidateOrigins(cfg); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// All clients share one API token, so they also share one request budget
+	limiter := cloudflare.NewRateLimiter(cfg.APIRateLimit.EffectiveRequestsPerSecond(), cfg.APIRateLimit.EffectiveBurst())
+
+	if err := loadProviderPlugins(cfg, settings.logger); err != nil {
+		return nil, err
+	}
 
-	defaultClient, err := cloudflare.NewDNSClient(
-		cfg.CloudflareAPIToken,
-		cfg.CloudflareZoneIDs[0].ZoneID,
-		false,
-		60,
-	)
+	auditSink, err := buildAuditSink(cfg, settings.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	if !cfg.SkipTokenCheck && settings.dnsClients == nil {
+		if err := checkPermissions(ctx, cfg, limiter); err != nil {
+			return nil, err
+		}
+	}
+
+	var defaultClient cloudflare.DNSClientInterface
+	if settings.dnsClients != nil {
+		defaultClient, err = settings.dnsClients(ctx, cfg.CloudflareZoneIDs[0], config.OriginConfig{})
+	} else {
+		defaultClient, err = newProviderClient(ctx, providerOptions{
+			Config:      cfg,
+			Zone:        cfg.CloudflareZoneIDs[0],
+			RateLimiter: limiter,
+			AuditSink:   auditSink,
+			Logger:      settings.logger,
+		})
+	}
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
+	for _, origin := range cfg.Origins {
+		if _, err := LookupStrategy(origin.Strategy); err != nil {
+			return nil, errors.Wrapf(err, "origin %s", origin.Name)
+		}
+	}
+
+	allowlists, err := buildAllowlists(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	zoneMap, zoneIDMap := buildZoneMaps(cfg)
 
-	dnsClients, err := buildDNSClients(cfg, zoneIDMap)
+	dnsClients, err := buildDNSClients(ctx, cfg, limiter, auditSink, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	notifiers, options := buildNotifiers(cfg, settings.logger)
+
+	eventHistory, err := buildEventHistory(cfg, settings.logger)
 	if err != nil {
 		return nil, err
 	}
 
-	notifiers := buildNotifiers(cfg)
+	mutationLog, err := buildMutationLog(cfg, settings.logger)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Service{
 		config:       cfg,
@@ -139,11 +437,43 @@ func NewService(cfg *config.Config) (*Service, error) {
 		zoneMap:      zoneMap,
 		zoneIDMap:    zoneIDMap,
 		notifiers:    notifiers,
+		options:      options,
+
+		activeSets: make(map[string]string),
+
+		changeLimiter: newChangeLimiter(cfg.ChangeLimit),
+		quarantine:    newQuarantineTracker(),
+		scorer:        newHealthScorer(),
+		slo:           newSLOTracker(),
+		allowlists:    allowlists,
+		services:      buildServiceResolvers(cfg, settings.logger),
+		consulPools:   buildConsulPools(cfg),
+
+		healthCheckClients: buildHealthCheckClients(cfg, limiter, settings.logger),
+		spectrumClients:    buildSpectrumClients(cfg, limiter, settings.logger),
+
+		stateStore:  newStateStore(cfg, limiter),
+		savedStates: make(map[string]string),
+
+		history:     eventHistory,
+		mutationLog: mutationLog,
+		heartbeat:   buildHeartbeat(cfg, settings.logger),
+
+		kubernetesStatus: buildKubernetesStatus(cfg, settings.logger, settings.clock),
+
+		logger:        settings.logger,
+		clock:         settings.clock,
+		checkers:      settings.checkers,
+		eventHandlers: settings.eventHandlers,
 	}, nil
 }
 
+func originKeyFor(origin config.OriginConfig) string {
+	return fmt.Sprintf("%s-%s-%s", origin.ZoneName, origin.Name, origin.RecordType)
+}
+
 func (s *Service) getDNSClientForOrigin(origin config.OriginConfig) cloudflare.DNSClientInterface {
-	originKey := fmt.Sprintf("%s-%s-%s", origin.ZoneName, origin.Name, origin.RecordType)
+	originKey := originKeyFor(origin)
 
 	s.dnsClientsMutex.RLock()
 	client, exists := s.dnsClients[originKey]
@@ -156,38 +486,75 @@ func (s *Service) getDNSClientForOrigin(origin config.OriginConfig) cloudflare.D
 }
 
 func (s *Service) Start(ctx context.Context) error {
-	log.Println("Starting GSLB service...")
+	s.logf("Starting GSLB service...")
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.restoreState(ctx)
+	s.loadAvailability()
+	s.assignRemoteChecks()
+	s.startConsulPools(ctx)
 
 	for _, origin := range s.config.Origins {
 		s.wg.Add(1)
 		go s.monitorOrigin(ctx, origin)
 	}
+	if s.config.Summary.Enabled() {
+		s.summary.reset(s.now())
+		s.wg.Add(1)
+		go s.runSummaries(ctx)
+	}
+	if s.hasQuietHours() {
+		s.wg.Add(1)
+		go s.runQuietHours(ctx)
+	}
+	if s.kubernetesStatus != nil {
+		s.wg.Add(1)
+		go s.runKubernetesStatus(ctx)
+	}
+	startedAt := s.now()
+	s.startedAt.Store(&startedAt)
+	s.started.Store(true)
 
 	return nil
 }
 
 func (s *Service) Stop() {
-	log.Println("Stopping GSLB service...")
+	s.logf("Stopping GSLB service...")
+	s.started.Store(false)
 	close(s.stopCh)
+	if s.cancel != nil {
+		s.cancel()
+	}
 	s.wg.Wait()
-	log.Println("GSLB service stopped")
+	s.heartbeatWG.Wait()
+	s.flushAvailability()
+	s.dropHeldNotifications()
+	s.flushDigests()
+	s.logf("GSLB service stopped")
 }
 
 func (s *Service) monitorOrigin(ctx context.Context, origin config.OriginConfig) {
 	defer s.wg.Done()
+	defer s.recoverPanic(originTags(origin))
 
-	log.Printf("Starting monitoring for origin: %s (%s)", origin.Name, origin.RecordType)
+	s.logf("Starting monitoring for origin: %s (%s)", origin.Name, origin.RecordType)
 
-	checker, err := healthcheck.NewChecker(origin.HealthCheck)
+	checker, err := s.newChecker(origin)
 	if err != nil {
-		log.Printf("Failed to create health checker for %s: %v", origin.Name, err)
+		// The origin is never checked again, so make the failure visible
+		// beyond the log line
+		s.logf("Failed to create health checker for %s: %v", origin.Name, err)
+		s.checkerFailed(ctx, origin, err)
 		return
 	}
 
 	ticker := time.NewTicker(s.config.CheckInterval)
 	defer ticker.Stop()
 
-	originKey := fmt.Sprintf("%s-%s-%s", origin.ZoneName, origin.Name, origin.RecordType)
+	s.runningMonitors.Add(1)
+	defer s.runningMonitors.Add(-1)
+
+	originKey := originKeyFor(origin)
 
 	s.originStatusMutex.Lock()
 	if _, exists := s.originStatus[originKey]; !exists {
@@ -202,8 +569,9 @@ func (s *Service) monitorOrigin(ctx context.Context, origin config.OriginConfig)
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			log.Printf("Running check cycle for origin: %s (%s)", origin.Name, origin.RecordType)
+			s.logf("Running check cycle for origin: %s (%s)", origin.Name, origin.RecordType)
 			s.checkOrigin(ctx, origin, checker)
+			s.persistState(ctx, origin)
 		}
 	}
 }
@@ -212,28 +580,61 @@ func (s *Service) checkOrigin(ctx context.Context, origin config.OriginConfig, c
 	s.checkMutex.Lock()
 	defer s.checkMutex.Unlock()
 
-	log.Printf("Checking origin: %s (%s)", origin.Name, origin.RecordType)
+	s.logf("Checking origin: %s (%s)", origin.Name, origin.RecordType)
+	ctx, span := tracing.Start(ctx, "gslb.check_origin",
+		tracing.String("gslb.origin", origin.Name),
+		tracing.String("gslb.zone", origin.ZoneName),
+		tracing.String("gslb.record_type", origin.RecordType))
+	defer span.End()
+	checksMetric.Add(1, originAttributes(origin)...)
+
+	originKey := originKeyFor(origin)
+	outcome := checkOutcome{result: CheckResultUnchanged}
+	s.settleFlapping(ctx, origin, originKey)
+	ctx, probes := withProbeRecorder(ctx)
+	defer func() {
+		s.recordCheckResult(originKey, outcome)
+		s.recordAvailability(origin, originKey, outcome.result)
+	}()
 
-	priorityLevels := origin.EffectivePriorityLevels()
-	if len(priorityLevels) == 0 {
-		log.Printf("No priority levels configured for %s", origin.Name)
+	if !origin.HasIPSets() && len(origin.EffectivePriorityLevels()) == 0 {
+		s.logf("No priority levels configured for %s", origin.Name)
+		outcome.result = CheckResultNoLevels
 		return
 	}
-	priorityLevels = sortPriorityLevels(priorityLevels)
-	maxPriority := priorityLevels[0].Priority
+	origin = s.resolveOriginHosts(ctx, origin)
 
 	dnsClient := s.getDNSClientForOrigin(origin)
 
 	records, err := dnsClient.GetDNSRecords(ctx, origin.Name, origin.RecordType)
 	if err != nil {
-		log.Printf("Failed to get DNS records for %s: %v", origin.Name, err)
+		s.logf("Failed to get DNS records for %s: %v", origin.Name, err)
+		span.RecordError(err)
+		reportError("dns_records", origin, err)
+		s.raiseAlert(ctx, notifier.EventTypeAPIFailure, origin, originKey, nil, nil,
+			fmt.Sprintf("Failed to read the DNS records: %v", err))
+		outcome = checkOutcome{result: CheckResultRecordsFailed, err: err}
 		return
 	}
 
-	originKey := fmt.Sprintf("%s-%s-%s", origin.ZoneName, origin.Name, origin.RecordType)
 	status := s.getOrInitOriginStatus(originKey)
 
 	currentIPs := collectRecordIPs(records)
+	if origin.IsObserveOnly() && status.Initialized {
+		// DNS is never changed in observe mode, so compare against the state we would have published
+		currentIPs = status.CurrentIPs
+	}
+
+	setName, priorityLevels := s.activePriorityLevels(origin, originKey, currentIPs)
+	if len(priorityLevels) == 0 {
+		s.logf("No priority levels configured for %s (IP set %q)", origin.Name, setName)
+		outcome.result = CheckResultNoLevels
+		return
+	}
+	priorityLevels = sortPriorityLevels(priorityLevels)
+	probes.setCandidates(priorityLevels)
+	maxPriority := priorityLevels[0].Priority
+	outcome.maxPriority = maxPriority
 
 	currentPriority := status.CurrentPriority
 	currentPrioritySet := status.Initialized
@@ -248,37 +649,175 @@ func (s *Service) checkOrigin(ctx context.Context, origin config.OriginConfig, c
 		currentPrioritySet = true
 	}
 
-	selectedPriority, selectedIPs, ok := s.selectPriorityLevel(origin, checker, priorityLevels, currentPriority, currentPrioritySet)
+	schedule, scheduled := origin.ActiveSchedule(s.now())
+
+	var selectedPriority int
+	var selectedIPs []string
+	var ok bool
+	forcedPriority, forcedIPs, forced := forcedTarget(ctx, priorityLevels)
+	if forced {
+		s.logf("Publishing priority level %d of %s as forced", forcedPriority, origin.Name)
+		selectedPriority, selectedIPs, ok = forcedPriority, forcedIPs, true
+	} else if scheduled {
+		s.logf("Schedule %s is active for %s, publishing %v", schedule.DisplayName(), origin.Name, schedule.IPs)
+		selectedPriority, selectedIPs, ok = scheduledTarget(priorityLevels, currentPriority, schedule)
+	} else {
+		checker = s.withCloudflareHealth(ctx, origin, s.withRemoteProbers(origin, checker))
+		selectedPriority, selectedIPs, ok = s.selectTargetIPs(ctx, origin, checker, originKey, priorityLevels, currentPriority, currentPrioritySet, currentIPs)
+	}
+	span.SetAttributes(tracing.Strings("gslb.current_ips", currentIPs), tracing.Int("gslb.current_priority", currentPriority))
 	if !ok {
-		log.Printf("No healthy IPs available for %s", origin.Name)
+		s.logf("No healthy IPs available for %s", origin.Name)
+		span.SetAttributes(tracing.Bool("gslb.no_healthy_ips", true))
+		outcome.result = CheckResultNoHealthyIPs
 		s.updateOriginStatus(originKey, currentPriority, currentIPs, currentPrioritySet)
+		s.raiseAlert(ctx, notifier.EventTypeAllIPsDown, origin, originKey, currentIPs, currentIPs,
+			"No IP of any priority level is healthy; the DNS records are left unchanged")
+		s.escalateAllIPsDown(ctx, origin, originKey, currentIPs)
 		return
 	}
+	s.alerts.clear(originKey, notifier.EventTypeAllIPsDown)
+	probes.selectPriority(selectedPriority)
 
 	selectedIPs = s.filterValidIPs(origin.RecordType, selectedIPs)
 	if len(selectedIPs) == 0 {
-		log.Printf("No valid IPs available for %s (%s)", origin.Name, origin.RecordType)
+		s.logf("No valid IPs available for %s (%s)", origin.Name, origin.RecordType)
+		outcome.result = CheckResultNoValidIPs
+		s.updateOriginStatus(originKey, currentPriority, currentIPs, currentPrioritySet)
+		return
+	}
+	if !s.guardAllowlist(ctx, origin, originKey, currentIPs, selectedIPs) {
+		outcome.result = CheckResultBlocked
 		s.updateOriginStatus(originKey, currentPriority, currentIPs, currentPrioritySet)
 		return
 	}
 
+	span.SetAttributes(tracing.Strings("gslb.selected_ips", selectedIPs), tracing.Int("gslb.selected_priority", selectedPriority))
 	if sameIPSet(currentIPs, selectedIPs) {
+		// The records were read and need no change, so the API works again
+		s.alerts.clear(originKey, notifier.EventTypeAPIFailure)
 		s.updateOriginStatus(originKey, selectedPriority, selectedIPs, true)
+		recordPublished(origin, selectedPriority, selectedIPs)
+		s.syncSpectrum(ctx, origin, selectedIPs)
 		return
 	}
 
-	if err := dnsClient.ReplaceRecords(ctx, origin.Name, origin.RecordType, selectedIPs); err != nil {
-		log.Printf("Failed to update DNS records for %s: %v", origin.Name, err)
+	reason := buildChangeReason(currentPrioritySet, currentPriority, selectedPriority, currentIPs, selectedIPs)
+	if forced {
+		reason = fmt.Sprintf("Priority level %d was forced by an operator", selectedPriority)
+	} else if scheduled {
+		reason = fmt.Sprintf("Scheduled switch %s is active", schedule.DisplayName())
+	}
+
+	if hold, held := s.holds.get(originKey); held && !origin.IsObserveOnly() && ctx.Value(manualChangeKey{}) == nil {
+		s.logf("Not updating DNS records for %s from %v to %v: held by %s", origin.Name, currentIPs, selectedIPs, hold.By)
+		outcome.result = CheckResultHeld
+		s.updateOriginStatus(originKey, currentPriority, currentIPs, currentPrioritySet)
+		return
+	}
+	if origin.IsObserveOnly() {
+		s.logf("Observe mode: would update DNS records for %s from %v to %v", origin.Name, currentIPs, selectedIPs)
+	} else if !s.applyDNSChange(ctx, dnsClient, origin, originKey, currentIPs, selectedIPs, recordState(scheduled, selectedPriority, maxPriority), reason) {
+		outcome.result = CheckResultNotApplied
+		s.updateOriginStatus(originKey, currentPriority, currentIPs, currentPrioritySet)
 		return
 	}
 
+	span.SetAttributes(tracing.Bool("gslb.changed", true))
 	s.updateOriginStatus(originKey, selectedPriority, selectedIPs, true)
+	recordPublished(origin, selectedPriority, selectedIPs)
+	s.syncSpectrum(ctx, origin, selectedIPs)
+	// Nothing was published in observe mode, so no IP was promoted
+	if origin.Quarantine.Enabled() && !origin.IsObserveOnly() {
+		s.quarantine.markPromoted(originKey, addedIPs(currentIPs, selectedIPs), s.now())
+	}
 
 	isPriorityIP := selectedPriority == maxPriority
 	isFailoverIP := selectedPriority < maxPriority
-	reason := buildChangeReason(currentPrioritySet, currentPriority, selectedPriority, currentIPs, selectedIPs)
 
-	s.sendNotifications(origin, currentIPs, selectedIPs, reason, isPriorityIP, isFailoverIP, currentPriority, selectedPriority, maxPriority)
+	outcome.result = CheckResultChanged
+	if origin.IsObserveOnly() {
+		outcome.result = CheckResultObserved
+	}
+	s.recordFailover(originKey, FailoverRecord{
+		Time:        s.now(),
+		OldIPs:      currentIPs,
+		NewIPs:      selectedIPs,
+		OldPriority: currentPriority,
+		NewPriority: selectedPriority,
+		Reason:      reason,
+		ObserveOnly: origin.IsObserveOnly(),
+	})
+	if !origin.IsObserveOnly() {
+		s.summary.recordFailover(originLabel(origin))
+	}
+	if !s.trackFlapping(ctx, origin, originKey, currentIPs, selectedIPs) {
+		s.sendNotifications(ctx, origin, currentIPs, selectedIPs, reason, isPriorityIP, isFailoverIP, currentPriority, selectedPriority, maxPriority)
+	}
+}
+
+// recordState is the state written to record comments and tags.
+func recordState(scheduled bool, selectedPriority, maxPriority int) string {
+	switch {
+	case scheduled:
+		return "scheduled"
+	case selectedPriority == maxPriority:
+		return "primary"
+	default:
+		return "failover"
+	}
+}
+
+// applyDNSChange publishes selectedIPs for the origin and reports whether the
+// records were changed. reason explains the change in the mutation log.
+func (s *Service) applyDNSChange(ctx context.Context, dnsClient cloudflare.DNSClientInterface, origin config.OriginConfig, originKey string, currentIPs, selectedIPs []string, state, reason string) bool {
+	ctx, span := tracing.Start(ctx, "gslb.apply_dns_change",
+		tracing.Strings("gslb.old_ips", currentIPs),
+		tracing.Strings("gslb.new_ips", selectedIPs),
+		tracing.String("gslb.state", state))
+	defer span.End()
+
+	if allowed, limitReason, firstBlock := s.changeLimiter.allow(originKey, origin.ChangeLimit, s.now()); !allowed {
+		s.logf("Skipping DNS update for %s: %s", origin.Name, limitReason)
+		span.SetAttributes(tracing.String("gslb.skipped", limitReason))
+		dnsChangesMetric.Add(1, originAttributes(origin, metrics.String("state", state), metrics.String("result", "skipped"))...)
+		s.recordDNSChangeEvent(origin, currentIPs, selectedIPs, state, history.ResultSkipped, limitReason, nil)
+		if firstBlock {
+			s.sendAlert(ctx, notifier.EventTypeChangeLimitExceeded, origin, currentIPs, selectedIPs, limitReason)
+		}
+		return false
+	}
+
+	ctx = cloudflare.WithRecordMetadata(ctx, cloudflare.RecordMetadata{State: state, Since: s.now()})
+	err := dnsClient.ReplaceRecords(ctx, origin.Name, origin.RecordType, selectedIPs)
+	dnsChangesMetric.Add(1, originAttributes(origin, metrics.String("state", state), resultAttribute(err == nil))...)
+	s.recordDNSChangeEvent(origin, currentIPs, selectedIPs, state, resultOf(err), "", err)
+	s.recordMutation(ctx, origin, currentIPs, selectedIPs, state, reason, err)
+	if err != nil {
+		s.logf("Failed to update DNS records for %s: %v", origin.Name, err)
+		span.RecordError(err)
+		reportError("dns_update", origin, err)
+		s.raiseAlert(ctx, notifier.EventTypeAPIFailure, origin, originKey, currentIPs, selectedIPs,
+			fmt.Sprintf("Failed to update the DNS records: %v", err))
+		return false
+	}
+	s.alerts.clear(originKey, notifier.EventTypeAPIFailure)
+
+	s.changeLimiter.record(originKey, origin.ChangeLimit, s.now())
+
+	if origin.Verify != nil {
+		verifyCtx, verifySpan := tracing.Start(ctx, "gslb.verify_dns_change")
+		err := s.verifyDNSChange(verifyCtx, dnsClient, origin, selectedIPs)
+		verifySpan.RecordError(err)
+		verifySpan.End()
+		if err != nil {
+			s.logf("Failed to verify DNS records for %s: %v", origin.Name, err)
+			reportError("dns_verify", origin, err)
+			s.sendAlert(ctx, notifier.EventTypeVerificationFailed, origin, currentIPs, selectedIPs,
+				fmt.Sprintf("DNS change could not be verified: %v", err))
+		}
+	}
+	return true
 }
 
 func (s *Service) getOrInitOriginStatus(originKey string) *OriginStatus {
@@ -295,11 +834,38 @@ func (s *Service) getOrInitOriginStatus(originKey string) *OriginStatus {
 	return status
 }
 
-func (s *Service) selectPriorityLevel(origin config.OriginConfig, checker healthcheck.Checker, levels []config.PriorityLevel, currentPriority int, currentPrioritySet bool) (int, []string, bool) {
+func (s *Service) selectTargetIPs(ctx context.Context, origin config.OriginConfig, checker healthcheck.Checker, originKey string, levels []config.PriorityLevel, currentPriority int, currentPrioritySet bool, currentIPs []string) (int, []string, bool) {
+	if origin.MinHealthy > 0 {
+		return s.selectWithMinHealthy(ctx, origin, checker, originKey, levels, currentPriority, currentPrioritySet, currentIPs)
+	}
+
+	strategy, err := LookupStrategy(origin.Strategy)
+	if err != nil {
+		s.logf("Invalid strategy for %s: %v", origin.Name, err)
+		return 0, nil, false
+	}
+
+	selection, ok := strategy.Select(SelectionRequest{
+		Origin:             origin,
+		Levels:             levels,
+		CurrentIPs:         currentIPs,
+		CurrentPriority:    currentPriority,
+		CurrentPrioritySet: currentPrioritySet,
+		Probe: func(ip string, priority int) ProbeResult {
+			return s.probeIP(ctx, origin, originKey, checker, ip, priority)
+		},
+	})
+	return selection.Priority, selection.IPs, ok
+}
+
+// levelEvaluator reports the IPs to publish for a priority level and whether the level is usable.
+type levelEvaluator func(level config.PriorityLevel) ([]string, bool)
+
+func selectPriorityLevel(origin config.OriginConfig, evaluate levelEvaluator, levels []config.PriorityLevel, currentPriority int, currentPrioritySet bool) (int, []string, bool) {
 	if !origin.ReturnToPriority && currentPrioritySet {
 		if level, ok := findPriorityLevel(levels, currentPriority); ok {
-			if s.checkPriorityLevel(origin.RecordType, checker, level) {
-				return currentPriority, level.IPs, true
+			if ips, ok := evaluate(level); ok {
+				return currentPriority, ips, true
 			}
 		}
 	}
@@ -309,40 +875,67 @@ func (s *Service) selectPriorityLevel(origin config.OriginConfig, checker health
 			continue
 		}
 
-		if s.checkPriorityLevel(origin.RecordType, checker, level) {
-			return level.Priority, level.IPs, true
+		if ips, ok := evaluate(level); ok {
+			return level.Priority, ips, true
 		}
 	}
 
 	return 0, nil, false
 }
 
-func (s *Service) checkPriorityLevel(recordType string, checker healthcheck.Checker, level config.PriorityLevel) bool {
-	log.Printf("Checking priority level %d (%d IPs)", level.Priority, len(level.IPs))
+func (s *Service) checkIP(ctx context.Context, origin config.OriginConfig, originKey string, checker healthcheck.Checker, ip string, priority int) bool {
+	return s.probeIP(ctx, origin, originKey, checker, ip, priority).Healthy
+}
 
-	if len(level.IPs) == 0 {
-		return false
+func (s *Service) probeIP(ctx context.Context, origin config.OriginConfig, originKey string, checker healthcheck.Checker, ip string, priority int) ProbeResult {
+	if err := s.validateIPType(origin.RecordType, ip); err != nil {
+		s.logf("Invalid IP %s for record type %s: %v", ip, origin.RecordType, err)
+		return ProbeResult{}
 	}
-
-	for _, ip := range level.IPs {
-		if err := s.validateIPType(recordType, ip); err != nil {
-			log.Printf("Invalid IP %s for record type %s: %v", ip, recordType, err)
-			return false
-		}
-		if err := checker.Check(ip); err != nil {
-			log.Printf("IP %s at priority %d is unhealthy: %v", ip, level.Priority, err)
-			return false
+	if s.quarantine.isQuarantined(originKey, ip, s.now()) {
+		s.logf("IP %s at priority %d is quarantined", ip, priority)
+		return ProbeResult{}
+	}
+	_, span := tracing.Start(ctx, "gslb.probe", tracing.String("gslb.ip", ip), tracing.Int("gslb.priority", priority))
+	defer span.End()
+
+	start := time.Now()
+	err := checker.Check(ip)
+	result := ProbeResult{Healthy: err == nil, Latency: time.Since(start)}
+	probeDurationMetric.Record(float64(result.Latency)/float64(time.Millisecond), originAttributes(origin, metrics.String("ip", ip))...)
+	s.recordLatencySLO(origin, originKey, ip, result.Healthy, result.Latency)
+
+	if origin.Scoring.Enabled() {
+		result.Score = s.scorer.observe(originKey, ip, origin.Scoring, result.Healthy, result.Latency)
+		s.recordScore(originKey, ip, result.Score)
+		if result.Healthy && result.Score < origin.Scoring.EffectiveThreshold() {
+			result.Healthy = false
+			err = fmt.Errorf("health score %.1f is below threshold %.1f", result.Score, origin.Scoring.EffectiveThreshold())
 		}
 	}
 
-	return true
+	if s.quarantine.recordResult(originKey, ip, origin.Quarantine, result.Healthy, s.now()) {
+		s.logf("IP %s for %s repeatedly failed after promotion, quarantined for %s", ip, origin.Name, origin.Quarantine.Duration())
+	}
+	if err != nil {
+		s.logf("IP %s at priority %d is unhealthy: %v", ip, priority, err)
+		span.RecordError(err)
+	}
+	probeRecorderFrom(ctx).record(origin, ip, priority, result.Healthy, result.Latency, err)
+	span.SetAttributes(tracing.Bool("gslb.healthy", result.Healthy), tracing.Milliseconds("gslb.latency_ms", result.Latency))
+	s.recordHealthEvent(origin, ip, result.Healthy, s.recordIPHealth(originKey, ip, result.Healthy))
+	probesMetric.Add(1, originAttributes(origin, metrics.String("ip", ip), healthyAttribute(result.Healthy))...)
+	if origin.Scoring.Enabled() {
+		span.SetAttributes(tracing.Float64("gslb.score", result.Score))
+	}
+	return result
 }
 
 func (s *Service) filterValidIPs(recordType string, ips []string) []string {
 	valid := make([]string, 0, len(ips))
 	for _, ip := range ips {
 		if err := s.validateIPType(recordType, ip); err != nil {
-			log.Printf("Invalid IP %s for record type %s: %v", ip, recordType, err)
+			s.logf("Invalid IP %s for record type %s: %v", ip, recordType, err)
 			continue
 		}
 		valid = append(valid, ip)
@@ -365,7 +958,38 @@ func (s *Service) updateOriginStatus(originKey string, priority int, ips []strin
 		status.Initialized = true
 	}
 	status.CurrentIPs = ips
-	status.LastCheck = time.Now()
+	status.LastCheck = s.now()
+}
+
+// OriginStatuses returns a snapshot of the status of every origin keyed by zone, name and record type.
+func (s *Service) OriginStatuses() map[string]OriginStatus {
+	s.originStatusMutex.RLock()
+	defer s.originStatusMutex.RUnlock()
+
+	statuses := make(map[string]OriginStatus, len(s.originStatus))
+	for key, status := range s.originStatus {
+		snapshot := *status
+		snapshot.CurrentIPs = append([]string(nil), status.CurrentIPs...)
+		snapshot.BlockedIPs = append([]string(nil), status.BlockedIPs...)
+		if status.IPHealth != nil {
+			snapshot.IPHealth = make(map[string]bool, len(status.IPHealth))
+			for ip, healthy := range status.IPHealth {
+				snapshot.IPHealth[ip] = healthy
+			}
+		}
+		if status.LastFailover != nil {
+			failover := *status.LastFailover
+			snapshot.LastFailover = &failover
+		}
+		if status.Scores != nil {
+			snapshot.Scores = make(map[string]float64, len(status.Scores))
+			for ip, score := range status.Scores {
+				snapshot.Scores[ip] = score
+			}
+		}
+		statuses[key] = snapshot
+	}
+	return statuses
 }
 
 func sortPriorityLevels(levels []config.PriorityLevel) []config.PriorityLevel {
@@ -445,6 +1069,17 @@ func collectRecordIPs(records []dns.RecordResponse) []string {
 	return ips
 }
 
+func addedIPs(oldIPs, newIPs []string) []string {
+	oldSet := sliceToSet(oldIPs)
+	added := make([]string, 0, len(newIPs))
+	for _, ip := range newIPs {
+		if _, ok := oldSet[ip]; !ok {
+			added = append(added, ip)
+		}
+	}
+	return added
+}
+
 func sameIPSet(a, b []string) bool {
 	if len(a) == 0 && len(b) == 0 {
 		return true
@@ -503,12 +1138,13 @@ func (s *Service) validateIPType(recordType, ipAddress string) error {
 	return nil
 }
 
-func (s *Service) sendNotifications(origin config.OriginConfig, oldIPs, newIPs []string, reason string, isPriorityIP, isFailoverIP bool, oldPriority, newPriority, maxPriority int) {
-	if len(s.notifiers) == 0 {
+func (s *Service) sendNotifications(ctx context.Context, origin config.OriginConfig, oldIPs, newIPs []string, reason string, isPriorityIP, isFailoverIP bool, oldPriority, newPriority, maxPriority int) {
+	if !s.hasEventReceivers() {
 		return
 	}
 
 	event := notifier.FailoverEvent{
+		Type:             notifier.EventTypeFailover,
 		OriginName:       origin.Name,
 		ZoneName:         origin.ZoneName,
 		RecordType:       origin.RecordType,
@@ -517,39 +1153,108 @@ func (s *Service) sendNotifications(origin config.OriginConfig, oldIPs, newIPs [
 		OldIPs:           oldIPs,
 		NewIPs:           newIPs,
 		Reason:           reason,
-		Timestamp:        time.Now(),
+		Timestamp:        s.now(),
 		IsPriorityIP:     isPriorityIP,
 		IsFailoverIP:     isFailoverIP,
 		ReturnToPriority: origin.ReturnToPriority,
 		OldPriority:      oldPriority,
 		NewPriority:      newPriority,
 		MaxPriority:      maxPriority,
+		ObserveOnly:      origin.IsObserveOnly(),
+		Labels:           origin.Labels,
 	}
 
-	// Create a context with timeout for notifications independent of parent cancellation
-	// Important: Do not cancel immediately on function return since notifications are sent in goroutines
-	notifyCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	s.dispatchEvent(ctx, event)
+}
+
+func (s *Service) sendAlert(ctx context.Context, eventType notifier.EventType, origin config.OriginConfig, oldIPs, newIPs []string, reason string) {
+	if !s.hasEventReceivers() {
+		return
+	}
+
+	s.dispatchEvent(ctx, s.alertEvent(eventType, origin, oldIPs, newIPs, reason))
+}
+
+func (s *Service) alertEvent(eventType notifier.EventType, origin config.OriginConfig, oldIPs, newIPs []string, reason string) notifier.FailoverEvent {
+	return notifier.FailoverEvent{
+		Type:        eventType,
+		OriginName:  origin.Name,
+		ZoneName:    origin.ZoneName,
+		RecordType:  origin.RecordType,
+		OldIP:       firstIP(oldIPs),
+		NewIP:       firstIP(newIPs),
+		OldIPs:      oldIPs,
+		NewIPs:      newIPs,
+		Reason:      reason,
+		Timestamp:   s.now(),
+		ObserveOnly: origin.IsObserveOnly(),
+		Labels:      origin.Labels,
+	}
+}
+
+// dispatchEvent sends event to the notifiers in the background and returns a
+// channel that is closed once the sends are done.
+func (s *Service) dispatchEvent(ctx context.Context, event notifier.FailoverEvent) <-chan struct{} {
+	// Notifications are sent independent of parent cancellation, each bounded
+	// by the timeout of its notifier
+	// The context keeps the caller's span so that the sends appear in its trace
+	notifyCtx := context.WithoutCancel(ctx)
+	event.Severity = notifier.SeverityFor(event)
+	probeRecorderFrom(ctx).annotate(&event)
+	if s.eventFeed != nil {
+		s.eventFeed.Publish(event)
+	}
+	for _, handler := range s.eventHandlers {
+		handler(ctx, event)
+	}
+	now := s.now()
 
 	var wg sync.WaitGroup
 	for _, n := range s.notifiers {
+		if route := s.options[n].route; route != nil && !route.Matches(event) {
+			continue
+		}
+		if s.holdForQuietHours(n, event, now) {
+			continue
+		}
+		if s.batchForDigest(n, event) {
+			continue
+		}
 		wg.Add(1)
-		go func(notifier notifier.Notifier) {
+		s.pendingNotifications.Add(1)
+		go func(n notifier.Notifier) {
 			defer wg.Done()
-			if err := notifier.Notify(notifyCtx, event); err != nil {
-				log.Printf("Failed to send notification: %v", err)
-			} else {
-				log.Printf("Notification sent successfully for %s.%s (%v -> %v)",
-					origin.Name, origin.ZoneName, oldIPs, newIPs)
-			}
+			defer s.pendingNotifications.Add(-1)
+			s.notify(notifyCtx, n, event)
 		}(n)
 	}
 
 	// Wait for all notifications to complete in a separate goroutine to not block failover
-	// Cancel the context after all notification goroutines have finished
+	done := make(chan struct{})
 	go func() {
 		wg.Wait()
-		cancel()
+		close(done)
 	}()
+	return done
+}
+
+// notify sends event to n, recording the result.
+func (s *Service) notify(ctx context.Context, n notifier.Notifier, event notifier.FailoverEvent) {
+	defer sentry.Recover(map[string]string{"notifier": fmt.Sprintf("%T", n)})
+	sendCtx, span := tracing.StartClient(ctx, "notify",
+		tracing.String("notifier.type", fmt.Sprintf("%T", n)),
+		tracing.String("notifier.event", string(event.Type)))
+	defer span.End()
+	err := s.sendWithTimeout(sendCtx, n, string(event.Type), func(ctx context.Context) error {
+		return n.Notify(ctx, event)
+	})
+	if err != nil {
+		span.RecordError(err)
+		s.logf("Failed to send notification to %s: %v", s.notifierName(n), err)
+	} else {
+		s.logf("Notification sent successfully for %s.%s (%v -> %v)",
+			event.OriginName, event.ZoneName, event.OldIPs, event.NewIPs)
+	}
 }
 
 func firstIP(ips []string) string {
@@ -560,16 +1265,21 @@ func firstIP(ips []string) string {
 }
 
 func (s *Service) runOriginCheck(ctx context.Context, origin config.OriginConfig) error {
-	checker, err := healthcheck.NewChecker(origin.HealthCheck)
+	checker, err := s.newChecker(origin)
 	if err != nil {
+		s.checkerFailed(ctx, origin, err)
 		return fmt.Errorf("failed to create health checker for %s: %w", origin.Name, err)
 	}
 	s.checkOrigin(ctx, origin, checker)
+	s.persistState(ctx, origin)
 	return nil
 }
 
 func (s *Service) RunOneShot(ctx context.Context) error {
-	log.Println("Running one-shot health check for all origins...")
+	s.logf("Running one-shot health check for all origins...")
+
+	s.restoreState(ctx)
+	s.loadAvailability()
 
 	var wg sync.WaitGroup
 	errCh := make(chan error, len(s.config.Origins))
@@ -578,6 +1288,7 @@ func (s *Service) RunOneShot(ctx context.Context) error {
 		wg.Add(1)
 		go func(o config.OriginConfig) {
 			defer wg.Done()
+			defer s.recoverPanic(originTags(o))
 			if err := s.runOriginCheck(ctx, o); err != nil {
 				errCh <- err
 			}
@@ -586,6 +1297,8 @@ func (s *Service) RunOneShot(ctx context.Context) error {
 
 	wg.Wait()
 	close(errCh)
+	s.heartbeatWG.Wait()
+	s.flushAvailability()
 
 	var multiErr error
 	for err := range errCh {
@@ -596,6 +1309,6 @@ func (s *Service) RunOneShot(ctx context.Context) error {
 		return multiErr
 	}
 
-	log.Println("One-shot health check completed")
+	s.logf("One-shot health check completed")
 	return nil
 }
@@ -2,6 +2,7 @@ package gslb
 
 import (
 	"context"
+	"log"
 	"testing"
 	"time"
 
@@ -88,6 +89,7 @@ func TestService_sendNotifications(t *testing.T) {
 
 			// Call sendNotifications
 			service.sendNotifications(
+				context.Background(),
 				tt.origin,
 				tt.oldIPs,
 				tt.newIPs,
@@ -162,6 +164,7 @@ func TestService_sendNotifications_noNotifiers(t *testing.T) {
 
 	// This should not panic even without notifiers
 	service.sendNotifications(
+		context.Background(),
 		origin,
 		[]string{"192.168.1.1"},
 		[]string{"192.168.1.2"},
@@ -195,6 +198,7 @@ func TestService_sendNotifications_multipleNotifiers(t *testing.T) {
 
 	// Call sendNotifications
 	service.sendNotifications(
+		context.Background(),
 		origin,
 		[]string{"192.168.1.1"},
 		[]string{"192.168.1.2"},
@@ -217,3 +221,59 @@ func TestService_sendNotifications_multipleNotifiers(t *testing.T) {
 		t.Error("Expected second notifier to be called, but it was not")
 	}
 }
+
+func TestService_sendNotifications_routes(t *testing.T) {
+	everything := &MockNotifier{}
+	otherZone := &MockNotifier{}
+	recoveries := &MockNotifier{}
+
+	cfg := &config.Config{Notifications: []config.NotificationConfig{
+		{Type: config.NotificationSlack, WebhookURL: "https://hooks.slack.com/services/x"},
+		{Type: config.NotificationSlack, WebhookURL: "https://hooks.slack.com/services/y", Route: &config.NotificationRouteConfig{Zones: []string{"example.org"}}},
+		{Type: config.NotificationSlack, WebhookURL: "https://hooks.slack.com/services/z", Route: &config.NotificationRouteConfig{Events: []string{"recovery"}, MinSeverity: "info"}},
+	}}
+	built, options := buildNotifiers(cfg, log.Default())
+	if len(built) != 3 || len(options) != 3 {
+		t.Fatalf("buildNotifiers() = %d notifiers, %d options", len(built), len(options))
+	}
+	if opts := options[built[0]]; opts.route != nil || opts.name != "notifications[0]" || opts.timeout != config.DefaultNotificationTimeout {
+		t.Errorf("Unexpected options %+v of the notifier without a route", opts)
+	}
+
+	service := &Service{
+		config:    cfg,
+		notifiers: []notifier.Notifier{everything, otherZone, recoveries},
+		options: map[notifier.Notifier]notifierOptions{
+			otherZone:  options[built[1]],
+			recoveries: options[built[2]],
+		},
+	}
+	origin := config.OriginConfig{Name: "www", ZoneName: "example.com", RecordType: "A"}
+	service.sendNotifications(context.Background(), origin, []string{"192.168.1.1"}, []string{"192.168.1.2"}, "Health check failed", false, true, 100, 50, 100)
+	waitForNotifications(t, service)
+
+	if !everything.NotifyCalled {
+		t.Error("Expected the notifier without a route to be called")
+	}
+	if otherZone.NotifyCalled || recoveries.NotifyCalled {
+		t.Error("Expected the routed notifiers to skip a failover in example.com")
+	}
+
+	service.sendNotifications(context.Background(), origin, []string{"192.168.1.2"}, []string{"192.168.1.1"}, "Recovered", true, false, 50, 100, 100)
+	waitForNotifications(t, service)
+	if !recoveries.NotifyCalled || otherZone.NotifyCalled {
+		t.Error("Expected only the recovery route to receive the recovery")
+	}
+}
+
+// waitForNotifications waits until the notifications being sent are done.
+func waitForNotifications(t *testing.T, service *Service) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for service.pendingNotifications.Load() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for notifications")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/bootjp/cloudflare-gslb/pkg/cloudflare"
 	cfmock "github.com/bootjp/cloudflare-gslb/pkg/cloudflare/mock"
 	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
 	"github.com/cloudflare/cloudflare-go/v6/dns"
 )
 
@@ -57,7 +58,7 @@ func TestServiceCheckOrigin_SelectsHighestPriority(t *testing.T) {
 		HealthCheck: config.HealthCheck{
 			Type:     "http",
 			Endpoint: "/health",
-			Timeout:  5,
+			Timeout:  config.Seconds(5 * time.Second),
 		},
 		PriorityLevels: []config.PriorityLevel{
 			{Priority: 100, IPs: []string{"192.168.1.1", "192.168.1.2"}},
@@ -107,7 +108,7 @@ func TestServiceCheckOrigin_FallbackToLowerPriority(t *testing.T) {
 		HealthCheck: config.HealthCheck{
 			Type:     "http",
 			Endpoint: "/health",
-			Timeout:  5,
+			Timeout:  config.Seconds(5 * time.Second),
 		},
 		PriorityLevels: []config.PriorityLevel{
 			{Priority: 100, IPs: []string{"192.168.1.1", "192.168.1.2"}},
@@ -160,7 +161,7 @@ func TestServiceCheckOrigin_ReturnToPriorityDisabled(t *testing.T) {
 		HealthCheck: config.HealthCheck{
 			Type:     "http",
 			Endpoint: "/health",
-			Timeout:  5,
+			Timeout:  config.Seconds(5 * time.Second),
 		},
 		PriorityLevels: []config.PriorityLevel{
 			{Priority: 100, IPs: []string{"192.168.1.1"}},
@@ -205,7 +206,7 @@ func TestServiceCheckOrigin_FallbackWhenPriorityLevelPartiallyUnhealthy(t *testi
 		HealthCheck: config.HealthCheck{
 			Type:     "http",
 			Endpoint: "/health",
-			Timeout:  5,
+			Timeout:  config.Seconds(5 * time.Second),
 		},
 		PriorityLevels: []config.PriorityLevel{
 			{Priority: 100, IPs: []string{"192.168.1.1", "192.168.1.2"}},
@@ -267,3 +268,104 @@ func sameStringSet(a, b []string) bool {
 	}
 	return true
 }
+
+func TestServiceCheckOrigin_ObserveModeDoesNotTouchDNS(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.168.1.1"}},
+			{Priority: 50, IPs: []string{"192.168.1.2"}},
+		},
+		ReturnToPriority: true,
+		Mode:             config.OriginModeObserve,
+	}
+
+	service, dnsClientMock := createTestService(origin)
+	recorder := &recordingNotifier{}
+	service.notifiers = []notifier.Notifier{recorder}
+
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "record-1", Name: name, Type: dns.RecordResponseTypeA, Content: "192.168.1.1"}}, nil
+	}
+	replaceCallCount := 0
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		replaceCallCount++
+		return nil
+	}
+
+	checker := hcmock.NewCheckerMock(func(ip string) error {
+		if ip == "192.168.1.1" {
+			return fmt.Errorf("unhealthy")
+		}
+		return nil
+	})
+
+	service.checkOrigin(context.Background(), origin, checker)
+	service.checkOrigin(context.Background(), origin, checker)
+	waitForNotifications(t, service)
+
+	if replaceCallCount != 0 {
+		t.Fatalf("ReplaceRecords was called %d times, expected 0", replaceCallCount)
+	}
+	events := recorder.take()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 notification, got %+v", events)
+	}
+	if !events[0].ObserveOnly {
+		t.Error("expected notification to be marked as observe only")
+	}
+	if !sameStringSet(events[0].NewIPs, []string{"192.168.1.2"}) {
+		t.Errorf("expected would-be IPs in notification, got %v", events[0].NewIPs)
+	}
+}
+
+func TestServiceStop_CancelsInFlightCalls(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		HealthCheck: config.HealthCheck{
+			Type:    "icmp",
+			Timeout: config.Seconds(1 * time.Second),
+		},
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.0.2.1"}},
+		},
+	}
+
+	service, dnsClientMock := createTestService(origin)
+	service.config.CheckInterval = 10 * time.Millisecond
+
+	entered := make(chan struct{}, 1)
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		select {
+		case entered <- struct{}{}:
+		default:
+		}
+		// Simulates a hung API connection
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	if err := service.Start(context.Background()); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("check cycle did not start")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		service.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not cancel the in-flight API call")
+	}
+}
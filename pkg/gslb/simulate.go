@@ -0,0 +1,360 @@
+package gslb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/cloudflare"
+	"github.com/bootjp/cloudflare-gslb/pkg/healthcheck"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrInvalidScenarioStep is returned by ParseScenarioStep for a step it does
+// not understand
+var ErrInvalidScenarioStep = errors.New("invalid scenario step")
+
+// Actions of a ScenarioStep
+const (
+	ScenarioDown  = "down"  // the IPs fail their health checks from this step on
+	ScenarioUp    = "up"    // the IPs pass their health checks again
+	ScenarioWait  = "wait"  // time passes before the check
+	ScenarioCheck = "check" // nothing changes before the check
+)
+
+// ScenarioStep is one step of a simulation: a change of the health of IPs or
+// of the time, followed by one check of every origin.
+type ScenarioStep struct {
+	Action string
+	IPs    []string      // IPs of down and up
+	Wait   time.Duration // duration of wait
+}
+
+// ParseScenarioStep parses a step such as "down 203.0.113.10", "up
+// 203.0.113.10 203.0.113.11", "wait 10m" or "check".
+func ParseScenarioStep(text string) (ScenarioStep, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return ScenarioStep{}, errors.Wrap(ErrInvalidScenarioStep, "empty step")
+	}
+	step := ScenarioStep{Action: fields[0]}
+	args := fields[1:]
+	switch step.Action {
+	case ScenarioDown, ScenarioUp:
+		if len(args) == 0 {
+			return ScenarioStep{}, errors.Wrapf(ErrInvalidScenarioStep, "%q needs at least one IP", text)
+		}
+		for _, ip := range args {
+			if net.ParseIP(ip) == nil {
+				return ScenarioStep{}, errors.Wrapf(ErrInvalidScenarioStep, "%q: %s is not an IP address", text, ip)
+			}
+		}
+		step.IPs = args
+	case ScenarioWait:
+		if len(args) != 1 {
+			return ScenarioStep{}, errors.Wrapf(ErrInvalidScenarioStep, "%q needs one duration", text)
+		}
+		wait, err := time.ParseDuration(args[0])
+		if err != nil || wait <= 0 {
+			return ScenarioStep{}, errors.Wrapf(ErrInvalidScenarioStep, "%q: %s is not a positive duration", text, args[0])
+		}
+		step.Wait = wait
+	case ScenarioCheck:
+		if len(args) != 0 {
+			return ScenarioStep{}, errors.Wrapf(ErrInvalidScenarioStep, "%q takes no arguments", text)
+		}
+	default:
+		return ScenarioStep{}, errors.Wrapf(ErrInvalidScenarioStep, "unknown action %q", step.Action)
+	}
+	return step, nil
+}
+
+// String returns the step as ParseScenarioStep accepts it.
+func (s ScenarioStep) String() string {
+	switch s.Action {
+	case ScenarioWait:
+		return s.Action + " " + s.Wait.String()
+	case ScenarioCheck:
+		return s.Action
+	default:
+		return s.Action + " " + strings.Join(s.IPs, " ")
+	}
+}
+
+// SimulatedStep is what the service did in one step of a simulation
+type SimulatedStep struct {
+	Step string `json:"step"`
+	// ElapsedSeconds is the time since the start of the simulation.
+	ElapsedSeconds float64           `json:"elapsed_seconds"`
+	Changes        []SimulatedChange `json:"changes,omitempty"`
+	Events         []SimulatedEvent  `json:"events,omitempty"`
+}
+
+// SimulatedChange is a change of the records of an origin
+type SimulatedChange struct {
+	Zone       string   `json:"zone"`
+	Name       string   `json:"name"`
+	RecordType string   `json:"record_type"`
+	Before     []string `json:"before"`
+	After      []string `json:"after"`
+}
+
+// SimulatedEvent is an event of the service and the notifications whose
+// route it matches
+type SimulatedEvent struct {
+	Event         notifier.FailoverEvent
+	Notifications []string
+}
+
+// MarshalJSON writes the event as the exec and MQTT notifiers send it.
+func (e SimulatedEvent) MarshalJSON() ([]byte, error) {
+	event, err := notifier.EventJSON(e.Event)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		Event         json.RawMessage `json:"event"`
+		Notifications []string        `json:"notifications"`
+	}{event, e.Notifications})
+}
+
+// Simulate runs the service of cfg against the scenario without probing
+// anything or changing any DNS records, and returns what it would do. The
+// records start out as a first check with every IP healthy leaves them,
+// reported as the step "start". Each step then changes the health of IPs or
+// the time and checks every origin once; the time also advances by the check
+// interval before each check.
+//
+// Hostnames, Kubernetes Services and Consul services are still resolved. The
+// Cloudflare health checks, verification and Spectrum applications of origins
+// are left out, and notifications are matched by their route only, regardless
+// of their quiet hours and digest.
+func Simulate(ctx context.Context, cfg *config.Config, scenario []ScenarioStep) ([]SimulatedStep, error) {
+	sim := newSimulation(cfg)
+	start := time.Now()
+	now := start
+	service, err := NewService(simulationConfig(cfg),
+		WithLogger(log.New(io.Discard, "", 0)),
+		WithClock(func() time.Time { return now }),
+		WithDNSClientFactory(sim.dnsClient),
+		WithCheckerFactory(sim.checker),
+		WithEventHandler(sim.handleEvent),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first check publishes the records every later step starts from
+	if err := sim.check(ctx, service); err != nil {
+		return nil, err
+	}
+	first := sim.take(ScenarioCheck, 0)
+	first.Step = "start"
+	first.Events = nil
+	for i := range first.Changes {
+		first.Changes[i].Before = nil
+	}
+	steps := []SimulatedStep{first}
+
+	for _, step := range scenario {
+		switch step.Action {
+		case ScenarioDown, ScenarioUp:
+			sim.setDown(step.IPs, step.Action == ScenarioDown)
+		case ScenarioWait:
+			now = now.Add(step.Wait)
+		}
+		now = now.Add(cfg.CheckInterval)
+		if err := sim.check(ctx, service); err != nil {
+			return nil, err
+		}
+		steps = append(steps, sim.take(step.String(), now.Sub(start)))
+	}
+	return steps, nil
+}
+
+// simulationConfig returns a copy of cfg without the parts of the service
+// that would reach outside the simulation.
+func simulationConfig(cfg *config.Config) *config.Config {
+	sim := *cfg
+	sim.Notifications = nil
+	sim.ProviderPlugins = nil
+	sim.StateStore = nil
+	sim.Audit = nil
+	sim.EventHistory = nil
+	sim.Heartbeat = nil
+	sim.KubernetesOrigins = nil
+	sim.Origins = make([]config.OriginConfig, len(cfg.Origins))
+	for i, origin := range cfg.Origins {
+		origin.CloudflareHealth = nil
+		origin.Verify = nil
+		origin.Spectrum = nil
+		sim.Origins[i] = origin
+	}
+	return &sim
+}
+
+// simulation holds the records, the health of IPs and the events of a
+// simulation.
+type simulation struct {
+	routes map[string]*notifier.Route // routes of the notifications by name, nil for all events
+	names  []string                   // names of the notifications in configuration order
+
+	mu      sync.Mutex
+	records map[string][]string // by zone, name and record type
+	down    map[string]bool
+	changes []SimulatedChange
+	events  []SimulatedEvent
+}
+
+func newSimulation(cfg *config.Config) *simulation {
+	sim := &simulation{
+		routes:  make(map[string]*notifier.Route),
+		records: make(map[string][]string),
+		down:    make(map[string]bool),
+	}
+	for i, nc := range cfg.Notifications {
+		name := nc.EffectiveName(i)
+		sim.names = append(sim.names, name)
+		if nc.Route != nil {
+			route := buildRoute(nc.Route)
+			sim.routes[name] = &route
+		}
+	}
+	return sim
+}
+
+// check checks every origin once, in configuration order so that the steps
+// list the changes and events in a stable order.
+func (sim *simulation) check(ctx context.Context, service *Service) error {
+	for _, origin := range service.config.Origins {
+		if err := service.runOriginCheck(ctx, origin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// take returns the changes and events since the last call as a step.
+func (sim *simulation) take(name string, elapsed time.Duration) SimulatedStep {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	step := SimulatedStep{Step: name, ElapsedSeconds: elapsed.Seconds(), Changes: sim.changes, Events: sim.events}
+	sim.changes, sim.events = nil, nil
+	return step
+}
+
+func (sim *simulation) setDown(ips []string, down bool) {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	for _, ip := range ips {
+		sim.down[ip] = down
+	}
+}
+
+func (sim *simulation) checker(config.OriginConfig) (healthcheck.Checker, error) {
+	return simulatedChecker{sim}, nil
+}
+
+func (sim *simulation) dnsClient(ctx context.Context, zone config.ZoneConfig, origin config.OriginConfig) (cloudflare.DNSClientInterface, error) {
+	return &simulatedDNSClient{sim: sim, zone: zone.Name}, nil
+}
+
+func (sim *simulation) handleEvent(ctx context.Context, event notifier.FailoverEvent) {
+	routed := SimulatedEvent{Event: event, Notifications: []string{}}
+	for _, name := range sim.names {
+		if route := sim.routes[name]; route == nil || route.Matches(event) {
+			routed.Notifications = append(routed.Notifications, name)
+		}
+	}
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	sim.events = append(sim.events, routed)
+}
+
+// simulatedChecker fails the IPs the scenario marked down.
+type simulatedChecker struct {
+	sim *simulation
+}
+
+func (c simulatedChecker) Check(ip string) error {
+	c.sim.mu.Lock()
+	defer c.sim.mu.Unlock()
+	if c.sim.down[ip] {
+		return errors.Newf("%s is down in the scenario", ip)
+	}
+	return nil
+}
+
+// simulatedDNSClient keeps the records of a zone in the simulation and
+// records every change of them.
+type simulatedDNSClient struct {
+	sim  *simulation
+	zone string
+}
+
+func (c *simulatedDNSClient) key(name, recordType string) string {
+	return c.zone + "/" + name + "/" + recordType
+}
+
+func (c *simulatedDNSClient) GetDNSRecords(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+	c.sim.mu.Lock()
+	defer c.sim.mu.Unlock()
+	contents := c.sim.records[c.key(name, recordType)]
+	records := make([]dns.RecordResponse, 0, len(contents))
+	for i, content := range contents {
+		records = append(records, dns.RecordResponse{
+			ID:      fmt.Sprintf("%s-%d", c.key(name, recordType), i),
+			Name:    name,
+			Type:    dns.RecordResponseType(recordType),
+			Content: content,
+		})
+	}
+	return records, nil
+}
+
+func (c *simulatedDNSClient) ReplaceRecords(ctx context.Context, name, recordType string, newContents []string) error {
+	c.sim.mu.Lock()
+	defer c.sim.mu.Unlock()
+	key := c.key(name, recordType)
+	before := c.sim.records[key]
+	if sameIPSet(before, newContents) {
+		return nil
+	}
+	after := append([]string(nil), newContents...)
+	c.sim.records[key] = after
+	c.sim.changes = append(c.sim.changes, SimulatedChange{
+		Zone:       c.zone,
+		Name:       name,
+		RecordType: recordType,
+		Before:     before,
+		After:      after,
+	})
+	return nil
+}
+
+// The service only replaces records, so single records are left unchanged.
+
+func (c *simulatedDNSClient) CreateDNSRecord(ctx context.Context, name, recordType, content string) (dns.RecordResponse, error) {
+	return dns.RecordResponse{Name: name, Type: dns.RecordResponseType(recordType), Content: content}, nil
+}
+
+func (c *simulatedDNSClient) UpdateDNSRecord(ctx context.Context, recordID, name, recordType, content string) (dns.RecordResponse, error) {
+	return dns.RecordResponse{ID: recordID, Name: name, Type: dns.RecordResponseType(recordType), Content: content}, nil
+}
+
+func (c *simulatedDNSClient) DeleteDNSRecord(ctx context.Context, recordID string) error {
+	return nil
+}
+
+func (c *simulatedDNSClient) GetZoneID() string {
+	return c.zone
+}
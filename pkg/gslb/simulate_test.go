@@ -0,0 +1,104 @@
+package gslb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+func TestParseScenarioStep(t *testing.T) {
+	tests := []struct {
+		text string
+		want ScenarioStep
+	}{
+		{"down 203.0.113.10", ScenarioStep{Action: ScenarioDown, IPs: []string{"203.0.113.10"}}},
+		{" up 203.0.113.10  2001:db8::1 ", ScenarioStep{Action: ScenarioUp, IPs: []string{"203.0.113.10", "2001:db8::1"}}},
+		{"wait 10m", ScenarioStep{Action: ScenarioWait, Wait: 10 * time.Minute}},
+		{"check", ScenarioStep{Action: ScenarioCheck}},
+	}
+	for _, tt := range tests {
+		got, err := ParseScenarioStep(tt.text)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseScenarioStep(%q) = %+v, %v, want %+v", tt.text, got, err, tt.want)
+		}
+	}
+
+	for _, text := range []string{"", "down", "down www.example.com", "wait", "wait -1m", "check 1", "reboot 203.0.113.10"} {
+		if _, err := ParseScenarioStep(text); !errors.Is(err, ErrInvalidScenarioStep) {
+			t.Errorf("ParseScenarioStep(%q) error = %v, want ErrInvalidScenarioStep", text, err)
+		}
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	cfg := &config.Config{
+		CloudflareZoneIDs: []config.ZoneConfig{{ZoneID: "zone-1", Name: "example.com"}},
+		CheckInterval:     time.Minute,
+		Origins: []config.OriginConfig{{
+			Name:             "www.example.com",
+			ZoneName:         "example.com",
+			RecordType:       "A",
+			ReturnToPriority: true,
+			HealthCheck:      config.HealthCheck{Type: config.HealthCheckTypeHTTP},
+			PriorityLevels: []config.PriorityLevel{
+				{Priority: 100, IPs: []string{"203.0.113.10"}},
+				{Priority: 50, IPs: []string{"203.0.113.20"}},
+			},
+		}},
+		Notifications: []config.NotificationConfig{
+			{Type: config.NotificationSlack, Name: "all", WebhookURL: "https://hooks.slack.com/services/x"},
+			{Type: config.NotificationSlack, Name: "other", WebhookURL: "https://hooks.slack.com/services/y", Route: &config.NotificationRouteConfig{Zones: []string{"example.org"}}},
+		},
+	}
+
+	var scenario []ScenarioStep
+	for _, text := range []string{"down 203.0.113.10", "check", "up 203.0.113.10"} {
+		step, err := ParseScenarioStep(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scenario = append(scenario, step)
+	}
+	steps, err := Simulate(context.Background(), cfg, scenario)
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+	if len(steps) != 4 {
+		t.Fatalf("Expected the start and 3 steps, got %+v", steps)
+	}
+
+	if steps[0].Step != "start" || len(steps[0].Changes) != 1 || !reflect.DeepEqual(steps[0].Changes[0].After, []string{"203.0.113.10"}) {
+		t.Errorf("Expected the start to publish the priority IP, got %+v", steps[0])
+	}
+
+	down := steps[1]
+	if down.Step != "down 203.0.113.10" || down.ElapsedSeconds != 60 {
+		t.Errorf("Unexpected step %q after %gs", down.Step, down.ElapsedSeconds)
+	}
+	want := SimulatedChange{Zone: "example.com", Name: "www.example.com", RecordType: "A", Before: []string{"203.0.113.10"}, After: []string{"203.0.113.20"}}
+	if len(down.Changes) != 1 || !reflect.DeepEqual(down.Changes[0], want) {
+		t.Errorf("Expected a failover to 203.0.113.20, got %+v", down.Changes)
+	}
+	if len(down.Events) == 0 || down.Events[0].Event.Type != notifier.EventTypeFailover || !reflect.DeepEqual(down.Events[0].Notifications, []string{"all"}) {
+		t.Errorf("Expected a failover sent to all, got %+v", down.Events)
+	}
+
+	data, err := json.Marshal(down.Events[0])
+	if err != nil || !strings.Contains(string(data), `"kind":"failover"`) || !strings.Contains(string(data), `"notifications":["all"]`) {
+		t.Errorf("Unexpected JSON of the event %s, %v", data, err)
+	}
+
+	if len(steps[2].Changes) != 0 {
+		t.Errorf("Expected no change while nothing changes, got %+v", steps[2].Changes)
+	}
+	if up := steps[3]; len(up.Changes) != 1 || !reflect.DeepEqual(up.Changes[0].After, []string{"203.0.113.10"}) {
+		t.Errorf("Expected a return to 203.0.113.10, got %+v", up.Changes)
+	}
+}
@@ -0,0 +1,115 @@
+package gslb
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/metrics"
+)
+
+var (
+	sloProbesMetric   = metrics.NewCounter("gslb.latency_slo.probes", "{probe}", "Health checks counted against the latency SLO, by whether they met it")
+	sloBurnRateMetric = metrics.NewGauge("gslb.latency_slo.burn_rate", "1", "Rate at which the latency SLO error budget is spent over the SLO window")
+)
+
+// LatencySLOReport is the state of the latency SLO of an origin over its window.
+type LatencySLOReport struct {
+	ThresholdMs   float64 `json:"threshold_ms"`
+	Objective     float64 `json:"objective"`
+	WindowSeconds float64 `json:"window_seconds"`
+	Probes        int     `json:"probes"`
+	Good          int     `json:"good"`
+	// Compliance is the ratio of good probes, or 1 when there are none.
+	Compliance float64 `json:"compliance"`
+	// BurnRate is how many times faster than allowed the error budget is
+	// spent: 1 exhausts it exactly at the end of the window.
+	BurnRate float64 `json:"burn_rate"`
+}
+
+type sloSample struct {
+	at   time.Time
+	good bool
+}
+
+// sloTracker keeps the probe results of each origin within its SLO window.
+type sloTracker struct {
+	mu      sync.Mutex
+	samples map[string][]sloSample
+}
+
+func newSLOTracker() *sloTracker {
+	return &sloTracker{samples: make(map[string][]sloSample)}
+}
+
+// meetsLatencySLO reports whether a probe counts as good: it succeeded within the threshold.
+func meetsLatencySLO(cfg *config.LatencySLOConfig, healthy bool, latency time.Duration) bool {
+	return healthy && latency <= cfg.ThresholdMs.Duration()
+}
+
+// observe records a probe of the origin and returns the updated report.
+func (t *sloTracker) observe(originKey string, cfg *config.LatencySLOConfig, good bool, now time.Time) LatencySLOReport {
+	if t == nil {
+		return newLatencySLOReport(cfg, nil)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	samples := append(t.prune(originKey, cfg, now), sloSample{at: now, good: good})
+	t.samples[originKey] = samples
+	return newLatencySLOReport(cfg, samples)
+}
+
+// report returns the state of the origin's SLO without recording a probe.
+func (t *sloTracker) report(originKey string, cfg *config.LatencySLOConfig, now time.Time) LatencySLOReport {
+	if t == nil {
+		return newLatencySLOReport(cfg, nil)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	samples := t.prune(originKey, cfg, now)
+	t.samples[originKey] = samples
+	return newLatencySLOReport(cfg, samples)
+}
+
+// prune drops the samples that left the window. t.mu must be held.
+func (t *sloTracker) prune(originKey string, cfg *config.LatencySLOConfig, now time.Time) []sloSample {
+	samples := t.samples[originKey]
+	cutoff := now.Add(-cfg.EffectiveWindow().Duration())
+	drop := 0
+	for drop < len(samples) && samples[drop].at.Before(cutoff) {
+		drop++
+	}
+	return samples[drop:]
+}
+
+func newLatencySLOReport(cfg *config.LatencySLOConfig, samples []sloSample) LatencySLOReport {
+	report := LatencySLOReport{
+		ThresholdMs:   float64(cfg.ThresholdMs.Duration()) / float64(time.Millisecond),
+		Objective:     cfg.EffectiveObjective(),
+		WindowSeconds: cfg.EffectiveWindow().Duration().Seconds(),
+		Probes:        len(samples),
+		Compliance:    1,
+	}
+	for _, sample := range samples {
+		if sample.good {
+			report.Good++
+		}
+	}
+	if report.Probes > 0 {
+		report.Compliance = float64(report.Good) / float64(report.Probes)
+		report.BurnRate = (1 - report.Compliance) / (1 - report.Objective)
+	}
+	return report
+}
+
+// recordLatencySLO counts a probe against the origin's latency SLO, if it has one.
+func (s *Service) recordLatencySLO(origin config.OriginConfig, originKey, ip string, healthy bool, latency time.Duration) {
+	if !origin.LatencySLO.Enabled() {
+		return
+	}
+	good := meetsLatencySLO(origin.LatencySLO, healthy, latency)
+	report := s.slo.observe(originKey, origin.LatencySLO, good, s.now())
+	sloProbesMetric.Add(1, originAttributes(origin, metrics.String("ip", ip), metrics.String("within_slo", strconv.FormatBool(good)))...)
+	sloBurnRateMetric.Set(report.BurnRate, originAttributes(origin)...)
+}
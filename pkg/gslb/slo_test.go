@@ -0,0 +1,95 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestSLOTracker_BurnRate(t *testing.T) {
+	cfg := &config.LatencySLOConfig{
+		ThresholdMs:   config.Milliseconds(200 * time.Millisecond),
+		Objective:     0.9,
+		WindowSeconds: config.Seconds(10 * time.Minute),
+	}
+	tracker := newSLOTracker()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	if report := tracker.report("origin", cfg, start); report.Probes != 0 || report.Compliance != 1 || report.BurnRate != 0 {
+		t.Errorf("report without probes = %+v, want full compliance", report)
+	}
+
+	// 2 of 10 probes miss the SLO, spending the budget twice as fast as allowed
+	var report LatencySLOReport
+	for i := 0; i < 10; i++ {
+		report = tracker.observe("origin", cfg, i >= 2, start.Add(time.Duration(i)*time.Minute))
+	}
+	if report.Probes != 10 || report.Good != 8 || math.Abs(report.BurnRate-2) > 1e-9 {
+		t.Errorf("report = %+v, want 8 of 10 good at burn rate 2", report)
+	}
+	if report.ThresholdMs != 200 || report.WindowSeconds != 600 {
+		t.Errorf("report = %+v, want the configured threshold and window", report)
+	}
+
+	// The bad probes leave the window
+	report = tracker.report("origin", cfg, start.Add(11*time.Minute+time.Second))
+	if report.Probes != 8 || report.Good != 8 || report.BurnRate != 0 {
+		t.Errorf("report after the window moved = %+v, want only good probes", report)
+	}
+}
+
+func TestMeetsLatencySLO(t *testing.T) {
+	cfg := &config.LatencySLOConfig{ThresholdMs: config.Milliseconds(100 * time.Millisecond)}
+	tests := []struct {
+		healthy bool
+		latency time.Duration
+		want    bool
+	}{
+		{true, 50 * time.Millisecond, true},
+		{true, 100 * time.Millisecond, true},
+		{true, 150 * time.Millisecond, false},
+		// A failed check never meets the SLO, however fast it failed
+		{false, time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		if got := meetsLatencySLO(cfg, tt.healthy, tt.latency); got != tt.want {
+			t.Errorf("meetsLatencySLO(%v, %v) = %v, want %v", tt.healthy, tt.latency, got, tt.want)
+		}
+	}
+}
+
+func TestOriginReports_LatencySLO(t *testing.T) {
+	origin := statusTestOrigin()
+	origin.LatencySLO = &config.LatencySLOConfig{ThresholdMs: config.Milliseconds(time.Second), Objective: 0.5}
+	service, dnsClientMock := createTestService(origin)
+	service.slo = newSLOTracker()
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "1", Content: "192.0.2.1"}}, nil
+	}
+
+	service.checkOrigin(context.Background(), origin, hcmock.NewCheckerMock(func(ip string) error { return nil }))
+	service.checkOrigin(context.Background(), origin, hcmock.NewCheckerMock(func(ip string) error {
+		if ip == "192.0.2.1" {
+			return errors.New("unhealthy")
+		}
+		return nil
+	}))
+
+	slo := service.OriginReports()[0].LatencySLO
+	if slo == nil {
+		t.Fatal("expected a latency SLO report")
+	}
+	// A healthy probe of the primary, then a failed primary and a healthy failover
+	if slo.Probes != 3 || slo.Good != 2 {
+		t.Errorf("latency SLO = %+v, want 2 of 3 good probes", slo)
+	}
+	if math.Abs(slo.BurnRate-2.0/3) > 1e-9 {
+		t.Errorf("burn rate = %v, want 2/3", slo.BurnRate)
+	}
+}
@@ -0,0 +1,190 @@
+package gslb
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/cloudflare"
+	"github.com/cockroachdb/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrUnknownSnapshotFormat is returned for snapshot formats other than JSON and YAML
+	ErrUnknownSnapshotFormat = errors.New("unknown snapshot format")
+	// ErrSnapshotRecordNotManaged is returned when a snapshot record matches no configured origin
+	ErrSnapshotRecordNotManaged = errors.New("snapshot record does not match a configured origin")
+)
+
+// Snapshot formats accepted by MarshalSnapshot and ParseSnapshot.
+const (
+	SnapshotFormatJSON = "json"
+	SnapshotFormatYAML = "yaml"
+)
+
+// recordStateRestored marks records written by ApplySnapshot.
+const recordStateRestored = "restored"
+
+// Snapshot is the expected DNS state of every managed origin, used to rebuild a zone.
+type Snapshot struct {
+	GeneratedAt time.Time        `json:"generated_at" yaml:"generated_at"`
+	Records     []SnapshotRecord `json:"records" yaml:"records"`
+}
+
+// SnapshotRecord is the expected state of one origin's record set.
+type SnapshotRecord struct {
+	Zone string   `json:"zone" yaml:"zone"`
+	Name string   `json:"name" yaml:"name"`
+	Type string   `json:"type" yaml:"type"`
+	IPs  []string `json:"ips" yaml:"ips"`
+	// Proxied is informational; records are always written with the origin's configured proxied setting.
+	Proxied bool `json:"proxied" yaml:"proxied"`
+}
+
+// ExportSnapshot returns the expected DNS state of every configured origin.
+// The IPs are what the service last published, or the live records if the
+// origin has not been checked yet, or the highest priority level if the
+// record does not exist at all.
+func (s *Service) ExportSnapshot(ctx context.Context) (Snapshot, error) {
+	snapshot := Snapshot{GeneratedAt: s.now().UTC()}
+
+	for _, origin := range s.config.Origins {
+		ips, err := s.expectedIPs(ctx, origin)
+		if err != nil {
+			return Snapshot{}, err
+		}
+		snapshot.Records = append(snapshot.Records, SnapshotRecord{
+			Zone:    origin.ZoneName,
+			Name:    origin.Name,
+			Type:    origin.RecordType,
+			IPs:     ips,
+			Proxied: origin.Proxied,
+		})
+	}
+	return snapshot, nil
+}
+
+func (s *Service) expectedIPs(ctx context.Context, origin config.OriginConfig) ([]string, error) {
+	originKey := originKeyFor(origin)
+
+	s.originStatusMutex.RLock()
+	status, ok := s.originStatus[originKey]
+	var ips []string
+	if ok && status.Initialized {
+		ips = append(ips, status.CurrentIPs...)
+	}
+	s.originStatusMutex.RUnlock()
+
+	if len(ips) == 0 {
+		records, err := s.getDNSClientForOrigin(origin).GetDNSRecords(ctx, origin.Name, origin.RecordType)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get DNS records for %s", origin.Name)
+		}
+		ips = collectRecordIPs(records)
+	}
+
+	if len(ips) == 0 {
+		_, levels := s.activePriorityLevels(s.resolveOriginHosts(ctx, origin), originKey, nil)
+		if levels = sortPriorityLevels(levels); len(levels) > 0 {
+			ips = append(ips, levels[0].IPs...)
+		}
+	}
+
+	sort.Strings(ips)
+	return ips, nil
+}
+
+// ApplySnapshot writes every record of snapshot to DNS. Each record must
+// belong to a configured origin; origins in observe mode are skipped. Change
+// limits do not apply, since restoring a zone is an explicit operator action.
+func (s *Service) ApplySnapshot(ctx context.Context, snapshot Snapshot) error {
+	origins := make(map[string]config.OriginConfig, len(s.config.Origins))
+	for _, origin := range s.config.Origins {
+		origins[originKeyFor(origin)] = origin
+	}
+
+	// Validate everything first so that a bad snapshot changes nothing
+	for _, record := range snapshot.Records {
+		key := originKeyFor(config.OriginConfig{ZoneName: record.Zone, Name: record.Name, RecordType: record.Type})
+		if _, ok := origins[key]; !ok {
+			return errors.Wrapf(ErrSnapshotRecordNotManaged, "%s %s in zone %s", record.Type, record.Name, record.Zone)
+		}
+		if err := s.validateSnapshotRecord(record); err != nil {
+			return err
+		}
+	}
+
+	for _, record := range snapshot.Records {
+		key := originKeyFor(config.OriginConfig{ZoneName: record.Zone, Name: record.Name, RecordType: record.Type})
+		origin := origins[key]
+		if origin.IsObserveOnly() {
+			s.logf("Skipping snapshot record for %s: origin is in observe mode", origin.Name)
+			continue
+		}
+
+		before := s.publishedIPs(key)
+		restoreCtx := cloudflare.WithRecordMetadata(ctx, cloudflare.RecordMetadata{State: recordStateRestored, Since: s.now()})
+		err := s.getDNSClientForOrigin(origin).ReplaceRecords(restoreCtx, origin.Name, origin.RecordType, record.IPs)
+		s.recordDNSChangeEvent(origin, nil, record.IPs, recordStateRestored, resultOf(err), "", err)
+		s.recordMutation(withManualChange(ctx, "snapshot restored"), origin, before, record.IPs, recordStateRestored, "", err)
+		if err != nil {
+			return errors.Wrapf(err, "failed to restore DNS records for %s", origin.Name)
+		}
+		s.logf("Restored %s (%s) to %v", origin.Name, origin.RecordType, record.IPs)
+
+		_, levels := s.activePriorityLevels(origin, key, record.IPs)
+		priority, detected := detectCurrentPriority(sortPriorityLevels(levels), record.IPs)
+		s.updateOriginStatus(key, priority, record.IPs, detected)
+	}
+	return nil
+}
+
+func (s *Service) validateSnapshotRecord(record SnapshotRecord) error {
+	if len(record.IPs) == 0 {
+		return errors.Newf("snapshot record %s (%s) has no IPs", record.Name, record.Type)
+	}
+	for _, ip := range record.IPs {
+		if err := s.validateIPType(record.Type, ip); err != nil {
+			return errors.Wrapf(err, "snapshot record %s", record.Name)
+		}
+	}
+	if disallowed := s.disallowedIPs(record.Zone, record.IPs); len(disallowed) > 0 {
+		return errors.Wrapf(ErrOutsideAllowlist, "snapshot record %s: %v", record.Name, disallowed)
+	}
+	return nil
+}
+
+// MarshalSnapshot encodes snapshot as JSON or YAML.
+func MarshalSnapshot(snapshot Snapshot, format string) ([]byte, error) {
+	switch format {
+	case SnapshotFormatJSON:
+		data, err := json.MarshalIndent(snapshot, "", "  ")
+		return data, errors.WithStack(err)
+	case SnapshotFormatYAML:
+		data, err := yaml.Marshal(snapshot)
+		return data, errors.WithStack(err)
+	default:
+		return nil, errors.Wrapf(ErrUnknownSnapshotFormat, "%s", format)
+	}
+}
+
+// ParseSnapshot decodes a JSON or YAML snapshot.
+func ParseSnapshot(data []byte, format string) (Snapshot, error) {
+	var snapshot Snapshot
+	var err error
+	switch format {
+	case SnapshotFormatJSON:
+		err = json.Unmarshal(data, &snapshot)
+	case SnapshotFormatYAML:
+		err = yaml.Unmarshal(data, &snapshot)
+	default:
+		return Snapshot{}, errors.Wrapf(ErrUnknownSnapshotFormat, "%s", format)
+	}
+	if err != nil {
+		return Snapshot{}, errors.Wrap(err, "failed to parse snapshot")
+	}
+	return snapshot, nil
+}
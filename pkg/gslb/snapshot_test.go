@@ -0,0 +1,125 @@
+package gslb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+	"github.com/cockroachdb/errors"
+)
+
+func snapshotTestOrigin() config.OriginConfig {
+	return config.OriginConfig{
+		Name:       "example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.168.1.1"}},
+			{Priority: 50, IPs: []string{"192.168.1.2"}},
+		},
+	}
+}
+
+func TestExportSnapshot(t *testing.T) {
+	origin := snapshotTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+
+	var live []string
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		records := make([]dns.RecordResponse, 0, len(live))
+		for _, ip := range live {
+			records = append(records, dns.RecordResponse{Name: name, Type: dns.RecordResponseTypeA, Content: ip})
+		}
+		return records, nil
+	}
+
+	// Without records or status, the highest priority level is expected
+	snapshot, err := service.ExportSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("ExportSnapshot returned error: %v", err)
+	}
+	if len(snapshot.Records) != 1 || !sameStringSet(snapshot.Records[0].IPs, []string{"192.168.1.1"}) {
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+
+	// Live records are used before the origin has been checked
+	live = []string{"192.168.1.2"}
+	snapshot, _ = service.ExportSnapshot(context.Background())
+	if !sameStringSet(snapshot.Records[0].IPs, []string{"192.168.1.2"}) {
+		t.Errorf("expected live records, got %v", snapshot.Records[0].IPs)
+	}
+
+	// The published state wins once the origin has been checked
+	service.updateOriginStatus(originKeyFor(origin), 100, []string{"192.168.1.1"}, true)
+	snapshot, _ = service.ExportSnapshot(context.Background())
+	if !sameStringSet(snapshot.Records[0].IPs, []string{"192.168.1.1"}) {
+		t.Errorf("expected published IPs, got %v", snapshot.Records[0].IPs)
+	}
+}
+
+func TestApplySnapshot(t *testing.T) {
+	origin := snapshotTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+
+	var replaced []string
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		replaced = newContents
+		return nil
+	}
+
+	snapshot := Snapshot{Records: []SnapshotRecord{{Zone: "default", Name: "example.com", Type: "A", IPs: []string{"192.168.1.2"}}}}
+	if err := service.ApplySnapshot(context.Background(), snapshot); err != nil {
+		t.Fatalf("ApplySnapshot returned error: %v", err)
+	}
+	if !sameStringSet(replaced, []string{"192.168.1.2"}) {
+		t.Errorf("unexpected replaced records %v", replaced)
+	}
+	status := service.OriginStatuses()[originKeyFor(origin)]
+	if !status.Initialized || status.CurrentPriority != 50 {
+		t.Errorf("expected status at priority 50, got %+v", status)
+	}
+}
+
+func TestApplySnapshot_RejectsUnmanagedRecords(t *testing.T) {
+	service, dnsClientMock := createTestService(snapshotTestOrigin())
+
+	calls := 0
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		calls++
+		return nil
+	}
+
+	snapshot := Snapshot{Records: []SnapshotRecord{
+		{Zone: "default", Name: "example.com", Type: "A", IPs: []string{"192.168.1.1"}},
+		{Zone: "default", Name: "other.example.com", Type: "A", IPs: []string{"192.168.1.9"}},
+	}}
+	if err := service.ApplySnapshot(context.Background(), snapshot); !errors.Is(err, ErrSnapshotRecordNotManaged) {
+		t.Fatalf("expected ErrSnapshotRecordNotManaged, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("expected no DNS changes for an invalid snapshot, got %d", calls)
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	snapshot := Snapshot{Records: []SnapshotRecord{{Zone: "default", Name: "example.com", Type: "AAAA", IPs: []string{"2001:db8::1"}, Proxied: true}}}
+
+	for _, format := range []string{SnapshotFormatJSON, SnapshotFormatYAML} {
+		data, err := MarshalSnapshot(snapshot, format)
+		if err != nil {
+			t.Fatalf("MarshalSnapshot(%s) returned error: %v", format, err)
+		}
+		parsed, err := ParseSnapshot(data, format)
+		if err != nil {
+			t.Fatalf("ParseSnapshot(%s) returned error: %v", format, err)
+		}
+		if len(parsed.Records) != 1 || parsed.Records[0].Name != "example.com" || !parsed.Records[0].Proxied || parsed.Records[0].IPs[0] != "2001:db8::1" {
+			t.Errorf("%s round trip mismatch: %+v", format, parsed)
+		}
+	}
+
+	if _, err := MarshalSnapshot(snapshot, "toml"); !errors.Is(err, ErrUnknownSnapshotFormat) {
+		t.Errorf("expected ErrUnknownSnapshotFormat, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package gslb
+
+import (
+	"context"
+	"log"
+	"net"
+	"strconv"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/cloudflare"
+)
+
+// spectrumOrigins reads and replaces the origins of Spectrum applications.
+type spectrumOrigins interface {
+	Origins(ctx context.Context, appID string) ([]string, error)
+	SetOrigins(ctx context.Context, appID string, origins []string) error
+}
+
+// buildSpectrumClients creates one Spectrum client per Cloudflare zone used by
+// an origin with spectrum configured.
+func buildSpectrumClients(cfg *config.Config, limiter *cloudflare.RateLimiter, logger *log.Logger) map[string]spectrumOrigins {
+	zones := make(map[string]config.ZoneConfig)
+	for _, zone := range cfg.CloudflareZoneIDs {
+		zones[zone.Name] = zone
+	}
+
+	clients := make(map[string]spectrumOrigins)
+	for _, origin := range cfg.Origins {
+		if !origin.Spectrum.Enabled() {
+			continue
+		}
+		zone, ok := zones[origin.ZoneName]
+		if !ok || clients[zone.Name] != nil {
+			continue
+		}
+		if zone.EffectiveProvider() != config.ProviderCloudflare {
+			logger.Printf("Origin %s configures spectrum, but zone %s is not on Cloudflare; ignoring", origin.Name, zone.Name)
+			continue
+		}
+
+		credentials := cfg.CredentialsForZone(zone.Name)
+		clients[zone.Name] = cloudflare.NewSpectrumClient(credentials.APIToken, zone.ZoneID, cloudflareClientOptions(cfg, credentials, limiter)...)
+	}
+	return clients
+}
+
+// syncSpectrum points the origin's Spectrum application at ips, if one is
+// configured and it does not already use them. Origins in observe mode are
+// never changed.
+func (s *Service) syncSpectrum(ctx context.Context, origin config.OriginConfig, ips []string) {
+	if !origin.Spectrum.Enabled() || origin.IsObserveOnly() {
+		return
+	}
+	client, ok := s.spectrumClients[origin.ZoneName]
+	if !ok {
+		return
+	}
+
+	appID := origin.Spectrum.AppID
+	current, err := client.Origins(ctx, appID)
+	if err != nil {
+		s.logf("Failed to get Spectrum application %s for %s: %v", appID, origin.Name, err)
+		return
+	}
+
+	desired := spectrumOriginAddresses(origin.Spectrum, ips)
+	if sameIPSet(current, desired) {
+		return
+	}
+	if err := client.SetOrigins(ctx, appID, desired); err != nil {
+		s.logf("Failed to update Spectrum application %s for %s: %v", appID, origin.Name, err)
+		reportError("spectrum_update", origin, err)
+		return
+	}
+	s.logf("Updated Spectrum application %s for %s from %v to %v", appID, origin.Name, current, desired)
+}
+
+// spectrumOriginAddresses formats ips as origin_direct addresses, e.g. "tcp://192.0.2.1:22".
+func spectrumOriginAddresses(spectrum *config.SpectrumConfig, ips []string) []string {
+	port := strconv.Itoa(spectrum.Port)
+	addresses := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addresses = append(addresses, spectrum.EffectiveProtocol()+"://"+net.JoinHostPort(ip, port))
+	}
+	return addresses
+}
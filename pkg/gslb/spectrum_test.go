@@ -0,0 +1,109 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+type fakeSpectrumOrigins struct {
+	origins  map[string][]string
+	setCalls int
+}
+
+func (f *fakeSpectrumOrigins) Origins(ctx context.Context, appID string) ([]string, error) {
+	return f.origins[appID], nil
+}
+
+func (f *fakeSpectrumOrigins) SetOrigins(ctx context.Context, appID string, origins []string) error {
+	f.setCalls++
+	f.origins[appID] = origins
+	return nil
+}
+
+func TestServiceCheckOrigin_SpectrumFailover(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "ssh.example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.168.1.1"}},
+			{Priority: 50, IPs: []string{"192.168.1.2"}},
+		},
+		Spectrum: &config.SpectrumConfig{AppID: "app-1", Port: 22},
+	}
+
+	service, dnsClientMock := createTestService(origin)
+	spectrum := &fakeSpectrumOrigins{origins: map[string][]string{"app-1": {"tcp://192.168.1.1:22"}}}
+	service.spectrumClients = map[string]spectrumOrigins{"default": spectrum}
+
+	current := "192.168.1.1"
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "record-1", Name: name, Type: dns.RecordResponseTypeA, Content: current}}, nil
+	}
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		current = newContents[0]
+		return nil
+	}
+
+	healthy := hcmock.NewCheckerMock(func(ip string) error { return nil })
+	service.checkOrigin(context.Background(), origin, healthy)
+	if spectrum.setCalls != 0 {
+		t.Fatalf("expected no Spectrum update while the application is in sync, got %d", spectrum.setCalls)
+	}
+
+	primaryDown := hcmock.NewCheckerMock(func(ip string) error {
+		if ip == "192.168.1.1" {
+			return fmt.Errorf("unhealthy")
+		}
+		return nil
+	})
+	service.checkOrigin(context.Background(), origin, primaryDown)
+	if current != "192.168.1.2" {
+		t.Fatalf("expected DNS failover, got %s", current)
+	}
+	if got := spectrum.origins["app-1"]; len(got) != 1 || got[0] != "tcp://192.168.1.2:22" {
+		t.Errorf("expected Spectrum origin to fail over, got %v", got)
+	}
+
+	// An application changed elsewhere is brought back in line even without a DNS change
+	spectrum.origins["app-1"] = []string{"tcp://192.0.2.99:22"}
+	service.checkOrigin(context.Background(), origin, primaryDown)
+	if got := spectrum.origins["app-1"]; len(got) != 1 || got[0] != "tcp://192.168.1.2:22" {
+		t.Errorf("expected Spectrum origin to be resynced, got %v", got)
+	}
+}
+
+func TestServiceCheckOrigin_SpectrumObserveMode(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "ssh.example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		Mode:       config.OriginModeObserve,
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.168.1.1"}},
+		},
+		Spectrum: &config.SpectrumConfig{AppID: "app-1", Port: 22},
+	}
+
+	service, _ := createTestService(origin)
+	spectrum := &fakeSpectrumOrigins{origins: map[string][]string{}}
+	service.spectrumClients = map[string]spectrumOrigins{"default": spectrum}
+
+	service.checkOrigin(context.Background(), origin, hcmock.NewCheckerMock(func(ip string) error { return nil }))
+	if spectrum.setCalls != 0 {
+		t.Errorf("expected no Spectrum update in observe mode, got %d", spectrum.setCalls)
+	}
+}
+
+func TestSpectrumOriginAddresses(t *testing.T) {
+	got := spectrumOriginAddresses(&config.SpectrumConfig{Port: 5353, Protocol: config.SpectrumProtocolUDP}, []string{"192.0.2.1", "2001:db8::1"})
+	want := []string{"udp://192.0.2.1:5353", "udp://[2001:db8::1]:5353"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("spectrumOriginAddresses() = %v, want %v", got, want)
+	}
+}
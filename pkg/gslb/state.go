@@ -0,0 +1,155 @@
+package gslb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/cloudflare"
+)
+
+// stateStore persists origin state so that restarts and other instances can pick it up.
+type stateStore interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Put(ctx context.Context, key string, value []byte) error
+}
+
+// sharedOriginState is the persisted form of an origin's state.
+type sharedOriginState struct {
+	CurrentPriority int       `json:"current_priority"`
+	CurrentIPs      []string  `json:"current_ips"`
+	ActiveSet       string    `json:"active_set,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// fingerprint identifies the state without its timestamp, to skip writes that change nothing.
+func (st sharedOriginState) fingerprint() string {
+	ips := append([]string(nil), st.CurrentIPs...)
+	sort.Strings(ips)
+	return fmt.Sprintf("%d|%v|%s", st.CurrentPriority, ips, st.ActiveSet)
+}
+
+func newStateStore(cfg *config.Config, limiter *cloudflare.RateLimiter) stateStore {
+	if !cfg.StateStore.Enabled() {
+		return nil
+	}
+
+	// KV namespaces belong to the account, so the account's credentials are used
+	credentials := cfg.CredentialsForAccount(cfg.StateStore.AccountID)
+	return cloudflare.NewKVStore(credentials.APIToken, cfg.StateStore.AccountID, cfg.StateStore.NamespaceID, cloudflareClientOptions(cfg, credentials, limiter)...)
+}
+
+func (s *Service) stateKey(originKey string) string {
+	return s.config.StateStore.EffectiveKeyPrefix() + originKey
+}
+
+// restoreState loads the shared state of every origin that has no local state yet.
+func (s *Service) restoreState(ctx context.Context) {
+	if s.stateStore == nil {
+		return
+	}
+
+	for _, origin := range s.config.Origins {
+		originKey := originKeyFor(origin)
+		data, ok, err := s.stateStore.Get(ctx, s.stateKey(originKey))
+		if err != nil {
+			s.logf("Failed to load shared state for %s: %v", origin.Name, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		var state sharedOriginState
+		if err := json.Unmarshal(data, &state); err != nil {
+			s.logf("Ignoring invalid shared state for %s: %v", origin.Name, err)
+			continue
+		}
+
+		s.originStatusMutex.Lock()
+		status := s.originStatus[originKey]
+		if status == nil {
+			status = &OriginStatus{}
+			s.originStatus[originKey] = status
+		}
+		if !status.Initialized {
+			status.CurrentPriority = state.CurrentPriority
+			status.CurrentIPs = state.CurrentIPs
+			status.Initialized = true
+		}
+		s.originStatusMutex.Unlock()
+
+		if _, known := origin.IPSets[state.ActiveSet]; known {
+			s.activeSetsMutex.Lock()
+			if s.activeSets == nil {
+				s.activeSets = make(map[string]string)
+			}
+			if _, exists := s.activeSets[originKey]; !exists {
+				s.activeSets[originKey] = state.ActiveSet
+			}
+			s.activeSetsMutex.Unlock()
+		}
+
+		s.rememberSavedState(originKey, state.fingerprint())
+
+		s.logf("Restored shared state for %s (%s): priority %d, IPs %v", origin.Name, origin.RecordType, state.CurrentPriority, state.CurrentIPs)
+	}
+}
+
+// persistState writes the origin's state to the shared store if it changed since the last write.
+func (s *Service) persistState(ctx context.Context, origin config.OriginConfig) {
+	if s.stateStore == nil {
+		return
+	}
+
+	originKey := originKeyFor(origin)
+
+	s.originStatusMutex.RLock()
+	status, ok := s.originStatus[originKey]
+	if !ok || !status.Initialized {
+		s.originStatusMutex.RUnlock()
+		return
+	}
+	state := sharedOriginState{
+		CurrentPriority: status.CurrentPriority,
+		CurrentIPs:      append([]string(nil), status.CurrentIPs...),
+		UpdatedAt:       s.now().UTC(),
+	}
+	s.originStatusMutex.RUnlock()
+
+	s.activeSetsMutex.RLock()
+	state.ActiveSet = s.activeSets[originKey]
+	s.activeSetsMutex.RUnlock()
+
+	fingerprint := state.fingerprint()
+	s.stateMutex.Lock()
+	unchanged := s.savedStates[originKey] == fingerprint
+	s.stateMutex.Unlock()
+	if unchanged {
+		return
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		s.logf("Failed to encode shared state for %s: %v", origin.Name, err)
+		return
+	}
+	if err := s.stateStore.Put(ctx, s.stateKey(originKey), data); err != nil {
+		s.logf("Failed to save shared state for %s: %v", origin.Name, err)
+		return
+	}
+
+	s.rememberSavedState(originKey, fingerprint)
+}
+
+func (s *Service) rememberSavedState(originKey, fingerprint string) {
+	s.stateMutex.Lock()
+	defer s.stateMutex.Unlock()
+	if s.savedStates == nil {
+		s.savedStates = make(map[string]string)
+	}
+	s.savedStates[originKey] = fingerprint
+}
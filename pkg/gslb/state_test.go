@@ -0,0 +1,117 @@
+package gslb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+type memoryStateStore struct {
+	values map[string][]byte
+	puts   int
+}
+
+func (m *memoryStateStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, ok := m.values[key]
+	return value, ok, nil
+}
+
+func (m *memoryStateStore) Put(ctx context.Context, key string, value []byte) error {
+	m.values[key] = value
+	m.puts++
+	return nil
+}
+
+func TestPersistState_WritesOnlyChanges(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.168.1.1"}},
+			{Priority: 50, IPs: []string{"192.168.1.2"}},
+		},
+	}
+	service, dnsClientMock := createTestService(origin)
+	store := &memoryStateStore{values: map[string][]byte{}}
+	service.stateStore = store
+
+	current := "192.168.1.1"
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "record-1", Name: name, Type: dns.RecordResponseTypeA, Content: current}}, nil
+	}
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		current = newContents[0]
+		return nil
+	}
+	down := map[string]bool{}
+	checker := hcmock.NewCheckerMock(func(ip string) error {
+		if down[ip] {
+			return fmt.Errorf("unhealthy")
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+	service.checkOrigin(ctx, origin, checker)
+	service.persistState(ctx, origin)
+	service.checkOrigin(ctx, origin, checker)
+	service.persistState(ctx, origin)
+	if store.puts != 1 {
+		t.Fatalf("expected a single write for unchanged state, got %d", store.puts)
+	}
+
+	down["192.168.1.1"] = true
+	service.checkOrigin(ctx, origin, checker)
+	service.persistState(ctx, origin)
+	if store.puts != 2 {
+		t.Fatalf("expected a write after failover, got %d", store.puts)
+	}
+
+	var state sharedOriginState
+	if err := json.Unmarshal(store.values["gslb:"+originKeyFor(origin)], &state); err != nil {
+		t.Fatalf("failed to decode stored state: %v", err)
+	}
+	if state.CurrentPriority != 50 || !sameStringSet(state.CurrentIPs, []string{"192.168.1.2"}) {
+		t.Errorf("unexpected stored state %+v", state)
+	}
+}
+
+func TestRestoreState(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		IPSets: map[string][]config.PriorityLevel{
+			"blue":  {{Priority: 100, IPs: []string{"192.168.1.1"}}},
+			"green": {{Priority: 100, IPs: []string{"192.168.2.1"}}},
+		},
+	}
+	service, _ := createTestService(origin)
+	originKey := originKeyFor(origin)
+
+	data, _ := json.Marshal(sharedOriginState{CurrentPriority: 100, CurrentIPs: []string{"192.168.2.1"}, ActiveSet: "green"})
+	store := &memoryStateStore{values: map[string][]byte{"gslb:" + originKey: data}}
+	service.stateStore = store
+
+	service.restoreState(context.Background())
+
+	status := service.OriginStatuses()[originKey]
+	if !status.Initialized || !sameStringSet(status.CurrentIPs, []string{"192.168.2.1"}) {
+		t.Errorf("unexpected restored status %+v", status)
+	}
+	if set, _ := service.activePriorityLevels(origin, originKey, nil); set != "green" {
+		t.Errorf("expected active set green, got %q", set)
+	}
+
+	// Restored state is not written back unchanged
+	service.persistState(context.Background(), origin)
+	if store.puts != 0 {
+		t.Errorf("expected no writes, got %d", store.puts)
+	}
+}
@@ -0,0 +1,201 @@
+package gslb
+
+import (
+	"sort"
+	"time"
+)
+
+// Results of a check cycle, reported as OriginStatus.LastResult.
+const (
+	CheckResultUnchanged     = "unchanged"
+	CheckResultChanged       = "changed"
+	CheckResultObserved      = "observed"
+	CheckResultNotApplied    = "not_applied"
+	CheckResultNoHealthyIPs  = "no_healthy_ips"
+	CheckResultNoValidIPs    = "no_valid_ips"
+	CheckResultBlocked       = "blocked"
+	CheckResultNoLevels      = "no_priority_levels"
+	CheckResultRecordsFailed = "dns_records_error"
+	CheckResultCheckerFailed = "health_checker_error"
+	CheckResultHeld          = "held"
+)
+
+// Health states of an origin, reported by OriginReports.
+const (
+	HealthUnknown  = "unknown"
+	HealthHealthy  = "healthy"
+	HealthFailover = "failover"
+	HealthDegraded = "degraded"
+	HealthDown     = "down"
+)
+
+// FailoverRecord is the last change of the published IPs of an origin.
+type FailoverRecord struct {
+	Time        time.Time `json:"time"`
+	OldIPs      []string  `json:"old_ips"`
+	NewIPs      []string  `json:"new_ips"`
+	OldPriority int       `json:"old_priority"`
+	NewPriority int       `json:"new_priority"`
+	Reason      string    `json:"reason"`
+	ObserveOnly bool      `json:"observe_only,omitempty"`
+}
+
+// OriginReport is the status of one origin as served by the status API.
+type OriginReport struct {
+	Name            string             `json:"name"`
+	Zone            string             `json:"zone"`
+	RecordType      string             `json:"record_type"`
+	Health          string             `json:"health"`
+	CurrentIPs      []string           `json:"current_ips"`
+	CurrentPriority int                `json:"current_priority"`
+	MaxPriority     int                `json:"max_priority"`
+	UsingPriority   bool               `json:"using_priority"`
+	ActiveIPSet     string             `json:"active_ip_set,omitempty"`
+	IPHealth        map[string]bool    `json:"ip_health,omitempty"`
+	Scores          map[string]float64 `json:"scores,omitempty"`
+	BlockedIPs      []string           `json:"blocked_ips,omitempty"`
+	LastCheck       *time.Time         `json:"last_check"`
+	LastResult      string             `json:"last_result,omitempty"`
+	LastError       string             `json:"last_error,omitempty"`
+	LastFailover    *FailoverRecord    `json:"last_failover"`
+	LatencySLO      *LatencySLOReport  `json:"latency_slo,omitempty"`
+	// Hold is set while automatic DNS changes of the origin are paused.
+	Hold *Hold `json:"hold,omitempty"`
+	// Availability covers the 24h, 7d and 30d windows, in that order.
+	Availability []AvailabilityReport `json:"availability"`
+}
+
+// checkOutcome is what a check cycle reports through recordCheckResult.
+type checkOutcome struct {
+	result      string
+	err         error
+	maxPriority int
+}
+
+// withStatus calls update with the status of originKey, creating it if needed.
+func (s *Service) withStatus(originKey string, update func(*OriginStatus)) {
+	s.originStatusMutex.Lock()
+	defer s.originStatusMutex.Unlock()
+
+	status := s.originStatus[originKey]
+	if status == nil {
+		status = &OriginStatus{}
+		s.originStatus[originKey] = status
+	}
+	update(status)
+}
+
+func (s *Service) recordCheckResult(originKey string, outcome checkOutcome) {
+	s.withStatus(originKey, func(status *OriginStatus) {
+		status.LastResult = outcome.result
+		status.LastError = ""
+		if outcome.err != nil {
+			status.LastError = outcome.err.Error()
+		}
+		if outcome.maxPriority != 0 {
+			status.MaxPriority = outcome.maxPriority
+		}
+	})
+	s.recordCycle(originKey, outcome.err)
+}
+
+// recordIPHealth stores the result of the last probe of ip and reports
+// whether it changed. The first result of an IP is a change when it is
+// unhealthy.
+func (s *Service) recordIPHealth(originKey, ip string, healthy bool) bool {
+	var changed bool
+	s.withStatus(originKey, func(status *OriginStatus) {
+		if status.IPHealth == nil {
+			status.IPHealth = make(map[string]bool)
+		}
+		previous, known := status.IPHealth[ip]
+		changed = known && previous != healthy || !known && !healthy
+		status.IPHealth[ip] = healthy
+	})
+	return changed
+}
+
+func (s *Service) recordFailover(originKey string, record FailoverRecord) {
+	record.OldIPs = append([]string(nil), record.OldIPs...)
+	record.NewIPs = append([]string(nil), record.NewIPs...)
+	s.withStatus(originKey, func(status *OriginStatus) {
+		status.LastFailover = &record
+	})
+}
+
+// OriginReports returns the status of every configured origin, including
+// those that have not been checked yet, sorted by zone, name and record type.
+func (s *Service) OriginReports() []OriginReport {
+	statuses := s.OriginStatuses()
+
+	reports := make([]OriginReport, 0, len(s.config.Origins))
+	for _, origin := range s.config.Origins {
+		originKey := originKeyFor(origin)
+		status := statuses[originKey]
+		report := OriginReport{
+			Name:            origin.Name,
+			Zone:            origin.ZoneName,
+			RecordType:      origin.RecordType,
+			Health:          healthState(status),
+			CurrentIPs:      status.CurrentIPs,
+			CurrentPriority: status.CurrentPriority,
+			MaxPriority:     status.MaxPriority,
+			UsingPriority:   status.Initialized && status.MaxPriority != 0 && status.CurrentPriority == status.MaxPriority,
+			IPHealth:        status.IPHealth,
+			Scores:          status.Scores,
+			BlockedIPs:      status.BlockedIPs,
+			LastResult:      status.LastResult,
+			LastError:       status.LastError,
+			LastFailover:    status.LastFailover,
+		}
+		if report.CurrentIPs == nil {
+			report.CurrentIPs = []string{}
+		}
+		if !status.LastCheck.IsZero() {
+			lastCheck := status.LastCheck
+			report.LastCheck = &lastCheck
+		}
+		if origin.HasIPSets() {
+			report.ActiveIPSet, _ = s.activePriorityLevels(origin, originKey, status.CurrentIPs)
+		}
+		if origin.LatencySLO.Enabled() {
+			slo := s.slo.report(originKey, origin.LatencySLO, s.now())
+			report.LatencySLO = &slo
+		}
+		if hold, ok := s.holds.get(originKey); ok {
+			report.Hold = &hold
+		}
+		report.Availability = s.availability.report(originKey, s.now())
+		reports = append(reports, report)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		a, b := reports[i], reports[j]
+		if a.Zone != b.Zone {
+			return a.Zone < b.Zone
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.RecordType < b.RecordType
+	})
+	return reports
+}
+
+// healthState summarizes the status of an origin.
+func healthState(status OriginStatus) string {
+	switch {
+	case !status.Initialized && status.LastResult == "":
+		return HealthUnknown
+	case status.LastResult == CheckResultNoHealthyIPs:
+		return HealthDown
+	case status.Degraded:
+		return HealthDegraded
+	case status.MaxPriority != 0 && status.CurrentPriority < status.MaxPriority:
+		return HealthFailover
+	case status.Initialized:
+		return HealthHealthy
+	default:
+		return HealthUnknown
+	}
+}
@@ -0,0 +1,127 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func statusTestOrigin() config.OriginConfig {
+	return config.OriginConfig{
+		Name:       "example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		HealthCheck: config.HealthCheck{
+			Type:     "http",
+			Endpoint: "/health",
+			Timeout:  config.Seconds(5 * time.Second),
+		},
+		PriorityLevels: []config.PriorityLevel{
+			{Priority: 100, IPs: []string{"192.0.2.1"}},
+			{Priority: 50, IPs: []string{"198.51.100.1"}},
+		},
+		ReturnToPriority: true,
+	}
+}
+
+func TestOriginReports_TracksFailover(t *testing.T) {
+	origin := statusTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+
+	published := []string{"192.0.2.1"}
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		records := make([]dns.RecordResponse, 0, len(published))
+		for i, ip := range published {
+			records = append(records, dns.RecordResponse{ID: fmt.Sprint(i), Content: ip})
+		}
+		return records, nil
+	}
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		published = newContents
+		return nil
+	}
+
+	reports := service.OriginReports()
+	if len(reports) != 1 || reports[0].Health != HealthUnknown || reports[0].LastCheck != nil || reports[0].LastFailover != nil {
+		t.Fatalf("reports before any check = %+v, want one unknown origin", reports)
+	}
+
+	healthy := hcmock.NewCheckerMock(func(ip string) error { return nil })
+	service.checkOrigin(context.Background(), origin, healthy)
+	report := service.OriginReports()[0]
+	if report.Health != HealthHealthy || !report.UsingPriority || report.LastResult != CheckResultUnchanged || report.LastCheck == nil {
+		t.Fatalf("report after a healthy check = %+v", report)
+	}
+	if !report.IPHealth["192.0.2.1"] {
+		t.Errorf("ip health = %v, want 192.0.2.1 healthy", report.IPHealth)
+	}
+
+	primaryDown := hcmock.NewCheckerMock(func(ip string) error {
+		if ip == "192.0.2.1" {
+			return errors.New("unhealthy")
+		}
+		return nil
+	})
+	service.checkOrigin(context.Background(), origin, primaryDown)
+	report = service.OriginReports()[0]
+	if report.Health != HealthFailover || report.UsingPriority || report.LastResult != CheckResultChanged {
+		t.Fatalf("report after failover = %+v", report)
+	}
+	if report.CurrentPriority != 50 || report.MaxPriority != 100 || !sameStringSet(report.CurrentIPs, []string{"198.51.100.1"}) {
+		t.Errorf("report after failover = %+v, want priority 50 of 100 publishing 198.51.100.1", report)
+	}
+	if report.IPHealth["192.0.2.1"] || !report.IPHealth["198.51.100.1"] {
+		t.Errorf("ip health = %v, want only 198.51.100.1 healthy", report.IPHealth)
+	}
+	failover := report.LastFailover
+	if failover == nil || failover.OldPriority != 100 || failover.NewPriority != 50 ||
+		!sameStringSet(failover.OldIPs, []string{"192.0.2.1"}) || !sameStringSet(failover.NewIPs, []string{"198.51.100.1"}) || failover.Reason == "" {
+		t.Errorf("last failover = %+v", failover)
+	}
+
+	allDown := hcmock.NewCheckerMock(func(ip string) error { return errors.New("unhealthy") })
+	service.checkOrigin(context.Background(), origin, allDown)
+	if report = service.OriginReports()[0]; report.Health != HealthDown || report.LastResult != CheckResultNoHealthyIPs {
+		t.Errorf("report without healthy IPs = %+v", report)
+	}
+	if report.LastFailover == nil || report.LastFailover.NewPriority != 50 {
+		t.Errorf("last failover should be kept, got %+v", report.LastFailover)
+	}
+}
+
+func TestOriginReports_RecordsDNSErrors(t *testing.T) {
+	origin := statusTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return nil, errors.New("rate limited")
+	}
+
+	service.checkOrigin(context.Background(), origin, hcmock.NewCheckerMock(func(ip string) error { return nil }))
+	report := service.OriginReports()[0]
+	if report.LastResult != CheckResultRecordsFailed || report.LastError != "rate limited" || report.Health != HealthUnknown {
+		t.Errorf("report = %+v, want the DNS error", report)
+	}
+}
+
+func TestOriginStatuses_CopiesReportedState(t *testing.T) {
+	origin := statusTestOrigin()
+	service, _ := createTestService(origin)
+	originKey := originKeyFor(origin)
+	service.recordIPHealth(originKey, "192.0.2.1", true)
+	service.recordFailover(originKey, FailoverRecord{NewIPs: []string{"192.0.2.1"}})
+
+	snapshot := service.OriginStatuses()[originKey]
+	snapshot.IPHealth["192.0.2.1"] = false
+	snapshot.LastFailover.NewPriority = 10
+
+	status := service.OriginStatuses()[originKey]
+	if !status.IPHealth["192.0.2.1"] || status.LastFailover.NewPriority != 0 {
+		t.Errorf("modifying a snapshot changed the status: %+v", status)
+	}
+}
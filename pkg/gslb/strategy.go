@@ -0,0 +1,260 @@
+package gslb
+
+import (
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrUnknownStrategy is returned when an origin references a strategy that is not registered
+var ErrUnknownStrategy = errors.New("unknown failover strategy")
+
+// ProbeResult is the outcome of a single health check against an IP.
+type ProbeResult struct {
+	Healthy bool
+	Latency time.Duration
+	// Score is the composite health score (0-100) when scoring is enabled for the origin.
+	Score float64
+}
+
+// SelectionRequest carries everything a Strategy needs to pick the IPs to publish.
+type SelectionRequest struct {
+	Origin             config.OriginConfig
+	Levels             []config.PriorityLevel // sorted by descending priority
+	CurrentIPs         []string
+	CurrentPriority    int
+	CurrentPrioritySet bool
+	// Probe health checks ip, which belongs to the given priority level.
+	Probe func(ip string, priority int) ProbeResult
+}
+
+// Selection is the set of IPs a Strategy decided to publish.
+type Selection struct {
+	Priority int
+	IPs      []string
+}
+
+// Strategy decides which IPs to publish for an origin based on health checks.
+type Strategy interface {
+	Select(req SelectionRequest) (Selection, bool)
+}
+
+var (
+	strategiesMutex sync.RWMutex
+	strategies      = map[string]Strategy{
+		config.StrategyPriority:     priorityStrategy{},
+		config.StrategyRoundRobin:   roundRobinStrategy{},
+		config.StrategyRandom:       randomStrategy{},
+		config.StrategyLeastLatency: leastLatencyStrategy{},
+		config.StrategyWeighted:     weightedStrategy{},
+	}
+)
+
+// RegisterStrategy makes a custom strategy available to origins under name.
+func RegisterStrategy(name string, strategy Strategy) {
+	strategiesMutex.Lock()
+	defer strategiesMutex.Unlock()
+	strategies[name] = strategy
+}
+
+// LookupStrategy returns the strategy registered under name. An empty name selects the priority strategy.
+func LookupStrategy(name string) (Strategy, error) {
+	if name == "" {
+		name = config.StrategyPriority
+	}
+
+	strategiesMutex.RLock()
+	defer strategiesMutex.RUnlock()
+
+	strategy, ok := strategies[name]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownStrategy, "%s", name)
+	}
+	return strategy, nil
+}
+
+// priorityStrategy publishes every IP of the highest priority level whose IPs are all healthy.
+type priorityStrategy struct{}
+
+func (priorityStrategy) Select(req SelectionRequest) (Selection, bool) {
+	evaluate := func(level config.PriorityLevel) ([]string, bool) {
+		log.Printf("Checking priority level %d (%d IPs)", level.Priority, len(level.IPs))
+		if len(level.IPs) == 0 {
+			return nil, false
+		}
+		for _, ip := range level.IPs {
+			if !req.Probe(ip, level.Priority).Healthy {
+				return nil, false
+			}
+		}
+		return level.IPs, true
+	}
+
+	priority, ips, ok := selectPriorityLevel(req.Origin, evaluate, req.Levels, req.CurrentPriority, req.CurrentPrioritySet)
+	return Selection{Priority: priority, IPs: ips}, ok
+}
+
+// candidate is a single IP together with the priority level it belongs to.
+type candidate struct {
+	ip       string
+	priority int
+}
+
+func flattenCandidates(levels []config.PriorityLevel) []candidate {
+	var candidates []candidate
+	for _, level := range levels {
+		for _, ip := range level.IPs {
+			candidates = append(candidates, candidate{ip: ip, priority: level.Priority})
+		}
+	}
+	return candidates
+}
+
+// keepCurrent returns the currently published IP when it is a healthy candidate.
+func keepCurrent(req SelectionRequest, candidates []candidate) (Selection, bool) {
+	if len(req.CurrentIPs) != 1 {
+		return Selection{}, false
+	}
+	for _, c := range candidates {
+		if c.ip == req.CurrentIPs[0] && req.Probe(c.ip, c.priority).Healthy {
+			return Selection{Priority: c.priority, IPs: []string{c.ip}}, true
+		}
+	}
+	return Selection{}, false
+}
+
+// healthyCandidates probes every candidate except the current IP, which
+// keepCurrent has already found unhealthy.
+func healthyCandidates(req SelectionRequest, candidates []candidate) []candidate {
+	healthy := make([]candidate, 0, len(candidates))
+	for _, c := range candidates {
+		if len(req.CurrentIPs) == 1 && c.ip == req.CurrentIPs[0] {
+			continue
+		}
+		if req.Probe(c.ip, c.priority).Healthy {
+			healthy = append(healthy, c)
+		}
+	}
+	return healthy
+}
+
+// roundRobinStrategy publishes a single IP and, when it fails, moves to the
+// next healthy IP in configuration order, wrapping around at the end.
+type roundRobinStrategy struct{}
+
+func (roundRobinStrategy) Select(req SelectionRequest) (Selection, bool) {
+	candidates := flattenCandidates(req.Levels)
+	if len(candidates) == 0 {
+		return Selection{}, false
+	}
+	if selection, ok := keepCurrent(req, candidates); ok {
+		return selection, true
+	}
+
+	start := 0
+	if len(req.CurrentIPs) > 0 {
+		for i, c := range candidates {
+			if c.ip == req.CurrentIPs[0] {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	for i := 0; i < len(candidates); i++ {
+		c := candidates[(start+i)%len(candidates)]
+		if c.ip == firstIP(req.CurrentIPs) {
+			continue
+		}
+		if req.Probe(c.ip, c.priority).Healthy {
+			return Selection{Priority: c.priority, IPs: []string{c.ip}}, true
+		}
+	}
+	return Selection{}, false
+}
+
+// randomStrategy publishes a single IP and, when it fails, moves to a random healthy IP.
+type randomStrategy struct{}
+
+func (randomStrategy) Select(req SelectionRequest) (Selection, bool) {
+	candidates := flattenCandidates(req.Levels)
+	if selection, ok := keepCurrent(req, candidates); ok {
+		return selection, true
+	}
+
+	healthy := healthyCandidates(req, candidates)
+	if len(healthy) == 0 {
+		return Selection{}, false
+	}
+	// #nosec G404 - IP selection does not need a cryptographically secure source
+	c := healthy[rand.IntN(len(healthy))]
+	return Selection{Priority: c.priority, IPs: []string{c.ip}}, true
+}
+
+// leastLatencyStrategy publishes the healthy IP with the lowest check latency.
+type leastLatencyStrategy struct{}
+
+func (leastLatencyStrategy) Select(req SelectionRequest) (Selection, bool) {
+	var best *candidate
+	var bestLatency time.Duration
+	for _, c := range flattenCandidates(req.Levels) {
+		result := req.Probe(c.ip, c.priority)
+		if !result.Healthy {
+			continue
+		}
+		if best == nil || result.Latency < bestLatency {
+			chosen := c
+			best = &chosen
+			bestLatency = result.Latency
+		}
+	}
+	if best == nil {
+		return Selection{}, false
+	}
+	return Selection{Priority: best.priority, IPs: []string{best.ip}}, true
+}
+
+// weightedStrategy publishes a single IP and, when it fails, moves to a
+// healthy IP chosen at random in proportion to origin.Weights (default 1).
+type weightedStrategy struct{}
+
+func (weightedStrategy) Select(req SelectionRequest) (Selection, bool) {
+	candidates := flattenCandidates(req.Levels)
+	if selection, ok := keepCurrent(req, candidates); ok {
+		return selection, true
+	}
+
+	healthy := healthyCandidates(req, candidates)
+	total := 0
+	for _, c := range healthy {
+		total += candidateWeight(req.Origin, c.ip)
+	}
+	if total == 0 {
+		return Selection{}, false
+	}
+
+	// #nosec G404 - IP selection does not need a cryptographically secure source
+	pick := rand.IntN(total)
+	for _, c := range healthy {
+		pick -= candidateWeight(req.Origin, c.ip)
+		if pick < 0 {
+			return Selection{Priority: c.priority, IPs: []string{c.ip}}, true
+		}
+	}
+	return Selection{}, false
+}
+
+func candidateWeight(origin config.OriginConfig, ip string) int {
+	weight, ok := origin.Weights[ip]
+	if !ok {
+		return 1
+	}
+	if weight < 0 {
+		return 0
+	}
+	return weight
+}
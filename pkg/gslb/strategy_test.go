@@ -0,0 +1,154 @@
+package gslb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+)
+
+func probeFrom(unhealthy map[string]bool, latencies map[string]time.Duration) func(string, int) ProbeResult {
+	return func(ip string, _ int) ProbeResult {
+		return ProbeResult{Healthy: !unhealthy[ip], Latency: latencies[ip]}
+	}
+}
+
+func strategyLevels() []config.PriorityLevel {
+	return []config.PriorityLevel{
+		{Priority: 100, IPs: []string{"192.0.2.1", "192.0.2.2"}},
+		{Priority: 50, IPs: []string{"192.0.2.3"}},
+	}
+}
+
+func TestLookupStrategy(t *testing.T) {
+	for _, name := range []string{"", config.StrategyPriority, config.StrategyRoundRobin, config.StrategyRandom, config.StrategyLeastLatency, config.StrategyWeighted} {
+		if _, err := LookupStrategy(name); err != nil {
+			t.Errorf("LookupStrategy(%q) returned error: %v", name, err)
+		}
+	}
+
+	if _, err := LookupStrategy("unknown"); !errors.Is(err, ErrUnknownStrategy) {
+		t.Errorf("expected ErrUnknownStrategy, got %v", err)
+	}
+}
+
+type fixedStrategy struct{ ip string }
+
+func (f fixedStrategy) Select(SelectionRequest) (Selection, bool) {
+	return Selection{IPs: []string{f.ip}}, true
+}
+
+func TestRegisterStrategy(t *testing.T) {
+	RegisterStrategy("fixed-test", fixedStrategy{ip: "192.0.2.9"})
+
+	strategy, err := LookupStrategy("fixed-test")
+	if err != nil {
+		t.Fatalf("LookupStrategy returned error: %v", err)
+	}
+	selection, ok := strategy.Select(SelectionRequest{})
+	if !ok || !sameStringSet(selection.IPs, []string{"192.0.2.9"}) {
+		t.Errorf("unexpected selection %v (ok=%v)", selection, ok)
+	}
+}
+
+func TestRoundRobinStrategy(t *testing.T) {
+	tests := []struct {
+		name       string
+		currentIPs []string
+		unhealthy  map[string]bool
+		want       string
+	}{
+		{name: "keeps healthy current IP", currentIPs: []string{"192.0.2.2"}, want: "192.0.2.2"},
+		{name: "advances to next IP", currentIPs: []string{"192.0.2.1"}, unhealthy: map[string]bool{"192.0.2.1": true}, want: "192.0.2.2"},
+		{name: "skips unhealthy IPs", currentIPs: []string{"192.0.2.1"}, unhealthy: map[string]bool{"192.0.2.1": true, "192.0.2.2": true}, want: "192.0.2.3"},
+		{name: "wraps around", currentIPs: []string{"192.0.2.3"}, unhealthy: map[string]bool{"192.0.2.3": true}, want: "192.0.2.1"},
+		{name: "starts at first IP without current", want: "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection, ok := roundRobinStrategy{}.Select(SelectionRequest{
+				Levels:     strategyLevels(),
+				CurrentIPs: tt.currentIPs,
+				Probe:      probeFrom(tt.unhealthy, nil),
+			})
+			if !ok {
+				t.Fatal("expected a selection")
+			}
+			if !sameStringSet(selection.IPs, []string{tt.want}) {
+				t.Errorf("expected %s, got %v", tt.want, selection.IPs)
+			}
+		})
+	}
+}
+
+func TestRoundRobinStrategy_AllUnhealthy(t *testing.T) {
+	unhealthy := map[string]bool{"192.0.2.1": true, "192.0.2.2": true, "192.0.2.3": true}
+	if _, ok := (roundRobinStrategy{}).Select(SelectionRequest{
+		Levels:     strategyLevels(),
+		CurrentIPs: []string{"192.0.2.1"},
+		Probe:      probeFrom(unhealthy, nil),
+	}); ok {
+		t.Error("expected no selection when every IP is unhealthy")
+	}
+}
+
+func TestLeastLatencyStrategy(t *testing.T) {
+	latencies := map[string]time.Duration{
+		"192.0.2.1": 30 * time.Millisecond,
+		"192.0.2.2": 10 * time.Millisecond,
+		"192.0.2.3": 5 * time.Millisecond,
+	}
+	selection, ok := leastLatencyStrategy{}.Select(SelectionRequest{
+		Levels: strategyLevels(),
+		Probe:  probeFrom(map[string]bool{"192.0.2.3": true}, latencies),
+	})
+	if !ok {
+		t.Fatal("expected a selection")
+	}
+	if !sameStringSet(selection.IPs, []string{"192.0.2.2"}) || selection.Priority != 100 {
+		t.Errorf("expected fastest healthy IP 192.0.2.2 at priority 100, got %v at %d", selection.IPs, selection.Priority)
+	}
+}
+
+func TestWeightedStrategy(t *testing.T) {
+	origin := config.OriginConfig{
+		Weights: map[string]int{"192.0.2.1": 0, "192.0.2.2": 0, "192.0.2.3": 5},
+	}
+
+	for i := 0; i < 20; i++ {
+		selection, ok := weightedStrategy{}.Select(SelectionRequest{
+			Origin:     origin,
+			Levels:     strategyLevels(),
+			CurrentIPs: []string{"192.0.2.1"},
+			Probe:      probeFrom(map[string]bool{"192.0.2.1": true}, nil),
+		})
+		if !ok {
+			t.Fatal("expected a selection")
+		}
+		if !sameStringSet(selection.IPs, []string{"192.0.2.3"}) {
+			t.Fatalf("zero-weight IP was selected: %v", selection.IPs)
+		}
+	}
+
+	origin.Weights["192.0.2.3"] = 0
+	if _, ok := (weightedStrategy{}).Select(SelectionRequest{
+		Origin: origin,
+		Levels: strategyLevels(),
+		Probe:  probeFrom(nil, nil),
+	}); ok {
+		t.Error("expected no selection when every weight is zero")
+	}
+}
+
+func TestRandomStrategy_KeepsHealthyCurrent(t *testing.T) {
+	selection, ok := randomStrategy{}.Select(SelectionRequest{
+		Levels:     strategyLevels(),
+		CurrentIPs: []string{"192.0.2.3"},
+		Probe:      probeFrom(nil, nil),
+	})
+	if !ok || !sameStringSet(selection.IPs, []string{"192.0.2.3"}) {
+		t.Errorf("expected current IP to be kept, got %v (ok=%v)", selection.IPs, ok)
+	}
+}
@@ -0,0 +1,118 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+// processStart is approximately when the process started. Unlike the
+// service, it is not reset by a reload.
+var processStart = time.Now()
+
+// summaryTracker counts the failovers since the last summary.
+type summaryTracker struct {
+	mu        sync.Mutex
+	since     time.Time
+	failovers map[string]int
+}
+
+func (t *summaryTracker) reset(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.since = now
+	t.failovers = nil
+}
+
+func (t *summaryTracker) recordFailover(origin string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.failovers == nil {
+		t.failovers = make(map[string]int)
+	}
+	t.failovers[origin]++
+}
+
+// take returns the start of the period and its failovers, and starts a new
+// period at now.
+func (t *summaryTracker) take(now time.Time) (time.Time, map[string]int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	since, failovers := t.since, t.failovers
+	if since.IsZero() {
+		since = processStart
+	}
+	t.since = now
+	t.failovers = nil
+	return since, failovers
+}
+
+// originLabel names an origin in summaries, e.g. "www.example.com (A)".
+func originLabel(origin config.OriginConfig) string {
+	return fmt.Sprintf("%s (%s)", origin.Name, origin.RecordType)
+}
+
+// runSummaries sends a summary to the notifiers on the configured schedule
+// until the service stops.
+func (s *Service) runSummaries(ctx context.Context) {
+	defer s.wg.Done()
+	defer s.recoverPanic(map[string]string{"task": "summary"})
+
+	next := s.config.Summary.NextAfter(s.now())
+	s.logf("Sending the next summary at %s", next.Format(time.RFC3339))
+	timer := time.NewTimer(next.Sub(s.now()))
+	defer timer.Stop()
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			now := s.now()
+			s.sendSummary(ctx, s.buildSummary(now))
+			timer.Reset(s.config.Summary.NextAfter(now).Sub(s.now()))
+		}
+	}
+}
+
+// buildSummary reports the period ending at now and starts a new one.
+func (s *Service) buildSummary(now time.Time) notifier.Summary {
+	since, failovers := s.summary.take(now)
+	summary := notifier.Summary{
+		Since:     since,
+		Until:     now,
+		Uptime:    now.Sub(processStart),
+		Origins:   len(s.config.Origins),
+		Monitored: int(s.runningMonitors.Load()),
+		Failovers: failovers,
+	}
+	for _, report := range s.OriginReports() {
+		if report.Health != HealthHealthy {
+			summary.Degraded = append(summary.Degraded, fmt.Sprintf("%s (%s): %s", report.Name, report.RecordType, report.Health))
+		}
+	}
+	return summary
+}
+
+// sendSummary sends summary to every notifier that supports summaries.
+func (s *Service) sendSummary(ctx context.Context, summary notifier.Summary) {
+	for _, n := range s.notifiers {
+		summaryNotifier, ok := n.(notifier.SummaryNotifier)
+		if !ok {
+			continue
+		}
+		err := s.sendWithTimeout(ctx, n, "summary", func(ctx context.Context) error {
+			return summaryNotifier.NotifySummary(ctx, summary)
+		})
+		if err != nil {
+			s.logf("Failed to send summary to %s: %v", s.notifierName(n), err)
+		}
+	}
+	s.logf("Summary sent: %d of %d origins monitored, %d failovers, %d degraded",
+		summary.Monitored, summary.Origins, summary.TotalFailovers(), len(summary.Degraded))
+}
@@ -0,0 +1,72 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+// summaryNotifier records the summaries it is sent.
+type summaryNotifier struct {
+	MockNotifier
+	summaries []notifier.Summary
+}
+
+func (n *summaryNotifier) NotifySummary(ctx context.Context, summary notifier.Summary) error {
+	n.summaries = append(n.summaries, summary)
+	return nil
+}
+
+func TestService_Summary(t *testing.T) {
+	origin := statusTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+	withSummaries := &summaryNotifier{}
+	service.notifiers = []notifier.Notifier{withSummaries}
+
+	published := []string{"192.0.2.1"}
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "1", Content: published[0]}}, nil
+	}
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		published = newContents
+		return nil
+	}
+
+	start := time.Now()
+	service.summary.reset(start)
+	primaryDown := hcmock.NewCheckerMock(func(ip string) error {
+		if ip == "192.0.2.1" {
+			return errors.New("unhealthy")
+		}
+		return nil
+	})
+	service.checkOrigin(context.Background(), origin, primaryDown)
+	service.runningMonitors.Add(1)
+
+	end := start.Add(time.Hour)
+	service.sendSummary(context.Background(), service.buildSummary(end))
+	if len(withSummaries.summaries) != 1 {
+		t.Fatalf("Expected one summary, got %d", len(withSummaries.summaries))
+	}
+	summary := withSummaries.summaries[0]
+	if !summary.Since.Equal(start) || !summary.Until.Equal(end) || summary.Origins != 1 || summary.Monitored != 1 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+	if summary.Failovers["example.com (A)"] != 1 || summary.TotalFailovers() != 1 {
+		t.Errorf("Expected one failover, got %v", summary.Failovers)
+	}
+	if len(summary.Degraded) != 1 || summary.Degraded[0] != "example.com (A): failover" {
+		t.Errorf("Expected the origin to be degraded, got %v", summary.Degraded)
+	}
+
+	// The next period starts empty
+	summary = service.buildSummary(end.Add(time.Hour))
+	if !summary.Since.Equal(end) || len(summary.Failovers) != 0 {
+		t.Errorf("Expected a new period, got %+v", summary)
+	}
+}
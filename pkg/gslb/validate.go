@@ -0,0 +1,127 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/cloudflare"
+	"github.com/bootjp/cloudflare-gslb/pkg/healthcheck"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrNoHealthyIP is reported by ValidateDeployment for an origin whose IPs
+// all failed their health check
+var ErrNoHealthyIP = errors.New("no IP passed the health check")
+
+// ValidationCheck is the outcome of one check of ValidateDeployment
+type ValidationCheck struct {
+	// Name is what was checked, such as "origins" or "origin www.example.com (A)"
+	Name string
+	// Detail describes a successful check, such as how many IPs are healthy
+	Detail string
+	Err    error
+}
+
+// ValidateDeployment checks that cfg can be deployed without changing
+// anything: that its origins are consistent, that the credentials can manage
+// the records of their Cloudflare zones and, with probe, that every origin
+// has an IP passing its health check. It reports the checks in that order.
+func ValidateDeployment(ctx context.Context, cfg *config.Config, probe bool) []ValidationCheck {
+	checks := []ValidationCheck{validateOrigins(cfg)}
+
+	limiter := cloudflare.NewRateLimiter(cfg.APIRateLimit.EffectiveRequestsPerSecond(), cfg.APIRateLimit.EffectiveBurst())
+	for _, group := range groupZonesByCredentials(cfg) {
+		check := ValidationCheck{
+			Name:   "access to " + strings.Join(group.zoneNames, ", "),
+			Detail: "DNS records can be managed",
+		}
+		check.Err = checkGroupPermissions(ctx, cfg, group, limiter)
+		checks = append(checks, check)
+	}
+
+	if probe {
+		checks = append(checks, probeOrigins(ctx, cfg)...)
+	}
+	return checks
+}
+
+// validateOrigins runs the checks of NewService that the configuration
+// loader does not.
+func validateOrigins(cfg *config.Config) ValidationCheck {
+	noun := "origins"
+	if len(cfg.Origins) == 1 {
+		noun = "origin"
+	}
+	check := ValidationCheck{Name: "origins", Detail: fmt.Sprintf("%d %s", len(cfg.Origins), noun)}
+	if len(cfg.CloudflareZoneIDs) == 0 {
+		check.Err = ErrNoCloudflareZoneConfig
+		return check
+	}
+	errs := []error{config.ValidateOrigins(cfg)}
+	for _, origin := range cfg.Origins {
+		if _, err := LookupStrategy(origin.Strategy); err != nil {
+			errs = append(errs, errors.Wrapf(err, "origin %s", origin.Name))
+		}
+	}
+	check.Err = errors.Join(errs...)
+	return check
+}
+
+// probeOrigins checks every IP of every origin once, the origins in
+// parallel. Hostnames and other dynamic targets are not resolved.
+func probeOrigins(ctx context.Context, cfg *config.Config) []ValidationCheck {
+	checks := make([]ValidationCheck, len(cfg.Origins))
+	var wg sync.WaitGroup
+	for i, origin := range cfg.Origins {
+		checks[i].Name = fmt.Sprintf("origin %s (%s)", origin.Name, origin.RecordType)
+		wg.Add(1)
+		go func(check *ValidationCheck, origin config.OriginConfig) {
+			defer wg.Done()
+			checker, err := healthcheck.NewChecker(origin.HealthCheck)
+			if err != nil {
+				check.Err = errors.Wrap(err, "failed to create health checker")
+				return
+			}
+			check.Detail, check.Err = probeOrigin(ctx, origin, checker)
+		}(&checks[i], origin)
+	}
+	wg.Wait()
+	return checks
+}
+
+// probeOrigin checks every static IP of origin once with checker.
+func probeOrigin(ctx context.Context, origin config.OriginConfig, checker healthcheck.Checker) (string, error) {
+	var ips []string
+	seen := make(map[string]bool)
+	for _, target := range origin.Targets() {
+		if net.ParseIP(target) != nil && !seen[target] {
+			seen[target] = true
+			ips = append(ips, target)
+		}
+	}
+	if len(ips) == 0 {
+		return "no static IPs to check", nil
+	}
+
+	var failures []string
+	for _, ip := range ips {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if err := checker.Check(ip); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", ip, err))
+		}
+	}
+	if len(failures) == len(ips) {
+		return "", errors.Wrapf(ErrNoHealthyIP, "%s", strings.Join(failures, "; "))
+	}
+	detail := fmt.Sprintf("%d of %d IPs healthy", len(ips)-len(failures), len(ips))
+	if len(failures) > 0 {
+		detail += " (" + strings.Join(failures, "; ") + ")"
+	}
+	return detail, nil
+}
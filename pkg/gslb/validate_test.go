@@ -0,0 +1,78 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/healthcheck"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+)
+
+func TestValidateOrigins(t *testing.T) {
+	if check := validateOrigins(&config.Config{}); !errors.Is(check.Err, ErrNoCloudflareZoneConfig) {
+		t.Errorf("validateOrigins() without zones = %v, want ErrNoCloudflareZoneConfig", check.Err)
+	}
+
+	cfg := &config.Config{
+		CloudflareZoneIDs: []config.ZoneConfig{{ZoneID: "zone-1", Name: "example.com"}},
+		Origins: []config.OriginConfig{
+			{Name: "www", ZoneName: "example.com", RecordType: "A", HealthCheck: config.HealthCheck{Type: config.HealthCheckTypeHTTP}, PriorityLevels: []config.PriorityLevel{{Priority: 1, IPs: []string{"192.0.2.1"}}}},
+		},
+	}
+	if check := validateOrigins(cfg); check.Err != nil || check.Detail != "1 origin" {
+		t.Errorf("validateOrigins() = %+v, want no error", check)
+	}
+
+	cfg.Origins[0].Strategy = "nonexistent"
+	if check := validateOrigins(cfg); check.Err == nil {
+		t.Error("validateOrigins() with an unknown strategy should fail")
+	}
+}
+
+func TestProbeOrigin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	origin := config.OriginConfig{
+		Name:        "www",
+		RecordType:  "A",
+		HealthCheck: config.HealthCheck{Type: config.HealthCheckTypeHTTP, Endpoint: "/", Timeout: config.Seconds(time.Second)},
+	}
+	httpChecker, err := healthcheck.NewChecker(origin.HealthCheck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The checker connects to port 80, so send it to the test server instead
+	var checked []string
+	checker := hcmock.NewCheckerMock(func(ip string) error {
+		checked = append(checked, ip)
+		return httpChecker.Check(net.JoinHostPort(ip, port))
+	})
+
+	origin.PriorityLevels = []config.PriorityLevel{{Priority: 1, IPs: []string{"www.example.com"}}}
+	if detail, err := probeOrigin(context.Background(), origin, checker); err != nil || detail != "no static IPs to check" {
+		t.Errorf("probeOrigin() of hostnames = %q, %v", detail, err)
+	}
+
+	origin.PriorityLevels = []config.PriorityLevel{{Priority: 1, IPs: []string{"127.0.0.1", "127.0.0.1"}}}
+	_, err = probeOrigin(context.Background(), origin, checker)
+	if !errors.Is(err, ErrNoHealthyIP) || !strings.Contains(err.Error(), healthcheck.ErrUnexpectedStatusCode.Error()) {
+		t.Errorf("probeOrigin() = %v, want ErrNoHealthyIP from the status code", err)
+	}
+	if len(checked) != 1 {
+		t.Errorf("Expected each IP to be checked once, got %v", checked)
+	}
+}
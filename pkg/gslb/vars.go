@@ -0,0 +1,52 @@
+package gslb
+
+import "time"
+
+// Vars are internal counters of the service, for quick inspection with
+// expvar and similar tools.
+type Vars struct {
+	Origins         int `json:"origins"`
+	RunningMonitors int `json:"running_monitors"`
+	// StartedAt is when the service was started, which is the last reload
+	// when the configuration has been reloaded. It is nil until Start.
+	StartedAt *time.Time `json:"started_at"`
+	// LastCheck is the time of the latest check of any origin.
+	LastCheck *time.Time `json:"last_check"`
+	// PendingNotifications is the number of notifications being sent.
+	PendingNotifications int `json:"pending_notifications"`
+	// HeldNotifications is the number of notifications held until the quiet
+	// hours of their notifier are over.
+	HeldNotifications int `json:"held_notifications"`
+	// DigestNotifications is the number of notifications waiting for the
+	// digest window of their notifier to end.
+	DigestNotifications int `json:"digest_notifications"`
+	// UnhealthyOrigins is the number of origins without a healthy IP in the
+	// last check.
+	UnhealthyOrigins int `json:"unhealthy_origins"`
+}
+
+// Vars returns the internal counters of the service.
+func (s *Service) Vars() Vars {
+	vars := Vars{
+		Origins:              len(s.config.Origins),
+		RunningMonitors:      int(s.runningMonitors.Load()),
+		PendingNotifications: int(s.pendingNotifications.Load()),
+		HeldNotifications:    s.quiet.len(),
+		DigestNotifications:  s.digests.len(),
+		StartedAt:            s.startedAt.Load(),
+	}
+
+	s.originStatusMutex.RLock()
+	defer s.originStatusMutex.RUnlock()
+	for _, status := range s.originStatus {
+		if status.LastResult == CheckResultNoHealthyIPs {
+			vars.UnhealthyOrigins++
+		}
+		if status.LastCheck.IsZero() || (vars.LastCheck != nil && !status.LastCheck.After(*vars.LastCheck)) {
+			continue
+		}
+		lastCheck := status.LastCheck
+		vars.LastCheck = &lastCheck
+	}
+	return vars
+}
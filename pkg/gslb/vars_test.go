@@ -0,0 +1,37 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestServiceVars(t *testing.T) {
+	origin := statusTestOrigin()
+	service, dnsClientMock := createTestService(origin)
+
+	vars := service.Vars()
+	if vars.Origins != 1 || vars.StartedAt != nil || vars.LastCheck != nil || vars.UnhealthyOrigins != 0 {
+		t.Fatalf("Vars() before Start = %+v", vars)
+	}
+
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "1", Content: "192.0.2.1"}}, nil
+	}
+	service.checkOrigin(context.Background(), origin, hcmock.NewCheckerMock(func(ip string) error { return errors.New("down") }))
+	vars = service.Vars()
+	if vars.LastCheck == nil || vars.UnhealthyOrigins != 1 {
+		t.Errorf("Vars() after a failed check = %+v", vars)
+	}
+
+	if err := service.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	service.Stop()
+	if vars := service.Vars(); vars.StartedAt == nil {
+		t.Errorf("Expected the start time, got %+v", vars)
+	}
+}
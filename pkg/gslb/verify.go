@@ -0,0 +1,137 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/cloudflare"
+	"github.com/bootjp/cloudflare-gslb/pkg/history"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrVerificationMismatch is returned when the live records do not match the expected contents
+var ErrVerificationMismatch = errors.New("live records do not match expected contents")
+
+// lookupFunc resolves host against a specific resolver address and returns the addresses found.
+type lookupFunc func(ctx context.Context, resolver, network, host string) ([]string, error)
+
+func lookupWithResolver(ctx context.Context, resolver, network, host string) ([]string, error) {
+	r := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "udp", resolver)
+		},
+	}
+	ips, err := r.LookupIP(ctx, network, host)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	out := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		out = append(out, ip.String())
+	}
+	return out, nil
+}
+
+// verifyDNSChange confirms that expected is live for the origin, retrying up to
+// the configured number of attempts. When verifying through the API and the
+// records still differ, the change is re-applied before the next attempt.
+func (s *Service) verifyDNSChange(ctx context.Context, dnsClient cloudflare.DNSClientInterface, origin config.OriginConfig, expected []string) error {
+	verify := origin.Verify
+	method := verify.EffectiveMethod()
+	if method == config.VerifyMethodDNS && origin.Proxied {
+		// Proxied records resolve to Cloudflare edge addresses, so only the API can confirm the content
+		s.logf("Origin %s is proxied, verifying through the API instead of DNS", origin.Name)
+		method = config.VerifyMethodAPI
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= verify.EffectiveAttempts(); attempt++ {
+		if attempt > 1 {
+			if err := cloudflare.SleepContext(ctx, verify.Interval()); err != nil {
+				return err
+			}
+		}
+
+		live, err := s.checkLiveContents(ctx, dnsClient, origin, method, expected)
+		if err == nil {
+			s.logf("Verified DNS records for %s via %s (attempt %d)", origin.Name, method, attempt)
+			return nil
+		}
+		lastErr = err
+		s.logf("DNS verification attempt %d for %s failed: %v", attempt, origin.Name, err)
+
+		if method == config.VerifyMethodAPI && errors.Is(err, ErrVerificationMismatch) {
+			s.reapplyDNSChange(ctx, dnsClient, origin, live, expected)
+		}
+	}
+
+	return lastErr
+}
+
+// reapplyDNSChange publishes expected again after a verification mismatch.
+// Like any other DNS change, it counts against the change limits and is
+// skipped once they are exhausted.
+func (s *Service) reapplyDNSChange(ctx context.Context, dnsClient cloudflare.DNSClientInterface, origin config.OriginConfig, live, expected []string) {
+	const reason = "re-applied after a verification mismatch"
+	originKey := originKeyFor(origin)
+	if allowed, limitReason, firstBlock := s.changeLimiter.allow(originKey, origin.ChangeLimit, s.now()); !allowed {
+		s.logf("Not re-applying DNS records for %s: %s", origin.Name, limitReason)
+		s.recordDNSChangeEvent(origin, live, expected, "", history.ResultSkipped, limitReason, nil)
+		if firstBlock {
+			s.sendAlert(ctx, notifier.EventTypeChangeLimitExceeded, origin, live, expected, limitReason)
+		}
+		return
+	}
+
+	err := dnsClient.ReplaceRecords(ctx, origin.Name, origin.RecordType, expected)
+	s.recordDNSChangeEvent(origin, live, expected, "", resultOf(err), reason, err)
+	s.recordMutation(ctx, origin, live, expected, "", reason, err)
+	if err != nil {
+		s.logf("Failed to re-apply DNS records for %s: %v", origin.Name, err)
+		return
+	}
+	s.changeLimiter.record(originKey, origin.ChangeLimit, s.now())
+}
+
+// checkLiveContents returns an error wrapping ErrVerificationMismatch, along
+// with the contents found, unless the live records of the origin are
+// expected. Through DNS, every resolver must serve exactly the expected set,
+// so a single stale answer shows up as a mismatch.
+func (s *Service) checkLiveContents(ctx context.Context, dnsClient cloudflare.DNSClientInterface, origin config.OriginConfig, method string, expected []string) ([]string, error) {
+	if method == config.VerifyMethodAPI {
+		records, err := dnsClient.GetDNSRecords(ctx, origin.Name, origin.RecordType)
+		if err != nil {
+			return nil, err
+		}
+		live := collectRecordIPs(records)
+		if !sameIPSet(live, expected) {
+			return live, errors.Wrapf(ErrVerificationMismatch, "expected %v, got %v", expected, live)
+		}
+		return live, nil
+	}
+
+	lookup := s.lookup
+	if lookup == nil {
+		lookup = lookupWithResolver
+	}
+	network := "ip4"
+	if origin.RecordType == "AAAA" {
+		network = "ip6"
+	}
+
+	for _, resolver := range origin.Verify.EffectiveResolvers() {
+		live, err := lookup(ctx, resolver, network, origin.Name)
+		if err != nil {
+			return nil, fmt.Errorf("resolver %s: %w", resolver, err)
+		}
+		if !sameIPSet(live, expected) {
+			return live, errors.Wrapf(ErrVerificationMismatch, "resolver %s: expected %v, got %v", resolver, expected, live)
+		}
+	}
+	return expected, nil
+}
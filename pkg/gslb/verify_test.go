@@ -0,0 +1,146 @@
+package gslb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+func TestVerifyDNSChange_APIReappliesOnMismatch(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		Verify:     &config.VerifyConfig{Attempts: 2, IntervalSeconds: config.Seconds(time.Second)},
+	}
+	service, dnsClientMock := createTestService(origin)
+
+	listCalls := 0
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		listCalls++
+		content := "192.168.1.1"
+		if listCalls > 1 {
+			content = "192.168.1.2"
+		}
+		return []dns.RecordResponse{{ID: "record-1", Name: name, Content: content}}, nil
+	}
+	replaceCallCount := 0
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		replaceCallCount++
+		return nil
+	}
+
+	err := service.verifyDNSChange(context.Background(), dnsClientMock, origin, []string{"192.168.1.2"})
+	if err != nil {
+		t.Fatalf("expected verification to succeed on retry, got %v", err)
+	}
+	if replaceCallCount != 1 {
+		t.Errorf("expected records to be re-applied once, got %d", replaceCallCount)
+	}
+}
+
+func TestVerifyDNSChange_DNSRequiresAllResolvers(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "www.example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		Verify: &config.VerifyConfig{
+			Method:    config.VerifyMethodDNS,
+			Resolvers: []string{"1.1.1.1:53", "8.8.8.8:53"},
+			Attempts:  1,
+		},
+	}
+	service, dnsClientMock := createTestService(origin)
+	service.lookup = func(ctx context.Context, resolver, network, host string) ([]string, error) {
+		if network != "ip4" || host != "www.example.com" {
+			t.Errorf("unexpected lookup %s %s", network, host)
+		}
+		if resolver == "8.8.8.8:53" {
+			return []string{"192.168.1.1"}, nil
+		}
+		return []string{"192.168.1.2"}, nil
+	}
+
+	err := service.verifyDNSChange(context.Background(), dnsClientMock, origin, []string{"192.168.1.2"})
+	if err == nil {
+		t.Fatal("expected verification to fail when a resolver serves stale records")
+	}
+}
+
+func TestVerifyDNSChange_DNSComparesEachResolver(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:       "www.example.com",
+		ZoneName:   "default",
+		RecordType: "A",
+		Verify: &config.VerifyConfig{
+			Method:    config.VerifyMethodDNS,
+			Resolvers: []string{"1.1.1.1:53", "8.8.8.8:53"},
+			Attempts:  1,
+		},
+	}
+	expected := []string{"192.168.1.1", "192.168.1.2"}
+
+	tests := []struct {
+		name    string
+		answers map[string][]string
+		wantErr bool
+	}{
+		{"every resolver serves the expected set", map[string][]string{"1.1.1.1:53": expected, "8.8.8.8:53": {"192.168.1.2", "192.168.1.1"}}, false},
+		{"each resolver serves half of the set", map[string][]string{"1.1.1.1:53": {"192.168.1.1"}, "8.8.8.8:53": {"192.168.1.2"}}, true},
+		{"one resolver serves a stale subset", map[string][]string{"1.1.1.1:53": {"192.168.1.1"}, "8.8.8.8:53": expected}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, dnsClientMock := createTestService(origin)
+			service.lookup = func(ctx context.Context, resolver, network, host string) ([]string, error) {
+				return tt.answers[resolver], nil
+			}
+
+			err := service.verifyDNSChange(context.Background(), dnsClientMock, origin, expected)
+			if tt.wantErr && !errors.Is(err, ErrVerificationMismatch) {
+				t.Errorf("expected ErrVerificationMismatch, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected verification to succeed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyDNSChange_ReapplyRespectsChangeLimit(t *testing.T) {
+	origin := config.OriginConfig{
+		Name:        "example.com",
+		ZoneName:    "default",
+		RecordType:  "A",
+		Verify:      &config.VerifyConfig{Attempts: 3, IntervalSeconds: config.Seconds(time.Millisecond)},
+		ChangeLimit: &config.ChangeLimitConfig{MaxChanges: 2, WindowSeconds: config.Seconds(time.Hour)},
+	}
+	service, dnsClientMock := createTestService(origin)
+	service.changeLimiter = newChangeLimiter(config.ChangeLimitConfig{})
+	// The change being verified was counted when it was applied
+	service.changeLimiter.record(originKeyFor(origin), origin.ChangeLimit, time.Now())
+
+	dnsClientMock.GetDNSRecordsFunc = func(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+		return []dns.RecordResponse{{ID: "record-1", Name: name, Content: "192.168.1.1"}}, nil
+	}
+	replaceCallCount := 0
+	dnsClientMock.ReplaceRecordsFunc = func(ctx context.Context, name, recordType string, newContents []string) error {
+		replaceCallCount++
+		return nil
+	}
+
+	err := service.verifyDNSChange(context.Background(), dnsClientMock, origin, []string{"192.168.1.2"})
+	if !errors.Is(err, ErrVerificationMismatch) {
+		t.Fatalf("expected ErrVerificationMismatch, got %v", err)
+	}
+	if replaceCallCount != 1 {
+		t.Errorf("expected a single re-apply within the change limit, got %d", replaceCallCount)
+	}
+	if ok, _, _ := service.changeLimiter.allow(originKeyFor(origin), origin.ChangeLimit, time.Now()); ok {
+		t.Error("expected the re-apply to be counted against the change limit")
+	}
+}
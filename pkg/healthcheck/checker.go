@@ -24,30 +24,31 @@ var (
 	ErrUnknownHealthCheckType = errors.New("unknown health check type")
 	ErrUnexpectedStatusCode   = errors.New("unexpected status code")
 	ErrUnexpectedICMPType     = errors.New("unexpected ICMP message type")
+	ErrNoEchoReply            = errors.New("no ICMP echo reply")
 )
 
 func NewChecker(hc config.HealthCheck) (Checker, error) {
 	switch hc.Type {
-	case "http":
+	case config.HealthCheckTypeHTTP:
 		return &HttpChecker{
 			Endpoint: hc.Endpoint,
 			Host:     hc.Host,
-			Timeout:  time.Duration(hc.Timeout) * time.Second,
+			Timeout:  hc.Timeout.Duration(),
 			Scheme:   "http",
 			Headers:  hc.Headers,
 		}, nil
-	case "https":
+	case config.HealthCheckTypeHTTPS:
 		return &HttpChecker{
 			Endpoint:           hc.Endpoint,
 			Host:               hc.Host,
-			Timeout:            time.Duration(hc.Timeout) * time.Second,
+			Timeout:            hc.Timeout.Duration(),
 			Scheme:             "https",
 			InsecureSkipVerify: hc.InsecureSkipVerify,
 			Headers:            hc.Headers,
 		}, nil
-	case "icmp":
+	case config.HealthCheckTypeICMP:
 		return &IcmpChecker{
-			Timeout: time.Duration(hc.Timeout) * time.Second,
+			Timeout: hc.Timeout.Duration(),
 		}, nil
 	default:
 		return nil, errors.WithStack(ErrUnknownHealthCheckType)
@@ -111,7 +112,7 @@ func (h *HttpChecker) Check(ip string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
-		return errors.WithStack(ErrUnexpectedStatusCode)
+		return errors.WithStack(&StatusCodeError{StatusCode: resp.StatusCode})
 	}
 
 	return nil
@@ -169,7 +170,7 @@ func (i *IcmpChecker) Check(ip string) error {
 
 	n, _, err := conn.ReadFrom(reply)
 	if err != nil {
-		return errors.WithStack(err)
+		return errors.WithStack(errors.Mark(err, ErrNoEchoReply))
 	}
 
 	parsedMsg, err := icmp.ParseMessage(protocol, reply[:n])
@@ -21,7 +21,7 @@ func TestNewChecker(t *testing.T) {
 				Type:     "http",
 				Endpoint: "/health",
 				Host:     "example.com",
-				Timeout:  5,
+				Timeout:  config.Seconds(5 * time.Second),
 			},
 			wantErr: false,
 		},
@@ -31,7 +31,7 @@ func TestNewChecker(t *testing.T) {
 				Type:     "https",
 				Endpoint: "/health",
 				Host:     "example.com",
-				Timeout:  5,
+				Timeout:  config.Seconds(5 * time.Second),
 			},
 			wantErr: false,
 		},
@@ -41,7 +41,7 @@ func TestNewChecker(t *testing.T) {
 				Type:               "https",
 				Endpoint:           "/health",
 				Host:               "example.com",
-				Timeout:            5,
+				Timeout:            config.Seconds(5 * time.Second),
 				InsecureSkipVerify: true,
 			},
 			wantErr: false,
@@ -50,7 +50,7 @@ func TestNewChecker(t *testing.T) {
 			name: "ICMP Checker",
 			hc: config.HealthCheck{
 				Type:    "icmp",
-				Timeout: 5,
+				Timeout: config.Seconds(5 * time.Second),
 			},
 			wantErr: false,
 		},
@@ -58,7 +58,7 @@ func TestNewChecker(t *testing.T) {
 			name: "Unknown Checker Type",
 			hc: config.HealthCheck{
 				Type:    "unknown",
-				Timeout: 5,
+				Timeout: config.Seconds(5 * time.Second),
 			},
 			wantErr: true,
 		},
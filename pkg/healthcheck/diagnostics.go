@@ -0,0 +1,68 @@
+package healthcheck
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// StatusCodeError is returned by HttpChecker when the response status is not
+// 2xx or 3xx. It matches ErrUnexpectedStatusCode.
+type StatusCodeError struct {
+	StatusCode int
+}
+
+func (e *StatusCodeError) Error() string {
+	return fmt.Sprintf("%s: %d", ErrUnexpectedStatusCode, e.StatusCode)
+}
+
+func (e *StatusCodeError) Is(target error) bool {
+	return target == ErrUnexpectedStatusCode
+}
+
+// Diagnostics are the details of a failed check that tell why it failed.
+type Diagnostics struct {
+	StatusCode int    // HTTP status of the response; 0 when there was none
+	TLSError   string // TLS handshake or certificate verification error
+	NoReply    bool   // the ICMP echo request was not answered
+}
+
+// Diagnose extracts the diagnostics from the error of a check. Checkers that
+// wrap another one, such as the Cloudflare Health Check combination, keep
+// them as long as they wrap the error.
+func Diagnose(err error) Diagnostics {
+	var diagnostics Diagnostics
+	if err == nil {
+		return diagnostics
+	}
+	var statusErr *StatusCodeError
+	if errors.As(err, &statusErr) {
+		diagnostics.StatusCode = statusErr.StatusCode
+	}
+	diagnostics.TLSError = tlsError(err)
+	diagnostics.NoReply = errors.Is(err, ErrNoEchoReply)
+	return diagnostics
+}
+
+// tlsError returns the TLS error in the chain of err, or "" if there is none.
+func tlsError(err error) string {
+	var verifyErr *tls.CertificateVerificationError
+	if errors.As(err, &verifyErr) {
+		return verifyErr.Error()
+	}
+	var alertErr tls.AlertError
+	if errors.As(err, &alertErr) {
+		return alertErr.Error()
+	}
+	var headerErr tls.RecordHeaderError
+	if errors.As(err, &headerErr) {
+		return headerErr.Error()
+	}
+	// Most handshake failures are plain errors prefixed by the tls package
+	if cause := errors.UnwrapAll(err).Error(); strings.HasPrefix(cause, "tls: ") {
+		return cause
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package healthcheck
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+func TestDiagnose_StatusCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	h := &HttpChecker{Endpoint: "/health", Timeout: 5 * time.Second, Scheme: "http"}
+	err := h.Check(server.URL[7:])
+	if !errors.Is(err, ErrUnexpectedStatusCode) {
+		t.Fatalf("Check() error = %v, want ErrUnexpectedStatusCode", err)
+	}
+	// Wrapping keeps the diagnostics, as the Cloudflare Health Check combination does
+	diagnostics := Diagnose(fmt.Errorf("local check: %w", err))
+	if diagnostics.StatusCode != http.StatusServiceUnavailable || diagnostics.TLSError != "" || diagnostics.NoReply {
+		t.Errorf("Diagnose() = %+v", diagnostics)
+	}
+}
+
+func TestDiagnose_TLSError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// The test server's certificate is self-signed
+	h := &HttpChecker{Endpoint: "/health", Timeout: 5 * time.Second, Scheme: "https", Host: "example.com"}
+	err := h.Check(server.URL[8:])
+	if err == nil {
+		t.Fatal("Expected a certificate error")
+	}
+	diagnostics := Diagnose(err)
+	if !strings.HasPrefix(diagnostics.TLSError, "tls: ") || diagnostics.StatusCode != 0 {
+		t.Errorf("Diagnose() = %+v", diagnostics)
+	}
+}
+
+func TestDiagnose_NoReply(t *testing.T) {
+	err := errors.WithStack(errors.Mark(errors.New("i/o timeout"), ErrNoEchoReply))
+	if diagnostics := Diagnose(err); !diagnostics.NoReply {
+		t.Errorf("Diagnose() = %+v", diagnostics)
+	}
+	if diagnostics := Diagnose(nil); diagnostics != (Diagnostics{}) {
+		t.Errorf("Diagnose(nil) = %+v", diagnostics)
+	}
+}
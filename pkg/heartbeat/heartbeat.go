@@ -0,0 +1,78 @@
+// Package heartbeat pings a dead man's switch such as healthchecks.io or
+// Cronitor, which alerts when the pings stop arriving.
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds a ping when no timeout is given.
+const DefaultTimeout = 10 * time.Second
+
+// maxReasonLength bounds the body of a failure ping.
+const maxReasonLength = 10000
+
+// Pinger pings a success URL and, optionally, a failure URL.
+type Pinger struct {
+	url        string
+	failURL    string
+	httpClient *http.Client
+}
+
+// New returns a pinger for url. failURL may be empty, in which case
+// failures are reported by not pinging.
+func New(url, failURL string, timeout time.Duration) *Pinger {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Pinger{url: url, failURL: failURL, httpClient: &http.Client{Timeout: timeout}}
+}
+
+// Success reports that the monitored work completed.
+func (p *Pinger) Success(ctx context.Context) error {
+	return p.ping(ctx, p.url, "")
+}
+
+// Fail reports that the monitored work failed, with reason in the body. It
+// does nothing without a failure URL.
+func (p *Pinger) Fail(ctx context.Context, reason string) error {
+	if p.failURL == "" {
+		return nil
+	}
+	if len(reason) > maxReasonLength {
+		reason = reason[:maxReasonLength]
+	}
+	return p.ping(ctx, p.failURL, reason)
+}
+
+// ping sends a GET, or a POST when there is a body, as both services accept either.
+func (p *Pinger) ping(ctx context.Context, url, body string) error {
+	method := http.MethodGet
+	var reader io.Reader
+	if body != "" {
+		method = http.MethodPost
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create heartbeat request: %w", err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send heartbeat: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("heartbeat URL returned status: %d", resp.StatusCode)
+	}
+	return nil
+}
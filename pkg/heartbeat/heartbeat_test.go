@@ -0,0 +1,49 @@
+package heartbeat
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPinger(t *testing.T) {
+	type request struct {
+		method, path, body string
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{r.Method, r.URL.Path, string(body)})
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	pinger := New(server.URL+"/uuid", server.URL+"/uuid/fail", 0)
+	if err := pinger.Success(context.Background()); err != nil {
+		t.Fatalf("Success() error = %v", err)
+	}
+	if err := pinger.Fail(context.Background(), "www.example.com: timeout"); err != nil {
+		t.Fatalf("Fail() error = %v", err)
+	}
+	want := []request{
+		{http.MethodGet, "/uuid", ""},
+		{http.MethodPost, "/uuid/fail", "www.example.com: timeout"},
+	}
+	if len(requests) != len(want) || requests[0] != want[0] || requests[1] != want[1] {
+		t.Errorf("requests = %+v, want %+v", requests, want)
+	}
+
+	// Without a failure URL, failures are only reported by the missing ping
+	requests = nil
+	if err := New(server.URL+"/uuid", "", 0).Fail(context.Background(), "reason"); err != nil || len(requests) != 0 {
+		t.Errorf("Fail() without a URL = %v with %d requests, want no request", err, len(requests))
+	}
+
+	if err := New(server.URL+"/broken", "", 0).Success(context.Background()); err == nil {
+		t.Error("Expected an error for a failed ping")
+	}
+}
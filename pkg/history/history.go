@@ -0,0 +1,227 @@
+// Package history keeps a persistent record of health transitions and DNS
+// changes for post-incident reviews.
+//
+// Events are appended as JSON lines to a single file. Events older than the
+// retention are dropped by rewriting the file, at most once per compaction
+// interval.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Types of recorded events.
+const (
+	// TypeHealth is a change in the health check result of an IP.
+	TypeHealth = "health"
+	// TypeDNSChange is an attempt to change the published IPs of an origin.
+	TypeDNSChange = "dns_change"
+	// TypeAvailability counts the checks of an origin in an hour and how
+	// many of them found a healthy IP.
+	TypeAvailability = "availability"
+)
+
+// Results of a DNS change.
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+	ResultSkipped = "skipped"
+)
+
+// compactInterval is how often Record drops events older than the retention.
+const compactInterval = time.Hour
+
+// maxLineSize is the longest event line Read accepts.
+const maxLineSize = 1 << 20
+
+// Event is a single entry of the history.
+type Event struct {
+	Time       time.Time `json:"time"`
+	Type       string    `json:"type"`
+	Origin     string    `json:"origin"`
+	Zone       string    `json:"zone"`
+	RecordType string    `json:"record_type"`
+	// IP and Healthy are set for TypeHealth.
+	IP      string `json:"ip,omitempty"`
+	Healthy *bool  `json:"healthy,omitempty"`
+	// OldIPs, NewIPs, State and Result are set for TypeDNSChange. State is
+	// the GSLB state that caused the change, e.g. "failover", and Reason
+	// explains a skipped change.
+	OldIPs []string `json:"old_ips,omitempty"`
+	NewIPs []string `json:"new_ips,omitempty"`
+	State  string   `json:"state,omitempty"`
+	Result string   `json:"result,omitempty"`
+	Reason string   `json:"reason,omitempty"`
+	Error  string   `json:"error,omitempty"`
+	// Checks and Up are set for TypeAvailability, which covers the hour of
+	// Time. An hour may be split across several events.
+	Checks int  `json:"checks,omitempty"`
+	Up     *int `json:"up,omitempty"`
+}
+
+// Filter selects events. Zero fields match every event.
+type Filter struct {
+	Origin string
+	Type   string
+	Since  time.Time
+	Until  time.Time
+	// Limit keeps only the newest Limit events.
+	Limit int
+}
+
+// Match reports whether event is selected by f, ignoring Limit.
+func (f Filter) Match(event Event) bool {
+	switch {
+	case f.Origin != "" && event.Origin != f.Origin:
+		return false
+	case f.Type != "" && event.Type != f.Type:
+		return false
+	case !f.Since.IsZero() && event.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !event.Time.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// Store records events to a file. The file is opened for each write, so
+// several stores of the same process may share it across reloads.
+type Store struct {
+	path      string
+	retention time.Duration
+	now       func() time.Time
+
+	mu          sync.Mutex
+	lastCompact time.Time
+}
+
+// Open returns a store writing to path and drops events older than
+// retention. A retention of zero keeps every event.
+func Open(path string, retention time.Duration) (*Store, error) {
+	s := &Store{path: path, retention: retention, now: time.Now}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event history: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to open event history: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Record appends event, setting its time if it is zero.
+func (s *Store) Record(event Event) error {
+	if event.Time.IsZero() {
+		event.Time = s.now()
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.now().Sub(s.lastCompact) >= compactInterval {
+		if err := s.compact(); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open event history: %w", err)
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return file.Close()
+}
+
+// Query returns the events selected by filter, oldest first.
+func (s *Store) Query(filter Filter) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Read(s.path, filter)
+}
+
+// compact rewrites the file without the events older than the retention
+// and without invalid lines, so that the next event starts on a new line.
+// s.mu must be held.
+func (s *Store) compact() error {
+	now := s.now()
+	s.lastCompact = now
+	var filter Filter
+	if s.retention > 0 {
+		filter.Since = now.Add(-s.retention)
+	}
+	events, err := Read(s.path, filter)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to compact event history: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to compact event history: %w", err)
+		}
+	}
+	if err := errors.Join(writer.Flush(), tmp.Close()); err != nil {
+		return fmt.Errorf("failed to compact event history: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to compact event history: %w", err)
+	}
+	return nil
+}
+
+// Read returns the events of the file at path selected by filter, oldest
+// first. Lines that are not valid events, such as a line cut short by a
+// crash, are skipped.
+func Read(path string, filter Filter) ([]Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event history: %w", err)
+	}
+	defer file.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		if filter.Match(event) {
+			events = append(events, event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read event history: %w", err)
+	}
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		events = events[len(events)-filter.Limit:]
+	}
+	return events, nil
+}
@@ -0,0 +1,112 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func healthy(v bool) *bool {
+	return &v
+}
+
+func TestStore_RecordAndQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	store, err := Open(path, 0)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Time: start, Type: TypeHealth, Origin: "www.example.com", IP: "192.0.2.1", Healthy: healthy(false)},
+		{Time: start.Add(time.Minute), Type: TypeDNSChange, Origin: "www.example.com", OldIPs: []string{"192.0.2.1"}, NewIPs: []string{"198.51.100.1"}, Result: ResultSuccess},
+		{Time: start.Add(2 * time.Minute), Type: TypeHealth, Origin: "api.example.com", IP: "192.0.2.9", Healthy: healthy(false)},
+		{Time: start.Add(3 * time.Minute), Type: TypeHealth, Origin: "www.example.com", IP: "192.0.2.1", Healthy: healthy(true)},
+	}
+	for _, event := range events {
+		if err := store.Record(event); err != nil {
+			t.Fatalf("Record returned error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []time.Time
+	}{
+		{"all", Filter{}, []time.Time{start, start.Add(time.Minute), start.Add(2 * time.Minute), start.Add(3 * time.Minute)}},
+		{"origin", Filter{Origin: "www.example.com"}, []time.Time{start, start.Add(time.Minute), start.Add(3 * time.Minute)}},
+		{"type", Filter{Type: TypeDNSChange}, []time.Time{start.Add(time.Minute)}},
+		{"range", Filter{Since: start.Add(time.Minute), Until: start.Add(3 * time.Minute)}, []time.Time{start.Add(time.Minute), start.Add(2 * time.Minute)}},
+		{"newest", Filter{Origin: "www.example.com", Limit: 2}, []time.Time{start.Add(time.Minute), start.Add(3 * time.Minute)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Query(tt.filter)
+			if err != nil {
+				t.Fatalf("Query returned error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Query returned %d events, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if !got[i].Time.Equal(tt.want[i]) {
+					t.Errorf("event %d at %v, want %v", i, got[i].Time, tt.want[i])
+				}
+			}
+		})
+	}
+
+	got, _ := store.Query(Filter{Type: TypeDNSChange})
+	if got[0].Result != ResultSuccess || len(got[0].NewIPs) != 1 || got[0].Healthy != nil {
+		t.Errorf("DNS change event was not kept intact: %+v", got[0])
+	}
+}
+
+func TestStore_DropsExpiredEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	now := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+
+	store, err := Open(path, 0)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	for _, age := range []time.Duration{72 * time.Hour, 36 * time.Hour, time.Hour} {
+		if err := store.Record(Event{Time: now.Add(-age), Type: TypeHealth}); err != nil {
+			t.Fatalf("Record returned error: %v", err)
+		}
+	}
+	// A line cut short by a crash is skipped rather than failing every query
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("failed to open history: %v", err)
+	}
+	if _, err := file.WriteString(`{"time":"2024-`); err != nil {
+		t.Fatalf("failed to write history: %v", err)
+	}
+	file.Close()
+
+	store = &Store{path: path, retention: 48 * time.Hour, now: func() time.Time { return now }}
+	if err := store.Record(Event{Type: TypeDNSChange}); err != nil {
+		t.Fatalf("Record returned error: %v", err)
+	}
+
+	events, err := Read(path, Filter{})
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events after compaction, got %d: %+v", len(events), events)
+	}
+	if !events[0].Time.Equal(now.Add(-36*time.Hour)) || !events[2].Time.Equal(now) {
+		t.Errorf("unexpected events after compaction: %+v", events)
+	}
+}
+
+func TestRead_MissingFile(t *testing.T) {
+	if _, err := Read(filepath.Join(t.TempDir(), "missing.log"), Filter{}); err == nil {
+		t.Error("expected an error for a missing history file")
+	}
+}
@@ -0,0 +1,311 @@
+// Package kubernetes reads GSLBOrigin custom resources from the Kubernetes
+// API, watches them for changes and writes the state of their origins back
+// to their status, so that the daemon can run as an operator. It also
+// resolves Services to the addresses of their load balancers, nodes or
+// endpoints. It speaks to the API server over HTTPS with the token of a
+// service account, without a client library.
+package kubernetes
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// The custom resource defining origins.
+const (
+	Group    = "gslb.bootjp.github.io"
+	Version  = "v1alpha1"
+	Kind     = "GSLBOrigin"
+	Resource = "gslborigins"
+)
+
+// serviceAccountDir holds the token and CA of the service account of a pod.
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// watchTimeout is how long a watch runs before it is started again.
+const watchTimeout = 5 * time.Minute
+
+// Options locate the API server and the GSLBOrigin resources. Outside a
+// pod, Server and TokenFile are required.
+type Options struct {
+	// Server is the URL of the API server (default: the in-cluster address).
+	Server string
+	// TokenFile holds the bearer token; it is read for every request, so
+	// that rotated tokens are used (default: the token of the service
+	// account).
+	TokenFile string
+	// CAFile is the PEM bundle the API server certificate is verified with
+	// (default: the CA of the service account in a pod, else the system
+	// roots).
+	CAFile string
+	// Namespace limits the resources to a namespace (default: all).
+	Namespace string
+	// LabelSelector limits the resources to those matching it.
+	LabelSelector string
+}
+
+// Client reads and updates GSLBOrigin resources and reads Services.
+type Client struct {
+	server        string
+	tokenFile     string
+	namespace     string
+	labelSelector string
+	httpClient    *http.Client
+}
+
+// Origin is a GSLBOrigin resource.
+type Origin struct {
+	Namespace  string
+	Name       string
+	Generation int64
+	// Spec is the origin, in the format of an entry of origins.
+	Spec json.RawMessage
+}
+
+// Key identifies the resource as namespace/name.
+func (o Origin) Key() string {
+	return o.Namespace + "/" + o.Name
+}
+
+// Snapshot is the resources read at once, with the resource version to
+// watch for changes from.
+type Snapshot struct {
+	ResourceVersion string
+	Origins         []Origin
+}
+
+// NewClient returns a client for opts, filling in the in-cluster defaults.
+func NewClient(opts Options) (*Client, error) {
+	inCluster := false
+	if opts.Server == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, errors.New("not running in a pod; the URL of the API server is required")
+		}
+		opts.Server = "https://" + net.JoinHostPort(host, port)
+		inCluster = true
+	}
+	if opts.TokenFile == "" {
+		opts.TokenFile = path.Join(serviceAccountDir, "token")
+	}
+	if opts.CAFile == "" && inCluster {
+		opts.CAFile = path.Join(serviceAccountDir, "ca.crt")
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the CA of the API server")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Newf("no certificates in %s", opts.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &Client{
+		server:        strings.TrimSuffix(opts.Server, "/"),
+		tokenFile:     opts.TokenFile,
+		namespace:     opts.Namespace,
+		labelSelector: opts.LabelSelector,
+		httpClient:    &http.Client{Transport: transport},
+	}, nil
+}
+
+// resourceObject is the part of a GSLBOrigin the client reads.
+type resourceObject struct {
+	Metadata struct {
+		Namespace       string `json:"namespace"`
+		Name            string `json:"name"`
+		Generation      int64  `json:"generation"`
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Spec json.RawMessage `json:"spec"`
+}
+
+func (o resourceObject) origin() Origin {
+	return Origin{Namespace: o.Metadata.Namespace, Name: o.Metadata.Name, Generation: o.Metadata.Generation, Spec: o.Spec}
+}
+
+// List returns the resources.
+func (c *Client) List(ctx context.Context) (Snapshot, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.collectionPath(), c.query(nil), "", nil)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer resp.Body.Close()
+	var list struct {
+		Metadata struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+		Items []resourceObject `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return Snapshot{}, errors.Wrap(err, "failed to decode the GSLBOrigin list")
+	}
+	snapshot := Snapshot{ResourceVersion: list.Metadata.ResourceVersion}
+	for _, item := range list.Items {
+		snapshot.Origins = append(snapshot.Origins, item.origin())
+	}
+	return snapshot, nil
+}
+
+// Wait blocks until a resource of snapshot is added or deleted or its spec
+// changes. Changes of the status alone, such as those written by
+// UpdateStatus, do not count. It also returns when the API server no longer
+// has the history to watch from, so that the caller lists the resources
+// again.
+func (c *Client) Wait(ctx context.Context, snapshot Snapshot) error {
+	generations := make(map[string]int64, len(snapshot.Origins))
+	for _, origin := range snapshot.Origins {
+		generations[origin.Key()] = origin.Generation
+	}
+	version := snapshot.ResourceVersion
+	for {
+		changed, next, err := c.watch(ctx, version, generations)
+		if err != nil || changed {
+			return err
+		}
+		version = next
+	}
+}
+
+// watchEvent is an event of a watch.
+type watchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// watch runs a watch from version until it ends or a spec changes, and
+// returns the version to continue from.
+func (c *Client) watch(ctx context.Context, version string, generations map[string]int64) (bool, string, error) {
+	query := c.query(url.Values{
+		"watch":               {"true"},
+		"resourceVersion":     {version},
+		"allowWatchBookmarks": {"true"},
+		"timeoutSeconds":      {fmt.Sprint(int(watchTimeout.Seconds()))},
+	})
+	resp, err := c.do(ctx, http.MethodGet, c.collectionPath(), query, "", nil)
+	if err != nil {
+		return false, "", err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(resp.Body))
+	for {
+		var event watchEvent
+		if err := decoder.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				return false, version, nil
+			}
+			return false, "", errors.Wrap(err, "failed to read the watch")
+		}
+		if event.Type == "ERROR" {
+			// Typically 410 Gone: the version is too old to watch from
+			return true, "", nil
+		}
+		var object resourceObject
+		if err := json.Unmarshal(event.Object, &object); err != nil {
+			return false, "", errors.Wrap(err, "failed to decode a watch event")
+		}
+		version = object.Metadata.ResourceVersion
+		key := object.origin().Key()
+		switch event.Type {
+		case "ADDED", "DELETED":
+			return true, "", nil
+		case "MODIFIED":
+			if generations[key] != object.Metadata.Generation {
+				return true, "", nil
+			}
+		}
+	}
+}
+
+// UpdateStatus replaces the status of the resource namespace/name.
+func (c *Client) UpdateStatus(ctx context.Context, namespace, name string, status Status) error {
+	body, err := json.Marshal(map[string]any{"status": status})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	resp, err := c.do(ctx, http.MethodPatch, c.resourcePath(namespace, name)+"/status", nil, "application/merge-patch+json", body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *Client) collectionPath() string {
+	if c.namespace == "" {
+		return fmt.Sprintf("/apis/%s/%s/%s", Group, Version, Resource)
+	}
+	return fmt.Sprintf("/apis/%s/%s/namespaces/%s/%s", Group, Version, url.PathEscape(c.namespace), Resource)
+}
+
+func (c *Client) resourcePath(namespace, name string) string {
+	return fmt.Sprintf("/apis/%s/%s/namespaces/%s/%s/%s", Group, Version, url.PathEscape(namespace), Resource, url.PathEscape(name))
+}
+
+func (c *Client) query(values url.Values) url.Values {
+	if values == nil {
+		values = url.Values{}
+	}
+	if c.labelSelector != "" {
+		values.Set("labelSelector", c.labelSelector)
+	}
+	return values
+}
+
+// do sends a request with the token of the client and fails for answers
+// other than 2xx.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, contentType string, body []byte) (*http.Response, error) {
+	u := c.server + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	token, err := os.ReadFile(c.tokenFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the service account token")
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "kubernetes request failed")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		var status struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&status)
+		return nil, errors.Newf("kubernetes %s %s returned %d: %s", method, path, resp.StatusCode, status.Message)
+	}
+	return resp, nil
+}
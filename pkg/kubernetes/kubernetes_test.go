@@ -0,0 +1,145 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestClient(t *testing.T, server *httptest.Server, namespace string) *Client {
+	t.Helper()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(Options{Server: server.URL + "/", TokenFile: tokenFile, Namespace: namespace, LabelSelector: "gslb/instance=prod"})
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	return client
+}
+
+func TestNewClient_OutsideCluster(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	if _, err := NewClient(Options{}); err == nil {
+		t.Error("expected an error without the URL of the API server")
+	}
+}
+
+func TestList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/apis/gslb.bootjp.github.io/v1alpha1/namespaces/gslb/gslborigins" || r.URL.Query().Get("labelSelector") != "gslb/instance=prod" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("missing token")
+		}
+		fmt.Fprint(w, `{"metadata": {"resourceVersion": "42"}, "items": [
+			{"metadata": {"namespace": "gslb", "name": "www", "generation": 3}, "spec": {"record_type": "A"}}
+		]}`)
+	}))
+	defer server.Close()
+
+	snapshot, err := newTestClient(t, server, "gslb").List(context.Background())
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if snapshot.ResourceVersion != "42" || len(snapshot.Origins) != 1 {
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+	origin := snapshot.Origins[0]
+	if origin.Key() != "gslb/www" || origin.Generation != 3 || string(origin.Spec) != `{"record_type": "A"}` {
+		t.Errorf("unexpected origin %+v", origin)
+	}
+}
+
+func TestList_Forbidden(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"kind": "Status", "message": "gslborigins is forbidden"}`)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(t, server, "").List(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "403: gslborigins is forbidden") {
+		t.Errorf("expected the message of the API server, got %v", err)
+	}
+}
+
+func TestWait(t *testing.T) {
+	watches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		watches++
+		query := r.URL.Query()
+		if r.URL.Path != "/apis/gslb.bootjp.github.io/v1alpha1/gslborigins" || query.Get("watch") != "true" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		switch watches {
+		case 1:
+			if query.Get("resourceVersion") != "42" {
+				t.Errorf("expected to watch from 42, got %s", r.URL)
+			}
+			// Only the status changed; the watch then times out
+			fmt.Fprintln(w, `{"type": "MODIFIED", "object": {"metadata": {"namespace": "gslb", "name": "www", "generation": 3, "resourceVersion": "43"}}}`)
+			fmt.Fprintln(w, `{"type": "BOOKMARK", "object": {"metadata": {"resourceVersion": "44"}}}`)
+		default:
+			if query.Get("resourceVersion") != "44" {
+				t.Errorf("expected to continue from 44, got %s", r.URL)
+			}
+			fmt.Fprintln(w, `{"type": "MODIFIED", "object": {"metadata": {"namespace": "gslb", "name": "www", "generation": 4, "resourceVersion": "45"}}}`)
+		}
+	}))
+	defer server.Close()
+
+	snapshot := Snapshot{ResourceVersion: "42", Origins: []Origin{{Namespace: "gslb", Name: "www", Generation: 3}}}
+	if err := newTestClient(t, server, "").Wait(context.Background(), snapshot); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if watches != 2 {
+		t.Errorf("expected Wait to return on the change of the spec, after %d watches", watches)
+	}
+}
+
+func TestWait_Expired(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "ERROR", "object": {"kind": "Status", "code": 410}}`)
+	}))
+	defer server.Close()
+
+	if err := newTestClient(t, server, "").Wait(context.Background(), Snapshot{ResourceVersion: "1"}); err != nil {
+		t.Errorf("expected Wait to return without error, got %v", err)
+	}
+}
+
+func TestUpdateStatus(t *testing.T) {
+	var body map[string]Status
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/apis/gslb.bootjp.github.io/v1alpha1/namespaces/gslb/gslborigins/www/status" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		if r.Header.Get("Content-Type") != "application/merge-patch+json" {
+			t.Errorf("unexpected content type %s", r.Header.Get("Content-Type"))
+		}
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("invalid body %s", data)
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	status := Status{ObservedGeneration: 3, Health: "healthy", CurrentIPs: []string{"192.0.2.1"}}
+	if err := newTestClient(t, server, "").UpdateStatus(context.Background(), "gslb", "www", status); err != nil {
+		t.Fatalf("UpdateStatus returned error: %v", err)
+	}
+	if got := body["status"]; got.ObservedGeneration != 3 || got.Health != "healthy" || len(got.CurrentIPs) != 1 {
+		t.Errorf("unexpected status %+v", got)
+	}
+}
@@ -0,0 +1,154 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Addresses a Service is resolved to.
+const (
+	// AddressesLoadBalancer is the ingress of a LoadBalancer Service. An
+	// ingress with a hostname instead of an IP is returned as the hostname.
+	AddressesLoadBalancer = "load_balancer"
+	// AddressesNodes is the address of the nodes running a ready endpoint of
+	// the Service, for NodePort and hostNetwork Services: the external IPs
+	// of a node, or its internal IPs when it has none.
+	AddressesNodes = "nodes"
+	// AddressesEndpoints is the address of the ready endpoints of the Service.
+	AddressesEndpoints = "endpoints"
+)
+
+// ServiceAddresses returns the addresses of the Service namespace/name, of
+// the kind given by addresses. A Service without addresses of that kind
+// yet resolves to none, without error.
+func (c *Client) ServiceAddresses(ctx context.Context, namespace, name, addresses string) ([]string, error) {
+	switch addresses {
+	case "", AddressesLoadBalancer:
+		return c.loadBalancerAddresses(ctx, namespace, name)
+	case AddressesEndpoints, AddressesNodes:
+		endpoints, err := c.readyEndpoints(ctx, namespace, name)
+		if err != nil || addresses == AddressesEndpoints {
+			return endpoints.addresses, err
+		}
+		return c.nodeAddresses(ctx, endpoints.nodes)
+	default:
+		return nil, errors.Newf("unknown addresses %q", addresses)
+	}
+}
+
+func (c *Client) loadBalancerAddresses(ctx context.Context, namespace, name string) ([]string, error) {
+	var service struct {
+		Status struct {
+			LoadBalancer struct {
+				Ingress []struct {
+					IP       string `json:"ip"`
+					Hostname string `json:"hostname"`
+				} `json:"ingress"`
+			} `json:"loadBalancer"`
+		} `json:"status"`
+	}
+	p := fmt.Sprintf("/api/v1/namespaces/%s/services/%s", url.PathEscape(namespace), url.PathEscape(name))
+	if err := c.get(ctx, p, nil, &service); err != nil {
+		return nil, err
+	}
+	var addresses []string
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		switch {
+		case ingress.IP != "":
+			addresses = append(addresses, ingress.IP)
+		case ingress.Hostname != "":
+			addresses = append(addresses, ingress.Hostname)
+		}
+	}
+	return addresses, nil
+}
+
+// endpoints are the ready endpoints of a Service and the nodes they run on.
+type endpoints struct {
+	addresses []string
+	nodes     []string
+}
+
+func (c *Client) readyEndpoints(ctx context.Context, namespace, name string) (endpoints, error) {
+	var list struct {
+		Items []struct {
+			Endpoints []struct {
+				Addresses  []string `json:"addresses"`
+				NodeName   string   `json:"nodeName"`
+				Conditions struct {
+					Ready *bool `json:"ready"`
+				} `json:"conditions"`
+			} `json:"endpoints"`
+		} `json:"items"`
+	}
+	p := fmt.Sprintf("/apis/discovery.k8s.io/v1/namespaces/%s/endpointslices", url.PathEscape(namespace))
+	query := url.Values{"labelSelector": {"kubernetes.io/service-name=" + name}}
+	if err := c.get(ctx, p, query, &list); err != nil {
+		return endpoints{}, err
+	}
+	var found endpoints
+	seenNodes := make(map[string]bool)
+	for _, slice := range list.Items {
+		for _, endpoint := range slice.Endpoints {
+			// An unknown readiness is to be read as ready
+			if ready := endpoint.Conditions.Ready; ready != nil && !*ready {
+				continue
+			}
+			found.addresses = append(found.addresses, endpoint.Addresses...)
+			if endpoint.NodeName != "" && !seenNodes[endpoint.NodeName] {
+				seenNodes[endpoint.NodeName] = true
+				found.nodes = append(found.nodes, endpoint.NodeName)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (c *Client) nodeAddresses(ctx context.Context, nodes []string) ([]string, error) {
+	var addresses []string
+	for _, name := range nodes {
+		var node struct {
+			Status struct {
+				Addresses []struct {
+					Type    string `json:"type"`
+					Address string `json:"address"`
+				} `json:"addresses"`
+			} `json:"status"`
+		}
+		if err := c.get(ctx, "/api/v1/nodes/"+url.PathEscape(name), nil, &node); err != nil {
+			return nil, err
+		}
+		var external, internal []string
+		for _, address := range node.Status.Addresses {
+			switch address.Type {
+			case "ExternalIP":
+				external = append(external, address.Address)
+			case "InternalIP":
+				internal = append(internal, address.Address)
+			}
+		}
+		if len(external) == 0 {
+			external = internal
+		}
+		addresses = append(addresses, external...)
+	}
+	return addresses, nil
+}
+
+// get reads the object at path into v.
+func (c *Client) get(ctx context.Context, path string, query url.Values, v any) error {
+	resp, err := c.do(ctx, http.MethodGet, path, query, "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return errors.Wrapf(err, "failed to decode %s", path)
+	}
+	return nil
+}
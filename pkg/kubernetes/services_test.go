@@ -0,0 +1,75 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func newServicesServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("labelSelector") && r.URL.Query().Get("labelSelector") != "kubernetes.io/service-name=frontend" {
+			t.Errorf("unexpected label selector %s", r.URL)
+		}
+		switch r.URL.Path {
+		case "/api/v1/namespaces/web/services/frontend":
+			fmt.Fprint(w, `{"status": {"loadBalancer": {"ingress": [{"ip": "198.51.100.1"}, {"hostname": "lb.example.net"}]}}}`)
+		case "/apis/discovery.k8s.io/v1/namespaces/web/endpointslices":
+			fmt.Fprint(w, `{"items": [
+				{"endpoints": [
+					{"addresses": ["10.0.0.1"], "nodeName": "node-a", "conditions": {"ready": true}},
+					{"addresses": ["10.0.0.2"], "nodeName": "node-b", "conditions": {"ready": false}}
+				]},
+				{"endpoints": [
+					{"addresses": ["10.0.1.1"], "nodeName": "node-c"},
+					{"addresses": ["10.0.1.2"], "nodeName": "node-a", "conditions": {"ready": true}}
+				]}
+			]}`)
+		case "/api/v1/nodes/node-a":
+			fmt.Fprint(w, `{"status": {"addresses": [{"type": "InternalIP", "address": "10.1.0.1"}, {"type": "ExternalIP", "address": "203.0.113.1"}]}}`)
+		case "/api/v1/nodes/node-c":
+			fmt.Fprint(w, `{"status": {"addresses": [{"type": "Hostname", "address": "node-c"}, {"type": "InternalIP", "address": "10.1.0.3"}]}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestServiceAddresses(t *testing.T) {
+	server := newServicesServer(t)
+	defer server.Close()
+	client := newTestClient(t, server, "")
+
+	tests := []struct {
+		addresses string
+		want      []string
+	}{
+		{addresses: AddressesLoadBalancer, want: []string{"198.51.100.1", "lb.example.net"}},
+		{addresses: AddressesEndpoints, want: []string{"10.0.0.1", "10.0.1.1", "10.0.1.2"}},
+		// A node without an external IP is reached on its internal IP
+		{addresses: AddressesNodes, want: []string{"203.0.113.1", "10.1.0.3"}},
+	}
+	for _, tt := range tests {
+		got, err := client.ServiceAddresses(context.Background(), "web", "frontend", tt.addresses)
+		if err != nil {
+			t.Errorf("ServiceAddresses(%s) returned error: %v", tt.addresses, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ServiceAddresses(%s) = %v, want %v", tt.addresses, got, tt.want)
+		}
+	}
+}
+
+func TestServiceAddresses_NotFound(t *testing.T) {
+	server := newServicesServer(t)
+	defer server.Close()
+
+	if _, err := newTestClient(t, server, "").ServiceAddresses(context.Background(), "web", "backend", AddressesLoadBalancer); err == nil {
+		t.Error("expected an error for a missing Service")
+	}
+}
@@ -0,0 +1,55 @@
+package kubernetes
+
+import "time"
+
+// ConditionReady is the condition of a GSLBOrigin whose origin is monitored
+// and has IPs published, so that "kubectl wait --for=condition=Ready" works.
+const ConditionReady = "Ready"
+
+// Reasons of the Ready condition.
+const (
+	ReasonInvalid  = "Invalid"  // the spec was refused; the message tells why
+	ReasonPending  = "Pending"  // the origin was not checked yet
+	ReasonDown     = "Down"     // no IP of the origin is healthy
+	ReasonHealthy  = "Healthy"  // the highest priority level is published
+	ReasonFailover = "Failover" // a lower priority level is published
+	ReasonDegraded = "Degraded" // some published IPs are unhealthy
+)
+
+// Status is the status of a GSLBOrigin. Every field is written, so that a
+// merge patch clears the fields that no longer apply.
+type Status struct {
+	ObservedGeneration int64       `json:"observedGeneration"`
+	Health             string      `json:"health"`
+	CurrentIPs         []string    `json:"currentIPs"`
+	CurrentPriority    int         `json:"currentPriority"`
+	MaxPriority        int         `json:"maxPriority"`
+	LastCheck          *time.Time  `json:"lastCheck"`
+	LastResult         string      `json:"lastResult"`
+	LastError          string      `json:"lastError"`
+	HeldBy             string      `json:"heldBy"`
+	Conditions         []Condition `json:"conditions"`
+}
+
+// Condition is a condition in the format of the Kubernetes API.
+type Condition struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	ObservedGeneration int64     `json:"observedGeneration"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+	Reason             string    `json:"reason"`
+	Message            string    `json:"message"`
+}
+
+// Target is a GSLBOrigin together with the origin its spec defines, or the
+// reason it was refused.
+type Target struct {
+	Origin
+	// Zone, Host and RecordType identify the origin; an empty Zone matches
+	// the origin of any zone.
+	Zone       string
+	Host       string
+	RecordType string
+	// Err is why the spec was refused.
+	Err string
+}
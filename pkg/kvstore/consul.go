@@ -0,0 +1,109 @@
+package kvstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// consulWait is how long a blocking query waits before Consul answers with
+// an unchanged index.
+const consulWait = 5 * time.Minute
+
+// Consul reads keys from the Consul KV HTTP API.
+type Consul struct {
+	address    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewConsul returns a store for the Consul agent at address, such as
+// http://127.0.0.1:8500. token is the ACL token and may be empty.
+func NewConsul(address, token string) *Consul {
+	return &Consul{
+		address:    strings.TrimSuffix(address, "/"),
+		token:      token,
+		httpClient: watchClient,
+	}
+}
+
+type consulKV struct {
+	Key   string
+	Value []byte // base64 in JSON; null for folders
+}
+
+// List implements Store.
+func (c *Consul) List(ctx context.Context, prefix string) ([]Entry, uint64, error) {
+	return c.get(ctx, prefix, 0)
+}
+
+// Wait implements Store with Consul's blocking queries.
+func (c *Consul) Wait(ctx context.Context, prefix string, index uint64) (uint64, error) {
+	for {
+		_, next, err := c.get(ctx, prefix, index)
+		if err != nil {
+			return 0, err
+		}
+		// The index is unchanged when the wait time passes, and goes
+		// backwards when the Raft state is restored from a snapshot
+		if next != index {
+			return next, nil
+		}
+	}
+}
+
+func (c *Consul) get(ctx context.Context, prefix string, index uint64) ([]Entry, uint64, error) {
+	query := url.Values{"recurse": {"true"}}
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", fmt.Sprintf("%ds", int(consulWait.Seconds())))
+	}
+	u := fmt.Sprintf("%s/v1/kv/%s?%s", c.address, (&url.URL{Path: strings.TrimPrefix(prefix, "/")}).EscapedPath(), query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "consul request failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return nil, 0, errors.Newf("consul returned status %d", resp.StatusCode)
+	}
+	next, err := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if err != nil {
+		return nil, 0, errors.New("consul response has no X-Consul-Index header")
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		// No keys under the prefix
+		return nil, next, nil
+	}
+
+	var kvs []consulKV
+	if err := json.NewDecoder(resp.Body).Decode(&kvs); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to decode consul response")
+	}
+	entries := make([]Entry, 0, len(kvs))
+	for _, kv := range kvs {
+		if kv.Value == nil {
+			continue
+		}
+		entries = append(entries, Entry{Key: kv.Key, Value: kv.Value})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, next, nil
+}
@@ -0,0 +1,186 @@
+package kvstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Etcd reads keys through the JSON gateway of the etcd v3 API.
+type Etcd struct {
+	endpoint   string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+// NewEtcd returns a store for the etcd member at endpoint, such as
+// http://127.0.0.1:2379. With a username, requests are authenticated with
+// a token obtained from etcd's auth API.
+func NewEtcd(endpoint, username, password string) *Etcd {
+	return &Etcd{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		username:   username,
+		password:   password,
+		httpClient: watchClient,
+	}
+}
+
+// etcdHeader is the response header; the gateway encodes 64-bit integers as strings.
+type etcdHeader struct {
+	Revision uint64 `json:"revision,string"`
+}
+
+type etcdRangeRequest struct {
+	Key      []byte `json:"key"`
+	RangeEnd []byte `json:"range_end"`
+}
+
+type etcdRangeResponse struct {
+	Header etcdHeader `json:"header"`
+	KVs    []struct {
+		Key   []byte `json:"key"`
+		Value []byte `json:"value"`
+	} `json:"kvs"`
+}
+
+type etcdWatchRequest struct {
+	CreateRequest struct {
+		etcdRangeRequest
+		StartRevision uint64 `json:"start_revision,string"`
+	} `json:"create_request"`
+}
+
+type etcdWatchResponse struct {
+	Result struct {
+		Header          etcdHeader        `json:"header"`
+		Canceled        bool              `json:"canceled"`
+		CancelReason    string            `json:"cancel_reason"`
+		CompactRevision uint64            `json:"compact_revision,string"`
+		Events          []json.RawMessage `json:"events"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// List implements Store.
+func (e *Etcd) List(ctx context.Context, prefix string) ([]Entry, uint64, error) {
+	var resp etcdRangeResponse
+	if err := e.call(ctx, "/v3/kv/range", prefixRange(prefix), &resp); err != nil {
+		return nil, 0, err
+	}
+	// etcd returns the keys in byte order
+	entries := make([]Entry, 0, len(resp.KVs))
+	for _, kv := range resp.KVs {
+		entries = append(entries, Entry{Key: string(kv.Key), Value: kv.Value})
+	}
+	return entries, resp.Header.Revision, nil
+}
+
+// Wait implements Store with an etcd watch starting after index.
+func (e *Etcd) Wait(ctx context.Context, prefix string, index uint64) (uint64, error) {
+	var req etcdWatchRequest
+	req.CreateRequest.etcdRangeRequest = prefixRange(prefix)
+	req.CreateRequest.StartRevision = index + 1
+
+	resp, err := e.post(ctx, "/v3/watch", req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// The gateway streams one JSON object per watch response
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var msg etcdWatchResponse
+		if err := dec.Decode(&msg); err != nil {
+			return 0, errors.Wrap(err, "etcd watch stream ended")
+		}
+		switch {
+		case msg.Error != nil:
+			return 0, errors.Newf("etcd watch failed: %s", msg.Error.Message)
+		case msg.Result.CompactRevision > 0:
+			// Changes after index were compacted away, so report the current
+			// revision; the caller re-reads everything anyway
+			_, revision, err := e.List(ctx, prefix)
+			return revision, err
+		case msg.Result.Canceled:
+			return 0, errors.Newf("etcd watch canceled: %s", msg.Result.CancelReason)
+		case len(msg.Result.Events) > 0:
+			return msg.Result.Header.Revision, nil
+		}
+	}
+}
+
+func (e *Etcd) call(ctx context.Context, path string, body, out any) error {
+	resp, err := e.post(ctx, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.Wrapf(err, "failed to decode etcd response from %s", path)
+	}
+	return nil
+}
+
+func (e *Etcd) post(ctx context.Context, path string, body any) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.username != "" && path != "/v3/auth/authenticate" {
+		token, err := e.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", token)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "etcd request to %s failed", path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Newf("etcd returned status %d for %s", resp.StatusCode, path)
+	}
+	return resp, nil
+}
+
+// authenticate obtains a token for each request; tokens expire on the
+// server and requests are rare, so they are not kept.
+func (e *Etcd) authenticate(ctx context.Context) (string, error) {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	body := map[string]string{"name": e.username, "password": e.password}
+	if err := e.call(ctx, "/v3/auth/authenticate", body, &resp); err != nil {
+		return "", errors.Wrap(err, "etcd authentication failed")
+	}
+	return resp.Token, nil
+}
+
+// prefixRange returns the range of all keys starting with prefix.
+func prefixRange(prefix string) etcdRangeRequest {
+	key := []byte(prefix)
+	end := bytes.Clone(key)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return etcdRangeRequest{Key: key, RangeEnd: end[:i+1]}
+		}
+	}
+	// A prefix of only 0xff bytes (or none) ranges to the end of the keyspace
+	return etcdRangeRequest{Key: key, RangeEnd: []byte{0}}
+}
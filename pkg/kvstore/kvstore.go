@@ -0,0 +1,28 @@
+// Package kvstore reads and watches keys under a prefix in Consul KV or etcd.
+// Both are spoken to through their HTTP APIs.
+package kvstore
+
+import (
+	"context"
+	"net/http"
+)
+
+// Entry is a key and its value.
+type Entry struct {
+	Key   string
+	Value []byte
+}
+
+// Store lists the keys under a prefix and waits for them to change.
+type Store interface {
+	// List returns the entries under prefix in key order, together with
+	// the index of the store at the time of the read.
+	List(ctx context.Context, prefix string) ([]Entry, uint64, error)
+	// Wait blocks until a key under prefix is created, changed or deleted
+	// after index, and returns the index of the change.
+	Wait(ctx context.Context, prefix string, index uint64) (uint64, error)
+}
+
+// watchClient is used for requests that block until something changes, so
+// it has no timeout of its own; the caller's context bounds them.
+var watchClient = &http.Client{}
@@ -0,0 +1,190 @@
+package kvstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConsulList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/gslb/origins/" || r.URL.Query().Get("recurse") != "true" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.Header.Get("X-Consul-Token") != "secret" {
+			t.Errorf("missing ACL token")
+		}
+		w.Header().Set("X-Consul-Index", "42")
+		fmt.Fprint(w, `[
+			{"Key": "gslb/origins/", "Value": null},
+			{"Key": "gslb/origins/web", "Value": "eyJuYW1lIjoid2ViIn0="},
+			{"Key": "gslb/origins/api", "Value": "eyJuYW1lIjoiYXBpIn0="}
+		]`)
+	}))
+	defer server.Close()
+
+	entries, index, err := NewConsul(server.URL+"/", "secret").List(context.Background(), "gslb/origins/")
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if index != 42 {
+		t.Errorf("expected index 42, got %d", index)
+	}
+	if len(entries) != 2 || entries[0].Key != "gslb/origins/api" || string(entries[1].Value) != `{"name":"web"}` {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+}
+
+func TestConsulList_Empty(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Consul-Index", "7")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	entries, index, err := NewConsul(server.URL, "").List(context.Background(), "gslb/origins/")
+	if err != nil || len(entries) != 0 || index != 7 {
+		t.Errorf("expected no entries at index 7, got %+v, %d, %v", entries, index, err)
+	}
+}
+
+func TestConsulWait(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("index") != "42" || r.URL.Query().Get("wait") == "" {
+			t.Errorf("expected a blocking query, got %s", r.URL)
+		}
+		// The first query times out without a change
+		index := "42"
+		if requests > 1 {
+			index = "43"
+		}
+		w.Header().Set("X-Consul-Index", index)
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	index, err := NewConsul(server.URL, "").Wait(context.Background(), "gslb/origins/", 42)
+	if err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if index != 43 || requests != 2 {
+		t.Errorf("expected index 43 after 2 requests, got %d after %d", index, requests)
+	}
+}
+
+func TestConsul_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	if _, _, err := NewConsul(server.URL, "").List(context.Background(), "gslb/"); err == nil {
+		t.Error("expected an error for a forbidden request")
+	}
+}
+
+// fakeEtcd serves the range, watch and auth endpoints of the JSON gateway.
+func fakeEtcd(t *testing.T, watch func(w http.ResponseWriter, req etcdWatchRequest)) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/auth/authenticate", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["name"] != "gslb" || body["password"] != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"token": "tok"}`)
+	})
+	mux.HandleFunc("/v3/kv/range", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req etcdRangeRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if string(req.Key) != "gslb/" || string(req.RangeEnd) != "gslb0" {
+			t.Errorf("unexpected range %q-%q", req.Key, req.RangeEnd)
+		}
+		fmt.Fprint(w, `{"header": {"revision": "12"}, "kvs": [
+			{"key": "Z3NsYi9hcGk=", "value": "eyJuYW1lIjoiYXBpIn0=", "mod_revision": "11"}
+		], "count": "1"}`)
+	})
+	mux.HandleFunc("/v3/watch", func(w http.ResponseWriter, r *http.Request) {
+		var req etcdWatchRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		watch(w, req)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEtcdList(t *testing.T) {
+	server := fakeEtcd(t, nil)
+
+	entries, index, err := NewEtcd(server.URL, "gslb", "pass").List(context.Background(), "gslb/")
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if index != 12 || len(entries) != 1 || entries[0].Key != "gslb/api" || string(entries[0].Value) != `{"name":"api"}` {
+		t.Errorf("unexpected result %+v at %d", entries, index)
+	}
+
+	if _, _, err := NewEtcd(server.URL, "gslb", "wrong").List(context.Background(), "gslb/"); err == nil {
+		t.Error("expected an error for wrong credentials")
+	}
+}
+
+func TestEtcdWait(t *testing.T) {
+	server := fakeEtcd(t, func(w http.ResponseWriter, req etcdWatchRequest) {
+		if req.CreateRequest.StartRevision != 13 {
+			t.Errorf("expected the watch to start after revision 12, got %d", req.CreateRequest.StartRevision)
+		}
+		fmt.Fprintln(w, `{"result": {"header": {"revision": "12"}, "created": true}}`)
+		w.(http.Flusher).Flush()
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprintln(w, `{"result": {"header": {"revision": "15"}, "events": [{"kv": {"key": "Z3NsYi9hcGk="}}]}}`)
+	})
+
+	index, err := NewEtcd(server.URL, "", "").Wait(context.Background(), "gslb/", 12)
+	if err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if index != 15 {
+		t.Errorf("expected revision 15, got %d", index)
+	}
+}
+
+func TestEtcdWait_Compacted(t *testing.T) {
+	server := fakeEtcd(t, func(w http.ResponseWriter, req etcdWatchRequest) {
+		fmt.Fprintln(w, `{"result": {"header": {"revision": "20"}, "canceled": true, "compact_revision": "14"}}`)
+	})
+
+	index, err := NewEtcd(server.URL, "gslb", "pass").Wait(context.Background(), "gslb/", 3)
+	if err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if index != 12 {
+		t.Errorf("expected the current revision 12, got %d", index)
+	}
+}
+
+func TestPrefixRange(t *testing.T) {
+	for prefix, want := range map[string]string{
+		"gslb/":      "gslb0",
+		"a\xff":      "b",
+		"\xff\xff":   "\x00",
+		"gslb/edge-": "gslb/edge.",
+	} {
+		if got := string(prefixRange(prefix).RangeEnd); got != want {
+			t.Errorf("prefixRange(%q) ends at %q, want %q", prefix, got, want)
+		}
+	}
+}
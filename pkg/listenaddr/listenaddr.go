@@ -0,0 +1,104 @@
+// Package listenaddr opens the listeners of the APIs of the daemon, which
+// listen on a TCP host:port or on a unix domain socket given as
+// unix:<path>, and connects their clients to them.
+package listenaddr
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// UnixPrefix starts the address of a unix domain socket, e.g.
+// unix:/run/gslb/admin.sock.
+const UnixPrefix = "unix:"
+
+// DefaultSocketMode is the permission of a socket when none is configured:
+// only the user the daemon runs as may connect.
+const DefaultSocketMode fs.FileMode = 0o600
+
+// SocketPath returns the path of the socket of address and whether address
+// is a unix domain socket.
+func SocketPath(address string) (string, bool) {
+	return strings.CutPrefix(address, UnixPrefix)
+}
+
+// Listen listens on address. A socket is created with mode, replacing the
+// file of a socket that nothing listens on anymore, e.g. after a crash.
+func Listen(address string, mode fs.FileMode) (net.Listener, error) {
+	path, ok := SocketPath(address)
+	if !ok {
+		return net.Listen("tcp", address)
+	}
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set the permissions of %s: %w", path, err)
+	}
+	return listener, nil
+}
+
+// removeStaleSocket removes the socket at path unless a server listens on it.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode().Type() != fs.ModeSocket {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another process", path)
+	}
+	return os.Remove(path)
+}
+
+// Transport returns an HTTP transport that reaches the server at address,
+// over TLS with tlsConfig when the URL is https. Requests to a socket go to
+// it whatever the host of their URL; see BaseURL.
+func Transport(address string, tlsConfig *tls.Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if path, ok := SocketPath(address); ok {
+		var dialer net.Dialer
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", path)
+		}
+	}
+	return transport
+}
+
+// BaseURL returns the URL of the server at address, which is a host:port, a
+// socket or already a URL, using https when secure is set.
+func BaseURL(address string, secure bool) string {
+	if strings.Contains(address, "://") {
+		return strings.TrimSuffix(address, "/")
+	}
+	scheme := "http"
+	if secure {
+		scheme = "https"
+	}
+	if _, ok := SocketPath(address); ok {
+		// The host only names the server in the Host header and for TLS
+		return scheme + "://localhost"
+	}
+	return scheme + "://" + address
+}
@@ -0,0 +1,85 @@
+package listenaddr
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListen_Socket(t *testing.T) {
+	address := UnixPrefix + filepath.Join(t.TempDir(), "admin.sock")
+	path, _ := SocketPath(address)
+
+	listener, err := Listen(address, 0o660)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Host+r.URL.Path)
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o660 {
+		t.Errorf("Expected a socket with mode 0660, got %v, %v", info, err)
+	}
+	client := &http.Client{Transport: Transport(address, nil)}
+	resp, err := client.Get(BaseURL(address, false) + "/api/v1/origins")
+	if err != nil {
+		t.Fatalf("GET over the socket: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "localhost/api/v1/origins" {
+		t.Errorf("Unexpected response %q", body)
+	}
+
+	// A socket in use is not replaced
+	if _, err := Listen(address, 0o600); err == nil {
+		t.Error("expected an error for a socket in use")
+	}
+}
+
+func TestListen_StaleSocket(t *testing.T) {
+	dir := t.TempDir()
+	address := UnixPrefix + filepath.Join(dir, "status.sock")
+	listener, err := Listen(address, DefaultSocketMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Leave the file behind, like a process that crashed
+	listener.(interface{ SetUnlinkOnClose(bool) }).SetUnlinkOnClose(false)
+	listener.Close()
+
+	listener, err = Listen(address, DefaultSocketMode)
+	if err != nil {
+		t.Fatalf("Listen() over a stale socket: %v", err)
+	}
+	listener.Close()
+
+	regular := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(regular, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(UnixPrefix+regular, DefaultSocketMode); err == nil {
+		t.Error("expected an error for a file that is not a socket")
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	tests := map[string]string{
+		"127.0.0.1:8082":          "http://127.0.0.1:8082",
+		"unix:/run/gslb/api.sock": "http://localhost",
+		"https://gslb.internal/":  "https://gslb.internal",
+	}
+	for address, want := range tests {
+		if got := BaseURL(address, false); got != want {
+			t.Errorf("BaseURL(%q) = %q, want %q", address, got, want)
+		}
+	}
+	if got := BaseURL("127.0.0.1:8082", true); got != "https://127.0.0.1:8082" {
+		t.Errorf("BaseURL(secure) = %q", got)
+	}
+}
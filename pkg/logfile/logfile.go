@@ -0,0 +1,251 @@
+// Package logfile writes logs to a file that is rotated by size and age, so
+// that long-running deployments do not need an external logrotate.
+//
+// A rotated file is renamed to the path followed by the time of the
+// rotation, e.g. gslb.log.20240102T030405.000000000Z, and optionally compressed with
+// gzip. Only the newest MaxBackups rotated files are kept.
+package logfile
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the suffix of rotated files. It sorts by time.
+const backupTimeFormat = "20060102T150405.000000000Z"
+
+// Config configures a Writer.
+type Config struct {
+	// Path is the file written to.
+	Path string
+	// MaxSize rotates the file before it grows beyond this many bytes (0 = never).
+	MaxSize int64
+	// MaxAge rotates the file this long after it was opened (0 = never).
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files kept (0 = all).
+	MaxBackups int
+	// Compress compresses rotated files with gzip.
+	Compress bool
+}
+
+// Writer is an io.Writer appending to a file and rotating it.
+type Writer struct {
+	cfg Config
+	now func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+
+	// compressing tracks the background compression of rotated files.
+	compressing sync.WaitGroup
+}
+
+// Open returns a writer appending to cfg.Path, creating it if needed.
+func Open(cfg Config) (*Writer, error) {
+	w := &Writer{cfg: cfg, now: time.Now}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.openLocked(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends p to the file, rotating it first if p would exceed MaxSize
+// or the file is older than MaxAge. A single write is never split.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if w.size > 0 && w.needsRotationLocked(int64(len(p))) {
+		if err := w.rotateLocked(); err != nil {
+			// Keep logging to the current file rather than losing lines
+			logError("Failed to rotate log file %s: %v", w.cfg.Path, err)
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file immediately.
+func (w *Writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.rotateLocked()
+}
+
+// Close closes the file and waits for rotated files to be compressed.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+	w.compressing.Wait()
+	return err
+}
+
+func (w *Writer) needsRotationLocked(incoming int64) bool {
+	if w.cfg.MaxSize > 0 && w.size+incoming > w.cfg.MaxSize {
+		return true
+	}
+	return w.cfg.MaxAge > 0 && w.now().Sub(w.opened) >= w.cfg.MaxAge
+}
+
+// openLocked opens the file for appending. w.mu must be held.
+func (w *Writer) openLocked() error {
+	file, err := os.OpenFile(w.cfg.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	w.file = file
+	w.size = info.Size()
+	w.opened = w.now()
+	return nil
+}
+
+// rotateLocked renames the file, opens a new one and removes old backups.
+// w.mu must be held.
+func (w *Writer) rotateLocked() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	backup := w.cfg.Path + "." + w.now().UTC().Format(backupTimeFormat)
+	renameErr := os.Rename(w.cfg.Path, backup)
+	// Reopen even if the rename failed, so that logging goes on
+	if err := w.openLocked(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return fmt.Errorf("failed to rename log file: %w", renameErr)
+	}
+
+	if w.cfg.Compress {
+		w.compressing.Add(1)
+		go func() {
+			defer w.compressing.Done()
+			if err := compress(backup); err != nil {
+				logError("Failed to compress rotated log file: %v", err)
+			}
+			w.removeOldBackups()
+		}()
+		return nil
+	}
+	w.removeOldBackups()
+	return nil
+}
+
+// removeOldBackups removes the oldest rotated files beyond MaxBackups.
+func (w *Writer) removeOldBackups() {
+	if w.cfg.MaxBackups <= 0 {
+		return
+	}
+	backups, err := Backups(w.cfg.Path)
+	if err != nil {
+		logError("Failed to list rotated log files: %v", err)
+		return
+	}
+	if len(backups) <= w.cfg.MaxBackups {
+		return
+	}
+	for _, backup := range backups[:len(backups)-w.cfg.MaxBackups] {
+		if err := os.Remove(backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logError("Failed to remove rotated log file: %v", err)
+		}
+	}
+}
+
+// Backups returns the rotated files of path, oldest first. A file that is
+// being compressed is listed once.
+func Backups(path string) ([]string, error) {
+	matches, err := filepath.Glob(globEscape(path) + ".*")
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(matches))
+	var backups []string
+	for _, match := range matches {
+		suffix := backupSuffix(path, match)
+		if _, err := time.Parse(backupTimeFormat, suffix); err != nil {
+			continue
+		}
+		if seen[suffix] {
+			// Both the file and its partially written .gz exist
+			continue
+		}
+		seen[suffix] = true
+		backups = append(backups, match)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backupSuffix(path, backups[i]) < backupSuffix(path, backups[j])
+	})
+	return backups, nil
+}
+
+func backupSuffix(path, backup string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(backup, path+"."), ".gz")
+}
+
+// logError reports a failure of the writer itself. It goes to stderr, as
+// logging it through the log package could write to the writer while it is
+// locked.
+func logError(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+}
+
+// globEscape escapes the glob metacharacters of path.
+func globEscape(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// compress replaces path with path.gz.
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	_, copyErr := io.Copy(gz, src)
+	if err := errors.Join(copyErr, gz.Close(), dst.Close()); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,139 @@
+package logfile
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriter_RotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gslb.log")
+	w, err := Open(Config{Path: path, MaxSize: 10, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	w.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	if got := readFile(t, path); got != "fourth\n" {
+		t.Errorf("expected the current file to hold the last line, got %q", got)
+	}
+	backups, err := Backups(path)
+	if err != nil {
+		t.Fatalf("Backups returned error: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups to be kept, got %v", backups)
+	}
+	if readFile(t, backups[0]) != "second\n" || readFile(t, backups[1]) != "third\n" {
+		t.Errorf("expected the newest backups to be kept, got %v", backups)
+	}
+}
+
+func TestWriter_RotatesByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gslb.log")
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	w, err := Open(Config{Path: path, MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	w.now = func() time.Time { return now }
+	w.opened = now
+
+	_, _ = w.Write([]byte("old\n"))
+	now = now.Add(59 * time.Minute)
+	_, _ = w.Write([]byte("still\n"))
+	now = now.Add(time.Minute)
+	_, _ = w.Write([]byte("new\n"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	if got := readFile(t, path); got != "new\n" {
+		t.Errorf("expected a new file after an hour, got %q", got)
+	}
+	backups, _ := Backups(path)
+	if len(backups) != 1 || readFile(t, backups[0]) != "old\nstill\n" {
+		t.Errorf("expected one backup, got %v", backups)
+	}
+}
+
+func TestWriter_CompressesBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gslb.log")
+	w, err := Open(Config{Path: path, Compress: true})
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	_, _ = w.Write([]byte("compressed\n"))
+	if err := w.Rotate(); err != nil {
+		t.Fatalf("Rotate returned error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	backups, _ := Backups(path)
+	if len(backups) != 1 || !strings.HasSuffix(backups[0], ".gz") {
+		t.Fatalf("expected one compressed backup, got %v", backups)
+	}
+	file, err := os.Open(backups[0])
+	if err != nil {
+		t.Fatalf("failed to open backup: %v", err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("backup is not gzip: %v", err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil || string(data) != "compressed\n" {
+		t.Errorf("unexpected backup content %q, %v", data, err)
+	}
+}
+
+func TestWriter_AppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gslb.log")
+	if err := os.WriteFile(path, []byte("before restart\n"), 0o600); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	w, err := Open(Config{Path: path, MaxSize: 20})
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	// The existing size counts towards MaxSize
+	_, _ = w.Write([]byte("after restart\n"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if got := readFile(t, path); got != "after restart\n" {
+		t.Errorf("expected the existing file to be rotated, got %q", got)
+	}
+	if _, err := w.Write([]byte("closed\n")); err == nil {
+		t.Error("expected Write after Close to fail")
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
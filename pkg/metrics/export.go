@@ -0,0 +1,278 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/otlp"
+)
+
+// DefaultInterval is used when Config.Interval is not positive.
+const DefaultInterval = time.Minute
+
+// OTLP aggregation temporality of counters and histograms, which are
+// exported as totals since Setup.
+const temporalityCumulative = 2
+
+// ErrAlreadySetUp is returned when Setup is called while metrics are enabled.
+var ErrAlreadySetUp = errors.New("metrics are already set up")
+
+// Config configures the OTLP/HTTP exporter.
+type Config struct {
+	// Endpoint is the base URL of the collector; metrics are posted to Endpoint + "/v1/metrics".
+	Endpoint string
+	// ServiceName is exported as the service.name resource attribute.
+	ServiceName string
+	// Headers are added to every export request, e.g. for authentication.
+	Headers map[string]string
+	// Interval is how often metrics are pushed (default: DefaultInterval).
+	Interval time.Duration
+}
+
+// Setup enables recording and pushes every instrument each interval. The
+// returned function pushes the final values and disables recording.
+func Setup(cfg Config) (func(context.Context) error, error) {
+	if !enabled.CompareAndSwap(false, true) {
+		return nil, ErrAlreadySetUp
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	e := &exporter{
+		client:   otlp.NewClient(cfg.Endpoint, cfg.Headers),
+		resource: otlp.NewResource(cfg.ServiceName),
+		start:    time.Now(),
+		now:      time.Now,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go e.run(cfg.Interval)
+
+	var once sync.Once
+	return func(ctx context.Context) error {
+		var err error
+		once.Do(func() {
+			close(e.stop)
+			select {
+			case <-e.done:
+			case <-ctx.Done():
+				err = ctx.Err()
+				return
+			}
+			err = e.export(ctx)
+			enabled.Store(false)
+			reset()
+		})
+		return err
+	}, nil
+}
+
+// reset forgets every recorded series, so that a later Setup starts from zero.
+func reset() {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, inst := range instruments {
+		inst.mu.Lock()
+		inst.series = make(map[string]*series)
+		inst.mu.Unlock()
+	}
+}
+
+type exporter struct {
+	client   *otlp.Client
+	resource otlp.Resource
+	// start is the start time of the cumulative counters and histograms.
+	start time.Time
+	now   func() time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (e *exporter) run(interval time.Duration) {
+	defer close(e.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		if err := e.export(ctx); err != nil {
+			log.Printf("Failed to export metrics: %v", err)
+		}
+		cancel()
+	}
+}
+
+func (e *exporter) export(ctx context.Context) error {
+	registryMu.Lock()
+	insts := append([]*instrument(nil), instruments...)
+	registryMu.Unlock()
+
+	now := e.now()
+	var encoded []otlpMetric
+	for _, inst := range insts {
+		if metric, ok := e.encode(inst, now); ok {
+			encoded = append(encoded, metric)
+		}
+	}
+	if len(encoded) == 0 {
+		return nil
+	}
+	return e.client.Export(ctx, "metrics", otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: e.resource,
+		ScopeMetrics: []otlpScopeMetrics{{
+			Scope:   otlp.Scope{Name: otlp.ScopeName},
+			Metrics: encoded,
+		}},
+	}}})
+}
+
+// The types below are the JSON encoding of an OTLP ExportMetricsServiceRequest.
+
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlp.Resource      `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlp.Scope   `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Unit        string         `json:"unit,omitempty"`
+	Sum         *otlpSum       `json:"sum,omitempty"`
+	Gauge       *otlpGauge     `json:"gauge,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+	AggregationTemporality int                   `json:"aggregationTemporality"`
+	IsMonotonic            bool                  `json:"isMonotonic"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberDataPoint `json:"dataPoints"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpHistogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                      `json:"aggregationTemporality"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes        []otlp.KeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsInt             *string         `json:"asInt,omitempty"`
+	AsDouble          *float64        `json:"asDouble,omitempty"`
+}
+
+type otlpHistogramDataPoint struct {
+	Attributes        []otlp.KeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	Count             string          `json:"count"`
+	Sum               float64         `json:"sum"`
+	BucketCounts      []string        `json:"bucketCounts"`
+	ExplicitBounds    []float64       `json:"explicitBounds"`
+	Min               float64         `json:"min"`
+	Max               float64         `json:"max"`
+}
+
+// encode returns the current value of every series of inst, or false if
+// nothing has been recorded.
+func (e *exporter) encode(inst *instrument, now time.Time) (otlpMetric, bool) {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	if len(inst.series) == 0 {
+		return otlpMetric{}, false
+	}
+
+	start, at := otlp.UnixNano(e.start), otlp.UnixNano(now)
+	metric := otlpMetric{Name: inst.name, Description: inst.description, Unit: inst.unit}
+	switch inst.kind {
+	case kindCounter:
+		metric.Sum = &otlpSum{AggregationTemporality: temporalityCumulative, IsMonotonic: true}
+		for _, s := range inst.sortedSeries() {
+			count := strconv.FormatInt(s.count, 10)
+			metric.Sum.DataPoints = append(metric.Sum.DataPoints, otlpNumberDataPoint{
+				Attributes:        encodeAttributes(s.attrs),
+				StartTimeUnixNano: start,
+				TimeUnixNano:      at,
+				AsInt:             &count,
+			})
+		}
+	case kindGauge:
+		metric.Gauge = &otlpGauge{}
+		for _, s := range inst.sortedSeries() {
+			value := s.value
+			metric.Gauge.DataPoints = append(metric.Gauge.DataPoints, otlpNumberDataPoint{
+				Attributes:   encodeAttributes(s.attrs),
+				TimeUnixNano: at,
+				AsDouble:     &value,
+			})
+		}
+	case kindHistogram:
+		metric.Histogram = &otlpHistogram{AggregationTemporality: temporalityCumulative}
+		for _, s := range inst.sortedSeries() {
+			buckets := make([]string, 0, len(s.buckets))
+			for _, n := range s.buckets {
+				buckets = append(buckets, strconv.FormatInt(n, 10))
+			}
+			metric.Histogram.DataPoints = append(metric.Histogram.DataPoints, otlpHistogramDataPoint{
+				Attributes:        encodeAttributes(s.attrs),
+				StartTimeUnixNano: start,
+				TimeUnixNano:      at,
+				Count:             strconv.FormatInt(s.count, 10),
+				Sum:               s.value,
+				BucketCounts:      buckets,
+				ExplicitBounds:    inst.bounds,
+				Min:               s.min,
+				Max:               s.max,
+			})
+		}
+	}
+	return metric, true
+}
+
+// sortedSeries returns the series in a stable order. inst.mu must be held.
+func (i *instrument) sortedSeries() []*series {
+	keys := make([]string, 0, len(i.series))
+	for key := range i.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	sorted := make([]*series, 0, len(keys))
+	for _, key := range keys {
+		sorted = append(sorted, i.series[key])
+	}
+	return sorted
+}
+
+func encodeAttributes(attrs []Attribute) []otlp.KeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	encoded := make([]otlp.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		encoded = append(encoded, otlp.KeyValue{Key: attr.Key, Value: otlp.Value(attr.Value)})
+	}
+	return encoded
+}
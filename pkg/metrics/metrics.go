@@ -0,0 +1,172 @@
+// Package metrics records counters, gauges and histograms and pushes them to
+// an OpenTelemetry collector over OTLP/HTTP or to a StatsD server.
+//
+// Instruments are package-level variables created with NewCounter, NewGauge
+// and NewHistogram. Until Setup or SetupStatsD is called recording is a
+// no-op, so instrumented code does not need to check whether metrics are
+// enabled.
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Attribute is a key-value pair identifying one series of an instrument.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// String returns an attribute.
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// DurationBuckets are the default histogram bounds for durations in milliseconds.
+var DurationBuckets = []float64{5, 10, 25, 50, 75, 100, 250, 500, 750, 1000, 2500, 5000, 7500, 10000, 30000}
+
+type kind int
+
+const (
+	kindCounter kind = iota
+	kindGauge
+	kindHistogram
+)
+
+// instrument holds every series recorded for one metric name.
+type instrument struct {
+	name        string
+	unit        string
+	description string
+	kind        kind
+	bounds      []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series is the aggregated value of one attribute set.
+type series struct {
+	attrs []Attribute
+	// count is the counter total or the number of histogram observations.
+	count int64
+	// value is the gauge value or the histogram sum.
+	value    float64
+	min, max float64
+	buckets  []int64
+}
+
+var (
+	// enabled is set by Setup; nothing is recorded while it is false.
+	enabled atomic.Bool
+
+	registryMu  sync.Mutex
+	instruments []*instrument
+)
+
+func register(name, unit, description string, k kind, bounds []float64) *instrument {
+	inst := &instrument{
+		name:        name,
+		unit:        unit,
+		description: description,
+		kind:        k,
+		bounds:      bounds,
+		series:      make(map[string]*series),
+	}
+	registryMu.Lock()
+	instruments = append(instruments, inst)
+	registryMu.Unlock()
+	return inst
+}
+
+// record updates the series of attrs with update.
+func (i *instrument) record(attrs []Attribute, update func(*series)) {
+	if !enabled.Load() {
+		return
+	}
+	key := seriesKey(attrs)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	s, ok := i.series[key]
+	if !ok {
+		s = &series{attrs: sortedAttributes(attrs)}
+		if i.kind == kindHistogram {
+			s.buckets = make([]int64, len(i.bounds)+1)
+		}
+		i.series[key] = s
+	}
+	update(s)
+}
+
+func sortedAttributes(attrs []Attribute) []Attribute {
+	sorted := append([]Attribute(nil), attrs...)
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a].Key < sorted[b].Key })
+	return sorted
+}
+
+func seriesKey(attrs []Attribute) string {
+	var b strings.Builder
+	for _, attr := range sortedAttributes(attrs) {
+		b.WriteString(attr.Key)
+		b.WriteByte(0)
+		b.WriteString(attr.Value)
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// Counter is a monotonically increasing total, such as the number of checks.
+type Counter struct{ inst *instrument }
+
+// NewCounter registers a counter. unit follows UCUM, e.g. "{check}".
+func NewCounter(name, unit, description string) *Counter {
+	return &Counter{inst: register(name, unit, description, kindCounter, nil)}
+}
+
+// Add increases the series of attrs by n.
+func (c *Counter) Add(n int64, attrs ...Attribute) {
+	c.inst.record(attrs, func(s *series) { s.count += n })
+	forward(c.inst, float64(n), attrs)
+}
+
+// Gauge is the last recorded value, such as the current priority.
+type Gauge struct{ inst *instrument }
+
+// NewGauge registers a gauge.
+func NewGauge(name, unit, description string) *Gauge {
+	return &Gauge{inst: register(name, unit, description, kindGauge, nil)}
+}
+
+// Set sets the series of attrs to value.
+func (g *Gauge) Set(value float64, attrs ...Attribute) {
+	g.inst.record(attrs, func(s *series) { s.value = value })
+	forward(g.inst, value, attrs)
+}
+
+// Histogram is the distribution of recorded values, such as probe latencies.
+type Histogram struct{ inst *instrument }
+
+// NewHistogram registers a histogram with the given bucket upper bounds.
+func NewHistogram(name, unit, description string, bounds []float64) *Histogram {
+	return &Histogram{inst: register(name, unit, description, kindHistogram, bounds)}
+}
+
+// Record adds value to the distribution of the series of attrs.
+func (h *Histogram) Record(value float64, attrs ...Attribute) {
+	h.inst.record(attrs, func(s *series) {
+		if s.count == 0 || value < s.min {
+			s.min = value
+		}
+		if s.count == 0 || value > s.max {
+			s.max = value
+		}
+		s.count++
+		s.value += value
+		s.buckets[sort.SearchFloat64s(h.inst.bounds, value)]++
+	})
+	forward(h.inst, value, attrs)
+}
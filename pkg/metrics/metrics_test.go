@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector is a fake OTLP/HTTP endpoint that keeps the last export of every metric.
+type collector struct {
+	mu      sync.Mutex
+	metrics map[string]otlpMetric
+	server  *httptest.Server
+}
+
+func newCollector(t *testing.T) *collector {
+	t.Helper()
+	c := &collector{metrics: make(map[string]otlpMetric)}
+	c.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" {
+			http.NotFound(w, r)
+			return
+		}
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for _, rm := range req.ResourceMetrics {
+			for _, sm := range rm.ScopeMetrics {
+				for _, metric := range sm.Metrics {
+					c.metrics[metric.Name] = metric
+				}
+			}
+		}
+	}))
+	t.Cleanup(c.server.Close)
+	return c
+}
+
+func (c *collector) metric(name string) (otlpMetric, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	metric, ok := c.metrics[name]
+	return metric, ok
+}
+
+func TestRecordingBeforeSetupIsIgnored(t *testing.T) {
+	counter := NewCounter("test.ignored", "{call}", "Ignored calls")
+	counter.Add(1)
+	if len(counter.inst.series) != 0 {
+		t.Errorf("expected nothing to be recorded before Setup, got %d series", len(counter.inst.series))
+	}
+}
+
+func TestExport(t *testing.T) {
+	c := newCollector(t)
+	checks := NewCounter("test.checks", "{check}", "Checks run")
+	priority := NewGauge("test.priority", "1", "Current priority")
+	latency := NewHistogram("test.latency", "ms", "Probe latency", []float64{10, 100})
+	unused := NewCounter("test.unused", "{call}", "Never recorded")
+
+	shutdown, err := Setup(Config{Endpoint: c.server.URL, ServiceName: "gslb-test", Interval: time.Hour})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	if _, err := Setup(Config{Endpoint: c.server.URL}); !errors.Is(err, ErrAlreadySetUp) {
+		t.Errorf("second Setup() error = %v, want ErrAlreadySetUp", err)
+	}
+
+	checks.Add(1, String("origin", "www"), String("zone", "example.com"))
+	checks.Add(2, String("zone", "example.com"), String("origin", "www"))
+	checks.Add(1, String("origin", "api"), String("zone", "example.com"))
+	priority.Set(100, String("origin", "www"))
+	priority.Set(50, String("origin", "www"))
+	for _, v := range []float64{5, 10, 50, 500} {
+		latency.Record(v)
+	}
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+
+	sum, ok := c.metric("test.checks")
+	if !ok || sum.Sum == nil || !sum.Sum.IsMonotonic || sum.Sum.AggregationTemporality != temporalityCumulative {
+		t.Fatalf("test.checks = %+v, want a cumulative monotonic sum", sum)
+	}
+	if len(sum.Sum.DataPoints) != 2 {
+		t.Fatalf("test.checks has %d series, want 2 (attribute order must not matter)", len(sum.Sum.DataPoints))
+	}
+	if got := *sum.Sum.DataPoints[1].AsInt; got != "3" {
+		t.Errorf("test.checks{origin=www} = %s, want 3", got)
+	}
+	if sum.Unit != "{check}" || sum.Description != "Checks run" {
+		t.Errorf("unit/description = %q/%q", sum.Unit, sum.Description)
+	}
+
+	gauge, ok := c.metric("test.priority")
+	if !ok || gauge.Gauge == nil || *gauge.Gauge.DataPoints[0].AsDouble != 50 {
+		t.Errorf("test.priority = %+v, want the last value 50", gauge)
+	}
+
+	hist, ok := c.metric("test.latency")
+	if !ok || hist.Histogram == nil {
+		t.Fatalf("test.latency = %+v, want a histogram", hist)
+	}
+	point := hist.Histogram.DataPoints[0]
+	if point.Count != "4" || point.Sum != 565 || point.Min != 5 || point.Max != 500 {
+		t.Errorf("histogram point = %+v", point)
+	}
+	want := []string{"2", "1", "1"}
+	for i := range want {
+		if point.BucketCounts[i] != want[i] {
+			t.Errorf("bucket counts = %v, want %v", point.BucketCounts, want)
+			break
+		}
+	}
+
+	if _, ok := c.metric("test.unused"); ok {
+		t.Error("instruments without series should not be exported")
+	}
+	unused.Add(1)
+	if len(unused.inst.series) != 0 {
+		t.Error("recording after shutdown should be ignored")
+	}
+}
+
+func TestExportReportsCollectorErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	counter := NewCounter("test.failing", "{call}", "Calls")
+	shutdown, err := Setup(Config{Endpoint: server.URL})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	counter.Add(1)
+	if err := shutdown(context.Background()); err == nil {
+		t.Error("shutdown() should report the collector's error status")
+	}
+}
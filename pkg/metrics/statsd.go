@@ -0,0 +1,220 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultStatsDAddress is the address of a local StatsD or Datadog agent.
+const DefaultStatsDAddress = "127.0.0.1:8125"
+
+// statsdFlushInterval is how often buffered StatsD lines are sent.
+const statsdFlushInterval = time.Second
+
+// maxStatsDPacket keeps each datagram below the usual MTU, as the Datadog
+// agent recommends.
+const maxStatsDPacket = 1432
+
+// StatsDConfig configures the StatsD exporter.
+type StatsDConfig struct {
+	// Address is the host:port of the StatsD server (default: DefaultStatsDAddress).
+	Address string
+	// Prefix is prepended to every metric name, e.g. "myteam.".
+	Prefix string
+	// Tags are added to every metric, e.g. "env:prod".
+	Tags []string
+}
+
+// statsd is the active StatsD exporter, or nil when it is not set up.
+var statsd atomic.Pointer[statsdExporter]
+
+// SetupStatsD enables recording and sends every recorded value to a StatsD
+// server using DogStatsD tags. Counters are sent as increments, gauges as
+// their new value and histograms as individual samples. The returned
+// function sends what is still buffered and disables recording.
+func SetupStatsD(cfg StatsDConfig) (func(context.Context) error, error) {
+	if !enabled.CompareAndSwap(false, true) {
+		return nil, ErrAlreadySetUp
+	}
+	if cfg.Address == "" {
+		cfg.Address = DefaultStatsDAddress
+	}
+	conn, err := net.Dial("udp", cfg.Address)
+	if err != nil {
+		enabled.Store(false)
+		return nil, err
+	}
+	e := &statsdExporter{
+		conn:   conn,
+		prefix: cfg.Prefix,
+		tags:   sanitizeTags(cfg.Tags),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	statsd.Store(e)
+	go e.run()
+
+	var once sync.Once
+	return func(ctx context.Context) error {
+		var err error
+		once.Do(func() {
+			statsd.Store(nil)
+			enabled.Store(false)
+			close(e.stop)
+			select {
+			case <-e.done:
+			case <-ctx.Done():
+				err = ctx.Err()
+				return
+			}
+			err = e.flush()
+			if closeErr := e.conn.Close(); err == nil {
+				err = closeErr
+			}
+			reset()
+		})
+		return err
+	}, nil
+}
+
+type statsdExporter struct {
+	conn   net.Conn
+	prefix string
+	tags   []string
+
+	mu  sync.Mutex
+	buf []byte
+	// failing suppresses repeated logs while the server is unreachable.
+	failing bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (e *statsdExporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(statsdFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+		}
+		if err := e.flush(); err != nil {
+			e.logFailure(err)
+		}
+	}
+}
+
+// send buffers one value of inst, flushing first if the packet would grow
+// too large.
+func (e *statsdExporter) send(inst *instrument, value float64, attrs []Attribute) {
+	line := e.format(inst, value, attrs)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.buf) > 0 && len(e.buf)+1+len(line) > maxStatsDPacket {
+		if err := e.writeLocked(); err != nil {
+			e.logFailureLocked(err)
+		}
+	}
+	if len(e.buf) > 0 {
+		e.buf = append(e.buf, '\n')
+	}
+	e.buf = append(e.buf, line...)
+}
+
+// format encodes a value as a DogStatsD line: name:value|type|#tag:value,...
+func (e *statsdExporter) format(inst *instrument, value float64, attrs []Attribute) []byte {
+	var b strings.Builder
+	b.WriteString(sanitizeName(e.prefix + inst.name))
+	b.WriteByte(':')
+	b.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	b.WriteByte('|')
+	switch {
+	case inst.kind == kindCounter:
+		b.WriteString("c")
+	case inst.kind == kindGauge:
+		b.WriteString("g")
+	case inst.unit == "ms":
+		b.WriteString("ms")
+	default:
+		b.WriteString("h")
+	}
+
+	tags := append([]string(nil), e.tags...)
+	for _, attr := range sortedAttributes(attrs) {
+		tags = append(tags, sanitizeTag(attr.Key+":"+attr.Value))
+	}
+	if len(tags) > 0 {
+		b.WriteString("|#")
+		b.WriteString(strings.Join(tags, ","))
+	}
+	return []byte(b.String())
+}
+
+func (e *statsdExporter) flush() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.writeLocked()
+}
+
+// writeLocked sends the buffered lines as one datagram. e.mu must be held.
+func (e *statsdExporter) writeLocked() error {
+	if len(e.buf) == 0 {
+		return nil
+	}
+	_, err := e.conn.Write(e.buf)
+	e.buf = e.buf[:0]
+	if err == nil {
+		e.failing = false
+	}
+	return err
+}
+
+func (e *statsdExporter) logFailure(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.logFailureLocked(err)
+}
+
+func (e *statsdExporter) logFailureLocked(err error) {
+	if !e.failing {
+		log.Printf("Failed to send metrics to StatsD: %v", err)
+	}
+	e.failing = true
+}
+
+// forward sends a recorded value to the StatsD exporter, if it is set up.
+func forward(inst *instrument, value float64, attrs []Attribute) {
+	if e := statsd.Load(); e != nil {
+		e.send(inst, value, attrs)
+	}
+}
+
+var nameReplacer = strings.NewReplacer(":", "_", "|", "_", "@", "_", "#", "_", ",", "_", "\n", "_")
+
+func sanitizeName(name string) string {
+	return nameReplacer.Replace(name)
+}
+
+var tagReplacer = strings.NewReplacer("|", "_", ",", "_", "#", "_", "\n", "_")
+
+func sanitizeTag(tag string) string {
+	return tagReplacer.Replace(tag)
+}
+
+func sanitizeTags(tags []string) []string {
+	sanitized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		sanitized = append(sanitized, sanitizeTag(tag))
+	}
+	return sanitized
+}
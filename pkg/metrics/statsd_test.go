@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listenStatsD returns a UDP server and a function returning every line it received.
+func listenStatsD(t *testing.T) (string, func() []string) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().String(), func() []string {
+		var lines []string
+		buf := make([]byte, 65535)
+		for {
+			_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return lines
+			}
+			if n > maxStatsDPacket {
+				t.Errorf("received a %d byte packet, want at most %d", n, maxStatsDPacket)
+			}
+			lines = append(lines, strings.Split(string(buf[:n]), "\n")...)
+		}
+	}
+}
+
+func TestStatsD(t *testing.T) {
+	addr, received := listenStatsD(t)
+	checks := NewCounter("test.statsd.checks", "{check}", "")
+	priority := NewGauge("test.statsd.priority", "1", "")
+	latency := NewHistogram("test.statsd.latency", "ms", "", DurationBuckets)
+	size := NewHistogram("test.statsd.size", "By", "", []float64{10})
+
+	shutdown, err := SetupStatsD(StatsDConfig{Address: addr, Prefix: "team.", Tags: []string{"env:prod"}})
+	if err != nil {
+		t.Fatalf("SetupStatsD() error = %v", err)
+	}
+	if _, err := Setup(Config{Endpoint: "http://localhost:4318"}); !errors.Is(err, ErrAlreadySetUp) {
+		t.Errorf("Setup() while StatsD is set up error = %v, want ErrAlreadySetUp", err)
+	}
+	checks.Add(2, String("origin", "www"), String("zone", "example.com"))
+	priority.Set(50, String("origin", "www|1"))
+	latency.Record(12.5)
+	size.Record(3)
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+	checks.Add(1)
+
+	want := []string{
+		"team.test.statsd.checks:2|c|#env:prod,origin:www,zone:example.com",
+		"team.test.statsd.priority:50|g|#env:prod,origin:www_1",
+		"team.test.statsd.latency:12.5|ms|#env:prod",
+		"team.test.statsd.size:3|h|#env:prod",
+	}
+	got := received()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("received\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestStatsDSplitsPackets(t *testing.T) {
+	addr, received := listenStatsD(t)
+	checks := NewCounter("test.statsd.split", "{check}", "")
+
+	shutdown, err := SetupStatsD(StatsDConfig{Address: addr})
+	if err != nil {
+		t.Fatalf("SetupStatsD() error = %v", err)
+	}
+	for i := 0; i < 200; i++ {
+		checks.Add(1, String("origin", "www.example.com"))
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+	if got := len(received()); got != 200 {
+		t.Errorf("received %d lines, want 200", got)
+	}
+}
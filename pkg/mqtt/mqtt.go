@@ -0,0 +1,351 @@
+// Package mqtt publishes messages to an MQTT 3.1.1 broker.
+//
+// It implements only what notifications need: each Publish opens a clean
+// session, publishes one message with QoS 0, 1 or 2, waits for the broker to
+// acknowledge it and disconnects. Events are rare enough that a lasting
+// connection, with its keep-alives and reconnects, is not worth it.
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrRefused is returned when the broker refuses the connection, e.g.
+	// because of bad credentials.
+	ErrRefused = errors.New("mqtt connection refused")
+	// ErrProtocol is returned when the broker answers with an unexpected packet.
+	ErrProtocol = errors.New("mqtt protocol error")
+)
+
+// Packet types of MQTT 3.1.1, in the high nibble of the first byte.
+const (
+	packetConnect    = 1
+	packetConnAck    = 2
+	packetPublish    = 3
+	packetPubAck     = 4
+	packetPubRec     = 5
+	packetPubRel     = 6
+	packetPubComp    = 7
+	packetDisconnect = 14
+)
+
+// protocolLevel is MQTT 3.1.1.
+const protocolLevel = 4
+
+// keepAlive is announced to the broker, which drops the connection after
+// one and a half times it without packets.
+const keepAlive = 30 * time.Second
+
+// dialTimeout bounds connecting when the context has no deadline.
+const dialTimeout = 10 * time.Second
+
+// maxRemainingLength is the largest packet MQTT can frame.
+const maxRemainingLength = 268435455
+
+// connAckErrors are the reasons of the CONNACK return codes.
+var connAckErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// Config configures a Publisher.
+type Config struct {
+	// Address is the host:port of the broker.
+	Address string
+	// TLS connects with TLS.
+	TLS bool
+	// CAFile verifies the broker with these PEM certificates instead of the
+	// system roots.
+	CAFile string
+	// CertFile and KeyFile are a PEM client certificate and key.
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the host name the certificate is verified for.
+	ServerName string
+	// InsecureSkipVerify disables verifying the certificate of the broker.
+	InsecureSkipVerify bool
+	// ClientID identifies the connection to the broker.
+	ClientID string
+	// Username and Password authenticate the connection, if set.
+	Username string
+	Password string
+}
+
+// Message is a message to publish.
+type Message struct {
+	Topic   string
+	Payload []byte
+	// QoS is 0 (at most once), 1 (at least once) or 2 (exactly once).
+	QoS byte
+	// Retain asks the broker to keep the message for later subscribers.
+	Retain bool
+}
+
+// Publisher publishes messages to one broker. It is safe for concurrent
+// use; messages are published one at a time, since the broker drops the
+// older of two connections with the same client identifier.
+type Publisher struct {
+	cfg       Config
+	tlsConfig *tls.Config
+
+	mu       sync.Mutex
+	packetID uint16
+}
+
+// NewPublisher returns a publisher for cfg, loading its certificates.
+func NewPublisher(cfg Config) (*Publisher, error) {
+	p := &Publisher{cfg: cfg}
+	if !cfg.TLS {
+		return p, nil
+	}
+	host, _, err := net.SplitHostPort(cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MQTT broker address %q: %w", cfg.Address, err)
+	}
+	// #nosec G402 - InsecureSkipVerify is only set when the configuration asks for it
+	p.tlsConfig = &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if cfg.ServerName != "" {
+		p.tlsConfig.ServerName = cfg.ServerName
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the MQTT CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in the MQTT CA file %s", cfg.CAFile)
+		}
+		p.tlsConfig.RootCAs = pool
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the MQTT client certificate: %w", err)
+		}
+		p.tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return p, nil
+}
+
+// Publish connects to the broker, publishes msg and disconnects once the
+// broker has acknowledged it as its QoS requires.
+func (p *Publisher) Publish(ctx context.Context, msg Message) error {
+	if msg.QoS > 2 {
+		return fmt.Errorf("invalid MQTT QoS %d", msg.QoS)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conn, err := p.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the MQTT broker %s: %w", p.cfg.Address, err)
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(dialTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	// Closing the connection interrupts the reads when ctx is canceled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	r := bufio.NewReader(conn)
+	if err := p.connect(conn, r); err != nil {
+		return err
+	}
+	if err := p.publish(conn, r, msg); err != nil {
+		return err
+	}
+	// The message is delivered; a failed disconnect does not matter
+	_, _ = conn.Write([]byte{packetDisconnect << 4, 0})
+	return nil
+}
+
+func (p *Publisher) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	if p.tlsConfig != nil {
+		return (&tls.Dialer{NetDialer: dialer, Config: p.tlsConfig}).DialContext(ctx, "tcp", p.cfg.Address)
+	}
+	return dialer.DialContext(ctx, "tcp", p.cfg.Address)
+}
+
+// connect sends CONNECT and waits for CONNACK.
+func (p *Publisher) connect(w io.Writer, r *bufio.Reader) error {
+	var flags byte = 0x02 // clean session
+	var payload []byte
+	payload = appendString(payload, p.cfg.ClientID)
+	if p.cfg.Username != "" {
+		flags |= 0x80
+		payload = appendString(payload, p.cfg.Username)
+		if p.cfg.Password != "" {
+			flags |= 0x40
+			payload = appendString(payload, p.cfg.Password)
+		}
+	}
+	body := appendString(nil, "MQTT")
+	body = append(body, protocolLevel, flags)
+	body = binary.BigEndian.AppendUint16(body, uint16(keepAlive.Seconds()))
+	body = append(body, payload...)
+	if err := writePacket(w, packetConnect<<4, body); err != nil {
+		return err
+	}
+
+	header, body, err := readPacket(r)
+	if err != nil {
+		return fmt.Errorf("failed to read CONNACK: %w", err)
+	}
+	if header>>4 != packetConnAck || len(body) != 2 {
+		return fmt.Errorf("%w: expected CONNACK, got packet type %d", ErrProtocol, header>>4)
+	}
+	if code := body[1]; code != 0 {
+		reason, ok := connAckErrors[code]
+		if !ok {
+			reason = fmt.Sprintf("return code %d", code)
+		}
+		return fmt.Errorf("%w: %s", ErrRefused, reason)
+	}
+	return nil
+}
+
+// publish sends PUBLISH and completes the acknowledgments of its QoS.
+func (p *Publisher) publish(w io.Writer, r *bufio.Reader, msg Message) error {
+	header := byte(packetPublish<<4) | msg.QoS<<1
+	if msg.Retain {
+		header |= 0x01
+	}
+	body := appendString(nil, msg.Topic)
+	var id uint16
+	if msg.QoS > 0 {
+		p.packetID++
+		if p.packetID == 0 {
+			p.packetID = 1
+		}
+		id = p.packetID
+		body = binary.BigEndian.AppendUint16(body, id)
+	}
+	body = append(body, msg.Payload...)
+	if err := writePacket(w, header, body); err != nil {
+		return err
+	}
+
+	switch msg.QoS {
+	case 1:
+		return expectAck(r, packetPubAck, id)
+	case 2:
+		if err := expectAck(r, packetPubRec, id); err != nil {
+			return err
+		}
+		if err := writePacket(w, packetPubRel<<4|0x02, binary.BigEndian.AppendUint16(nil, id)); err != nil {
+			return err
+		}
+		return expectAck(r, packetPubComp, id)
+	default:
+		return nil
+	}
+}
+
+// expectAck reads the acknowledgment of packet id.
+func expectAck(r *bufio.Reader, packetType byte, id uint16) error {
+	header, body, err := readPacket(r)
+	if err != nil {
+		return fmt.Errorf("failed to read the acknowledgment: %w", err)
+	}
+	if header>>4 != packetType || len(body) != 2 || binary.BigEndian.Uint16(body) != id {
+		return fmt.Errorf("%w: expected acknowledgment type %d of packet %d, got type %d", ErrProtocol, packetType, id, header>>4)
+	}
+	return nil
+}
+
+// appendString appends s with its 2-byte length prefix.
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// writePacket writes a packet with the fixed header byte and body.
+func writePacket(w io.Writer, header byte, body []byte) error {
+	if len(body) > maxRemainingLength {
+		return fmt.Errorf("MQTT packet of %d bytes is too large", len(body))
+	}
+	packet := []byte{header}
+	// The remaining length is a varint of 7 bits per byte
+	for length := len(body); ; {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if length == 0 {
+			break
+		}
+	}
+	_, err := w.Write(append(packet, body...))
+	return err
+}
+
+// readPacket reads a packet and returns its fixed header byte and body.
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, fmt.Errorf("%w: malformed remaining length", ErrProtocol)
+		}
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(digit&0x7f) * multiplier
+		multiplier *= 128
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+// ValidateTopic checks that topic can be published to: it must not be
+// empty or contain wildcards.
+func ValidateTopic(topic string) error {
+	switch {
+	case topic == "":
+		return errors.New("topic is empty")
+	case strings.ContainsAny(topic, "+#\x00"):
+		return fmt.Errorf("topic %q must not contain wildcards", topic)
+	case len(topic) > 65535:
+		return errors.New("topic is too long")
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// received is what the fake broker read from a connection.
+type received struct {
+	clientID string
+	username string
+	password string
+	header   byte
+	topic    string
+	payload  string
+	packets  []byte
+}
+
+// fakeBroker accepts one connection per publish, answers CONNACK with
+// returnCode and acknowledges the publish as its QoS requires.
+func fakeBroker(t *testing.T, returnCode byte) (string, <-chan received) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	results := make(chan received, 4)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			results <- serveConn(conn, returnCode)
+		}
+	}()
+	return listener.Addr().String(), results
+}
+
+func serveConn(conn net.Conn, returnCode byte) received {
+	defer conn.Close()
+	var got received
+	r := bufio.NewReader(conn)
+	readString := func(b []byte) (string, []byte) {
+		n := binary.BigEndian.Uint16(b)
+		return string(b[2 : 2+n]), b[2+n:]
+	}
+
+	header, body, err := readPacket(r)
+	if err != nil || header>>4 != packetConnect {
+		return got
+	}
+	got.packets = append(got.packets, header>>4)
+	_, rest := readString(body)
+	flags := rest[1]
+	got.clientID, rest = readString(rest[4:])
+	if flags&0x80 != 0 {
+		got.username, rest = readString(rest)
+	}
+	if flags&0x40 != 0 {
+		got.password, _ = readString(rest)
+	}
+	_ = writePacket(conn, packetConnAck<<4, []byte{0, returnCode})
+	if returnCode != 0 {
+		return got
+	}
+
+	for {
+		header, body, err := readPacket(r)
+		if err != nil {
+			return got
+		}
+		got.packets = append(got.packets, header>>4)
+		switch header >> 4 {
+		case packetPublish:
+			got.header = header
+			got.topic, body = readString(body)
+			qos := header >> 1 & 0x03
+			var id []byte
+			if qos > 0 {
+				id, body = body[:2], body[2:]
+			}
+			got.payload = string(body)
+			switch qos {
+			case 1:
+				_ = writePacket(conn, packetPubAck<<4, id)
+			case 2:
+				_ = writePacket(conn, packetPubRec<<4, id)
+			}
+		case packetPubRel:
+			_ = writePacket(conn, packetPubComp<<4, body)
+		case packetDisconnect:
+			return got
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	address, results := fakeBroker(t, 0)
+	publisher, err := NewPublisher(Config{Address: address, ClientID: "gslb-test", Username: "user", Password: "secret"})
+	if err != nil {
+		t.Fatalf("NewPublisher() error = %v", err)
+	}
+
+	tests := []struct {
+		qos     byte
+		retain  bool
+		packets []byte
+	}{
+		{qos: 0, packets: []byte{packetConnect, packetPublish, packetDisconnect}},
+		{qos: 1, retain: true, packets: []byte{packetConnect, packetPublish, packetDisconnect}},
+		{qos: 2, packets: []byte{packetConnect, packetPublish, packetPubRel, packetDisconnect}},
+	}
+	for _, tt := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := publisher.Publish(ctx, Message{Topic: "gslb/events", Payload: []byte(`{"kind":"failover"}`), QoS: tt.qos, Retain: tt.retain})
+		cancel()
+		if err != nil {
+			t.Fatalf("QoS %d: Publish() error = %v", tt.qos, err)
+		}
+
+		got := <-results
+		if got.clientID != "gslb-test" || got.username != "user" || got.password != "secret" {
+			t.Errorf("QoS %d: unexpected connect %+v", tt.qos, got)
+		}
+		if got.topic != "gslb/events" || got.payload != `{"kind":"failover"}` {
+			t.Errorf("QoS %d: unexpected publish of %q to %q", tt.qos, got.payload, got.topic)
+		}
+		if qos, retain := got.header>>1&0x03, got.header&0x01 == 1; qos != tt.qos || retain != tt.retain {
+			t.Errorf("QoS %d: published with QoS %d and retain %v", tt.qos, qos, retain)
+		}
+		if string(got.packets) != string(tt.packets) {
+			t.Errorf("QoS %d: packets = %v, want %v", tt.qos, got.packets, tt.packets)
+		}
+	}
+}
+
+func TestPublish_Refused(t *testing.T) {
+	address, _ := fakeBroker(t, 4)
+	publisher, err := NewPublisher(Config{Address: address, ClientID: "gslb-test"})
+	if err != nil {
+		t.Fatalf("NewPublisher() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := publisher.Publish(ctx, Message{Topic: "gslb/events", QoS: 1}); !errors.Is(err, ErrRefused) {
+		t.Errorf("expected ErrRefused, got %v", err)
+	}
+}
+
+func TestNewPublisher_MissingCAFile(t *testing.T) {
+	_, err := NewPublisher(Config{Address: "broker.example.com:8883", TLS: true, CAFile: "/nonexistent/ca.pem"})
+	if err == nil {
+		t.Error("expected an error for a missing CA file")
+	}
+}
+
+func TestValidateTopic(t *testing.T) {
+	for topic, valid := range map[string]bool{
+		"gslb/events":   true,
+		"":              false,
+		"gslb/+/events": false,
+		"gslb/#":        false,
+	} {
+		if err := ValidateTopic(topic); (err == nil) != valid {
+			t.Errorf("ValidateTopic(%q) = %v, want valid %v", topic, err, valid)
+		}
+	}
+}
+
+func TestRemainingLength(t *testing.T) {
+	for _, length := range []int{0, 127, 128, 16383, 16384, 2097152} {
+		var buf bytes.Buffer
+		if err := writePacket(&buf, packetPublish<<4, make([]byte, length)); err != nil {
+			t.Fatalf("writePacket() error = %v", err)
+		}
+		_, body, err := readPacket(bufio.NewReader(&buf))
+		if err != nil || len(body) != length {
+			t.Errorf("length %d: read %d bytes, error %v", length, len(body), err)
+		}
+	}
+}
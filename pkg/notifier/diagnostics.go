@@ -0,0 +1,61 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxListedChecks keeps the checks of an origin with many IPs within the
+// field limits of the chat services
+const maxListedChecks = 10
+
+// formatCheck describes one health check, e.g.
+// "192.0.2.1 (priority 100): unhealthy, HTTP 503, 120ms"
+func formatCheck(check CheckDiagnostic) string {
+	parts := []string{"healthy"}
+	if !check.Healthy {
+		parts[0] = "unhealthy"
+	}
+	if check.StatusCode != 0 {
+		parts = append(parts, fmt.Sprintf("HTTP %d", check.StatusCode))
+	}
+	if check.TLSError != "" {
+		parts = append(parts, check.TLSError)
+	}
+	if check.CheckType == "icmp" {
+		parts = append(parts, fmt.Sprintf("%.0f%% packet loss", check.PacketLoss*100))
+	}
+	if check.StatusCode == 0 && check.TLSError == "" && check.Error != "" {
+		parts = append(parts, check.Error)
+	}
+	parts = append(parts, check.Latency.Round(time.Millisecond).String())
+	return fmt.Sprintf("%s (priority %d): %s", check.IP, check.Priority, strings.Join(parts, ", "))
+}
+
+// formatChecks lists the checks of event one per line
+func formatChecks(event FailoverEvent) string {
+	lines := make([]string, 0, len(event.Checks))
+	for i, check := range event.Checks {
+		if i == maxListedChecks {
+			lines = append(lines, fmt.Sprintf("and %d more", len(event.Checks)-maxListedChecks))
+			break
+		}
+		lines = append(lines, formatCheck(check))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatCandidates lists the priority levels of event one per line, marking
+// the one switched to
+func formatCandidates(event FailoverEvent) string {
+	lines := make([]string, 0, len(event.Candidates))
+	for i, candidate := range event.Candidates {
+		marker := "  "
+		if i == event.FailoverIndex {
+			marker = "→ "
+		}
+		lines = append(lines, fmt.Sprintf("%s%d. priority %d: %s", marker, i+1, candidate.Priority, strings.Join(candidate.IPs, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
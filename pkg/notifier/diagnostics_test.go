@@ -0,0 +1,65 @@
+package notifier
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		check CheckDiagnostic
+		want  string
+	}{
+		{
+			name:  "HTTP status",
+			check: CheckDiagnostic{IP: "192.0.2.1", Priority: 100, CheckType: "https", StatusCode: 503, Latency: 120 * time.Millisecond, Error: "unexpected status code: 503"},
+			want:  "192.0.2.1 (priority 100): unhealthy, HTTP 503, 120ms",
+		},
+		{
+			name:  "TLS error",
+			check: CheckDiagnostic{IP: "192.0.2.1", Priority: 100, CheckType: "https", TLSError: "tls: failed to verify certificate", Latency: 5 * time.Millisecond, Error: "Get ...: tls: failed to verify certificate"},
+			want:  "192.0.2.1 (priority 100): unhealthy, tls: failed to verify certificate, 5ms",
+		},
+		{
+			name:  "packet loss",
+			check: CheckDiagnostic{IP: "192.0.2.1", Priority: 0, CheckType: "icmp", PacketLoss: 1, Latency: time.Second, Error: "i/o timeout"},
+			want:  "192.0.2.1 (priority 0): unhealthy, 100% packet loss, i/o timeout, 1s",
+		},
+		{
+			name:  "healthy",
+			check: CheckDiagnostic{IP: "198.51.100.1", Priority: 50, Healthy: true, CheckType: "http", Latency: 1500 * time.Microsecond},
+			want:  "198.51.100.1 (priority 50): healthy, 2ms",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatCheck(tt.check); got != tt.want {
+				t.Errorf("formatCheck() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatChecks_Limit(t *testing.T) {
+	event := FailoverEvent{Checks: make([]CheckDiagnostic, maxListedChecks+3)}
+	lines := strings.Split(formatChecks(event), "\n")
+	if len(lines) != maxListedChecks+1 || lines[maxListedChecks] != "and 3 more" {
+		t.Errorf("formatChecks() = %q", lines)
+	}
+}
+
+func TestFormatCandidates(t *testing.T) {
+	event := FailoverEvent{
+		Candidates: []FailoverCandidate{
+			{Priority: 100, IPs: []string{"192.0.2.1"}},
+			{Priority: 50, IPs: []string{"198.51.100.1", "198.51.100.2"}},
+		},
+		FailoverIndex: 1,
+	}
+	want := "  1. priority 100: 192.0.2.1\n→ 2. priority 50: 198.51.100.1, 198.51.100.2"
+	if got := formatCandidates(event); got != want {
+		t.Errorf("formatCandidates() = %q, want %q", got, want)
+	}
+}
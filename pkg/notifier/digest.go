@@ -0,0 +1,54 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxListedDigestEvents keeps a digest of a large outage within the field
+// limits of the chat services
+const maxListedDigestEvents = 20
+
+// digestPushLevels maps the severity of a digest to its push level
+var digestPushLevels = map[Severity]pushLevel{
+	SeverityInfo:     pushDefault,
+	SeverityWarning:  pushHigh,
+	SeverityCritical: pushEmergency,
+}
+
+// digestSeverity returns the highest severity of the events of a digest
+func digestSeverity(event FailoverEvent) Severity {
+	severity := SeverityInfo
+	for _, e := range event.Events {
+		severity = max(severity, SeverityFor(e))
+	}
+	return severity
+}
+
+// digestTitle returns the title of a digest, e.g.
+// "DNS Failover Digest - 40 events for 38 origins"
+func digestTitle(event FailoverEvent) string {
+	origins := make(map[string]bool, len(event.Events))
+	for _, e := range event.Events {
+		origins[eventOrigin(e)] = true
+	}
+	return fmt.Sprintf("DNS Failover Digest - %d events for %d origins", len(event.Events), len(origins))
+}
+
+// formatDigestEvents lists the events of a digest one per line, e.g.
+// "[warning] Failover to Backup IP: www.example.com (A) 192.0.2.1 -> 198.51.100.1"
+func formatDigestEvents(event FailoverEvent) string {
+	lines := make([]string, 0, len(event.Events))
+	for i, e := range event.Events {
+		if i == maxListedDigestEvents {
+			lines = append(lines, fmt.Sprintf("and %d more", len(event.Events)-maxListedDigestEvents))
+			break
+		}
+		line := fmt.Sprintf("[%s] %s: %s", SeverityFor(e), eventDescription(e), eventOrigin(e))
+		if len(e.OldIPs) > 0 || len(e.NewIPs) > 0 {
+			line += fmt.Sprintf(" %s -> %s", formatPushIPList(e.OldIPs, e.OldIP), formatPushIPList(e.NewIPs, e.NewIP))
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
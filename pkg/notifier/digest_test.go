@@ -0,0 +1,76 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func digestTestEvent() FailoverEvent {
+	return FailoverEvent{
+		Type:      EventTypeDigest,
+		Reason:    "3 events within 12s",
+		Timestamp: time.Now(),
+		Events: []FailoverEvent{
+			{Type: EventTypeFailover, OriginName: "www", ZoneName: "example.com", RecordType: "A", OldIPs: []string{"192.0.2.1"}, NewIPs: []string{"198.51.100.1"}, IsFailoverIP: true},
+			{Type: EventTypeAllIPsDown, OriginName: "api", ZoneName: "example.com", RecordType: "A"},
+			{Type: EventTypeFailover, OriginName: "www", ZoneName: "example.com", RecordType: "A", OldIPs: []string{"198.51.100.1"}, NewIPs: []string{"192.0.2.1"}, IsPriorityIP: true},
+		},
+	}
+}
+
+func TestDigestFormatting(t *testing.T) {
+	event := digestTestEvent()
+	if got := SeverityFor(event); got != SeverityCritical {
+		t.Errorf("SeverityFor() = %s, want the highest severity of the events", got)
+	}
+	if got := digestTitle(event); got != "DNS Failover Digest - 3 events for 2 origins" {
+		t.Errorf("digestTitle() = %q", got)
+	}
+	want := strings.Join([]string{
+		"[warning] Failover to Backup IP: www.example.com (A) 192.0.2.1 -> 198.51.100.1",
+		"[critical] All IPs Down (Records Unchanged): api.example.com (A)",
+		"[info] Failover to Priority IP: www.example.com (A) 198.51.100.1 -> 192.0.2.1",
+	}, "\n")
+	if got := formatDigestEvents(event); got != want {
+		t.Errorf("formatDigestEvents() = %q, want %q", got, want)
+	}
+
+	event.Events = make([]FailoverEvent, maxListedDigestEvents+5)
+	lines := strings.Split(formatDigestEvents(event), "\n")
+	if len(lines) != maxListedDigestEvents+1 || lines[maxListedDigestEvents] != "and 5 more" {
+		t.Errorf("Expected the list to be cut after %d events, got %d lines", maxListedDigestEvents, len(lines))
+	}
+}
+
+func TestSlackNotifier_Digest(t *testing.T) {
+	var message slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&message)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	slack := NewSlackNotifier(server.URL)
+	tmpl, err := NewMessageTemplate("{{ .Origin }}", "", nil)
+	if err != nil {
+		t.Fatalf("NewMessageTemplate() error = %v", err)
+	}
+	slack.SetTemplate(tmpl)
+	event := digestTestEvent()
+	event.Severity = SeverityFor(event)
+	if err := slack.Notify(context.Background(), event); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if message.Text != "DNS Failover Digest - 3 events for 2 origins" {
+		t.Errorf("Expected the template to be ignored for a digest, got %q", message.Text)
+	}
+	fields := slackFieldValues(message)
+	if message.Blocks[0].Text.Text != slackDanger+" "+message.Text || message.Blocks[1].Text.Text != "3 events within 12s" || len(fields) != 2 || strings.Count(fields["Events"], "\n") != 2 {
+		t.Errorf("Unexpected blocks %+v", message.Blocks)
+	}
+}
@@ -12,24 +12,61 @@ import (
 
 // DiscordNotifier implements the Notifier interface for Discord webhooks
 type DiscordNotifier struct {
+	templated
 	webhookURL string
-	httpClient *http.Client
+	// eventWebhooks are the webhooks of the event kinds that go elsewhere
+	eventWebhooks map[string]string
+	mentions      DiscordMentions
+	httpSender
+}
+
+// DiscordMentions are the roles and users pinged by the events of at least
+// MinSeverity. Nobody else is pinged, even when a template mentions them.
+type DiscordMentions struct {
+	Roles []string
+	Users []string
+	// Here pings the members of the channel that are online
+	Here        bool
+	MinSeverity Severity
+}
+
+// empty reports whether there is nobody to ping
+func (m DiscordMentions) empty() bool {
+	return len(m.Roles) == 0 && len(m.Users) == 0 && !m.Here
 }
 
 // NewDiscordNotifier creates a new Discord notifier
 func NewDiscordNotifier(webhookURL string) *DiscordNotifier {
 	return &DiscordNotifier{
 		webhookURL: webhookURL,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		httpSender: newHTTPSender(),
 	}
 }
 
+// SetMentions pings the roles and users of mentions on the events that are
+// severe enough
+func (d *DiscordNotifier) SetMentions(mentions DiscordMentions) {
+	d.mentions = mentions
+}
+
+// SetEventWebhooks sends the events of the kinds in webhooks, as returned by
+// EventKind, to their own webhook instead
+func (d *DiscordNotifier) SetEventWebhooks(webhooks map[string]string) {
+	d.eventWebhooks = webhooks
+}
+
 // discordMessage represents the message structure for Discord webhook
 type discordMessage struct {
-	Content string         `json:"content,omitempty"`
-	Embeds  []discordEmbed `json:"embeds,omitempty"`
+	Content         string                  `json:"content,omitempty"`
+	Embeds          []discordEmbed          `json:"embeds,omitempty"`
+	AllowedMentions *discordAllowedMentions `json:"allowed_mentions,omitempty"`
+}
+
+// discordAllowedMentions limits who a message pings
+type discordAllowedMentions struct {
+	Parse []string `json:"parse"`
+	Roles []string `json:"roles,omitempty"`
+	Users []string `json:"users,omitempty"`
 }
 
 type discordEmbed struct {
@@ -53,24 +90,119 @@ type discordFooter struct {
 
 // Notify sends a notification to Discord
 func (d *DiscordNotifier) Notify(ctx context.Context, event FailoverEvent) error {
+	if event.Type == EventTypeDigest {
+		return d.notifyDigest(ctx, event)
+	}
+
 	color := 16776960 // Yellow for warning
-	if event.ReturnToPriority && event.IsPriorityIP {
+	if (event.ReturnToPriority && event.IsPriorityIP) || event.Type == EventTypeFlappingStopped || event.Type == EventTypeOriginReleased || event.Type.IsLifecycle() {
 		color = 5763719 // Green for success
-	} else if event.IsFailoverIP {
+	} else if event.IsFailoverIP || event.Type.IsAlert() {
 		color = 15158332 // Red for danger
 	}
 
+	custom := d.message(event)
+	title := "🔄 DNS Failover Event - " + eventName(event)
+	if event.ObserveOnly {
+		title += observeOnlySuffix
+	}
+	title += repeatSuffix(event)
+	if custom.Title != "" {
+		title = custom.Title
+	}
+	description := event.Reason
+	if custom.Body != "" {
+		description = custom.Body
+	}
+
+	fields := []discordField{
+		{Name: "Origin", Value: eventOrigin(event), Inline: true},
+		{Name: "Event Type", Value: d.getEventType(event), Inline: true},
+		{Name: "Severity", Value: event.Severity.String(), Inline: true},
+		{Name: "Old IPs", Value: formatDiscordIPList(event.OldIPs, event.OldIP), Inline: true},
+		{Name: "New IPs", Value: formatDiscordIPList(event.NewIPs, event.NewIP), Inline: true},
+	}
+	if len(event.Checks) > 0 {
+		fields = append(fields, discordField{Name: "Checks", Value: formatChecks(event), Inline: false})
+	}
+	if len(event.Candidates) > 0 {
+		fields = append(fields, discordField{Name: "Candidates", Value: formatCandidates(event), Inline: false})
+	}
+	if len(custom.Fields) > 0 {
+		fields = fields[:0]
+		for _, field := range custom.Fields {
+			fields = append(fields, discordField{Name: field.Name, Value: field.Value, Inline: field.Inline})
+		}
+	}
+
 	message := discordMessage{
 		Embeds: []discordEmbed{
 			{
-				Title:       fmt.Sprintf("🔄 DNS Failover Event - %s.%s", event.OriginName, event.ZoneName),
+				Title:       title,
+				Description: description,
+				Color:       color,
+				Fields:      fields,
+				Footer: &discordFooter{
+					Text: "Cloudflare GSLB",
+				},
+				Timestamp: event.Timestamp.Format(time.RFC3339),
+			},
+		},
+	}
+	d.mention(&message, event.Severity >= d.mentions.MinSeverity)
+
+	webhookURL := d.webhookURL
+	if eventWebhook, ok := d.eventWebhooks[EventKind(event)]; ok {
+		webhookURL = eventWebhook
+	}
+	return d.send(ctx, webhookURL, message)
+}
+
+// mention pings the configured roles and users in message when ping is set.
+// Once mentions are configured, only they can ping, so that a template
+// cannot ping anyone on a recovery.
+func (d *DiscordNotifier) mention(message *discordMessage, ping bool) {
+	if d.mentions.empty() {
+		return
+	}
+	message.AllowedMentions = &discordAllowedMentions{Parse: []string{}}
+	if !ping {
+		return
+	}
+	var pings []string
+	if d.mentions.Here {
+		pings = append(pings, "@here")
+		message.AllowedMentions.Parse = []string{"everyone"}
+	}
+	for _, role := range d.mentions.Roles {
+		pings = append(pings, "<@&"+role+">")
+	}
+	for _, user := range d.mentions.Users {
+		pings = append(pings, "<@"+user+">")
+	}
+	message.Content = strings.Join(pings, " ")
+	message.AllowedMentions.Roles = d.mentions.Roles
+	message.AllowedMentions.Users = d.mentions.Users
+}
+
+// notifyDigest sends the events combined by a digest as one message
+func (d *DiscordNotifier) notifyDigest(ctx context.Context, event FailoverEvent) error {
+	color := 5763719 // Green for success
+	switch event.Severity {
+	case SeverityCritical:
+		color = 15158332 // Red for danger
+	case SeverityWarning:
+		color = 16776960 // Yellow for warning
+	}
+	message := discordMessage{
+		Embeds: []discordEmbed{
+			{
+				Title:       "📋 " + digestTitle(event),
 				Description: event.Reason,
 				Color:       color,
 				Fields: []discordField{
-					{Name: "Origin", Value: fmt.Sprintf("%s.%s (%s)", event.OriginName, event.ZoneName, event.RecordType), Inline: true},
-					{Name: "Event Type", Value: d.getEventType(event), Inline: true},
-					{Name: "Old IPs", Value: formatDiscordIPList(event.OldIPs, event.OldIP), Inline: true},
-					{Name: "New IPs", Value: formatDiscordIPList(event.NewIPs, event.NewIP), Inline: true},
+					{Name: "Severity", Value: event.Severity.String(), Inline: true},
+					{Name: "Events", Value: formatDigestEvents(event), Inline: false},
 				},
 				Footer: &discordFooter{
 					Text: "Cloudflare GSLB",
@@ -79,13 +211,48 @@ func (d *DiscordNotifier) Notify(ctx context.Context, event FailoverEvent) error
 			},
 		},
 	}
+	d.mention(&message, event.Severity >= d.mentions.MinSeverity)
+	return d.send(ctx, d.webhookURL, message)
+}
+
+// NotifySummary sends a periodic summary to Discord
+func (d *DiscordNotifier) NotifySummary(ctx context.Context, summary Summary) error {
+	color := 5763719 // Green for success
+	title := "✅ " + summaryTitle(summary)
+	if !summary.Healthy() {
+		color = 16776960 // Yellow for warning
+		title = "⚠️ " + summaryTitle(summary)
+	}
+	message := discordMessage{
+		Embeds: []discordEmbed{
+			{
+				Title:       title,
+				Description: summaryPeriod(summary),
+				Color:       color,
+				Fields: []discordField{
+					{Name: "Failovers", Value: summaryFailovers(summary), Inline: true},
+					{Name: "Degraded Origins", Value: summaryDegraded(summary), Inline: true},
+					{Name: "Uptime", Value: summary.Uptime.Round(time.Minute).String(), Inline: true},
+				},
+				Footer: &discordFooter{
+					Text: "Cloudflare GSLB",
+				},
+				Timestamp: summary.Until.Format(time.RFC3339),
+			},
+		},
+	}
+	// Summaries are routine, so they ping nobody
+	d.mention(&message, false)
+	return d.send(ctx, d.webhookURL, message)
+}
 
+func (d *DiscordNotifier) send(ctx context.Context, webhookURL string, message discordMessage) error {
 	payload, err := json.Marshal(message)
 	if err != nil {
 		return fmt.Errorf("failed to marshal Discord message: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", d.webhookURL, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewBuffer(payload))
 	if err != nil {
 		return fmt.Errorf("failed to create Discord request: %w", err)
 	}
@@ -107,6 +274,38 @@ func (d *DiscordNotifier) Notify(ctx context.Context, event FailoverEvent) error
 
 func (d *DiscordNotifier) getEventType(event FailoverEvent) string {
 	switch {
+	case event.Type == EventTypeChangeLimitExceeded:
+		return "🛑 Change Limit Exceeded (DNS Changes Frozen)"
+	case event.Type == EventTypeVerificationFailed:
+		return "🚫 DNS Verification Failed"
+	case event.Type == EventTypeMinHealthyViolated:
+		return "🚨 Minimum Healthy Not Met (Serving Degraded IPs)"
+	case event.Type == EventTypeAllowlistViolation:
+		return "⛔ IP Outside Allowlist (DNS Change Refused)"
+	case event.Type == EventTypeAllIPsDown:
+		return "💀 All IPs Down (Records Unchanged)"
+	case event.Type == EventTypeAPIFailure:
+		return "🔌 DNS API Failure"
+	case event.Type == EventTypeFlapping:
+		return "🔁 Origin Flapping (Individual Changes Not Notified)"
+	case event.Type == EventTypeFlappingStopped:
+		return "✅ Flapping Stopped"
+	case event.Type == EventTypeCheckerFailed:
+		return "🩺 Health Checker Failed (Origin Not Monitored)"
+	case event.Type == EventTypeReloadFailed:
+		return "📄 Config Reload Failed (Current Config Kept)"
+	case event.Type == EventTypeServiceStarted:
+		return "▶️ Service Started"
+	case event.Type == EventTypeServiceStopped:
+		return "⏹️ Service Stopped"
+	case event.Type == EventTypeConfigReloaded:
+		return "📄 Config Reloaded"
+	case event.Type == EventTypeOriginHeld:
+		return "✋ Origin Held (Automatic Changes Paused)"
+	case event.Type == EventTypeOriginReleased:
+		return "▶️ Origin Released (Automatic Changes Resumed)"
+	case event.Type == EventTypePanic:
+		return "💥 Service Panicked (Exiting)"
 	case event.ReturnToPriority && event.IsPriorityIP:
 		return "✅ Recovery (Return to Priority IP)"
 	case event.IsPriorityIP:
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 	"time"
 )
@@ -184,6 +185,19 @@ func TestDiscordNotifier_GetEventType(t *testing.T) {
 			},
 			expected: "❌ Failover to Backup IP",
 		},
+		{
+			name: "change limit exceeded",
+			event: FailoverEvent{
+				Type:         EventTypeChangeLimitExceeded,
+				IsFailoverIP: true,
+			},
+			expected: "🛑 Change Limit Exceeded (DNS Changes Frozen)",
+		},
+		{
+			name:     "allowlist violation",
+			event:    FailoverEvent{Type: EventTypeAllowlistViolation},
+			expected: "⛔ IP Outside Allowlist (DNS Change Refused)",
+		},
 		{
 			name:     "generic failover",
 			event:    FailoverEvent{},
@@ -200,3 +214,85 @@ func TestDiscordNotifier_GetEventType(t *testing.T) {
 		})
 	}
 }
+
+func TestDiscordNotifier_Mentions(t *testing.T) {
+	server, body := captureBody(t, http.StatusNoContent)
+	notifier := NewDiscordNotifier(server.URL)
+	notifier.SetMentions(DiscordMentions{Roles: []string{"111"}, Users: []string{"222"}, Here: true, MinSeverity: SeverityCritical})
+
+	tests := []struct {
+		name        string
+		event       FailoverEvent
+		wantContent string
+		wantParse   []string
+	}{
+		{
+			name:        "critical alert pings",
+			event:       FailoverEvent{OriginName: "www", ZoneName: "example.com", Type: EventTypeAllIPsDown, Severity: SeverityCritical},
+			wantContent: "@here <@&111> <@222>",
+			wantParse:   []string{"everyone"},
+		},
+		{
+			name:      "recovery pings nobody",
+			event:     FailoverEvent{OriginName: "www", ZoneName: "example.com", IsPriorityIP: true, ReturnToPriority: true, Severity: SeverityInfo},
+			wantParse: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := notifier.Notify(context.Background(), tt.event); err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+			var msg discordMessage
+			if err := json.Unmarshal(*body, &msg); err != nil {
+				t.Fatalf("Failed to unmarshal request body: %v", err)
+			}
+			if msg.Content != tt.wantContent || msg.AllowedMentions == nil || !slices.Equal(msg.AllowedMentions.Parse, tt.wantParse) {
+				t.Errorf("content %q, allowed mentions %+v", msg.Content, msg.AllowedMentions)
+			}
+		})
+	}
+
+	// Without mentions, messages are sent as before
+	if err := NewDiscordNotifier(server.URL).Notify(context.Background(), tests[0].event); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	var msg discordMessage
+	if err := json.Unmarshal(*body, &msg); err != nil {
+		t.Fatalf("Failed to unmarshal request body: %v", err)
+	}
+	if msg.Content != "" || msg.AllowedMentions != nil {
+		t.Errorf("Expected no mentions, got content %q, allowed mentions %+v", msg.Content, msg.AllowedMentions)
+	}
+}
+
+func TestDiscordNotifier_EventWebhooks(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier := NewDiscordNotifier(server.URL + "/default")
+	notifier.SetEventWebhooks(map[string]string{
+		KindRecovery:                server.URL + "/quiet",
+		string(EventTypeAllIPsDown): server.URL + "/oncall",
+	})
+	events := []FailoverEvent{
+		{OriginName: "www", ZoneName: "example.com", IsFailoverIP: true},
+		{OriginName: "www", ZoneName: "example.com", Type: EventTypeAllIPsDown},
+		{OriginName: "www", ZoneName: "example.com", IsPriorityIP: true, ReturnToPriority: true},
+	}
+	for _, event := range events {
+		if err := notifier.Notify(context.Background(), event); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+	}
+	if err := notifier.NotifySummary(context.Background(), testSummary()); err != nil {
+		t.Fatalf("NotifySummary() error = %v", err)
+	}
+	if want := []string{"/default", "/oncall", "/quiet", "/default"}; !slices.Equal(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+}
@@ -0,0 +1,128 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// execOutputLimit is how much of the output of a failed command is kept for
+// the error
+const execOutputLimit = 1024
+
+// ExecNotifier implements the Notifier interface by running a local command
+// for every event, such as a legacy paging script. The event is written to
+// the standard input of the command as JSON and set in GSLB_* environment
+// variables.
+type ExecNotifier struct {
+	templated
+	command []string
+	env     []string
+	timeout time.Duration
+	slots   chan struct{}
+}
+
+// NewExecNotifier creates a new exec notifier running command with the
+// extra environment variables in env. Each run is killed after timeout, and
+// at most maxConcurrent commands run at once; the others wait for a slot.
+func NewExecNotifier(command []string, env map[string]string, timeout time.Duration, maxConcurrent int) *ExecNotifier {
+	extra := make([]string, 0, len(env))
+	for name, value := range env {
+		extra = append(extra, name+"="+value)
+	}
+	return &ExecNotifier{
+		command: command,
+		env:     extra,
+		timeout: timeout,
+		slots:   make(chan struct{}, max(maxConcurrent, 1)),
+	}
+}
+
+// Notify runs the command for event
+func (n *ExecNotifier) Notify(ctx context.Context, event FailoverEvent) error {
+	custom := n.message(event)
+	payload := newEventPayload(event, pushTitle(event, custom), pushMessage(event, custom))
+	env := []string{
+		"GSLB_EVENT_KIND=" + payload.Kind,
+		"GSLB_SEVERITY=" + payload.Severity,
+		"GSLB_TITLE=" + payload.Title,
+		"GSLB_MESSAGE=" + payload.Message,
+		"GSLB_ORIGIN=" + payload.Origin,
+		"GSLB_ZONE=" + payload.Zone,
+		"GSLB_RECORD_TYPE=" + payload.RecordType,
+		"GSLB_OLD_IPS=" + strings.Join(payload.OldIPs, ","),
+		"GSLB_NEW_IPS=" + strings.Join(payload.NewIPs, ","),
+		"GSLB_REASON=" + payload.Reason,
+		"GSLB_TIMESTAMP=" + payload.Timestamp.Format(time.RFC3339),
+	}
+	return n.run(ctx, payload, env)
+}
+
+// NotifySummary runs the command for a periodic summary
+func (n *ExecNotifier) NotifySummary(ctx context.Context, summary Summary) error {
+	payload := newSummaryPayload(summary)
+	env := []string{
+		"GSLB_EVENT_KIND=" + payload.Kind,
+		"GSLB_TITLE=" + payload.Title,
+		"GSLB_MESSAGE=" + payload.Message,
+		"GSLB_TIMESTAMP=" + payload.Until.Format(time.RFC3339),
+	}
+	return n.run(ctx, payload, env)
+}
+
+// run runs the command with payload on its standard input, once a slot is free
+func (n *ExecNotifier) run(ctx context.Context, payload any, env []string) error {
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal exec payload: %w", err)
+	}
+
+	select {
+	case n.slots <- struct{}{}:
+		defer func() { <-n.slots }()
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for a free exec slot: %w", ctx.Err())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, n.command[0], n.command[1:]...)
+	cmd.Env = append(append(os.Environ(), n.env...), env...)
+	cmd.Stdin = bytes.NewReader(input)
+	output := &cappedBuffer{limit: execOutputLimit}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	// Children that keep the output open must not block the notifier
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", n.timeout)
+		}
+		if out := strings.TrimSpace(output.String()); out != "" {
+			return fmt.Errorf("exec notifier %s failed: %w: %s", n.command[0], err, strconv.Quote(out))
+		}
+		return fmt.Errorf("exec notifier %s failed: %w", n.command[0], err)
+	}
+	return nil
+}
+
+// cappedBuffer keeps the first limit bytes written to it and discards the rest
+type cappedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
@@ -0,0 +1,105 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecNotifier_Notify(t *testing.T) {
+	dir := t.TempDir()
+	stdin := filepath.Join(dir, "stdin.json")
+	env := filepath.Join(dir, "env")
+	script := `cat > "$1"; echo "$GSLB_EVENT_KIND $GSLB_SEVERITY $GSLB_ORIGIN $GSLB_NEW_IPS $PAGER_TEAM" > "$2"`
+	notifier := NewExecNotifier([]string{"/bin/sh", "-c", script, "sh", stdin, env}, map[string]string{"PAGER_TEAM": "sre"}, 5*time.Second, 1)
+
+	event := FailoverEvent{
+		OriginName:   "www",
+		ZoneName:     "example.com",
+		RecordType:   "A",
+		OldIPs:       []string{"192.0.2.1"},
+		NewIPs:       []string{"198.51.100.1", "198.51.100.2"},
+		IsFailoverIP: true,
+		Reason:       "primary down",
+		Timestamp:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	if err := notifier.Notify(context.Background(), event); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	data, err := os.ReadFile(stdin)
+	if err != nil {
+		t.Fatalf("Failed to read the standard input: %v", err)
+	}
+	var payload eventPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("Failed to decode %s: %v", data, err)
+	}
+	if payload.Kind != KindFailover || payload.Severity != "warning" || payload.Zone != "example.com" ||
+		len(payload.NewIPs) != 2 || payload.Reason != "primary down" || !payload.Timestamp.Equal(event.Timestamp) {
+		t.Errorf("Unexpected payload %+v", payload)
+	}
+	if !strings.HasPrefix(payload.Title, "DNS Failover Event - www.example.com") {
+		t.Errorf("Unexpected title %q", payload.Title)
+	}
+
+	data, err = os.ReadFile(env)
+	if err != nil {
+		t.Fatalf("Failed to read the environment: %v", err)
+	}
+	if got, want := strings.TrimSpace(string(data)), "failover warning www 198.51.100.1,198.51.100.2 sre"; got != want {
+		t.Errorf("environment = %q, want %q", got, want)
+	}
+}
+
+func TestExecNotifier_NotifySummary(t *testing.T) {
+	stdin := filepath.Join(t.TempDir(), "stdin.json")
+	notifier := NewExecNotifier([]string{"/bin/sh", "-c", `cat > "$1"`, "sh", stdin}, nil, 5*time.Second, 1)
+
+	summary := Summary{Origins: 2, Monitored: 2, Uptime: time.Hour, Failovers: map[string]int{"www.example.com (A)": 1}}
+	if err := notifier.NotifySummary(context.Background(), summary); err != nil {
+		t.Fatalf("NotifySummary() error = %v", err)
+	}
+	data, err := os.ReadFile(stdin)
+	if err != nil {
+		t.Fatalf("Failed to read the standard input: %v", err)
+	}
+	var payload summaryPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("Failed to decode %s: %v", data, err)
+	}
+	if payload.Kind != "summary" || payload.UptimeSeconds != 3600 || payload.Failovers["www.example.com (A)"] != 1 {
+		t.Errorf("Unexpected payload %+v", payload)
+	}
+}
+
+func TestExecNotifier_Errors(t *testing.T) {
+	event := FailoverEvent{OriginName: "www", ZoneName: "example.com", RecordType: "A", Timestamp: time.Now()}
+
+	failing := NewExecNotifier([]string{"/bin/sh", "-c", "echo 'pager unreachable' >&2; exit 3"}, nil, 5*time.Second, 1)
+	if err := failing.Notify(context.Background(), event); err == nil || !strings.Contains(err.Error(), "pager unreachable") {
+		t.Errorf("Expected the output in the error, got %v", err)
+	}
+
+	slow := NewExecNotifier([]string{"/bin/sh", "-c", "sleep 10"}, nil, 100*time.Millisecond, 1)
+	start := time.Now()
+	if err := slow.Notify(context.Background(), event); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the command to be killed, took %s", elapsed)
+	}
+
+	// With every slot taken, the event waits until its context is done
+	busy := NewExecNotifier([]string{"/bin/true"}, nil, 5*time.Second, 1)
+	busy.slots <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := busy.Notify(ctx, event); err == nil || !strings.Contains(err.Error(), "slot") {
+		t.Errorf("Expected to wait for a slot, got %v", err)
+	}
+}
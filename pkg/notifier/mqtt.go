@@ -0,0 +1,52 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/mqtt"
+)
+
+// MQTTNotifier implements the Notifier interface by publishing the events as
+// JSON to an MQTT topic, for automation that subscribes to a broker
+type MQTTNotifier struct {
+	templated
+	publisher *mqtt.Publisher
+	topic     string
+	qos       byte
+	retain    bool
+}
+
+// NewMQTTNotifier creates a new MQTT notifier publishing to topic through
+// publisher with the given QoS, retained by the broker if retain is set
+func NewMQTTNotifier(publisher *mqtt.Publisher, topic string, qos byte, retain bool) *MQTTNotifier {
+	return &MQTTNotifier{
+		publisher: publisher,
+		topic:     topic,
+		qos:       qos,
+		retain:    retain,
+	}
+}
+
+// Notify publishes event
+func (m *MQTTNotifier) Notify(ctx context.Context, event FailoverEvent) error {
+	custom := m.message(event)
+	return m.publish(ctx, newEventPayload(event, pushTitle(event, custom), pushMessage(event, custom)))
+}
+
+// NotifySummary publishes a periodic summary
+func (m *MQTTNotifier) NotifySummary(ctx context.Context, summary Summary) error {
+	return m.publish(ctx, newSummaryPayload(summary))
+}
+
+func (m *MQTTNotifier) publish(ctx context.Context, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal MQTT message: %w", err)
+	}
+	if err := m.publisher.Publish(ctx, mqtt.Message{Topic: m.topic, Payload: data, QoS: m.qos, Retain: m.retain}); err != nil {
+		return fmt.Errorf("failed to send MQTT notification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/mqtt"
+)
+
+func TestMQTTNotifier_Notify(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// Accept the CONNECT, then keep everything up to the DISCONNECT
+		buf := make([]byte, 1024)
+		if _, err := conn.Read(buf); err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte{0x20, 0x02, 0x00, 0x00})
+		data, _ := io.ReadAll(conn)
+		received <- data
+	}()
+
+	publisher, err := mqtt.NewPublisher(mqtt.Config{Address: listener.Addr().String(), ClientID: "test"})
+	if err != nil {
+		t.Fatalf("NewPublisher() error = %v", err)
+	}
+	notifier := NewMQTTNotifier(publisher, "gslb/events", 0, false)
+	event := FailoverEvent{OriginName: "www", ZoneName: "example.com", RecordType: "A", NewIPs: []string{"198.51.100.1"}, IsFailoverIP: true, Timestamp: time.Now()}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := notifier.Notify(ctx, event); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	var data []byte
+	select {
+	case data = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the publish")
+	}
+	topic := bytes.Index(data, []byte("gslb/events"))
+	start := bytes.IndexByte(data, '{')
+	end := bytes.LastIndexByte(data, '}')
+	if topic < 0 || start < topic || end < start {
+		t.Fatalf("Expected a publish to gslb/events, got %q", data)
+	}
+	var payload eventPayload
+	if err := json.Unmarshal(data[start:end+1], &payload); err != nil {
+		t.Fatalf("Failed to decode %q: %v", data[start:end+1], err)
+	}
+	if payload.Kind != KindFailover || payload.Origin != "www" || len(payload.NewIPs) != 1 {
+		t.Errorf("Unexpected payload %+v", payload)
+	}
+}
@@ -2,11 +2,81 @@ package notifier
 
 import (
 	"context"
+	"fmt"
 	"time"
 )
 
+// EventType identifies the kind of event being notified
+type EventType string
+
+const (
+	// EventTypeFailover is a DNS change driven by health check results
+	EventTypeFailover EventType = "failover"
+	// EventTypeChangeLimitExceeded is raised when DNS changes are frozen by the change limit
+	EventTypeChangeLimitExceeded EventType = "change_limit_exceeded"
+	// EventTypeVerificationFailed is raised when a DNS change could not be confirmed as live
+	EventTypeVerificationFailed EventType = "verification_failed"
+	// EventTypeMinHealthyViolated is raised when fewer than min_healthy IPs are healthy and degraded IPs are served
+	EventTypeMinHealthyViolated EventType = "min_healthy_violated"
+	// EventTypeAllowlistViolation is raised when a DNS change is refused because an IP is outside the allowed CIDRs
+	EventTypeAllowlistViolation EventType = "allowlist_violation"
+	// EventTypeAllIPsDown is raised when no IP of an origin is healthy and the records are left as they are
+	EventTypeAllIPsDown EventType = "all_ips_down"
+	// EventTypeAPIFailure is raised when the DNS records of an origin cannot be read or updated
+	EventTypeAPIFailure EventType = "dns_api_failure"
+	// EventTypeFlapping is raised when an origin changes its published IPs too often; the
+	// notifications of its individual changes are suppressed until it is stable again
+	EventTypeFlapping EventType = "flapping"
+	// EventTypeFlappingStopped is sent when a flapping origin has been stable for the flapping window
+	EventTypeFlappingStopped EventType = "flapping_stopped"
+	// EventTypeCheckerFailed is raised when the health checker of an origin cannot be created, so it is not monitored
+	EventTypeCheckerFailed EventType = "health_checker_failed"
+	// EventTypeReloadFailed is raised when a changed configuration cannot be loaded or applied and the
+	// current one keeps running. It is about the service rather than an origin, so the origin fields are empty
+	EventTypeReloadFailed EventType = "config_reload_failed"
+	// EventTypeServiceStarted is sent when the service starts monitoring
+	EventTypeServiceStarted EventType = "service_started"
+	// EventTypeServiceStopped is sent when the service shuts down cleanly
+	EventTypeServiceStopped EventType = "service_stopped"
+	// EventTypeConfigReloaded is sent when a changed configuration has been applied
+	EventTypeConfigReloaded EventType = "config_reloaded"
+	// EventTypePanic is raised when the service panicked and is exiting
+	EventTypePanic EventType = "service_panicked"
+	// EventTypeOriginHeld is sent when someone acknowledged an origin and held it, which pauses its
+	// automatic DNS changes until it is released
+	EventTypeOriginHeld EventType = "origin_held"
+	// EventTypeOriginReleased is sent when a held origin is released to automatic DNS changes again
+	EventTypeOriginReleased EventType = "origin_released"
+	// EventTypeDigest combines the events sent to a notifier within its digest window, which are in Events
+	EventTypeDigest EventType = "digest"
+)
+
+// IsAlert reports whether the event type signals a problem with the failover itself
+func (t EventType) IsAlert() bool {
+	switch t {
+	case EventTypeChangeLimitExceeded, EventTypeVerificationFailed, EventTypeMinHealthyViolated, EventTypeAllowlistViolation,
+		EventTypeAllIPsDown, EventTypeAPIFailure, EventTypeFlapping, EventTypeCheckerFailed, EventTypeReloadFailed, EventTypePanic:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsLifecycle reports whether the event type reports the service starting,
+// stopping or reloading. Lifecycle events are about the service rather than
+// an origin, so their origin fields are empty
+func (t EventType) IsLifecycle() bool {
+	switch t {
+	case EventTypeServiceStarted, EventTypeServiceStopped, EventTypeConfigReloaded:
+		return true
+	default:
+		return false
+	}
+}
+
 // FailoverEvent represents a failover event
 type FailoverEvent struct {
+	Type             EventType
 	OriginName       string
 	ZoneName         string
 	RecordType       string
@@ -22,6 +92,110 @@ type FailoverEvent struct {
 	OldPriority      int
 	NewPriority      int
 	MaxPriority      int
+	ObserveOnly      bool                // true when the origin is in observe mode and DNS was not changed
+	Severity         Severity            // set from SeverityFor when the event is dispatched
+	Labels           map[string]string   // labels of the origin, for message templates
+	Repeat           int                 // how many times an alert has been sent again by escalation; 0 the first time
+	Checks           []CheckDiagnostic   // health checks run by the check that led to the event, in the order they ran
+	Candidates       []FailoverCandidate // priority levels considered, most preferred first
+	FailoverIndex    int                 // index in Candidates of the level switched to; -1 when none was
+	Events           []FailoverEvent     // events combined by a digest, oldest first
+}
+
+// CheckDiagnostic is the health check of one IP
+type CheckDiagnostic struct {
+	IP         string
+	Priority   int
+	Healthy    bool
+	Latency    time.Duration
+	CheckType  string  // health check type of the origin: http, https or icmp
+	StatusCode int     // HTTP status of a failed check; 0 when there was no response
+	TLSError   string  // TLS handshake or certificate error
+	PacketLoss float64 // share of ICMP echo requests left unanswered, from 0 to 1
+	Error      string  // why the check failed
+}
+
+// FailoverCandidate is a priority level the IPs to publish were chosen from
+type FailoverCandidate struct {
+	Priority int
+	IPs      []string
+}
+
+const observeOnlySuffix = " (observe only, DNS not changed)"
+
+// serviceName stands in for the origin in events about the service itself
+const serviceName = "cloudflare-gslb"
+
+// eventName names the record of event, e.g. "www.example.com"
+func eventName(event FailoverEvent) string {
+	if event.OriginName == "" {
+		return serviceName
+	}
+	return event.OriginName + "." + event.ZoneName
+}
+
+// eventOrigin names the record of event with its type, e.g. "www.example.com (A)"
+func eventOrigin(event FailoverEvent) string {
+	if event.OriginName == "" {
+		return serviceName
+	}
+	return fmt.Sprintf("%s.%s (%s)", event.OriginName, event.ZoneName, event.RecordType)
+}
+
+// repeatSuffix marks an alert sent again by escalation
+func repeatSuffix(event FailoverEvent) string {
+	if event.Repeat == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (repeat %d)", event.Repeat)
+}
+
+// eventDescription describes the kind of event in plain text
+func eventDescription(event FailoverEvent) string {
+	switch {
+	case event.Type == EventTypeChangeLimitExceeded:
+		return "Change Limit Exceeded (DNS Changes Frozen)"
+	case event.Type == EventTypeVerificationFailed:
+		return "DNS Verification Failed"
+	case event.Type == EventTypeMinHealthyViolated:
+		return "Minimum Healthy Not Met (Serving Degraded IPs)"
+	case event.Type == EventTypeAllowlistViolation:
+		return "IP Outside Allowlist (DNS Change Refused)"
+	case event.Type == EventTypeAllIPsDown:
+		return "All IPs Down (Records Unchanged)"
+	case event.Type == EventTypeAPIFailure:
+		return "DNS API Failure"
+	case event.Type == EventTypeFlapping:
+		return "Origin Flapping (Individual Changes Not Notified)"
+	case event.Type == EventTypeFlappingStopped:
+		return "Flapping Stopped"
+	case event.Type == EventTypeCheckerFailed:
+		return "Health Checker Failed (Origin Not Monitored)"
+	case event.Type == EventTypeReloadFailed:
+		return "Config Reload Failed (Current Config Kept)"
+	case event.Type == EventTypeServiceStarted:
+		return "Service Started"
+	case event.Type == EventTypeServiceStopped:
+		return "Service Stopped"
+	case event.Type == EventTypeConfigReloaded:
+		return "Config Reloaded"
+	case event.Type == EventTypeOriginHeld:
+		return "Origin Held"
+	case event.Type == EventTypeOriginReleased:
+		return "Origin Released"
+	case event.Type == EventTypePanic:
+		return "Service Panicked (Exiting)"
+	case event.Type == EventTypeDigest:
+		return "Digest"
+	case event.ReturnToPriority && event.IsPriorityIP:
+		return "Recovery (Return to Priority IP)"
+	case event.IsPriorityIP:
+		return "Failover to Priority IP"
+	case event.IsFailoverIP:
+		return "Failover to Backup IP"
+	default:
+		return "Failover"
+	}
 }
 
 // Notifier is the interface that all notifiers must implement
@@ -0,0 +1,121 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ntfyPriorities maps push levels to ntfy priorities (1 = min to 5 = max)
+var ntfyPriorities = map[pushLevel]int{
+	pushLow:       2,
+	pushDefault:   3,
+	pushHigh:      4,
+	pushEmergency: 5,
+}
+
+// NtfyNotifier implements the Notifier interface for ntfy topics
+type NtfyNotifier struct {
+	templated
+	serverURL string
+	topic     string
+	token     string
+	httpSender
+}
+
+// NewNtfyNotifier creates a new ntfy notifier publishing to topic on the
+// server. token is an access token for protected topics and may be empty
+func NewNtfyNotifier(serverURL, topic, token string) *NtfyNotifier {
+	return &NtfyNotifier{
+		serverURL:  strings.TrimSuffix(serverURL, "/"),
+		topic:      topic,
+		token:      token,
+		httpSender: newHTTPSender(),
+	}
+}
+
+// ntfyMessage is the body of a JSON publish request
+type ntfyMessage struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// Notify sends a notification to ntfy
+func (n *NtfyNotifier) Notify(ctx context.Context, event FailoverEvent) error {
+	level := pushLevelFor(event)
+	custom := n.message(event)
+	return n.send(ctx, ntfyMessage{
+		Topic:    n.topic,
+		Title:    pushTitle(event, custom),
+		Message:  pushMessage(event, custom),
+		Priority: ntfyPriorities[level],
+		Tags:     []string{ntfyTag(event, level)},
+	})
+}
+
+// NotifySummary sends a periodic summary to ntfy
+func (n *NtfyNotifier) NotifySummary(ctx context.Context, summary Summary) error {
+	tag := "white_check_mark"
+	if !summary.Healthy() {
+		tag = "warning"
+	}
+	return n.send(ctx, ntfyMessage{
+		Topic:    n.topic,
+		Title:    summaryTitle(summary),
+		Message:  pushSummaryMessage(summary),
+		Priority: ntfyPriorities[pushLow],
+		Tags:     []string{tag},
+	})
+}
+
+func (n *NtfyNotifier) send(ctx context.Context, message ntfyMessage) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ntfy message: %w", err)
+	}
+
+	// Publishing JSON goes to the root URL with the topic in the body
+	req, err := http.NewRequestWithContext(ctx, "POST", n.serverURL+"/", bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create ntfy request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send ntfy notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ntfy server returned status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// ntfyTag returns the emoji shortcode shown with the notification
+func ntfyTag(event FailoverEvent, level pushLevel) string {
+	switch {
+	case level == pushEmergency:
+		return "rotating_light"
+	case (event.ReturnToPriority && event.IsPriorityIP) || event.Type == EventTypeFlappingStopped || event.Type == EventTypeOriginReleased:
+		return "white_check_mark"
+	case event.Type.IsLifecycle():
+		return "information_source"
+	case level == pushHigh:
+		return "x"
+	default:
+		return "arrows_counterclockwise"
+	}
+}
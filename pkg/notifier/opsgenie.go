@@ -0,0 +1,222 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// opsgenieSource is the source of the alerts created by the notifier
+const opsgenieSource = "cloudflare-gslb"
+
+// opsgenieMaxMessage is the longest alert message Opsgenie accepts
+const opsgenieMaxMessage = 130
+
+// OpsgenieNotifier implements the Notifier interface for the Opsgenie Alert API.
+// Alerts use an alias per origin and event type, so that repeated events
+// update one open alert, and the failover alert of an origin is closed when
+// it is back on its highest priority IPs.
+type OpsgenieNotifier struct {
+	templated
+	apiURL   string
+	apiKey   string
+	priority string
+	tags     []string
+	httpSender
+}
+
+// NewOpsgenieNotifier creates a new Opsgenie notifier
+func NewOpsgenieNotifier(apiURL, apiKey, priority string, tags []string) *OpsgenieNotifier {
+	return &OpsgenieNotifier{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		apiKey:     apiKey,
+		priority:   priority,
+		tags:       tags,
+		httpSender: newHTTPSender(),
+	}
+}
+
+// opsgenieAlert is the body of a create alert request
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Entity      string            `json:"entity,omitempty"`
+	Source      string            `json:"source"`
+	Priority    string            `json:"priority,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+// opsgenieClose is the body of a close alert request
+type opsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note,omitempty"`
+}
+
+// opsgenieResolves maps the events that end a condition to the alert they close
+var opsgenieResolves = map[EventType]EventType{
+	EventTypeFlappingStopped: EventTypeFlapping,
+	EventTypeServiceStarted:  EventTypePanic,
+	EventTypeConfigReloaded:  EventTypeReloadFailed,
+	EventTypeOriginReleased:  EventTypeOriginHeld,
+}
+
+// Notify creates or updates the alert of the event, or closes the failover
+// alert of the origin when the event returns it to its highest priority and
+// the alert of a condition when an event ends it, such as the flapping alert
+// when the origin stopped flapping
+func (o *OpsgenieNotifier) Notify(ctx context.Context, event FailoverEvent) error {
+	if event.Type == EventTypeServiceStopped {
+		// A clean shutdown needs nobody's attention
+		return nil
+	}
+	alias := opsgenieAlias(event)
+	resolved, resolves := opsgenieResolves[event.Type]
+	if resolves {
+		closed := event
+		closed.Type = resolved
+		alias = opsgenieAlias(closed)
+	}
+	if (event.Type == EventTypeFailover && event.IsPriorityIP) || resolves {
+		path := "/v2/alerts/" + url.PathEscape(alias) + "/close?identifierType=alias"
+		return o.send(ctx, path, opsgenieClose{Source: opsgenieSource, Note: event.Reason})
+	}
+
+	custom := o.message(event)
+	message := o.getEventType(event) + ": " + eventOrigin(event)
+	if event.ObserveOnly {
+		message += observeOnlySuffix
+	}
+	if custom.Title != "" {
+		message = custom.Title
+	}
+	if runes := []rune(message); len(runes) > opsgenieMaxMessage {
+		message = string(runes[:opsgenieMaxMessage])
+	}
+
+	details := map[string]string{
+		"origin":      event.OriginName,
+		"zone":        event.ZoneName,
+		"record_type": event.RecordType,
+		"event":       string(event.Type),
+		"severity":    event.Severity.String(),
+		"old_ips":     formatOpsgenieIPList(event.OldIPs, event.OldIP),
+		"new_ips":     formatOpsgenieIPList(event.NewIPs, event.NewIP),
+	}
+	if event.MaxPriority != 0 {
+		details["old_priority"] = strconv.Itoa(event.OldPriority)
+		details["new_priority"] = strconv.Itoa(event.NewPriority)
+		details["max_priority"] = strconv.Itoa(event.MaxPriority)
+	}
+	if len(event.Checks) > 0 {
+		details["checks"] = formatChecks(event)
+	}
+	if len(event.Candidates) > 0 {
+		details["candidates"] = formatCandidates(event)
+	}
+	// Integrations may rely on the details above, so fields are added to them
+	for _, field := range custom.Fields {
+		details[field.Name] = field.Value
+	}
+	description := fmt.Sprintf("%s\n\nOld IPs: %s\nNew IPs: %s", event.Reason, details["old_ips"], details["new_ips"])
+	if checks := details["checks"]; checks != "" {
+		description += "\n\nChecks:\n" + checks
+	}
+	if custom.Body != "" {
+		description = custom.Body
+	}
+
+	alert := opsgenieAlert{
+		Message:     message,
+		Alias:       alias,
+		Description: description,
+		Entity:      eventName(event),
+		Source:      opsgenieSource,
+		Priority:    o.priority,
+		Tags:        append(append([]string{}, o.tags...), string(event.Type)),
+		Details:     details,
+	}
+	return o.send(ctx, "/v2/alerts", alert)
+}
+
+// opsgenieAlias identifies the alert of an origin and event type
+func opsgenieAlias(event FailoverEvent) string {
+	eventType := event.Type
+	if eventType == "" {
+		eventType = EventTypeFailover
+	}
+	return fmt.Sprintf("%s:%s:%s:%s", opsgenieSource, eventType, eventName(event), event.RecordType)
+}
+
+func (o *OpsgenieNotifier) send(ctx context.Context, path string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Opsgenie request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.apiURL+path, bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create Opsgenie request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+o.apiKey)
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Opsgenie request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Opsgenie processes requests asynchronously and answers 202 Accepted
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("opsgenie API returned status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (o *OpsgenieNotifier) getEventType(event FailoverEvent) string {
+	switch event.Type {
+	case EventTypeChangeLimitExceeded:
+		return "DNS changes frozen by the change limit"
+	case EventTypeVerificationFailed:
+		return "DNS verification failed"
+	case EventTypeMinHealthyViolated:
+		return "Minimum healthy not met"
+	case EventTypeAllowlistViolation:
+		return "DNS change refused by the allowlist"
+	case EventTypeAllIPsDown:
+		return "All IPs down"
+	case EventTypeAPIFailure:
+		return "DNS API failure"
+	case EventTypeFlapping:
+		return "Origin flapping"
+	case EventTypeCheckerFailed:
+		return "Health checker failed"
+	case EventTypeReloadFailed:
+		return "Config reload failed"
+	case EventTypePanic:
+		return "Service panicked"
+	case EventTypeOriginHeld:
+		return "Origin held, automatic DNS changes paused"
+	default:
+		return "DNS failover to backup IPs"
+	}
+}
+
+func formatOpsgenieIPList(ips []string, fallback string) string {
+	if len(ips) == 0 {
+		if fallback == "" {
+			return "-"
+		}
+		return fallback
+	}
+	return strings.Join(ips, ", ")
+}
@@ -0,0 +1,169 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpsgenieNotifier_Notify(t *testing.T) {
+	type request struct {
+		path  string
+		query string
+		auth  string
+		body  map[string]any
+	}
+	var requests []request
+	statusCode := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		requests = append(requests, request{r.URL.EscapedPath(), r.URL.RawQuery, r.Header.Get("Authorization"), body})
+		w.WriteHeader(statusCode)
+	}))
+	defer server.Close()
+
+	notifier := NewOpsgenieNotifier(server.URL+"/", "secret", "P2", []string{"dns"})
+	failover := FailoverEvent{
+		Type:         EventTypeFailover,
+		OriginName:   "www",
+		ZoneName:     "example.com",
+		RecordType:   "A",
+		OldIPs:       []string{"192.0.2.1"},
+		NewIPs:       []string{"198.51.100.1", "198.51.100.2"},
+		Reason:       "Health check failed",
+		Timestamp:    time.Now(),
+		IsFailoverIP: true,
+		OldPriority:  100,
+		NewPriority:  50,
+		MaxPriority:  100,
+	}
+	if err := notifier.Notify(context.Background(), failover); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("Expected one request, got %d", len(requests))
+	}
+	created := requests[0]
+	if created.path != "/v2/alerts" || created.auth != "GenieKey secret" {
+		t.Errorf("Unexpected request %s with %q", created.path, created.auth)
+	}
+	alias := "cloudflare-gslb:failover:www.example.com:A"
+	if created.body["alias"] != alias || created.body["priority"] != "P2" || created.body["entity"] != "www.example.com" {
+		t.Errorf("Unexpected alert %v", created.body)
+	}
+	if message, _ := created.body["message"].(string); message != "DNS failover to backup IPs: www.example.com (A)" {
+		t.Errorf("message = %q", message)
+	}
+	details, _ := created.body["details"].(map[string]any)
+	if details["new_ips"] != "198.51.100.1, 198.51.100.2" || details["new_priority"] != "50" {
+		t.Errorf("Unexpected details %v", details)
+	}
+	if tags, _ := created.body["tags"].([]any); len(tags) != 2 || tags[0] != "dns" || tags[1] != "failover" {
+		t.Errorf("Unexpected tags %v", created.body["tags"])
+	}
+
+	// Returning to the highest priority closes the alert by its alias
+	recovery := failover
+	recovery.IsFailoverIP, recovery.IsPriorityIP = false, true
+	recovery.Reason = "Priority IP is healthy again"
+	if err := notifier.Notify(context.Background(), recovery); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	closed := requests[1]
+	if closed.path != "/v2/alerts/cloudflare-gslb:failover:www.example.com:A/close" || closed.query != "identifierType=alias" {
+		t.Errorf("Unexpected close request %s?%s", closed.path, closed.query)
+	}
+	if closed.body["note"] != recovery.Reason || closed.body["source"] != "cloudflare-gslb" {
+		t.Errorf("Unexpected close body %v", closed.body)
+	}
+
+	// Alerts of other event types have an alias of their own
+	alert := FailoverEvent{Type: EventTypeVerificationFailed, OriginName: "www", ZoneName: "example.com", RecordType: "A", ObserveOnly: true}
+	if err := notifier.Notify(context.Background(), alert); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if body := requests[2].body; body["alias"] != "cloudflare-gslb:verification_failed:www.example.com:A" || !strings.HasSuffix(body["message"].(string), observeOnlySuffix) {
+		t.Errorf("Unexpected alert %v", body)
+	}
+
+	// Once the origin stops flapping, the flapping alert is closed
+	stopped := FailoverEvent{Type: EventTypeFlappingStopped, OriginName: "www", ZoneName: "example.com", RecordType: "A", Reason: "No transitions"}
+	if err := notifier.Notify(context.Background(), stopped); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if path := requests[3].path; path != "/v2/alerts/cloudflare-gslb:flapping:www.example.com:A/close" {
+		t.Errorf("Unexpected close request %s", path)
+	}
+
+	statusCode = http.StatusUnauthorized
+	if err := notifier.Notify(context.Background(), failover); err == nil {
+		t.Error("Expected an error for a rejected request")
+	}
+}
+
+func TestOpsgenieNotifier_TruncatesMessage(t *testing.T) {
+	var message string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body opsgenieAlert
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		message = body.Message
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	event := FailoverEvent{OriginName: strings.Repeat("ä", 200), ZoneName: "example.com", RecordType: "A", IsFailoverIP: true}
+	if err := NewOpsgenieNotifier(server.URL, "secret", "P3", nil).Notify(context.Background(), event); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if n := len([]rune(message)); n != opsgenieMaxMessage {
+		t.Errorf("message has %d characters, want %d", n, opsgenieMaxMessage)
+	}
+}
+
+func TestOpsgenieNotifier_ServiceEvent(t *testing.T) {
+	var alert opsgenieAlert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&alert)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	event := FailoverEvent{Type: EventTypeReloadFailed, Reason: "invalid config", Timestamp: time.Now()}
+	if err := NewOpsgenieNotifier(server.URL, "secret", "P3", nil).Notify(context.Background(), event); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if alert.Message != "Config reload failed: cloudflare-gslb" || alert.Entity != "cloudflare-gslb" {
+		t.Errorf("Unexpected alert %+v", alert)
+	}
+}
+
+func TestOpsgenieNotifier_LifecycleEvents(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	notifier := NewOpsgenieNotifier(server.URL, "secret", "P3", nil)
+	for _, eventType := range []EventType{EventTypeServiceStopped, EventTypeServiceStarted, EventTypeConfigReloaded} {
+		if err := notifier.Notify(context.Background(), FailoverEvent{Type: eventType, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("Notify(%s) error = %v", eventType, err)
+		}
+	}
+	want := []string{
+		"/v2/alerts/" + url.PathEscape("cloudflare-gslb:service_panicked:cloudflare-gslb:") + "/close",
+		"/v2/alerts/" + url.PathEscape("cloudflare-gslb:config_reload_failed:cloudflare-gslb:") + "/close",
+	}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Errorf("Expected no request for the shutdown and the panic and reload alerts to be closed, got %v", paths)
+	}
+}
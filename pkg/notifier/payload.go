@@ -0,0 +1,103 @@
+package notifier
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// eventPayload is the JSON of an event sent by the notifiers that pass events
+// on to other programs, such as the exec and MQTT notifiers
+type eventPayload struct {
+	Kind        string            `json:"kind"`
+	Severity    string            `json:"severity"`
+	Title       string            `json:"title"`
+	Message     string            `json:"message"`
+	Origin      string            `json:"origin,omitempty"`
+	Zone        string            `json:"zone,omitempty"`
+	RecordType  string            `json:"record_type,omitempty"`
+	OldIPs      []string          `json:"old_ips,omitempty"`
+	NewIPs      []string          `json:"new_ips,omitempty"`
+	OldPriority int               `json:"old_priority,omitempty"`
+	NewPriority int               `json:"new_priority,omitempty"`
+	MaxPriority int               `json:"max_priority,omitempty"`
+	Reason      string            `json:"reason,omitempty"`
+	ObserveOnly bool              `json:"observe_only,omitempty"`
+	Repeat      int               `json:"repeat,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Events      []eventPayload    `json:"events,omitempty"`
+}
+
+// summaryPayload is the JSON of a periodic summary sent by the same notifiers
+type summaryPayload struct {
+	Kind          string         `json:"kind"`
+	Title         string         `json:"title"`
+	Message       string         `json:"message"`
+	Since         time.Time      `json:"since"`
+	Until         time.Time      `json:"until"`
+	UptimeSeconds int64          `json:"uptime_seconds"`
+	Origins       int            `json:"origins"`
+	Monitored     int            `json:"monitored"`
+	Failovers     map[string]int `json:"failovers,omitempty"`
+	Degraded      []string       `json:"degraded,omitempty"`
+}
+
+// newEventPayload returns the JSON payload of event with its title and message
+func newEventPayload(event FailoverEvent, title, message string) eventPayload {
+	payload := eventPayload{
+		Kind:        EventKind(event),
+		Severity:    SeverityFor(event).String(),
+		Title:       title,
+		Message:     message,
+		Origin:      event.OriginName,
+		Zone:        event.ZoneName,
+		RecordType:  event.RecordType,
+		OldIPs:      eventIPs(event.OldIPs, event.OldIP),
+		NewIPs:      eventIPs(event.NewIPs, event.NewIP),
+		OldPriority: event.OldPriority,
+		NewPriority: event.NewPriority,
+		MaxPriority: event.MaxPriority,
+		Reason:      event.Reason,
+		ObserveOnly: event.ObserveOnly,
+		Repeat:      event.Repeat,
+		Labels:      event.Labels,
+		Timestamp:   event.Timestamp,
+	}
+	if event.Type == EventTypeDigest {
+		payload.Kind = string(EventTypeDigest)
+	}
+	for _, combined := range event.Events {
+		payload.Events = append(payload.Events, newEventPayload(combined, eventDescription(combined), combined.Reason))
+	}
+	return payload
+}
+
+// EventJSON returns the JSON of event as sent by the exec and MQTT notifiers,
+// for other consumers of the events such as the event stream of the status API
+func EventJSON(event FailoverEvent) ([]byte, error) {
+	return json.Marshal(newEventPayload(event, pushTitle(event, Message{}), pushMessage(event, Message{})))
+}
+
+// eventIPs returns ips, or the single ip of events that only set one
+func eventIPs(ips []string, ip string) []string {
+	if len(ips) == 0 && ip != "" {
+		return []string{ip}
+	}
+	return ips
+}
+
+// newSummaryPayload returns the JSON payload of summary
+func newSummaryPayload(summary Summary) summaryPayload {
+	return summaryPayload{
+		Kind:          "summary",
+		Title:         summaryTitle(summary),
+		Message:       pushSummaryMessage(summary),
+		Since:         summary.Since,
+		Until:         summary.Until,
+		UptimeSeconds: int64(summary.Uptime.Seconds()),
+		Origins:       summary.Origins,
+		Monitored:     summary.Monitored,
+		Failovers:     summary.Failovers,
+		Degraded:      summary.Degraded,
+	}
+}
@@ -0,0 +1,30 @@
+package notifier
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewEventPayload_Digest(t *testing.T) {
+	failover := FailoverEvent{OriginName: "www", ZoneName: "example.com", RecordType: "A", IsFailoverIP: true}
+	digest := FailoverEvent{Type: EventTypeDigest, Events: []FailoverEvent{failover, failover}}
+
+	payload := newEventPayload(digest, "title", "message")
+	if payload.Kind != "digest" || len(payload.Events) != 2 || payload.Events[0].Kind != KindFailover || payload.Events[0].Origin != "www" {
+		t.Errorf("Unexpected digest payload %+v", payload)
+	}
+}
+
+func TestEventJSON(t *testing.T) {
+	data, err := EventJSON(FailoverEvent{OriginName: "www", ZoneName: "example.com", RecordType: "A", IsFailoverIP: true, OldIP: "192.0.2.1", NewIP: "198.51.100.1"})
+	if err != nil {
+		t.Fatalf("EventJSON() error = %v", err)
+	}
+	var payload eventPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Kind != KindFailover || payload.Title == "" || len(payload.NewIPs) != 1 || payload.NewIPs[0] != "198.51.100.1" {
+		t.Errorf("Unexpected event JSON %s", data)
+	}
+}
@@ -0,0 +1,110 @@
+package notifier
+
+import (
+	"strings"
+	"time"
+)
+
+// pushLevel is how urgently a push notification should reach a phone. Each
+// push service maps it to its own priorities.
+type pushLevel int
+
+const (
+	// pushLow is for observe mode events, lifecycle events and summaries
+	pushLow pushLevel = iota
+	// pushDefault is for failovers back to the priority IPs
+	pushDefault
+	// pushHigh is for failovers to backup IPs
+	pushHigh
+	// pushEmergency is for alerts about the failover itself, such as frozen or
+	// refused DNS changes
+	pushEmergency
+)
+
+// pushLevelFor returns the level of event
+func pushLevelFor(event FailoverEvent) pushLevel {
+	switch {
+	case event.Type == EventTypeDigest:
+		return digestPushLevels[event.Severity]
+	case event.ObserveOnly, event.Type.IsLifecycle():
+		return pushLow
+	case event.Type == EventTypeFlapping, event.Type == EventTypeReloadFailed:
+		// Flapping replaces the notifications of the failovers, and the
+		// running configuration keeps working after a failed reload
+		return pushHigh
+	case event.Type.IsAlert():
+		return pushEmergency
+	case event.IsFailoverIP:
+		return pushHigh
+	default:
+		return pushDefault
+	}
+}
+
+// pushTitle returns the title of a push notification about event, or the
+// custom title
+func pushTitle(event FailoverEvent, custom Message) string {
+	if custom.Title != "" {
+		return custom.Title
+	}
+	if event.Type == EventTypeDigest {
+		return digestTitle(event)
+	}
+	title := "DNS Failover Event - " + eventName(event)
+	if event.ObserveOnly {
+		title += observeOnlySuffix
+	}
+	title += repeatSuffix(event)
+	return title
+}
+
+// pushMessage returns the plain text body of a push notification about event,
+// with the custom body and fields in place of the default lines
+func pushMessage(event FailoverEvent, custom Message) string {
+	if event.Type == EventTypeDigest {
+		return event.Reason + "\n" + formatDigestEvents(event)
+	}
+	if custom.Body != "" || len(custom.Fields) > 0 {
+		var lines []string
+		if custom.Body != "" {
+			lines = append(lines, custom.Body)
+		}
+		for _, field := range custom.Fields {
+			lines = append(lines, field.Name+": "+field.Value)
+		}
+		return strings.Join(lines, "\n")
+	}
+	lines := []string{
+		eventDescription(event) + " (" + event.Severity.String() + ")",
+		"Origin: " + eventOrigin(event),
+		"Old IPs: " + formatPushIPList(event.OldIPs, event.OldIP),
+		"New IPs: " + formatPushIPList(event.NewIPs, event.NewIP),
+	}
+	if event.Reason != "" {
+		lines = append(lines, "Reason: "+event.Reason)
+	}
+	if len(event.Checks) > 0 {
+		lines = append(lines, "Checks:\n"+formatChecks(event))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pushSummaryMessage returns the plain text body of a summary
+func pushSummaryMessage(summary Summary) string {
+	return strings.Join([]string{
+		summaryPeriod(summary),
+		"Failovers: " + summaryFailovers(summary),
+		"Degraded origins: " + summaryDegraded(summary),
+		"Uptime: " + summary.Uptime.Round(time.Minute).String(),
+	}, "\n")
+}
+
+func formatPushIPList(ips []string, fallback string) string {
+	if len(ips) == 0 {
+		if fallback == "" {
+			return "-"
+		}
+		return fallback
+	}
+	return strings.Join(ips, ", ")
+}
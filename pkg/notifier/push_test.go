@@ -0,0 +1,139 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPushLevelFor(t *testing.T) {
+	tests := []struct {
+		name  string
+		event FailoverEvent
+		want  pushLevel
+	}{
+		{"failover to backup", FailoverEvent{IsFailoverIP: true}, pushHigh},
+		{"recovery", FailoverEvent{IsPriorityIP: true, ReturnToPriority: true}, pushDefault},
+		{"alert", FailoverEvent{Type: EventTypeChangeLimitExceeded, IsFailoverIP: true}, pushEmergency},
+		{"observe only", FailoverEvent{Type: EventTypeVerificationFailed, ObserveOnly: true}, pushLow},
+		{"flapping", FailoverEvent{Type: EventTypeFlapping}, pushHigh},
+	}
+	for _, tt := range tests {
+		if got := pushLevelFor(tt.event); got != tt.want {
+			t.Errorf("%s: pushLevelFor() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPushTitle_Repeat(t *testing.T) {
+	event := FailoverEvent{Type: EventTypeAllIPsDown, OriginName: "www", ZoneName: "example.com"}
+	if title := pushTitle(event, Message{}); strings.Contains(title, "repeat") {
+		t.Errorf("Expected no repeat in the first alert, got %q", title)
+	}
+	event.Repeat = 2
+	if title := pushTitle(event, Message{}); !strings.HasSuffix(title, " (repeat 2)") {
+		t.Errorf("Expected the repeat in an escalated alert, got %q", title)
+	}
+}
+
+func testPushEvent() FailoverEvent {
+	return FailoverEvent{
+		Type:         EventTypeFailover,
+		OriginName:   "www",
+		ZoneName:     "example.com",
+		RecordType:   "A",
+		OldIPs:       []string{"192.0.2.1"},
+		NewIPs:       []string{"198.51.100.1"},
+		Reason:       "Health check failed",
+		Timestamp:    time.Unix(1714564800, 0),
+		IsFailoverIP: true,
+	}
+}
+
+func TestNtfyNotifier_Notify(t *testing.T) {
+	var auth string
+	var message ntfyMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if r.URL.Path != "/" {
+			t.Errorf("Expected a JSON publish to /, got %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	if err := NewNtfyNotifier(server.URL+"/", "gslb", "tk_secret").Notify(context.Background(), testPushEvent()); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if auth != "Bearer tk_secret" || message.Topic != "gslb" || message.Priority != 4 || message.Tags[0] != "x" {
+		t.Errorf("Unexpected message %+v with %q", message, auth)
+	}
+	if message.Title != "DNS Failover Event - www.example.com" || !strings.Contains(message.Message, "New IPs: 198.51.100.1") {
+		t.Errorf("Unexpected message %+v", message)
+	}
+
+	alert := testPushEvent()
+	alert.Type = EventTypeVerificationFailed
+	if err := NewNtfyNotifier(server.URL, "gslb", "").Notify(context.Background(), alert); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if auth != "" || message.Priority != 5 || message.Tags[0] != "rotating_light" {
+		t.Errorf("Unexpected alert %+v with %q", message, auth)
+	}
+
+	if err := NewNtfyNotifier(server.URL, "gslb", "").NotifySummary(context.Background(), testSummary()); err != nil {
+		t.Fatalf("NotifySummary() error = %v", err)
+	}
+	if message.Priority != 2 || !strings.Contains(message.Message, "Failovers: 4 in total") {
+		t.Errorf("Unexpected summary %+v", message)
+	}
+}
+
+func TestPushoverNotifier_Notify(t *testing.T) {
+	var form url.Values
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/1/messages.json" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Failed to parse form: %v", err)
+		}
+		form = r.PostForm
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			_, _ = w.Write([]byte(`{"status":0,"errors":["user identifier is invalid"]}`))
+		}
+	}))
+	defer server.Close()
+
+	notifier := NewPushoverNotifier(server.URL, "app-token", "user-key")
+	if err := notifier.Notify(context.Background(), testPushEvent()); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if form.Get("token") != "app-token" || form.Get("user") != "user-key" || form.Get("priority") != "1" || form.Get("retry") != "" || form.Get("timestamp") != "1714564800" {
+		t.Errorf("Unexpected form %v", form)
+	}
+
+	// Emergency notifications must say how often and how long to repeat
+	alert := testPushEvent()
+	alert.Type = EventTypeMinHealthyViolated
+	if err := notifier.Notify(context.Background(), alert); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if form.Get("priority") != "2" || form.Get("retry") != "60" || form.Get("expire") != "3600" {
+		t.Errorf("Unexpected emergency form %v", form)
+	}
+
+	status = http.StatusBadRequest
+	if err := notifier.Notify(context.Background(), testPushEvent()); err == nil || !strings.Contains(err.Error(), "user identifier is invalid") {
+		t.Errorf("Expected the API error, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pushoverPriorities maps push levels to Pushover priorities (-2 = lowest to
+// 2 = emergency)
+var pushoverPriorities = map[pushLevel]int{
+	pushLow:       -1,
+	pushDefault:   0,
+	pushHigh:      1,
+	pushEmergency: 2,
+}
+
+// Emergency notifications are repeated until acknowledged, every
+// pushoverRetry for at most pushoverExpire
+const (
+	pushoverRetry  = time.Minute
+	pushoverExpire = time.Hour
+)
+
+// PushoverNotifier implements the Notifier interface for Pushover
+type PushoverNotifier struct {
+	templated
+	apiURL   string
+	appToken string
+	userKey  string
+	httpSender
+}
+
+// NewPushoverNotifier creates a new Pushover notifier sending from the
+// application to the user or group key
+func NewPushoverNotifier(apiURL, appToken, userKey string) *PushoverNotifier {
+	return &PushoverNotifier{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		appToken:   appToken,
+		userKey:    userKey,
+		httpSender: newHTTPSender(),
+	}
+}
+
+// pushoverResponse is the body of a Pushover API response
+type pushoverResponse struct {
+	Status int      `json:"status"`
+	Errors []string `json:"errors"`
+}
+
+// Notify sends a notification to Pushover
+func (p *PushoverNotifier) Notify(ctx context.Context, event FailoverEvent) error {
+	custom := p.message(event)
+	return p.send(ctx, pushTitle(event, custom), pushMessage(event, custom), pushLevelFor(event), event.Timestamp)
+}
+
+// NotifySummary sends a periodic summary to Pushover
+func (p *PushoverNotifier) NotifySummary(ctx context.Context, summary Summary) error {
+	return p.send(ctx, summaryTitle(summary), pushSummaryMessage(summary), pushLow, summary.Until)
+}
+
+func (p *PushoverNotifier) send(ctx context.Context, title, message string, level pushLevel, timestamp time.Time) error {
+	priority := pushoverPriorities[level]
+	form := url.Values{
+		"token":    {p.appToken},
+		"user":     {p.userKey},
+		"title":    {title},
+		"message":  {message},
+		"priority": {strconv.Itoa(priority)},
+	}
+	if priority == pushoverPriorities[pushEmergency] {
+		form.Set("retry", strconv.Itoa(int(pushoverRetry.Seconds())))
+		form.Set("expire", strconv.Itoa(int(pushoverExpire.Seconds())))
+	}
+	if !timestamp.IsZero() {
+		form.Set("timestamp", strconv.FormatInt(timestamp.Unix(), 10))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.apiURL+"/1/messages.json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create Pushover request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Pushover notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var response pushoverResponse
+		if json.NewDecoder(resp.Body).Decode(&response) == nil && len(response.Errors) > 0 {
+			return fmt.Errorf("pushover API returned status %d: %s", resp.StatusCode, strings.Join(response.Errors, ", "))
+		}
+		return fmt.Errorf("pushover API returned status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package notifier
+
+import (
+	"path"
+	"slices"
+)
+
+// Kinds of events a route can select. Failover events are split into
+// failovers and recoveries; the other kinds are the alert event types,
+// flapping_stopped, the lifecycle event types and origin_held and
+// origin_released.
+const (
+	KindFailover = "failover"
+	KindRecovery = "recovery"
+)
+
+// EventKind returns the kind of event: "recovery" when the origin is back on
+// its highest priority IPs, "failover" for other changes and the event type
+// for alerts
+func EventKind(event FailoverEvent) string {
+	switch {
+	case event.Type.IsAlert(), event.Type == EventTypeFlappingStopped, event.Type.IsLifecycle(),
+		event.Type == EventTypeOriginHeld, event.Type == EventTypeOriginReleased:
+		return string(event.Type)
+	case event.IsPriorityIP:
+		return KindRecovery
+	default:
+		return KindFailover
+	}
+}
+
+// Route selects the events sent to a notifier. Empty fields match every event.
+type Route struct {
+	Zones []string
+	// Origins are path.Match patterns of origin names, e.g. "*.example.com"
+	Origins []string
+	// Kinds are event kinds as returned by EventKind
+	Kinds       []string
+	MinSeverity Severity
+}
+
+// Matches reports whether event should be sent through the route
+func (r Route) Matches(event FailoverEvent) bool {
+	if len(r.Zones) > 0 && !slices.Contains(r.Zones, event.ZoneName) {
+		return false
+	}
+	if len(r.Origins) > 0 && !slices.ContainsFunc(r.Origins, func(pattern string) bool {
+		matched, _ := path.Match(pattern, event.OriginName)
+		return matched
+	}) {
+		return false
+	}
+	if len(r.Kinds) > 0 && !slices.Contains(r.Kinds, EventKind(event)) {
+		return false
+	}
+	return SeverityFor(event) >= r.MinSeverity
+}
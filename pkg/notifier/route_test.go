@@ -0,0 +1,42 @@
+package notifier
+
+import "testing"
+
+func TestRoute_Matches(t *testing.T) {
+	failover := FailoverEvent{Type: EventTypeFailover, OriginName: "api.example.com", ZoneName: "example.com", IsFailoverIP: true}
+	recovery := FailoverEvent{Type: EventTypeFailover, OriginName: "api.example.com", ZoneName: "example.com", IsPriorityIP: true, ReturnToPriority: true}
+	alert := FailoverEvent{Type: EventTypeVerificationFailed, OriginName: "www.example.org", ZoneName: "example.org"}
+	observed := failover
+	observed.ObserveOnly = true
+	flapping := FailoverEvent{Type: EventTypeFlapping, OriginName: "api.example.com", ZoneName: "example.com"}
+	stopped := FailoverEvent{Type: EventTypeFlappingStopped, OriginName: "api.example.com", ZoneName: "example.com", IsPriorityIP: true}
+
+	tests := []struct {
+		name  string
+		route Route
+		event FailoverEvent
+		want  bool
+	}{
+		{"empty route", Route{}, failover, true},
+		{"zone", Route{Zones: []string{"example.org"}}, failover, false},
+		{"origin pattern", Route{Origins: []string{"api.*"}}, failover, true},
+		{"other origin", Route{Origins: []string{"www.*", "db.example.com"}}, failover, false},
+		{"failovers only", Route{Kinds: []string{KindFailover}}, recovery, false},
+		{"recoveries", Route{Kinds: []string{KindRecovery}}, recovery, true},
+		{"alert kind", Route{Kinds: []string{string(EventTypeVerificationFailed)}}, alert, true},
+		{"warning and above", Route{MinSeverity: SeverityWarning}, failover, true},
+		{"recovery is info", Route{MinSeverity: SeverityWarning}, recovery, false},
+		{"observe mode is info", Route{MinSeverity: SeverityWarning}, observed, false},
+		{"critical only", Route{MinSeverity: SeverityCritical}, alert, true},
+		{"flapping is a warning", Route{MinSeverity: SeverityCritical}, flapping, false},
+		{"flapping kind", Route{Kinds: []string{string(EventTypeFlapping)}}, flapping, true},
+		{"flapping stopped is not a recovery", Route{Kinds: []string{KindRecovery}}, stopped, false},
+		{"flapping stopped kind", Route{Kinds: []string{string(EventTypeFlappingStopped)}}, stopped, true},
+		{"all conditions", Route{Zones: []string{"example.com"}, Origins: []string{"api.*"}, Kinds: []string{KindFailover}, MinSeverity: SeverityWarning}, failover, true},
+	}
+	for _, tt := range tests {
+		if got := tt.route.Matches(tt.event); got != tt.want {
+			t.Errorf("%s: Matches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
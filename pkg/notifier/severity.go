@@ -0,0 +1,64 @@
+package notifier
+
+// Severity is how serious an event is
+type Severity int
+
+// Severities in increasing order
+const (
+	// SeverityInfo is for recoveries and everything in observe mode
+	SeverityInfo Severity = iota
+	// SeverityWarning is for failovers to lower priority IPs
+	SeverityWarning
+	// SeverityCritical is for alerts, such as all IPs down or DNS API failures
+	SeverityCritical
+)
+
+// String returns the name of the severity
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "info"
+	}
+}
+
+// ParseSeverity returns the severity named "info", "warning" or "critical"
+func ParseSeverity(name string) (Severity, bool) {
+	switch name {
+	case "info":
+		return SeverityInfo, true
+	case "warning":
+		return SeverityWarning, true
+	case "critical":
+		return SeverityCritical, true
+	default:
+		return SeverityInfo, false
+	}
+}
+
+// SeverityFor returns the severity of event. Events in observe mode and
+// recoveries are informational, failovers are warnings and alerts critical.
+func SeverityFor(event FailoverEvent) Severity {
+	switch {
+	case event.Type == EventTypeDigest:
+		return digestSeverity(event)
+	case event.ObserveOnly, event.Type == EventTypeFlappingStopped, event.Type == EventTypeOriginReleased, event.Type.IsLifecycle():
+		return SeverityInfo
+	case event.Type == EventTypeFlapping:
+		// Flapping replaces the notifications of the failovers, so it is as
+		// serious as one
+		return SeverityWarning
+	case event.Type == EventTypeReloadFailed:
+		// The running configuration keeps working
+		return SeverityWarning
+	case event.Type.IsAlert():
+		return SeverityCritical
+	case event.IsPriorityIP:
+		return SeverityInfo
+	default:
+		return SeverityWarning
+	}
+}
@@ -7,98 +7,368 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
-// SlackNotifier implements the Notifier interface for Slack webhooks
+// slackHeaderLimit is the most characters Slack allows in a header block
+const slackHeaderLimit = 150
+
+// SlackNotifier implements the Notifier interface for Slack. It posts
+// Block Kit messages to an incoming webhook, or with a bot token through the
+// Web API, which also threads the events of an incident under its first
+// message.
 type SlackNotifier struct {
+	templated
 	webhookURL string
-	httpClient *http.Client
+	apiURL     string
+	botToken   string
+	channel    string
+	actions    bool
+	httpSender
+
+	// mu serializes Web API posts, so that the events of an origin find the
+	// thread opened by the one before them
+	mu sync.Mutex
+	// threads holds the ts of the message an open incident is threaded
+	// under, by origin
+	threads map[string]string
 }
 
-// NewSlackNotifier creates a new Slack notifier
+// NewSlackNotifier creates a new Slack notifier posting to an incoming webhook
 func NewSlackNotifier(webhookURL string) *SlackNotifier {
 	return &SlackNotifier{
 		webhookURL: webhookURL,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		httpSender: newHTTPSender(),
 	}
 }
 
-// slackMessage represents the message structure for Slack webhook
+// NewSlackAPINotifier creates a new Slack notifier posting to channel as the
+// bot of botToken through the Web API at apiURL. Events of an origin are
+// threaded under its first message until it is back on its priority IPs.
+func NewSlackAPINotifier(apiURL, botToken, channel string) *SlackNotifier {
+	return &SlackNotifier{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		botToken:   botToken,
+		channel:    channel,
+		threads:    make(map[string]string),
+		httpSender: newHTTPSender(),
+	}
+}
+
+// slackMessage is the body of a webhook or chat.postMessage request. Text is
+// shown in notifications and by clients that cannot render the blocks.
 type slackMessage struct {
-	Text        string            `json:"text"`
-	Attachments []slackAttachment `json:"attachments,omitempty"`
+	Channel        string       `json:"channel,omitempty"`
+	Text           string       `json:"text"`
+	Blocks         []slackBlock `json:"blocks,omitempty"`
+	ThreadTS       string       `json:"thread_ts,omitempty"`
+	ReplyBroadcast bool         `json:"reply_broadcast,omitempty"`
+}
+
+// slackBlock is a Block Kit header, section, context or actions block.
+// Elements are texts in a context block and buttons in an actions block.
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []any       `json:"elements,omitempty"`
 }
 
-type slackAttachment struct {
-	Color  string       `json:"color,omitempty"`
-	Fields []slackField `json:"fields,omitempty"`
-	Footer string       `json:"footer,omitempty"`
-	Ts     int64        `json:"ts,omitempty"`
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
 }
 
+// slackField is a titled value of a message. Short fields are laid out
+// side by side.
 type slackField struct {
-	Title string `json:"title"`
-	Value string `json:"value"`
-	Short bool   `json:"short"`
+	Title string
+	Value string
+	Short bool
+}
+
+// slackResponse is the body of a Web API response
+type slackResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+	TS    string `json:"ts"`
 }
 
 // Notify sends a notification to Slack
 func (s *SlackNotifier) Notify(ctx context.Context, event FailoverEvent) error {
-	color := "warning"
-	if event.ReturnToPriority && event.IsPriorityIP {
-		color = "good"
-	} else if event.IsFailoverIP {
-		color = "danger"
+	if event.Type == EventTypeDigest {
+		return s.notifyDigest(ctx, event)
+	}
+
+	status := slackWarning
+	if (event.ReturnToPriority && event.IsPriorityIP) || event.Type == EventTypeFlappingStopped || event.Type == EventTypeOriginReleased || event.Type.IsLifecycle() {
+		status = slackGood
+	} else if event.IsFailoverIP || event.Type.IsAlert() {
+		status = slackDanger
+	}
+
+	custom := s.message(event)
+	title := "DNS Failover Event - " + eventName(event)
+	if event.ObserveOnly {
+		title += observeOnlySuffix
+	}
+	title += repeatSuffix(event)
+	if custom.Title != "" {
+		title = custom.Title
+	}
+
+	fields := []slackField{
+		{Title: "Origin", Value: eventOrigin(event), Short: true},
+		{Title: "Old IPs", Value: formatIPList(event.OldIPs, event.OldIP), Short: true},
+		{Title: "New IPs", Value: formatIPList(event.NewIPs, event.NewIP), Short: true},
+		{Title: "Event Type", Value: s.getEventType(event), Short: true},
+		{Title: "Severity", Value: event.Severity.String(), Short: true},
+		{Title: "Reason", Value: event.Reason, Short: false},
+	}
+	if len(event.Checks) > 0 {
+		fields = append(fields, slackField{Title: "Checks", Value: formatChecks(event), Short: false})
+	}
+	if len(event.Candidates) > 0 {
+		fields = append(fields, slackField{Title: "Candidates", Value: formatCandidates(event), Short: false})
+	}
+	if len(custom.Fields) > 0 {
+		fields = fields[:0]
+		for _, field := range custom.Fields {
+			fields = append(fields, slackField{Title: field.Name, Value: field.Value, Short: field.Inline})
+		}
 	}
 
+	blocks := slackBlocks(status, title, custom.Body, fields, event.Timestamp)
+	if s.actions && event.OriginName != "" {
+		// The buttons go above the footer
+		footer := blocks[len(blocks)-1]
+		blocks = append(blocks[:len(blocks)-1], slackActionsBlock(event), footer)
+	}
 	message := slackMessage{
-		Text: fmt.Sprintf("*DNS Failover Event* - %s.%s", event.OriginName, event.ZoneName),
-		Attachments: []slackAttachment{
-			{
-				Color: color,
-				Fields: []slackField{
-					{Title: "Origin", Value: fmt.Sprintf("%s.%s (%s)", event.OriginName, event.ZoneName, event.RecordType), Short: true},
-					{Title: "Old IPs", Value: formatIPList(event.OldIPs, event.OldIP), Short: true},
-					{Title: "New IPs", Value: formatIPList(event.NewIPs, event.NewIP), Short: true},
-					{Title: "Event Type", Value: s.getEventType(event), Short: true},
-					{Title: "Reason", Value: event.Reason, Short: false},
-				},
-				Footer: "Cloudflare GSLB",
-				Ts:     event.Timestamp.Unix(),
-			},
-		},
+		Text:   title,
+		Blocks: blocks,
+	}
+	if s.botToken == "" || event.OriginName == "" {
+		_, err := s.send(ctx, message)
+		return err
+	}
+	return s.notifyThreaded(ctx, slackThreadKey(event), slackResolves(event), message)
+}
+
+// notifyThreaded posts message in the thread of the open incident of key,
+// or opens one with it. A message that resolves the incident is also shown
+// in the channel and closes the thread, so that the next event starts a new one.
+func (s *SlackNotifier) notifyThreaded(ctx context.Context, key string, resolves bool, message slackMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	thread := s.threads[key]
+	message.ThreadTS = thread
+	message.ReplyBroadcast = thread != "" && resolves
+	ts, err := s.send(ctx, message)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resolves:
+		delete(s.threads, key)
+	case thread == "" && ts != "":
+		s.threads[key] = ts
+	}
+	return nil
+}
+
+// slackThreadKey identifies the incidents of the origin of event
+func slackThreadKey(event FailoverEvent) string {
+	return eventName(event) + "/" + event.RecordType
+}
+
+// slackResolves reports whether event ends the incident of its origin,
+// which is when it is back on its highest priority IPs
+func slackResolves(event FailoverEvent) bool {
+	return (event.Type == "" || event.Type == EventTypeFailover) && event.IsPriorityIP
+}
+
+// notifyDigest sends the events combined by a digest as one message
+func (s *SlackNotifier) notifyDigest(ctx context.Context, event FailoverEvent) error {
+	status := slackGood
+	switch event.Severity {
+	case SeverityCritical:
+		status = slackDanger
+	case SeverityWarning:
+		status = slackWarning
+	}
+	title := digestTitle(event)
+	_, err := s.send(ctx, slackMessage{
+		Text: title,
+		Blocks: slackBlocks(status, title, event.Reason, []slackField{
+			{Title: "Severity", Value: event.Severity.String(), Short: true},
+			{Title: "Events", Value: formatDigestEvents(event), Short: false},
+		}, event.Timestamp),
+	})
+	return err
+}
+
+// NotifySummary sends a periodic summary to Slack
+func (s *SlackNotifier) NotifySummary(ctx context.Context, summary Summary) error {
+	status := slackGood
+	if !summary.Healthy() {
+		status = slackWarning
+	}
+	title := summaryTitle(summary)
+	_, err := s.send(ctx, slackMessage{
+		Text: title,
+		Blocks: slackBlocks(status, title, "", []slackField{
+			{Title: "Period", Value: summaryPeriod(summary), Short: false},
+			{Title: "Failovers", Value: summaryFailovers(summary), Short: true},
+			{Title: "Degraded Origins", Value: summaryDegraded(summary), Short: true},
+			{Title: "Uptime", Value: summary.Uptime.Round(time.Minute).String(), Short: true},
+		}, summary.Until),
+	})
+	return err
+}
+
+// send posts message and returns its ts, which only the Web API reports
+func (s *SlackNotifier) send(ctx context.Context, message slackMessage) (string, error) {
+	url := s.webhookURL
+	if s.botToken != "" {
+		url = s.apiURL + "/chat.postMessage"
+		message.Channel = s.channel
 	}
 
 	payload, err := json.Marshal(message)
 	if err != nil {
-		return fmt.Errorf("failed to marshal Slack message: %w", err)
+		return "", fmt.Errorf("failed to marshal Slack message: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", s.webhookURL, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
 	if err != nil {
-		return fmt.Errorf("failed to create Slack request: %w", err)
+		return "", fmt.Errorf("failed to create Slack request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if s.botToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.botToken)
+	}
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send Slack notification: %w", err)
+		return "", fmt.Errorf("failed to send Slack notification: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("slack webhook returned status: %d", resp.StatusCode)
+		return "", fmt.Errorf("slack webhook returned status: %d", resp.StatusCode)
+	}
+	if s.botToken == "" {
+		return "", nil
 	}
 
-	return nil
+	// The Web API reports errors in the body of a 200 response
+	var result slackResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode Slack response: %w", err)
+	}
+	if !result.OK {
+		return "", fmt.Errorf("slack API returned error: %s", result.Error)
+	}
+	return result.TS, nil
+}
+
+// Emoji in front of the header, in place of the color bar of attachments
+const (
+	slackGood    = "🟢"
+	slackWarning = "🟡"
+	slackDanger  = "🔴"
+)
+
+// slackBlocks lays out a message as a header, an optional body, sections
+// for the fields and a footer with the time
+func slackBlocks(status, title, body string, fields []slackField, at time.Time) []slackBlock {
+	header := status + " " + title
+	if runes := []rune(header); len(runes) > slackHeaderLimit {
+		header = string(runes[:slackHeaderLimit-1]) + "…"
+	}
+	blocks := []slackBlock{{Type: "header", Text: &slackText{Type: "plain_text", Text: header}}}
+	if body != "" {
+		blocks = append(blocks, slackBlock{Type: "section", Text: slackMarkdown(body)})
+	}
+
+	// Consecutive short fields share a section, up to the 10 fields Slack
+	// allows in one
+	var short []slackText
+	flush := func() {
+		if len(short) > 0 {
+			blocks = append(blocks, slackBlock{Type: "section", Fields: short})
+			short = nil
+		}
+	}
+	for _, field := range fields {
+		text := slackMarkdown("*" + field.Title + "*\n" + slackValue(field.Value))
+		if !field.Short {
+			flush()
+			blocks = append(blocks, slackBlock{Type: "section", Text: text})
+			continue
+		}
+		short = append(short, *text)
+		if len(short) == 10 {
+			flush()
+		}
+	}
+	flush()
+
+	return append(blocks, slackBlock{Type: "context", Elements: []any{
+		*slackMarkdown(fmt.Sprintf("Cloudflare GSLB | <!date^%d^{date_short_pretty} {time_secs}|%s>", at.Unix(), at.UTC().Format(time.RFC3339))),
+	}})
+}
+
+func slackMarkdown(text string) *slackText {
+	return &slackText{Type: "mrkdwn", Text: text}
+}
+
+// slackValue keeps a field from being empty, which Slack rejects
+func slackValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
 }
 
 func (s *SlackNotifier) getEventType(event FailoverEvent) string {
 	switch {
+	case event.Type == EventTypeChangeLimitExceeded:
+		return "Change Limit Exceeded (DNS Changes Frozen)"
+	case event.Type == EventTypeVerificationFailed:
+		return "DNS Verification Failed"
+	case event.Type == EventTypeMinHealthyViolated:
+		return "Minimum Healthy Not Met (Serving Degraded IPs)"
+	case event.Type == EventTypeAllowlistViolation:
+		return "IP Outside Allowlist (DNS Change Refused)"
+	case event.Type == EventTypeAllIPsDown:
+		return "All IPs Down (Records Unchanged)"
+	case event.Type == EventTypeAPIFailure:
+		return "DNS API Failure"
+	case event.Type == EventTypeFlapping:
+		return "Origin Flapping (Individual Changes Not Notified)"
+	case event.Type == EventTypeFlappingStopped:
+		return "Flapping Stopped"
+	case event.Type == EventTypeCheckerFailed:
+		return "Health Checker Failed (Origin Not Monitored)"
+	case event.Type == EventTypeReloadFailed:
+		return "Config Reload Failed (Current Config Kept)"
+	case event.Type == EventTypeServiceStarted:
+		return "Service Started"
+	case event.Type == EventTypeServiceStopped:
+		return "Service Stopped"
+	case event.Type == EventTypeConfigReloaded:
+		return "Config Reloaded"
+	case event.Type == EventTypeOriginHeld:
+		return "Origin Held (Automatic Changes Paused)"
+	case event.Type == EventTypeOriginReleased:
+		return "Origin Released (Automatic Changes Resumed)"
+	case event.Type == EventTypePanic:
+		return "Service Panicked (Exiting)"
 	case event.ReturnToPriority && event.IsPriorityIP:
 		return "Recovery (Return to Priority IP)"
 	case event.IsPriorityIP:
@@ -0,0 +1,71 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Action IDs of the buttons on Slack messages about an origin
+const (
+	SlackActionHold    = "gslb_hold"
+	SlackActionRelease = "gslb_release"
+)
+
+// SlackActionOrigin is the value of a button, naming the origin of the message
+type SlackActionOrigin struct {
+	Zone       string `json:"zone"`
+	Name       string `json:"name"`
+	RecordType string `json:"record_type"`
+}
+
+// EnableActions adds buttons to hold and release the origin to the messages
+// about an origin. Slack sends the clicks to the request URL of the app.
+func (s *SlackNotifier) EnableActions() {
+	s.actions = true
+}
+
+type slackButton struct {
+	Type     string        `json:"type"`
+	Text     slackText     `json:"text"`
+	ActionID string        `json:"action_id"`
+	Value    string        `json:"value"`
+	Style    string        `json:"style,omitempty"`
+	Confirm  *slackConfirm `json:"confirm,omitempty"`
+}
+
+// slackConfirm is the dialog shown before a button takes effect
+type slackConfirm struct {
+	Title   slackText `json:"title"`
+	Text    slackText `json:"text"`
+	Confirm slackText `json:"confirm"`
+	Deny    slackText `json:"deny"`
+}
+
+// slackActionsBlock returns the buttons to hold and release the origin of event
+func slackActionsBlock(event FailoverEvent) slackBlock {
+	value, _ := json.Marshal(SlackActionOrigin{Zone: event.ZoneName, Name: event.OriginName, RecordType: event.RecordType})
+	plain := func(text string) slackText {
+		return slackText{Type: "plain_text", Text: text}
+	}
+	return slackBlock{Type: "actions", Elements: []any{
+		slackButton{
+			Type:     "button",
+			Text:     plain("Acknowledge & hold"),
+			ActionID: SlackActionHold,
+			Value:    string(value),
+			Style:    "danger",
+			Confirm: &slackConfirm{
+				Title:   plain("Hold " + eventName(event) + "?"),
+				Text:    plain(fmt.Sprintf("Automatic DNS changes of %s stay paused until someone releases it.", eventOrigin(event))),
+				Confirm: plain("Hold"),
+				Deny:    plain("Cancel"),
+			},
+		},
+		slackButton{
+			Type:     "button",
+			Text:     plain("Release"),
+			ActionID: SlackActionRelease,
+			Value:    string(value),
+		},
+	}}
+}
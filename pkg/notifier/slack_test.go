@@ -3,22 +3,24 @@ package notifier
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
 
 func TestSlackNotifier_Notify(t *testing.T) {
 	tests := []struct {
-		name          string
-		event         FailoverEvent
-		expectedColor string
-		expectedOld   string
-		expectedNew   string
-		wantError     bool
-		statusCode    int
+		name           string
+		event          FailoverEvent
+		expectedStatus string
+		expectedOld    string
+		expectedNew    string
+		wantError      bool
+		statusCode     int
 	}{
 		{
 			name: "successful notification - failover to backup IP",
@@ -34,11 +36,11 @@ func TestSlackNotifier_Notify(t *testing.T) {
 				Timestamp:    time.Now(),
 				IsFailoverIP: true,
 			},
-			expectedColor: "danger",
-			expectedOld:   "192.168.1.1\n192.168.1.10",
-			expectedNew:   "192.168.1.2\n192.168.1.20",
-			wantError:     false,
-			statusCode:    http.StatusOK,
+			expectedStatus: slackDanger,
+			expectedOld:    "192.168.1.1\n192.168.1.10",
+			expectedNew:    "192.168.1.2\n192.168.1.20",
+			wantError:      false,
+			statusCode:     http.StatusOK,
 		},
 		{
 			name: "successful notification - return to priority",
@@ -55,11 +57,11 @@ func TestSlackNotifier_Notify(t *testing.T) {
 				IsPriorityIP:     true,
 				ReturnToPriority: true,
 			},
-			expectedColor: "good",
-			expectedOld:   "192.168.1.2",
-			expectedNew:   "192.168.1.1\n192.168.1.3",
-			wantError:     false,
-			statusCode:    http.StatusOK,
+			expectedStatus: slackGood,
+			expectedOld:    "192.168.1.2",
+			expectedNew:    "192.168.1.1\n192.168.1.3",
+			wantError:      false,
+			statusCode:     http.StatusOK,
 		},
 		{
 			name: "failed notification - bad status code",
@@ -86,7 +88,7 @@ func TestSlackNotifier_Notify(t *testing.T) {
 					t.Errorf("Expected POST request, got %s", r.Method)
 				}
 
-				if r.Header.Get("Content-Type") != "application/json" {
+				if r.Header.Get("Content-Type") != "application/json; charset=utf-8" {
 					t.Errorf("Expected Content-Type application/json, got %s", r.Header.Get("Content-Type"))
 				}
 
@@ -99,20 +101,13 @@ func TestSlackNotifier_Notify(t *testing.T) {
 
 				// Verify message structure
 				if !tt.wantError {
-					if len(msg.Attachments) == 0 {
-						t.Error("Expected attachments in message")
-					} else if msg.Attachments[0].Color != tt.expectedColor {
-						t.Errorf("Expected color %s, got %s", tt.expectedColor, msg.Attachments[0].Color)
+					if len(msg.Blocks) == 0 || msg.Blocks[0].Type != "header" {
+						t.Error("Expected a header block in message")
+					} else if header := msg.Blocks[0].Text.Text; !strings.HasPrefix(header, tt.expectedStatus+" ") {
+						t.Errorf("Expected header starting with %s, got %s", tt.expectedStatus, header)
 					} else {
-						var oldValue, newValue string
-						for _, field := range msg.Attachments[0].Fields {
-							if field.Title == "Old IPs" {
-								oldValue = field.Value
-							}
-							if field.Title == "New IPs" {
-								newValue = field.Value
-							}
-						}
+						fields := slackFieldValues(msg)
+						oldValue, newValue := fields["Old IPs"], fields["New IPs"]
 						if tt.expectedOld != "" && oldValue != tt.expectedOld {
 							t.Errorf("Expected Old IPs %q, got %q", tt.expectedOld, oldValue)
 						}
@@ -170,6 +165,19 @@ func TestSlackNotifier_GetEventType(t *testing.T) {
 			},
 			expected: "Failover to Backup IP",
 		},
+		{
+			name: "change limit exceeded",
+			event: FailoverEvent{
+				Type:         EventTypeChangeLimitExceeded,
+				IsFailoverIP: true,
+			},
+			expected: "Change Limit Exceeded (DNS Changes Frozen)",
+		},
+		{
+			name:     "allowlist violation",
+			event:    FailoverEvent{Type: EventTypeAllowlistViolation},
+			expected: "IP Outside Allowlist (DNS Change Refused)",
+		},
 		{
 			name:     "generic failover",
 			event:    FailoverEvent{},
@@ -186,3 +194,173 @@ func TestSlackNotifier_GetEventType(t *testing.T) {
 		})
 	}
 }
+
+// slackFieldValues returns the titled fields of the sections of msg by title
+func slackFieldValues(msg slackMessage) map[string]string {
+	fields := make(map[string]string)
+	for _, block := range msg.Blocks {
+		if block.Type != "section" {
+			continue
+		}
+		texts := block.Fields
+		if block.Text != nil {
+			texts = append(texts, *block.Text)
+		}
+		for _, text := range texts {
+			title, value, ok := strings.Cut(text.Text, "\n")
+			if ok && len(title) > 2 && strings.HasPrefix(title, "*") && strings.HasSuffix(title, "*") {
+				fields[strings.Trim(title, "*")] = value
+			}
+		}
+	}
+	return fields
+}
+
+func TestSlackBlocks(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	fields := make([]slackField, 0, 13)
+	for i := 0; i < 12; i++ {
+		fields = append(fields, slackField{Title: "F", Value: "v", Short: true})
+	}
+	fields = append(fields, slackField{Title: "Reason", Value: ""})
+	blocks := slackBlocks(slackDanger, strings.Repeat("x", 200), "body", fields, at)
+
+	if len(blocks) != 6 {
+		t.Fatalf("Expected header, body, two field sections, reason and context, got %+v", blocks)
+	}
+	if header := []rune(blocks[0].Text.Text); len(header) != slackHeaderLimit || header[len(header)-1] != '…' {
+		t.Errorf("Expected the header to be cut to %d characters, got %q", slackHeaderLimit, string(header))
+	}
+	if blocks[1].Text.Text != "body" || len(blocks[2].Fields) != 10 || len(blocks[3].Fields) != 2 {
+		t.Errorf("Unexpected body and field sections %+v", blocks[1:4])
+	}
+	if blocks[4].Text.Text != "*Reason*\n-" {
+		t.Errorf("Expected an empty value to be shown as -, got %q", blocks[4].Text.Text)
+	}
+	if got, want := blocks[5].Elements[0].(slackText).Text, "Cloudflare GSLB | <!date^1767323045^{date_short_pretty} {time_secs}|2026-01-02T03:04:05Z>"; got != want {
+		t.Errorf("context = %q, want %q", got, want)
+	}
+}
+
+func TestSlackNotifier_Actions(t *testing.T) {
+	var requests []slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slackMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		requests = append(requests, msg)
+	}))
+	defer server.Close()
+
+	notifier := NewSlackNotifier(server.URL)
+	notifier.EnableActions()
+	failover := FailoverEvent{OriginName: "www", ZoneName: "example.com", RecordType: "A", IsFailoverIP: true, Timestamp: time.Now()}
+	started := FailoverEvent{Type: EventTypeServiceStarted, Timestamp: time.Now()}
+	for _, event := range []FailoverEvent{failover, started} {
+		if err := notifier.Notify(context.Background(), event); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+	}
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+
+	actions := func(msg slackMessage) []map[string]any {
+		var buttons []map[string]any
+		for _, block := range msg.Blocks {
+			if block.Type != "actions" {
+				continue
+			}
+			for _, element := range block.Elements {
+				buttons = append(buttons, element.(map[string]any))
+			}
+		}
+		return buttons
+	}
+	buttons := actions(requests[0])
+	if len(buttons) != 2 || buttons[0]["action_id"] != SlackActionHold || buttons[1]["action_id"] != SlackActionRelease {
+		t.Fatalf("Expected hold and release buttons, got %v", buttons)
+	}
+	var origin SlackActionOrigin
+	if err := json.Unmarshal([]byte(buttons[0]["value"].(string)), &origin); err != nil {
+		t.Fatalf("Failed to decode the button value: %v", err)
+	}
+	if origin != (SlackActionOrigin{Zone: "example.com", Name: "www", RecordType: "A"}) {
+		t.Errorf("Unexpected button value %+v", origin)
+	}
+	if buttons := actions(requests[1]); len(buttons) != 0 {
+		t.Errorf("Expected no buttons on service events, got %v", buttons)
+	}
+}
+
+func TestSlackAPINotifier_Threads(t *testing.T) {
+	var requests []slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat.postMessage" || r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			t.Errorf("Unexpected request %s with %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var msg slackMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		requests = append(requests, msg)
+		_ = json.NewEncoder(w).Encode(slackResponse{OK: true, TS: fmt.Sprintf("1700000000.%06d", len(requests))})
+	}))
+	defer server.Close()
+
+	notifier := NewSlackAPINotifier(server.URL+"/", "xoxb-test", "C123")
+	www := FailoverEvent{OriginName: "www", ZoneName: "example.com", RecordType: "A", Type: EventTypeFailover, Timestamp: time.Now()}
+	failover := www
+	failover.IsFailoverIP = true
+	allDown := www
+	allDown.Type = EventTypeAllIPsDown
+	api := failover
+	api.OriginName = "api"
+	recovery := www
+	recovery.IsPriorityIP, recovery.ReturnToPriority = true, true
+	started := FailoverEvent{Type: EventTypeServiceStarted, Timestamp: time.Now()}
+
+	for _, event := range []FailoverEvent{failover, allDown, api, recovery, started, failover} {
+		if err := notifier.Notify(context.Background(), event); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+	}
+
+	want := []struct {
+		thread    string
+		broadcast bool
+	}{
+		{"", false},
+		{"1700000000.000001", false},
+		{"", false},
+		{"1700000000.000001", true},
+		{"", false},
+		{"", false},
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("Expected %d requests, got %d", len(want), len(requests))
+	}
+	for i, msg := range requests {
+		if msg.Channel != "C123" || msg.ThreadTS != want[i].thread || msg.ReplyBroadcast != want[i].broadcast {
+			t.Errorf("requests[%d] = channel %q, thread %q, broadcast %v, want thread %q, broadcast %v",
+				i, msg.Channel, msg.ThreadTS, msg.ReplyBroadcast, want[i].thread, want[i].broadcast)
+		}
+	}
+	if thread := notifier.threads["www.example.com/A"]; thread != "1700000000.000006" {
+		t.Errorf("Expected a new incident to open a new thread, got %q", thread)
+	}
+}
+
+func TestSlackAPINotifier_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(slackResponse{OK: false, Error: "channel_not_found"})
+	}))
+	defer server.Close()
+
+	notifier := NewSlackAPINotifier(server.URL, "xoxb-test", "C404")
+	event := FailoverEvent{OriginName: "www", ZoneName: "example.com", RecordType: "A", IsFailoverIP: true}
+	err := notifier.Notify(context.Background(), event)
+	if err == nil || !strings.Contains(err.Error(), "channel_not_found") {
+		t.Fatalf("Expected the API error, got %v", err)
+	}
+	if len(notifier.threads) != 0 {
+		t.Errorf("Expected no thread after a failed post, got %v", notifier.threads)
+	}
+}
@@ -0,0 +1,85 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Summary is a periodic report of the service, sent so that silence can be
+// told apart from a service that stopped running.
+type Summary struct {
+	// Since and Until bound the period the summary covers.
+	Since time.Time
+	Until time.Time
+	// Uptime is how long the service has been running.
+	Uptime time.Duration
+	// Origins is the number of configured origins and Monitored the number
+	// of them whose health is being checked.
+	Origins   int
+	Monitored int
+	// Failovers counts the changes of the published IPs in the period by
+	// origin, e.g. "www.example.com (A)".
+	Failovers map[string]int
+	// Degraded lists the origins that are not healthy at Until, with their
+	// health, e.g. "www.example.com (A): failover".
+	Degraded []string
+}
+
+// TotalFailovers returns the number of failovers of all origins.
+func (s Summary) TotalFailovers() int {
+	total := 0
+	for _, count := range s.Failovers {
+		total += count
+	}
+	return total
+}
+
+// Healthy reports whether every origin is monitored and healthy.
+func (s Summary) Healthy() bool {
+	return len(s.Degraded) == 0 && s.Monitored == s.Origins
+}
+
+// SummaryNotifier is implemented by notifiers that can send summaries.
+type SummaryNotifier interface {
+	NotifySummary(ctx context.Context, summary Summary) error
+}
+
+func summaryTitle(summary Summary) string {
+	return fmt.Sprintf("GSLB Summary - %d of %d origins monitored", summary.Monitored, summary.Origins)
+}
+
+// summaryFailovers lists the failovers per origin, most first.
+func summaryFailovers(summary Summary) string {
+	if len(summary.Failovers) == 0 {
+		return "none"
+	}
+	origins := make([]string, 0, len(summary.Failovers))
+	for origin := range summary.Failovers {
+		origins = append(origins, origin)
+	}
+	sort.Slice(origins, func(i, j int) bool {
+		if summary.Failovers[origins[i]] != summary.Failovers[origins[j]] {
+			return summary.Failovers[origins[i]] > summary.Failovers[origins[j]]
+		}
+		return origins[i] < origins[j]
+	})
+	lines := make([]string, 0, len(origins))
+	for _, origin := range origins {
+		lines = append(lines, fmt.Sprintf("%s: %d", origin, summary.Failovers[origin]))
+	}
+	return fmt.Sprintf("%d in total\n%s", summary.TotalFailovers(), strings.Join(lines, "\n"))
+}
+
+func summaryDegraded(summary Summary) string {
+	if len(summary.Degraded) == 0 {
+		return "none"
+	}
+	return strings.Join(summary.Degraded, "\n")
+}
+
+func summaryPeriod(summary Summary) string {
+	return fmt.Sprintf("%s - %s", summary.Since.UTC().Format(time.RFC3339), summary.Until.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,109 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSummary() Summary {
+	until := time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)
+	return Summary{
+		Since:     until.Add(-24 * time.Hour),
+		Until:     until,
+		Uptime:    72 * time.Hour,
+		Origins:   3,
+		Monitored: 3,
+		Failovers: map[string]int{"www.example.com (A)": 1, "api.example.com (A)": 3},
+		Degraded:  []string{"www.example.com (A): failover"},
+	}
+}
+
+// captureBody returns a server that records the body of the last request.
+func captureBody(t *testing.T, status int) (*httptest.Server, *[]byte) {
+	t.Helper()
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &body
+}
+
+func TestSummaryFailovers(t *testing.T) {
+	if got, want := summaryFailovers(testSummary()), "4 in total\napi.example.com (A): 3\nwww.example.com (A): 1"; got != want {
+		t.Errorf("summaryFailovers() = %q, want %q", got, want)
+	}
+	if got := summaryFailovers(Summary{}); got != "none" {
+		t.Errorf("summaryFailovers() = %q, want none", got)
+	}
+}
+
+func TestSlackNotifier_NotifySummary(t *testing.T) {
+	server, body := captureBody(t, http.StatusOK)
+	if err := NewSlackNotifier(server.URL).NotifySummary(context.Background(), testSummary()); err != nil {
+		t.Fatalf("NotifySummary() error = %v", err)
+	}
+
+	var msg slackMessage
+	if err := json.Unmarshal(*body, &msg); err != nil {
+		t.Fatalf("Failed to unmarshal request body: %v", err)
+	}
+	if !strings.Contains(msg.Text, "3 of 3 origins monitored") || !strings.HasPrefix(msg.Blocks[0].Text.Text, slackWarning) {
+		t.Fatalf("Unexpected message %+v", msg)
+	}
+	fields := slackFieldValues(msg)
+	if !strings.HasPrefix(fields["Failovers"], "4 in total") || fields["Degraded Origins"] != "www.example.com (A): failover" || fields["Uptime"] != "72h0m0s" {
+		t.Errorf("Unexpected fields %v", fields)
+	}
+}
+
+func TestDiscordNotifier_NotifySummary(t *testing.T) {
+	server, body := captureBody(t, http.StatusNoContent)
+	summary := testSummary()
+	summary.Degraded = nil
+	if err := NewDiscordNotifier(server.URL).NotifySummary(context.Background(), summary); err != nil {
+		t.Fatalf("NotifySummary() error = %v", err)
+	}
+
+	var msg discordMessage
+	if err := json.Unmarshal(*body, &msg); err != nil {
+		t.Fatalf("Failed to unmarshal request body: %v", err)
+	}
+	if len(msg.Embeds) != 1 || msg.Embeds[0].Color != 5763719 || !strings.Contains(msg.Embeds[0].Title, "3 of 3 origins monitored") {
+		t.Fatalf("Unexpected message %+v", msg)
+	}
+	for _, field := range msg.Embeds[0].Fields {
+		if field.Name == "Degraded Origins" && field.Value != "none" {
+			t.Errorf("Expected no degraded origins, got %q", field.Value)
+		}
+	}
+
+	server, _ = captureBody(t, http.StatusInternalServerError)
+	if err := NewDiscordNotifier(server.URL).NotifySummary(context.Background(), summary); err == nil {
+		t.Error("Expected an error for a failed webhook")
+	}
+}
+
+func TestTelegramNotifier_NotifySummary(t *testing.T) {
+	server, body := captureBody(t, http.StatusOK)
+	if err := NewTelegramNotifier(server.URL, "123:abc", "-100123").NotifySummary(context.Background(), testSummary()); err != nil {
+		t.Fatalf("NotifySummary() error = %v", err)
+	}
+
+	var msg telegramMessage
+	if err := json.Unmarshal(*body, &msg); err != nil {
+		t.Fatalf("Failed to unmarshal request body: %v", err)
+	}
+	for _, want := range []string{"⚠️ *GSLB Summary \\- 3 of 3 origins monitored*", "*Failovers:*\n4 in total\napi\\.example\\.com \\(A\\): 3", "*Uptime:* 72h0m0s"} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("Expected %q in message:\n%s", want, msg.Text)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// telegramEscaper escapes the characters that are reserved in MarkdownV2
+var telegramEscaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+	"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+	"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// TelegramNotifier implements the Notifier interface for the Telegram Bot API
+type TelegramNotifier struct {
+	templated
+	apiURL   string
+	botToken string
+	chatID   string
+	httpSender
+}
+
+// NewTelegramNotifier creates a new Telegram notifier that sends messages
+// from the bot to the chat, which is a chat ID or @channelusername
+func NewTelegramNotifier(apiURL, botToken, chatID string) *TelegramNotifier {
+	return &TelegramNotifier{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		botToken:   botToken,
+		chatID:     chatID,
+		httpSender: newHTTPSender(),
+	}
+}
+
+// telegramMessage is the body of a sendMessage request
+type telegramMessage struct {
+	ChatID    string `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode"`
+}
+
+// telegramResponse is the body of every Bot API response
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+// Notify sends a notification to Telegram
+func (t *TelegramNotifier) Notify(ctx context.Context, event FailoverEvent) error {
+	if event.Type == EventTypeDigest {
+		lines := []string{
+			"📋 *" + escapeTelegram(digestTitle(event)) + "*",
+			"",
+			escapeTelegram(event.Reason),
+			"",
+			telegramField("Severity", escapeTelegram(event.Severity.String())),
+			telegramField("Events", escapeTelegram(formatDigestEvents(event))),
+		}
+		return t.send(ctx, strings.Join(lines, "\n"))
+	}
+
+	custom := t.message(event)
+	title := "DNS Failover Event - " + eventName(event)
+	if event.ObserveOnly {
+		title += observeOnlySuffix
+	}
+	title += repeatSuffix(event)
+	if custom.Title != "" {
+		title = custom.Title
+	}
+
+	lines := []string{t.getEmoji(event) + " *" + escapeTelegram(title) + "*", ""}
+	if custom.Body != "" {
+		lines = append(lines, escapeTelegram(custom.Body), "")
+	}
+	if len(custom.Fields) > 0 {
+		for _, field := range custom.Fields {
+			lines = append(lines, telegramField(field.Name, escapeTelegram(field.Value)))
+		}
+	} else {
+		lines = append(lines,
+			telegramField("Origin", escapeTelegram(eventOrigin(event))),
+			telegramField("Event Type", escapeTelegram(eventDescription(event))),
+			telegramField("Severity", escapeTelegram(event.Severity.String())),
+			telegramField("Old IPs", formatTelegramIPList(event.OldIPs, event.OldIP)),
+			telegramField("New IPs", formatTelegramIPList(event.NewIPs, event.NewIP)),
+		)
+		if event.Reason != "" {
+			lines = append(lines, telegramField("Reason", escapeTelegram(event.Reason)))
+		}
+		if len(event.Checks) > 0 {
+			lines = append(lines, telegramField("Checks", escapeTelegram(formatChecks(event))))
+		}
+		if len(event.Candidates) > 0 {
+			lines = append(lines, telegramField("Candidates", escapeTelegram(formatCandidates(event))))
+		}
+	}
+
+	return t.send(ctx, strings.Join(lines, "\n"))
+}
+
+// NotifySummary sends a periodic summary to Telegram
+func (t *TelegramNotifier) NotifySummary(ctx context.Context, summary Summary) error {
+	emoji := "✅"
+	if !summary.Healthy() {
+		emoji = "⚠️"
+	}
+	lines := []string{
+		emoji + " *" + escapeTelegram(summaryTitle(summary)) + "*",
+		"",
+		telegramField("Period", escapeTelegram(summaryPeriod(summary))),
+		telegramField("Failovers", escapeTelegram(summaryFailovers(summary))),
+		telegramField("Degraded Origins", escapeTelegram(summaryDegraded(summary))),
+		telegramField("Uptime", escapeTelegram(summary.Uptime.Round(time.Minute).String())),
+	}
+	return t.send(ctx, strings.Join(lines, "\n"))
+}
+
+func (t *TelegramNotifier) send(ctx context.Context, text string) error {
+	payload, err := json.Marshal(telegramMessage{ChatID: t.chatID, Text: text, ParseMode: "MarkdownV2"})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Telegram message: %w", err)
+	}
+
+	endpoint := t.apiURL + "/bot" + t.botToken + "/sendMessage"
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(payload))
+	if err != nil {
+		return errors.New("failed to create Telegram request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		// The URL contains the bot token, so only the cause is reported
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to send Telegram notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var response telegramResponse
+		if json.NewDecoder(resp.Body).Decode(&response) == nil && response.Description != "" {
+			return fmt.Errorf("telegram API returned status %d: %s", resp.StatusCode, response.Description)
+		}
+		return fmt.Errorf("telegram API returned status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (t *TelegramNotifier) getEmoji(event FailoverEvent) string {
+	switch {
+	case event.Type.IsAlert():
+		return "🚨"
+	case (event.ReturnToPriority && event.IsPriorityIP) || event.Type == EventTypeFlappingStopped || event.Type == EventTypeOriginReleased:
+		return "✅"
+	case event.Type == EventTypeOriginHeld:
+		return "✋"
+	case event.Type.IsLifecycle():
+		return "ℹ️"
+	case event.IsFailoverIP:
+		return "❌"
+	default:
+		return "🔄"
+	}
+}
+
+// escapeTelegram escapes text for a MarkdownV2 message
+func escapeTelegram(text string) string {
+	return telegramEscaper.Replace(text)
+}
+
+// telegramField formats a bold name and an already escaped value
+func telegramField(name, value string) string {
+	if strings.Contains(value, "\n") {
+		return "*" + escapeTelegram(name) + ":*\n" + value
+	}
+	return "*" + escapeTelegram(name) + ":* " + value
+}
+
+// formatTelegramIPList formats IPs as code, which needs no escaping
+func formatTelegramIPList(ips []string, fallback string) string {
+	if len(ips) == 0 {
+		if fallback == "" {
+			return escapeTelegram("-")
+		}
+		ips = []string{fallback}
+	}
+	formatted := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		formatted = append(formatted, "`"+ip+"`")
+	}
+	return strings.Join(formatted, ", ")
+}
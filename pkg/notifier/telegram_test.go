@@ -0,0 +1,86 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTelegramNotifier_Notify(t *testing.T) {
+	var path string
+	var message telegramMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	notifier := NewTelegramNotifier(server.URL+"/", "123:abc", "-100123")
+	event := FailoverEvent{
+		OriginName:   "www",
+		ZoneName:     "example.com",
+		RecordType:   "A",
+		OldIPs:       []string{"192.0.2.1"},
+		NewIPs:       []string{"198.51.100.1", "198.51.100.2"},
+		Reason:       "Health check failed (timeout)",
+		Timestamp:    time.Now(),
+		IsFailoverIP: true,
+	}
+	if err := notifier.Notify(context.Background(), event); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if path != "/bot123:abc/sendMessage" || message.ChatID != "-100123" || message.ParseMode != "MarkdownV2" {
+		t.Errorf("Unexpected request to %s: %+v", path, message)
+	}
+	for _, want := range []string{
+		"❌ *DNS Failover Event \\- www\\.example\\.com*",
+		"*Event Type:* Failover to Backup IP",
+		"*New IPs:* `198.51.100.1`, `198.51.100.2`",
+		"*Reason:* Health check failed \\(timeout\\)",
+	} {
+		if !strings.Contains(message.Text, want) {
+			t.Errorf("Expected %q in message:\n%s", want, message.Text)
+		}
+	}
+
+	event.IsFailoverIP, event.IsPriorityIP, event.ReturnToPriority = false, true, true
+	if err := notifier.Notify(context.Background(), event); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if !strings.HasPrefix(message.Text, "✅") || !strings.Contains(message.Text, "Recovery \\(Return to Priority IP\\)") {
+		t.Errorf("Unexpected recovery message:\n%s", message.Text)
+	}
+}
+
+func TestTelegramNotifier_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"ok":false,"description":"Bad Request: chat not found"}`))
+	}))
+	defer server.Close()
+
+	err := NewTelegramNotifier(server.URL, "123:abc", "-100123").Notify(context.Background(), FailoverEvent{OriginName: "www"})
+	if err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Errorf("Expected the API description, got %v", err)
+	}
+
+	// Connection errors do not reveal the bot token in the URL
+	server.Close()
+	err = NewTelegramNotifier(server.URL, "123:abc", "-100123").Notify(context.Background(), FailoverEvent{OriginName: "www"})
+	if err == nil || strings.Contains(err.Error(), "123:abc") {
+		t.Errorf("Expected an error without the token, got %v", err)
+	}
+}
+
+func TestEscapeTelegram(t *testing.T) {
+	if got := escapeTelegram("a_b*c[d](e)~f`g>h#i+j-k=l|m{n}o.p!q\\r"); got != "a\\_b\\*c\\[d\\]\\(e\\)\\~f\\`g\\>h\\#i\\+j\\-k\\=l\\|m\\{n\\}o\\.p\\!q\\\\r" {
+		t.Errorf("escapeTelegram() = %q", got)
+	}
+}
@@ -0,0 +1,185 @@
+package notifier
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateField is a field of a MessageTemplate, such as a runbook link.
+type TemplateField struct {
+	Name  string
+	Value string // template of the value
+	// Inline places the field next to others where the service supports it
+	Inline bool
+}
+
+// MessageTemplate customizes the event messages of a notifier with Go
+// templates. Parts left empty keep the default layout of the notifier. The
+// zero value and nil keep every default.
+type MessageTemplate struct {
+	title  *template.Template
+	body   *template.Template
+	fields []fieldTemplate
+}
+
+type fieldTemplate struct {
+	name   string
+	value  *template.Template
+	inline bool
+}
+
+// Message is an event message rendered from a MessageTemplate. Empty parts
+// are left to the notifier.
+type Message struct {
+	Title  string
+	Body   string
+	Fields []MessageField
+}
+
+// MessageField is a rendered TemplateField.
+type MessageField struct {
+	Name   string
+	Value  string
+	Inline bool
+}
+
+// TemplateData is what message templates are executed with: the event, plus
+// the texts the default messages are made of.
+type TemplateData struct {
+	FailoverEvent
+	// Origin names the record, e.g. "www.example.com (A)"
+	Origin string
+	// Description is the kind of event, e.g. "Failover to Backup IP"
+	Description string
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	// lower and upper also take values such as .Severity and .Type
+	"lower": func(value any) string { return strings.ToLower(fmt.Sprint(value)) },
+	"upper": func(value any) string { return strings.ToUpper(fmt.Sprint(value)) },
+	"default": func(fallback, value string) string {
+		if value == "" {
+			return fallback
+		}
+		return value
+	},
+}
+
+// NewMessageTemplate parses the templates of a message. The templates are
+// tried on a sample event, so that mistakes such as unknown fields are
+// reported here rather than when an event is sent.
+func NewMessageTemplate(title, body string, fields []TemplateField) (*MessageTemplate, error) {
+	t := &MessageTemplate{}
+	var err error
+	if t.title, err = parseTemplate("title", title); err != nil {
+		return nil, err
+	}
+	if t.body, err = parseTemplate("body", body); err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		value, err := parseTemplate("field "+field.Name, field.Value)
+		if err != nil {
+			return nil, err
+		}
+		t.fields = append(t.fields, fieldTemplate{name: field.Name, value: value, inline: field.Inline})
+	}
+
+	sample := FailoverEvent{
+		Type: EventTypeFailover, OriginName: "www", ZoneName: "example.com", RecordType: "A",
+		OldIP: "192.0.2.1", NewIP: "198.51.100.1", OldIPs: []string{"192.0.2.1"}, NewIPs: []string{"198.51.100.1"},
+		Reason: "Health check failed", Timestamp: time.Now(), IsFailoverIP: true, Labels: map[string]string{},
+	}
+	if _, err := t.Render(sample); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	// Missing labels render empty rather than as "<no value>"
+	parsed, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return parsed, nil
+}
+
+// Render executes the templates for event.
+func (t *MessageTemplate) Render(event FailoverEvent) (Message, error) {
+	var message Message
+	if t == nil {
+		return message, nil
+	}
+	data := TemplateData{
+		FailoverEvent: event,
+		Origin:        eventOrigin(event),
+		Description:   eventDescription(event),
+	}
+	var err error
+	if message.Title, err = execute(t.title, data); err != nil {
+		return Message{}, err
+	}
+	if message.Body, err = execute(t.body, data); err != nil {
+		return Message{}, err
+	}
+	for _, field := range t.fields {
+		value, err := execute(field.value, data)
+		if err != nil {
+			return Message{}, err
+		}
+		message.Fields = append(message.Fields, MessageField{Name: field.name, Value: value, Inline: field.inline})
+	}
+	return message, nil
+}
+
+func execute(t *template.Template, data TemplateData) (string, error) {
+	if t == nil {
+		return "", nil
+	}
+	var out strings.Builder
+	if err := t.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", t.Name(), err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// TemplatedNotifier is implemented by notifiers whose event messages can be
+// customized with a MessageTemplate.
+type TemplatedNotifier interface {
+	Notifier
+	// SetTemplate customizes the event messages; nil restores the defaults.
+	// It must be called before the notifier is used.
+	SetTemplate(t *MessageTemplate)
+}
+
+// templated is embedded by the notifiers that implement TemplatedNotifier.
+type templated struct {
+	template *MessageTemplate
+}
+
+// SetTemplate customizes the event messages of the notifier
+func (t *templated) SetTemplate(tmpl *MessageTemplate) {
+	t.template = tmpl
+}
+
+// message renders the template for event. A template that fails falls back
+// to the default message, so that the event is still sent.
+func (t *templated) message(event FailoverEvent) Message {
+	if event.Type == EventTypeDigest {
+		// Templates are written for single events
+		return Message{}
+	}
+	message, err := t.template.Render(event)
+	if err != nil {
+		log.Printf("Sending the default message: %v", err)
+	}
+	return message
+}
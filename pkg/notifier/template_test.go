@@ -0,0 +1,144 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func templateTestEvent() FailoverEvent {
+	return FailoverEvent{
+		Type:         EventTypeFailover,
+		OriginName:   "www",
+		ZoneName:     "example.com",
+		RecordType:   "A",
+		OldIPs:       []string{"192.0.2.1"},
+		NewIPs:       []string{"198.51.100.1", "198.51.100.2"},
+		Reason:       "Health check failed",
+		Timestamp:    time.Now(),
+		IsFailoverIP: true,
+		Severity:     SeverityWarning,
+		Labels:       map[string]string{"asset_id": "A-42"},
+	}
+}
+
+func TestMessageTemplate_Render(t *testing.T) {
+	tmpl, err := NewMessageTemplate(
+		"[{{ .Severity | upper }}] {{ .Description }}: {{ .Origin }}",
+		"{{ .Reason }}\n",
+		[]TemplateField{
+			{Name: "IPs", Value: `{{ join .NewIPs ", " }}`, Inline: true},
+			{Name: "Asset", Value: `{{ .Labels.asset_id }}`},
+			{Name: "Owner", Value: `{{ .Labels.owner | default "unknown" }}`},
+		})
+	if err != nil {
+		t.Fatalf("NewMessageTemplate() error = %v", err)
+	}
+
+	message, err := tmpl.Render(templateTestEvent())
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if message.Title != "[WARNING] Failover to Backup IP: www.example.com (A)" {
+		t.Errorf("Title = %q", message.Title)
+	}
+	if message.Body != "Health check failed" {
+		t.Errorf("Body = %q, want the trimmed reason", message.Body)
+	}
+	want := []MessageField{
+		{Name: "IPs", Value: "198.51.100.1, 198.51.100.2", Inline: true},
+		{Name: "Asset", Value: "A-42"},
+		{Name: "Owner", Value: "unknown"},
+	}
+	if len(message.Fields) != len(want) {
+		t.Fatalf("Fields = %+v, want %+v", message.Fields, want)
+	}
+	for i := range want {
+		if message.Fields[i] != want[i] {
+			t.Errorf("Fields[%d] = %+v, want %+v", i, message.Fields[i], want[i])
+		}
+	}
+
+	var none *MessageTemplate
+	if message, err := none.Render(templateTestEvent()); err != nil || message.Title != "" || message.Fields != nil {
+		t.Errorf("Expected a nil template to render nothing, got %+v, %v", message, err)
+	}
+}
+
+func TestNewMessageTemplate_Invalid(t *testing.T) {
+	tests := map[string][]string{
+		"syntax":        {"{{ .Origin ", ""},
+		"unknown field": {"{{ .Owner }}", ""},
+		"unknown func":  {"", "{{ shout .Reason }}"},
+	}
+	for name, parts := range tests {
+		if _, err := NewMessageTemplate(parts[0], parts[1], nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := NewMessageTemplate("", "", []TemplateField{{Name: "Runbook", Value: "{{ .Runbook }}"}}); err == nil {
+		t.Error("Expected an error for an unknown field in a field template")
+	}
+}
+
+func TestNotifiers_UseTemplate(t *testing.T) {
+	tmpl, err := NewMessageTemplate("{{ .Labels.asset_id }} failed over", "Runbook: https://runbooks.example.com/{{ .OriginName }}",
+		[]TemplateField{{Name: "Asset", Value: "{{ .Labels.asset_id }}", Inline: true}})
+	if err != nil {
+		t.Fatalf("NewMessageTemplate() error = %v", err)
+	}
+
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	slack := NewSlackNotifier(server.URL)
+	slack.SetTemplate(tmpl)
+	if err := slack.Notify(context.Background(), templateTestEvent()); err != nil {
+		t.Fatalf("Slack Notify() error = %v", err)
+	}
+	blocks := body["blocks"].([]any)
+	bodyText := blocks[1].(map[string]any)["text"].(map[string]any)["text"].(string)
+	if body["text"] != "A-42 failed over" || !strings.HasSuffix(bodyText, "/www") || len(blocks) != 4 {
+		t.Errorf("Unexpected Slack message %+v", body)
+	}
+
+	discord := NewDiscordNotifier(server.URL)
+	discord.SetTemplate(tmpl)
+	if err := discord.Notify(context.Background(), templateTestEvent()); err != nil {
+		t.Fatalf("Discord Notify() error = %v", err)
+	}
+	embed := body["embeds"].([]any)[0].(map[string]any)
+	field := embed["fields"].([]any)[0].(map[string]any)
+	if embed["title"] != "A-42 failed over" || field["name"] != "Asset" || field["value"] != "A-42" || field["inline"] != true {
+		t.Errorf("Unexpected Discord message %+v", body)
+	}
+
+	ntfy := NewNtfyNotifier(server.URL, "gslb", "")
+	ntfy.SetTemplate(tmpl)
+	if err := ntfy.Notify(context.Background(), templateTestEvent()); err != nil {
+		t.Fatalf("ntfy Notify() error = %v", err)
+	}
+	if body["title"] != "A-42 failed over" || body["message"] != "Runbook: https://runbooks.example.com/www\nAsset: A-42" {
+		t.Errorf("Unexpected ntfy message %+v", body)
+	}
+
+	// Without a template, the default layout is kept
+	slack = NewSlackNotifier(server.URL)
+	if err := slack.Notify(context.Background(), templateTestEvent()); err != nil {
+		t.Fatalf("Slack Notify() error = %v", err)
+	}
+	if !strings.HasPrefix(body["text"].(string), "DNS Failover Event - ") {
+		t.Errorf("Expected the default Slack text, got %q", body["text"])
+	}
+}
@@ -0,0 +1,33 @@
+package notifier
+
+import (
+	"net/http"
+	"time"
+)
+
+// DefaultTimeout bounds each request of the notifiers that send over HTTP,
+// unless SetTimeout changes it
+const DefaultTimeout = 10 * time.Second
+
+// TimeoutNotifier is implemented by the notifiers whose requests have a
+// timeout of their own
+type TimeoutNotifier interface {
+	Notifier
+	// SetTimeout bounds each request of the notifier. It must be called
+	// before the notifier is used.
+	SetTimeout(timeout time.Duration)
+}
+
+// httpSender is embedded by the notifiers that send over HTTP
+type httpSender struct {
+	httpClient *http.Client
+}
+
+func newHTTPSender() httpSender {
+	return httpSender{httpClient: &http.Client{Timeout: DefaultTimeout}}
+}
+
+// SetTimeout bounds each request of the notifier
+func (h *httpSender) SetTimeout(timeout time.Duration) {
+	h.httpClient.Timeout = timeout
+}
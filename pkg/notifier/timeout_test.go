@@ -0,0 +1,31 @@
+package notifier
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSetTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	var n TimeoutNotifier = NewNtfyNotifier(server.URL, "alerts", "")
+	n.SetTimeout(50 * time.Millisecond)
+	start := time.Now()
+	if err := n.Notify(context.Background(), FailoverEvent{Type: EventTypeFailover, OriginName: "www"}); err == nil {
+		t.Fatal("Expected an error from a server slower than the timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Notify() took %v despite a 50ms timeout", elapsed)
+	}
+}
@@ -0,0 +1,141 @@
+// Package otlp sends telemetry to an OpenTelemetry collector over OTLP/HTTP
+// with the JSON encoding. It holds what the tracing and metrics packages share.
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScopeName identifies the instrumentation in exported telemetry.
+const ScopeName = "github.com/bootjp/cloudflare-gslb"
+
+// exportTimeout bounds a single export request.
+const exportTimeout = 10 * time.Second
+
+// Client posts export requests to a collector.
+type Client struct {
+	endpoint   string
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+// NewClient returns a client for the collector at endpoint, the base URL
+// without the /v1/<signal> path. headers are added to every request.
+func NewClient(endpoint string, headers map[string]string) *Client {
+	return &Client{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		headers:    headers,
+		httpClient: &http.Client{Timeout: exportTimeout},
+	}
+}
+
+// Export posts payload as JSON to the path of signal, such as "traces" or "metrics".
+func (c *Client) Export(ctx context.Context, signal string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", signal, err)
+	}
+
+	url := c.endpoint + "/v1/" + signal
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s to %s: %w", signal, url, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector %s returned status: %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// Resource describes the process that produced the telemetry.
+type Resource struct {
+	Attributes []KeyValue `json:"attributes"`
+}
+
+// NewResource returns a resource with service.name and, if known, host.name.
+func NewResource(serviceName string) Resource {
+	attrs := []KeyValue{{Key: "service.name", Value: Value(serviceName)}}
+	if hostname, err := os.Hostname(); err == nil {
+		attrs = append(attrs, KeyValue{Key: "host.name", Value: Value(hostname)})
+	}
+	return Resource{Attributes: attrs}
+}
+
+// Scope describes the instrumentation library.
+type Scope struct {
+	Name string `json:"name"`
+}
+
+// KeyValue is an attribute.
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+// AnyValue holds exactly one of its fields.
+type AnyValue struct {
+	StringValue *string     `json:"stringValue,omitempty"`
+	BoolValue   *bool       `json:"boolValue,omitempty"`
+	IntValue    *string     `json:"intValue,omitempty"`
+	DoubleValue *float64    `json:"doubleValue,omitempty"`
+	ArrayValue  *ArrayValue `json:"arrayValue,omitempty"`
+}
+
+// ArrayValue is a list of values.
+type ArrayValue struct {
+	Values []AnyValue `json:"values"`
+}
+
+// Value encodes a string, bool, int, int64, float64 or []string. Other
+// types are encoded as their fmt.Sprint string.
+func Value(value any) AnyValue {
+	switch v := value.(type) {
+	case string:
+		return AnyValue{StringValue: &v}
+	case bool:
+		return AnyValue{BoolValue: &v}
+	case int:
+		s := strconv.Itoa(v)
+		return AnyValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return AnyValue{IntValue: &s}
+	case float64:
+		return AnyValue{DoubleValue: &v}
+	case []string:
+		values := make([]AnyValue, 0, len(v))
+		for _, item := range v {
+			values = append(values, Value(item))
+		}
+		return AnyValue{ArrayValue: &ArrayValue{Values: values}}
+	default:
+		s := fmt.Sprint(v)
+		return AnyValue{StringValue: &s}
+	}
+}
+
+// UnixNano encodes t as OTLP's decimal nanoseconds since the Unix epoch.
+func UnixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
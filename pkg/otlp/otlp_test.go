@@ -0,0 +1,49 @@
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValue(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{"www", `{"stringValue":"www"}`},
+		{true, `{"boolValue":true}`},
+		{42, `{"intValue":"42"}`},
+		{int64(7), `{"intValue":"7"}`},
+		{1.5, `{"doubleValue":1.5}`},
+		{[]string{"a", "b"}, `{"arrayValue":{"values":[{"stringValue":"a"},{"stringValue":"b"}]}}`},
+		{uint8(3), `{"stringValue":"3"}`},
+	}
+	for _, tt := range tests {
+		got, err := json.Marshal(Value(tt.value))
+		if err != nil {
+			t.Fatalf("Marshal(%v) error = %v", tt.value, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Value(%#v) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestClientExport(t *testing.T) {
+	var path, contentType, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, contentType, auth = r.URL.Path, r.Header.Get("Content-Type"), r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/", map[string]string{"Authorization": "Bearer token"})
+	if err := client.Export(context.Background(), "metrics", map[string]any{}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if path != "/v1/metrics" || contentType != "application/json" || auth != "Bearer token" {
+		t.Errorf("request = %s %s %s, want /v1/metrics with JSON and the configured header", path, contentType, auth)
+	}
+}
@@ -0,0 +1,124 @@
+// Package prober runs the health checks a daemon assigns to a remote prober
+// and reports their results back, so that the daemon can judge its origins
+// from several vantage points while staying the only one to change DNS.
+package prober
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/healthcheck"
+)
+
+const (
+	// retryInterval is how long to wait after a failed registration, or when
+	// the daemon assigns no interval
+	retryInterval = 10 * time.Second
+	// maxConcurrentChecks is how many checks run at the same time
+	maxConcurrentChecks = 16
+)
+
+// Controller is the daemon the prober works for, normally a
+// *grpcapi.Client.
+type Controller interface {
+	Register(ctx context.Context, name, region string) ([]gslb.RemoteCheck, time.Duration, error)
+	ReportResults(ctx context.Context, prober string, results []gslb.RemoteResult) error
+}
+
+// Prober registers with a controller and runs its checks in rounds.
+type Prober struct {
+	name       string
+	region     string
+	controller Controller
+	newChecker func(config.HealthCheck) (healthcheck.Checker, error)
+}
+
+// New returns a prober named name in region. An empty name makes the
+// controller use the name of the token.
+func New(name, region string, controller Controller) *Prober {
+	return &Prober{
+		name:       name,
+		region:     region,
+		controller: controller,
+		newChecker: healthcheck.NewChecker,
+	}
+}
+
+// Run runs rounds of checks until ctx is done. Every round registers again,
+// so that the prober follows configuration changes and restarts of the
+// controller.
+func (p *Prober) Run(ctx context.Context) error {
+	for {
+		start := time.Now()
+		interval := p.round(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Until(start.Add(interval))):
+		}
+	}
+}
+
+// round registers, runs the assigned checks and reports their results. It
+// returns how long after its start the next round should begin.
+func (p *Prober) round(ctx context.Context) time.Duration {
+	checks, interval, err := p.controller.Register(ctx, p.name, p.region)
+	if err != nil {
+		log.Printf("Failed to register with the controller: %v", err)
+		return retryInterval
+	}
+	if interval <= 0 {
+		interval = retryInterval
+	}
+	if len(checks) == 0 {
+		return interval
+	}
+
+	results := p.check(checks)
+	if err := p.controller.ReportResults(ctx, p.name, results); err != nil {
+		log.Printf("Failed to report %d results to the controller: %v", len(results), err)
+	}
+	return interval
+}
+
+// check runs checks concurrently and returns their results in order.
+func (p *Prober) check(checks []gslb.RemoteCheck) []gslb.RemoteResult {
+	results := make([]gslb.RemoteResult, len(checks))
+	slots := make(chan struct{}, maxConcurrentChecks)
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = p.run(check)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// run runs a single check.
+func (p *Prober) run(check gslb.RemoteCheck) gslb.RemoteResult {
+	result := gslb.RemoteResult{Zone: check.Zone, Name: check.Name, RecordType: check.RecordType, IP: check.IP}
+	checker, err := p.newChecker(check.HealthCheck)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	start := time.Now()
+	err = checker.Check(check.IP)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Error = err.Error()
+		log.Printf("IP %s of %s is unhealthy: %v", check.IP, check.Name, err)
+		return result
+	}
+	result.Healthy = true
+	return result
+}
@@ -0,0 +1,96 @@
+package prober
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/healthcheck"
+	hcmock "github.com/bootjp/cloudflare-gslb/pkg/healthcheck/mock"
+)
+
+type fakeController struct {
+	mu          sync.Mutex
+	checks      []gslb.RemoteCheck
+	interval    time.Duration
+	registerErr error
+	registered  []string
+	reports     [][]gslb.RemoteResult
+}
+
+func (c *fakeController) Register(ctx context.Context, name, region string) ([]gslb.RemoteCheck, time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.registered = append(c.registered, name+"@"+region)
+	return c.checks, c.interval, c.registerErr
+}
+
+func (c *fakeController) ReportResults(ctx context.Context, prober string, results []gslb.RemoteResult) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reports = append(c.reports, results)
+	return nil
+}
+
+func TestProber_Round(t *testing.T) {
+	controller := &fakeController{
+		checks: []gslb.RemoteCheck{
+			{Zone: "example.com", Name: "www.example.com", RecordType: "A", IP: "192.0.2.1", HealthCheck: config.HealthCheck{Type: "http"}},
+			{Zone: "example.com", Name: "www.example.com", RecordType: "A", IP: "192.0.2.2", HealthCheck: config.HealthCheck{Type: "http"}},
+			{Zone: "example.com", Name: "www.example.com", RecordType: "A", IP: "192.0.2.3", HealthCheck: config.HealthCheck{Type: "gopher"}},
+		},
+		interval: 30 * time.Second,
+	}
+	p := New("tokyo", "ap-northeast-1", controller)
+	p.newChecker = func(hc config.HealthCheck) (healthcheck.Checker, error) {
+		if hc.Type != "http" {
+			return nil, errors.New("unknown health check type")
+		}
+		return hcmock.NewCheckerMock(func(ip string) error {
+			if ip == "192.0.2.2" {
+				return errors.New("connection refused")
+			}
+			return nil
+		}), nil
+	}
+
+	if interval := p.round(context.Background()); interval != 30*time.Second {
+		t.Errorf("round() = %v, want the assigned interval", interval)
+	}
+	if len(controller.registered) != 1 || controller.registered[0] != "tokyo@ap-northeast-1" {
+		t.Errorf("unexpected registrations %v", controller.registered)
+	}
+	if len(controller.reports) != 1 {
+		t.Fatalf("expected one report, got %d", len(controller.reports))
+	}
+	results := controller.reports[0]
+	if len(results) != 3 || !results[0].Healthy || results[0].IP != "192.0.2.1" {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if results[1].Healthy || results[1].Error != "connection refused" {
+		t.Errorf("expected the failure of 192.0.2.2, got %+v", results[1])
+	}
+	if results[2].Healthy || results[2].Error == "" {
+		t.Errorf("expected a check that cannot be run to fail, got %+v", results[2])
+	}
+}
+
+func TestProber_RoundWithoutChecks(t *testing.T) {
+	controller := &fakeController{registerErr: errors.New("unavailable")}
+	p := New("tokyo", "", controller)
+	if interval := p.round(context.Background()); interval != retryInterval {
+		t.Errorf("round() after a failed registration = %v, want %v", interval, retryInterval)
+	}
+
+	controller.registerErr = nil
+	if interval := p.round(context.Background()); interval != retryInterval {
+		t.Errorf("round() without an interval = %v, want %v", interval, retryInterval)
+	}
+	if len(controller.reports) != 0 {
+		t.Errorf("expected nothing to be reported without checks, got %v", controller.reports)
+	}
+}
@@ -0,0 +1,32 @@
+package provider
+
+import (
+	"plugin"
+
+	"github.com/cockroachdb/errors"
+)
+
+// pluginRegisterSymbol is the function a provider plugin must export.
+const pluginRegisterSymbol = "RegisterProviders"
+
+// LoadPlugin opens the Go plugin at path and calls its RegisterProviders
+// function, which is expected to Register one or more providers. The plugin
+// must be built with the same Go version and module versions as the binary.
+func LoadPlugin(path string) error {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open provider plugin %s", path)
+	}
+
+	sym, err := p.Lookup(pluginRegisterSymbol)
+	if err != nil {
+		return errors.Wrapf(err, "provider plugin %s", path)
+	}
+	register, ok := sym.(func())
+	if !ok {
+		return errors.Newf("provider plugin %s: %s must be a func()", path, pluginRegisterSymbol)
+	}
+
+	register()
+	return nil
+}
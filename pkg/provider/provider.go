@@ -0,0 +1,117 @@
+// Package provider defines the interface between the GSLB engine and DNS
+// providers that are not built in.
+//
+// A provider implements Provider for one zone and registers a Factory under
+// the name used in the zone's `provider` setting:
+//
+//	func init() {
+//		provider.Register("powerdns", func(ctx context.Context, opts provider.Options) (provider.Provider, error) {
+//			return newPowerDNSClient(opts.Zone)
+//		})
+//	}
+//
+// Providers can be compiled into a custom build by importing their package, or
+// built with `go build -buildmode=plugin` and listed in `provider_plugins`, in
+// which case the plugin must export a `RegisterProviders func()` that calls
+// Register.
+package provider
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrUnknownProvider is returned when no provider is registered under a name.
+var ErrUnknownProvider = errors.New("unknown DNS provider")
+
+// Record is a single DNS record value as seen by the engine.
+type Record struct {
+	// ID identifies the record to UpdateRecord and DeleteRecord. It is opaque
+	// to the engine and only needs to be stable between calls.
+	ID      string
+	Name    string
+	Type    string
+	Content string
+	TTL     int
+	Proxied bool
+}
+
+// Capabilities describes optional features a provider supports. The engine
+// logs a warning when a zone's configuration asks for an unsupported one.
+type Capabilities struct {
+	// Proxy means records can be served through the provider's proxy.
+	Proxy bool
+	// AtomicReplace means ReplaceRecords applies all changes at once, so
+	// resolvers never see a partially replaced record set.
+	AtomicReplace bool
+}
+
+// Provider manages the records of one zone.
+type Provider interface {
+	// GetRecords returns every record with the given name and type.
+	GetRecords(ctx context.Context, name, recordType string) ([]Record, error)
+	// CreateRecord adds a record and returns it with its ID.
+	CreateRecord(ctx context.Context, name, recordType, content string, proxied bool) (Record, error)
+	// UpdateRecord changes the content of the record with the given ID.
+	UpdateRecord(ctx context.Context, id, name, recordType, content string, proxied bool) (Record, error)
+	// DeleteRecord removes the record with the given ID.
+	DeleteRecord(ctx context.Context, id string) error
+	// ReplaceRecords makes contents the only records with the given name and type.
+	ReplaceRecords(ctx context.Context, name, recordType string, contents []string, proxied bool) error
+	// ZoneID returns the provider's identifier of the zone.
+	ZoneID() string
+	// Capabilities reports the optional features the provider supports.
+	Capabilities() Capabilities
+}
+
+// Options carries what a Factory needs to build a Provider for one zone.
+type Options struct {
+	Zone config.ZoneConfig
+	// TTL is the record TTL in seconds the engine expects.
+	TTL int
+}
+
+// Factory builds a Provider for a zone.
+type Factory func(ctx context.Context, opts Options) (Provider, error)
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[string]Factory{}
+)
+
+// Register makes a provider available to zones under name. Registering the
+// same name again replaces the previous factory.
+func Register(name string, factory Factory) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registry[name] = factory
+}
+
+// Lookup returns the factory registered under name.
+func Lookup(name string) (Factory, error) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	factory, ok := registry[name]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownProvider, "%s", name)
+	}
+	return factory, nil
+}
+
+// Names returns the names of all registered providers in sorted order.
+func Names() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
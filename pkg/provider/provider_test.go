@@ -0,0 +1,38 @@
+package provider
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+)
+
+func TestRegisterAndLookup(t *testing.T) {
+	Register("test-provider", func(ctx context.Context, opts Options) (Provider, error) {
+		return nil, errors.New("not implemented")
+	})
+
+	if _, err := Lookup("test-provider"); err != nil {
+		t.Fatalf("Lookup returned error: %v", err)
+	}
+	if _, err := Lookup("missing-provider"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("expected ErrUnknownProvider, got %v", err)
+	}
+
+	found := false
+	for _, name := range Names() {
+		if name == "test-provider" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected test-provider in %v", Names())
+	}
+}
+
+func TestLoadPlugin_MissingFile(t *testing.T) {
+	if err := LoadPlugin(filepath.Join(t.TempDir(), "missing.so")); err == nil {
+		t.Fatal("expected error for a missing plugin")
+	}
+}
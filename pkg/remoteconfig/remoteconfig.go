@@ -0,0 +1,238 @@
+// Package remoteconfig loads the configuration from an HTTPS or S3 URL and
+// polls it for changes.
+package remoteconfig
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/bootjp/cloudflare-gslb/config"
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultPollInterval is used when config_poll_seconds is not set.
+const DefaultPollInterval = time.Minute
+
+// emptyPayloadHash is the SHA-256 of an empty body, which S3 requires for GET requests.
+var emptyPayloadHash = func() string {
+	sum := sha256.Sum256(nil)
+	return hex.EncodeToString(sum[:])
+}()
+
+// IsRemote reports whether location is an https:// or s3:// URL rather than a file path.
+func IsRemote(location string) bool {
+	return strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "s3://")
+}
+
+// Fetcher downloads a remote configuration file. It remembers the ETag of
+// the last download so that unchanged files are not downloaded again.
+type Fetcher struct {
+	location string
+	url      string
+	// name is the path part of the URL, used to detect the file format.
+	name       string
+	httpClient *http.Client
+
+	mu   sync.Mutex
+	etag string
+	// data is the last downloaded file, parsed again by Reload.
+	data []byte
+
+	// S3 requests are signed with these; nil for HTTPS URLs.
+	credentials aws.CredentialsProvider
+	region      string
+	signer      *v4.Signer
+}
+
+// NewFetcher returns a fetcher for an https:// or s3://bucket/key URL. S3
+// objects are read with the default AWS credential chain and region.
+func NewFetcher(ctx context.Context, location string) (*Fetcher, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid config URL %s", location)
+	}
+	f := &Fetcher{
+		location:   location,
+		url:        location,
+		name:       u.Path,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	switch u.Scheme {
+	case "https":
+		return f, nil
+	case "s3":
+		key := strings.TrimPrefix(u.Path, "/")
+		if u.Host == "" || key == "" {
+			return nil, errors.Newf("config URL %s must be s3://bucket/key", location)
+		}
+		cfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load AWS configuration")
+		}
+		if cfg.Region == "" {
+			return nil, errors.Newf("no AWS region configured for %s", location)
+		}
+		f.credentials = cfg.Credentials
+		f.region = cfg.Region
+		f.signer = v4.NewSigner()
+		f.url = s3ObjectURL(u.Host, key, cfg.Region)
+		return f, nil
+	default:
+		return nil, errors.Newf("unsupported config URL %s: use https:// or s3://", location)
+	}
+}
+
+func s3ObjectURL(bucket, key, region string) string {
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, (&url.URL{Path: key}).EscapedPath())
+}
+
+// Fetch downloads the configuration. It returns false without data if the
+// file has not changed since the previous successful Fetch.
+func (f *Fetcher) Fetch(ctx context.Context) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	if etag := f.lastETag(); etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if f.signer != nil {
+		if err := f.sign(ctx, req); err != nil {
+			return nil, false, err
+		}
+	}
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "failed to fetch config from %s", f.location)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, false, nil
+	case http.StatusOK:
+	default:
+		return nil, false, errors.Newf("failed to fetch config from %s: status %d", f.location, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "failed to fetch config from %s", f.location)
+	}
+	f.mu.Lock()
+	f.etag = resp.Header.Get("ETag")
+	f.data = data
+	f.mu.Unlock()
+	return data, true, nil
+}
+
+func (f *Fetcher) lastETag() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.etag
+}
+
+// forget clears the ETag so that the next Fetch downloads the file again.
+func (f *Fetcher) forget() {
+	f.mu.Lock()
+	f.etag = ""
+	f.mu.Unlock()
+}
+
+func (f *Fetcher) sign(ctx context.Context, req *http.Request) error {
+	creds, err := f.credentials.Retrieve(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve AWS credentials")
+	}
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if err := f.signer.SignHTTP(ctx, creds, req, emptyPayloadHash, "s3", f.region, time.Now()); err != nil {
+		return errors.Wrap(err, "failed to sign S3 request")
+	}
+	return nil
+}
+
+// Load fetches and parses the configuration. Like Fetch, it returns false
+// if the file has not changed.
+func (f *Fetcher) Load(ctx context.Context) (*config.Config, bool, error) {
+	data, changed, err := f.Fetch(ctx)
+	if err != nil || !changed {
+		return nil, changed, err
+	}
+	cfg, err := config.LoadConfigData(f.name, data)
+	if err != nil {
+		// Forget the ETag so that transient failures, such as an unreachable secret store, are retried
+		f.forget()
+		return nil, false, errors.Wrapf(err, "invalid config at %s", f.location)
+	}
+	return cfg, true, nil
+}
+
+// Reload parses the last downloaded file again without fetching it, for
+// when something it refers to, such as the origins in a KV store, changed.
+func (f *Fetcher) Reload() (*config.Config, error) {
+	f.mu.Lock()
+	data := f.data
+	f.mu.Unlock()
+	if data == nil {
+		return nil, errors.Newf("config at %s has not been loaded", f.location)
+	}
+	cfg, err := config.LoadConfigData(f.name, data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid config at %s", f.location)
+	}
+	return cfg, nil
+}
+
+// Watch polls the configuration every interval until ctx is done and calls
+// apply with every changed configuration that loads successfully. Fetch,
+// parse and apply errors are logged and the current configuration is kept.
+// Every poll is passed to report: the error, or nil once the configuration
+// loads and applies again.
+func (f *Fetcher) Watch(ctx context.Context, interval time.Duration, apply func(*config.Config) error, report func(error)) {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		cfg, changed, err := f.Load(ctx)
+		if err != nil {
+			log.Printf("Keeping the current config: %v", err)
+			report(err)
+			continue
+		}
+		if !changed {
+			report(nil)
+			continue
+		}
+		log.Printf("Config at %s changed, reloading", f.location)
+		if err := apply(cfg); err != nil {
+			log.Printf("Failed to apply the config from %s: %v", f.location, err)
+			f.forget()
+			report(err)
+			continue
+		}
+		report(nil)
+	}
+}
@@ -0,0 +1,200 @@
+package remoteconfig
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/bootjp/cloudflare-gslb/config"
+)
+
+const testConfig = `{
+  "cloudflare_api_token": "%s",
+  "cloudflare_zones": [{"zone_id": "zone-1", "name": "example.com"}],
+  "check_interval_seconds": 60,
+  "origins": []
+}`
+
+// configServer serves a config whose token can be changed, with an ETag derived from it.
+type configServer struct {
+	mu       sync.Mutex
+	token    string
+	requests int
+	notMod   int
+	headers  http.Header
+}
+
+func (s *configServer) set(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	s.headers = r.Header.Clone()
+	etag := `"` + s.token + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		s.notMod++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	_, _ = w.Write([]byte(strings.Replace(testConfig, "%s", s.token, 1)))
+}
+
+func newTestFetcher(t *testing.T, server *configServer) *Fetcher {
+	t.Helper()
+	ts := httptest.NewTLSServer(server)
+	t.Cleanup(ts.Close)
+	f, err := NewFetcher(context.Background(), ts.URL+"/gslb/config.json?version=1")
+	if err != nil {
+		t.Fatalf("NewFetcher returned error: %v", err)
+	}
+	f.httpClient = ts.Client()
+	return f
+}
+
+func TestIsRemote(t *testing.T) {
+	for location, want := range map[string]bool{
+		"https://example.com/config.yaml": true,
+		"s3://bucket/config.yaml":         true,
+		"http://example.com/config.yaml":  false,
+		"config.yaml":                     false,
+	} {
+		if got := IsRemote(location); got != want {
+			t.Errorf("IsRemote(%q) = %v, want %v", location, got, want)
+		}
+	}
+}
+
+func TestFetcherLoad(t *testing.T) {
+	server := &configServer{token: "first"}
+	f := newTestFetcher(t, server)
+	ctx := context.Background()
+
+	cfg, changed, err := f.Load(ctx)
+	if err != nil || !changed {
+		t.Fatalf("expected a config, got changed=%v err=%v", changed, err)
+	}
+	if cfg.CloudflareAPIToken != "first" {
+		t.Errorf("unexpected token %q", cfg.CloudflareAPIToken)
+	}
+
+	if _, changed, err := f.Load(ctx); err != nil || changed {
+		t.Fatalf("expected no change, got changed=%v err=%v", changed, err)
+	}
+	if server.notMod != 1 {
+		t.Errorf("expected a conditional request, got %d 304 responses", server.notMod)
+	}
+
+	server.set("second")
+	cfg, changed, err = f.Load(ctx)
+	if err != nil || !changed || cfg.CloudflareAPIToken != "second" {
+		t.Fatalf("expected the changed config, got %+v changed=%v err=%v", cfg, changed, err)
+	}
+}
+
+func TestFetcherLoad_InvalidConfig(t *testing.T) {
+	server := &configServer{token: `"broken`}
+	f := newTestFetcher(t, server)
+
+	if _, _, err := f.Load(context.Background()); err == nil {
+		t.Fatal("expected an error for an invalid config")
+	}
+	if _, _, err := f.Load(context.Background()); err == nil {
+		t.Fatal("expected the invalid config to be fetched and reported again")
+	}
+	if server.notMod != 0 {
+		t.Errorf("expected unconditional requests after a failure, got %d 304 responses", server.notMod)
+	}
+}
+
+func TestFetcherWatch(t *testing.T) {
+	server := &configServer{token: "first"}
+	f := newTestFetcher(t, server)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, _, err := f.Load(ctx); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	applied := make(chan *config.Config, 1)
+	go f.Watch(ctx, 10*time.Millisecond, func(cfg *config.Config) error {
+		applied <- cfg
+		return nil
+	}, func(error) {})
+
+	server.set("second")
+	select {
+	case cfg := <-applied:
+		if cfg.CloudflareAPIToken != "second" {
+			t.Errorf("unexpected token %q", cfg.CloudflareAPIToken)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the changed config was not applied")
+	}
+}
+
+func TestFetcherS3Signing(t *testing.T) {
+	server := &configServer{token: "first"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	f := &Fetcher{
+		location:    "s3://bucket/gslb/config.json",
+		url:         ts.URL + "/gslb/config.json",
+		name:        "/gslb/config.json",
+		httpClient:  ts.Client(),
+		credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		region:      "ap-northeast-1",
+		signer:      v4.NewSigner(),
+	}
+	if _, _, err := f.Load(context.Background()); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	auth := server.headers.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/ap-northeast-1/s3/") {
+		t.Errorf("request is not signed for S3: %q", auth)
+	}
+	if server.headers.Get("X-Amz-Content-Sha256") != emptyPayloadHash {
+		t.Errorf("missing payload hash header")
+	}
+}
+
+func TestS3ObjectURL(t *testing.T) {
+	got := s3ObjectURL("bucket", "gslb/my config.yaml", "us-east-1")
+	if want := "https://bucket.s3.us-east-1.amazonaws.com/gslb/my%20config.yaml"; got != want {
+		t.Errorf("s3ObjectURL() = %s, want %s", got, want)
+	}
+}
+
+func TestFetcherReload(t *testing.T) {
+	server := &configServer{token: "first"}
+	f := newTestFetcher(t, server)
+
+	if _, err := f.Reload(); err == nil {
+		t.Fatal("expected an error before the first Load")
+	}
+	if _, _, err := f.Load(context.Background()); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	server.set("second")
+
+	cfg, err := f.Reload()
+	if err != nil {
+		t.Fatalf("Reload returned error: %v", err)
+	}
+	if cfg.CloudflareAPIToken != "first" || server.requests != 1 {
+		t.Errorf("expected the downloaded file to be parsed again, got %q after %d requests", cfg.CloudflareAPIToken, server.requests)
+	}
+}
@@ -0,0 +1,327 @@
+// Package route53 implements the GSLB DNS client interface on top of AWS Route 53.
+package route53
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/cloudflare/cloudflare-go/v6/dns"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrInvalidRecordID is returned when a record ID was not produced by this client.
+var ErrInvalidRecordID = errors.New("invalid route53 record ID")
+
+type route53API interface {
+	ListResourceRecordSets(ctx context.Context, params *route53.ListResourceRecordSetsInput, optFns ...func(*route53.Options)) (*route53.ListResourceRecordSetsOutput, error)
+	ChangeResourceRecordSets(ctx context.Context, params *route53.ChangeResourceRecordSetsInput, optFns ...func(*route53.Options)) (*route53.ChangeResourceRecordSetsOutput, error)
+}
+
+// Options configures a DNSClient created by NewDNSClient.
+type Options struct {
+	// Region is the AWS region used for API calls. Route 53 is global, so
+	// this only matters for credential resolution and endpoints.
+	Region string
+	// AccessKeyID and SecretAccessKey are static credentials. When empty the
+	// default AWS credential chain (environment, shared config, IAM role) is used.
+	AccessKeyID     string
+	SecretAccessKey string
+	// TTL is the TTL in seconds written to record sets.
+	TTL int
+}
+
+// DNSClient manages simple (non-routing-policy) record sets in one hosted zone.
+//
+// Route 53 stores all values of a name and type in one record set, while the
+// GSLB service works with one record per value. Each value is therefore
+// exposed as its own dns.RecordResponse, with an ID that encodes the record
+// set and the value.
+type DNSClient struct {
+	api          route53API
+	hostedZoneID string
+	ttl          int64
+}
+
+// NewDNSClient returns a client for the hosted zone hostedZoneID.
+func NewDNSClient(ctx context.Context, hostedZoneID string, opts Options) (*DNSClient, error) {
+	var loadOpts []func(*awsconfig.LoadOptions) error
+	if opts.Region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(opts.Region))
+	}
+	if opts.AccessKeyID != "" {
+		loadOpts = append(loadOpts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(opts.AccessKeyID, opts.SecretAccessKey, ""),
+		))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load AWS configuration")
+	}
+
+	return newDNSClient(route53.NewFromConfig(cfg), hostedZoneID, opts.TTL), nil
+}
+
+func newDNSClient(api route53API, hostedZoneID string, ttl int) *DNSClient {
+	if ttl <= 0 {
+		ttl = 60
+	}
+	return &DNSClient{
+		api:          api,
+		hostedZoneID: hostedZoneID,
+		ttl:          int64(ttl),
+	}
+}
+
+func (c *DNSClient) GetZoneID() string {
+	return c.hostedZoneID
+}
+
+func (c *DNSClient) GetDNSRecords(ctx context.Context, name, recordType string) ([]dns.RecordResponse, error) {
+	set, err := c.getRecordSet(ctx, name, recordType)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return []dns.RecordResponse{}, nil
+	}
+
+	records := make([]dns.RecordResponse, 0, len(set.ResourceRecords))
+	for _, rr := range set.ResourceRecords {
+		records = append(records, c.recordResponse(name, recordType, aws.ToString(rr.Value), aws.ToInt64(set.TTL)))
+	}
+	return records, nil
+}
+
+func (c *DNSClient) CreateDNSRecord(ctx context.Context, name, recordType, content string) (dns.RecordResponse, error) {
+	values, err := c.currentValues(ctx, name, recordType)
+	if err != nil {
+		return dns.RecordResponse{}, err
+	}
+	if !containsValue(values, content) {
+		values = append(values, content)
+	}
+	if err := c.upsert(ctx, name, recordType, values); err != nil {
+		return dns.RecordResponse{}, err
+	}
+	return c.recordResponse(name, recordType, content, c.ttl), nil
+}
+
+func (c *DNSClient) UpdateDNSRecord(ctx context.Context, recordID, name, recordType, content string) (dns.RecordResponse, error) {
+	oldName, oldType, oldValue, err := parseRecordID(recordID)
+	if err != nil {
+		return dns.RecordResponse{}, err
+	}
+	if oldName != fqdn(name) || oldType != recordType {
+		// Moving a value to another record set is a delete plus a create
+		if err := c.DeleteDNSRecord(ctx, recordID); err != nil {
+			return dns.RecordResponse{}, err
+		}
+		return c.CreateDNSRecord(ctx, name, recordType, content)
+	}
+
+	values, err := c.currentValues(ctx, name, recordType)
+	if err != nil {
+		return dns.RecordResponse{}, err
+	}
+	values = removeValue(values, oldValue)
+	if !containsValue(values, content) {
+		values = append(values, content)
+	}
+	if err := c.upsert(ctx, name, recordType, values); err != nil {
+		return dns.RecordResponse{}, err
+	}
+	return c.recordResponse(name, recordType, content, c.ttl), nil
+}
+
+func (c *DNSClient) DeleteDNSRecord(ctx context.Context, recordID string) error {
+	name, recordType, value, err := parseRecordID(recordID)
+	if err != nil {
+		return err
+	}
+
+	set, err := c.getRecordSet(ctx, name, recordType)
+	if err != nil {
+		return err
+	}
+	if set == nil {
+		return nil
+	}
+
+	values := removeValue(recordValues(set), value)
+	if len(values) == 0 {
+		return c.change(ctx, types.ChangeActionDelete, set)
+	}
+	return c.upsert(ctx, name, recordType, values)
+}
+
+// ReplaceRecords sets the record set to exactly newContents with a single
+// UPSERT, which Route 53 applies atomically.
+func (c *DNSClient) ReplaceRecords(ctx context.Context, name, recordType string, newContents []string) error {
+	contents := dedupeValues(newContents)
+
+	set, err := c.getRecordSet(ctx, name, recordType)
+	if err != nil {
+		return err
+	}
+
+	if len(contents) == 0 {
+		if set == nil {
+			return nil
+		}
+		log.Printf("Deleting Route 53 record set %s (%s)", name, recordType)
+		return c.change(ctx, types.ChangeActionDelete, set)
+	}
+
+	if set != nil && aws.ToInt64(set.TTL) == c.ttl && sameValues(recordValues(set), contents) {
+		return nil
+	}
+
+	log.Printf("Upserting Route 53 record set %s (%s): %s", name, recordType, strings.Join(contents, ", "))
+	return c.upsert(ctx, name, recordType, contents)
+}
+
+// getRecordSet returns the simple record set for name and type, or nil if there is none.
+func (c *DNSClient) getRecordSet(ctx context.Context, name, recordType string) (*types.ResourceRecordSet, error) {
+	out, err := c.api.ListResourceRecordSets(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(c.hostedZoneID),
+		StartRecordName: aws.String(fqdn(name)),
+		StartRecordType: types.RRType(recordType),
+		MaxItems:        aws.Int32(1),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list Route 53 record sets for %s", name)
+	}
+
+	for i := range out.ResourceRecordSets {
+		set := out.ResourceRecordSets[i]
+		if aws.ToString(set.Name) != fqdn(name) || string(set.Type) != recordType {
+			continue
+		}
+		if set.SetIdentifier != nil || set.AliasTarget != nil {
+			return nil, errors.Newf("record set %s (%s) uses a routing policy or alias and cannot be managed", name, recordType)
+		}
+		return &set, nil
+	}
+	return nil, nil
+}
+
+func (c *DNSClient) currentValues(ctx context.Context, name, recordType string) ([]string, error) {
+	set, err := c.getRecordSet(ctx, name, recordType)
+	if err != nil || set == nil {
+		return nil, err
+	}
+	return recordValues(set), nil
+}
+
+func (c *DNSClient) upsert(ctx context.Context, name, recordType string, values []string) error {
+	records := make([]types.ResourceRecord, 0, len(values))
+	for _, value := range values {
+		records = append(records, types.ResourceRecord{Value: aws.String(value)})
+	}
+	return c.change(ctx, types.ChangeActionUpsert, &types.ResourceRecordSet{
+		Name:            aws.String(fqdn(name)),
+		Type:            types.RRType(recordType),
+		TTL:             aws.Int64(c.ttl),
+		ResourceRecords: records,
+	})
+}
+
+func (c *DNSClient) change(ctx context.Context, action types.ChangeAction, set *types.ResourceRecordSet) error {
+	_, err := c.api.ChangeResourceRecordSets(ctx, &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(c.hostedZoneID),
+		ChangeBatch: &types.ChangeBatch{
+			Comment: aws.String("managed-by=cloudflare-gslb"),
+			Changes: []types.Change{{Action: action, ResourceRecordSet: set}},
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to %s Route 53 record set %s", strings.ToLower(string(action)), aws.ToString(set.Name))
+	}
+	return nil
+}
+
+func (c *DNSClient) recordResponse(name, recordType, value string, ttl int64) dns.RecordResponse {
+	return dns.RecordResponse{
+		ID:      recordID(name, recordType, value),
+		Name:    strings.TrimSuffix(name, "."),
+		Type:    dns.RecordResponseType(recordType),
+		Content: value,
+		TTL:     dns.TTL(ttl),
+	}
+}
+
+// recordID identifies one value of a record set as "name|type|value".
+func recordID(name, recordType, value string) string {
+	return strings.Join([]string{fqdn(name), recordType, value}, "|")
+}
+
+func parseRecordID(id string) (name, recordType, value string, err error) {
+	parts := strings.SplitN(id, "|", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", errors.Wrapf(ErrInvalidRecordID, "%q", id)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+func recordValues(set *types.ResourceRecordSet) []string {
+	values := make([]string, 0, len(set.ResourceRecords))
+	for _, rr := range set.ResourceRecords {
+		values = append(values, aws.ToString(rr.Value))
+	}
+	return values
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func removeValue(values []string, value string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func dedupeValues(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !containsValue(result, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func sameValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, v := range a {
+		if !containsValue(b, v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,190 @@
+package route53
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+)
+
+// fakeRoute53API stores record sets keyed by "name|type" and applies changes to them.
+type fakeRoute53API struct {
+	sets    map[string]types.ResourceRecordSet
+	changes []types.Change
+}
+
+func newFakeRoute53API(sets ...types.ResourceRecordSet) *fakeRoute53API {
+	f := &fakeRoute53API{sets: make(map[string]types.ResourceRecordSet)}
+	for _, set := range sets {
+		f.sets[aws.ToString(set.Name)+"|"+string(set.Type)] = set
+	}
+	return f
+}
+
+func (f *fakeRoute53API) ListResourceRecordSets(ctx context.Context, params *route53.ListResourceRecordSetsInput, optFns ...func(*route53.Options)) (*route53.ListResourceRecordSetsOutput, error) {
+	out := &route53.ListResourceRecordSetsOutput{}
+	if set, ok := f.sets[aws.ToString(params.StartRecordName)+"|"+string(params.StartRecordType)]; ok {
+		out.ResourceRecordSets = []types.ResourceRecordSet{set}
+	}
+	return out, nil
+}
+
+func (f *fakeRoute53API) ChangeResourceRecordSets(ctx context.Context, params *route53.ChangeResourceRecordSetsInput, optFns ...func(*route53.Options)) (*route53.ChangeResourceRecordSetsOutput, error) {
+	for _, change := range params.ChangeBatch.Changes {
+		f.changes = append(f.changes, change)
+		key := aws.ToString(change.ResourceRecordSet.Name) + "|" + string(change.ResourceRecordSet.Type)
+		switch change.Action {
+		case types.ChangeActionDelete:
+			delete(f.sets, key)
+		default:
+			f.sets[key] = *change.ResourceRecordSet
+		}
+	}
+	return &route53.ChangeResourceRecordSetsOutput{}, nil
+}
+
+func (f *fakeRoute53API) values(name, recordType string) []string {
+	set, ok := f.sets[name+"|"+recordType]
+	if !ok {
+		return nil
+	}
+	values := recordValues(&set)
+	sort.Strings(values)
+	return values
+}
+
+func aSet(name string, values ...string) types.ResourceRecordSet {
+	records := make([]types.ResourceRecord, 0, len(values))
+	for _, v := range values {
+		records = append(records, types.ResourceRecord{Value: aws.String(v)})
+	}
+	return types.ResourceRecordSet{
+		Name:            aws.String(name),
+		Type:            types.RRTypeA,
+		TTL:             aws.Int64(60),
+		ResourceRecords: records,
+	}
+}
+
+func TestGetDNSRecords_OneRecordPerValue(t *testing.T) {
+	api := newFakeRoute53API(aSet("www.example.com.", "192.0.2.1", "192.0.2.2"))
+	client := newDNSClient(api, "Z123", 60)
+
+	records, err := client.GetDNSRecords(context.Background(), "www.example.com", "A")
+	if err != nil {
+		t.Fatalf("GetDNSRecords returned error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Content != "192.0.2.1" || records[0].Name != "www.example.com" {
+		t.Errorf("unexpected record %+v", records[0])
+	}
+	if records[0].ID == records[1].ID {
+		t.Error("expected distinct record IDs")
+	}
+}
+
+func TestGetDNSRecords_MissingSet(t *testing.T) {
+	client := newDNSClient(newFakeRoute53API(), "Z123", 60)
+
+	records, err := client.GetDNSRecords(context.Background(), "www.example.com", "A")
+	if err != nil {
+		t.Fatalf("GetDNSRecords returned error: %v", err)
+	}
+	if len(records) != 0 {
+		t.Errorf("expected no records, got %d", len(records))
+	}
+}
+
+func TestGetDNSRecords_RejectsRoutingPolicy(t *testing.T) {
+	set := aSet("www.example.com.", "192.0.2.1")
+	set.SetIdentifier = aws.String("weighted-1")
+	client := newDNSClient(newFakeRoute53API(set), "Z123", 60)
+
+	if _, err := client.GetDNSRecords(context.Background(), "www.example.com", "A"); err == nil {
+		t.Fatal("expected error for a record set with a routing policy")
+	}
+}
+
+func TestReplaceRecords_SingleUpsert(t *testing.T) {
+	api := newFakeRoute53API(aSet("www.example.com.", "192.0.2.1"))
+	client := newDNSClient(api, "Z123", 60)
+
+	if err := client.ReplaceRecords(context.Background(), "www.example.com", "A", []string{"192.0.2.2", "192.0.2.3", "192.0.2.2"}); err != nil {
+		t.Fatalf("ReplaceRecords returned error: %v", err)
+	}
+	if len(api.changes) != 1 || api.changes[0].Action != types.ChangeActionUpsert {
+		t.Fatalf("expected a single UPSERT, got %+v", api.changes)
+	}
+	got := api.values("www.example.com.", "A")
+	if len(got) != 2 || got[0] != "192.0.2.2" || got[1] != "192.0.2.3" {
+		t.Errorf("unexpected values %v", got)
+	}
+}
+
+func TestReplaceRecords_NoChange(t *testing.T) {
+	api := newFakeRoute53API(aSet("www.example.com.", "192.0.2.1", "192.0.2.2"))
+	client := newDNSClient(api, "Z123", 60)
+
+	if err := client.ReplaceRecords(context.Background(), "www.example.com", "A", []string{"192.0.2.2", "192.0.2.1"}); err != nil {
+		t.Fatalf("ReplaceRecords returned error: %v", err)
+	}
+	if len(api.changes) != 0 {
+		t.Errorf("expected no changes, got %d", len(api.changes))
+	}
+}
+
+func TestCreateAndDeleteDNSRecord(t *testing.T) {
+	api := newFakeRoute53API(aSet("www.example.com.", "192.0.2.1"))
+	client := newDNSClient(api, "Z123", 60)
+	ctx := context.Background()
+
+	created, err := client.CreateDNSRecord(ctx, "www.example.com", "A", "192.0.2.2")
+	if err != nil {
+		t.Fatalf("CreateDNSRecord returned error: %v", err)
+	}
+	if got := api.values("www.example.com.", "A"); len(got) != 2 {
+		t.Fatalf("expected 2 values after create, got %v", got)
+	}
+
+	if err := client.DeleteDNSRecord(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteDNSRecord returned error: %v", err)
+	}
+	if got := api.values("www.example.com.", "A"); len(got) != 1 || got[0] != "192.0.2.1" {
+		t.Fatalf("expected only 192.0.2.1 after delete, got %v", got)
+	}
+
+	if err := client.DeleteDNSRecord(ctx, recordID("www.example.com", "A", "192.0.2.1")); err != nil {
+		t.Fatalf("DeleteDNSRecord returned error: %v", err)
+	}
+	if _, ok := api.sets["www.example.com.|A"]; ok {
+		t.Error("expected the record set to be deleted with its last value")
+	}
+	if last := api.changes[len(api.changes)-1]; last.Action != types.ChangeActionDelete {
+		t.Errorf("expected a DELETE change, got %s", last.Action)
+	}
+}
+
+func TestUpdateDNSRecord(t *testing.T) {
+	api := newFakeRoute53API(aSet("www.example.com.", "192.0.2.1", "192.0.2.2"))
+	client := newDNSClient(api, "Z123", 60)
+
+	id := recordID("www.example.com", "A", "192.0.2.1")
+	if _, err := client.UpdateDNSRecord(context.Background(), id, "www.example.com", "A", "192.0.2.9"); err != nil {
+		t.Fatalf("UpdateDNSRecord returned error: %v", err)
+	}
+	got := api.values("www.example.com.", "A")
+	if len(got) != 2 || got[0] != "192.0.2.2" || got[1] != "192.0.2.9" {
+		t.Errorf("unexpected values %v", got)
+	}
+}
+
+func TestParseRecordID_Invalid(t *testing.T) {
+	if _, _, _, err := parseRecordID("not-a-route53-id"); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,348 @@
+// Package sentry reports panics and operational errors to Sentry or any
+// service accepting Sentry's envelope API, such as GlitchTip.
+//
+// Reports are only sent after Setup. Until then, and after the returned
+// shutdown function has been called, CaptureError and Recover only keep
+// their usual behaviour, so reporting code does not need to check whether
+// error reporting is enabled.
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrInvalidDSN is returned for a DSN that is not https://key@host/project.
+	ErrInvalidDSN = errors.New("invalid Sentry DSN")
+	// ErrAlreadySetUp is returned when Setup is called twice without shutting down.
+	ErrAlreadySetUp = errors.New("error reporting is already set up")
+)
+
+// DefaultRepeatInterval is how often an error that keeps happening is reported.
+const DefaultRepeatInterval = time.Hour
+
+// queueSize is the number of reports waiting to be sent before new ones are dropped.
+const queueSize = 100
+
+// panicTimeout bounds sending a panic, which happens before the process dies.
+const panicTimeout = 5 * time.Second
+
+// Levels of a report.
+const (
+	LevelError = "error"
+	LevelFatal = "fatal"
+)
+
+// Config configures error reporting.
+type Config struct {
+	// DSN is the Sentry DSN, e.g. https://public@o1.ingest.sentry.io/2.
+	DSN string
+	// Environment and Release are attached to every report.
+	Environment string
+	Release     string
+	// RepeatInterval is how often an error with the same key is reported
+	// while it keeps happening (default: DefaultRepeatInterval).
+	RepeatInterval time.Duration
+}
+
+// reporter is the state installed by Setup.
+type reporter struct {
+	cfg        Config
+	endpoint   string
+	authHeader string
+	serverName string
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu      sync.Mutex
+	repeats map[string]*repeat
+
+	// queueMu guards sending to queue against its closing on shutdown.
+	queueMu sync.RWMutex
+	closed  bool
+	queue   chan event
+	done    chan struct{}
+}
+
+// repeat tracks how often an error key was seen since it was last reported.
+type repeat struct {
+	lastReport time.Time
+	suppressed int
+}
+
+// current is the reporter installed by Setup, nil while reporting is disabled.
+var current atomic.Pointer[reporter]
+
+// Setup starts reporting to the DSN in cfg. The returned function sends the
+// queued reports and disables reporting.
+func Setup(cfg Config) (func(context.Context) error, error) {
+	endpoint, key, err := parseDSN(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.RepeatInterval <= 0 {
+		cfg.RepeatInterval = DefaultRepeatInterval
+	}
+	hostname, _ := os.Hostname()
+	r := &reporter{
+		cfg:        cfg,
+		endpoint:   endpoint,
+		authHeader: "Sentry sentry_version=7, sentry_client=cloudflare-gslb/1.0, sentry_key=" + key,
+		serverName: hostname,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+		repeats:    make(map[string]*repeat),
+		queue:      make(chan event, queueSize),
+		done:       make(chan struct{}),
+	}
+	if !current.CompareAndSwap(nil, r) {
+		return nil, ErrAlreadySetUp
+	}
+	go r.run()
+
+	var once sync.Once
+	return func(ctx context.Context) error {
+		var err error
+		once.Do(func() {
+			current.CompareAndSwap(r, nil)
+			r.queueMu.Lock()
+			r.closed = true
+			close(r.queue)
+			r.queueMu.Unlock()
+			select {
+			case <-r.done:
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		})
+		return err
+	}, nil
+}
+
+// parseDSN returns the envelope endpoint and public key of dsn.
+func parseDSN(dsn string) (endpoint, key string, err error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrInvalidDSN, err)
+	}
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.User == nil || u.User.Username() == "" {
+		return "", "", fmt.Errorf("%w: expected http(s)://key@host/project", ErrInvalidDSN)
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	project := path[slash+1:]
+	if slash < 0 || project == "" {
+		return "", "", fmt.Errorf("%w: missing project ID", ErrInvalidDSN)
+	}
+	return fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, path[:slash], project), u.User.Username(), nil
+}
+
+// ValidateDSN returns an error if dsn is not a valid Sentry DSN.
+func ValidateDSN(dsn string) error {
+	_, _, err := parseDSN(dsn)
+	return err
+}
+
+// CaptureError reports err with tags. Errors with the same key, which
+// should name the operation and the origin, are reported the first time and
+// then at most once per RepeatInterval while they keep happening, with the
+// number of occurrences in between.
+func CaptureError(key string, err error, tags map[string]string) {
+	r := current.Load()
+	if r == nil || err == nil {
+		return
+	}
+	occurrences, ok := r.allow(key)
+	if !ok {
+		return
+	}
+	e := r.newEvent(LevelError, err.Error(), rootType(err), tags)
+	e.Fingerprint = []string{key}
+	if occurrences > 1 {
+		e.Extra["occurrences"] = occurrences
+	}
+
+	r.queueMu.RLock()
+	defer r.queueMu.RUnlock()
+	if r.closed {
+		return
+	}
+	select {
+	case r.queue <- e:
+	default:
+		log.Printf("Dropping error report, the queue is full: %v", err)
+	}
+}
+
+// Queued returns the number of reports waiting to be sent.
+func Queued() int {
+	r := current.Load()
+	if r == nil {
+		return 0
+	}
+	return len(r.queue)
+}
+
+// rootType returns the type of the innermost error wrapped by err, which
+// groups errors better than the type of the outermost wrapper.
+func rootType(err error) string {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = next
+	}
+}
+
+// Recover reports a panic in progress with tags and panics again, so that
+// the process still crashes. Use it with defer at the top of a goroutine.
+func Recover(tags map[string]string) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	ReportPanic(recovered, tags)
+	panic(recovered)
+}
+
+// ReportPanic reports a panic recovered by the caller with tags and waits
+// for it to be sent. It is for deferred functions that do more than Recover
+// before panicking again; they must call it from the panicking goroutine so
+// that the stack is the one of the panic.
+func ReportPanic(recovered any, tags map[string]string) {
+	if r := current.Load(); r != nil {
+		e := r.newEvent(LevelFatal, fmt.Sprint(recovered), "panic", tags)
+		e.Extra["stack"] = string(debug.Stack())
+		ctx, cancel := context.WithTimeout(context.Background(), panicTimeout)
+		if err := r.send(ctx, e); err != nil {
+			log.Printf("Failed to report panic: %v", err)
+		}
+		cancel()
+	}
+}
+
+// allow reports whether key is due to be reported and how often it was seen
+// since the last report.
+func (r *reporter) allow(key string) (int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	rep, ok := r.repeats[key]
+	if !ok {
+		r.repeats[key] = &repeat{lastReport: now}
+		return 1, true
+	}
+	rep.suppressed++
+	if now.Sub(rep.lastReport) < r.cfg.RepeatInterval {
+		return 0, false
+	}
+	occurrences := rep.suppressed
+	rep.lastReport = now
+	rep.suppressed = 0
+	return occurrences, true
+}
+
+func (r *reporter) run() {
+	defer close(r.done)
+	for e := range r.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), r.httpClient.Timeout)
+		if err := r.send(ctx, e); err != nil {
+			log.Printf("Failed to report error to Sentry: %v", err)
+		}
+		cancel()
+	}
+}
+
+// event is the subset of the Sentry event payload that is sent.
+type event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Platform    string            `json:"platform"`
+	Level       string            `json:"level"`
+	Logger      string            `json:"logger"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Exception   *exceptions       `json:"exception,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+	Fingerprint []string          `json:"fingerprint,omitempty"`
+}
+
+type exceptions struct {
+	Values []exception `json:"values"`
+}
+
+type exception struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+func (r *reporter) newEvent(level, message, errType string, tags map[string]string) event {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return event{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   r.now().UTC(),
+		Platform:    "go",
+		Level:       level,
+		Logger:      "cloudflare-gslb",
+		ServerName:  r.serverName,
+		Environment: r.cfg.Environment,
+		Release:     r.cfg.Release,
+		Message:     message,
+		Exception:   &exceptions{Values: []exception{{Type: errType, Value: message}}},
+		Tags:        tags,
+		Extra:       map[string]any{},
+	}
+}
+
+// send posts e as an envelope with a single event item.
+func (r *reporter) send(ctx context.Context, e event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	header, err := json.Marshal(map[string]any{"event_id": e.EventID, "sent_at": r.now().UTC()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal envelope: %w", err)
+	}
+	var body bytes.Buffer
+	body.Write(header)
+	fmt.Fprintf(&body, "\n{\"type\":\"event\",\"length\":%d}\n", len(payload))
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", r.authHeader)
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send event: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry returned status: %d", resp.StatusCode)
+	}
+	return nil
+}
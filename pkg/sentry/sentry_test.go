@@ -0,0 +1,156 @@
+package sentry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector is a fake Sentry server recording the events it receives.
+type collector struct {
+	mu     sync.Mutex
+	events []event
+	auth   []string
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/42/envelope/" {
+		http.NotFound(w, r)
+		return
+	}
+	scanner := bufio.NewScanner(r.Body)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 3 {
+		http.Error(w, "expected header, item header and payload", http.StatusBadRequest)
+		return
+	}
+	var e event
+	if err := json.Unmarshal([]byte(lines[2]), &e); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	c.events = append(c.events, e)
+	c.auth = append(c.auth, r.Header.Get("X-Sentry-Auth"))
+	c.mu.Unlock()
+}
+
+func setupTest(t *testing.T, c *collector) func() {
+	t.Helper()
+	server := httptest.NewServer(c)
+	dsn := strings.Replace(server.URL, "http://", "http://public@", 1) + "/42"
+	shutdown, err := Setup(Config{DSN: dsn, Environment: "test"})
+	if err != nil {
+		t.Fatalf("Setup returned error: %v", err)
+	}
+	return func() {
+		if err := shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown returned error: %v", err)
+		}
+		server.Close()
+	}
+}
+
+func TestCaptureError_ReportsRepeatsOncePerInterval(t *testing.T) {
+	c := &collector{}
+	shutdown := setupTest(t, c)
+
+	var clockMu sync.Mutex
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	current.Load().now = func() time.Time {
+		clockMu.Lock()
+		defer clockMu.Unlock()
+		return now
+	}
+
+	tags := map[string]string{"origin": "www.example.com"}
+	CaptureError("dns_update:www.example.com", fmt.Errorf("update failed: %w", errors.New("boom")), tags)
+	CaptureError("dns_update:www.example.com", errors.New("boom"), tags)
+	CaptureError("dns_update:www.example.com", errors.New("boom"), tags)
+	CaptureError("records:www.example.com", errors.New("list failed"), tags)
+	clockMu.Lock()
+	now = now.Add(DefaultRepeatInterval)
+	clockMu.Unlock()
+	CaptureError("dns_update:www.example.com", errors.New("boom again"), tags)
+	shutdown()
+
+	if len(c.events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", c.events)
+	}
+	first := c.events[0]
+	if first.Message != "update failed: boom" || first.Level != LevelError || first.Tags["origin"] != "www.example.com" || first.Environment != "test" {
+		t.Errorf("unexpected event %+v", first)
+	}
+	if first.Exception == nil || first.Exception.Values[0].Type != "*errors.errorString" {
+		t.Errorf("expected the root error type, got %+v", first.Exception)
+	}
+	if c.events[2].Message != "boom again" || c.events[2].Extra["occurrences"] != float64(3) {
+		t.Errorf("expected the repeat to count 3 occurrences, got %+v", c.events[2])
+	}
+	if !strings.Contains(c.auth[0], "sentry_key=public") {
+		t.Errorf("unexpected auth header %q", c.auth[0])
+	}
+
+	// Nothing is reported after shutdown
+	CaptureError("late", errors.New("late"), nil)
+}
+
+func TestRecover_ReportsAndPanicsAgain(t *testing.T) {
+	c := &collector{}
+	shutdown := setupTest(t, c)
+	defer shutdown()
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != "boom" {
+				t.Errorf("expected the panic to continue, got %v", recovered)
+			}
+		}()
+		defer Recover(map[string]string{"origin": "www.example.com"})
+		panic("boom")
+	}()
+
+	if len(c.events) != 1 || c.events[0].Level != LevelFatal || c.events[0].Message != "boom" {
+		t.Fatalf("expected a fatal event, got %+v", c.events)
+	}
+	if stack, _ := c.events[0].Extra["stack"].(string); !strings.Contains(stack, "TestRecover_ReportsAndPanicsAgain") {
+		t.Errorf("expected the stack of the panic, got %q", stack)
+	}
+}
+
+func TestParseDSN(t *testing.T) {
+	tests := []struct {
+		dsn          string
+		wantEndpoint string
+		wantErr      bool
+	}{
+		{dsn: "https://public@o1.ingest.sentry.io/2", wantEndpoint: "https://o1.ingest.sentry.io/api/2/envelope/"},
+		{dsn: "https://public@sentry.example.com/prefix/7/", wantEndpoint: "https://sentry.example.com/prefix/api/7/envelope/"},
+		{dsn: "https://sentry.example.com/2", wantErr: true},
+		{dsn: "https://public@sentry.example.com", wantErr: true},
+		{dsn: "ftp://public@sentry.example.com/2", wantErr: true},
+	}
+	for _, tt := range tests {
+		endpoint, key, err := parseDSN(tt.dsn)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidDSN) {
+				t.Errorf("parseDSN(%q): expected ErrInvalidDSN, got %v", tt.dsn, err)
+			}
+			continue
+		}
+		if err != nil || endpoint != tt.wantEndpoint || key != "public" {
+			t.Errorf("parseDSN(%q) = %q, %q, %v; want %q", tt.dsn, endpoint, key, err, tt.wantEndpoint)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package servertls loads the certificates of the HTTPS listeners of the
+// daemon, such as the status and admin APIs, and of the clients calling
+// them.
+package servertls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Config is the TLS configuration of a server.
+type Config struct {
+	// CertFile and KeyFile are the PEM certificate and key of the server.
+	CertFile string
+	KeyFile  string
+	// ClientCAFile is a PEM bundle of the CAs that sign client certificates.
+	// When set, a certificate a client presents must be signed by one of
+	// them; see Verified.
+	ClientCAFile string
+}
+
+// Load returns the TLS configuration of cfg. The certificate is loaded again
+// when its files change, so that renewed certificates take effect without a
+// restart.
+func Load(cfg Config) (*tls.Config, error) {
+	keyPair := &keyPair{certFile: cfg.CertFile, keyFile: cfg.KeyFile}
+	if _, err := keyPair.certificate(); err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return keyPair.certificate()
+		},
+	}
+	if cfg.ClientCAFile != "" {
+		pool, err := loadPool(cfg.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = pool
+		// The handlers decide which paths need a certificate, so that health
+		// probes without one still get an answer
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, nil
+}
+
+// ClientConfig returns the TLS configuration of a client that trusts the CAs
+// in caFile besides the system roots, and presents the certificate in
+// certFile and keyFile. Every argument is optional.
+func ClientConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the CA file: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in the CA file %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// Serve serves server on listener, over TLS with tlsConfig unless it is nil.
+func Serve(server *http.Server, listener net.Listener, tlsConfig *tls.Config) error {
+	if tlsConfig == nil {
+		return server.Serve(listener)
+	}
+	server.TLSConfig = tlsConfig
+	return server.ServeTLS(listener, "", "")
+}
+
+// Verified reports whether r came with a client certificate signed by the
+// client CAs of the server.
+func Verified(r *http.Request) bool {
+	return r.TLS != nil && len(r.TLS.VerifiedChains) > 0
+}
+
+func loadPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the client CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates in the client CA file %s", file)
+	}
+	return pool, nil
+}
+
+// keyPair caches a certificate until its files are modified.
+type keyPair struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	modified time.Time
+}
+
+// certificate returns the certificate, loading it again when either file was
+// modified since it was loaded. A certificate that fails to load keeps the
+// previous one in use.
+func (p *keyPair) certificate() (*tls.Certificate, error) {
+	modified, err := latestModification(p.certFile, p.keyFile)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cert != nil && (err != nil || !modified.After(p.modified)) {
+		return p.cert, nil
+	}
+	cert, loadErr := tls.LoadX509KeyPair(p.certFile, p.keyFile)
+	if loadErr != nil {
+		if p.cert != nil {
+			return p.cert, nil
+		}
+		return nil, fmt.Errorf("failed to load the server certificate: %w", loadErr)
+	}
+	p.cert, p.modified = &cert, modified
+	return p.cert, nil
+}
+
+func latestModification(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,137 @@
+package servertls
+
+import (
+	"crypto/tls"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/servertls/tlstest"
+)
+
+func TestLoad_MutualTLS(t *testing.T) {
+	files := tlstest.Write(t, t.TempDir())
+	serverConfig, err := Load(Config{CertFile: files.ServerCert, KeyFile: files.ServerKey, ClientCAFile: files.CA})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !Verified(r) {
+				http.Error(w, "no client certificate", http.StatusUnauthorized)
+			}
+		}),
+		TLSConfig: serverConfig,
+		ErrorLog:  log.New(io.Discard, "", 0),
+	}
+	go server.ServeTLS(listener, "", "")
+	defer server.Close()
+
+	get := func(tlsConfig *tls.Config) (int, error) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		resp, err := client.Get("https://" + listener.Addr().String())
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	withCert, err := ClientConfig(files.CA, files.ClientCert, files.ClientKey)
+	if err != nil {
+		t.Fatalf("ClientConfig() error = %v", err)
+	}
+	if code, err := get(withCert); err != nil || code != http.StatusOK {
+		t.Errorf("with a client certificate: got %d, %v", code, err)
+	}
+	withoutCert, err := ClientConfig(files.CA, "", "")
+	if err != nil {
+		t.Fatalf("ClientConfig() error = %v", err)
+	}
+	if code, err := get(withoutCert); err != nil || code != http.StatusUnauthorized {
+		t.Errorf("without a client certificate: got %d, %v", code, err)
+	}
+
+	// A certificate of another CA is refused in the handshake
+	other := tlstest.Write(t, t.TempDir())
+	foreign, err := ClientConfig(files.CA, other.ClientCert, other.ClientKey)
+	if err != nil {
+		t.Fatalf("ClientConfig() error = %v", err)
+	}
+	if _, err := get(foreign); err == nil {
+		t.Error("expected the handshake to fail with a certificate of another CA")
+	}
+	// And so is a server that is not signed by a trusted CA
+	if _, err := get(&tls.Config{MinVersion: tls.VersionTLS12}); err == nil {
+		t.Error("expected the client to refuse the server certificate")
+	}
+}
+
+func TestLoad_ReloadsRenewedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	files := tlstest.Write(t, dir)
+	tlsConfig, err := Load(Config{CertFile: files.ServerCert, KeyFile: files.ServerKey})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	first, err := tlsConfig.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Renew the certificate in place
+	renewed := tlstest.Write(t, t.TempDir())
+	for src, dst := range map[string]string{renewed.ServerCert: files.ServerCert, renewed.ServerKey: files.ServerKey} {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(dst, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		later := time.Now().Add(time.Minute)
+		if err := os.Chtimes(dst, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	second, err := tlsConfig.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first.Certificate[0]) == string(second.Certificate[0]) {
+		t.Error("expected the renewed certificate to be served")
+	}
+
+	// A broken file keeps the last good certificate
+	if err := os.WriteFile(files.ServerKey, []byte("broken"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(2 * time.Minute)
+	if err := os.Chtimes(files.ServerKey, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if third, err := tlsConfig.GetCertificate(nil); err != nil || string(third.Certificate[0]) != string(second.Certificate[0]) {
+		t.Errorf("expected the last good certificate, got %v", err)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	files := tlstest.Write(t, t.TempDir())
+	if _, err := Load(Config{CertFile: files.ServerCert, KeyFile: files.ClientKey}); err == nil {
+		t.Error("expected an error for a key of another certificate")
+	}
+	if _, err := Load(Config{CertFile: files.ServerCert, KeyFile: files.ServerKey, ClientCAFile: files.ServerKey}); err == nil {
+		t.Error("expected an error for a client CA file without certificates")
+	}
+	if _, err := ClientConfig(files.ServerKey, "", ""); err == nil {
+		t.Error("expected an error for a CA file without certificates")
+	}
+}
@@ -0,0 +1,232 @@
+// Package slackactions serves the callback of the buttons on Slack messages,
+// which let on-call engineers hold an origin to pause its automatic DNS
+// changes and release it again.
+package slackactions
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+// Path is where Slack sends the clicks, the request URL of the Slack app.
+const Path = "/slack/actions"
+
+// maxRequestAge is how old a signed request may be, against replays.
+const maxRequestAge = 5 * time.Minute
+
+// maxBodySize limits the request body, which Slack keeps well below it.
+const maxBodySize = 1 << 20
+
+// Controller holds and releases origins, normally a *gslb.Service.
+type Controller interface {
+	HoldOrigin(ctx context.Context, zone, name, recordType, by string) error
+	ReleaseOrigin(ctx context.Context, zone, name, recordType, by string) error
+}
+
+// Server verifies the requests of Slack and applies the clicked buttons to
+// the current controller. The controller can be replaced while serving, e.g.
+// when the configuration is reloaded.
+type Server struct {
+	mu         sync.RWMutex
+	controller Controller
+
+	signingSecret []byte
+	allowedUsers  []string
+	httpClient    *http.Client
+	now           func() time.Time
+
+	server *http.Server
+}
+
+// NewServer returns a server that accepts the requests signed with
+// signingSecret from the users in allowedUsers, or from anyone if it is empty.
+func NewServer(signingSecret string, allowedUsers []string) *Server {
+	mux := http.NewServeMux()
+	s := &Server{
+		signingSecret: []byte(signingSecret),
+		allowedUsers:  allowedUsers,
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+		now:           time.Now,
+		server:        &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second},
+	}
+	mux.HandleFunc(Path, s.handleActions)
+	return s
+}
+
+// SetController replaces the controller the buttons are applied to.
+func (s *Server) SetController(controller Controller) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.controller = controller
+}
+
+// Handler returns the HTTP handler of the callback.
+func (s *Server) Handler() http.Handler {
+	return s.server.Handler
+}
+
+// ListenAndServe listens on addr and serves the callback in the background.
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Slack actions callback stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Shutdown stops the server, waiting for in-flight requests until ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+func (s *Server) currentController() Controller {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.controller
+}
+
+// interactionPayload is the part of a block_actions payload the server uses.
+type interactionPayload struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+	ResponseURL string `json:"response_url"`
+}
+
+func (s *Server) handleActions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "failed to read the request", http.StatusBadRequest)
+		return
+	}
+	if err := s.verify(r.Header, body); err != nil {
+		log.Printf("Rejected Slack action: %v", err)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	var payload interactionPayload
+	if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	// Slack only needs a 200 within 3 seconds; the outcome is posted to the
+	// channel by the held and released notifications, or to the user
+	w.WriteHeader(http.StatusOK)
+	if payload.Type != "block_actions" {
+		return
+	}
+
+	for _, action := range payload.Actions {
+		if action.ActionID != notifier.SlackActionHold && action.ActionID != notifier.SlackActionRelease {
+			continue
+		}
+		if err := s.apply(r.Context(), payload, action.ActionID, action.Value); err != nil {
+			log.Printf("Slack action %s by %s failed: %v", action.ActionID, payload.User.ID, err)
+			go s.respond(payload.ResponseURL, err.Error())
+		}
+	}
+}
+
+// apply holds or releases the origin named by value on behalf of the user
+// who clicked the button.
+func (s *Server) apply(ctx context.Context, payload interactionPayload, actionID, value string) error {
+	if len(s.allowedUsers) > 0 && !slices.Contains(s.allowedUsers, payload.User.ID) {
+		return fmt.Errorf("you are not allowed to hold or release origins")
+	}
+	var origin notifier.SlackActionOrigin
+	if err := json.Unmarshal([]byte(value), &origin); err != nil {
+		return fmt.Errorf("invalid button value: %w", err)
+	}
+	controller := s.currentController()
+	if controller == nil {
+		return fmt.Errorf("the service is not running")
+	}
+	by := payload.User.Username
+	if by == "" {
+		by = payload.User.ID
+	}
+	by += " (Slack)"
+	if actionID == notifier.SlackActionHold {
+		return controller.HoldOrigin(ctx, origin.Zone, origin.Name, origin.RecordType, by)
+	}
+	return controller.ReleaseOrigin(ctx, origin.Zone, origin.Name, origin.RecordType, by)
+}
+
+// verify checks the signature Slack computes over the timestamp and body
+// with the signing secret, and that the request is recent.
+func (s *Server) verify(header http.Header, body []byte) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	if age := s.now().Sub(time.Unix(seconds, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return fmt.Errorf("request is %s old", age.Round(time.Second))
+	}
+	mac := hmac.New(sha256.New, s.signingSecret)
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// respond shows text to the user who clicked, through the response URL of
+// the interaction.
+func (s *Server) respond(responseURL, text string) {
+	if responseURL == "" {
+		return
+	}
+	payload, _ := json.Marshal(map[string]any{
+		"response_type":    "ephemeral",
+		"replace_original": false,
+		"text":             text,
+	})
+	resp, err := s.httpClient.Post(responseURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("Failed to respond to the Slack action: %v", err)
+		return
+	}
+	resp.Body.Close()
+}
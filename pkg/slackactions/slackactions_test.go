@@ -0,0 +1,151 @@
+package slackactions
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+const testSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+type fakeController struct {
+	mu    sync.Mutex
+	calls []string
+	err   error
+}
+
+func (c *fakeController) HoldOrigin(ctx context.Context, zone, name, recordType, by string) error {
+	return c.record("hold", zone, name, recordType, by)
+}
+
+func (c *fakeController) ReleaseOrigin(ctx context.Context, zone, name, recordType, by string) error {
+	return c.record("release", zone, name, recordType, by)
+}
+
+func (c *fakeController) record(action, zone, name, recordType, by string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, fmt.Sprintf("%s %s/%s/%s by %s", action, zone, name, recordType, by))
+	return c.err
+}
+
+// actionRequest builds a block_actions request for actionID, signed with
+// secret at timestamp.
+func actionRequest(t *testing.T, secret, userID, actionID, responseURL string, timestamp time.Time) *http.Request {
+	t.Helper()
+	value, _ := json.Marshal(notifier.SlackActionOrigin{Zone: "example.com", Name: "www.example.com", RecordType: "A"})
+	payload, _ := json.Marshal(map[string]any{
+		"type":         "block_actions",
+		"user":         map[string]string{"id": userID, "username": "alice"},
+		"actions":      []map[string]string{{"action_id": actionID, "value": string(value)}},
+		"response_url": responseURL,
+	})
+	body := url.Values{"payload": {string(payload)}}.Encode()
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", ts, body)
+
+	req := httptest.NewRequest(http.MethodPost, Path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestServer_HoldAndRelease(t *testing.T) {
+	server := NewServer(testSecret, nil)
+	controller := &fakeController{}
+	server.SetController(controller)
+
+	for _, actionID := range []string{notifier.SlackActionHold, notifier.SlackActionRelease} {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, actionRequest(t, testSecret, "U123", actionID, "", time.Now()))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", actionID, rec.Code)
+		}
+	}
+
+	expected := []string{
+		"hold example.com/www.example.com/A by alice (Slack)",
+		"release example.com/www.example.com/A by alice (Slack)",
+	}
+	if strings.Join(controller.calls, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected calls %v", controller.calls)
+	}
+}
+
+func TestServer_RejectsUnverifiedRequests(t *testing.T) {
+	server := NewServer(testSecret, nil)
+	controller := &fakeController{}
+	server.SetController(controller)
+
+	tests := map[string]*http.Request{
+		"wrong secret":    actionRequest(t, "another-secret", "U123", notifier.SlackActionHold, "", time.Now()),
+		"stale timestamp": actionRequest(t, testSecret, "U123", notifier.SlackActionHold, "", time.Now().Add(-10*time.Minute)),
+	}
+	for name, req := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("expected 401, got %d", rec.Code)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", rec.Code)
+	}
+	if len(controller.calls) != 0 {
+		t.Errorf("expected no calls, got %v", controller.calls)
+	}
+}
+
+func TestServer_RespondsToDisallowedUsers(t *testing.T) {
+	responses := make(chan map[string]any, 1)
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+			t.Errorf("Failed to decode the response: %v", err)
+		}
+		responses <- response
+	}))
+	defer responder.Close()
+
+	server := NewServer(testSecret, []string{"U999"})
+	controller := &fakeController{}
+	server.SetController(controller)
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, actionRequest(t, testSecret, "U123", notifier.SlackActionHold, responder.URL, time.Now()))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	select {
+	case response := <-responses:
+		if response["response_type"] != "ephemeral" || !strings.Contains(fmt.Sprint(response["text"]), "not allowed") {
+			t.Errorf("unexpected response %v", response)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the ephemeral response")
+	}
+	if len(controller.calls) != 0 {
+		t.Errorf("expected no calls, got %v", controller.calls)
+	}
+}
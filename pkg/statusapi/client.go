@@ -0,0 +1,99 @@
+package statusapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/listenaddr"
+)
+
+// DialAddress returns the address to reach a server listening on listen
+// from the same host: an unspecified host such as ":8080" or "0.0.0.0:8080"
+// is replaced with the loopback address. A unix socket is returned as is.
+func DialAddress(listen string) (string, error) {
+	if _, ok := listenaddr.SocketPath(listen); ok {
+		return listen, nil
+	}
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "", err
+	}
+	switch host {
+	case "", "0.0.0.0":
+		host = "127.0.0.1"
+	case "::":
+		host = "::1"
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// NewHTTPClient returns a client for a server that requires token as the
+// bearer token, unless it is empty, sending the requests with transport,
+// such as one of listenaddr.Transport, or http.DefaultTransport if it is nil.
+func NewHTTPClient(token string, transport http.RoundTripper) *http.Client {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if token == "" {
+		return &http.Client{Transport: transport}
+	}
+	return &http.Client{Transport: bearerTransport{token: token, next: transport}}
+}
+
+// bearerTransport adds "Authorization: Bearer <token>" to every request.
+type bearerTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (t bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.next.RoundTrip(req)
+}
+
+// FetchOrigins returns the origin reports of the server at baseURL, e.g.
+// http://127.0.0.1:8080.
+func FetchOrigins(ctx context.Context, client *http.Client, baseURL string) ([]gslb.OriginReport, error) {
+	var response originsResponse
+	if err := fetch(ctx, client, baseURL+OriginsPath, &response); err != nil {
+		return nil, err
+	}
+	return response.Origins, nil
+}
+
+// FetchNotifications returns the notification reports of the server at
+// baseURL.
+func FetchNotifications(ctx context.Context, client *http.Client, baseURL string) ([]gslb.NotificationReport, error) {
+	var response notificationsResponse
+	if err := fetch(ctx, client, baseURL+NotificationsPath, &response); err != nil {
+		return nil, err
+	}
+	return response.Notifications, nil
+}
+
+// fetch decodes the JSON body of GET url into response.
+func fetch(ctx context.Context, client *http.Client, url string, response any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status API returned %s: %s", resp.Status, body)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("failed to decode status API response: %w", err)
+	}
+	return nil
+}
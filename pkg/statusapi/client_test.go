@@ -0,0 +1,89 @@
+package statusapi
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+)
+
+func TestFetchOrigins(t *testing.T) {
+	source := staticSource{{Name: "www.example.com", Zone: "example.com", RecordType: "A", Health: gslb.HealthHealthy, CurrentIPs: []string{"192.0.2.1"}}}
+	server := httptest.NewServer(NewServer(source).Handler())
+	defer server.Close()
+
+	origins, err := FetchOrigins(context.Background(), server.Client(), server.URL)
+	if err != nil {
+		t.Fatalf("FetchOrigins() error = %v", err)
+	}
+	if len(origins) != 1 || origins[0].Name != "www.example.com" || origins[0].CurrentIPs[0] != "192.0.2.1" {
+		t.Errorf("Unexpected origins %+v", origins)
+	}
+
+	// A debug-only listener has no status endpoints
+	debug := httptest.NewServer(NewDebugServer("").Handler())
+	defer debug.Close()
+	if _, err := FetchOrigins(context.Background(), debug.Client(), debug.URL); err == nil {
+		t.Error("Expected an error for a server without the status endpoints")
+	}
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	api := NewServer(staticSource{{Name: "www.example.com"}})
+	api.RequireToken("reader")
+	server := httptest.NewServer(api.Handler())
+	defer server.Close()
+
+	if _, err := FetchOrigins(context.Background(), NewHTTPClient("", nil), server.URL); err == nil {
+		t.Error("Expected an error without the token")
+	}
+	origins, err := FetchOrigins(context.Background(), NewHTTPClient("reader", nil), server.URL)
+	if err != nil || len(origins) != 1 {
+		t.Errorf("FetchOrigins() = %+v, %v", origins, err)
+	}
+}
+
+func TestFetchNotifications(t *testing.T) {
+	source := notificationSource{notifications: []gslb.NotificationReport{
+		{Name: "oncall", Type: "slack", Status: gslb.NotificationFailing, Failed: 3, ConsecutiveFailures: 3, LastError: "slack webhook returned status: 500"},
+		{Name: "notifications[1]", Type: "ntfy", Status: gslb.NotificationIdle},
+	}}
+	server := httptest.NewServer(NewServer(source).Handler())
+	defer server.Close()
+
+	notifications, err := FetchNotifications(context.Background(), server.Client(), server.URL)
+	if err != nil {
+		t.Fatalf("FetchNotifications() error = %v", err)
+	}
+	if len(notifications) != 2 || notifications[0].Status != gslb.NotificationFailing || notifications[0].LastError == "" {
+		t.Errorf("Unexpected notifications %+v", notifications)
+	}
+
+	// Without a source the list is empty rather than null
+	empty := httptest.NewServer(NewServer(nil).Handler())
+	defer empty.Close()
+	if notifications, err := FetchNotifications(context.Background(), empty.Client(), empty.URL); err != nil || notifications == nil || len(notifications) != 0 {
+		t.Errorf("FetchNotifications() = %v, %v, want an empty list", notifications, err)
+	}
+}
+
+func TestDialAddress(t *testing.T) {
+	tests := map[string]string{
+		":8080":                      "127.0.0.1:8080",
+		"0.0.0.0:8080":               "127.0.0.1:8080",
+		"[::]:8080":                  "[::1]:8080",
+		"10.0.0.5:8080":              "10.0.0.5:8080",
+		"localhost:9090":             "localhost:9090",
+		"unix:/run/gslb/status.sock": "unix:/run/gslb/status.sock",
+	}
+	for listen, want := range tests {
+		got, err := DialAddress(listen)
+		if err != nil || got != want {
+			t.Errorf("DialAddress(%q) = %q, %v, want %q", listen, got, err, want)
+		}
+	}
+	if _, err := DialAddress("8080"); err == nil {
+		t.Error("Expected an error for an address without a port")
+	}
+}
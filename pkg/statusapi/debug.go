@@ -0,0 +1,122 @@
+package statusapi
+
+import (
+	"crypto/subtle"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Debug endpoints, served only when enabled.
+const (
+	// PprofPath serves the net/http/pprof profiles, e.g. /debug/pprof/heap.
+	PprofPath = "/debug/pprof/"
+	// RuntimePath returns goroutine and memory statistics as JSON.
+	RuntimePath = "/debug/runtime"
+	// VarsPath serves the variables published with the expvar package.
+	VarsPath = "/debug/vars"
+)
+
+// started is when the process started, approximately.
+var started = time.Now()
+
+// runtimeResponse is the body of GET /debug/runtime.
+type runtimeResponse struct {
+	GoVersion     string  `json:"go_version"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+	Goroutines    int     `json:"goroutines"`
+	GOMAXPROCS    int     `json:"gomaxprocs"`
+	NumCPU        int     `json:"num_cpu"`
+	Memory        memory  `json:"memory"`
+}
+
+// memory is a subset of runtime.MemStats, in bytes unless noted.
+type memory struct {
+	// Sys is the memory obtained from the OS, which bounds the RSS of the Go heap and stacks.
+	Sys          uint64 `json:"sys"`
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapIdle     uint64 `json:"heap_idle"`
+	HeapReleased uint64 `json:"heap_released"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	StackInuse   uint64 `json:"stack_inuse"`
+	TotalAlloc   uint64 `json:"total_alloc"`
+	NumGC        uint32 `json:"num_gc"`
+	// PauseTotalSeconds is the total time the world was stopped for GC.
+	PauseTotalSeconds float64 `json:"gc_pause_total_seconds"`
+	NextGC            uint64  `json:"next_gc"`
+}
+
+// NewDebugServer returns a server with only the debug endpoints, for serving
+// them on a listener of their own.
+func NewDebugServer(token string) *Server {
+	s := newServer()
+	s.EnableDebug(token)
+	return s
+}
+
+// EnableDebug adds the pprof, runtime and expvar endpoints. When token is set, they
+// require it as a bearer token, since profiles reveal the memory of the
+// process. It must be called before serving.
+func (s *Server) EnableDebug(token string) {
+	s.mux.Handle(PprofPath, s.requireClientCert(requireToken(token, http.HandlerFunc(pprof.Index))))
+	s.mux.Handle(PprofPath+"cmdline", s.requireClientCert(requireToken(token, http.HandlerFunc(pprof.Cmdline))))
+	s.mux.Handle(PprofPath+"profile", s.requireClientCert(requireToken(token, http.HandlerFunc(pprof.Profile))))
+	s.mux.Handle(PprofPath+"symbol", s.requireClientCert(requireToken(token, http.HandlerFunc(pprof.Symbol))))
+	s.mux.Handle(PprofPath+"trace", s.requireClientCert(requireToken(token, http.HandlerFunc(pprof.Trace))))
+	s.mux.Handle(RuntimePath, s.requireClientCert(requireToken(token, http.HandlerFunc(handleRuntime))))
+	s.mux.Handle(VarsPath, s.requireClientCert(requireToken(token, expvar.Handler())))
+}
+
+// requireToken rejects requests without "Authorization: Bearer <token>",
+// unless token is empty.
+func requireToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasToken(r, token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="debug"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasToken reports whether r has "Authorization: Bearer <token>".
+func hasToken(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+func handleRuntime(w http.ResponseWriter, r *http.Request) {
+	if !allowRead(w, r) {
+		return
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	writeJSON(w, runtimeResponse{
+		GoVersion:     runtime.Version(),
+		UptimeSeconds: time.Since(started).Seconds(),
+		Goroutines:    runtime.NumGoroutine(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		NumCPU:        runtime.NumCPU(),
+		Memory: memory{
+			Sys:               stats.Sys,
+			HeapAlloc:         stats.HeapAlloc,
+			HeapInuse:         stats.HeapInuse,
+			HeapIdle:          stats.HeapIdle,
+			HeapReleased:      stats.HeapReleased,
+			HeapObjects:       stats.HeapObjects,
+			StackInuse:        stats.StackInuse,
+			TotalAlloc:        stats.TotalAlloc,
+			NumGC:             stats.NumGC,
+			PauseTotalSeconds: time.Duration(stats.PauseTotalNs).Seconds(),
+			NextGC:            stats.NextGC,
+		},
+	})
+}
@@ -0,0 +1,78 @@
+package statusapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEnableDebug(t *testing.T) {
+	server := NewServer(staticSource{})
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, RuntimePath, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("Expected no debug endpoints by default, got %d", rec.Code)
+	}
+
+	server.EnableDebug("")
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, RuntimePath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var response runtimeResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Goroutines == 0 || response.Memory.Sys == 0 || response.GoVersion == "" {
+		t.Errorf("Unexpected runtime stats %+v", response)
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, PprofPath+"goroutine?debug=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("goroutine profile status = %d, want 200", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, VarsPath, nil))
+	var vars map[string]json.RawMessage
+	if err := json.NewDecoder(rec.Body).Decode(&vars); err != nil || vars["memstats"] == nil {
+		t.Errorf("Expected the expvar variables, got %d (%v)", rec.Code, err)
+	}
+
+	// The status endpoints are still served
+	getOrigins(t, server.Handler())
+}
+
+func TestNewDebugServer_RequiresToken(t *testing.T) {
+	handler := NewDebugServer("secret").Handler()
+
+	for _, path := range []string{RuntimePath, VarsPath, PprofPath, PprofPath + "heap"} {
+		for header, want := range map[string]int{
+			"":              http.StatusUnauthorized,
+			"Bearer wrong":  http.StatusUnauthorized,
+			"secret":        http.StatusUnauthorized,
+			"Bearer secret": http.StatusOK,
+		} {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			if header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != want {
+				t.Errorf("%s with %q: status = %d, want %d", path, header, rec.Code, want)
+			}
+		}
+	}
+
+	// Only the debug endpoints are served
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, OriginsPath, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected no status endpoints, got %d", rec.Code)
+	}
+}
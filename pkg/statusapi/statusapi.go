@@ -0,0 +1,335 @@
+// Package statusapi serves the status of every origin as a read-only JSON
+// API, along with liveness and readiness probes of the daemon. The API can
+// require a bearer token and a client certificate; the probes never do, so
+// that orchestrators can reach them.
+package statusapi
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/history"
+	"github.com/bootjp/cloudflare-gslb/pkg/servertls"
+)
+
+// Endpoints of the API.
+const (
+	// OriginsPath returns the status of every origin.
+	OriginsPath = "/api/v1/origins"
+	// EventsPath returns the recorded event history.
+	EventsPath = "/api/v1/events"
+	// NotificationsPath returns the delivery status of every notification.
+	NotificationsPath = "/api/v1/notifications"
+	// LivenessPath answers as long as the process is up.
+	LivenessPath = "/healthz"
+	// ReadinessPath answers 200 only while the service is monitoring every origin.
+	ReadinessPath = "/readyz"
+)
+
+// DefaultEventLimit is the number of events returned when limit is omitted.
+const DefaultEventLimit = 1000
+
+// Source provides the data served by the API, normally a *gslb.Service.
+type Source interface {
+	OriginReports() []gslb.OriginReport
+	// Events returns gslb.ErrEventHistoryDisabled if no history is kept.
+	Events(filter history.Filter) ([]history.Event, error)
+	Readiness() []gslb.ReadinessCheck
+	NotificationReports() []gslb.NotificationReport
+}
+
+// Server serves the reports of the current source. The source can be
+// replaced while serving, e.g. when the configuration is reloaded.
+type Server struct {
+	mu     sync.RWMutex
+	source Source
+
+	token      string
+	clientCert bool
+	feed       *gslb.EventFeed
+
+	stopping chan struct{}
+	stopOnce sync.Once
+	mux      *http.ServeMux
+	server   *http.Server
+}
+
+// originsResponse is the body of GET /api/v1/origins.
+type originsResponse struct {
+	Origins []gslb.OriginReport `json:"origins"`
+}
+
+// eventsResponse is the body of GET /api/v1/events.
+type eventsResponse struct {
+	Events []history.Event `json:"events"`
+}
+
+// notificationsResponse is the body of GET /api/v1/notifications.
+type notificationsResponse struct {
+	Notifications []gslb.NotificationReport `json:"notifications"`
+}
+
+// readinessResponse is the body of GET /readyz.
+type readinessResponse struct {
+	Status string                `json:"status"`
+	Checks []gslb.ReadinessCheck `json:"checks"`
+}
+
+// NewServer returns a server for source.
+func NewServer(source Source) *Server {
+	s := newServer()
+	s.source = source
+	s.mux.Handle(OriginsPath, s.protect(s.handleOrigins))
+	s.mux.Handle(EventsPath, s.protect(s.handleEvents))
+	s.mux.Handle(StreamPath, s.protect(s.handleStream))
+	s.mux.Handle(NotificationsPath, s.protect(s.handleNotifications))
+	s.mux.HandleFunc(LivenessPath, s.handleLiveness)
+	s.mux.HandleFunc(ReadinessPath, s.handleReadiness)
+	return s
+}
+
+func newServer() *Server {
+	mux := http.NewServeMux()
+	return &Server{
+		stopping: make(chan struct{}),
+		mux:      mux,
+		server:   &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second},
+	}
+}
+
+// RequireToken makes the API endpoints accept only requests with token as
+// their bearer token. It must be called before serving.
+func (s *Server) RequireToken(token string) {
+	s.token = token
+}
+
+// RequireClientCert makes the API and debug endpoints accept only requests
+// with a verified client certificate. It must be called before serving.
+func (s *Server) RequireClientCert() {
+	s.clientCert = true
+}
+
+// SetSource replaces the source of the reports.
+func (s *Server) SetSource(source Source) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.source = source
+}
+
+// Handler returns the HTTP handler of the API.
+func (s *Server) Handler() http.Handler {
+	return s.server.Handler
+}
+
+// ListenAndServe listens on addr and serves the API in the background, over
+// TLS with tlsConfig unless it is nil.
+func (s *Server) ListenAndServe(addr string, tlsConfig *tls.Config) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.Serve(listener, tlsConfig)
+	return nil
+}
+
+// Serve serves the API on listener in the background, like ListenAndServe.
+// It can be called for several listeners, such as a TCP port and a unix
+// socket.
+func (s *Server) Serve(listener net.Listener, tlsConfig *tls.Config) {
+	go func() {
+		if err := servertls.Serve(s.server, listener, tlsConfig); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Status API stopped: %v", err)
+		}
+	}()
+}
+
+// Shutdown ends the event streams and stops the server, waiting for
+// in-flight requests until ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stopping) })
+	return s.server.Shutdown(ctx)
+}
+
+func (s *Server) currentSource() Source {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.source
+}
+
+// protect applies the token and the client certificate required with
+// RequireToken and RequireClientCert to next.
+func (s *Server) protect(next http.HandlerFunc) http.Handler {
+	return s.requireClientCert(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" && !hasToken(r, s.token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="status"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}))
+}
+
+// requireClientCert rejects requests without a verified client certificate
+// once RequireClientCert was called.
+func (s *Server) requireClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.clientCert && !servertls.Verified(r) {
+			http.Error(w, "a client certificate is required", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowRead rejects every method but GET and HEAD.
+func allowRead(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+	w.Header().Set("Allow", "GET, HEAD")
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	writeJSONStatus(w, http.StatusOK, body)
+}
+
+func writeJSONStatus(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Failed to write status response: %v", err)
+	}
+}
+
+func (s *Server) handleOrigins(w http.ResponseWriter, r *http.Request) {
+	if !allowRead(w, r) {
+		return
+	}
+
+	response := originsResponse{Origins: []gslb.OriginReport{}}
+	if source := s.currentSource(); source != nil {
+		response.Origins = append(response.Origins, source.OriginReports()...)
+	}
+	writeJSON(w, response)
+}
+
+func (s *Server) handleNotifications(w http.ResponseWriter, r *http.Request) {
+	if !allowRead(w, r) {
+		return
+	}
+
+	response := notificationsResponse{Notifications: []gslb.NotificationReport{}}
+	if source := s.currentSource(); source != nil {
+		response.Notifications = append(response.Notifications, source.NotificationReports()...)
+	}
+	writeJSON(w, response)
+}
+
+func (s *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	if !allowRead(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// handleReadiness answers 503 until the service is monitoring every origin,
+// e.g. while the configuration is being reloaded.
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	if !allowRead(w, r) {
+		return
+	}
+	response := readinessResponse{Status: "not_ready", Checks: []gslb.ReadinessCheck{}}
+	if source := s.currentSource(); source != nil {
+		response.Checks = source.Readiness()
+	} else {
+		response.Checks = append(response.Checks, gslb.ReadinessCheck{Name: gslb.ReadinessConfig, Detail: "no service is running"})
+	}
+	code := http.StatusServiceUnavailable
+	if gslb.Ready(response.Checks) {
+		response.Status = "ready"
+		code = http.StatusOK
+	}
+	writeJSONStatus(w, code, response)
+}
+
+// handleEvents serves the events selected by the origin, type, since, until
+// and limit query parameters. since and until are RFC 3339 times or
+// durations before now, such as 24h.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !allowRead(w, r) {
+		return
+	}
+	filter, err := ParseEventFilter(r.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	source := s.currentSource()
+	if source == nil {
+		writeJSON(w, eventsResponse{Events: []history.Event{}})
+		return
+	}
+	events, err := source.Events(filter)
+	if errors.Is(err, gslb.ErrEventHistoryDisabled) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to query event history: %v", err)
+		http.Error(w, "failed to query event history", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, eventsResponse{Events: append([]history.Event{}, events...)})
+}
+
+// ParseEventFilter returns the filter of the origin, type, since, until and
+// limit query parameters of an events request.
+func ParseEventFilter(query url.Values, now time.Time) (history.Filter, error) {
+	filter := history.Filter{
+		Origin: query.Get("origin"),
+		Type:   query.Get("type"),
+		Limit:  DefaultEventLimit,
+	}
+	var err error
+	if filter.Since, err = ParseTime(query.Get("since"), now); err != nil {
+		return filter, fmt.Errorf("invalid since: %w", err)
+	}
+	if filter.Until, err = ParseTime(query.Get("until"), now); err != nil {
+		return filter, fmt.Errorf("invalid until: %w", err)
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit < 0 {
+			return filter, fmt.Errorf("invalid limit %q", limit)
+		}
+	}
+	return filter, nil
+}
+
+// ParseTime parses an RFC 3339 time or a duration before now, such as 24h.
+// An empty value is the zero time.
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,263 @@
+package statusapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/history"
+)
+
+type staticSource []gslb.OriginReport
+
+func (s staticSource) OriginReports() []gslb.OriginReport {
+	return s
+}
+
+func (s staticSource) Events(filter history.Filter) ([]history.Event, error) {
+	return nil, gslb.ErrEventHistoryDisabled
+}
+
+func (s staticSource) Readiness() []gslb.ReadinessCheck {
+	return []gslb.ReadinessCheck{{Name: gslb.ReadinessConfig, OK: true}}
+}
+
+func (s staticSource) NotificationReports() []gslb.NotificationReport {
+	return nil
+}
+
+// readinessSource reports fixed readiness checks.
+type readinessSource struct {
+	staticSource
+	checks []gslb.ReadinessCheck
+}
+
+func (s readinessSource) Readiness() []gslb.ReadinessCheck {
+	return s.checks
+}
+
+// notificationSource reports fixed notification reports.
+type notificationSource struct {
+	staticSource
+	notifications []gslb.NotificationReport
+}
+
+func (s notificationSource) NotificationReports() []gslb.NotificationReport {
+	return s.notifications
+}
+
+// historySource serves the events of a store.
+type historySource struct {
+	staticSource
+	store *history.Store
+}
+
+func (s historySource) Events(filter history.Filter) ([]history.Event, error) {
+	return s.store.Query(filter)
+}
+
+func getOrigins(t *testing.T, handler http.Handler) originsResponse {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, OriginsPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	var response originsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return response
+}
+
+func TestOrigins_ServesCurrentSource(t *testing.T) {
+	server := NewServer(staticSource{{Name: "www.example.com", Zone: "example.com", RecordType: "A", Health: gslb.HealthHealthy, CurrentIPs: []string{"192.0.2.1"}}})
+
+	response := getOrigins(t, server.Handler())
+	if len(response.Origins) != 1 || response.Origins[0].Name != "www.example.com" || response.Origins[0].CurrentIPs[0] != "192.0.2.1" {
+		t.Fatalf("origins = %+v, want the report of www.example.com", response.Origins)
+	}
+
+	server.SetSource(staticSource{{Name: "api.example.com"}})
+	response = getOrigins(t, server.Handler())
+	if len(response.Origins) != 1 || response.Origins[0].Name != "api.example.com" {
+		t.Errorf("origins after SetSource = %+v, want the report of api.example.com", response.Origins)
+	}
+}
+
+func TestOrigins_EmptyWithoutSource(t *testing.T) {
+	response := getOrigins(t, NewServer(nil).Handler())
+	if response.Origins == nil || len(response.Origins) != 0 {
+		t.Errorf("origins = %#v, want an empty list", response.Origins)
+	}
+}
+
+func TestOrigins_RejectsWrites(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewServer(staticSource{}).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, OriginsPath, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", rec.Code)
+	}
+}
+
+func TestListenAndServe(t *testing.T) {
+	server := NewServer(staticSource{{Name: "www.example.com"}})
+	if err := server.ListenAndServe("127.0.0.1:0", nil); err != nil {
+		t.Fatalf("ListenAndServe() error = %v", err)
+	}
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+}
+
+func TestServer_RequiresTokenAndClientCert(t *testing.T) {
+	server := NewServer(staticSource{{Name: "www.example.com"}})
+	server.RequireToken("reader")
+	get := func(path, token string, state *tls.ConnectionState) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		req.TLS = state
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get(OriginsPath, "", nil); rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("without the token: expected 401 with a challenge, got %d", rec.Code)
+	}
+	if rec := get(NotificationsPath, "wrong", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("with a wrong token: expected 401, got %d", rec.Code)
+	}
+	if rec := get(OriginsPath, "reader", nil); rec.Code != http.StatusOK {
+		t.Errorf("with the token: expected 200, got %d", rec.Code)
+	}
+
+	server.RequireClientCert()
+	verified := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+	if rec := get(OriginsPath, "reader", &tls.ConnectionState{}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without a client certificate: expected 401, got %d", rec.Code)
+	}
+	if rec := get(OriginsPath, "reader", verified); rec.Code != http.StatusOK {
+		t.Errorf("with a client certificate: expected 200, got %d", rec.Code)
+	}
+	// The probes stay open for orchestrators
+	for _, path := range []string{LivenessPath, ReadinessPath} {
+		if rec := get(path, "", nil); rec.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestEvents_FiltersHistory(t *testing.T) {
+	store, err := history.Open(filepath.Join(t.TempDir(), "events.log"), 0)
+	if err != nil {
+		t.Fatalf("history.Open() error = %v", err)
+	}
+	now := time.Now()
+	for _, event := range []history.Event{
+		{Time: now.Add(-48 * time.Hour), Type: history.TypeDNSChange, Origin: "www.example.com"},
+		{Time: now.Add(-time.Hour), Type: history.TypeHealth, Origin: "www.example.com", IP: "192.0.2.1"},
+		{Time: now.Add(-time.Hour), Type: history.TypeHealth, Origin: "api.example.com", IP: "192.0.2.9"},
+	} {
+		if err := store.Record(event); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	handler := NewServer(historySource{store: store}).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, EventsPath+"?origin=www.example.com&since=24h", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var response eventsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Events) != 1 || response.Events[0].IP != "192.0.2.1" {
+		t.Errorf("events = %+v, want the health event of www.example.com", response.Events)
+	}
+
+	for _, query := range []string{"?since=yesterday", "?limit=-1"} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, EventsPath+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestEvents_NotFoundWithoutHistory(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewServer(staticSource{}).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, EventsPath, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"":                     {},
+		"90m":                  now.Add(-90 * time.Minute),
+		"2024-04-30T00:00:00Z": time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC),
+	}
+	for value, want := range tests {
+		got, err := ParseTime(value, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseTime(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewServer(nil).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, LivenessPath, nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok\n" {
+		t.Errorf("liveness = %d %q, want 200 ok", rec.Code, rec.Body)
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     Source
+		wantCode   int
+		wantStatus string
+	}{
+		{"no service", nil, http.StatusServiceUnavailable, "not_ready"},
+		{"ready", staticSource{}, http.StatusOK, "ready"},
+		{"monitors down", readinessSource{checks: []gslb.ReadinessCheck{
+			{Name: gslb.ReadinessConfig, OK: true},
+			{Name: gslb.ReadinessMonitors, OK: false, Detail: "1 of 2 origins monitored"},
+		}}, http.StatusServiceUnavailable, "not_ready"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewServer(tt.source).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			var response readinessResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Status != tt.wantStatus || len(response.Checks) == 0 {
+				t.Errorf("response = %+v, want status %s with checks", response, tt.wantStatus)
+			}
+		})
+	}
+}
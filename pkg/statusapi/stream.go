@@ -0,0 +1,117 @@
+package statusapi
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+// StreamPath streams the events of the daemon as Server-Sent Events as they
+// happen.
+const StreamPath = "/api/v1/events/stream"
+
+const (
+	// streamBuffer is how many events a stream may fall behind before it is
+	// ended
+	streamBuffer = 256
+	// keepAliveInterval is how often an idle stream sends a comment, so that
+	// proxies do not close it
+	keepAliveInterval = 30 * time.Second
+)
+
+// SetEventFeed serves the events of feed at StreamPath. It must be called
+// before serving.
+func (s *Server) SetEventFeed(feed *gslb.EventFeed) {
+	s.feed = feed
+}
+
+// handleStream sends every event of the feed selected by the zone, origin,
+// kind and min_severity query parameters as an SSE message whose data is the
+// JSON of the event. A client that falls behind receives an overflow event
+// and is disconnected, after which EventSource clients reconnect.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	if !allowRead(w, r) {
+		return
+	}
+	if s.feed == nil {
+		http.Error(w, "the event stream is not available", http.StatusNotFound)
+		return
+	}
+	route, err := parseRoute(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	sub := s.feed.Subscribe(streamBuffer)
+	defer sub.Close()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	// Keep reverse proxies such as nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprint(w, ": subscribed\n\n"); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.stopping:
+			return
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+		case event, ok := <-sub.Events():
+			if !ok {
+				if sub.Overflowed() {
+					_, _ = fmt.Fprintf(w, "event: overflow\ndata: the client fell more than %d events behind\n\n", streamBuffer)
+					flusher.Flush()
+				}
+				return
+			}
+			if !route.Matches(event) {
+				continue
+			}
+			data, marshalErr := notifier.EventJSON(event)
+			if marshalErr != nil {
+				log.Printf("Failed to encode event for the stream: %v", marshalErr)
+				continue
+			}
+			_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// parseRoute returns the route of the zone, origin, kind and min_severity
+// query parameters of a stream request, which may be repeated except for
+// min_severity.
+func parseRoute(query url.Values) (notifier.Route, error) {
+	route := notifier.Route{Zones: query["zone"], Origins: query["origin"], Kinds: query["kind"]}
+	if value := query.Get("min_severity"); value != "" {
+		severity, ok := notifier.ParseSeverity(value)
+		if !ok {
+			return route, fmt.Errorf("invalid min_severity %q", value)
+		}
+		route.MinSeverity = severity
+	}
+	return route, nil
+}
@@ -0,0 +1,83 @@
+package statusapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/gslb"
+	"github.com/bootjp/cloudflare-gslb/pkg/notifier"
+)
+
+func TestServer_Stream(t *testing.T) {
+	feed := gslb.NewEventFeed()
+	server := NewServer(staticSource{})
+	server.SetEventFeed(feed)
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + StreamPath + "?zone=example.com&min_severity=warning")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	lines := bufio.NewScanner(resp.Body)
+	if !lines.Scan() || lines.Text() != ": subscribed" {
+		t.Fatalf("Expected the subscription comment first, got %q", lines.Text())
+	}
+
+	// Only the failover of example.com is selected
+	feed.Publish(notifier.FailoverEvent{OriginName: "www", ZoneName: "example.org", IsFailoverIP: true, Timestamp: time.Now()})
+	feed.Publish(notifier.FailoverEvent{OriginName: "www", ZoneName: "example.com", IsPriorityIP: true, ReturnToPriority: true, Timestamp: time.Now()})
+	feed.Publish(notifier.FailoverEvent{OriginName: "www", ZoneName: "example.com", RecordType: "A", IsFailoverIP: true, NewIP: "198.51.100.1", Timestamp: time.Now()})
+
+	var data string
+	for lines.Scan() {
+		if value, ok := strings.CutPrefix(lines.Text(), "data: "); ok {
+			data = value
+			break
+		}
+	}
+	var event struct {
+		Kind   string   `json:"kind"`
+		Zone   string   `json:"zone"`
+		NewIPs []string `json:"new_ips"`
+	}
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatalf("Failed to decode %q: %v", data, err)
+	}
+	if event.Kind != notifier.KindFailover || event.Zone != "example.com" || len(event.NewIPs) != 1 {
+		t.Errorf("Unexpected event %+v", event)
+	}
+
+	// Shutting down ends the stream instead of waiting for it
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+}
+
+func TestServer_StreamErrors(t *testing.T) {
+	server := NewServer(staticSource{})
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, StreamPath, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("without a feed: expected 404, got %d", rec.Code)
+	}
+
+	server.SetEventFeed(gslb.NewEventFeed())
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, StreamPath+"?min_severity=loud", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid min_severity: expected 400, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,287 @@
+// Package syslog sends log lines to a local or remote syslog server in the
+// RFC 5424 format.
+//
+// Unlike the standard library's log/syslog, which writes the older BSD
+// format, every message carries a full timestamp with time zone, the host
+// name and the application name, so that collectors do not have to guess
+// them.
+package syslog
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnknownFacility is returned by ParseFacility for names it does not know.
+var ErrUnknownFacility = errors.New("unknown syslog facility")
+
+// Facility is the syslog facility of the messages.
+type Facility int
+
+// Facilities defined by RFC 5424.
+const (
+	FacilityKern Facility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+	FacilityLocal0 Facility = iota + 4
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+var facilities = map[string]Facility{
+	"kern":     FacilityKern,
+	"user":     FacilityUser,
+	"mail":     FacilityMail,
+	"daemon":   FacilityDaemon,
+	"auth":     FacilityAuth,
+	"syslog":   FacilitySyslog,
+	"lpr":      FacilityLPR,
+	"news":     FacilityNews,
+	"uucp":     FacilityUUCP,
+	"cron":     FacilityCron,
+	"authpriv": FacilityAuthPriv,
+	"ftp":      FacilityFTP,
+	"local0":   FacilityLocal0,
+	"local1":   FacilityLocal1,
+	"local2":   FacilityLocal2,
+	"local3":   FacilityLocal3,
+	"local4":   FacilityLocal4,
+	"local5":   FacilityLocal5,
+	"local6":   FacilityLocal6,
+	"local7":   FacilityLocal7,
+}
+
+// ParseFacility returns the facility with the given name, such as "daemon" or "local0".
+func ParseFacility(name string) (Facility, error) {
+	facility, ok := facilities[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownFacility, name)
+	}
+	return facility, nil
+}
+
+// Severity is the syslog severity of a message.
+type Severity int
+
+// Severities used for log lines.
+const (
+	SeverityError   Severity = 3
+	SeverityWarning Severity = 4
+	SeverityInfo    Severity = 6
+)
+
+// Networks a Writer can send over.
+const (
+	NetworkUDP = "udp"
+	NetworkTCP = "tcp"
+)
+
+// localSockets are the usual paths of the local syslog socket.
+var localSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// nilValue is the RFC 5424 value of an absent header field.
+const nilValue = "-"
+
+// Config configures a Writer.
+type Config struct {
+	// Network is NetworkUDP or NetworkTCP. Both are empty for the local syslog.
+	Network string
+	// Address is the host:port of a remote server, or empty for the local syslog.
+	Address string
+	// Facility of every message.
+	Facility Facility
+	// AppName identifies the application in every message.
+	AppName string
+	// Hostname is sent in every message (default: os.Hostname).
+	Hostname string
+}
+
+// Writer sends each write as one syslog message. It is safe for concurrent use.
+type Writer struct {
+	cfg Config
+	pid string
+	now func() time.Time
+
+	mu   sync.Mutex
+	conn net.Conn
+	// framed is set for stream connections, which need octet counting to
+	// separate messages (RFC 6587).
+	framed bool
+	// failing suppresses repeated reports while the server is unreachable.
+	failing bool
+}
+
+// Dial connects to the syslog server of cfg.
+func Dial(cfg Config) (*Writer, error) {
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+	w := &Writer{cfg: cfg, pid: strconv.Itoa(os.Getpid()), now: time.Now}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.connectLocked(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// connectLocked (re)connects to the server. w.mu must be held.
+func (w *Writer) connectLocked() error {
+	if w.cfg.Address != "" {
+		network := w.cfg.Network
+		if network == "" {
+			network = NetworkUDP
+		}
+		conn, err := net.DialTimeout(network, w.cfg.Address, 10*time.Second)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog at %s: %w", w.cfg.Address, err)
+		}
+		w.conn = conn
+		w.framed = network == NetworkTCP
+		return nil
+	}
+
+	for _, path := range localSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.Dial(network, path)
+			if err == nil {
+				w.conn = conn
+				w.framed = false
+				return nil
+			}
+		}
+	}
+	return errors.New("failed to connect to the local syslog: no socket found")
+}
+
+// Write sends p, one log line, as a message. A leading timestamp written by
+// the log package is removed, since the message header carries the time.
+func (w *Writer) Write(p []byte) (int, error) {
+	msg := stripLogTimestamp(strings.TrimRight(string(p), "\n"))
+	line := w.format(severityOf(msg), msg, w.now())
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.sendLocked(line)
+	if err != nil {
+		// The connection may have been closed by the server; retry once
+		if w.conn != nil {
+			w.conn.Close()
+			w.conn = nil
+		}
+		if err = w.connectLocked(); err == nil {
+			err = w.sendLocked(line)
+		}
+	}
+	if err != nil {
+		if !w.failing {
+			// Not logged through the log package, which writes to w
+			fmt.Fprintf(os.Stderr, "Failed to send log to syslog: %v\n", err)
+		}
+		w.failing = true
+		return 0, err
+	}
+	w.failing = false
+	return len(p), nil
+}
+
+func (w *Writer) sendLocked(line []byte) error {
+	if w.conn == nil {
+		return net.ErrClosed
+	}
+	if w.framed {
+		line = append([]byte(strconv.Itoa(len(line))+" "), line...)
+	}
+	_, err := w.conn.Write(line)
+	return err
+}
+
+// Close closes the connection.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// format encodes msg as an RFC 5424 message:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (w *Writer) format(severity Severity, msg string, now time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("<" + strconv.Itoa(int(w.cfg.Facility)*8+int(severity)) + ">1 ")
+	b.WriteString(now.Format("2006-01-02T15:04:05.000000Z07:00"))
+	b.WriteString(" " + headerField(w.cfg.Hostname, 255))
+	b.WriteString(" " + headerField(w.cfg.AppName, 48))
+	b.WriteString(" " + w.pid)
+	b.WriteString(" " + nilValue + " " + nilValue + " ")
+	b.WriteString(msg)
+	return []byte(b.String())
+}
+
+// headerField returns value as a header field of at most maxLen printable
+// ASCII characters, or the nil value if it is empty.
+func headerField(value string, maxLen int) string {
+	field := strings.Map(func(r rune) rune {
+		if r < '!' || r > '~' {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(field) > maxLen {
+		field = field[:maxLen]
+	}
+	if field == "" {
+		return nilValue
+	}
+	return field
+}
+
+// logTimestampLayout is the timestamp the log package writes with its default flags.
+const logTimestampLayout = "2006/01/02 15:04:05"
+
+func stripLogTimestamp(msg string) string {
+	if len(msg) <= len(logTimestampLayout) || msg[len(logTimestampLayout)] != ' ' {
+		return msg
+	}
+	if _, err := time.Parse(logTimestampLayout, msg[:len(logTimestampLayout)]); err != nil {
+		return msg
+	}
+	return msg[len(logTimestampLayout)+1:]
+}
+
+// severityOf guesses the severity of a log line from the conventions of
+// this project's messages.
+func severityOf(msg string) Severity {
+	switch {
+	case strings.HasPrefix(msg, "Warning"):
+		return SeverityWarning
+	case strings.HasPrefix(msg, "Failed"):
+		return SeverityError
+	default:
+		return SeverityInfo
+	}
+}
@@ -0,0 +1,118 @@
+package syslog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriter_UDP(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer server.Close()
+
+	w, err := Dial(Config{Network: NetworkUDP, Address: server.LocalAddr().String(), Facility: FacilityDaemon, AppName: "cloudflare-gslb", Hostname: "gslb-1"})
+	if err != nil {
+		t.Fatalf("Dial returned error: %v", err)
+	}
+	defer w.Close()
+	w.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 123456000, time.UTC) }
+
+	if _, err := w.Write([]byte("2024/05/01 12:00:00 Failed to update DNS records for www.example.com: boom\n")); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	buf := make([]byte, 2048)
+	_ = server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := server.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("failed to read message: %v", err)
+	}
+	want := "<27>1 2024-05-01T12:00:00.123456Z gslb-1 cloudflare-gslb " + w.pid + " - - Failed to update DNS records for www.example.com: boom"
+	if got := string(buf[:n]); got != want {
+		t.Errorf("unexpected message\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestWriter_TCPUsesOctetCounting(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		var messages []string
+		for range 2 {
+			length, err := reader.ReadString(' ')
+			if err != nil {
+				break
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(length))
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(reader, msg); err != nil {
+				break
+			}
+			messages = append(messages, string(msg))
+		}
+		received <- messages
+	}()
+
+	w, err := Dial(Config{Network: NetworkTCP, Address: listener.Addr().String(), Facility: FacilityLocal0, AppName: "gslb"})
+	if err != nil {
+		t.Fatalf("Dial returned error: %v", err)
+	}
+	defer w.Close()
+	for _, line := range []string{"Warning: something\n", "Started\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+	}
+
+	select {
+	case messages := <-received:
+		if len(messages) != 2 || !strings.HasPrefix(messages[0], "<132>1 ") || !strings.HasSuffix(messages[0], " Warning: something") ||
+			!strings.HasPrefix(messages[1], "<134>1 ") || !strings.HasSuffix(messages[1], " Started") {
+			t.Errorf("unexpected messages %q", messages)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for messages")
+	}
+}
+
+func TestParseFacility(t *testing.T) {
+	for name, want := range map[string]Facility{"daemon": 3, "LOCAL0": 16, "local7": 23, "authpriv": 10} {
+		got, err := ParseFacility(name)
+		if err != nil || got != want {
+			t.Errorf("ParseFacility(%q) = %d, %v; want %d", name, got, err, want)
+		}
+	}
+	if _, err := ParseFacility("local8"); !errors.Is(err, ErrUnknownFacility) {
+		t.Errorf("expected ErrUnknownFacility, got %v", err)
+	}
+}
+
+func TestHeaderField(t *testing.T) {
+	if got := headerField("", 48); got != "-" {
+		t.Errorf("expected the nil value for an empty field, got %q", got)
+	}
+	if got := headerField("my host", 48); got != "my_host" {
+		t.Errorf("expected spaces to be replaced, got %q", got)
+	}
+	if got := headerField(strings.Repeat("a", 60), 48); len(got) != 48 {
+		t.Errorf("expected the field to be truncated, got %d characters", len(got))
+	}
+}
@@ -0,0 +1,253 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/otlp"
+)
+
+const (
+	// exportInterval is how often queued spans are sent to the collector.
+	exportInterval = 5 * time.Second
+	// maxBatchSize is the number of queued spans that triggers an export before the interval.
+	maxBatchSize = 512
+	// maxQueueSize is the number of queued spans above which new spans are dropped.
+	maxQueueSize = 4096
+	// exportTimeout bounds a periodic export.
+	exportTimeout = 10 * time.Second
+)
+
+// ErrAlreadySetUp is returned when Setup is called while tracing is enabled.
+var ErrAlreadySetUp = errors.New("tracing is already set up")
+
+// Config configures the OTLP/HTTP exporter.
+type Config struct {
+	// Endpoint is the base URL of the collector; spans are posted to Endpoint + "/v1/traces".
+	Endpoint string
+	// ServiceName is exported as the service.name resource attribute.
+	ServiceName string
+	// Headers are added to every export request, e.g. for authentication.
+	Headers map[string]string
+}
+
+// Setup enables tracing and starts exporting spans in the background. The
+// returned function disables tracing and exports the spans still queued; it
+// should be called before the process exits.
+func Setup(cfg Config) (func(context.Context) error, error) {
+	e := newExporter(cfg)
+	t := &tracer{exporter: e, now: time.Now}
+	if !current.CompareAndSwap(nil, t) {
+		return nil, ErrAlreadySetUp
+	}
+	go e.run()
+
+	var once sync.Once
+	return func(ctx context.Context) error {
+		var err error
+		once.Do(func() {
+			current.CompareAndSwap(t, nil)
+			err = e.shutdown(ctx)
+		})
+		return err
+	}, nil
+}
+
+// exporter batches ended spans and posts them to the collector as OTLP JSON.
+type exporter struct {
+	client   *otlp.Client
+	resource otlp.Resource
+
+	mu      sync.Mutex
+	pending []*Span
+	dropped int
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newExporter(cfg Config) *exporter {
+	return &exporter{
+		client:   otlp.NewClient(cfg.Endpoint, cfg.Headers),
+		resource: otlp.NewResource(cfg.ServiceName),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Queued returns the number of ended spans waiting to be exported.
+func Queued() int {
+	t := current.Load()
+	if t == nil {
+		return 0
+	}
+	t.exporter.mu.Lock()
+	defer t.exporter.mu.Unlock()
+	return len(t.exporter.pending)
+}
+
+func (e *exporter) enqueue(span *Span) {
+	e.mu.Lock()
+	if len(e.pending) >= maxQueueSize {
+		e.dropped++
+		e.mu.Unlock()
+		return
+	}
+	e.pending = append(e.pending, span)
+	full := len(e.pending) >= maxBatchSize
+	e.mu.Unlock()
+
+	if full {
+		select {
+		case e.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (e *exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+		case <-e.wake:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		if err := e.flush(ctx); err != nil {
+			log.Printf("Failed to export trace spans: %v", err)
+		}
+		cancel()
+	}
+}
+
+func (e *exporter) shutdown(ctx context.Context) error {
+	close(e.stop)
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return e.flush(ctx)
+}
+
+// flush exports every queued span. Spans that fail to export are dropped.
+func (e *exporter) flush(ctx context.Context) error {
+	e.mu.Lock()
+	spans, dropped := e.pending, e.dropped
+	e.pending, e.dropped = nil, 0
+	e.mu.Unlock()
+
+	if dropped > 0 {
+		log.Printf("Dropped %d trace spans because the export queue was full", dropped)
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+	if err := e.client.Export(ctx, "traces", e.request(spans)); err != nil {
+		return fmt.Errorf("%d spans: %w", len(spans), err)
+	}
+	return nil
+}
+
+// The types below are the JSON encoding of an OTLP ExportTraceServiceRequest.
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlp.Resource    `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlp.Scope `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlp.KeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent     `json:"events,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string          `json:"timeUnixNano"`
+	Name         string          `json:"name"`
+	Attributes   []otlp.KeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func (e *exporter) request(spans []*Span) otlpRequest {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		encoded = append(encoded, encodeSpan(span))
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: e.resource,
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlp.Scope{Name: otlp.ScopeName},
+			Spans: encoded,
+		}},
+	}}}
+}
+
+func encodeSpan(s *Span) otlpSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(s.traceID[:]),
+		SpanID:            hex.EncodeToString(s.spanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: otlp.UnixNano(s.start),
+		EndTimeUnixNano:   otlp.UnixNano(s.end),
+		Attributes:        encodeAttributes(s.attributes),
+		Status:            otlpStatus{Code: s.statusCode, Message: s.statusMessage},
+	}
+	if s.parentID != ([8]byte{}) {
+		span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+	for _, ev := range s.events {
+		span.Events = append(span.Events, otlpEvent{
+			TimeUnixNano: otlp.UnixNano(ev.time),
+			Name:         ev.name,
+			Attributes:   encodeAttributes(ev.attributes),
+		})
+	}
+	return span
+}
+
+func encodeAttributes(attrs []Attribute) []otlp.KeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	encoded := make([]otlp.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		encoded = append(encoded, otlp.KeyValue{Key: attr.Key, Value: otlp.Value(attr.Value)})
+	}
+	return encoded
+}
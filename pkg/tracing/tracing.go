@@ -0,0 +1,212 @@
+// Package tracing records spans of the check, DNS change and notification
+// flows and exports them to an OpenTelemetry collector over OTLP/HTTP.
+//
+// Spans are only recorded after Setup. Until then, and after the returned
+// shutdown function has been called, Start returns a nil *Span whose methods
+// do nothing, so instrumented code does not need to check whether tracing is
+// enabled.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Kinds of span, as defined by OTLP.
+const (
+	KindInternal = 1
+	KindClient   = 3
+)
+
+// statusError is the OTLP status code of a failed span.
+const statusError = 2
+
+// Attribute is a key-value pair recorded on a span or an event. Values are
+// strings, bools, ints, int64s, float64s or string slices.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String returns a string attribute.
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int returns an integer attribute.
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Float64 returns a floating point attribute.
+func Float64(key string, value float64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Strings returns a string slice attribute.
+func Strings(key string, value []string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Milliseconds returns d as a floating point attribute in milliseconds.
+func Milliseconds(key string, d time.Duration) Attribute {
+	return Float64(key, float64(d)/float64(time.Millisecond))
+}
+
+// Span is a timed operation within a trace. A nil *Span is valid and ignores
+// every call.
+type Span struct {
+	tracer   *tracer
+	name     string
+	kind     int
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	start    time.Time
+
+	mu            sync.Mutex
+	end           time.Time
+	ended         bool
+	attributes    []Attribute
+	events        []event
+	statusCode    int
+	statusMessage string
+}
+
+type event struct {
+	name       string
+	time       time.Time
+	attributes []Attribute
+}
+
+// tracer creates spans and hands the ended ones to its exporter.
+type tracer struct {
+	exporter *exporter
+	now      func() time.Time
+}
+
+// current is the tracer installed by Setup, nil while tracing is disabled.
+var current atomic.Pointer[tracer]
+
+type spanKey struct{}
+
+// Start starts a span named name as a child of the span in ctx, or as the
+// root of a new trace if ctx has none. The returned context carries the new
+// span. The span must be ended with End.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return start(ctx, name, KindInternal, attrs)
+}
+
+// StartClient is like Start for a span describing a request to a remote service.
+func StartClient(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return start(ctx, name, KindClient, attrs)
+}
+
+func start(ctx context.Context, name string, kind int, attrs []Attribute) (context.Context, *Span) {
+	t := current.Load()
+	if t == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		tracer:     t,
+		name:       name,
+		kind:       kind,
+		start:      t.now(),
+		attributes: append([]Attribute(nil), attrs...),
+	}
+	if parent := FromContext(ctx); parent != nil {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	} else {
+		_, _ = rand.Read(span.traceID[:])
+	}
+	_, _ = rand.Read(span.spanID[:])
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// FromContext returns the span carried by ctx, or nil.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// TraceID returns the hex-encoded trace ID of the span, or "" for a nil span.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// SetAttributes adds attrs to the span, replacing attributes with the same key.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, attr := range attrs {
+		replaced := false
+		for i := range s.attributes {
+			if s.attributes[i].Key == attr.Key {
+				s.attributes[i] = attr
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			s.attributes = append(s.attributes, attr)
+		}
+	}
+}
+
+// AddEvent records a point in time within the span, such as a retry.
+func (s *Span) AddEvent(name string, attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event{name: name, time: s.tracer.now(), attributes: attrs})
+}
+
+// RecordError marks the span as failed with err. A nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event{
+		name:       "exception",
+		time:       s.tracer.now(),
+		attributes: []Attribute{String("exception.message", err.Error())},
+	})
+	s.statusCode = statusError
+	s.statusMessage = err.Error()
+}
+
+// End ends the span and queues it for export. Calls after the first are ignored.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = s.tracer.now()
+	s.mu.Unlock()
+	s.tracer.exporter.enqueue(s)
+}
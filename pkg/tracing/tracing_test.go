@@ -0,0 +1,202 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/bootjp/cloudflare-gslb/pkg/otlp"
+)
+
+// collector is a fake OTLP/HTTP endpoint that records every exported span.
+type collector struct {
+	mu      sync.Mutex
+	spans   []otlpSpan
+	headers http.Header
+	server  *httptest.Server
+}
+
+func newCollector(t *testing.T) *collector {
+	t.Helper()
+	c := &collector{}
+	c.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			http.NotFound(w, r)
+			return
+		}
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.headers = r.Header.Clone()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				c.spans = append(c.spans, ss.Spans...)
+			}
+		}
+	}))
+	t.Cleanup(c.server.Close)
+	return c
+}
+
+func (c *collector) byName() map[string]otlpSpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	spans := make(map[string]otlpSpan, len(c.spans))
+	for _, span := range c.spans {
+		spans[span.Name] = span
+	}
+	return spans
+}
+
+func setupCollector(t *testing.T) (*collector, func(context.Context) error) {
+	t.Helper()
+	c := newCollector(t)
+	shutdown, err := Setup(Config{
+		Endpoint:    c.server.URL + "/",
+		ServiceName: "gslb-test",
+		Headers:     map[string]string{"Authorization": "Bearer secret"},
+	})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	t.Cleanup(func() { _ = shutdown(context.Background()) })
+	return c, shutdown
+}
+
+func attribute(span otlpSpan, key string) (otlp.AnyValue, bool) {
+	for _, attr := range span.Attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return otlp.AnyValue{}, false
+}
+
+func TestStartWithoutSetupReturnsNoopSpan(t *testing.T) {
+	ctx := context.Background()
+	got, span := Start(ctx, "noop")
+	if span != nil {
+		t.Fatalf("Start() span = %v, want nil", span)
+	}
+	if got != ctx {
+		t.Error("Start() should return the context unchanged")
+	}
+	// Every method must be safe on a nil span
+	span.SetAttributes(String("key", "value"))
+	span.AddEvent("event")
+	span.RecordError(errors.New("failed"))
+	span.End()
+	if span.TraceID() != "" {
+		t.Errorf("TraceID() = %q, want empty", span.TraceID())
+	}
+}
+
+func TestSpansAreExportedWithParents(t *testing.T) {
+	c, shutdown := setupCollector(t)
+
+	ctx, root := Start(context.Background(), "check", String("origin", "www"))
+	_, child := StartClient(ctx, "HTTP GET", Int("attempt", 2))
+	child.SetAttributes(Bool("healthy", false), Float64("latency_ms", 1.5))
+	child.AddEvent("retry", Strings("ips", []string{"192.0.2.1"}))
+	child.RecordError(errors.New("timeout"))
+	child.End()
+	root.SetAttributes(String("origin", "api"))
+	root.End()
+	root.End()
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+	if _, span := Start(context.Background(), "after"); span != nil {
+		t.Error("Start() after shutdown should return a nil span")
+	}
+
+	spans := c.byName()
+	if len(c.spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(c.spans))
+	}
+	if got := c.headers.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization header = %q, want the configured header", got)
+	}
+
+	check, get := spans["check"], spans["HTTP GET"]
+	if check.TraceID != root.TraceID() || get.TraceID != check.TraceID {
+		t.Errorf("trace IDs = %s/%s, want both %s", check.TraceID, get.TraceID, root.TraceID())
+	}
+	if check.ParentSpanID != "" {
+		t.Errorf("root parentSpanId = %q, want empty", check.ParentSpanID)
+	}
+	if get.ParentSpanID != check.SpanID {
+		t.Errorf("child parentSpanId = %q, want %q", get.ParentSpanID, check.SpanID)
+	}
+	if check.Kind != KindInternal || get.Kind != KindClient {
+		t.Errorf("kinds = %d/%d, want %d/%d", check.Kind, get.Kind, KindInternal, KindClient)
+	}
+	if origin, _ := attribute(check, "origin"); origin.StringValue == nil || *origin.StringValue != "api" {
+		t.Errorf("origin attribute = %+v, want the replaced value api", origin)
+	}
+	if len(check.Attributes) != 1 {
+		t.Errorf("root has %d attributes, want 1", len(check.Attributes))
+	}
+	if attempt, _ := attribute(get, "attempt"); attempt.IntValue == nil || *attempt.IntValue != "2" {
+		t.Errorf("attempt attribute = %+v, want intValue 2", attempt)
+	}
+	if healthy, _ := attribute(get, "healthy"); healthy.BoolValue == nil || *healthy.BoolValue {
+		t.Errorf("healthy attribute = %+v, want false", healthy)
+	}
+	if get.Status.Code != statusError || get.Status.Message != "timeout" {
+		t.Errorf("status = %+v, want an error status", get.Status)
+	}
+	if len(get.Events) != 2 || get.Events[0].Name != "retry" || get.Events[1].Name != "exception" {
+		t.Fatalf("events = %+v, want retry and exception", get.Events)
+	}
+	if ips := get.Events[0].Attributes[0].Value.ArrayValue; ips == nil || len(ips.Values) != 1 {
+		t.Errorf("ips event attribute = %+v, want a one element array", ips)
+	}
+	if check.StartTimeUnixNano == "" || check.EndTimeUnixNano < check.StartTimeUnixNano {
+		t.Errorf("times = %s..%s, want an ordered range", check.StartTimeUnixNano, check.EndTimeUnixNano)
+	}
+}
+
+func TestSetupTwice(t *testing.T) {
+	setupCollector(t)
+	if _, err := Setup(Config{Endpoint: "http://localhost:4318"}); !errors.Is(err, ErrAlreadySetUp) {
+		t.Errorf("second Setup() error = %v, want ErrAlreadySetUp", err)
+	}
+}
+
+func TestFlushReportsCollectorErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	shutdown, err := Setup(Config{Endpoint: server.URL})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	_, span := Start(context.Background(), "check")
+	span.End()
+	if err := shutdown(context.Background()); err == nil {
+		t.Error("shutdown() should report the collector's error status")
+	}
+}
+
+func TestQueueDropsSpansWhenFull(t *testing.T) {
+	e := newExporter(Config{Endpoint: "http://localhost:4318"})
+	tr := &tracer{exporter: e}
+	for i := 0; i < maxQueueSize+3; i++ {
+		e.enqueue(&Span{tracer: tr})
+	}
+	if len(e.pending) != maxQueueSize || e.dropped != 3 {
+		t.Errorf("pending = %d, dropped = %d, want %d and 3", len(e.pending), e.dropped, maxQueueSize)
+	}
+}